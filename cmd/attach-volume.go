@@ -27,6 +27,9 @@ unik describe image
 
 If the specified mount point is occupied by another volume, the command will result
 in an error
+
+To attach a volume read-only, give it to 'unik run' as
+--vol VOLUME:MOUNT_POINT:ro instead (qemu and libvirt)
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
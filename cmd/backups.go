@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "List the backup schedules and backups of volumes",
+	Long: `Lists the backup schedules of a volume (made with unik schedule-backup), with
+when they run next, and the backups made for them, newest first. Without
+--volume, those of all volumes are listed. Backups that failed are listed with
+the error, up to the last 10 of each schedule.
+
+Example usage:
+	unik backups --volume myVolume
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if outputFormat != "" && outputFormat != "json" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json", nil)
+			}
+			backups, err := client.UnikClient(host).Volumes().Backups(volumeName)
+			if err != nil {
+				return err
+			}
+			if outputFormat == "json" {
+				data, err := json.Marshal(backups)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\n", string(data))
+				return nil
+			}
+			printBackupSchedules(backups.Schedules...)
+			fmt.Println()
+			printVolumeBackups(backups.Backups...)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed listing backups: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func printVolumeBackups(backups ...*types.VolumeBackup) {
+	fmt.Printf("%-15.15s %-25.25s %-10.10s %s\n", "VOLUME", "STARTED", "SIZE(MB)", "LOCATION")
+	for _, backup := range backups {
+		size, location := strconv.FormatInt(backup.SizeBytes>>20, 10), backup.Location
+		if backup.Error != "" {
+			size, location = "failed", backup.Error
+		}
+		fmt.Printf("%-15.15s %-25.25s %-10.10s %s\n", backup.VolumeName, backup.Started.Format(time.RFC3339), size, location)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(backupsCmd)
+	backupsCmd.Flags().StringVar(&volumeName, "volume", "", "<string,optional> name or id of the volume to list the backups of. lists those of all volumes if omitted")
+	backupsCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the schedules and backups in this format instead of tables. Available: json")
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var benchmarkDuration time.Duration
+
+var benchmarkInstanceCmd = &cobra.Command{
+	Use:   "benchmark INSTANCE",
+	Short: "Measure the network throughput and disk write speed of an instance",
+	Long: `Runs a quick check of the I/O of a running instance, and prints the result as json.
+
+The daemon starts an iperf3 server in the instance and runs an iperf3 client
+against it from the daemon host for --duration, then writes 100MiB to /tmp/test
+in the instance with dd (conv=fsync) and removes it. A test that fails does not
+keep the other from running; the errors are in the "Errors" of the result, and
+the command then exits with a non-zero status.
+
+The commands are run through the qemu guest agent, so only qemu instances whose
+unikernel runs a guest agent, and has iperf3, dd and rm, can be benchmarked. The
+daemon host needs iperf3 too, and must reach port 5201 of the instance: on its
+ip address, or on the host port forwarded to it (run --port HOST:5201).
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik instances benchmark myInstance --duration 30s
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give exactly one instance", nil)
+			}
+			if benchmarkDuration <= 0 {
+				return errors.New("--duration must be positive", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "instance": args[0], "duration": benchmarkDuration}).Info("benchmarking instance")
+			result, err := client.UnikClient(host).Instances().Benchmark(args[0], benchmarkDuration)
+			if err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", string(data))
+			if len(result.Errors) > 0 {
+				failed := []string{}
+				for test := range result.Errors {
+					failed = append(failed, test)
+				}
+				sort.Strings(failed)
+				return errors.New("benchmark failed: "+strings.Join(failed, ", "), nil)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed benchmarking instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	psCmd.AddCommand(benchmarkInstanceCmd)
+	benchmarkInstanceCmd.Flags().DurationVar(&benchmarkDuration, "duration", 10*time.Second, "<duration, optional> how long to run the network test for, e.g. 30s")
+}
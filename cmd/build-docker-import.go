@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+)
+
+var dockerImage, dockerImagePath string
+
+var buildDockerImportCmd = &cobra.Command{
+	Use:   "docker-import",
+	Short: "Build a unikernel image from the files of a docker image",
+	Long: `Builds a unikernel image as 'unik build' does, taking the sources from a docker
+(OCI) image instead of a local folder. The image is pulled with the docker cli of
+this machine unless it is already present, and the files of its layers, merged as
+a container of the image would see them, are the sources of the build.
+
+'--image-path' selects the folder of the image to build, e.g. the folder the
+application was copied to in its Dockerfile. It defaults to the WORKDIR of the
+image, or / if it has none. Only directories and regular files are taken from the
+image; symlinks and device files are left out.
+
+All the flags of 'unik build' but --path apply.
+
+Example usage:
+	unik build docker-import --docker-image myorg/myapp:1.2 --image-path /go/src/myapp --name myUnikernel --base rump --language go --provider qemu
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if name == "" {
+				return errors.New("--name must be set", nil)
+			}
+			if dockerImage == "" {
+				return errors.New("--docker-image must be set", nil)
+			}
+			imageConfig, err := pullDockerImage(dockerImage)
+			if err != nil {
+				return err
+			}
+			rootfs, err := exportDockerImage(dockerImage)
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(rootfs)
+			imagePath := dockerImagePath
+			if imagePath == "" {
+				imagePath = imageConfig.Config.WorkingDir
+			}
+			sourcePath = filepath.Join(rootfs, filepath.Clean("/"+imagePath))
+			if info, err := os.Stat(sourcePath); err != nil || !info.IsDir() {
+				return errors.New(imagePath+" is not a folder of docker image "+dockerImage, err)
+			}
+			logrus.WithFields(logrus.Fields{"docker-image": dockerImage, "image-path": imagePath, "path": sourcePath}).Info("building from docker image")
+			return buildImage()
+		}(); err != nil {
+			logrus.Errorf("build failed: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// dockerImageConfig is the part of docker image inspect unik uses
+type dockerImageConfig struct {
+	Os           string `json:"Os"`
+	Architecture string `json:"Architecture"`
+	Config       struct {
+		WorkingDir string   `json:"WorkingDir"`
+		Entrypoint []string `json:"Entrypoint"`
+		Cmd        []string `json:"Cmd"`
+		Env        []string `json:"Env"`
+	} `json:"Config"`
+}
+
+// pullDockerImage pulls image unless docker has it, and returns its config
+func pullDockerImage(image string) (*dockerImageConfig, error) {
+	if err := exec.Command("docker", "image", "inspect", image).Run(); err != nil {
+		logrus.WithField("docker-image", image).Info("pulling docker image")
+		if out, err := exec.Command("docker", "pull", image).CombinedOutput(); err != nil {
+			return nil, errors.New("pulling docker image "+image+": "+strings.TrimSpace(string(out)), err)
+		}
+	}
+	out, err := exec.Command("docker", "image", "inspect", image).Output()
+	if err != nil {
+		return nil, errors.New("inspecting docker image "+image, err)
+	}
+	var configs []dockerImageConfig
+	if err := json.Unmarshal(out, &configs); err != nil || len(configs) != 1 {
+		return nil, errors.New("parsing docker image inspect of "+image, err)
+	}
+	if configs[0].Config.WorkingDir == "" {
+		configs[0].Config.WorkingDir = "/"
+	}
+	return &configs[0], nil
+}
+
+// exportDockerImage extracts the merged files of the layers of image, which
+// docker must have, into a new tmp dir
+func exportDockerImage(image string) (string, error) {
+	// images without a CMD can only be exported from a container given a command,
+	// which is never run
+	out, err := exec.Command("docker", "create", image, "unik-docker-import").Output()
+	if err != nil {
+		return "", errors.New("creating container of docker image "+image, err)
+	}
+	containerId := strings.TrimSpace(string(out))
+	defer func() {
+		if err := exec.Command("docker", "rm", containerId).Run(); err != nil {
+			logrus.WithError(err).Warnf("failed to remove container %s", containerId)
+		}
+	}()
+
+	rootfs, err := ioutil.TempDir("", "docker.rootfs.")
+	if err != nil {
+		return "", errors.New("creating tmp dir for docker image", err)
+	}
+	export := exec.Command("docker", "export", containerId)
+	filesTar, err := export.StdoutPipe()
+	if err != nil {
+		os.RemoveAll(rootfs)
+		return "", errors.New("exporting docker image "+image, err)
+	}
+	if err := export.Start(); err != nil {
+		os.RemoveAll(rootfs)
+		return "", errors.New("exporting docker image "+image, err)
+	}
+	logrus.WithFields(logrus.Fields{"docker-image": image, "dir": rootfs}).Info("extracting files of docker image")
+	if err := unikos.ExtractTar(filesTar, rootfs); err != nil {
+		export.Process.Kill()
+		export.Wait()
+		os.RemoveAll(rootfs)
+		return "", errors.New("extracting files of docker image "+image, err)
+	}
+	if err := export.Wait(); err != nil {
+		os.RemoveAll(rootfs)
+		return "", errors.New("exporting docker image "+image, err)
+	}
+	return rootfs, nil
+}
+
+func init() {
+	buildCmd.AddCommand(buildDockerImportCmd)
+	buildDockerImportCmd.Flags().StringVar(&dockerImage, "docker-image", "", "<string,required> docker (OCI) image to take the sources from, e.g. myorg/myapp:1.2. pulled unless docker has it")
+	buildDockerImportCmd.Flags().StringVar(&dockerImagePath, "image-path", "", "<string,optional> folder of the docker image to build. defaults to the WORKDIR of the image, or /")
+}
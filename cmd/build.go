@@ -1,20 +1,29 @@
 package cmd
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/daemon"
 	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
-var name, sourcePath, base, lang, provider, runArgs string
-var mountPoints []string
-var force, noCleanup bool
+var name, sourcePath, base, lang, provider, runArgs, buildArch, buildNetworkMode, buildBaseImage, buildTarget, buildPlatform string
+var mountPoints, tags []string
+var force, noCleanup, squash, buildDryRun bool
+var buildMemory, buildVCPUs int
+var buildTimeout time.Duration
 
 var buildCmd = &cobra.Command{
 	Use:   "build",
@@ -31,6 +40,11 @@ In order to see a list of all supported base/language/provider combinations, run
 Images must be compiled for a specific provider, specified with the '--provider' flag
 To see a list of available providers, run 'unik providers'
 
+Instead of '--provider', the platform can be given with '--platform': qemu, kvm, xen,
+aws, ec2, gcp, gce or vbox, or an alias set in platform_aliases of the daemon config.
+'unik providers --platforms' lists the platforms of the daemon and their providers.
+Building with '--provider' for a provider that has a platform name is deprecated.
+
 A unikernel base that is compatible with the provider must be specified with the '--base' flag.
 A language runtime that is compatible with the base must be specified with the '--language' flag.
 To see a table of all compatible base-language-provider combinations, run 'unik compilers'
@@ -44,6 +58,36 @@ You can specify arguments as a single string passed to the '--args' flag
 
 Image names must be unique. If an image exists with the same name, you can force overwriting with the --force flag
 
+Images can be annotated with any number of tags, given as '--tag key=value'.
+Tags can be changed later with 'unik tag-image' and 'unik untag-image', and 'unik images --tag key=value'
+lists only the images with that tag.
+
+The '--arch' flag sets the cpu architecture the image is built for. It defaults to amd64
+and must match the architecture of the provider. Images of the same application built for
+different architectures can be tied together under one name with 'unik create-manifest'.
+
+'--memory' sets the memory (in MB) given to instances of the image that are run without
+'--instanceMemory'. Without it, they get the default of the compiler. In the same way,
+'--vcpus' sets the number of virtual cpus (1 to 256) of instances run without '--vcpus'.
+It is used on qemu and virtualbox.
+
+'--network-mode' sets how instances of the image are attached to the network: host,
+nat or bridge (see 'unik run --help'). Without it, instances get the default networking
+of the provider.
+
+With '--squash', the contents of the volumes are copied into the boot partition of the image
+instead of being attached at run time. Each mount point is then given with the local folder
+holding its contents, as '--mountpoint /data:./myApp/data', and instances of the image are
+run without '--vol'. Only images with base rump can be squashed.
+
+'--target' selects what compilers that build more than one kind of kernel make, e.g. the
+mirage target (xen|virtio|unix). It defaults to the target the provider boots.
+
+'--timeout' bounds how long the compiler may run, e.g. '--timeout 15m'. When it
+expires, the daemon kills the compiler containers, removes the sources and build
+artifacts (unless '--no-cleanup' is given) and fails the build with the time it
+ran for. Without it, builds do not time out.
+
 Example usage:
 	unik build --name myUnikernel --path ./myApp/src --base rump --language go --provider aws --mountpoint /foo --mountpoint /bar --args 'arg1 arg2 arg3' --force
 
@@ -65,47 +109,7 @@ Another example (using only the required parameters):
 			if sourcePath == "" {
 				return errors.New("--path must be set", nil)
 			}
-			if base == "" {
-				return errors.New("--base must be set", nil)
-			}
-			if lang == "" {
-				return errors.New("--language must be set", nil)
-			}
-			if provider == "" {
-				return errors.New("--provider must be set", nil)
-			}
-			if err := readClientConfig(); err != nil {
-				return err
-			}
-			if host == "" {
-				host = clientConfig.Host
-			}
-			logrus.WithFields(logrus.Fields{
-				"name":        name,
-				"path":        sourcePath,
-				"base":        base,
-				"language":    lang,
-				"provider":    provider,
-				"args":        runArgs,
-				"mountPoints": mountPoints,
-				"force":       force,
-				"host":        host,
-			}).Infof("running unik build")
-			sourceTar, err := ioutil.TempFile("", "sources.tar.gz.")
-			if err != nil {
-				logrus.WithError(err).Error("failed to create tmp tar file")
-			}
-			defer os.Remove(sourceTar.Name())
-			if err := unikos.Compress(sourcePath, sourceTar.Name()); err != nil {
-				return errors.New("failed to tar sources", err)
-			}
-			logrus.Infof("App packaged as tarball: %s\n", sourceTar.Name())
-			image, err := client.UnikClient(host).Images().Build(name, sourceTar.Name(), base, lang, provider, runArgs, mountPoints, force, noCleanup)
-			if err != nil {
-				return errors.New("building image failed", err)
-			}
-			printImages(image)
-			return nil
+			return buildImage()
 		}(); err != nil {
 			logrus.Errorf("build failed: %v", err)
 			os.Exit(-1)
@@ -113,15 +117,304 @@ Another example (using only the required parameters):
 	},
 }
 
+// buildImage builds the sources in sourcePath with the flags of build. --name must be set
+func buildImage() error {
+	if base == "" {
+		return errors.New("--base must be set", nil)
+	}
+	if lang == "" {
+		return errors.New("--language must be set", nil)
+	}
+	if provider == "" && buildPlatform == "" {
+		return errors.New("--provider or --platform must be set", nil)
+	}
+	if provider != "" && buildPlatform != "" {
+		return errors.New("only one of --provider and --platform can be set", nil)
+	}
+	if buildMemory < 0 {
+		return errors.New("--memory must not be negative", nil)
+	}
+	if buildVCPUs != 0 {
+		if err := types.ValidateVCPUs(buildVCPUs); err != nil {
+			return err
+		}
+	}
+	networkMode, err := types.ParseNetworkMode(buildNetworkMode)
+	if err != nil {
+		return err
+	}
+	if err := readClientConfig(); err != nil {
+		return err
+	}
+	if host == "" {
+		host = clientConfig.Host
+	}
+	if err := resolveBuildProvider(); err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{
+		"name":         name,
+		"path":         sourcePath,
+		"base":         base,
+		"language":     lang,
+		"provider":     provider,
+		"args":         runArgs,
+		"mountPoints":  mountPoints,
+		"force":        force,
+		"squash":       squash,
+		"memory":       buildMemory,
+		"vcpus":        buildVCPUs,
+		"network-mode": networkMode,
+		"arch":         buildArch,
+		"base-image":   buildBaseImage,
+		"target":       buildTarget,
+		"timeout":      buildTimeout,
+		"host":         host,
+	}).Infof("running unik build")
+	imageTags, err := types.ParseTags(tags)
+	if err != nil {
+		return err
+	}
+	arch, err := types.ParseArchitecture(buildArch)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(sourcePath); err != nil || !info.IsDir() {
+		return errors.New("--path "+sourcePath+" is not a directory", err)
+	}
+	if buildDryRun {
+		return planBuild(imageTags, arch, networkMode)
+	}
+	packagedPath := sourcePath
+	buildMountPoints := mountPoints
+	if squash {
+		squashedPath, squashedMountPoints, err := stageSquashedVolumes(sourcePath, mountPoints)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(squashedPath)
+		packagedPath = squashedPath
+		buildMountPoints = squashedMountPoints
+	}
+	sourceTar, err := ioutil.TempFile("", "sources.tar.gz.")
+	if err != nil {
+		logrus.WithError(err).Error("failed to create tmp tar file")
+	}
+	defer os.Remove(sourceTar.Name())
+	if err := unikos.Compress(packagedPath, sourceTar.Name()); err != nil {
+		return errors.New("failed to tar sources", err)
+	}
+	logrus.Infof("App packaged as tarball: %s\n", sourceTar.Name())
+	image, err := client.UnikClient(host).Images().Build(name, sourceTar.Name(), client.BuildOptions{
+		Base:        base,
+		Lang:        lang,
+		Provider:    provider,
+		Args:        runArgs,
+		Mounts:      buildMountPoints,
+		Force:       force,
+		NoCleanup:   noCleanup,
+		Tags:        imageTags,
+		Arch:        arch,
+		Squash:      squash,
+		MemoryMb:    buildMemory,
+		VCPUs:       buildVCPUs,
+		NetworkMode: networkMode,
+		BaseImage:   buildBaseImage,
+		Target:      buildTarget,
+		Timeout:     buildTimeout,
+	})
+	if err != nil {
+		return errors.New("building image failed", err)
+	}
+	printImages(image)
+	if noCleanup {
+		fmt.Println("--no-cleanup: the sources and build artifacts were kept on the daemon host in:")
+		for _, path := range image.KeptArtifacts {
+			fmt.Println("  " + path)
+		}
+	}
+	return nil
+}
+
+// resolveBuildProvider sets provider to the one --platform builds for. builds
+// given a --provider that has a platform alias are told to use the alias
+func resolveBuildProvider() error {
+	if buildPlatform != "" {
+		resolved, err := client.UnikClient(host).ResolvePlatform(buildPlatform)
+		if err != nil {
+			return errors.New("resolving --platform "+buildPlatform, err)
+		}
+		logrus.WithFields(logrus.Fields{"platform": buildPlatform, "provider": resolved}).Info("building for platform")
+		provider = resolved
+		return nil
+	}
+	platforms, err := client.UnikClient(host).Platforms()
+	if err != nil {
+		logrus.WithError(err).Debug("could not list the platform aliases of the daemon")
+		return nil
+	}
+	if _, ok := platforms[provider]; ok {
+		return nil
+	}
+	aliases := []string{}
+	for platform, providerName := range platforms {
+		if providerName == provider {
+			aliases = append(aliases, platform)
+		}
+	}
+	if len(aliases) > 0 {
+		sort.Strings(aliases)
+		logrus.Warnf("DEPRECATED: building with --provider %s, use --platform %s instead", provider, strings.Join(aliases, " or --platform "))
+	}
+	return nil
+}
+
 func init() {
 	RootCmd.AddCommand(buildCmd)
-	buildCmd.Flags().StringVar(&name, "name", "", "<string,required> name to give the unikernel. must be unique")
+	// shared with the subcommands of build, which take their sources from elsewhere than --path
+	buildCmd.PersistentFlags().StringVar(&name, "name", "", "<string,required> name to give the unikernel. must be unique")
 	buildCmd.Flags().StringVar(&sourcePath, "path", "", "<string,required> path to root application sources folder")
-	buildCmd.Flags().StringVar(&base, "base", "", "<string,required> name of the unikernel base to use")
-	buildCmd.Flags().StringVar(&lang, "language", "", "<string,required> language the unikernel source is written in")
-	buildCmd.Flags().StringVar(&provider, "provider", "", "<string,required> name of the target infrastructure to compile for")
-	buildCmd.Flags().StringVar(&runArgs, "args", "", "<string,optional> to be passed to the unikernel at runtime")
-	buildCmd.Flags().StringSliceVar(&mountPoints, "mountpoint", []string{}, "<string,repeated> specify up to 8 mount points for volumes")
-	buildCmd.Flags().BoolVar(&force, "force", false, "<bool, optional> force overwriting a previously existing")
-	buildCmd.Flags().BoolVar(&noCleanup, "no-cleanup", false, "<bool, optional> for debugging; do not clean up artifacts for images that fail to build")
+	buildCmd.PersistentFlags().StringVar(&base, "base", "", "<string,required> name of the unikernel base to use")
+	buildCmd.PersistentFlags().StringVar(&lang, "language", "", "<string,required> language the unikernel source is written in")
+	buildCmd.PersistentFlags().StringVar(&provider, "provider", "", "<string,required> name of the target infrastructure to compile for. deprecated where the infrastructure has a --platform name")
+	buildCmd.PersistentFlags().StringVar(&buildPlatform, "platform", "", "<string,optional> platform to compile for instead of --provider: qemu, kvm, xen, aws, ec2, gcp, gce, vbox, or an alias in the platform_aliases of the daemon config")
+	buildCmd.PersistentFlags().StringVar(&runArgs, "args", "", "<string,optional> to be passed to the unikernel at runtime")
+	buildCmd.PersistentFlags().StringSliceVar(&mountPoints, "mountpoint", []string{}, "<string,repeated> specify up to 8 mount points for volumes")
+	buildCmd.PersistentFlags().BoolVar(&force, "force", false, "<bool, optional> force overwriting a previously existing")
+	buildCmd.PersistentFlags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> annotate the image with a tag, given as key=value")
+	buildCmd.PersistentFlags().BoolVar(&squash, "squash", false, "<bool, optional> copy the contents of the volumes into the boot partition instead of attaching them at run time. mount points must then be given as MOUNT_POINT:LOCAL_FOLDER")
+	buildCmd.PersistentFlags().IntVar(&buildMemory, "memory", 0, "<int,optional> memory (in MB) to give instances of the image that are run without --instanceMemory. defaults to the compiler's default")
+	buildCmd.PersistentFlags().IntVar(&buildVCPUs, "vcpus", 0, "<int,optional> number of virtual cpus (1-256) to give instances of the image that are run without --vcpus. defaults to 1")
+	buildCmd.PersistentFlags().StringVar(&buildNetworkMode, "network-mode", "", "<string,optional> network mode of instances of the image that are run without --network-mode: host|nat|bridge. defaults to the networking of the provider")
+	buildCmd.PersistentFlags().StringVar(&buildArch, "arch", "", "<string,optional> cpu architecture to build the image for (amd64|arm64). defaults to amd64")
+	buildCmd.PersistentFlags().StringVar(&buildBaseImage, "base-image", "", "<string,optional> image to build on: its files are copied under the sources, and its mount points, memory, vcpus and network mode are the defaults of the new image. must be an image of --provider")
+	buildCmd.PersistentFlags().StringVar(&buildTarget, "target", "", "<string,optional> target of compilers that build for several, e.g. the mirage target (xen|virtio|unix). defaults to the target the provider boots")
+	buildCmd.PersistentFlags().BoolVar(&buildDryRun, "dry-run", false, "<bool, optional> check the sources, compiler, provider, squashed volume folders and base image, and print what would be built and its estimated size, without uploading the sources or building")
+	buildCmd.PersistentFlags().DurationVar(&buildTimeout, "timeout", 0, "<duration, optional> kill the compiler and fail the build if it takes longer than this, e.g. 15m. builds do not time out without it")
+	buildCmd.PersistentFlags().BoolVar(&noCleanup, "no-cleanup", false, "<bool, optional> for debugging; keep the uploaded sources and intermediate build artifacts on the daemon host and print where they are")
+}
+
+// stageSquashedVolumes copies the sources and the folder given for each mount point
+// (as MOUNT_POINT:LOCAL_FOLDER) into a tmp dir to be uploaded, with the folders
+// under daemon.SquashDir. It returns the tmp dir and the bare mount points.
+func stageSquashedVolumes(sourcePath string, mountPoints []string) (string, []string, error) {
+	if len(mountPoints) == 0 {
+		return "", nil, errors.New("--squash requires at least one --mountpoint", nil)
+	}
+	stagingDir, err := ioutil.TempDir("", "squashed.sources.")
+	if err != nil {
+		return "", nil, errors.New("creating tmp dir for squashed sources", err)
+	}
+	if err := unikos.CopyDir(sourcePath, stagingDir); err != nil {
+		os.RemoveAll(stagingDir)
+		return "", nil, errors.New("copying sources", err)
+	}
+	bareMountPoints := []string{}
+	for _, mountPoint := range mountPoints {
+		mntPoint, folder, err := parseSquashedMountPoint(mountPoint)
+		if err != nil {
+			os.RemoveAll(stagingDir)
+			return "", nil, err
+		}
+		logrus.WithFields(logrus.Fields{"mountPoint": mntPoint, "folder": folder}).Info("squashing volume into image")
+		if err := unikos.CopyDir(folder, filepath.Join(stagingDir, daemon.SquashDir, mntPoint)); err != nil {
+			os.RemoveAll(stagingDir)
+			return "", nil, errors.New("copying "+folder+" for mount point "+mntPoint, err)
+		}
+		bareMountPoints = append(bareMountPoints, mntPoint)
+	}
+	return stagingDir, bareMountPoints, nil
+}
+
+func parseSquashedMountPoint(mountPoint string) (string, string, error) {
+	pair := strings.SplitN(mountPoint, ":", 2)
+	if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+		return "", "", errors.New("with --squash, --mountpoint must be given as MOUNT_POINT:LOCAL_FOLDER, not "+mountPoint, nil)
+	}
+	return pair[0], pair[1], nil
+}
+
+// planBuild has the daemon validate the build without uploading the sources,
+// and prints what it would build
+func planBuild(imageTags map[string]string, arch types.Architecture, networkMode types.NetworkMode) error {
+	sourcesSize, err := unikos.GetDirSize(sourcePath)
+	if err != nil {
+		return errors.New("computing size of sources in "+sourcePath, err)
+	}
+	planMountPoints := mountPoints
+	if squash {
+		if len(mountPoints) == 0 {
+			return errors.New("--squash requires at least one --mountpoint", nil)
+		}
+		planMountPoints = []string{}
+		for _, mountPoint := range mountPoints {
+			mntPoint, folder, err := parseSquashedMountPoint(mountPoint)
+			if err != nil {
+				return err
+			}
+			if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+				return errors.New("folder "+folder+" for mount point "+mntPoint+" is not a directory", err)
+			}
+			folderSize, err := unikos.GetDirSize(folder)
+			if err != nil {
+				return errors.New("computing size of "+folder, err)
+			}
+			sourcesSize += folderSize
+			planMountPoints = append(planMountPoints, mntPoint)
+		}
+	}
+	plan, err := client.UnikClient(host).Images().BuildPlan(name, client.BuildOptions{
+		Base:        base,
+		Lang:        lang,
+		Provider:    provider,
+		Args:        runArgs,
+		Mounts:      planMountPoints,
+		Force:       force,
+		Tags:        imageTags,
+		Arch:        arch,
+		Squash:      squash,
+		MemoryMb:    buildMemory,
+		VCPUs:       buildVCPUs,
+		NetworkMode: networkMode,
+		BaseImage:   buildBaseImage,
+		Target:      buildTarget,
+	}, sourcesSize)
+	if err != nil {
+		return errors.New("validating build failed", err)
+	}
+	fmt.Printf("dry run: image %s would be built with compiler %s for provider %s (%s)\n", plan.Name, plan.Compiler, plan.Provider, plan.Architecture)
+	if plan.ReplacesImage {
+		fmt.Printf("the existing image %s would be deleted (--force)\n", plan.Name)
+	}
+	if plan.Args != "" {
+		fmt.Printf("args:             %s\n", plan.Args)
+	}
+	if len(plan.MountPoints) > 0 {
+		if plan.Squash {
+			fmt.Printf("squashed volumes: %s\n", strings.Join(plan.MountPoints, ","))
+		} else {
+			fmt.Printf("mount points:     %s\n", strings.Join(plan.MountPoints, ","))
+		}
+	}
+	if len(plan.Tags) > 0 {
+		fmt.Printf("tags:             %s\n", formatTags(plan.Tags))
+	}
+	if plan.BaseImage != "" {
+		fmt.Printf("base image:       %s\n", plan.BaseImage)
+	}
+	if plan.Target != "" {
+		fmt.Printf("target:           %s\n", plan.Target)
+	}
+	if plan.MemoryMB != 0 {
+		fmt.Printf("memory:           %v MB\n", plan.MemoryMB)
+	}
+	if plan.VCPUs != 0 {
+		fmt.Printf("vcpus:            %v\n", plan.VCPUs)
+	}
+	if plan.NetworkMode != "" {
+		fmt.Printf("network mode:     %s\n", plan.NetworkMode)
+	}
+	fmt.Printf("sources:          %v MB\n", plan.SourcesSizeMB)
+	fmt.Printf("estimated size:   %v MB, plus the compiled kernel\n", plan.EstimatedSizeMB)
+	return nil
 }
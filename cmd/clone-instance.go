@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var cloneName string
+
+var cloneInstanceCmd = &cobra.Command{
+	Use:   "clone-instance",
+	Short: "Run a copy of an existing instance",
+	Long: `Runs a new instance from the same image as an existing instance, with the
+same restart policy and the same mount points.
+
+The clone never shares volumes with the source instance. Every volume mounted
+on the source is copied, and the copy is mounted on the clone at the same mount
+point. Copies are named <clone-name>-<volume-name>. Where the provider stores
+volumes on a filesystem with copy-on-write support (e.g. qemu volumes on btrfs
+or xfs), the copy is a cheap copy-on-write snapshot; otherwise the volume data
+is copied in full, which may take a while for large volumes. Providers that
+cannot copy volumes at all can only clone instances without volumes.
+
+Environment variables and memory settings of the source are not copied;
+the clone gets the image defaults.
+
+You may specify the source instance by name or id.
+
+Example usage:
+	unik clone-instance --instance myInstance --name myInstance-2
+
+	# will run myInstance-2 from the image of myInstance
+	# with a copy of each volume mounted on myInstance
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			if cloneName == "" {
+				return errors.New("must specify --name", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName, "name": cloneName, "provider": provider}).Info("cloning instance")
+			instance, err := client.UnikClient(host).Instances().Clone(instanceName, cloneName, provider)
+			if err != nil {
+				return errors.New("cloning instance failed", err)
+			}
+			printInstances(instance)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed cloning instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(cloneInstanceCmd)
+	cloneInstanceCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of the instance to clone. unik accepts a prefix of the name or id")
+	cloneInstanceCmd.Flags().StringVar(&cloneName, "name", "", "<string,required> name to give the new instance. must be unique")
+	cloneInstanceCmd.Flags().StringVar(&provider, "provider", "", "<string,optional> run the clone on this provider instead of the source's. requires an image of the same name there, and is not supported for instances with volumes")
+}
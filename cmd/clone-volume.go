@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var cloneVolumeCmd = &cobra.Command{
+	Use:   "clone-volume",
+	Short: "Copy a volume to a new volume",
+	Long: `Creates a new volume with the data, filesystem, size and tags of an existing
+volume. The copy is ready to be attached to instances; it does not need to be
+formatted.
+
+On qemu and libvirt the files of the volume are copied (as a copy-on-write
+snapshot on btrfs or xfs), and on aws the ebs volume is snapshotted and the new
+volume created from the snapshot. Volumes of other providers stored on the
+daemon host (ukvm, virtualbox and xen) are exported to a raw image, which the
+new volume is created from.
+
+With --provider, the copy is created on another provider, from a raw image of
+the volume, like 'unik migrate-volume' does. The volume must then be stored on
+the daemon host.
+
+The volume must not be attached to an instance. You may specify it by name or id.
+
+Example usage:
+	unik clone-volume --volume myVolume --name myVolume-copy
+
+	# will create myVolume-copy with the data of myVolume, on its provider
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if volumeName == "" {
+				return errors.New("must specify --volume", nil)
+			}
+			if newVolumeName == "" {
+				return errors.New("must specify --name", nil)
+			}
+			if outputFormat != "" && outputFormat != "json" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "volume": volumeName, "name": newVolumeName, "provider": provider}).Info("cloning volume")
+			volume, err := client.UnikClient(host).Volumes().Clone(volumeName, newVolumeName, provider)
+			if err != nil {
+				return errors.New("cloning volume failed", err)
+			}
+			if outputFormat == "json" {
+				data, err := json.Marshal(volume)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\n", string(data))
+				return nil
+			}
+			printVolumes(volume)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed cloning volume: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(cloneVolumeCmd)
+	cloneVolumeCmd.Flags().StringVar(&volumeName, "volume", "", "<string,required> name or id of volume to copy. unik accepts a prefix of the name or id")
+	cloneVolumeCmd.Flags().StringVar(&newVolumeName, "name", "", "<string,required> name of the new volume")
+	cloneVolumeCmd.Flags().StringVar(&provider, "provider", "", "<string,optional> create the copy on this provider instead of the volume's")
+	cloneVolumeCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the new volume in this format instead of a table. Available: json")
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+// bashCompletionFunction completes the values of flags that name an instance,
+// image or volume with the names the daemon knows, by running 'unik __complete-names'.
+// the generated completion calls __custom_func when it has nothing else to offer.
+const bashCompletionFunction = `
+__unik_complete_names()
+{
+    local names
+    names=$(unik __complete-names "$1" 2>/dev/null)
+    COMPREPLY=( $(compgen -W "${names}" -- "$cur") )
+}
+
+__custom_func()
+{
+    case ${prev} in
+        --instance)
+            __unik_complete_names instances
+            ;;
+        --image|--imageName)
+            __unik_complete_names images
+            ;;
+        --volume)
+            __unik_complete_names volumes
+            ;;
+    esac
+}
+`
+
+var completionCmd = &cobra.Command{
+	Use:   "completion SHELL",
+	Short: "Print a shell completion script",
+	Long: `Prints a script that completes unik commands and flags in your shell.
+The names of instances, images and volumes are completed for --instance,
+--image, --imageName and --volume by asking the daemon (see 'unik target').
+
+Only bash is supported.
+
+Example usage:
+	# load completion in the current shell
+	source <(unik completion bash)
+
+	# load completion in every new shell
+	unik completion bash > /etc/bash_completion.d/unik
+`,
+	ValidArgs: []string{"bash"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give the shell to complete. Available: bash", nil)
+			}
+			if args[0] != "bash" {
+				return errors.New("completion for "+args[0]+" is not supported. Available: bash", nil)
+			}
+			out := &bytes.Buffer{}
+			RootCmd.GenBashCompletion(out)
+			_, err := os.Stdout.Write(out.Bytes())
+			return err
+		}(); err != nil {
+			logrus.Errorf("failed generating completion: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// completeNamesCmd prints the names of the instances, images or volumes the daemon
+// knows, one per line, for the completion script. it fails quietly and without
+// retrying so that completion doesn't hang when the daemon can't be reached.
+var completeNamesCmd = &cobra.Command{
+	Use:    "__complete-names instances|images|volumes",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			os.Exit(-1)
+		}
+		logrus.SetOutput(&bytes.Buffer{})
+		if err := readClientConfig(); err != nil {
+			os.Exit(-1)
+		}
+		if host == "" {
+			host = clientConfig.Host
+		}
+		client.SetRetryTransport(nil)
+		names := []string{}
+		switch args[0] {
+		case "instances":
+			instances, err := client.UnikClient(host).Instances().All()
+			if err != nil {
+				os.Exit(-1)
+			}
+			for _, instance := range instances {
+				names = append(names, instance.Name)
+			}
+		case "images":
+			images, err := client.UnikClient(host).Images().All()
+			if err != nil {
+				os.Exit(-1)
+			}
+			for _, image := range images {
+				names = append(names, image.Name)
+			}
+		case "volumes":
+			volumes, err := client.UnikClient(host).Volumes().All()
+			if err != nil {
+				os.Exit(-1)
+			}
+			for _, volume := range volumes {
+				names = append(names, volume.Name)
+			}
+		default:
+			os.Exit(-1)
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(completionCmd)
+	RootCmd.AddCommand(completeNamesCmd)
+	RootCmd.BashCompletionFunction = bashCompletionFunction
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+)
+
+var nonInteractive bool
+var initTLSCert, initTLSKey, initTLSCA, initToken string
+
+// initSetting is a setting 'unik config init' asks for. flag is the flag that
+// gives it, and env the environment variable used in --non-interactive mode
+type initSetting struct {
+	key   string
+	flag  string
+	env   string
+	value *string
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the client config file",
+	Long: `Asks for the settings of the client config file, checks that the daemon can be
+reached with them (GET /healthz), and writes the file. Press enter to keep the
+value in brackets; enter - to clear it.
+
+With --non-interactive, nothing is asked: the settings are taken from the flags,
+or else from the environment variables UNIK_HOST, UNIK_TLS_CERT, UNIK_TLS_KEY,
+UNIK_TLS_CA and UNIK_TOKEN, and the command fails if the daemon cannot be reached.
+
+Example usage:
+	unik config init
+	UNIK_TOKEN=$TOKEN unik config init --non-interactive --host https://unik.example.com:3000
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			clientConfig, err := loadClientConfigFile()
+			if err != nil {
+				return err
+			}
+			settings := []initSetting{
+				{key: "host", flag: "host", env: "UNIK_HOST", value: &clientConfig.Host},
+				{key: "tls_cert", flag: "tls-cert", env: "UNIK_TLS_CERT", value: &clientConfig.TLSCert},
+				{key: "tls_key", flag: "tls-key", env: "UNIK_TLS_KEY", value: &clientConfig.TLSKey},
+				{key: "tls_ca", flag: "tls-ca", env: "UNIK_TLS_CA", value: &clientConfig.TLSCA},
+				{key: "token", flag: "token", env: "UNIK_TOKEN", value: &clientConfig.Token},
+			}
+			flagValues := map[string]string{"host": host, "tls-cert": initTLSCert, "tls-key": initTLSKey, "tls-ca": initTLSCA, "token": initToken}
+			for _, setting := range settings {
+				if cmd.Flags().Changed(setting.flag) {
+					*setting.value = flagValues[setting.flag]
+				} else if nonInteractive && os.Getenv(setting.env) != "" {
+					*setting.value = os.Getenv(setting.env)
+				}
+			}
+			stdin := bufio.NewReader(os.Stdin)
+			if !nonInteractive {
+				for _, setting := range settings {
+					if err := prompt(stdin, setting.key, setting.value, setting.key == "token"); err != nil {
+						return err
+					}
+				}
+			}
+			if clientConfig.Host == "" {
+				return errors.New("must give the host of the daemon", nil)
+			}
+			usesTLS := clientConfig.TLSCert != "" || clientConfig.TLSKey != "" || clientConfig.TLSCA != ""
+			if usesTLS && !strings.Contains(clientConfig.Host, "://") {
+				clientConfig.Host = "https://" + clientConfig.Host
+			}
+
+			if err := checkClientConfig(clientConfig); err != nil {
+				if nonInteractive {
+					return err
+				}
+				logrus.Warnf("%v", err)
+				if !confirm(stdin, "write the config anyway?") {
+					return errors.New("config not written", nil)
+				}
+			}
+			if err := writeClientConfig(clientConfig); err != nil {
+				return err
+			}
+			fmt.Printf("wrote client config to %s\n", clientConfigFile)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed creating config: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// prompt asks for a setting, keeping value if the answer is empty. secret values
+// are not shown
+func prompt(stdin *bufio.Reader, key string, value *string, secret bool) error {
+	current := *value
+	if secret && current != "" {
+		current = "(set)"
+	}
+	fmt.Printf("%s [%s]: ", key, current)
+	answer, err := stdin.ReadString('\n')
+	if err != nil {
+		return errors.New("reading "+key, err)
+	}
+	switch answer = strings.TrimSpace(answer); answer {
+	case "":
+	case "-":
+		*value = ""
+	default:
+		*value = answer
+	}
+	return nil
+}
+
+func confirm(stdin *bufio.Reader, question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := stdin.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// checkClientConfig checks that the daemon is healthy when asked with clientConfig
+func checkClientConfig(clientConfig config.ClientConfig) error {
+	client.SetRetryTransport(nil)
+	client.SetToken(clientConfig.Token)
+	if err := client.SetTLS(clientConfig.TLSCert, clientConfig.TLSKey, clientConfig.TLSCA); err != nil {
+		return err
+	}
+	health, err := client.UnikClient(clientConfig.Host).Health(false)
+	if err != nil {
+		return errors.New("could not reach the daemon at "+clientConfig.Host, err)
+	}
+	if health.Status != "ok" {
+		return errors.New(fmt.Sprintf("the daemon at %s is %s: %v", clientConfig.Host, health.Status, health.Errors), nil)
+	}
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configInitCmd)
+	configInitCmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "<bool,optional> take the settings from flags and UNIK_* environment variables instead of asking")
+	configInitCmd.Flags().StringVar(&initTLSCert, "tls-cert", "", "<string,optional> client certificate to present to https:// hosts")
+	configInitCmd.Flags().StringVar(&initTLSKey, "tls-key", "", "<string,optional> private key of --tls-cert")
+	configInitCmd.Flags().StringVar(&initTLSCA, "tls-ca", "", "<string,optional> ca to verify the certificate of the daemon with")
+	configInitCmd.Flags().StringVar(&initToken, "token", "", "<string,optional> bearer token to send with every request")
+}
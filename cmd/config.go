@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+)
+
+// clientConfigKey is a setting of the client config file that can be read and
+// written with 'unik config'. name is the key in the yaml file.
+type clientConfigKey struct {
+	name        string
+	description string
+	get         func(c *config.ClientConfig) string
+	set         func(c *config.ClientConfig, value string) error
+	// secret values are not printed by 'unik config show'
+	secret bool
+}
+
+var clientConfigKeys = []clientConfigKey{
+	{
+		name:        "host",
+		description: "host:port of the unik daemon. also set by 'unik target'",
+		get:         func(c *config.ClientConfig) string { return c.Host },
+		set: func(c *config.ClientConfig, value string) error {
+			c.Host = value
+			return nil
+		},
+	},
+	{
+		name:        "no_retry",
+		description: "true to fail right away if the daemon can't be reached, like --no-retry",
+		get:         func(c *config.ClientConfig) string { return strconv.FormatBool(c.NoRetry) },
+		set: func(c *config.ClientConfig, value string) error {
+			noRetry, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.New("no_retry must be true or false", err)
+			}
+			c.NoRetry = noRetry
+			return nil
+		},
+	},
+	{
+		name:        "user",
+		description: "user to make requests as. the daemon enforces its quotas per user",
+		get:         func(c *config.ClientConfig) string { return c.User },
+		set: func(c *config.ClientConfig, value string) error {
+			c.User = value
+			return nil
+		},
+	},
+	{
+		name:        "tls_cert",
+		description: "client certificate to present to https:// hosts",
+		get:         func(c *config.ClientConfig) string { return c.TLSCert },
+		set: func(c *config.ClientConfig, value string) error {
+			c.TLSCert = value
+			return nil
+		},
+	},
+	{
+		name:        "tls_key",
+		description: "private key of tls_cert",
+		get:         func(c *config.ClientConfig) string { return c.TLSKey },
+		set: func(c *config.ClientConfig, value string) error {
+			c.TLSKey = value
+			return nil
+		},
+	},
+	{
+		name:        "tls_ca",
+		description: "ca to verify the certificate of https:// hosts with, instead of the system's",
+		get:         func(c *config.ClientConfig) string { return c.TLSCA },
+		set: func(c *config.ClientConfig, value string) error {
+			c.TLSCA = value
+			return nil
+		},
+	},
+	{
+		name:        "token",
+		description: "bearer token to send with every request",
+		get:         func(c *config.ClientConfig) string { return c.Token },
+		set: func(c *config.ClientConfig, value string) error {
+			c.Token = value
+			return nil
+		},
+		secret: true,
+	},
+	{
+		name:        "grpc_host",
+		description: "host:port of the gRPC api of the daemon, used with --grpc. the host of host with port 3001 if empty",
+		get:         func(c *config.ClientConfig) string { return c.GrpcHost },
+		set: func(c *config.ClientConfig, value string) error {
+			c.GrpcHost = value
+			return nil
+		},
+	},
+}
+
+func clientConfigKeyNames() []string {
+	names := []string{}
+	for _, key := range clientConfigKeys {
+		names = append(names, key.name)
+	}
+	return names
+}
+
+func findClientConfigKey(name string) (clientConfigKey, error) {
+	for _, key := range clientConfigKeys {
+		if key.name == name {
+			return key, nil
+		}
+	}
+	return clientConfigKey{}, errors.New("unknown config key "+name+". Available: "+strings.Join(clientConfigKeyNames(), "|"), nil)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change the client config file",
+	Long: `Reads and changes the settings in the client config file
+(--client-config, ~/.unik/client-config.yaml by default).
+
+Settings:
+	host      host:port of the unik daemon, or https://host:port. also set by 'unik target'
+	no_retry  true to fail right away if the daemon can't be reached, like --no-retry
+	user      user to make requests as. the daemon enforces its quotas per user. ignored by daemons with a user_token_key, which take the user from the token
+	tls_cert  client certificate to present to https:// hosts
+	tls_key   private key of tls_cert
+	tls_ca    ca to verify the certificate of https:// hosts with, instead of the system's
+	token     bearer token to send with every request, e.g. from 'unik daemon user-token'
+	grpc_host host:port of the gRPC api of the daemon, used with --grpc. the host of host with port 3001 if empty
+
+Run 'unik config init' to be asked for each of them.
+
+Example usage:
+	unik config set host 10.0.0.5:3000
+	unik config get host
+	unik config show --output json
+`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:       "get KEY",
+	Short:     "Print a setting of the client config file",
+	ValidArgs: clientConfigKeyNames(),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give exactly one key. Available: "+strings.Join(clientConfigKeyNames(), "|"), nil)
+			}
+			key, err := findClientConfigKey(args[0])
+			if err != nil {
+				return err
+			}
+			clientConfig, err := loadClientConfigFile()
+			if err != nil {
+				return err
+			}
+			fmt.Println(key.get(&clientConfig))
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed reading config: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:       "set KEY VALUE",
+	Short:     "Change a setting of the client config file",
+	ValidArgs: clientConfigKeyNames(),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 2 {
+				return errors.New("must give a key and a value", nil)
+			}
+			key, err := findClientConfigKey(args[0])
+			if err != nil {
+				return err
+			}
+			clientConfig, err := loadClientConfigFile()
+			if err != nil {
+				return err
+			}
+			if err := key.set(&clientConfig, args[1]); err != nil {
+				return err
+			}
+			if err := writeClientConfig(clientConfig); err != nil {
+				return err
+			}
+			logrus.Infof("%s set to %s in %s", key.name, key.get(&clientConfig), clientConfigFile)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed changing config: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print all settings of the client config file",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if outputFormat != "" && outputFormat != "json" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json", nil)
+			}
+			clientConfig, err := loadClientConfigFile()
+			if err != nil {
+				return err
+			}
+			if outputFormat == "json" {
+				data, err := json.MarshalIndent(clientConfig, "", "  ")
+				if err != nil {
+					return errors.New("marshalling config to json", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+			fmt.Printf("%-10s %s\n", "KEY", "VALUE")
+			for _, key := range clientConfigKeys {
+				value := key.get(&clientConfig)
+				if key.secret && value != "" {
+					value = "(set)"
+				}
+				fmt.Printf("%-10s %s\n", key.name, value)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed reading config: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configShowCmd)
+	configShowCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the settings in this format instead of a table. Available: json")
+}
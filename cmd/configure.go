@@ -58,6 +58,13 @@ xen
 				}
 				return nil
 			}
+		case "libvirt":
+			configFunc = func() error {
+				if err := doLibvirtConfig(reader); err != nil {
+					return err
+				}
+				return nil
+			}
 		case "openstack":
 			configFunc = func() error {
 				if err := doOpenstackConfig(reader); err != nil {
@@ -108,6 +115,9 @@ xen
 				if err := doGcloudConfig(reader); err != nil {
 					return err
 				}
+				if err := doLibvirtConfig(reader); err != nil {
+					return err
+				}
 				if err := doOpenstackConfig(reader); err != nil {
 					return err
 				}
@@ -337,6 +347,42 @@ func doQemuConfig(reader *bufio.Reader) error {
 	return nil
 }
 
+func doLibvirtConfig(reader *bufio.Reader) error {
+	fmt.Print("Do you wish to configure unik for use with Libvirt? [y/N]: ")
+	y, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	y = strings.TrimSuffix(y, "\n")
+	if y == "y" {
+		if len(daemonConfig.Providers.Libvirt) < 1 {
+			daemonConfig.Providers.Libvirt = append(daemonConfig.Providers.Libvirt, config.Libvirt{})
+		}
+		if daemonConfig.Providers.Libvirt[0].Name == "" {
+			daemonConfig.Providers.Libvirt[0].Name = "Libvirt-configuration"
+		}
+		fmt.Printf("URI of the libvirtd to connect to, e.g. qemu+tcp://host/system [%s]: ", daemonConfig.Providers.Libvirt[0].URI)
+		uri, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		uri = strings.TrimSuffix(uri, "\n")
+		if uri != "" {
+			daemonConfig.Providers.Libvirt[0].URI = uri
+		}
+		fmt.Printf("Name of the libvirt network to attach instances to [%s]: ", daemonConfig.Providers.Libvirt[0].Network)
+		network, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		network = strings.TrimSuffix(network, "\n")
+		if network != "" {
+			daemonConfig.Providers.Libvirt[0].Network = network
+		}
+	}
+	return nil
+}
+
 func doUkvmConfig(reader *bufio.Reader) error {
 	fmt.Print("Do you wish to configure unik for use with Ukvm? [y/N]: ")
 	y, err := reader.ReadString('\n')
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var sshUser, sshKey string
+var sshPort int
+
+var connectCmd = &cobra.Command{
+	Use:   "connect",
+	Short: "Open an ssh session to a unikernel instance",
+	Long: `Runs the ssh client of the system against an instance, for unikernels that run
+an ssh server.
+
+If a port of the daemon host is forwarded to the ssh port of the instance (run --port),
+ssh connects to it on the daemon host. Otherwise it connects to the ip address of the
+instance, or to the first address listed by unik instance-network if the provider
+does not know it. Arguments after -- are passed on to ssh.
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik connect --instance myInstance --user root --key ~/.ssh/id_rsa
+	unik connect --instance myInstance -- uptime
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			instance, err := client.UnikClient(host).Instances().Get(instanceName)
+			if err != nil {
+				return errors.New("retrieving instance "+instanceName, err)
+			}
+			sshHost, port, err := sshAddress(instance)
+			if err != nil {
+				return err
+			}
+			sshArgs := []string{"-p", fmt.Sprintf("%d", port)}
+			if sshKey != "" {
+				sshArgs = append(sshArgs, "-i", sshKey)
+			}
+			if sshUser != "" {
+				sshArgs = append(sshArgs, sshUser+"@"+sshHost)
+			} else {
+				sshArgs = append(sshArgs, sshHost)
+			}
+			sshArgs = append(sshArgs, args...)
+			logrus.WithFields(logrus.Fields{"instance": instance.Name, "host": sshHost, "port": port}).Info("connecting with ssh")
+			ssh := exec.Command("ssh", sshArgs...)
+			ssh.Stdin, ssh.Stdout, ssh.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := ssh.Run(); err != nil {
+				if _, ok := err.(*exec.ExitError); ok {
+					// ssh has printed why already
+					os.Exit(1)
+				}
+				return errors.New("running ssh - make sure it's in your path", err)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed connecting to instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// sshAddress returns where to reach the ssh port of an instance: a port of the
+// daemon host forwarded to it, or else the ip address of the instance
+func sshAddress(instance *types.Instance) (string, int, error) {
+	for _, mapping := range instance.Ports {
+		if mapping.GuestPort == sshPort && mapping.Protocol == "tcp" {
+			return strings.Split(host, ":")[0], mapping.HostPort, nil
+		}
+	}
+	if instance.IpAddress != "" {
+		return instance.IpAddress, sshPort, nil
+	}
+	interfaces, err := client.UnikClient(host).Instances().Network(instance.Id)
+	if err != nil {
+		return "", 0, errors.New("instance "+instance.Name+" has no known ip address, and its interfaces could not be listed", err)
+	}
+	for _, iface := range interfaces {
+		for _, address := range iface.IPAddresses {
+			if ip := net.ParseIP(address); ip != nil && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() {
+				return address, sshPort, nil
+			}
+		}
+	}
+	return "", 0, errors.New("instance "+instance.Name+" has no known ip address", nil)
+}
+
+func init() {
+	RootCmd.AddCommand(connectCmd)
+	connectCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	connectCmd.Flags().StringVar(&sshUser, "user", "", "<string,optional> user to log in as. defaults to the user of the ssh config")
+	connectCmd.Flags().StringVar(&sshKey, "key", "", "<string,optional> private key to authenticate with, e.g. ~/.ssh/id_rsa")
+	connectCmd.Flags().IntVar(&sshPort, "port", 22, "<int,optional> port the ssh server of the instance listens on")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var openConsole bool
+
+var consoleCmd = &cobra.Command{
+	Use:   "console",
+	Short: "Print the vnc address of the screen of a unikernel instance",
+	Long: `Prints the vnc:// address of the console (the screen) of an instance, e.g. to
+see how far it got before failing to boot. With --open, the address is opened with
+the vnc viewer of the system instead.
+
+Only qemu instances have a vnc display, unless the qemu provider is configured with
+no_graphic. The displays listen on 127.0.0.1 of the daemon host unless vnc_listen is
+set in the qemu config; for a remote daemon, tunnel the port over ssh or set
+vnc_listen: 0.0.0.0.
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik console --instance myInstance --open
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			instance, err := client.UnikClient(host).Instances().Get(instanceName)
+			if err != nil {
+				return errors.New("retrieving instance "+instanceName, err)
+			}
+			if instance.VncPort == 0 {
+				return errors.New("instance "+instance.Name+" has no vnc display", nil)
+			}
+			vncHost := strings.Split(host, ":")[0]
+			url := fmt.Sprintf("vnc://%s:%d", vncHost, instance.VncPort)
+			if !openConsole {
+				fmt.Println(url)
+				return nil
+			}
+			logrus.WithFields(logrus.Fields{"instance": instance.Name, "url": url}).Info("opening vnc viewer")
+			return openVncViewer(vncHost, instance.VncPort, url)
+		}(); err != nil {
+			logrus.Errorf("failed opening console: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// openVncViewer starts vncviewer if it is installed, or else opens url with the
+// program the desktop opens vnc:// urls with
+func openVncViewer(vncHost string, port int, url string) error {
+	var viewer *exec.Cmd
+	if _, err := exec.LookPath("vncviewer"); err == nil {
+		// HOST::PORT is a port rather than a display number
+		viewer = exec.Command("vncviewer", fmt.Sprintf("%s::%d", vncHost, port))
+	} else if runtime.GOOS == "darwin" {
+		viewer = exec.Command("open", url)
+	} else {
+		viewer = exec.Command("xdg-open", url)
+	}
+	if err := viewer.Start(); err != nil {
+		return errors.New("starting "+viewer.Path+"; open "+url+" with a vnc viewer", err)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(consoleCmd)
+	consoleCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	consoleCmd.Flags().BoolVar(&openConsole, "open", false, "<bool,optional> open the console with the vnc viewer of the system instead of printing its address")
+}
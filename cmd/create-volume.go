@@ -11,12 +11,15 @@ import (
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/client"
 	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
 var data string
-var size int
+var sizeStr string
 var volumeType string
 var rawVolume bool
+var volumeMode string
+var partitionTable string
 
 const (
 	VolTypeExt2 = "ext2"
@@ -49,7 +52,24 @@ Volume names must be unique. If a volume exists with the same name, you will be
 required to remove the volume with 'unik delete-volume' before the new volume
 can be created.
 
---size parameter uses MB
+--size takes a number of MB, or a size with a unit (500MB, 500MiB, 1GB, 1GiB).
+Empty volumes on providers that don't use partition tables (aws, gcloud, xen)
+are a bare filesystem with no partition table.
+
+Volumes can be tagged with any number of --tag key=value flags. On aws, the
+tags are also set on the EBS volume.
+
+--volume-mode virtiofs creates a qemu volume that is a folder on the daemon host
+instead of a disk image. Instances mount it with virtio-fs, tagged with the name
+of the volume (mount -t virtiofs VOLUME_NAME MOUNT_POINT), which needs virtiofsd
+on the daemon host. It takes no --size or --type, and can be created empty.
+--volume-mode 9p creates the same folder, shared by qemu itself over virtio-9p
+(mount -t 9p -o trans=virtio VOLUME_NAME MOUNT_POINT). run --volume-mode shares
+folder volumes in the other mode.
+
+On providers that put volumes on a partition table (qemu, virtualbox, vsphere...),
+--partition-table picks it: bsd (a disk label, the default, which rump reads),
+msdos or gpt.
 
 Example usage:
 	unik create-volume --name myVolume --data ./myApp/data --provider aws
@@ -64,24 +84,58 @@ Another example (empty volume):
 
 	# will create a 500mb sparse vmdk file and upload it to the vsphere datastore,
 	where it can be attached to a vsphere instance
+
+	unik create-volume --name scratch --size 1GiB --provider aws
+
+	# will create a blank 1GiB ext2 volume on aws without copying any data
+
+	unik create-volume --name shared --data ./myApp/data --provider qemu --volume-mode virtiofs
+
+	# will copy ./myApp/data to a folder the daemon shares with instances that mount shared
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if name == "" {
 				return errors.New("--name must be set", nil)
 			}
-			if data == "" && size == 0 {
+			mode, err := types.ParseVolumeMode(strings.ToLower(volumeMode))
+			if err != nil {
+				return errors.New("invalid --volume-mode", err)
+			}
+			if mode.IsFolder() {
+				if sizeStr != "" || volumeType != "" || rawVolume || partitionTable != "" {
+					return errors.New(string(mode)+" volumes are folders, they take no --size, --type, --raw or --partition-table", nil)
+				}
+			} else if data == "" && sizeStr == "" {
 				return errors.New("either --data or --size must be set", nil)
 			}
+			var size int
+			if sizeStr != "" {
+				sizeMb, err := unikos.ParseSize(sizeStr)
+				if err != nil {
+					return errors.New("invalid --size", err)
+				}
+				size = int(sizeMb)
+			}
 			if provider == "" {
 				return errors.New("--provider must be set", nil)
 			}
+			if partitionTable != "" {
+				if _, err := unikos.GetPartitioner(partitionTable, unikos.SectorSize512); err != nil {
+					return errors.New("invalid --partition-table", err)
+				}
+			}
 			if volumeType == "" {
 				volumeType = VolTypeExt2
 			} else {
 				volumeType = strings.ToLower(volumeType)
 			}
 
+			volumeTags, err := types.ParseTags(tags)
+			if err != nil {
+				return err
+			}
+
 			if err := readClientConfig(); err != nil {
 				return err
 			}
@@ -89,12 +143,15 @@ Another example (empty volume):
 				host = clientConfig.Host
 			}
 			logrus.WithFields(logrus.Fields{
-				"name":       name,
-				"data":       data,
-				"size":       size,
-				"provider":   provider,
-				"host":       host,
-				"volumeType": volumeType,
+				"name":           name,
+				"data":           data,
+				"size":           size,
+				"provider":       provider,
+				"host":           host,
+				"volumeType":     volumeType,
+				"volumeMode":     mode,
+				"partitionTable": partitionTable,
+				"tags":           volumeTags,
 			}).Infof("creating volume")
 			if data != "" {
 				dataTar, err := ioutil.TempFile("", "data.tar.gz.")
@@ -111,7 +168,17 @@ Another example (empty volume):
 				logrus.Infof("Data packaged as tarball: %s\n", dataTar.Name())
 			}
 
-			volume, err := client.UnikClient(host).Volumes().Create(name, data, provider, rawVolume, size, volumeType, noCleanup)
+			volume, err := client.UnikClient(host).Volumes().Create(name, client.CreateVolumeOptions{
+				DataTar:        data,
+				Provider:       provider,
+				Raw:            rawVolume,
+				SizeMb:         size,
+				Type:           volumeType,
+				Mode:           mode,
+				PartitionTable: partitionTable,
+				NoCleanup:      noCleanup,
+				Tags:           volumeTags,
+			})
 
 			if err != nil {
 				return errors.New("creatinv volume image failed", err)
@@ -130,9 +197,12 @@ func init() {
 	cvCmd.Flags().StringVar(&name, "name", "", "<string,required> name to give the volume. must be unique")
 	cvCmd.Flags().StringVar(&data, "data", "", "<string,special> path to data folder (or file if --raw is provided). optional if --size is provided")
 	cvCmd.Flags().BoolVar(&rawVolume, "raw", false, "<bool,optional> if true then then data is expected to be a file that will be used as is. if false (default) data should point to a folder which will be turned into a volume.")
-	cvCmd.Flags().IntVar(&size, "size", 0, "<int,special> size to create volume in MB. optional if --data is provided")
+	cvCmd.Flags().StringVar(&sizeStr, "size", "", "<string,special> size to create volume, in MB or with a unit (e.g. 500MiB, 1GiB). optional if --data is provided")
 	cvCmd.Flags().StringVar(&provider, "provider", "", "<string,required> name of the target infrastructure to compile for")
+	cvCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> tag the volume, given as key=value")
 	cvCmd.Flags().StringVar(&volumeType, "type", "", "<string,optional> FS type of the volume. ext2 or FAT are supported. defaults to ext2")
+	cvCmd.Flags().StringVar(&partitionTable, "partition-table", "", "<string,optional> partition table of the volume: "+strings.Join(unikos.PartitionTables(), "|")+". defaults to "+unikos.DefaultPartitionTable+". only for providers whose volumes have a partition table")
+	cvCmd.Flags().StringVar(&volumeMode, "volume-mode", "", "<string,optional> block (default) for a disk image, or virtiofs|9p for a folder shared with qemu instances through virtiofsd or virtio-9p")
 
 	cvCmd.Flags().BoolVar(&noCleanup, "no-cleanup", false, "<bool, optional> for debugging; do not clean up artifacts for volumes that fail to build")
 }
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var shutdownTimeout time.Duration
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Shut the targeted daemon down gracefully",
+	Long: `Asks the targeted daemon to shut down (POST /admin/shutdown). The daemon turns
+new requests away with 503, waits up to --timeout for the requests in flight to
+finish, writes the state of its providers to disk, and exits. Streams of events
+or logs are not waited for.
+
+If the connection is dropped before the daemon replies, unik checks that the
+daemon is gone instead.
+
+Example usage:
+	unik daemon stop --timeout 1m
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "timeout": shutdownTimeout}).Info("stopping daemon")
+			result, err := client.UnikClient(host).Shutdown(true, shutdownTimeout)
+			if err != nil {
+				// the daemon may have exited before its reply went out
+				if !waitForDaemonExit(10 * time.Second) {
+					return errors.New("stopping daemon at "+host, err)
+				}
+				logrus.WithError(err).Warnf("daemon did not reply, but has stopped")
+				fmt.Println("daemon stopped")
+				return nil
+			}
+			if !result.Drained {
+				logrus.Warnf("requests were still in flight after %s", shutdownTimeout)
+			}
+			for provider, stateErr := range result.Errors {
+				logrus.Warnf("daemon failed to write the state of provider %s: %s", provider, stateErr)
+			}
+			fmt.Println("daemon stopped")
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed stopping daemon: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// waitForDaemonExit returns true once the daemon cannot be reached, or false if
+// it still answers after timeout
+func waitForDaemonExit(timeout time.Duration) bool {
+	// connection refused is what we are waiting for here
+	client.SetRetryTransport(nil)
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(500 * time.Millisecond) {
+		if _, err := client.UnikClient(host).Health(false); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonStopCmd)
+	daemonStopCmd.Flags().DurationVar(&shutdownTimeout, "timeout", 30*time.Second, "<duration,optional> how long the daemon waits for requests in flight before exiting")
+}
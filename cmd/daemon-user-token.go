@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/daemon"
+)
+
+var tokenUser string
+var tokenTTL time.Duration
+
+var daemonUserTokenCmd = &cobra.Command{
+	Use:   "user-token",
+	Short: "Print a bearer token that makes requests for a user",
+	Long: `Signs a token for --user with the user_token_key of the daemon config, and
+prints it. A daemon with a user_token_key refuses requests without such a token
+(other than health checks), and enforces quotas and writes the audit log for the
+user of the token instead of whoever the X-Unik-User header claims. Give the
+token to the user, who sets it with unik config set token.
+
+Run it where the daemon config is, e.g. on the daemon host.
+
+Example usage:
+	unik daemon user-token --user alice --ttl 720h
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if tokenUser == "" {
+				return errors.New("must specify --user", nil)
+			}
+			if daemonConfigFile == "" {
+				daemonConfigFile = filepath.Join(daemonRuntimeFolder, "daemon-config.yaml")
+			}
+			if err := readDaemonConfig(); err != nil {
+				return err
+			}
+			if daemonConfig.UserTokenKey == "" {
+				return errors.New("the daemon config at "+daemonConfigFile+" has no user_token_key", nil)
+			}
+			token, err := daemon.NewUserToken(daemonConfig.UserTokenKey, tokenUser, tokenTTL)
+			if err != nil {
+				return err
+			}
+			fmt.Println(token)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed creating user token: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonUserTokenCmd)
+	daemonUserTokenCmd.Flags().StringVar(&tokenUser, "user", "", "<string,required> user to make requests for with the token")
+	daemonUserTokenCmd.Flags().DurationVar(&tokenTTL, "ttl", 0, "<duration,optional> how long the token is valid for. tokens don't expire by default")
+	daemonUserTokenCmd.Flags().StringVar(&daemonRuntimeFolder, "d", os.Getenv("HOME")+"/.unik/", "daemon runtime folder, where the daemon config is looked for")
+	daemonUserTokenCmd.Flags().StringVar(&daemonConfigFile, "f", "", "daemon config file (default is {RuntimeFolder}/daemon-config.yaml)")
+}
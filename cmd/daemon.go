@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sync"
+	"syscall"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -15,11 +18,21 @@ import (
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/config"
 	"github.com/emc-advanced-dev/unik/pkg/daemon"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	unikutil "github.com/emc-advanced-dev/unik/pkg/util"
 )
 
 var daemonRuntimeFolder, daemonConfigFile, logFile string
 var debugMode, trace bool
+var volumeBackend, nfsMount string
+var auditLogPath string
+var auditLogMaxSizeMB, auditLogMaxBackups int
+var stateBackend string
+var etcdEndpoints []string
+var requireSignedImages bool
+var trustedKeysDir string
+var compilerPluginDir string
+var grpcAddr string
 
 var daemonCmd = &cobra.Command{
 	Use:   "daemon",
@@ -80,13 +93,49 @@ Example usage:
 				logrus.AddHook(&unikutil.TeeHook{f})
 			}
 
+			if err := setupVolumeBackend(); err != nil {
+				return err
+			}
+			// a no-op once the daemon stopped and released it below
+			defer releaseVolumeBackend()
+			// the flags override the audit_log section of the daemon config
+			if cmd.Flags().Changed("audit-log-path") {
+				daemonConfig.AuditLog.Path = auditLogPath
+			}
+			if cmd.Flags().Changed("audit-log-max-size-mb") || daemonConfig.AuditLog.MaxSizeMB == 0 {
+				daemonConfig.AuditLog.MaxSizeMB = auditLogMaxSizeMB
+			}
+			if cmd.Flags().Changed("audit-log-max-backups") || daemonConfig.AuditLog.MaxBackups == 0 {
+				daemonConfig.AuditLog.MaxBackups = auditLogMaxBackups
+			}
+			if cmd.Flags().Changed("state-backend") {
+				daemonConfig.StateBackend.Type = stateBackend
+			}
+			if cmd.Flags().Changed("etcd-endpoints") || len(daemonConfig.StateBackend.EtcdEndpoints) == 0 {
+				daemonConfig.StateBackend.EtcdEndpoints = etcdEndpoints
+			}
+			if cmd.Flags().Changed("require-signed-images") {
+				daemonConfig.RequireSignedImages = requireSignedImages
+			}
+			if cmd.Flags().Changed("trusted-keys-dir") {
+				daemonConfig.TrustedKeysDir = trustedKeysDir
+			}
+			if cmd.Flags().Changed("compiler-plugin-dir") {
+				daemonConfig.CompilerPluginDir = compilerPluginDir
+			}
+			if cmd.Flags().Changed("grpc-addr") {
+				daemonConfig.GrpcAddr = grpcAddr
+			}
+
 			logrus.WithField("config", daemonConfig).Info("daemon started")
 			d, err := daemon.NewUnikDaemon(daemonConfig)
 			if err != nil {
 				return errors.New("daemon failed to initialize", err)
 			}
+			stopOnSignal(d)
 			d.Run(port)
-			return nil
+			// stopped with unik daemon stop, or by a signal
+			return releaseVolumeBackend()
 		}(); err != nil {
 			logrus.Errorf("running daemon failed: %v", err)
 			os.Exit(-1)
@@ -102,6 +151,78 @@ func init() {
 	daemonCmd.Flags().BoolVar(&debugMode, "debug", false, "<bool, optional> more verbose logging for the daemon")
 	daemonCmd.Flags().BoolVar(&trace, "trace", false, "<bool, optional> add stack trace to daemon logs")
 	daemonCmd.Flags().StringVar(&logFile, "logfile", "", "<string, optional> output logs to file (in addition to stdout)")
+	daemonCmd.Flags().StringVar(&volumeBackend, "volume-backend", "local", "<string, optional> where local providers store volumes. Available: local|nfs")
+	daemonCmd.Flags().StringVar(&nfsMount, "nfs-mount", "", "<string, optional> nfs export to store volumes on, as server:/export. required with --volume-backend nfs")
+	daemonCmd.Flags().StringVar(&auditLogPath, "audit-log-path", "", "<string, optional> file to write a json line to for every request that is not a GET. no audit log is written by default")
+	daemonCmd.Flags().IntVar(&auditLogMaxSizeMB, "audit-log-max-size-mb", 100, "<int, optional> rotate the audit log once it reaches this size")
+	daemonCmd.Flags().IntVar(&auditLogMaxBackups, "audit-log-max-backups", 5, "<int, optional> number of rotated audit logs to keep")
+	daemonCmd.Flags().StringVar(&stateBackend, "state-backend", "file", "<string, optional> where the state of providers is kept. Available: file|etcd")
+	daemonCmd.Flags().StringSliceVar(&etcdEndpoints, "etcd-endpoints", []string{"http://localhost:2379"}, "<string,repeated> etcd endpoints to keep state on, with --state-backend etcd")
+	daemonCmd.Flags().StringVar(&compilerPluginDir, "compiler-plugin-dir", "", "<string, optional> directory to load compiler plugins (.so files built with go build -buildmode=plugin) from at startup")
+	daemonCmd.Flags().StringVar(&grpcAddr, "grpc-addr", "", "<string, optional> address to serve the gRPC api on as well as the REST api, e.g. :3001. the gRPC api is not served by default")
+	daemonCmd.Flags().BoolVar(&requireSignedImages, "require-signed-images", false, "<bool, optional> refuse to run images that are not signed with unik sign-image, or that changed since they were signed. needs --trusted-keys-dir")
+	daemonCmd.Flags().StringVar(&trustedKeysDir, "trusted-keys-dir", "", "<string, optional> directory of the PEM encoded public keys images may be signed with. signatures made with other keys are refused")
+}
+
+// setupVolumeBackend mounts the nfs volume store if one was requested. it is
+// unmounted again when the daemon is interrupted.
+func setupVolumeBackend() error {
+	switch volumeBackend {
+	case "", "local":
+		return nil
+	case "nfs":
+	default:
+		return errors.New("unknown volume backend "+volumeBackend+". Available: local|nfs", nil)
+	}
+	if nfsMount == "" {
+		return errors.New("must specify --nfs-mount with --volume-backend nfs", nil)
+	}
+	backend, err := common.NewNfsVolumeBackend(nfsMount, filepath.Join(config.Internal.UnikHome, "nfs-volumes"))
+	if err != nil {
+		return err
+	}
+	if err := backend.Mount(); err != nil {
+		return errors.New("mounting nfs volume store", err)
+	}
+	config.Internal.VolumeStore = backend.MountPoint
+	var once sync.Once
+	releaseVolumeBackend = func() error {
+		var err error
+		once.Do(func() {
+			logrus.Infof("unmounting nfs volume store %s", nfsMount)
+			if unmountErr := backend.Unmount(); unmountErr != nil {
+				err = errors.New("unmounting nfs volume store", unmountErr)
+			}
+		})
+		return err
+	}
+	// logrus.Fatalf exits without running deferred functions
+	logrus.RegisterExitHandler(func() {
+		if err := releaseVolumeBackend(); err != nil {
+			logrus.WithError(err).Errorf("failed to unmount nfs volume store")
+		}
+	})
+	return nil
+}
+
+// releaseVolumeBackend unmounts the volume store set up by setupVolumeBackend.
+// only the first call does anything
+var releaseVolumeBackend = func() error { return nil }
+
+// stopOnSignal stops d on SIGINT and SIGTERM like unik daemon stop does, so the
+// state is written and the volume store released before the daemon exits. a
+// second signal kills it right away
+func stopOnSignal(d *daemon.UnikDaemon) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+		logrus.Infof("received %v, stopping daemon", sig)
+		if err := d.Stop(); err != nil {
+			logrus.WithError(err).Warnf("failed to stop daemon")
+		}
+	}()
 }
 
 var daemonConfig config.DaemonConfig
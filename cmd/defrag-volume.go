@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var defragThreshold int
+
+var defragVolumeCmd = &cobra.Command{
+	Use:   "defrag VOLUME",
+	Short: "Defragment the filesystem of a volume",
+	Long: `Measures the fragmentation of the ext filesystem of a volume, and defragments it
+if it is too fragmented. The daemon loop mounts the filesystem read-only and
+scores its fragmentation with e4defrag -c (0-30 is no problem, 31-55 a little
+fragmented, 56 and above needs defragmenting). If the score is above --threshold,
+the filesystem is remounted read-write and defragmented with e4defrag, and scored again.
+
+e4defrag only moves files that use extents, so only ext4 volumes can be
+defragmented. The fragmentation of ext2 (the default filesystem of unik volumes)
+and ext3 volumes is reported, and defragmenting them fails. xfs volumes are
+refused: they are defragmented with xfs_fsr.
+
+The volume must be detached, stored on the daemon host (qemu, xen, ukvm or
+libvirt) as a raw or qcow2 file, and the daemon must be able to set up loop
+devices and mount filesystems (root on linux).
+
+Example usage:
+	unik volumes defrag myVolume --threshold 30
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give exactly one volume", nil)
+			}
+			if defragThreshold < 0 {
+				return errors.New("--threshold must not be negative", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "volume": args[0], "threshold": defragThreshold}).Info("defragmenting volume")
+			result, err := client.UnikClient(host).Volumes().Defrag(args[0], defragThreshold)
+			if err != nil {
+				return err
+			}
+			if result.Defragmented {
+				fmt.Printf("volume %s (%s): fragmentation score %d before, %d after\n", result.Volume, result.FilesystemType, result.ScoreBefore, result.ScoreAfter)
+			} else {
+				fmt.Printf("volume %s (%s): fragmentation score %d, not above %d, not defragmented\n", result.Volume, result.FilesystemType, result.ScoreBefore, result.Threshold)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed defragmenting volume: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	volumesCmd.AddCommand(defragVolumeCmd)
+	defragVolumeCmd.Flags().IntVar(&defragThreshold, "threshold", 20, "<int, optional> defragment the volume if its e4defrag fragmentation score is above this")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var backupScheduleId string
+
+var deleteBackupScheduleCmd = &cobra.Command{
+	Use:   "delete-backup-schedule",
+	Short: "Stop backing up a volume on a schedule",
+	Long: `Deletes a backup schedule made with unik schedule-backup. The backups already
+made for it are kept, at their destination and in the list of unik backups.
+Get the id of the schedule from unik backups.
+
+Example usage:
+	unik delete-backup-schedule --id 0f6c2bd4-8a1e-4f2e-9b63-3f6f1d0c9a57
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if backupScheduleId == "" {
+				return errors.New("must specify --id", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "id": backupScheduleId}).Info("deleting backup schedule")
+			return client.UnikClient(host).Volumes().DeleteBackupSchedule(backupScheduleId)
+		}(); err != nil {
+			logrus.Errorf("failed deleting backup schedule: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(deleteBackupScheduleCmd)
+	deleteBackupScheduleCmd.Flags().StringVar(&backupScheduleId, "id", "", "<string,required> id of the backup schedule")
+}
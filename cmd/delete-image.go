@@ -15,18 +15,37 @@ var rmiCmd = &cobra.Command{
 	Aliases: []string{"rmi"},
 	Short:   "Delete a unikernel image",
 	Long: `Deletes an image.
-You may specify the image by name or id.`,
+You may specify the image by name or id.
+
+With --all, every image is deleted, or with --provider, every one of that
+provider. An image that fails to be deleted does not keep the others from being
+deleted; the failures are all reported at the end. Deleted images cannot be
+brought back.
+
+Example usage:
+	unik delete-image --all --provider qemu`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
 				return err
 			}
-			if imageName == "" {
-				return errors.New("must specify --image", nil)
-			}
 			if host == "" {
 				host = clientConfig.Host
 			}
+			if all {
+				if imageName != "" {
+					return errors.New("--image cannot be given with --all", nil)
+				}
+				logrus.WithFields(logrus.Fields{"host": host, "force": force, "provider": provider}).Info("deleting all images")
+				result, err := client.UnikClient(host).Images().DeleteAll(provider, force)
+				if err != nil {
+					return err
+				}
+				return printBatchResult("deleted images", result)
+			}
+			if imageName == "" {
+				return errors.New("must specify --image or --all", nil)
+			}
 			logrus.WithFields(logrus.Fields{"host": host, "force": force, "image": imageName}).Info("deleting image")
 			if err := client.UnikClient(host).Images().Delete(imageName, force); err != nil {
 				return err
@@ -42,5 +61,7 @@ You may specify the image by name or id.`,
 func init() {
 	RootCmd.AddCommand(rmiCmd)
 	rmiCmd.Flags().StringVar(&imageName, "image", "", "<string,required> name or id of image. unik accepts a prefix of the name or id")
+	rmiCmd.Flags().BoolVar(&all, "all", false, "<bool, optional> delete all images instead of --image")
+	rmiCmd.Flags().StringVar(&provider, "provider", "", "<string, optional> with --all, only delete the images of this provider")
 	rmiCmd.Flags().BoolVar(&force, "force", false, "<bool, optional> force deleting image in the case that it is running")
 }
@@ -15,7 +15,15 @@ var rmCmd = &cobra.Command{
 	Aliases: []string{"rm"},
 	Short:   "Delete a unikernel instance",
 	Long: `Deletes an instance.
-You may specify the instance by name or id.`,
+You may specify the instance by name or id.
+
+With --all, every instance is deleted, or with --provider, every one of that
+provider. An instance that fails to be deleted does not keep the others from being
+deleted; the failures are all reported at the end. Deleted instances cannot be
+brought back.
+
+Example usage:
+	unik delete-instance --all --provider qemu`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
@@ -24,8 +32,19 @@ You may specify the instance by name or id.`,
 			if host == "" {
 				host = clientConfig.Host
 			}
+			if all {
+				if instanceName != "" {
+					return errors.New("--instance cannot be given with --all", nil)
+				}
+				logrus.WithFields(logrus.Fields{"host": host, "force": force, "provider": provider}).Info("deleting all instances")
+				result, err := client.UnikClient(host).Instances().DeleteAll(provider, force)
+				if err != nil {
+					return err
+				}
+				return printBatchResult("deleted instances", result)
+			}
 			if instanceName == "" {
-				return errors.New("must specify --instance", nil)
+				return errors.New("must specify --instance or --all", nil)
 			}
 			logrus.WithFields(logrus.Fields{"host": host, "force": force, "instance": instanceName}).Info("deleting instance")
 			if err := client.UnikClient(host).Instances().Delete(instanceName, force); err != nil {
@@ -42,5 +61,7 @@ You may specify the instance by name or id.`,
 func init() {
 	RootCmd.AddCommand(rmCmd)
 	rmCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	rmCmd.Flags().BoolVar(&all, "all", false, "<bool, optional> delete all instances instead of --instance")
+	rmCmd.Flags().StringVar(&provider, "provider", "", "<string, optional> with --all, only delete the instances of this provider")
 	rmCmd.Flags().BoolVar(&force, "force", false, "<bool, optional> force deleting instance in the case that it is running")
 }
@@ -17,18 +17,37 @@ var rmvCmd = &cobra.Command{
 	Aliases: []string{"rmv"},
 	Short:   "Delete a unikernel volume",
 	Long: `Deletes a volume.
-You may specify the volume by name or id.`,
+You may specify the volume by name or id.
+
+With --all, every volume is deleted, or with --provider, every one of that
+provider. A volume that fails to be deleted does not keep the others from being
+deleted; the failures are all reported at the end. Deleted volumes cannot be
+brought back.
+
+Example usage:
+	unik delete-volume --all --provider qemu`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
 				return err
 			}
-			if volumeName == "" {
-				return errors.New("must specify --volume", nil)
-			}
 			if host == "" {
 				host = clientConfig.Host
 			}
+			if all {
+				if volumeName != "" {
+					return errors.New("--volume cannot be given with --all", nil)
+				}
+				logrus.WithFields(logrus.Fields{"host": host, "force": force, "provider": provider}).Info("deleting all volumes")
+				result, err := client.UnikClient(host).Volumes().DeleteAll(provider, force)
+				if err != nil {
+					return err
+				}
+				return printBatchResult("deleted volumes", result)
+			}
+			if volumeName == "" {
+				return errors.New("must specify --volume or --all", nil)
+			}
 			logrus.WithFields(logrus.Fields{"host": host, "force": force, "volume": volumeName}).Info("deleting volume")
 			if err := client.UnikClient(host).Volumes().Delete(volumeName, force); err != nil {
 				return err
@@ -44,5 +63,7 @@ You may specify the volume by name or id.`,
 func init() {
 	RootCmd.AddCommand(rmvCmd)
 	rmvCmd.Flags().StringVar(&volumeName, "volume", "", "<string,required> name or id of volume. unik accepts a prefix of the name or id")
+	rmvCmd.Flags().BoolVar(&all, "all", false, "<bool, optional> delete all volumes instead of --volume")
+	rmvCmd.Flags().StringVar(&provider, "provider", "", "<string, optional> with --all, only delete the volumes of this provider")
 	rmvCmd.Flags().BoolVar(&force, "force", false, "<bool, optional> forces detaching the volume before deletion if it is currently attached")
 }
@@ -14,8 +14,17 @@ import (
 
 var describeImageCmd = &cobra.Command{
 	Use:   "describe-image",
-	Short: "Get image info as a Json string",
-	Long:  `Get a json representation of an image as it is stored in unik.`,
+	Short: "Show all details of an image",
+	Long: `Prints everything unik knows about an image: its id, provider, size,
+creation time, compiler, tags, and the mount points its volumes are attached at.
+
+With --output json, the image is printed as the json object the daemon stores.
+
+You may specify the image by name or id.
+
+Example usage:
+	unik describe-image --image myImage --output json
+`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
@@ -27,10 +36,17 @@ var describeImageCmd = &cobra.Command{
 			if name == "" {
 				return errors.New("must specify --image", nil)
 			}
+			if outputFormat != "" && outputFormat != "json" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json", nil)
+			}
 			image, err := client.UnikClient(host).Images().Get(name)
 			if err != nil {
 				return err
 			}
+			if outputFormat != "json" {
+				printImageDetails(image)
+				return nil
+			}
 			data, err := json.Marshal(image)
 			if err != nil {
 				return err
@@ -47,4 +63,5 @@ var describeImageCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(describeImageCmd)
 	describeImageCmd.Flags().StringVar(&name, "image", "", "<string,required> name or id of image. unik accepts a prefix of the name or id")
+	describeImageCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the image in this format instead of a list of details. Available: json")
 }
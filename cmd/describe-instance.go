@@ -4,18 +4,39 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
+var describeFormat string
+
 var describeInstanceCmd = &cobra.Command{
 	Use:   "describe-instance",
-	Short: "Get instance info as a Json string",
-	Long:  `Get a json representation of an instance as it is stored in unik.`,
+	Short: "Show all details of an instance",
+	Long: `Prints everything unik knows about an instance: its id, state, image,
+provider, ip address, the volumes mounted on it, tags, creation time, restart
+count and time to live.
+
+With --output json, the instance is printed as the json object the daemon stores.
+With --format, the instance is printed with a go template instead; the fields
+available are those of the json object.
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik describe-instance --instance myInstance --format '{{.Name}} {{.IpAddress}}'
+
+	# will print the name and ip address of myInstance
+`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
@@ -27,15 +48,39 @@ var describeInstanceCmd = &cobra.Command{
 			if name == "" {
 				return errors.New("must specify --instance", nil)
 			}
+			if outputFormat != "" && outputFormat != "json" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json", nil)
+			}
+			if outputFormat != "" && describeFormat != "" {
+				return errors.New("--output and --format cannot be used together", nil)
+			}
+			var tmpl *template.Template
+			if describeFormat != "" {
+				var err error
+				tmpl, err = template.New("instance").Parse(strings.TrimPrefix(describeFormat, "go-template="))
+				if err != nil {
+					return errors.New("parsing --format template", err)
+				}
+			}
 			instance, err := client.UnikClient(host).Instances().Get(name)
 			if err != nil {
 				return err
 			}
-			data, err := json.Marshal(instance)
-			if err != nil {
-				return err
+			switch {
+			case tmpl != nil:
+				if err := tmpl.Execute(os.Stdout, instance); err != nil {
+					return errors.New("executing --format template", err)
+				}
+				fmt.Println()
+			case outputFormat == "json":
+				data, err := json.Marshal(instance)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\n", string(data))
+			default:
+				printInstanceDetails(instance)
 			}
-			fmt.Printf("%s\n", string(data))
 			return nil
 		}(); err != nil {
 			logrus.Errorf("failed describing instance: %v", err)
@@ -44,7 +89,67 @@ var describeInstanceCmd = &cobra.Command{
 	},
 }
 
+// printInstanceDetails prints every field of an instance that is relevant to users,
+// one per line. image and volume names are looked up on a best effort basis;
+// their ids are printed if the lookup fails.
+func printInstanceDetails(instance *types.Instance) {
+	unik := client.UnikClient(host)
+	imageName := instance.ImageId
+	if image, err := unik.Images().Get(instance.ImageId); err == nil {
+		imageName = image.Name
+	}
+	printDetail("Id", instance.Id)
+	printDetail("Name", instance.Name)
+	printDetail("State", string(instance.State))
+	printDetail("Image", imageName)
+	printDetail("Provider", string(instance.Infrastructure))
+	printDetail("Ip Address", instance.IpAddress)
+	printDetail("IPv6 Addresses", strings.Join(instance.IPv6Addresses, ", "))
+	printDetail("Created", instance.Created.String())
+	printDetail("Group", instance.Group)
+	printDetail("Tags", formatTags(instance.Tags))
+
+	mountPoints := []string{}
+	for mountPoint := range instance.Mounts {
+		mountPoints = append(mountPoints, mountPoint)
+	}
+	sort.Strings(mountPoints)
+	volumes := []string{}
+	for _, mountPoint := range mountPoints {
+		volumeName := instance.Mounts[mountPoint]
+		if volume, err := unik.Volumes().Get(volumeName); err == nil {
+			volumeName = volume.Name
+		}
+		volumes = append(volumes, volumeName+" at "+mountPoint)
+	}
+	printDetail("Volumes", strings.Join(volumes, ", "))
+	ports := []string{}
+	for _, mapping := range instance.Ports {
+		ports = append(ports, mapping.String())
+	}
+	printDetail("Ports", strings.Join(ports, ", "))
+	vncPort := ""
+	if instance.VncPort != 0 {
+		vncPort = fmt.Sprintf("%d", instance.VncPort)
+	}
+	printDetail("VNC Port", vncPort)
+
+	restartPolicy := ""
+	if instance.RestartPolicy != nil {
+		restartPolicy = string(instance.RestartPolicy.Mode)
+	}
+	printDetail("Restart Policy", restartPolicy)
+	printDetail("Restart Count", fmt.Sprintf("%d", instance.RestartCount))
+	ttl := ""
+	if !instance.ExpiresAt.IsZero() {
+		ttl = fmt.Sprintf("expires %s (in %s)", instance.ExpiresAt.String(), instance.ExpiresAt.Sub(time.Now()).Truncate(time.Second))
+	}
+	printDetail("Time To Live", ttl)
+}
+
 func init() {
 	RootCmd.AddCommand(describeInstanceCmd)
 	describeInstanceCmd.Flags().StringVar(&name, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	describeInstanceCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the instance in this format instead of a list of details. Available: json")
+	describeInstanceCmd.Flags().StringVar(&describeFormat, "format", "", "<string,optional> print the instance with this go template, e.g. '{{.Name}} {{.State}}'")
 }
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var verbose bool
+
+var describeVolumeCmd = &cobra.Command{
+	Use:   "describe-volume",
+	Short: "Show all details of a volume",
+	Long: `Prints everything unik knows about a volume: its id, name, size,
+filesystem type, the instance it is attached to, provider, tags, and when it
+was created and last modified.
+
+For volumes stored on the daemon host (qemu, ukvm, virtualbox and xen), the
+daemon reads the superblock of the volume's filesystem with dumpe2fs.
+--verbose prints its block, inode and mount counts as well.
+
+With --output json or --output yaml, the volume and its filesystem details are
+printed in that format instead.
+
+You may specify the volume by name or id.
+
+Example usage:
+	unik describe-volume --volume myVolume --verbose
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if volumeName == "" {
+				return errors.New("must specify --volume", nil)
+			}
+			if outputFormat != "" && outputFormat != "json" && outputFormat != "yaml" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json|yaml", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			details, err := client.UnikClient(host).Volumes().Describe(volumeName)
+			if err != nil {
+				return err
+			}
+			switch outputFormat {
+			case "json":
+				data, err := json.Marshal(details)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\n", string(data))
+			case "yaml":
+				data, err := yaml.Marshal(details)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s", string(data))
+			default:
+				printVolumeDetails(details, verbose)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed describing volume: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func printVolumeDetails(details *types.VolumeDetails, verbose bool) {
+	volume := details.Volume
+	printDetail("Id", volume.Id)
+	printDetail("Name", volume.Name)
+	printDetail("Size", fmt.Sprintf("%d MB", volume.SizeMb))
+	printDetail("Filesystem", details.FilesystemType)
+	printDetail("Attached To", volume.Attachment)
+	printDetail("Provider", string(volume.Infrastructure))
+	printDetail("Tags", formatTags(volume.Tags))
+	printDetail("Created", volume.Created.String())
+	modified := ""
+	if !details.Modified.IsZero() {
+		modified = details.Modified.String()
+	}
+	printDetail("Modified", modified)
+	if !verbose || details.Filesystem == nil {
+		return
+	}
+	fs := details.Filesystem
+	printDetail("Fs UUID", fs.UUID)
+	printDetail("Fs Label", fs.VolumeName)
+	printDetail("Fs State", fs.State)
+	printDetail("Block Size", fmt.Sprintf("%d", fs.BlockSize))
+	printDetail("Block Count", fmt.Sprintf("%d (%d free)", fs.BlockCount, fs.FreeBlocks))
+	printDetail("Inode Count", fmt.Sprintf("%d (%d free)", fs.InodeCount, fs.FreeInodes))
+	printDetail("Mount Count", fmt.Sprintf("%d", fs.MountCount))
+	printDetail("Last Mounted", fs.LastMounted)
+	printDetail("Last Written", fs.LastWritten)
+}
+
+func init() {
+	RootCmd.AddCommand(describeVolumeCmd)
+	describeVolumeCmd.Flags().StringVar(&volumeName, "volume", "", "<string,required> name or id of volume. unik accepts a prefix of the name or id")
+	describeVolumeCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the volume in this format instead of a list of details. Available: json|yaml")
+	describeVolumeCmd.Flags().BoolVar(&verbose, "verbose", false, "<bool,optional> also print the block, inode and mount counts of the volume's filesystem")
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var diffFrom, diffTo, outputFormat string
+
+var diffImagesCmd = &cobra.Command{
+	Use:   "diff-images",
+	Short: "Compare the files in two unikernel images",
+	Long: `Compares the root filesystems of two images and lists the files that were
+added, removed or modified between them, with the change in size of each file.
+Modified files are listed with the SHA-256 of their old and new contents.
+
+Both images are mounted read-only on the daemon host to be compared, so they
+must belong to a provider that stores images locally (qemu, virtualbox or xen).
+Images in other formats than raw are converted to a temporary raw copy first.
+
+Use --output json to get the full list of changes as json.
+
+Example usage:
+	unik diff-images --from myImage-v1 --to myImage-v2
+
+	+ app/new-asset.js (+2048 bytes)
+	- app/old-asset.js (-1024 bytes)
+	M program.bin (+512 bytes) sha256 3f1a9c0be2d4 -> 9c2e77d01a3b
+	3 files changed between myImage-v1 and myImage-v2, +1536 bytes
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if diffFrom == "" || diffTo == "" {
+				return errors.New("must specify --from and --to", nil)
+			}
+			if outputFormat != "" && outputFormat != "json" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "from": diffFrom, "to": diffTo}).Info("diffing images")
+			diff, err := client.UnikClient(host).Images().Diff(diffFrom, diffTo)
+			if err != nil {
+				return errors.New("diffing images failed", err)
+			}
+			if outputFormat == "json" {
+				data, err := json.MarshalIndent(diff, "", "  ")
+				if err != nil {
+					return errors.New("marshalling diff to json", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+			printImageDiff(diff)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed diffing images: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(diffImagesCmd)
+	diffImagesCmd.Flags().StringVar(&diffFrom, "from", "", "<string,required> name or id of the image to compare against")
+	diffImagesCmd.Flags().StringVar(&diffTo, "to", "", "<string,required> name or id of the image to compare")
+	diffImagesCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the diff in this format instead of a list of changes. Available: json")
+}
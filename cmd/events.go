@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show image, instance and volume events from the daemon",
+	Long: `Prints the most recent events recorded by the daemon, such as instances
+starting, stopping or crashing, volumes being attached or detached, and
+builds completing.
+
+Use the --follow flag to keep the connection open and print events as
+they happen. Any number of clients can follow events at the same time.
+
+Example usage:
+	unik events --follow
+
+	# will print every event until interrupted (i.e. with Ctrl+C)
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if !follow {
+				logrus.WithField("host", host).Info("listing recent events")
+				events, err := client.UnikClient(host).Events().Recent()
+				if err != nil {
+					return errors.New("listing events failed", err)
+				}
+				for _, event := range events {
+					printEvent(event)
+				}
+				return nil
+			}
+			logrus.WithField("host", host).Info("following events")
+			stream, err := client.UnikClient(host).Events().Follow()
+			if err != nil {
+				return errors.New("connecting to event stream failed", err)
+			}
+			defer stream.Close()
+			for {
+				event, err := stream.Next()
+				if err != nil {
+					return errors.New("event stream closed", err)
+				}
+				printEvent(event)
+			}
+		}(); err != nil {
+			logrus.Errorf("failed getting events: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(eventsCmd)
+	eventsCmd.Flags().BoolVar(&follow, "follow", false, "<bool,optional> keep the connection open and print events as they happen")
+}
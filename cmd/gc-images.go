@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var gcStatus bool
+
+var gcImagesCmd = &cobra.Command{
+	Use:   "gc-images",
+	Short: "Delete unused images beyond the daemon's image_gc policy",
+	Long: `Runs the daemon's image garbage collector now. The collector deletes images
+that no instance uses, oldest first, while the image_gc policy in the daemon
+config is exceeded:
+	max_images        more images than this exist
+	max_age_days      the image is older than this
+	min_free_disk_gb  the unik home of the daemon host has less free space than this.
+	                  only images stored on the daemon host are deleted for it
+
+The daemon also runs the collector every 10 minutes if a policy is set.
+With --status, prints what the last run did instead.
+
+Example usage:
+	unik gc-images
+
+	last run 2017-03-01 12:00:00: deleted 2 images (1024 MB): oldImage, olderImage
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			var status *types.ImageGCStatus
+			var err error
+			if gcStatus {
+				status, err = client.UnikClient(host).Images().GCStatus()
+			} else {
+				logrus.WithField("host", host).Info("running image garbage collection")
+				status, err = client.UnikClient(host).Images().GC()
+			}
+			if err != nil {
+				return err
+			}
+			if status.LastRun.IsZero() {
+				fmt.Println("image garbage collection has not run yet")
+				return nil
+			}
+			fmt.Printf("last run %s: deleted %d images (%d MB)", status.LastRun.Format("2006-01-02 15:04:05"), len(status.ImagesDeleted), status.BytesFreed>>20)
+			if len(status.ImagesDeleted) > 0 {
+				fmt.Printf(": %s", strings.Join(status.ImagesDeleted, ", "))
+			}
+			fmt.Println()
+			if status.Error != "" {
+				fmt.Printf("errors: %s\n", status.Error)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("image garbage collection failed: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(gcImagesCmd)
+	gcImagesCmd.Flags().BoolVar(&gcStatus, "status", false, "<bool,optional> print what the last run of the collector did instead of running it")
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
 var imagesCmd = &cobra.Command{
@@ -15,7 +16,10 @@ var imagesCmd = &cobra.Command{
 	Short: "List available unikernel images",
 	Long: `Lists all available unikernel images across providers.
 Includes important information for running and managing instances,
-including bind mounts required at runtime.`,
+including bind mounts required at runtime.
+
+Use --tag key=value to list only images with that tag. When given more than once,
+only images with all of the tags are listed.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
@@ -25,11 +29,16 @@ including bind mounts required at runtime.`,
 				host = clientConfig.Host
 			}
 			logrus.WithField("host", host).Info("listing images")
-			images, err := client.UnikClient(host).Images().All()
+			imageTags, err := types.ParseTags(tags)
+			if err != nil {
+				return err
+			}
+			images, err := client.UnikClient(host).Images().List(imageTags)
 			if err != nil {
 				return errors.New("listing images failed", err)
 			}
 			printImages(images...)
+			printImageSummary(images)
 			return nil
 		}(); err != nil {
 			logrus.Errorf("failed listing images: %v", err)
@@ -40,4 +49,5 @@ including bind mounts required at runtime.`,
 
 func init() {
 	RootCmd.AddCommand(imagesCmd)
+	imagesCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> only list images with this tag, given as key=value")
 }
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var importFromDocker, importBase, importLanguage string
+
+var importImageCmd = &cobra.Command{
+	Use:   "import-image",
+	Short: "Convert a docker image into a unikernel image",
+	Long: `Makes a unikernel image of the application packaged in a docker (OCI) image. The
+docker image is pulled with the docker cli of this machine unless it is already
+present, and its files are built, with the compiler of --base and --language for
+--provider, into an image that runs the ENTRYPOINT and CMD of the docker image,
+with its ENV.
+
+By default the image is built with the osv native compiler, which runs the
+command line as the only process of the unikernel. Only statically linked (or
+position independent) linux binaries work this way: there is no shell, no
+dynamic loader from the docker image, no fork and no other processes, so images
+whose ENTRYPOINT is a shell script, or that start several processes, will not
+run. The docker images of languages unik compiles (go, node, java...) are better
+built with 'unik build docker-import' and the compiler of the language.
+
+Only linux images of a single platform can be imported; for a multi-platform
+image, give the digest of one of its platforms. The architecture of the unikernel
+image is the one of the docker image.
+
+Example usage:
+	unik import-image --from-docker myorg/static-server:1.0 --name static-server --provider qemu
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if importFromDocker == "" {
+				return errors.New("--from-docker must be set", nil)
+			}
+			if name == "" {
+				return errors.New("--name must be set", nil)
+			}
+			if provider == "" {
+				return errors.New("--provider must be set", nil)
+			}
+			if platforms := dockerImagePlatforms(importFromDocker); len(platforms) > 1 {
+				return errors.New(importFromDocker+" is a multi-platform image ("+strings.Join(platforms, ", ")+"), import the digest of one of its platforms", nil)
+			}
+			imageConfig, err := pullDockerImage(importFromDocker)
+			if err != nil {
+				return err
+			}
+			if imageConfig.Os != "linux" {
+				return errors.New(importFromDocker+" is an image for "+imageConfig.Os+", only linux images can be imported", nil)
+			}
+			arch, err := types.ParseArchitecture(imageConfig.Architecture)
+			if err != nil {
+				return errors.New("architecture of "+importFromDocker, err)
+			}
+			command := append(append([]string{}, imageConfig.Config.Entrypoint...), imageConfig.Config.Cmd...)
+			if len(command) == 0 {
+				return errors.New(importFromDocker+" has no ENTRYPOINT or CMD to run", nil)
+			}
+			rootfs, err := exportDockerImage(importFromDocker)
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(rootfs)
+			if importBase == "osv" {
+				if err := writeOsvRunConfig(rootfs, command, imageConfig.Config.Env); err != nil {
+					return err
+				}
+			}
+			logrus.WithFields(logrus.Fields{"docker-image": importFromDocker, "command": command, "arch": arch}).Info("importing docker image")
+			sourcePath = rootfs
+			base = importBase
+			lang = importLanguage
+			buildArch = string(arch)
+			return buildImage()
+		}(); err != nil {
+			logrus.Errorf("import failed: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// dockerImagePlatforms lists the platforms of image if the registry has it as a
+// multi-platform image (a manifest list or OCI index). images the registry does
+// not have, such as ones built locally, list none
+func dockerImagePlatforms(image string) []string {
+	out, err := exec.Command("docker", "manifest", "inspect", image).Output()
+	if err != nil {
+		logrus.WithError(err).Debugf("no manifest of %s in its registry, not checking its platforms", image)
+		return nil
+	}
+	var manifestList struct {
+		Manifests []struct {
+			Platform struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err := json.Unmarshal(out, &manifestList); err != nil {
+		return nil
+	}
+	platforms := []string{}
+	for _, manifest := range manifestList.Manifests {
+		platform := manifest.Platform.OS + "/" + manifest.Platform.Architecture
+		if manifest.Platform.Variant != "" {
+			platform += "/" + manifest.Platform.Variant
+		}
+		platforms = append(platforms, platform)
+	}
+	return platforms
+}
+
+// writeOsvRunConfig writes the meta/run.yaml the osv compilers boot with, running
+// command with env. a meta/run.yaml of the docker image is kept
+func writeOsvRunConfig(rootfs string, command, env []string) error {
+	runYaml := filepath.Join(rootfs, "meta", "run.yaml")
+	if _, err := os.Stat(runYaml); err == nil {
+		logrus.Infof("keeping the meta/run.yaml of the docker image")
+		return nil
+	}
+	quoted := []string{}
+	for _, arg := range command {
+		if strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	config := "config_set:\n   docker:\n      bootcmd: " + strconv.Quote(strings.Join(quoted, " ")) + "\n"
+	if len(env) > 0 {
+		config += "      env:\n"
+		for _, keyValue := range env {
+			pair := strings.SplitN(keyValue, "=", 2)
+			if len(pair) != 2 {
+				continue
+			}
+			config += fmt.Sprintf("         %s: %s\n", strconv.Quote(pair[0]), strconv.Quote(pair[1]))
+		}
+	}
+	config += "config_set_default: docker\n"
+	if err := os.MkdirAll(filepath.Dir(runYaml), 0755); err != nil {
+		return errors.New("creating meta folder", err)
+	}
+	if err := ioutil.WriteFile(runYaml, []byte(config), 0644); err != nil {
+		return errors.New("writing meta/run.yaml", err)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(importImageCmd)
+	importImageCmd.Flags().StringVar(&importFromDocker, "from-docker", "", "<string,required> docker (OCI) image to convert, e.g. myorg/myapp:1.2. pulled unless docker has it")
+	importImageCmd.Flags().StringVar(&name, "name", "", "<string,required> name to give the unikernel image. must be unique")
+	importImageCmd.Flags().StringVar(&provider, "provider", "", "<string,required> name of the target infrastructure to build the image for")
+	importImageCmd.Flags().StringVar(&importBase, "base", "osv", "<string,optional> unikernel base to build the image with")
+	importImageCmd.Flags().StringVar(&importLanguage, "language", "native", "<string,optional> language of the compiler to build the image with")
+	importImageCmd.Flags().BoolVar(&force, "force", false, "<bool, optional> force overwriting a previously existing image with this name")
+	importImageCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> annotate the image with a tag, given as key=value")
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var groupTimeout time.Duration
+var scaleTo int
+
+var stopGroupCmd = &cobra.Command{
+	Use:   "stop-group GROUP",
+	Short: "Stop the instances of a group",
+	Long: `Stops the running instances of a group (see run --group), as 'unik stop' does.
+An instance that fails to stop does not keep the others from being stopped; the
+failures are all reported at the end.
+
+Example usage:
+	unik instances stop-group web --timeout 30s
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give exactly one group", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "group": args[0], "timeout": groupTimeout}).Info("stopping group")
+			result, err := client.UnikClient(host).Instances().StopGroup(args[0], groupTimeout)
+			if err != nil {
+				return err
+			}
+			return printBatchResult("stopped instances", result)
+		}(); err != nil {
+			logrus.Errorf("failed stopping group: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var restartGroupCmd = &cobra.Command{
+	Use:   "restart-group GROUP",
+	Short: "Restart the instances of a group",
+	Long: `Restarts the running instances of a group (see run --group) one after the
+other, as 'unik restart' does, so the group is never down as a whole.
+
+Example usage:
+	unik instances restart-group web --timeout 30s
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give exactly one group", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "group": args[0], "timeout": groupTimeout}).Info("restarting group")
+			result, err := client.UnikClient(host).Instances().RestartGroup(args[0], groupTimeout)
+			if err != nil {
+				return err
+			}
+			return printBatchResult("restarted instances", result)
+		}(); err != nil {
+			logrus.Errorf("failed restarting group: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var scaleGroupCmd = &cobra.Command{
+	Use:   "scale-group GROUP --to COUNT",
+	Short: "Start or stop instances of a group to run a number of them",
+	Long: `Starts or stops instances of a group (see run --group) until --to of them are
+running. Stopped instances of the group are started first; if there are not
+enough, new instances are run like the oldest instance of the group (as
+'unik clone-instance' does, copying its volumes), named GROUP-1, GROUP-2...
+When too many are running, the newest are stopped. Instances are never deleted
+by scale-group.
+
+Example usage:
+	unik instances scale-group web --to 5
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give exactly one group", nil)
+			}
+			if !cmd.Flags().Changed("to") {
+				return errors.New("must specify --to", nil)
+			}
+			if scaleTo < 0 {
+				return errors.New("--to must not be negative", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "group": args[0], "to": scaleTo}).Info("scaling group")
+			result, err := client.UnikClient(host).Instances().ScaleGroup(args[0], scaleTo, groupTimeout)
+			if err != nil {
+				return err
+			}
+			for _, changed := range []struct {
+				verb  string
+				names []string
+			}{{"started", result.Started}, {"ran", result.Ran}, {"stopped", result.Stopped}} {
+				if len(changed.names) > 0 {
+					fmt.Printf("%s %d: %s\n", changed.verb, len(changed.names), strings.Join(changed.names, ", "))
+				}
+			}
+			fmt.Printf("%d instances of group %s running\n", result.Running, result.Group)
+			for name, failure := range result.Failed {
+				logrus.Errorf("%s: %s", name, failure)
+			}
+			if result.Running != scaleTo {
+				return errors.New(fmt.Sprintf("could not scale group %s to %d instances", result.Group, scaleTo), nil)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed scaling group: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	psCmd.AddCommand(stopGroupCmd)
+	stopGroupCmd.Flags().DurationVar(&groupTimeout, "timeout", 0, "<duration, optional> ask each instance to shut down and wait this long, e.g. 30s, before forcing it to stop")
+	psCmd.AddCommand(restartGroupCmd)
+	restartGroupCmd.Flags().DurationVar(&groupTimeout, "timeout", 0, "<duration, optional> ask each instance to shut down and wait this long, e.g. 30s, before forcing it to stop")
+	psCmd.AddCommand(scaleGroupCmd)
+	scaleGroupCmd.Flags().IntVar(&scaleTo, "to", 0, "<int,required> number of instances of the group to run")
+	scaleGroupCmd.Flags().DurationVar(&groupTimeout, "timeout", 0, "<duration, optional> ask each instance stopped to shut down and wait this long, e.g. 30s, before forcing it to stop")
+}
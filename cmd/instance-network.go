@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var instanceNetworkCmd = &cobra.Command{
+	Use:   "instance-network",
+	Short: "List the network interfaces of a unikernel instance",
+	Long: `Lists the network interfaces of an instance, with their mac address, ip addresses
+and mtu, as reported by the provider.
+
+Only the qemu, libvirt and aws providers can inspect the interfaces of an instance.
+qemu instances answer through the qemu guest agent if the unikernel runs one; for
+the others, qemu only knows the mac address of the nic, and the ip address unik
+found for the instance.
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik instance-network --instance myInstance
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			if outputFormat != "" && outputFormat != "json" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json", nil)
+			}
+			interfaces, err := client.UnikClient(host).Instances().Network(instanceName)
+			if err != nil {
+				return errors.New("retrieving network interfaces of instance "+instanceName, err)
+			}
+			if outputFormat == "json" {
+				data, err := json.Marshal(interfaces)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\n", string(data))
+				return nil
+			}
+			printNetworkInterfaces(interfaces...)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed listing network interfaces: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func printNetworkInterfaces(interfaces ...types.NetworkInterface) {
+	fmt.Printf("%-15.15s %-18.18s %-6.6s %s\n", "NAME", "MAC", "MTU", "IPADDRESSES")
+	for _, iface := range interfaces {
+		mtu := "-"
+		if iface.MTU > 0 {
+			mtu = strconv.Itoa(iface.MTU)
+		}
+		addresses := "-"
+		if len(iface.IPAddresses) > 0 {
+			addresses = strings.Join(iface.IPAddresses, ",")
+		}
+		fmt.Printf("%-15.15s %-18.18s %-6.6s %s\n", iface.Name, iface.MACAddress, mtu, addresses)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(instanceNetworkCmd)
+	instanceNetworkCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	instanceNetworkCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the interfaces in this format instead of a table. Available: json")
+}
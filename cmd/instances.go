@@ -1,21 +1,60 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
+var sortBy, sortOrder string
+var limit, offset int
+var filterImage, filterProvider, createdAfter, createdBefore string
+var filterStates []string
+var filterGroup string
+
 var psCmd = &cobra.Command{
 	Use:     "instances",
 	Aliases: []string{"ps"},
 	Short:   "List pending/running/stopped unik instances",
-	Long:    `Lists all unik-managed instances across providers.`,
+	Long: `Lists all unik-managed instances across providers.
+
+Instances are sorted by name. Use --sort and --order to sort them differently,
+and --limit and --offset to list one page of instances at a time.
+Use --tag key=value (any number of times) to list only instances with all
+of the given tags, and --image, --provider, --state, --created-after and
+--created-before to list only the instances of an image, of a provider,
+in one of the given states, or created in the given period. --group lists the
+instances run with run --group. Times are
+given as RFC 3339 times (2017-03-01T12:00:00Z), dates (2017-03-01), or
+durations before now (12h, 7d).
+
+Example usage:
+	unik instances --sort created --order desc --limit 20
+
+	# will list the 20 most recently created instances
+	unik instances --sort created --order desc --limit 20 --offset 20
+
+	# will list the 20 instances after those
+	unik instances --tag env=staging
+
+	# will list the instances tagged env=staging
+	unik instances --image myImage --state running --created-after 7d
+
+	# will list the running instances of myImage created in the last 7 days
+`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
+			instanceTags, err := types.ParseTags(tags)
+			if err != nil {
+				return err
+			}
 			if err := readClientConfig(); err != nil {
 				return err
 			}
@@ -23,11 +62,26 @@ var psCmd = &cobra.Command{
 				host = clientConfig.Host
 			}
 			logrus.WithField("host", host).Info("listing instances")
-			instances, err := client.UnikClient(host).Instances().All()
+			page := client.InstancePage{Sort: sortBy, Order: sortOrder, Limit: limit, Offset: offset}
+			filter := client.InstanceFilter{Tags: instanceTags, Image: filterImage, Provider: filterProvider, Group: filterGroup}
+			for _, state := range filterStates {
+				filter.States = append(filter.States, types.InstanceState(state))
+			}
+			if filter.CreatedAfter, err = parseTimeFlag(createdAfter); err != nil {
+				return errors.New("invalid --created-after", err)
+			}
+			if filter.CreatedBefore, err = parseTimeFlag(createdBefore); err != nil {
+				return errors.New("invalid --created-before", err)
+			}
+			instances, total, err := client.UnikClient(host).Instances().List(filter, page)
 			if err != nil {
 				return err
 			}
 			printInstances(instances...)
+			printInstanceSummary(instances)
+			if limit > 0 && len(instances) > 0 {
+				fmt.Printf("showing instances %d-%d of %d\n", offset+1, offset+len(instances), total)
+			}
 			return nil
 		}(); err != nil {
 			logrus.Errorf("failed listing instances: %v", err)
@@ -38,4 +92,33 @@ var psCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(psCmd)
+	psCmd.Flags().StringVar(&sortBy, "sort", "", "<string,optional> sort instances by this field. Available: name|created|state|provider (default name)")
+	psCmd.Flags().StringVar(&sortOrder, "order", "", "<string,optional> sort order. Available: asc|desc (default asc)")
+	psCmd.Flags().IntVar(&limit, "limit", 0, "<int,optional> list at most this many instances")
+	psCmd.Flags().IntVar(&offset, "offset", 0, "<int,optional> skip this many instances before listing")
+	psCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> only list instances with this tag, given as key=value")
+	psCmd.Flags().StringVar(&filterImage, "image", "", "<string,optional> only list instances of this image, given by name or id")
+	psCmd.Flags().StringVar(&filterProvider, "provider", "", "<string,optional> only list instances of this provider")
+	psCmd.Flags().StringSliceVar(&filterStates, "state", []string{}, "<string,repeated> only list instances in this state, e.g. running or stopped")
+	psCmd.Flags().StringVar(&createdAfter, "created-after", "", "<string,optional> only list instances created after this time, date or duration before now")
+	psCmd.Flags().StringVar(&filterGroup, "group", "", "<string,optional> only list instances of this group (run --group)")
+	psCmd.Flags().StringVar(&createdBefore, "created-before", "", "<string,optional> only list instances created before this time, date or duration before now")
+}
+
+// parseTimeFlag parses an RFC 3339 time, a date, or a duration before now. "" is the zero time
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	ago, err := types.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, errors.New(value+" is not a time (2017-03-01T12:00:00Z), date (2017-03-01) or duration (12h, 7d)", nil)
+	}
+	return time.Now().Add(-ago), nil
 }
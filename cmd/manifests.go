@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var manifestName string
+var manifestImages []string
+
+var manifestsCmd = &cobra.Command{
+	Use:   "manifests",
+	Short: "List image manifests",
+	Long: `Lists the manifests known to the daemon, along with the image each
+resolves to for every architecture.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithField("host", host).Info("listing manifests")
+			manifests, err := client.UnikClient(host).Manifests().All()
+			if err != nil {
+				return errors.New("listing manifests failed", err)
+			}
+			printManifests(manifests...)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed listing manifests: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var createManifestCmd = &cobra.Command{
+	Use:   "create-manifest",
+	Short: "Tie images built for different architectures together under one name",
+	Long: `Creates a manifest: a name that resolves to one of several images,
+depending on the cpu architecture. Each image is given as '--image arch=IMAGE',
+and must have been built for that architecture (see 'unik build --arch').
+
+When 'unik run' is given the name of a manifest instead of an image, the daemon
+runs the image of the manifest whose architecture matches its provider.
+
+Example usage:
+	unik create-manifest --name myApp --image amd64=myApp-amd64 --image arm64=myApp-arm64
+	unik run --instanceName myInstance --imageName myApp
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if manifestName == "" {
+				return errors.New("--name must be set", nil)
+			}
+			if len(manifestImages) == 0 {
+				return errors.New("at least one --image must be set", nil)
+			}
+			images := make(map[types.Architecture]string)
+			for _, image := range manifestImages {
+				pair := strings.SplitN(image, "=", 2)
+				if len(pair) != 2 || pair[1] == "" {
+					return errors.New("invalid --image "+image+", must be given as arch=IMAGE", nil)
+				}
+				arch, err := types.ParseArchitecture(pair[0])
+				if err != nil {
+					return err
+				}
+				images[arch] = pair[1]
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "name": manifestName, "images": images}).Info("creating manifest")
+			manifest, err := client.UnikClient(host).Manifests().Create(manifestName, images)
+			if err != nil {
+				return errors.New("creating manifest failed", err)
+			}
+			printManifests(manifest)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed creating manifest: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var deleteManifestCmd = &cobra.Command{
+	Use:   "delete-manifest",
+	Short: "Delete an image manifest",
+	Long:  `Deletes a manifest by name. The images it refers to are not deleted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if manifestName == "" {
+				return errors.New("--name must be set", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "name": manifestName}).Info("deleting manifest")
+			return client.UnikClient(host).Manifests().Delete(manifestName)
+		}(); err != nil {
+			logrus.Errorf("failed deleting manifest: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(manifestsCmd)
+	RootCmd.AddCommand(createManifestCmd)
+	RootCmd.AddCommand(deleteManifestCmd)
+	createManifestCmd.Flags().StringVar(&manifestName, "name", "", "<string,required> name of the manifest. must not be the name of an image")
+	createManifestCmd.Flags().StringSliceVar(&manifestImages, "image", []string{}, "<string,repeated> image for an architecture, given as arch=IMAGE")
+	deleteManifestCmd.Flags().StringVar(&manifestName, "name", "", "<string,required> name of the manifest to delete")
+}
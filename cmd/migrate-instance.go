@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var migrationDestination, destinationDaemon string
+var migrationTimeout time.Duration
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move a running unikernel instance to another host",
+	Long: `Live migrates a running instance to the host of another unik daemon, with the
+migration of qemu: the instance keeps running while its memory is copied, and
+only pauses for the last of it.
+
+The daemon asks the daemon of the destination host (HOST:3000 unless
+--destination-daemon is given) to run the instance with the same settings,
+waiting on the port of --destination for its state, then has qemu send the
+state there. Once the destination has taken over, the instance is gone from the
+source daemon; the instance is printed as the destination daemon knows it.
+
+Only qemu instances can be migrated. The destination daemon needs an image with
+the same name as the image of the instance (e.g. unik push and unik pull it
+there), and the port of --destination must be reachable from the source host.
+Volumes are not copied, so instances with volumes cannot be migrated.
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik migrate --instance myInstance --destination 10.0.0.2:4444
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			if migrationDestination == "" {
+				return errors.New("must specify --destination", nil)
+			}
+			if migrationTimeout < 0 {
+				return errors.New("--timeout must not be negative", nil)
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName, "destination": migrationDestination}).Info("migrating instance")
+			instance, err := client.UnikClient(host).Instances().Migrate(instanceName, migrationDestination, destinationDaemon, migrationTimeout)
+			if err != nil {
+				return err
+			}
+			printInstances(instance)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed migrating instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	migrateCmd.Flags().StringVar(&migrationDestination, "destination", "", "<string,required> HOST:PORT the destination host waits on for the state of the instance")
+	migrateCmd.Flags().StringVar(&destinationDaemon, "destination-daemon", "", "<string,optional> HOST:PORT of the unik daemon of the destination host. defaults to port 3000 of the destination host")
+	migrateCmd.Flags().DurationVar(&migrationTimeout, "timeout", 0, "<duration,optional> time the migration may take before it is cancelled. defaults to 10m")
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var toProvider string
+
+var migrateVolumeCmd = &cobra.Command{
+	Use:   "migrate-volume",
+	Short: "Copy a volume to another provider",
+	Long: `Copies the data of a volume to a new volume with the same name and size
+on another provider. The volume is exported to a temporary raw image on the
+daemon host, and the new volume is created (and for remote providers, uploaded)
+from that image. The daemon keeps a record of every completed migration.
+
+The original volume is left as it is. Delete it with 'unik delete-volume'
+once you no longer need it.
+
+Only volumes stored on the daemon host (qemu, ukvm, virtualbox and xen) can be
+migrated, and the volume must not be attached to an instance.
+
+You specify the volume by name or id.
+
+Example usage:
+	unik migrate-volume --volume myVolume --to-provider aws
+
+	# will create myVolume on aws with the data of myVolume on its current provider
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if volumeName == "" {
+				return errors.New("must specify --volume", nil)
+			}
+			if toProvider == "" {
+				return errors.New("must specify --to-provider", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "volume": volumeName, "to-provider": toProvider}).Info("migrating volume")
+			stream, err := client.UnikClient(host).Events().Follow()
+			if err != nil {
+				logrus.WithError(err).Warnf("could not follow events, migration progress will not be shown")
+			} else {
+				defer stream.Close()
+				go printMigrationProgress(stream, volumeName)
+			}
+			migration, err := client.UnikClient(host).Volumes().Migrate(volumeName, toProvider)
+			if err != nil {
+				return errors.New("migrating volume failed", err)
+			}
+			fmt.Printf("migrated volume %s from %s to %s (%d MB) in %s\n",
+				migration.VolumeName, migration.SourceProvider, migration.TargetProvider, migration.SizeMb,
+				migration.Completed.Sub(migration.Started).String())
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed migrating volume: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// printMigrationProgress prints the progress events of the migration of volume until the stream is closed
+func printMigrationProgress(stream *client.EventStream, volume string) {
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			return
+		}
+		if event.Type != types.EventType_VolumeMigrationProgress {
+			continue
+		}
+		data, err := json.Marshal(event.Payload)
+		if err != nil {
+			continue
+		}
+		var progress types.MigrationProgress
+		if err := json.Unmarshal(data, &progress); err != nil || (progress.VolumeName != volume && event.Id != volume) {
+			continue
+		}
+		elapsed := progress.Elapsed.Truncate(time.Second)
+		switch {
+		case progress.BytesDone > 0 && progress.BytesTotal > 0:
+			fmt.Printf("%s: %d/%d MB (%d%%), %s elapsed\n", progress.Stage,
+				progress.BytesDone>>20, progress.BytesTotal>>20, progress.BytesDone*100/progress.BytesTotal, elapsed)
+		case progress.BytesTotal > 0:
+			fmt.Printf("%s: %d MB, %s elapsed\n", progress.Stage, progress.BytesTotal>>20, elapsed)
+		default:
+			fmt.Printf("%s...\n", progress.Stage)
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(migrateVolumeCmd)
+	migrateVolumeCmd.Flags().StringVar(&volumeName, "volume", "", "<string,required> name or id of volume to migrate. unik accepts a prefix of the name or id")
+	migrateVolumeCmd.Flags().StringVar(&toProvider, "to-provider", "", "<string,required> name of the provider to copy the volume to")
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -12,10 +13,15 @@ import (
 	"github.com/emc-advanced-dev/unik/pkg/client"
 )
 
+var listPlatforms bool
+
 var providersCmd = &cobra.Command{
 	Use:   "providers",
 	Short: "List available unikernel providers",
-	Long:  `Returns a list of providers available to the targeted unik backend.`,
+	Long: `Returns a list of providers available to the targeted unik backend.
+
+With --platforms, lists the platform names 'unik build --platform' takes instead,
+with the provider each of them builds for.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
@@ -24,6 +30,21 @@ var providersCmd = &cobra.Command{
 			if host == "" {
 				host = clientConfig.Host
 			}
+			if listPlatforms {
+				platforms, err := client.UnikClient(host).Platforms()
+				if err != nil {
+					return errors.New("listing platforms failed", err)
+				}
+				names := []string{}
+				for platform := range platforms {
+					names = append(names, platform)
+				}
+				sort.Strings(names)
+				for _, platform := range names {
+					fmt.Printf("%s\t%s\n", platform, platforms[platform])
+				}
+				return nil
+			}
 			logrus.WithField("host", host).Info("listing providers")
 			providers, err := client.UnikClient(host).AvailableProviders()
 			if err != nil {
@@ -40,4 +61,5 @@ var providersCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(providersCmd)
+	providersCmd.Flags().BoolVar(&listPlatforms, "platforms", false, "<bool, optional> list the platforms of build --platform and their providers instead")
 }
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var pruneOlderThan string
+var pruneUntagged, pruneDryRun bool
+
+var pruneImagesCmd = &cobra.Command{
+	Use:   "prune-images",
+	Short: "Delete unused images that are old or untagged",
+	Long: `Deletes the images that no instance uses and that match all of the given criteria:
+	--older-than  the image was created longer ago than this, e.g. 30d or 12h
+	--untagged    the image has no tags
+
+Without criteria, every unused image is deleted. Use --dry-run to list the images
+that would be deleted without deleting them.
+
+Example usage:
+	unik prune-images --older-than 30d --untagged --dry-run
+
+	would delete 2 images (1024 MB): oldImage, olderImage
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			opts := types.PruneOptions{Untagged: pruneUntagged, DryRun: pruneDryRun}
+			if pruneOlderThan != "" {
+				olderThan, err := types.ParseDuration(pruneOlderThan)
+				if err != nil {
+					return err
+				}
+				opts.OlderThan = olderThan
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "older-than": pruneOlderThan, "untagged": pruneUntagged, "dry-run": pruneDryRun}).Info("pruning images")
+			result, err := client.UnikClient(host).Images().Prune(opts)
+			if err != nil {
+				return err
+			}
+			verb := "deleted"
+			if result.DryRun {
+				verb = "would delete"
+			}
+			fmt.Printf("%s %d images (%d MB)", verb, len(result.ImagesDeleted), result.BytesFreed>>20)
+			if len(result.ImagesDeleted) > 0 {
+				fmt.Printf(": %s", strings.Join(result.ImagesDeleted, ", "))
+			}
+			fmt.Println()
+			if result.Error != "" {
+				fmt.Printf("errors: %s\n", result.Error)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("pruning images failed: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(pruneImagesCmd)
+	pruneImagesCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "<duration,optional> only delete images created longer ago than this, e.g. 30d or 12h")
+	pruneImagesCmd.Flags().BoolVar(&pruneUntagged, "untagged", false, "<bool,optional> only delete images without tags")
+	pruneImagesCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "<bool,optional> print the images that would be deleted without deleting them")
+}
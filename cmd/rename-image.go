@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var newImageName string
+
+var renameImageCmd = &cobra.Command{
+	Use:   "rename-image",
+	Short: "Rename a unikernel image",
+	Long: `Changes the name of an image. The new name must not be taken by another image
+or a manifest. Instances run from the image, images built on it and manifests
+listing it are updated to the new name.
+
+On qemu, images are identified by their name, so the id of the image changes too.
+On aws, the Name tag of the ami is set to the new name. Other providers cannot
+rename images.
+
+You may specify the image by name or id.
+
+Example usage:
+	unik rename-image --image myImage --name myImage-v1
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if imageName == "" {
+				return errors.New("must specify --image", nil)
+			}
+			if newImageName == "" {
+				return errors.New("must specify --name", nil)
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "image": imageName, "name": newImageName}).Info("renaming image")
+			image, err := client.UnikClient(host).Images().Rename(imageName, newImageName)
+			if err != nil {
+				return err
+			}
+			printImages(image)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed renaming image: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(renameImageCmd)
+	renameImageCmd.Flags().StringVar(&imageName, "image", "", "<string,required> name or id of image. unik accepts a prefix of the name or id")
+	renameImageCmd.Flags().StringVar(&newImageName, "name", "", "<string,required> new name of the image")
+}
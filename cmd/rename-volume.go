@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var newVolumeName string
+
+var renameVolumeCmd = &cobra.Command{
+	Use:   "rename-volume",
+	Short: "Rename a volume",
+	Long: `Changes the name of a volume. The new name must not be taken by another volume,
+and the volume must be detached: some providers attach volumes by the path of
+their files, which has the name of the volume in it.
+
+On qemu, volumes are identified by their name, so the id of the volume changes too,
+and instances that were run with the volume mounted refer to its new id. On aws,
+the Name tag of the ebs volume is set to the new name. Other providers cannot
+rename volumes.
+
+You may specify the volume by name or id.
+
+Example usage:
+	unik rename-volume --volume myVolume --name myVolume-old
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if volumeName == "" {
+				return errors.New("must specify --volume", nil)
+			}
+			if newVolumeName == "" {
+				return errors.New("must specify --name", nil)
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "volume": volumeName, "name": newVolumeName}).Info("renaming volume")
+			volume, err := client.UnikClient(host).Volumes().Rename(volumeName, newVolumeName)
+			if err != nil {
+				return err
+			}
+			printVolumes(volume)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed renaming volume: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(renameVolumeCmd)
+	renameVolumeCmd.Flags().StringVar(&volumeName, "volume", "", "<string,required> name or id of volume. unik accepts a prefix of the name or id")
+	renameVolumeCmd.Flags().StringVar(&newVolumeName, "name", "", "<string,required> new name of the volume")
+}
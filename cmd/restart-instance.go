@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var restartTimeout time.Duration
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart a running unikernel instance",
+	Long: `Stops an instance and starts it again, keeping its id and configuration.
+You may specify the instance by name or id.
+
+The instance is first asked to shut down, and is only forced to stop if it is
+still running when --timeout runs out, as with unik stop --timeout. qemu
+instances are rebooted in place: qemu keeps running (qemu 6.0 or later is
+needed to wait for the instance to shut down), so their id, the qemu pid, does
+not change.
+
+Example usage:
+	unik restart --instance myInstance --timeout 30s
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			if restartTimeout < 0 {
+				return errors.New("--timeout must not be negative", nil)
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName, "timeout": restartTimeout}).Info("restarting instance")
+			instance, err := client.UnikClient(host).Instances().Restart(instanceName, restartTimeout)
+			if err != nil {
+				return err
+			}
+			printInstances(instance)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed restarting instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(restartCmd)
+	restartCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	restartCmd.Flags().DurationVar(&restartTimeout, "timeout", 30*time.Second, "<duration, optional> time the instance gets to shut down before it is forced to stop. 0 stops it right away")
+}
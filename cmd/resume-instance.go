@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume a suspended unikernel instance",
+	Long: `Starts an instance suspended with unik suspend from its snapshot, the one it was
+suspended to unless --snapshot-name names another snapshot of its disks.
+You may specify the instance by name or id.
+
+qemu is started again with the arguments the instance was run with, so the id of
+the instance, the qemu pid, changes.
+
+Example usage:
+	unik resume --instance myInstance
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName, "snapshot-name": snapshotName}).Info("resuming instance")
+			instance, err := client.UnikClient(host).Instances().Resume(instanceName, snapshotName)
+			if err != nil {
+				return err
+			}
+			printInstances(instance)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed resuming instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(resumeCmd)
+	resumeCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	resumeCmd.Flags().StringVar(&snapshotName, "snapshot-name", "", "<string,optional> name of the snapshot to resume the instance from. defaults to the one it was suspended to")
+}
@@ -2,21 +2,34 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
 	"github.com/emc-advanced-dev/unik/pkg/config"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/types"
 	"sort"
 )
 
 var clientConfigFile, hubConfigFile, host string
 var port int
+var noRetry bool
+var useGrpc bool
+
+// port of the gRPC api --grpc connects to, if grpc_host is not set
+const defaultGrpcPort = 3001
 
 var RootCmd = &cobra.Command{
 	Use:   "unik",
@@ -35,6 +48,8 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&clientConfigFile, "client-config", os.Getenv("HOME")+"/.unik/client-config.yaml", "client config file")
 	RootCmd.PersistentFlags().StringVar(&hubConfigFile, "hub-config", os.Getenv("HOME")+"/.unik/hub-config.yaml", "hub config file")
 	RootCmd.PersistentFlags().StringVar(&host, "host", "", "<string, optional>: host/ip address of the host running the unik daemon")
+	RootCmd.PersistentFlags().BoolVar(&useGrpc, "grpc", false, "<bool, optional>: make the calls the gRPC api has to it (grpc_host of the client config) instead of the REST api. the daemon must be run with --grpc-addr")
+	RootCmd.PersistentFlags().BoolVar(&noRetry, "no-retry", false, "<bool, optional>: fail right away if the daemon can't be reached instead of retrying with backoff")
 	targetCmd.Flags().IntVar(&port, "port", 3000, "<int, optional>: port the daemon is running on (default: 3000)")
 }
 
@@ -47,14 +62,40 @@ func readClientConfig() error {
 Try setting your config with 'unik target --host HOST_URL'`)
 		return err
 	}
-	data = bytes.Replace(data, []byte("\n"), []byte{}, -1)
-	if err := yaml.Unmarshal(data, &clientConfig); err != nil {
+	if err := yaml.Unmarshal(bytes.TrimSpace(data), &clientConfig); err != nil {
 		logrus.WithError(err).Errorf("failed to parse client configuration yaml at " + clientConfigFile + `
 Please ensure config file contains valid yaml.'\n
 Try setting your config with 'unik target --host HOST_URL'`)
 		return err
 	}
-	return nil
+	if noRetry || clientConfig.NoRetry {
+		client.SetRetryTransport(nil)
+	}
+	client.SetUser(clientConfig.User)
+	client.SetToken(clientConfig.Token)
+	if useGrpc {
+		if err := client.UseGrpc(grpcHost()); err != nil {
+			return err
+		}
+	}
+	return client.SetTLS(clientConfig.TLSCert, clientConfig.TLSKey, clientConfig.TLSCA)
+}
+
+// grpcHost is the address --grpc connects to: grpc_host of the client config, or
+// the host of the daemon with the default port of the gRPC api
+func grpcHost() string {
+	if clientConfig.GrpcHost != "" {
+		return clientConfig.GrpcHost
+	}
+	daemonHost := host
+	if daemonHost == "" {
+		daemonHost = clientConfig.Host
+	}
+	daemonHost = strings.TrimPrefix(strings.TrimPrefix(daemonHost, "http://"), "https://")
+	if hostname, _, err := net.SplitHostPort(daemonHost); err == nil {
+		daemonHost = hostname
+	}
+	return net.JoinHostPort(daemonHost, strconv.Itoa(defaultGrpcPort))
 }
 
 type imageSlice []*types.Image
@@ -137,6 +178,59 @@ func printUserImage(image *types.UserImage) {
 	}
 }
 
+// printImageDetails prints every field of an image that is relevant to users, one per line
+func printImageDetails(image *types.Image) {
+	printDetail("Id", image.Id)
+	printDetail("Name", image.Name)
+	printDetail("Provider", string(image.Infrastructure))
+	printDetail("Size", fmt.Sprintf("%d MB", image.SizeMb))
+	printDetail("Created", image.Created.String())
+	printDetail("Compiler", image.RunSpec.Compiler)
+	if image.BaseImage != "" {
+		printDetail("Base Image", image.BaseImage)
+	}
+	printDetail("Image Format", string(image.StageSpec.ImageFormat))
+	if image.StageSpec.XenVirtualizationType != "" {
+		printDetail("Virtualization", string(image.StageSpec.XenVirtualizationType))
+	}
+	defaultMemory := image.RunSpec.DefaultInstanceMemory
+	if image.DefaultMemoryMB > 0 {
+		defaultMemory = image.DefaultMemoryMB
+	}
+	printDetail("Default Memory", fmt.Sprintf("%d MB", defaultMemory))
+	if image.DefaultVCPUs > 0 {
+		printDetail("Default vCPUs", fmt.Sprintf("%d", image.DefaultVCPUs))
+	}
+	if image.NetworkMode != "" {
+		printDetail("Network Mode", string(image.NetworkMode))
+	}
+	printDetail("Tags", formatTags(image.Tags))
+	volumes := []string{}
+	for _, deviceMapping := range image.RunSpec.DeviceMappings {
+		if deviceMapping.MountPoint != "/" {
+			volumes = append(volumes, deviceMapping.MountPoint+" ("+deviceMapping.DeviceName+")")
+		}
+	}
+	printDetail("Volumes", strings.Join(volumes, ", "))
+}
+
+func printDetail(key, value string) {
+	if value == "" {
+		value = "-"
+	}
+	fmt.Printf("%-16s %s\n", key+":", value)
+}
+
+// formatTags returns tags as key=value pairs sorted by key
+func formatTags(tags map[string]string) string {
+	pairs := []string{}
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
 type instanceSlice []*types.Instance
 
 func (p instanceSlice) Len() int           { return len(p) }
@@ -176,3 +270,199 @@ func printVolume(volume *types.Volume) {
 	fmt.Printf("%-15.15s %-15.15s %-14.14s %-30.30s %-20.20v %-12.12d\n",
 		volume.Name, volume.Id, volume.Infrastructure, volume.Created.String(), volume.Attachment, volume.SizeMb)
 }
+
+// instanceSummary counts listed instances by state, for the footer of the
+// instance listing
+type instanceSummary struct {
+	Total   int
+	ByState map[types.InstanceState]int
+}
+
+// the order states are given in by the footer; other states follow, sorted
+var summaryStateOrder = []types.InstanceState{
+	types.InstanceState_Running,
+	types.InstanceState_Pending,
+	types.InstanceState_Paused,
+	types.InstanceState_Suspended,
+	types.InstanceState_Stopped,
+	types.InstanceState_Error,
+	types.InstanceState_Unknown,
+	types.InstanceState_Terminated,
+	types.InstanceState_Expired,
+}
+
+func summarizeInstances(instances []*types.Instance) instanceSummary {
+	summary := instanceSummary{Total: len(instances), ByState: make(map[types.InstanceState]int)}
+	for _, instance := range instances {
+		summary.ByState[instance.State]++
+	}
+	return summary
+}
+
+// String is e.g. "Total: 12 instances (3 running, 2 stopped, 7 terminated)"
+func (s instanceSummary) String() string {
+	counts := []string{}
+	known := make(map[types.InstanceState]bool)
+	for _, state := range summaryStateOrder {
+		known[state] = true
+		if s.ByState[state] > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", s.ByState[state], state))
+		}
+	}
+	others := []string{}
+	for state := range s.ByState {
+		if !known[state] {
+			others = append(others, string(state))
+		}
+	}
+	sort.Strings(others)
+	for _, state := range others {
+		counts = append(counts, fmt.Sprintf("%d %s", s.ByState[types.InstanceState(state)], state))
+	}
+	return formatSummary(s.Total, "instance", counts)
+}
+
+func printInstanceSummary(instances []*types.Instance) {
+	fmt.Println(summarizeInstances(instances))
+}
+
+func printVolumeSummary(volumes []*types.Volume) {
+	attached := 0
+	var sizeMb int64
+	for _, volume := range volumes {
+		if volume.Attachment != "" {
+			attached++
+		}
+		sizeMb += volume.SizeMb
+	}
+	counts := []string{}
+	if attached > 0 {
+		counts = append(counts, fmt.Sprintf("%d attached", attached))
+	}
+	if len(volumes) > attached {
+		counts = append(counts, fmt.Sprintf("%d unattached", len(volumes)-attached))
+	}
+	fmt.Printf("%s, %d MB\n", formatSummary(len(volumes), "volume", counts), sizeMb)
+}
+
+func printImageSummary(images []*types.Image) {
+	byInfrastructure := make(map[types.Infrastructure]int)
+	var sizeMb int64
+	for _, image := range images {
+		byInfrastructure[image.Infrastructure]++
+		sizeMb += image.SizeMb
+	}
+	infrastructures := []string{}
+	for infrastructure := range byInfrastructure {
+		infrastructures = append(infrastructures, string(infrastructure))
+	}
+	sort.Strings(infrastructures)
+	counts := []string{}
+	for _, infrastructure := range infrastructures {
+		counts = append(counts, fmt.Sprintf("%d %s", byInfrastructure[types.Infrastructure(infrastructure)], infrastructure))
+	}
+	fmt.Printf("%s, %d MB\n", formatSummary(len(images), "image", counts), sizeMb)
+}
+
+func formatSummary(total int, noun string, counts []string) string {
+	if total != 1 {
+		noun += "s"
+	}
+	summary := fmt.Sprintf("Total: %d %s", total, noun)
+	if len(counts) > 0 {
+		summary += " (" + strings.Join(counts, ", ") + ")"
+	}
+	return summary
+}
+
+func printManifests(manifests ...*types.ImageManifest) {
+	fmt.Printf("%-20.20s %-60.60s %-30.30s\n",
+		"NAME", "IMAGES", "CREATED")
+	for _, manifest := range manifests {
+		printManifest(manifest)
+	}
+}
+
+func printManifest(manifest *types.ImageManifest) {
+	images := []string{}
+	for arch, imageId := range manifest.Images {
+		images = append(images, string(arch)+"="+imageId)
+	}
+	sort.Strings(images)
+	fmt.Printf("%-20.20s %-60.60s %-30.30s\n",
+		manifest.Name, strings.Join(images, ","), manifest.Created.String())
+}
+
+func printWebhooks(webhook ...*types.Webhook) {
+	fmt.Printf("%-36.36s %-40.40s %-30.30s %-30.30s\n",
+		"ID", "URL", "EVENTS", "CREATED")
+	for _, webhook := range webhook {
+		printWebhook(webhook)
+	}
+}
+
+func printWebhook(webhook *types.Webhook) {
+	events := "all"
+	if len(webhook.Events) > 0 {
+		events = strings.Join(webhook.Events, ",")
+	}
+	fmt.Printf("%-36.36s %-40.40s %-30.30s %-30.30s\n",
+		webhook.Id, webhook.URL, events, webhook.Created.String())
+}
+
+func printImageDiff(diff *types.ImageDiff) {
+	for _, change := range diff.Changes {
+		switch change.Change {
+		case unikos.FileAdded:
+			fmt.Printf("+ %s (%s)\n", change.Path, formatSizeDelta(change.SizeDelta))
+		case unikos.FileRemoved:
+			fmt.Printf("- %s (%s)\n", change.Path, formatSizeDelta(change.SizeDelta))
+		default:
+			fmt.Printf("M %s (%s) sha256 %.12s -> %.12s\n", change.Path, formatSizeDelta(change.SizeDelta), change.OldSha256, change.NewSha256)
+		}
+	}
+	fmt.Printf("%d files changed between %s and %s, %s\n", len(diff.Changes), diff.From, diff.To, formatSizeDelta(diff.SizeDelta))
+}
+
+func formatSizeDelta(bytes int64) string {
+	if bytes < 0 {
+		return fmt.Sprintf("-%d bytes", -bytes)
+	}
+	return fmt.Sprintf("+%d bytes", bytes)
+}
+
+func printEvent(event *types.Event) {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		payload = []byte(fmt.Sprintf("%v", event.Payload))
+	}
+	fmt.Printf("%-30.30s %-18.18s %-36.36s %s\n",
+		event.Timestamp.Format(time.RFC3339), event.Type, event.Id, string(payload))
+}
+
+// all selects every resource (of --provider) for stop, delete-instance,
+// delete-volume and delete-image
+var all bool
+
+// printBatchResult prints what a batch operation did, and fails if it failed
+// for any of the resources
+func printBatchResult(verb string, result *types.BatchResult) error {
+	sort.Strings(result.Succeeded)
+	fmt.Printf("%s %d", verb, len(result.Succeeded))
+	if len(result.Succeeded) > 0 {
+		fmt.Printf(": %s", strings.Join(result.Succeeded, ", "))
+	}
+	fmt.Println()
+	if len(result.Failed) == 0 {
+		return nil
+	}
+	names := []string{}
+	for name := range result.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		logrus.Errorf("%s: %s", name, result.Failed[name])
+	}
+	return errors.New(fmt.Sprintf("failed for %d: %s", len(names), strings.Join(names, ", ")), nil)
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -13,11 +15,24 @@ import (
 
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/client"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
 var instanceName, imageName string
 var volumes, envPairs []string
-var instanceMemory, debugPort int
+var instanceMemory, instanceVCPUs, debugPort int
+var restartMode string
+var maxRestarts, restartBackoff int
+var ttl time.Duration
+var portMappings []string
+var runCmdline, cmdlineMode, runNetworkMode, runVolumeMode string
+var userDataFile, metaDataFile string
+var ipv6Address, ipv6Gateway string
+var ipv6PrefixLength int
+var staticIP, gateway string
+var dnsServers []string
+var instanceGroup string
 
 var runCmd = &cobra.Command{
 	Use:   "run",
@@ -33,8 +48,12 @@ This means that if the image was compiled with two mount points, /data1 and /dat
 'unik run' requires 2 available volumes to be attached to the instance at runtime, which
 must be specified with the flags --vol SOME_VOLUME_NAME:/data1 --vol ANOTHER_VOLUME_NAME:/data2
 If no mount points are required for the image, volumes cannot be attached.
+Add :ro to attach a volume read-only (--vol SOME_VOLUME_NAME:/data1:ro). Only qemu
+supports read-only volumes.
 
 environment variables can be set at runtime through the use of the -env flag.
+on qemu (for kernels booted without a bootloader) and ukvm, they are appended to the
+kernel command line as env=KEY:VALUE, so names and values cannot contain whitespace.
 
 Example usage:
 	unik run --instanceName newInstance --imageName myImage --vol myVol:/mount1 --vol yourVol:/mount2 --env foo=bar --env another=one --instanceMemory 1234
@@ -48,6 +67,65 @@ Example usage:
 	# instance will get 1234 MB of memory
 
 	# note that run must take exactly one --vol argument for each mount point defined in the image specification
+
+instances can be restarted automatically by the daemon if they stop or crash:
+	unik run --instanceName newInstance --imageName myImage --restart always --max-restarts 5 --restart-backoff 10
+
+	# if the instance goes down, the daemon will wait 10 seconds and then restart it,
+	# giving up after 5 restarts. 'on-failure' only restarts instances that crashed,
+	# 'always' also restarts instances that stopped (unless stopped with 'unik stop')
+
+instances can be tagged with any number of key=value pairs, e.g. to find them later
+with 'unik instances --tag'. on aws, the tags are also set on the ec2 instance:
+	unik run --instanceName newInstance --imageName myImage --tag env=staging --tag team=backend
+
+on qemu, ports of the daemon host can be forwarded to the instance:
+	unik run --instanceName webServer --imageName myImage --port 8080:80 --port 5353:53/udp
+
+	# connections to port 8080 of the daemon host will reach port 80 of the instance
+
+the image's kernel command line can be changed without rebuilding it, on qemu
+(for kernels booted without a bootloader, other than rump) and ukvm:
+	unik run --instanceName newInstance --imageName myImage --cmdline-mode template --cmdline 'ip={{.IpAddress}} name={{.InstanceName}}'
+
+	# --cmdline-mode append (the default) adds --cmdline after the image's command line,
+	# replace boots with it instead, and template renders it first with the instance's
+	# name, ip address, mount points and env
+
+on qemu and virtualbox, instances can be given more than one virtual cpu:
+	unik run --instanceName newInstance --imageName myImage --vcpus 4
+
+	# without --vcpus, the instance gets the vcpus the image was built with (build --vcpus), or one
+
+on qemu and virtualbox, the network mode sets how the instance is attached to the network:
+	unik run --instanceName newInstance --imageName myImage --network-mode bridge
+
+	# nat (the default) puts the instance behind the hypervisor's nat; ports are reached with --port.
+	# host attaches it to a network shared with the daemon host (a tap device on qemu, the
+	# host-only adapter on virtualbox), and bridge bridges it onto the network of the host.
+	# without --network-mode, the mode the image was built with (build --network-mode) is used
+
+on qemu and libvirt, instances can be configured the way cloud-init configures vms:
+	unik run --instanceName newInstance --imageName myImage --user-data ./user-data.yaml
+
+	# the files are attached to the instance as a cloud-init 'nocloud' iso (volume id cidata),
+	# which the unikernel can read from its cd-rom drive (/dev/sr0). without --meta-data, the
+	# instance gets meta-data with its name as instance-id and local-hostname
+
+ephemeral instances can be given a time to live, after which the daemon deletes them:
+	unik run --instanceName ciRunner --imageName myImage --ttl 30m
+
+instances of the same image can be run as a group, to be listed, stopped,
+restarted and scaled together (see 'unik instances stop-group', 'restart-group'
+and 'scale-group'):
+	unik run --instanceName web-1 --imageName myImage --group web
+
+on qemu, folder volumes (create-volume --volume-mode virtiofs|9p) are shared with the
+instance in the mode they were created with, unless --volume-mode says otherwise:
+	unik run --instanceName newInstance --imageName myOsvImage --vol shared:/data --volume-mode 9p
+
+	# qemu exports the folder of volume shared itself, on a virtio-9p device tagged shared.
+	# 9p needs no virtiofsd on the daemon host, and more unikernels (osv, rump) can mount it
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
@@ -57,6 +135,11 @@ Example usage:
 			if imageName == "" {
 				return errors.New("--imageName must be set", nil)
 			}
+			if instanceVCPUs != 0 {
+				if err := types.ValidateVCPUs(instanceVCPUs); err != nil {
+					return err
+				}
+			}
 			if err := readClientConfig(); err != nil {
 				return err
 			}
@@ -65,14 +148,103 @@ Example usage:
 			}
 
 			mountPointsToVols := make(map[string]string)
+			var readOnlyMounts []string
 			for _, vol := range volumes {
-				pair := strings.SplitN(vol, ":", 2)
-				if len(pair) != 2 {
+				pair := strings.SplitN(vol, ":", 3)
+				if len(pair) < 2 || (len(pair) == 3 && pair[2] != "ro") {
 					return errors.New(fmt.Sprintf("invalid format for vol flag: %s", vol), nil)
 				}
 				volId := pair[0]
 				mnt := pair[1]
 				mountPointsToVols[mnt] = volId
+				if len(pair) == 3 {
+					readOnlyMounts = append(readOnlyMounts, mnt)
+				}
+			}
+
+			var restartPolicy *types.RestartPolicy
+			if restartMode != "" {
+				mode, err := types.ParseRestartMode(restartMode)
+				if err != nil {
+					return err
+				}
+				restartPolicy = &types.RestartPolicy{
+					Mode:           mode,
+					MaxRestarts:    maxRestarts,
+					BackoffSeconds: restartBackoff,
+				}
+			}
+
+			ports := []types.PortMapping{}
+			for _, portMapping := range portMappings {
+				mapping, err := types.ParsePortMapping(portMapping)
+				if err != nil {
+					return err
+				}
+				ports = append(ports, mapping)
+			}
+			if err := types.ValidatePortMappings(ports); err != nil {
+				return err
+			}
+
+			networkMode, err := types.ParseNetworkMode(runNetworkMode)
+			if err != nil {
+				return err
+			}
+			var volumeMode types.VolumeMode
+			if runVolumeMode != "" {
+				if volumeMode, err = types.ParseVolumeMode(strings.ToLower(runVolumeMode)); err != nil {
+					return err
+				}
+				if !volumeMode.IsFolder() {
+					return errors.New("--volume-mode only applies to folder volumes, and must be "+string(types.VolumeMode_VirtioFS)+" or "+string(types.VolumeMode_9P), nil)
+				}
+			}
+
+			mode, err := unikos.ParseCmdlineMode(cmdlineMode)
+			if err != nil {
+				return err
+			}
+			if mode == unikos.CmdlineGoTemplate {
+				if err := unikos.ValidateCmdlineTemplate(runCmdline); err != nil {
+					return err
+				}
+			}
+
+			var userData, metaData string
+			if userDataFile != "" {
+				data, err := ioutil.ReadFile(userDataFile)
+				if err != nil {
+					return errors.New("reading --user-data file", err)
+				}
+				userData = string(data)
+			}
+			if metaDataFile != "" {
+				data, err := ioutil.ReadFile(metaDataFile)
+				if err != nil {
+					return errors.New("reading --meta-data file", err)
+				}
+				metaData = string(data)
+			}
+
+			var ipv6 *types.IPv6Config
+			if ipv6Address != "" {
+				ipv6 = &types.IPv6Config{Address: ipv6Address, PrefixLength: ipv6PrefixLength, Gateway: ipv6Gateway}
+				if err := ipv6.Validate(); err != nil {
+					return err
+				}
+			} else if ipv6Gateway != "" || cmd.Flags().Changed("ipv6-prefix-length") {
+				return errors.New("--ipv6-gateway and --ipv6-prefix-length can only be used with --ipv6-address", nil)
+			}
+
+			var staticIPConfig *types.StaticIPConfig
+			if staticIP != "" {
+				staticIPConfig = &types.StaticIPConfig{Address: staticIP, Gateway: gateway, DNS: dnsServers}
+				if err := staticIPConfig.Validate(); err != nil {
+					return err
+				}
+			} else if gateway != "" || len(dnsServers) > 0 {
+				return errors.New("--gateway and --dns can only be used with --static-ip", nil)
 			}
 
 			env := make(map[string]string)
@@ -86,14 +258,55 @@ Example usage:
 				env[key] = val
 			}
 
+			instanceTags, err := types.ParseTags(tags)
+			if err != nil {
+				return err
+			}
+
 			logrus.WithFields(logrus.Fields{
 				"instanceName": instanceName,
 				"imageName":    imageName,
 				"env":          env,
 				"mounts":       mountPointsToVols,
 				"host":         host,
+				"restart":      restartPolicy,
+				"ttl":          ttl,
+				"tags":         instanceTags,
+				"ports":        ports,
+				"cmdline":      runCmdline,
+				"cmdline-mode": mode,
+				"network-mode": networkMode,
+				"volume-mode":  volumeMode,
+				"user-data":    userDataFile,
+				"meta-data":    metaDataFile,
+				"ipv6":         ipv6,
+				"static-ip":    staticIPConfig,
+				"group":        instanceGroup,
 			}).Infof("running unik run")
-			instance, err := client.UnikClient(host).Instances().Run(instanceName, imageName, mountPointsToVols, env, instanceMemory, noCleanup, debugMode)
+			instance, err := client.UnikClient(host).Instances().Run(types.RunInstanceRequest{
+				InstanceName:   instanceName,
+				ImageName:      imageName,
+				Mounts:         mountPointsToVols,
+				Env:            env,
+				MemoryMb:       instanceMemory,
+				VCPUs:          instanceVCPUs,
+				NetworkMode:    networkMode,
+				NoCleanup:      noCleanup,
+				DebugMode:      debugMode,
+				RestartPolicy:  restartPolicy,
+				Ttl:            ttl,
+				Tags:           instanceTags,
+				Ports:          ports,
+				Cmdline:        runCmdline,
+				CmdlineMode:    mode,
+				ReadOnlyMounts: readOnlyMounts,
+				UserData:       userData,
+				MetaData:       metaData,
+				IPv6:           ipv6,
+				StaticIP:       staticIPConfig,
+				Group:          instanceGroup,
+				VolumeMode:     volumeMode,
+			})
 			if err != nil {
 				return errors.New("running image failed: %v", err)
 			}
@@ -118,11 +331,31 @@ func init() {
 	runCmd.Flags().StringSliceVar(&volumes, "vol", []string{}, `<string,repeated> each --vol flag specifies one volume id and the corresponding mount point to attach
 	to the instance at boot time. volumes must be attached to the instance for each mount point expected by the image.
 	run 'unik image <image_name>' to see the mount points required for the image.
-	specified in the format 'volume_id:mount_point'`)
-	runCmd.Flags().IntVar(&instanceMemory, "instanceMemory", 0, "<int, optional> amount of memory (in MB) to assign to the instance. if none is given, the provider default will be used")
+	specified in the format 'volume_id:mount_point', or 'volume_id:mount_point:ro' to attach the volume read-only`)
+	runCmd.Flags().IntVar(&instanceMemory, "instanceMemory", 0, "<int, optional> amount of memory (in MB) to assign to the instance. if none is given, the memory the image was built with (build --memory) or the provider default will be used")
+	runCmd.Flags().IntVar(&instanceVCPUs, "vcpus", 0, "<int, optional> number of virtual cpus (1-256) to assign to the instance. overrides the vcpus the image was built with (build --vcpus). supported on qemu and virtualbox")
+	runCmd.Flags().StringVar(&runNetworkMode, "network-mode", "", "<string, optional> how to attach the instance to the network: host|nat|bridge. overrides the mode the image was built with (build --network-mode). supported on qemu and virtualbox")
 	runCmd.Flags().BoolVar(&noCleanup, "no-cleanup", false, "<bool, optional> for debugging; do not clean up artifacts for instances that fail to launch")
 	runCmd.Flags().BoolVar(&debugMode, "debug-mode", false, "<bool, optional> runs the instance in Debug mode so GDB can be attached. Currently only supported on QEMU provider")
 	runCmd.Flags().IntVar(&debugPort, "debug-port", 3001, "<int, optional> target port for debugger tcp connections. used in conjunction with --debug-mode")
+	runCmd.Flags().StringVar(&restartMode, "restart", "", "<string, optional> restart policy for the instance: always|on-failure|never. defaults to never")
+	runCmd.Flags().IntVar(&maxRestarts, "max-restarts", 0, "<int, optional> maximum number of times the daemon will restart the instance. 0 means no limit. used in conjunction with --restart")
+	runCmd.Flags().IntVar(&restartBackoff, "restart-backoff", 0, "<int, optional> number of seconds to wait before restarting the instance. used in conjunction with --restart")
+	runCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> tag the instance, given as key=value")
+	runCmd.Flags().StringSliceVar(&portMappings, "port", []string{}, "<string,repeated> forward a port of the daemon host to the instance, given as host:guest[/tcp|udp]. only supported on qemu")
+	runCmd.Flags().StringVar(&runCmdline, "cmdline", "", "<string, optional> kernel command line for the instance, combined with the image's as --cmdline-mode says. only supported on qemu and ukvm")
+	runCmd.Flags().StringVar(&cmdlineMode, "cmdline-mode", "", "<string, optional> how --cmdline is combined with the image's command line: append|replace|template. defaults to append")
+	runCmd.Flags().StringVar(&userDataFile, "user-data", "", "<string, optional> file to give the instance as cloud-init user-data, on a nocloud iso attached as a cd-rom. only supported on qemu and libvirt")
+	runCmd.Flags().StringVar(&metaDataFile, "meta-data", "", "<string, optional> file to give the instance as cloud-init meta-data, with --user-data. defaults to the instance name as instance-id and local-hostname")
+	runCmd.Flags().StringVar(&ipv6Address, "ipv6-address", "", "<string, optional> static ipv6 address to give the instance. in nat mode, qemu advertises its network to the instance, which still has to configure the address, e.g. from a --cmdline template. only supported on qemu")
+	runCmd.Flags().IntVar(&ipv6PrefixLength, "ipv6-prefix-length", types.DefaultIPv6PrefixLength, "<int, optional> prefix length of the network of --ipv6-address")
+	runCmd.Flags().StringVar(&ipv6Gateway, "ipv6-gateway", "", "<string, optional> ipv6 address of the router of the instance, in the network of --ipv6-address. in nat mode, qemu answers on it")
+	runCmd.Flags().StringVar(&staticIP, "static-ip", "", "<string, optional> fixed ipv4 address for the instance instead of one leased by dhcp, given as address/prefix-length, e.g. 192.168.1.100/24. passed on the kernel command line as ip=. only supported on qemu and aws")
+	runCmd.Flags().StringVar(&gateway, "gateway", "", "<string, optional> ipv4 address of the router of the instance, in the network of --static-ip")
+	runCmd.Flags().StringSliceVar(&dnsServers, "dns", []string{}, "<string,repeated> dns server for the instance, used with --static-ip")
+	runCmd.Flags().StringVar(&runVolumeMode, "volume-mode", "", "<string, optional> share the folder volumes of the instance as virtiofs or 9p, instead of in the mode each was created with. only supported on qemu")
+	runCmd.Flags().StringVar(&instanceGroup, "group", "", "<string, optional> put the instance in this group, e.g. a fleet of instances of the same image")
+	runCmd.Flags().DurationVar(&ttl, "ttl", 0, "<duration, optional> delete the instance automatically once it has been running this long, e.g. 30m or 2h")
 }
 
 func connectDebugger() {
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var backupCron, backupDestination string
+var backupRetention int
+
+var scheduleBackupCmd = &cobra.Command{
+	Use:   "schedule-backup",
+	Short: "Back up a volume on a schedule",
+	Long: `Has the daemon back up a volume whenever a cron expression matches, without
+an external cron job. Each backup is a copy of the volume file as the provider
+keeps it (e.g. qcow2 for qemu), named after the volume and the time of the
+backup, in --destination: a directory on the daemon host, or an
+s3://bucket/prefix url. s3 uploads use the region of the first aws provider of
+the daemon, or AWS_REGION, and the credentials of the daemon host.
+
+The cron expression has the usual five fields (minute hour day-of-month month
+day-of-week) and is matched in the time zone of the daemon host; @hourly, @daily,
+@weekly, @monthly and @yearly may be used too. Only the newest --retention backups
+of a schedule are kept. Webhooks get a volume_backup_completed or
+volume_backup_failed event for every backup.
+
+Only volumes stored on the daemon host (qemu, ukvm, virtualbox and xen) can be
+backed up. Volumes attached to a running instance are copied as they are, so
+detach them, or stop the instance, for a consistent backup.
+
+You specify the volume by name or id.
+
+Example usage:
+	unik schedule-backup --volume myVolume --cron "0 2 * * *" --destination s3://my-bucket/backups --retention 7
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if volumeName == "" {
+				return errors.New("must specify --volume", nil)
+			}
+			if backupCron == "" {
+				return errors.New("must specify --cron", nil)
+			}
+			if backupDestination == "" {
+				return errors.New("must specify --destination", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "volume": volumeName, "cron": backupCron, "destination": backupDestination}).Info("scheduling backups")
+			schedule, err := client.UnikClient(host).Volumes().ScheduleBackup(volumeName, backupCron, backupDestination, backupRetention)
+			if err != nil {
+				return err
+			}
+			printBackupSchedules(schedule)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed scheduling backups: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func printBackupSchedules(schedules ...*types.BackupSchedule) {
+	fmt.Printf("%-36.36s %-15.15s %-15.15s %-9.9s %-25.25s %s\n", "ID", "VOLUME", "CRON", "RETENTION", "NEXT-RUN", "DESTINATION")
+	for _, schedule := range schedules {
+		retention := "all"
+		if schedule.Retention > 0 {
+			retention = strconv.Itoa(schedule.Retention)
+		}
+		nextRun := "-"
+		if !schedule.NextRun.IsZero() {
+			nextRun = schedule.NextRun.Format(time.RFC3339)
+		}
+		fmt.Printf("%-36.36s %-15.15s %-15.15s %-9.9s %-25.25s %s\n", schedule.Id, schedule.VolumeName, schedule.CronExpression, retention, nextRun, schedule.Destination)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(scheduleBackupCmd)
+	scheduleBackupCmd.Flags().StringVar(&volumeName, "volume", "", "<string,required> name or id of the volume to back up")
+	scheduleBackupCmd.Flags().StringVar(&backupCron, "cron", "", "<string,required> cron expression of when to back up the volume, e.g. \"0 2 * * *\" for 2am every day")
+	scheduleBackupCmd.Flags().StringVar(&backupDestination, "destination", "", "<string,required> absolute path of a directory on the daemon host, or an s3://bucket/prefix url, to keep the backups in")
+	scheduleBackupCmd.Flags().IntVar(&backupRetention, "retention", 7, "<int,optional> number of backups to keep. 0 keeps all of them")
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/util"
+)
+
+var signingKeyFile string
+
+var signImageCmd = &cobra.Command{
+	Use:   "sign-image",
+	Short: "Sign an image with a private key",
+	Long: `Signs the sha256 digest of the boot disk of an image with a private key.
+The daemon computes the digest; the key never leaves this machine. The daemon
+checks the signature and keeps it, with the public key, as a detached signature
+file next to the image metadata. The fingerprint of the key is shown by
+describe-image.
+
+The key must be an unencrypted ecdsa, rsa or ed25519 private key in PEM, e.g. made
+with openssl ecparam -genkey -name prime256v1 -noout -out private.pem.
+ecdsa and rsa signatures are the ones openssl dgst -sha256 -sign makes for the
+image file. Encrypted keys (such as cosign.key) must be decrypted first.
+
+Only images stored on the daemon host (qemu, virtualbox and xen) can be signed.
+Daemons started with --require-signed-images refuse to run unsigned images.
+
+Example usage:
+	unik sign-image --image myImage --key private.pem
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if imageName == "" {
+				return errors.New("must specify --image", nil)
+			}
+			if signingKeyFile == "" {
+				return errors.New("must specify --key", nil)
+			}
+			keyPem, err := ioutil.ReadFile(signingKeyFile)
+			if err != nil {
+				return errors.New("reading private key "+signingKeyFile, err)
+			}
+			privateKey, err := util.ParsePrivateKeyPEM(keyPem)
+			if err != nil {
+				return err
+			}
+			publicKeyPem, err := util.EncodePublicKeyPEM(privateKey.Public())
+			if err != nil {
+				return err
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "image": imageName}).Info("signing image")
+			digest, err := client.UnikClient(host).Images().Digest(imageName)
+			if err != nil {
+				return errors.New("computing digest of image failed", err)
+			}
+			digestBytes, err := decodeImageDigest(digest.Digest)
+			if err != nil {
+				return err
+			}
+			signature, err := util.SignDigest(privateKey, digestBytes)
+			if err != nil {
+				return err
+			}
+			image, err := client.UnikClient(host).Images().Sign(imageName, base64.StdEncoding.EncodeToString(signature), string(publicKeyPem))
+			if err != nil {
+				return errors.New("signing image failed", err)
+			}
+			fmt.Printf("image %s (%s) signed with key %s\n", image.Name, digest.Digest, image.SignatureKeyFingerprint)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed signing image: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var verifyImageCmd = &cobra.Command{
+	Use:   "verify-image",
+	Short: "Verify the signature of an image with a public key",
+	Long: `Has the daemon compute the sha256 digest of the boot disk of an image again,
+and checks the signature kept for the image against it with the given public key
+(PEM). verify-image exits with a non-zero status if the image is not signed, was
+signed with another key, or changed since it was signed.
+
+Example usage:
+	unik verify-image --image myImage --key public.pem
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if imageName == "" {
+				return errors.New("must specify --image", nil)
+			}
+			if signingKeyFile == "" {
+				return errors.New("must specify --key", nil)
+			}
+			keyPem, err := ioutil.ReadFile(signingKeyFile)
+			if err != nil {
+				return errors.New("reading public key "+signingKeyFile, err)
+			}
+			publicKey, err := util.ParsePublicKeyPEM(keyPem)
+			if err != nil {
+				return err
+			}
+			fingerprint, err := util.PublicKeyFingerprint(publicKey)
+			if err != nil {
+				return err
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "image": imageName, "key": fingerprint}).Info("verifying image")
+			signature, err := client.UnikClient(host).Images().Signature(imageName)
+			if err != nil {
+				return errors.New("retrieving signature of image failed", err)
+			}
+			if signature.Fingerprint != fingerprint {
+				return errors.New("image "+signature.Image+" was signed with key "+signature.Fingerprint+", not "+fingerprint, nil)
+			}
+			digest, err := client.UnikClient(host).Images().Digest(imageName)
+			if err != nil {
+				return errors.New("computing digest of image failed", err)
+			}
+			digestBytes, err := decodeImageDigest(digest.Digest)
+			if err != nil {
+				return err
+			}
+			signatureBytes, err := base64.StdEncoding.DecodeString(signature.Signature)
+			if err != nil {
+				return errors.New("signature of image is not base64", err)
+			}
+			if err := util.VerifyDigest(publicKey, digestBytes, signatureBytes); err != nil {
+				return errors.New("image "+signature.Image+" ("+digest.Digest+") does not match its signature", err)
+			}
+			fmt.Printf("image %s (%s) verified with key %s\n", signature.Image, digest.Digest, fingerprint)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed verifying image: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func decodeImageDigest(digest string) ([]byte, error) {
+	digestBytes, err := hex.DecodeString(strings.TrimPrefix(digest, "sha256:"))
+	if err != nil || len(digestBytes) != sha256.Size {
+		return nil, errors.New("daemon returned invalid digest "+digest, err)
+	}
+	return digestBytes, nil
+}
+
+func init() {
+	RootCmd.AddCommand(signImageCmd)
+	signImageCmd.Flags().StringVar(&imageName, "image", "", "<string,required> name or id of image. unik accepts a prefix of the name or id")
+	signImageCmd.Flags().StringVar(&signingKeyFile, "key", "", "<string,required> private key to sign with (PEM)")
+	RootCmd.AddCommand(verifyImageCmd)
+	verifyImageCmd.Flags().StringVar(&imageName, "image", "", "<string,required> name or id of image. unik accepts a prefix of the name or id")
+	verifyImageCmd.Flags().StringVar(&signingKeyFile, "key", "", "<string,required> public key to verify with (PEM)")
+}
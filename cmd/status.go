@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print the health, version and uptime of the daemon",
+	Long: `Checks the health of the targeted daemon (GET /healthz) and prints its version,
+uptime, the number of instances, volumes and images of each provider, the free
+space where it keeps its state, and how many goroutines it runs.
+
+Exits with status 1 if the daemon is unhealthy.
+
+Example usage:
+	unik status
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		healthy := true
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			health, err := client.UnikClient(host).Health(false)
+			if err != nil {
+				return errors.New("checking health of daemon at "+host, err)
+			}
+			status, err := client.UnikClient(host).Status()
+			if err != nil {
+				return errors.New("retrieving status of daemon at "+host, err)
+			}
+			fmt.Printf("%-12s %s\n", "Daemon:", host)
+			fmt.Printf("%-12s %s\n", "Health:", health.Status)
+			failed := []string{}
+			for what := range health.Errors {
+				failed = append(failed, what)
+			}
+			sort.Strings(failed)
+			for _, what := range failed {
+				fmt.Printf("%-12s %s: %s\n", "", what, health.Errors[what])
+			}
+			healthy = len(failed) == 0
+			fmt.Printf("%-12s %s\n", "Version:", status.Version)
+			fmt.Printf("%-12s %s (since %s)\n", "Uptime:", time.Duration(status.UptimeSeconds)*time.Second, status.Started.Format("2006-01-02 15:04:05"))
+			if status.FreeDiskBytes >= 0 {
+				fmt.Printf("%-12s %s (%d MB free)\n", "State dir:", status.StateDir, status.FreeDiskBytes>>20)
+			} else {
+				fmt.Printf("%-12s %s\n", "State dir:", status.StateDir)
+			}
+			fmt.Printf("%-12s %d\n", "Goroutines:", status.Goroutines)
+			fmt.Println()
+			providerNames := []string{}
+			for name := range status.Providers {
+				providerNames = append(providerNames, name)
+			}
+			sort.Strings(providerNames)
+			fmt.Printf("%-15.15s %-10.10s %-10.10s %-10.10s\n", "PROVIDER", "INSTANCES", "VOLUMES", "IMAGES")
+			for _, name := range providerNames {
+				counts := status.Providers[name]
+				fmt.Printf("%-15.15s %-10d %-10d %-10d\n", name, counts.Instances, counts.Volumes, counts.Images)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed retrieving daemon status: %v", err)
+			os.Exit(-1)
+		}
+		if !healthy {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+}
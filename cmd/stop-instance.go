@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -10,11 +11,28 @@ import (
 	"github.com/emc-advanced-dev/unik/pkg/client"
 )
 
+var stopTimeout time.Duration
+
 var stopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Stop a running unikernel instance",
 	Long: `Stops a running instance.
-You may specify the instance by name or id.`,
+You may specify the instance by name or id.
+
+With --timeout, the instance is first asked to shut down (on qemu, with an ACPI
+power off sent through QMP) and is only forced to stop if it is still running
+when the timeout runs out. Providers that cannot ask an instance to shut down
+stop it right away. Without --timeout, qemu instances still get the stop_timeout
+of the qemu config (10s by default) to shut down.
+
+With --all, every instance that is not stopped yet is stopped, or with
+--provider, every one of that provider. An instance that fails to stop does not
+keep the others from being stopped; the failures are all reported at the end.
+
+Example usage:
+	unik stop --instance myInstance --timeout 30s
+	unik stop --all --provider qemu
+`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
@@ -23,11 +41,25 @@ You may specify the instance by name or id.`,
 			if host == "" {
 				host = clientConfig.Host
 			}
+			if stopTimeout < 0 {
+				return errors.New("--timeout must not be negative", nil)
+			}
+			if all {
+				if instanceName != "" {
+					return errors.New("--instance cannot be given with --all", nil)
+				}
+				logrus.WithFields(logrus.Fields{"host": host, "provider": provider, "timeout": stopTimeout}).Info("stopping all instances")
+				result, err := client.UnikClient(host).Instances().StopAll(provider, stopTimeout)
+				if err != nil {
+					return err
+				}
+				return printBatchResult("stopped instances", result)
+			}
 			if instanceName == "" {
-				return errors.New("must specify --instance", nil)
+				return errors.New("must specify --instance or --all", nil)
 			}
-			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName}).Info("stopping instance")
-			if err := client.UnikClient(host).Instances().Stop(instanceName); err != nil {
+			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName, "timeout": stopTimeout}).Info("stopping instance")
+			if err := client.UnikClient(host).Instances().Stop(instanceName, stopTimeout); err != nil {
 				return err
 			}
 			return nil
@@ -41,4 +73,7 @@ You may specify the instance by name or id.`,
 func init() {
 	RootCmd.AddCommand(stopCmd)
 	stopCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	stopCmd.Flags().BoolVar(&all, "all", false, "<bool, optional> stop all running instances instead of --instance")
+	stopCmd.Flags().StringVar(&provider, "provider", "", "<string, optional> with --all, only stop the instances of this provider")
+	stopCmd.Flags().DurationVar(&stopTimeout, "timeout", 0, "<duration, optional> ask the instance to shut down and wait this long, e.g. 30s, before forcing it to stop")
 }
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var snapshotName string
+
+var suspendCmd = &cobra.Command{
+	Use:   "suspend",
+	Short: "Save the state of a running unikernel instance and stop it",
+	Long: `Saves the memory and devices of an instance to a snapshot and stops it, so it
+can be resumed later where it left off with unik resume.
+You may specify the instance by name or id.
+
+Only qemu instances can be suspended. qemu keeps the snapshot (unik-suspend
+unless --snapshot-name is given) inside the qcow2 disks of the instance, so
+instances of images that boot from a raw disk with a bootloader cannot be
+suspended. A suspended instance is listed with state suspended until it is
+resumed or deleted.
+
+Example usage:
+	unik suspend --instance myInstance --snapshot-name before-upgrade
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName, "snapshot-name": snapshotName}).Info("suspending instance")
+			instance, err := client.UnikClient(host).Instances().Suspend(instanceName, snapshotName)
+			if err != nil {
+				return err
+			}
+			printInstances(instance)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed suspending instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(suspendCmd)
+	suspendCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of instance. unik accepts a prefix of the name or id")
+	suspendCmd.Flags().StringVar(&snapshotName, "snapshot-name", "", "<string,optional> name of the snapshot to save the instance to. defaults to unik-suspend")
+}
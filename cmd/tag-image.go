@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var tagKey string
+
+var tagImageCmd = &cobra.Command{
+	Use:   "tag-image",
+	Short: "Add tags to an image",
+	Long: `Adds tags to an existing image. Tags are given as key=value;
+a tag that already exists on the image is overwritten.
+
+You may specify the image by name or id.
+
+Example usage:
+	unik tag-image --image myImage --tag version=1.2 --tag team=backend
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if imageName == "" {
+				return errors.New("must specify --image", nil)
+			}
+			imageTags, err := types.ParseTags(tags)
+			if err != nil {
+				return err
+			}
+			if len(imageTags) == 0 {
+				return errors.New("must specify at least one --tag", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "image": imageName, "tags": imageTags}).Info("tagging image")
+			image, err := client.UnikClient(host).Images().Tag(imageName, imageTags)
+			if err != nil {
+				return errors.New("tagging image failed", err)
+			}
+			printImages(image)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed tagging image: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var untagImageCmd = &cobra.Command{
+	Use:   "untag-image",
+	Short: "Remove a tag from an image",
+	Long: `Removes the tag with the given key from an image.
+
+You may specify the image by name or id.
+
+Example usage:
+	unik untag-image --image myImage --key version
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if imageName == "" {
+				return errors.New("must specify --image", nil)
+			}
+			if tagKey == "" {
+				return errors.New("must specify --key", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "image": imageName, "key": tagKey}).Info("untagging image")
+			image, err := client.UnikClient(host).Images().Untag(imageName, tagKey)
+			if err != nil {
+				return errors.New("untagging image failed", err)
+			}
+			printImages(image)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed untagging image: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(tagImageCmd)
+	tagImageCmd.Flags().StringVar(&imageName, "image", "", "<string,required> name or id of the image to tag. unik accepts a prefix of the name or id")
+	tagImageCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> tag to add, given as key=value")
+	RootCmd.AddCommand(untagImageCmd)
+	untagImageCmd.Flags().StringVar(&imageName, "image", "", "<string,required> name or id of the image to untag. unik accepts a prefix of the name or id")
+	untagImageCmd.Flags().StringVar(&tagKey, "key", "", "<string,required> key of the tag to remove")
+}
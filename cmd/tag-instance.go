@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var tagInstanceCmd = &cobra.Command{
+	Use:   "tag-instance",
+	Short: "Add tags to an instance",
+	Long: `Adds tags to an existing instance. Tags are given as key=value;
+a tag that already exists on the instance is overwritten.
+
+On aws, the tags are also set on the ec2 instance.
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik tag-instance --instance myInstance --tag env=staging --tag team=backend
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			instanceTags, err := types.ParseTags(tags)
+			if err != nil {
+				return err
+			}
+			if len(instanceTags) == 0 {
+				return errors.New("must specify at least one --tag", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName, "tags": instanceTags}).Info("tagging instance")
+			instance, err := client.UnikClient(host).Instances().Tag(instanceName, instanceTags)
+			if err != nil {
+				return errors.New("tagging instance failed", err)
+			}
+			printInstances(instance)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed tagging instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var untagInstanceCmd = &cobra.Command{
+	Use:   "untag-instance",
+	Short: "Remove a tag from an instance",
+	Long: `Removes the tag with the given key from an instance.
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik untag-instance --instance myInstance --key env
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if instanceName == "" {
+				return errors.New("must specify --instance", nil)
+			}
+			if tagKey == "" {
+				return errors.New("must specify --key", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "instance": instanceName, "key": tagKey}).Info("untagging instance")
+			instance, err := client.UnikClient(host).Instances().Untag(instanceName, tagKey)
+			if err != nil {
+				return errors.New("untagging instance failed", err)
+			}
+			printInstances(instance)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed untagging instance: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(tagInstanceCmd)
+	tagInstanceCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of the instance to tag. unik accepts a prefix of the name or id")
+	tagInstanceCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> tag to add, given as key=value")
+	RootCmd.AddCommand(untagInstanceCmd)
+	untagInstanceCmd.Flags().StringVar(&instanceName, "instance", "", "<string,required> name or id of the instance to untag. unik accepts a prefix of the name or id")
+	untagInstanceCmd.Flags().StringVar(&tagKey, "key", "", "<string,required> key of the tag to remove")
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var tagVolumeCmd = &cobra.Command{
+	Use:   "tag-volume",
+	Short: "Add tags to a volume",
+	Long: `Adds tags to an existing volume. Tags are given as key=value;
+a tag that already exists on the volume is overwritten.
+
+On aws, the tags are also set on the EBS volume.
+
+You may specify the volume by name or id.
+
+Example usage:
+	unik tag-volume --volume myVolume --tag backup=daily --tag team=backend
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if volumeName == "" {
+				return errors.New("must specify --volume", nil)
+			}
+			volumeTags, err := types.ParseTags(tags)
+			if err != nil {
+				return err
+			}
+			if len(volumeTags) == 0 {
+				return errors.New("must specify at least one --tag", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "volume": volumeName, "tags": volumeTags}).Info("tagging volume")
+			volume, err := client.UnikClient(host).Volumes().Tag(volumeName, volumeTags)
+			if err != nil {
+				return errors.New("tagging volume failed", err)
+			}
+			printVolumes(volume)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed tagging volume: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var untagVolumeCmd = &cobra.Command{
+	Use:   "untag-volume",
+	Short: "Remove a tag from a volume",
+	Long: `Removes the tag with the given key from a volume.
+
+You may specify the volume by name or id.
+
+Example usage:
+	unik untag-volume --volume myVolume --key backup
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if volumeName == "" {
+				return errors.New("must specify --volume", nil)
+			}
+			if tagKey == "" {
+				return errors.New("must specify --key", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "volume": volumeName, "key": tagKey}).Info("untagging volume")
+			volume, err := client.UnikClient(host).Volumes().Untag(volumeName, tagKey)
+			if err != nil {
+				return errors.New("untagging volume failed", err)
+			}
+			printVolumes(volume)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed untagging volume: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(tagVolumeCmd)
+	tagVolumeCmd.Flags().StringVar(&volumeName, "volume", "", "<string,required> name or id of the volume to tag. unik accepts a prefix of the name or id")
+	tagVolumeCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> tag to add, given as key=value")
+	RootCmd.AddCommand(untagVolumeCmd)
+	untagVolumeCmd.Flags().StringVar(&volumeName, "volume", "", "<string,required> name or id of the volume to untag. unik accepts a prefix of the name or id")
+	untagVolumeCmd.Flags().StringVar(&tagKey, "key", "", "<string,required> key of the tag to remove")
+}
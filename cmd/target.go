@@ -53,15 +53,42 @@ args:
 }
 
 func setClientConfig(host string, port int) error {
-	data, err := yaml.Marshal(config.ClientConfig{Host: fmt.Sprintf("%s:%v", host, port)})
+	clientConfig, err := loadClientConfigFile()
+	if err != nil {
+		return err
+	}
+	clientConfig.Host = fmt.Sprintf("%s:%v", host, port)
+	return writeClientConfig(clientConfig)
+}
+
+// loadClientConfigFile reads the client config file, or returns an empty
+// config if there is none yet
+func loadClientConfigFile() (config.ClientConfig, error) {
+	var clientConfig config.ClientConfig
+	data, err := ioutil.ReadFile(clientConfigFile)
+	if os.IsNotExist(err) {
+		return clientConfig, nil
+	}
+	if err != nil {
+		return clientConfig, errors.New("reading config file "+clientConfigFile, err)
+	}
+	if err := yaml.Unmarshal(data, &clientConfig); err != nil {
+		return clientConfig, errors.New("parsing config file "+clientConfigFile, err)
+	}
+	return clientConfig, nil
+}
+
+func writeClientConfig(clientConfig config.ClientConfig) error {
+	data, err := yaml.Marshal(clientConfig)
 	if err != nil {
 		return errors.New("failed to convert config to yaml string ", err)
 	}
 	os.MkdirAll(filepath.Dir(clientConfigFile), 0755)
-	if err := ioutil.WriteFile(clientConfigFile, data, 0644); err != nil {
+	// the config may hold a token
+	if err := ioutil.WriteFile(clientConfigFile, data, 0600); err != nil {
 		return errors.New("failed writing config to file "+clientConfigFile, err)
 	}
-	return nil
+	return os.Chmod(clientConfigFile, 0600)
 }
 
 func init() {
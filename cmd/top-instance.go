@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// moves the cursor to the top left of the terminal and clears it
+const clearScreen = "\033[H\033[2J"
+
+var (
+	topInterval time.Duration
+	topCount    int
+)
+
+var topInstanceCmd = &cobra.Command{
+	Use:   "top INSTANCE",
+	Short: "Show the cpu and memory usage of an instance as it runs",
+	Long: `Samples the metrics of a running instance every --interval, and redraws a
+table of the utilization of each of its vcpus and of its memory.
+
+The utilization of a vcpu is the share of the interval it ran for, so it is
+shown from the second sample on. The memory is what the instance has (its
+balloon, if it has one) and what it uses on the host.
+
+Only qemu instances report metrics. top runs until it is interrupted, or for
+--count samples.
+
+You may specify the instance by name or id.
+
+Example usage:
+	unik instances top myInstance --interval 2s --count 10
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give exactly one instance", nil)
+			}
+			if topInterval <= 0 {
+				return errors.New("--interval must be positive", nil)
+			}
+			if topCount < 0 {
+				return errors.New("--count cannot be negative", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			instances := client.UnikClient(host).Instances()
+			var previous *types.InstanceMetrics
+			for sample := 1; topCount == 0 || sample <= topCount; sample++ {
+				if previous != nil {
+					time.Sleep(topInterval)
+				}
+				metrics, err := instances.Metrics(args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Print(clearScreen)
+				printInstanceMetrics(args[0], previous, metrics)
+				previous = metrics
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed showing instance metrics: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// printInstanceMetrics prints the usage of each vcpu between the previous
+// sample and current one, and the memory of current
+func printInstanceMetrics(instance string, previous, current *types.InstanceMetrics) {
+	fmt.Printf("%s - %s\n\n", instance, current.Time.Format(time.RFC3339))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "VCPU\tCPU%\tCPU TIME")
+	for _, vcpu := range current.VCPUs {
+		fmt.Fprintf(tw, "%d\t%s\t%v\n", vcpu.Index, vcpuUtilization(previous, current, vcpu), vcpu.CPUTime)
+	}
+	tw.Flush()
+	used := "-"
+	if current.MemoryUsedBytes > 0 {
+		used = fmt.Sprintf("%dMB", current.MemoryUsedBytes>>20)
+	}
+	total := "-"
+	if current.MemoryBytes > 0 {
+		total = fmt.Sprintf("%dMB", current.MemoryBytes>>20)
+	}
+	fmt.Printf("\nMEMORY  %s used / %s\n", used, total)
+}
+
+func vcpuUtilization(previous, current *types.InstanceMetrics, vcpu types.VCPUMetrics) string {
+	if previous == nil {
+		return "-"
+	}
+	elapsed := current.Time.Sub(previous.Time)
+	if elapsed <= 0 {
+		return "-"
+	}
+	for _, before := range previous.VCPUs {
+		if before.Index == vcpu.Index {
+			return fmt.Sprintf("%.1f", float64(vcpu.CPUTime-before.CPUTime)/float64(elapsed)*100)
+		}
+	}
+	return "-"
+}
+
+func init() {
+	psCmd.AddCommand(topInstanceCmd)
+	topInstanceCmd.Flags().DurationVar(&topInterval, "interval", time.Second, "<duration, optional> time between samples, e.g. 2s")
+	topInstanceCmd.Flags().IntVar(&topCount, "count", 0, "<int, optional> number of samples to show before exiting. 0 shows them until interrupted")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+)
+
+var trimCompact bool
+
+var trimVolumeCmd = &cobra.Command{
+	Use:   "trim VOLUME",
+	Short: "Reclaim the space of deleted files on a volume",
+	Long: `Gives back the space that files deleted from a volume still take on the daemon
+host. The daemon loop mounts the filesystem of the volume with -o discard and runs
+fstrim on it, which punches holes in the volume file for the unused blocks.
+qcow2 volumes (qemu, libvirt) are trimmed as a raw copy, which is converted back to qcow2.
+With --compact, raw volumes (xen, ukvm) are also rewritten with qemu-img convert.
+
+The volume must be detached, stored on the daemon host (qemu, xen, ukvm or
+libvirt) as a raw or qcow2 file, and the daemon must be able to set up loop
+devices and mount filesystems (root on linux).
+
+Example usage:
+	unik volumes trim myVolume
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if len(args) != 1 {
+				return errors.New("must give exactly one volume", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "volume": args[0], "compact": trimCompact}).Info("trimming volume")
+			result, err := client.UnikClient(host).Volumes().Trim(args[0], trimCompact)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("volume %s: %s trimmed, %s reclaimed\n", result.Volume, unikos.Bytes(result.TrimmedBytes), unikos.Bytes(result.ReclaimedBytes))
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed trimming volume: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	volumesCmd.AddCommand(trimVolumeCmd)
+	trimVolumeCmd.Flags().BoolVar(&trimCompact, "compact", false, "<bool, optional> also rewrite raw volume files with qemu-img convert")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var bootImage bool
+
+var validateImageCmd = &cobra.Command{
+	Use:   "validate-image",
+	Short: "Check that an image is bootable before deploying it",
+	Long: `Checks the boot disk of an image on the daemon host:
+	loop        the image can be attached as a loop device and its partitions read
+	filesystem  e2fsck -n finds no problems on the boot partition
+	kernel      boot/program.bin is present and not empty
+	grub config boot/grub/menu.lst parses and its default entry boots a kernel that exists
+
+With --boot, the image is also booted in qemu for 5 seconds (changes to the disk
+are discarded) and the check fails if the console shows a kernel panic.
+
+A check is skipped if a check before it did not pass. validate-image exits with
+a non-zero status if any check did not pass.
+
+Only images stored on the daemon host (qemu, virtualbox and xen) can be validated.
+
+Example usage:
+	unik validate-image --image myImage --boot
+
+	PASS loop
+	PASS filesystem
+	PASS kernel
+	PASS grub config
+	PASS boot
+	image myImage is valid
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if imageName == "" {
+				return errors.New("must specify --image", nil)
+			}
+			if outputFormat != "" && outputFormat != "json" {
+				return errors.New("unsupported output format "+outputFormat+". Available: json", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "image": imageName, "boot": bootImage}).Info("validating image")
+			report, err := client.UnikClient(host).Images().Validate(imageName, bootImage)
+			if err != nil {
+				return errors.New("validating image failed", err)
+			}
+			if outputFormat == "json" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return errors.New("marshalling validation report to json", err)
+				}
+				fmt.Println(string(data))
+			} else {
+				printValidationReport(report)
+			}
+			if !report.Valid {
+				os.Exit(1)
+			}
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed validating image: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func printValidationReport(report *types.ValidationReport) {
+	for _, check := range report.Checks {
+		status := "PASS"
+		switch {
+		case check.Skipped:
+			status = "SKIP"
+		case !check.Passed:
+			status = "FAIL"
+		}
+		if check.Message != "" {
+			fmt.Printf("%s %s: %s\n", status, check.Name, check.Message)
+		} else {
+			fmt.Printf("%s %s\n", status, check.Name)
+		}
+	}
+	if report.Valid {
+		fmt.Printf("image %s is valid\n", report.Image)
+	} else {
+		fmt.Printf("image %s is not valid\n", report.Image)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(validateImageCmd)
+	validateImageCmd.Flags().StringVar(&imageName, "image", "", "<string,required> name or id of the image to validate")
+	validateImageCmd.Flags().BoolVar(&bootImage, "boot", false, "<bool,optional> also boot the image in qemu for 5 seconds and check for a kernel panic")
+	validateImageCmd.Flags().StringVar(&outputFormat, "output", "", "<string,optional> print the report in this format instead of a list of checks. Available: json")
+}
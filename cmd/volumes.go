@@ -8,8 +8,13 @@ import (
 
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/client"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
+var attached, unattached bool
+var nameContains, sizeGt, sizeLt string
+
 var volumesCmd = &cobra.Command{
 	Use:   "volumes",
 	Short: "List available unik-managed volumes",
@@ -17,7 +22,22 @@ var volumesCmd = &cobra.Command{
 
 ATTACHED-INSTANCE gives the instance ID of the instance a volume
 is attached to, if any. Only volumes that have no attachment are
-available to be attached to an instance.`,
+available to be attached to an instance.
+
+Volumes can be filtered by provider, attachment, name, tags (--tag key=value,
+any number of times; a volume must have all of them), or size (--size-gt and
+--size-lt, in bytes or with a unit such as 500MiB or 1GiB). Filtering is
+performed by the daemon. Use --sort and --order to sort the volumes, e.g.
+--sort size --order desc to list the largest first.
+
+Example usage:
+	unik volumes --provider qemu --unattached --name-contains data
+
+	# will list only qemu volumes that are not attached to any instance
+	# and whose name contains 'data'
+	unik volumes --size-gt 1GiB --sort size --order desc
+
+	# will list the volumes larger than 1 GiB, largest first`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := func() error {
 			if err := readClientConfig(); err != nil {
@@ -26,12 +46,39 @@ available to be attached to an instance.`,
 			if host == "" {
 				host = clientConfig.Host
 			}
-			logrus.WithField("host", host).Info("listing volumes")
-			volumes, err := client.UnikClient(host).Volumes().All()
+			if attached && unattached {
+				return errors.New("--attached and --unattached cannot be used together", nil)
+			}
+			volumeTags, err := types.ParseTags(tags)
+			if err != nil {
+				return err
+			}
+			filter := client.VolumeFilter{
+				Provider:     provider,
+				Attached:     attached,
+				Unattached:   unattached,
+				NameContains: nameContains,
+				Tags:         volumeTags,
+				Sort:         sortBy,
+				Order:        sortOrder,
+			}
+			if sizeGt != "" {
+				if filter.SizeGt, err = unikos.ParseHumanSize(sizeGt); err != nil {
+					return errors.New("invalid --size-gt", err)
+				}
+			}
+			if sizeLt != "" {
+				if filter.SizeLt, err = unikos.ParseHumanSize(sizeLt); err != nil {
+					return errors.New("invalid --size-lt", err)
+				}
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "filter": filter}).Info("listing volumes")
+			volumes, err := client.UnikClient(host).Volumes().List(filter)
 			if err != nil {
 				return errors.New("listing volumes failed", err)
 			}
 			printVolumes(volumes...)
+			printVolumeSummary(volumes)
 			return nil
 		}(); err != nil {
 			logrus.Errorf("failed listing volumes: %v", err)
@@ -42,4 +89,13 @@ available to be attached to an instance.`,
 
 func init() {
 	RootCmd.AddCommand(volumesCmd)
+	volumesCmd.Flags().StringVar(&provider, "provider", "", "<string,optional> only list volumes belonging to this provider")
+	volumesCmd.Flags().BoolVar(&attached, "attached", false, "<bool,optional> only list volumes that are attached to an instance")
+	volumesCmd.Flags().BoolVar(&unattached, "unattached", false, "<bool,optional> only list volumes that are not attached to any instance")
+	volumesCmd.Flags().StringVar(&nameContains, "name-contains", "", "<string,optional> only list volumes whose name contains this string")
+	volumesCmd.Flags().StringSliceVar(&tags, "tag", []string{}, "<string,repeated> only list volumes with this tag, given as key=value")
+	volumesCmd.Flags().StringVar(&sizeGt, "size-gt", "", "<string,optional> only list volumes larger than this, in bytes or with a unit, e.g. 500MiB or 1GiB")
+	volumesCmd.Flags().StringVar(&sizeLt, "size-lt", "", "<string,optional> only list volumes smaller than this, in bytes or with a unit, e.g. 500MiB or 1GiB")
+	volumesCmd.Flags().StringVar(&sortBy, "sort", "", "<string,optional> sort volumes by this field. Available: name|size|created|provider (default name)")
+	volumesCmd.Flags().StringVar(&sortOrder, "order", "", "<string,optional> sort order. Available: asc|desc (default asc)")
 }
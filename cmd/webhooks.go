@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+var webhookURL, webhookSecret, webhookId string
+var webhookEvents []string
+
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "List registered webhooks",
+	Long: `Lists the webhooks registered with the daemon, including those
+defined in the daemon config file. Secrets are never shown.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithField("host", host).Info("listing webhooks")
+			webhooks, err := client.UnikClient(host).Webhooks().All()
+			if err != nil {
+				return errors.New("listing webhooks failed", err)
+			}
+			printWebhooks(webhooks...)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed listing webhooks: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var createWebhookCmd = &cobra.Command{
+	Use:   "create-webhook",
+	Short: "Register a webhook for instance and volume events",
+	Long: `Registers a url to be notified when instances start, stop, crash,
+are deleted or expire, when volumes are attached or detached, and when
+builds complete.
+
+The daemon POSTs a JSON description of the event to the url. If a secret
+is given, the X-Unik-Signature header of each request will contain
+'sha256=' followed by the hex encoded HMAC-SHA256 of the request body,
+keyed with the secret.
+
+Available events:
+	instance_started, instance_stopped, instance_crashed, instance_deleted,
+	instance_expired, instance_restarted, instance_suspended, instance_resumed,
+	instance_migrated, volume_attached, volume_detached, build_completed,
+	volume_migration_progress, volume_migrated, volume_backup_completed,
+	volume_backup_failed
+
+Example usage:
+	unik create-webhook --url https://ci.example.com/hooks/unik --secret s3cr3t --event instance_crashed --event instance_stopped
+
+	# will notify https://ci.example.com/hooks/unik whenever an instance crashes or stops
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if webhookURL == "" {
+				return errors.New("--url must be set", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "url": webhookURL, "events": webhookEvents}).Info("creating webhook")
+			webhook, err := client.UnikClient(host).Webhooks().Create(webhookURL, webhookSecret, webhookEvents)
+			if err != nil {
+				return errors.New("creating webhook failed", err)
+			}
+			printWebhooks(webhook)
+			return nil
+		}(); err != nil {
+			logrus.Errorf("failed creating webhook: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var deleteWebhookCmd = &cobra.Command{
+	Use:   "delete-webhook",
+	Short: "Delete a registered webhook",
+	Long: `Deletes a webhook by id. Webhooks defined in the daemon config
+file must be removed from the config instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := func() error {
+			if webhookId == "" {
+				return errors.New("--id must be set", nil)
+			}
+			if err := readClientConfig(); err != nil {
+				return err
+			}
+			if host == "" {
+				host = clientConfig.Host
+			}
+			logrus.WithFields(logrus.Fields{"host": host, "id": webhookId}).Info("deleting webhook")
+			return client.UnikClient(host).Webhooks().Delete(webhookId)
+		}(); err != nil {
+			logrus.Errorf("failed deleting webhook: %v", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(webhooksCmd)
+	RootCmd.AddCommand(createWebhookCmd)
+	RootCmd.AddCommand(deleteWebhookCmd)
+	createWebhookCmd.Flags().StringVar(&webhookURL, "url", "", "<string,required> http or https url to deliver events to")
+	createWebhookCmd.Flags().StringVar(&webhookSecret, "secret", "", "<string,optional> secret used to sign the payloads sent to the webhook")
+	createWebhookCmd.Flags().StringSliceVar(&webhookEvents, "event", []string{}, "<string,repeated> event to subscribe to. if none are given the webhook receives all events")
+	deleteWebhookCmd.Flags().StringVar(&webhookId, "id", "", "<string,required> id of the webhook to delete")
+}
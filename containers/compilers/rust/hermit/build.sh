@@ -0,0 +1,22 @@
+#!/bin/bash
+# builds the rust project in /opt/code for hermit and copies its binary to /opt/code/program.bin
+set -ex
+
+cd /opt/code
+CARGO_TOML=${CARGO_TOML:-Cargo.toml}
+
+FEATURE_ARGS=()
+if [ -n "${CARGO_FEATURES}" ]; then
+    FEATURE_ARGS=(--features "${CARGO_FEATURES}")
+fi
+
+cargo build -Zbuild-std=std,panic_abort --target x86_64-unknown-hermit --release \
+    --manifest-path "${CARGO_TOML}" "${FEATURE_ARGS[@]}"
+
+METADATA=$(cargo metadata --format-version 1 --no-deps --manifest-path "${CARGO_TOML}")
+if [ -z "${BINARY}" ]; then
+    BINARY=$(echo "${METADATA}" | jq -r '[.packages[].targets[] | select(.kind | index("bin"))][0].name')
+fi
+TARGET_DIR=$(echo "${METADATA}" | jq -r .target_directory)
+
+cp "${TARGET_DIR}/x86_64-unknown-hermit/release/${BINARY}" /opt/code/program.bin
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"os/signal"
 	"path"
+	"syscall"
 
 	log "github.com/Sirupsen/logrus"
 
@@ -22,21 +25,38 @@ func main() {
 	usePartitionTables := flag.Bool("part", true, "indicates whether or not to use partition tables and install grub")
 	args := flag.String("a", "", "arguments to kernel")
 	out := flag.String("o", "", "base name of output file")
+	diskNaming := flag.String("disk-naming", string(unikos.DiskNaming_IDE), "naming convention of the disks of the hypervisor: 'ide', 'virtio' or 'nvme'. used in conjunction with -part")
+	iso := flag.Bool("iso", false, "write a bootable iso image booted by isolinux instead of a disk image")
+	isolinuxDir := flag.String("isolinux-dir", unikos.IsolinuxDir, "directory to copy isolinux.bin from. used in conjunction with -iso")
 
 	flag.Parse()
 
+	naming, err := unikos.ParseDiskNamingConvention(*diskNaming)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	kernelFile := path.Join(*buildcontextdir, *kernelInContext)
 	imgFile := path.Join(*buildcontextdir, "boot.image."+uuid.New())
 	defer os.Remove(imgFile)
 
-	log.WithFields(log.Fields{"kernelFile": kernelFile, "args": *args, "imgFile": imgFile, "usePartitionTables": *usePartitionTables}).Debug("calling CreateBootImageWithSize")
+	if *iso {
+		unikos.IsolinuxDir = *isolinuxDir
+		log.WithFields(log.Fields{"kernelFile": kernelFile, "args": *args, "imgFile": imgFile}).Debug("calling CreateISOImage")
+		if err := unikos.CreateISOImage(kernelFile, *args, imgFile); err != nil {
+			log.Fatal(err)
+		}
+		copyToOutput(imgFile, path.Join(*buildcontextdir, *out))
+		return
+	}
+
+	log.WithFields(log.Fields{"kernelFile": kernelFile, "args": *args, "imgFile": imgFile, "usePartitionTables": *usePartitionTables, "diskNaming": naming}).Debug("calling CreateBootImageWithSize")
 
 	s1, err := unikos.DirSize(*buildcontextdir)
 	if err != nil {
 		log.Fatal(err)
 	}
-	s2 := float64(s1) * 1.1
-	size := ((int64(s2) >> 20) + 20)
+	size := unikos.BootImageSizeMB(s1)
 
 	if err := unikos.CopyDir(*buildcontextdir, staticFileDir); err != nil {
 		log.Fatal(err)
@@ -45,15 +65,29 @@ func main() {
 	//no need to copy twice
 	os.Remove(path.Join(staticFileDir, *kernelInContext))
 
-	if err := unikos.CreateBootImageWithSize(imgFile, unikos.MegaBytes(size), kernelFile, staticFileDir, *args, *usePartitionTables); err != nil {
+	// docker stop sends SIGTERM; kill mkfs, mount and grub-install rather than hang on them
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Warn("interrupted, cancelling")
+		cancel()
+	}()
+
+	if err := unikos.CreateBootImageWithSizeContext(ctx, imgFile, unikos.MegaBytes(size), kernelFile, staticFileDir, *args, *usePartitionTables, naming); err != nil {
 		log.Fatal(err)
 	}
 
+	copyToOutput(imgFile, path.Join(*buildcontextdir, *out))
+}
+
+func copyToOutput(imgFile, outFile string) {
 	src, err := os.Open(imgFile)
 	if err != nil {
 		log.Fatal("failed to open produced image file "+imgFile, err)
 	}
-	outFile := path.Join(*buildcontextdir, *out)
 	dst, err := os.OpenFile(outFile, os.O_RDWR, 0)
 	if err != nil {
 		log.Fatal("failed to open target output file "+outFile, err)
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/emc-advanced-dev/pkg/errors"
 
@@ -30,17 +33,31 @@ func (m *volumeslice) Set(value string) error {
 
 	volparts := strings.Split(value, ",")
 
-	if (len(volparts) != 1) && (len(volparts) != 2) {
+	if len(volparts) < 1 {
 		return errors.New("bad format", nil)
 	}
 
-	folder := volparts[0]
+	volume := unikos.RawVolume{Path: volparts[0]}
 
-	var size int64
 	if len(volparts) >= 2 {
-		size, _ = strconv.ParseInt(volparts[1], 0, 64)
+		volume.Size, _ = strconv.ParseInt(volparts[1], 0, 64)
 	}
-	*m = append(*m, unikos.RawVolume{Path: folder, Size: size})
+	// the options after the size: ro, label=LABEL and uuid=UUID
+	if len(volparts) > 2 {
+		for _, option := range volparts[2:] {
+			switch {
+			case option == "ro":
+				volume.ReadOnly = true
+			case strings.HasPrefix(option, "label="):
+				volume.Label = strings.TrimPrefix(option, "label=")
+			case strings.HasPrefix(option, "uuid="):
+				volume.UUID = strings.TrimPrefix(option, "uuid=")
+			default:
+				return errors.New("bad format: expected ro, label= or uuid=, got "+option, nil)
+			}
+		}
+	}
+	*m = append(*m, volume)
 
 	return nil
 }
@@ -59,12 +76,15 @@ func main() {
 	partitionTable := flag.String("p", "true", "create partition table")
 	buildcontextdir := flag.String("d", "/opt/vol", "build context. relative volume names are relative to that")
 	volType := flag.String("t", "ext2", "type of volume 'mirage-fat', 'fat' or 'ext2'")
-	flag.Var(&volumes, "v", "volumes folder[,size]")
+	flag.Var(&volumes, "v", "volumes folder[,size][,label=LABEL][,uuid=UUID], or image[,size],ro for a formatted filesystem image mounted read-only. size may be 0 to fit the folder")
 	out := flag.String("o", "", "base name of output file")
+	sectorSize := flag.Int64("sector-size", unikos.SectorSize, "sector size in bytes of the disk the volumes are for, 512 or 4096. used in conjunction with -p")
+	tableName := flag.String("partition-table", unikos.DefaultPartitionTable, "partition table to create: "+strings.Join(unikos.PartitionTables(), ", ")+". used in conjunction with -p")
+	emptySize := flag.Int64("empty", 0, "create an empty volume of this many bytes with no partition table instead of the -v volumes")
 
 	flag.Parse()
 
-	if len(volumes) == 0 {
+	if *emptySize == 0 && len(volumes) == 0 {
 		log.Fatal("No volumes provided")
 	}
 
@@ -76,7 +96,24 @@ func main() {
 	}
 
 	verifyPreConditions()
-	if *volType == "mirage-fat" {
+
+	// docker stop sends SIGTERM; stop sizing the volumes rather than hang on a slow mount
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Warn("interrupted, cancelling")
+		cancel()
+	}()
+
+	if *emptySize != 0 {
+		log.WithFields(log.Fields{"size": *emptySize, "type": *volType}).Info("Creating empty volume")
+		if err := unikos.CreateEmptyVolume(imgFile, unikos.Bytes(*emptySize), unikos.FilesystemType(*volType)); err != nil {
+			log.Fatal(err)
+		}
+	} else if *volType == "mirage-fat" {
 		if *partitionTable == "true" {
 			log.Fatal("Can't create mirage-fat volume with a partition table.")
 		}
@@ -87,7 +124,7 @@ func main() {
 		volume := volumes[0]
 		if volume.Size == 0 {
 			var err error
-			volume.Size, err = unikos.GetDirSize(volume.Path)
+			volume.Size, err = unikos.GetDirSizeContext(ctx, volume.Path)
 			if err != nil {
 				log.Panic(err)
 			}
@@ -99,13 +136,13 @@ func main() {
 
 		log.WithFields(log.Fields{"sizeKb": sizeKb, "size": volume.Size}).Info("Creating mirage fat volume.")
 
-		err := unikos.RunLogCommand("fat", "create", imgFile, fmt.Sprintf("%d%s", sizeKb, "KiB"))
+		err := unikos.RunLogCommandContext(ctx, "fat", "create", imgFile, fmt.Sprintf("%d%s", sizeKb, "KiB"))
 		if err != nil {
 			log.Panic(err)
 		}
 
 		if volume.Path != "" {
-			err := unikos.RunLogCommand("/bin/bash", "-c", fmt.Sprintf("cd \"%s\" && fat add %s *", volume.Path, imgFile))
+			err := unikos.RunLogCommandContext(ctx, "/bin/bash", "-c", fmt.Sprintf("cd \"%s\" && fat add %s *", volume.Path, imgFile))
 			if err != nil {
 				log.Panic(err)
 			}
@@ -114,12 +151,14 @@ func main() {
 	} else {
 
 		if *partitionTable == "true" {
-			log.Info("Creating volume with partition table")
+			log.WithField("partition-table", *tableName).Info("Creating volume with partition table")
 
-			diskLabelGen := func(device string) unikos.Partitioner { return &unikos.DiskLabelPartioner{device} }
+			newPartitioner, err := unikos.GetPartitioner(*tableName, unikos.SectorSizeBytes(*sectorSize))
+			if err != nil {
+				log.Fatal(err)
+			}
 
-			// rump so we use disklabel
-			err := unikos.CreateVolumes(imgFile, *volType, []unikos.RawVolume(volumes), diskLabelGen)
+			err = unikos.CreateVolumesContext(ctx, imgFile, *volType, []unikos.RawVolume(volumes), newPartitioner, unikos.SectorSizeBytes(*sectorSize))
 
 			if err != nil {
 				panic(err)
@@ -131,7 +170,7 @@ func main() {
 				log.Fatal("Can only create one volume with no partition table")
 			}
 
-			err := unikos.CreateSingleVolume(imgFile, *volType, volumes[0])
+			err := unikos.CreateSingleVolumeContext(ctx, imgFile, *volType, volumes[0])
 
 			if err != nil {
 				panic(err)
@@ -0,0 +1,6 @@
+// The daemon and the cli are built in GOPATH mode, with the dependencies in
+// vendor/ (see Godeps/Godeps.json). This file only lets terraform-provider-unik,
+// which is a module of its own, depend on pkg/client.
+module github.com/emc-advanced-dev/unik
+
+go 1.22
@@ -4,34 +4,55 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/emc-advanced-dev/pkg/errors"
-	"github.com/layer-x/layerx-commons/lxhttpclient"
+	"github.com/emc-advanced-dev/unik/pkg/types"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type client struct {
-	unikIP string
+	unikIP     string
+	httpClient *http.Client
 }
 
+// UnikClient returns a client of the daemon at unikIP. its requests go through
+// an *http.Client of its own, with the retries of SetRetryTransport, the user of
+// SetUser and SetToken, and the tls settings of SetTLS
 func UnikClient(unikIP string) *client {
-	return &client{unikIP: unikIP}
+	var base http.RoundTripper = daemonTransport
+	if retryTransport != nil {
+		base = retryTransport
+	}
+	return &client{unikIP: unikIP, httpClient: &http.Client{Transport: &userTransport{base: base}}}
 }
 
 func (c *client) Images() *images {
-	return &images{unikIP: c.unikIP}
+	return &images{unikIP: c.unikIP, httpClient: c.httpClient}
 }
 
 func (c *client) Instances() *instances {
-	return &instances{unikIP: c.unikIP}
+	return &instances{unikIP: c.unikIP, httpClient: c.httpClient}
 }
 
 func (c *client) Volumes() *volumes {
-	return &volumes{unikIP: c.unikIP}
+	return &volumes{unikIP: c.unikIP, httpClient: c.httpClient}
+}
+
+func (c *client) Events() *events {
+	return &events{unikIP: c.unikIP, httpClient: c.httpClient}
+}
+
+func (c *client) Webhooks() *webhooks {
+	return &webhooks{unikIP: c.unikIP, httpClient: c.httpClient}
+}
+
+func (c *client) Manifests() *manifests {
+	return &manifests{unikIP: c.unikIP, httpClient: c.httpClient}
 }
 
 func (c *client) AvailableCompilers() ([]string, error) {
-	resp, body, err := lxhttpclient.Get(c.unikIP, "/available_compilers", nil)
+	resp, body, err := httpGet(c.httpClient, c.unikIP, "/available_compilers", nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -46,7 +67,7 @@ func (c *client) AvailableCompilers() ([]string, error) {
 }
 
 func (c *client) AvailableProviders() ([]string, error) {
-	resp, body, err := lxhttpclient.Get(c.unikIP, "/available_providers", nil)
+	resp, body, err := httpGet(c.httpClient, c.unikIP, "/available_providers", nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -60,13 +81,46 @@ func (c *client) AvailableProviders() ([]string, error) {
 	return compilers, nil
 }
 
+// Platforms returns the platform names build --platform takes, and the
+// provider each of them builds for
+func (c *client) Platforms() (map[string]string, error) {
+	resp, body, err := httpGet(c.httpClient, c.unikIP, "/platforms", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var platforms map[string]string
+	if err := json.Unmarshal(body, &platforms); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type map[string]string", string(body)), err)
+	}
+	return platforms, nil
+}
+
+// ResolvePlatform returns the name of the provider builds for platform are made on
+func (c *client) ResolvePlatform(platform string) (string, error) {
+	resp, body, err := httpGet(c.httpClient, c.unikIP, "/platforms/"+url.PathEscape(platform), nil)
+	if err != nil {
+		return "", errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var alias types.PlatformAlias
+	if err := json.Unmarshal(body, &alias); err != nil {
+		return "", errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.PlatformAlias", string(body)), err)
+	}
+	return alias.Provider, nil
+}
+
 func (c *client) DescribeCompiler(base string, lang string, provider string) (string, error) {
 	query := buildQuery(map[string]interface{}{
 		"base":     base,
 		"lang":     lang,
 		"provider": provider,
 	})
-	resp, body, err := lxhttpclient.Get(c.unikIP, "/describe_compiler"+query, nil)
+	resp, body, err := httpGet(c.httpClient, c.unikIP, "/describe_compiler"+query, nil)
 	if err != nil {
 		return "", errors.New("request failed", err)
 	}
@@ -76,6 +130,68 @@ func (c *client) DescribeCompiler(base string, lang string, provider string) (st
 	return string(body), nil
 }
 
+// Health calls GET /healthz, or GET /readyz if ready is set. an unhealthy daemon
+// answers with status 503 and says what failed in the returned status
+func (c *client) Health(ready bool) (*types.HealthStatus, error) {
+	path := "/healthz"
+	if ready {
+		path = "/readyz"
+	}
+	resp, body, err := httpGet(c.httpClient, c.unikIP, path, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var status types.HealthStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.HealthStatus", string(body)), err)
+	}
+	return &status, nil
+}
+
+// Status returns the version and uptime of the daemon and what its providers hold
+func (c *client) Status() (*types.DaemonStatus, error) {
+	resp, body, err := httpGet(c.httpClient, c.unikIP, "/admin/status", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var status types.DaemonStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.DaemonStatus", string(body)), err)
+	}
+	return &status, nil
+}
+
+// Shutdown asks the daemon to shut down once the requests in flight are done, or
+// timeout has passed. with wait, the daemon replies after it has written its
+// state; otherwise it replies right away, and the result is nil
+func (c *client) Shutdown(wait bool, timeout time.Duration) (*types.ShutdownResult, error) {
+	query := buildQuery(map[string]interface{}{
+		"wait":    wait,
+		"timeout": timeout.String(),
+	})
+	resp, body, err := httpPost(c.httpClient, c.unikIP, "/admin/shutdown"+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if !wait && resp.StatusCode == http.StatusAccepted {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var result types.ShutdownResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ShutdownResult", string(body)), err)
+	}
+	return &result, nil
+}
+
 func buildQuery(params map[string]interface{}) string {
 	queryArray := []string{}
 	for key, val := range params {
@@ -84,3 +200,40 @@ func buildQuery(params map[string]interface{}) string {
 	queryString := "?" + strings.Join(queryArray, "&")
 	return queryString
 }
+
+// tagQuery returns a query selecting resources with all of the given tags
+func tagQuery(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	query := url.Values{}
+	for key, value := range tags {
+		query.Add("tag", key+"="+value)
+	}
+	return "?" + query.Encode()
+}
+
+// batchResult reads the response of a batch request, such as DELETE
+// /instances?all=true
+func batchResult(resp *http.Response, body []byte, err error) (*types.BatchResult, error) {
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var result types.BatchResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.BatchResult", string(body)), err)
+	}
+	return &result, nil
+}
+
+// batchQuery selects all the resources of provider, or of all providers if it is empty
+func batchQuery(provider string, params map[string]interface{}) string {
+	params["all"] = true
+	if provider != "" {
+		params["provider"] = provider
+	}
+	return buildQuery(params)
+}
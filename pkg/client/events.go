@@ -0,0 +1,96 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikgrpc "github.com/emc-advanced-dev/unik/pkg/daemon/grpc"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+type events struct {
+	unikIP     string
+	httpClient *http.Client
+}
+
+// EventStream reads events from the daemon's /events stream, or from the
+// StreamEvents call of its gRPC api
+type EventStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	grpc   unikgrpc.Unik_StreamEventsClient
+	// cancel closes the gRPC stream
+	cancel context.CancelFunc
+}
+
+// Recent returns the most recent events recorded by the daemon
+func (e *events) Recent() ([]*types.Event, error) {
+	query := buildQuery(map[string]interface{}{
+		"follow": false,
+	})
+	resp, body, err := httpGet(e.httpClient, e.unikIP, "/events"+query, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var events []*types.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type []*types.Event", string(body)), err)
+	}
+	return events, nil
+}
+
+// Follow opens a connection to the daemon that receives events as they happen
+func (e *events) Follow() (*EventStream, error) {
+	if grpcApi != nil {
+		return grpcFollowEvents()
+	}
+	resp, err := httpGetAsync(e.httpClient, e.unikIP, "/events", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New(fmt.Sprintf("failed with status %v", resp.StatusCode), nil)
+	}
+	return &EventStream{body: resp.Body, reader: bufio.NewReader(resp.Body)}, nil
+}
+
+// Next blocks until the next event arrives
+func (s *EventStream) Next() (*types.Event, error) {
+	if s.grpc != nil {
+		return s.nextGrpcEvent()
+	}
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "data:") {
+			//event names, keep-alives and blank separator lines
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		var event types.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, errors.New(fmt.Sprintf("event %s did not unmarshal to type *types.Event", data), err)
+		}
+		return &event, nil
+	}
+}
+
+func (s *EventStream) Close() error {
+	if s.grpc != nil {
+		s.cancel()
+		return nil
+	}
+	return s.body.Close()
+}
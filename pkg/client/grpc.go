@@ -0,0 +1,383 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikgrpc "github.com/emc-advanced-dev/unik/pkg/daemon/grpc"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcApi is the gRPC api set with UseGrpc, nil for REST only
+var grpcApi unikgrpc.UnikClient
+
+// UseGrpc makes the calls the gRPC api has (building, listing, getting and
+// deleting images; running, listing, getting, starting, stopping and deleting
+// instances and getting their logs; creating, listing, getting, deleting,
+// attaching and detaching volumes; and following events) over gRPC to addr, the
+// HOST:PORT of a daemon run with --grpc-addr. all other calls, and builds and
+// volumes with more data than a gRPC message takes, still use REST
+func UseGrpc(addr string) error {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(types.GrpcMaxMessageSize), grpc.MaxCallSendMsgSize(types.GrpcMaxMessageSize)),
+	)
+	if err != nil {
+		return errors.New("connecting to grpc api at "+addr, err)
+	}
+	grpcApi = unikgrpc.NewUnikClient(conn)
+	return nil
+}
+
+// grpcContext sends the user and bearer token along with a call, like
+// userTransport does for REST
+func grpcContext() context.Context {
+	ctx := context.Background()
+	if user != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, types.UserHeader, user)
+	}
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "Authorization", "Bearer "+token)
+	}
+	return ctx
+}
+
+func grpcError(err error) error {
+	if s, ok := status.FromError(err); ok {
+		return errors.New(fmt.Sprintf("failed with status %v: %s", s.Code(), s.Message()), nil)
+	}
+	return errors.New("grpc call failed", err)
+}
+
+func grpcMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+func fromGrpcImage(image *unikgrpc.Image) (*types.Image, error) {
+	var result types.Image
+	if err := json.Unmarshal(image.Json, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("image %s did not unmarshal to type *types.Image", string(image.Json)), err)
+	}
+	return &result, nil
+}
+
+func fromGrpcInstance(instance *unikgrpc.Instance) (*types.Instance, error) {
+	var result types.Instance
+	if err := json.Unmarshal(instance.Json, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("instance %s did not unmarshal to type *types.Instance", string(instance.Json)), err)
+	}
+	return &result, nil
+}
+
+func fromGrpcVolume(volume *unikgrpc.Volume) (*types.Volume, error) {
+	var result types.Volume
+	if err := json.Unmarshal(volume.Json, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("volume %s did not unmarshal to type *types.Volume", string(volume.Json)), err)
+	}
+	return &result, nil
+}
+
+// grpcFits tells whether the file at path can be sent in a gRPC message. larger
+// files go through the REST api, which streams them
+func grpcFits(path string) bool {
+	info, err := os.Stat(path)
+	// leave room for the other fields of the request
+	return err != nil || info.Size() < types.GrpcMaxMessageSize-1<<20
+}
+
+func grpcBuildImage(name, sourceTar string, opts BuildOptions) (*types.Image, error) {
+	sources, err := ioutil.ReadFile(sourceTar)
+	if err != nil {
+		return nil, errors.New("reading sources "+sourceTar, err)
+	}
+	image, err := grpcApi.BuildImage(grpcContext(), &unikgrpc.BuildImageRequest{
+		Name:        name,
+		Sources:     sources,
+		Base:        opts.Base,
+		Lang:        opts.Lang,
+		Provider:    opts.Provider,
+		Args:        opts.Args,
+		MountPoints: opts.Mounts,
+		Force:       opts.Force,
+		NoCleanup:   opts.NoCleanup,
+		Tags:        opts.Tags,
+		Arch:        string(opts.Arch),
+		Squash:      opts.Squash,
+		MemoryMb:    int32(opts.MemoryMb),
+		Vcpus:       int32(opts.VCPUs),
+		NetworkMode: string(opts.NetworkMode),
+		BaseImage:   opts.BaseImage,
+		Target:      opts.Target,
+		TimeoutMs:   grpcMillis(opts.Timeout),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return fromGrpcImage(image)
+}
+
+func grpcListImages(tags map[string]string) ([]*types.Image, error) {
+	response, err := grpcApi.ListImages(grpcContext(), &unikgrpc.ListImagesRequest{Tags: tags})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	images := []*types.Image{}
+	for _, image := range response.Images {
+		converted, err := fromGrpcImage(image)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, converted)
+	}
+	return images, nil
+}
+
+func grpcGetImage(id string) (*types.Image, error) {
+	image, err := grpcApi.GetImage(grpcContext(), &unikgrpc.GetImageRequest{NameOrId: id})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return fromGrpcImage(image)
+}
+
+func grpcDeleteImage(id string, force bool) error {
+	if _, err := grpcApi.DeleteImage(grpcContext(), &unikgrpc.DeleteImageRequest{NameOrId: id, Force: force}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func grpcRunInstance(request types.RunInstanceRequest) (*types.Instance, error) {
+	run := &unikgrpc.RunInstanceRequest{
+		InstanceName:   request.InstanceName,
+		ImageName:      request.ImageName,
+		Mounts:         request.Mounts,
+		Env:            request.Env,
+		MemoryMb:       int32(request.MemoryMb),
+		NoCleanup:      request.NoCleanup,
+		DebugMode:      request.DebugMode,
+		TtlMs:          grpcMillis(request.Ttl),
+		Vcpus:          int32(request.VCPUs),
+		NetworkMode:    string(request.NetworkMode),
+		Tags:           request.Tags,
+		Cmdline:        request.Cmdline,
+		CmdlineMode:    string(request.CmdlineMode),
+		ReadOnlyMounts: request.ReadOnlyMounts,
+		UserData:       request.UserData,
+		MetaData:       request.MetaData,
+		Group:          request.Group,
+		VolumeMode:     string(request.VolumeMode),
+	}
+	if policy := request.RestartPolicy; policy != nil {
+		run.RestartPolicy = &unikgrpc.RestartPolicy{
+			Mode:           string(policy.Mode),
+			MaxRestarts:    int32(policy.MaxRestarts),
+			BackoffSeconds: int32(policy.BackoffSeconds),
+		}
+	}
+	for _, port := range request.Ports {
+		run.Ports = append(run.Ports, &unikgrpc.PortMapping{HostPort: int32(port.HostPort), GuestPort: int32(port.GuestPort), Protocol: port.Protocol})
+	}
+	if ipv6 := request.IPv6; ipv6 != nil {
+		run.Ipv6 = &unikgrpc.IPv6Config{Address: ipv6.Address, PrefixLength: int32(ipv6.PrefixLength), Gateway: ipv6.Gateway}
+	}
+	if staticIP := request.StaticIP; staticIP != nil {
+		run.StaticIp = &unikgrpc.StaticIPConfig{Address: staticIP.Address, Gateway: staticIP.Gateway, Dns: staticIP.DNS}
+	}
+	instance, err := grpcApi.RunInstance(grpcContext(), run)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return fromGrpcInstance(instance)
+}
+
+func grpcListInstances(filter InstanceFilter, page InstancePage) ([]*types.Instance, int, error) {
+	list := &unikgrpc.ListInstancesRequest{
+		Tags:     filter.Tags,
+		Image:    filter.Image,
+		Provider: filter.Provider,
+		Group:    filter.Group,
+		Sort:     page.Sort,
+		Order:    page.Order,
+		Limit:    int32(page.Limit),
+		Offset:   int32(page.Offset),
+	}
+	for _, state := range filter.States {
+		list.States = append(list.States, string(state))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		list.CreatedAfterUnix = filter.CreatedAfter.Unix()
+	}
+	if !filter.CreatedBefore.IsZero() {
+		list.CreatedBeforeUnix = filter.CreatedBefore.Unix()
+	}
+	response, err := grpcApi.ListInstances(grpcContext(), list)
+	if err != nil {
+		return nil, 0, grpcError(err)
+	}
+	instances := []*types.Instance{}
+	for _, instance := range response.Instances {
+		converted, err := fromGrpcInstance(instance)
+		if err != nil {
+			return nil, 0, err
+		}
+		instances = append(instances, converted)
+	}
+	return instances, int(response.Total), nil
+}
+
+func grpcGetInstance(id string) (*types.Instance, error) {
+	instance, err := grpcApi.GetInstance(grpcContext(), &unikgrpc.GetInstanceRequest{NameOrId: id})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return fromGrpcInstance(instance)
+}
+
+func grpcStartInstance(id string) error {
+	if _, err := grpcApi.StartInstance(grpcContext(), &unikgrpc.InstanceIdRequest{InstanceId: id}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func grpcStopInstance(id string, timeout time.Duration) error {
+	if _, err := grpcApi.StopInstance(grpcContext(), &unikgrpc.StopInstanceRequest{InstanceId: id, TimeoutMs: grpcMillis(timeout)}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func grpcDeleteInstance(id string, force bool) error {
+	if _, err := grpcApi.DeleteInstance(grpcContext(), &unikgrpc.DeleteInstanceRequest{InstanceId: id, Force: force}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func grpcGetInstanceLogs(id string) (string, error) {
+	logs, err := grpcApi.GetInstanceLogs(grpcContext(), &unikgrpc.InstanceIdRequest{InstanceId: id})
+	if err != nil {
+		return "", grpcError(err)
+	}
+	return logs.Logs, nil
+}
+
+func grpcCreateVolume(name string, opts CreateVolumeOptions) (*types.Volume, error) {
+	create := &unikgrpc.CreateVolumeRequest{
+		Name:           name,
+		Provider:       opts.Provider,
+		SizeMb:         int32(opts.SizeMb),
+		Type:           opts.Type,
+		Raw:            opts.Raw,
+		NoCleanup:      opts.NoCleanup,
+		Mode:           string(opts.Mode),
+		PartitionTable: opts.PartitionTable,
+		Tags:           opts.Tags,
+	}
+	if opts.DataTar != "" {
+		data, err := ioutil.ReadFile(opts.DataTar)
+		if err != nil {
+			return nil, errors.New("reading volume data "+opts.DataTar, err)
+		}
+		create.Data = data
+	}
+	volume, err := grpcApi.CreateVolume(grpcContext(), create)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return fromGrpcVolume(volume)
+}
+
+func grpcListVolumes(filter VolumeFilter) ([]*types.Volume, error) {
+	response, err := grpcApi.ListVolumes(grpcContext(), &unikgrpc.ListVolumesRequest{
+		Provider:     filter.Provider,
+		Attached:     filter.Attached,
+		Unattached:   filter.Unattached,
+		NameContains: filter.NameContains,
+		Tags:         filter.Tags,
+		SizeGt:       filter.SizeGt,
+		SizeLt:       filter.SizeLt,
+		Sort:         filter.Sort,
+		Order:        filter.Order,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	volumes := []*types.Volume{}
+	for _, volume := range response.Volumes {
+		converted, err := fromGrpcVolume(volume)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, converted)
+	}
+	return volumes, nil
+}
+
+func grpcGetVolume(id string) (*types.Volume, error) {
+	volume, err := grpcApi.GetVolume(grpcContext(), &unikgrpc.GetVolumeRequest{NameOrId: id})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return fromGrpcVolume(volume)
+}
+
+func grpcDeleteVolume(id string, force bool) error {
+	if _, err := grpcApi.DeleteVolume(grpcContext(), &unikgrpc.DeleteVolumeRequest{NameOrId: id, Force: force}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func grpcAttachVolume(id, instanceId, mountPoint string) error {
+	if _, err := grpcApi.AttachVolume(grpcContext(), &unikgrpc.AttachVolumeRequest{Volume: id, InstanceId: instanceId, MountPoint: mountPoint}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func grpcDetachVolume(id string) error {
+	if _, err := grpcApi.DetachVolume(grpcContext(), &unikgrpc.DetachVolumeRequest{Volume: id}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func grpcFollowEvents() (*EventStream, error) {
+	ctx, cancel := context.WithCancel(grpcContext())
+	stream, err := grpcApi.StreamEvents(ctx, &unikgrpc.StreamEventsRequest{})
+	if err != nil {
+		cancel()
+		return nil, grpcError(err)
+	}
+	return &EventStream{grpc: stream, cancel: cancel}, nil
+}
+
+// nextGrpcEvent blocks until the next event of the gRPC stream arrives
+func (s *EventStream) nextGrpcEvent() (*types.Event, error) {
+	received, err := s.grpc.Recv()
+	if err != nil {
+		return nil, err
+	}
+	event := &types.Event{
+		Type:      types.EventType(received.Type),
+		Id:        received.Id,
+		Timestamp: time.Unix(received.TimestampUnix, 0),
+	}
+	if len(received.Payload) > 0 {
+		if err := json.Unmarshal(received.Payload, &event.Payload); err != nil {
+			return nil, errors.New(fmt.Sprintf("payload %s of event did not unmarshal", string(received.Payload)), err)
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// the requests to the daemon go through the *http.Client of the UnikClient that
+// makes them, not http.DefaultClient, so that the retries, user headers and tls
+// settings of this package don't apply to other requests of the process. these
+// send requests like their lxhttpclient counterparts, without retrying them
+
+func daemonURL(unikIP, path string) string {
+	if !strings.HasPrefix(unikIP, "http://") && !strings.HasPrefix(unikIP, "https://") {
+		unikIP = "http://" + unikIP
+	}
+	return strings.TrimSuffix(unikIP, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func httpDo(c *http.Client, req *http.Request) (*http.Response, []byte, error) {
+	resp, err := c.Do(req)
+	if err != nil {
+		return resp, nil, errors.New("performing "+req.Method+" request", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, errors.New("reading response body", err)
+	}
+	return resp, body, nil
+}
+
+func newRequest(method, unikIP, path string, headers map[string]string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, daemonURL(unikIP, path), body)
+	if err != nil {
+		return nil, errors.New("creating "+method+" request", err)
+	}
+	for key, value := range headers {
+		req.Header.Add(key, value)
+	}
+	return req, nil
+}
+
+func httpGet(c *http.Client, unikIP, path string, headers map[string]string) (*http.Response, []byte, error) {
+	req, err := newRequest("GET", unikIP, path, headers, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return httpDo(c, req)
+}
+
+// httpGetAsync returns the response without reading its body, for streams
+func httpGetAsync(c *http.Client, unikIP, path string, headers map[string]string) (*http.Response, error) {
+	req, err := newRequest("GET", unikIP, path, headers, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return resp, errors.New("performing GET request", err)
+	}
+	return resp, nil
+}
+
+func httpDelete(c *http.Client, unikIP, path string, headers map[string]string) (*http.Response, []byte, error) {
+	req, err := newRequest("DELETE", unikIP, path, headers, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return httpDo(c, req)
+}
+
+// httpPost sends message as the json body of a POST request
+func httpPost(c *http.Client, unikIP, path string, headers map[string]string, message interface{}) (*http.Response, []byte, error) {
+	return httpSend(c, "POST", unikIP, path, headers, message)
+}
+
+// httpPatch sends message as the json body of a PATCH request
+func httpPatch(c *http.Client, unikIP, path string, message interface{}) (*http.Response, []byte, error) {
+	return httpSend(c, "PATCH", unikIP, path, map[string]string{"Content-Type": "application/json"}, message)
+}
+
+func httpSend(c *http.Client, method, unikIP, path string, headers map[string]string, message interface{}) (*http.Response, []byte, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, nil, errors.New("marshalling request body", err)
+	}
+	req, err := newRequest(method, unikIP, path, headers, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return httpDo(c, req)
+}
+
+// httpPostFile uploads the file at pathToFile as the form file fileKey of a
+// multipart POST request. the file is streamed, not read into memory, so the
+// request is sent once; a RetryTransport cannot replay it
+func httpPostFile(c *http.Client, unikIP, path, fileKey, pathToFile string) (*http.Response, []byte, error) {
+	file, err := os.Open(pathToFile)
+	if err != nil {
+		return nil, nil, errors.New("opening "+pathToFile, err)
+	}
+	defer file.Close()
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		part, err := form.CreateFormFile(fileKey, pathToFile)
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	req, err := newRequest("POST", unikIP, path, map[string]string{"Content-Type": form.FormDataContentType()}, reader)
+	if err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+	resp, body, err := httpDo(c, req)
+	// stops the copy if the daemon answered before reading all of the file
+	reader.Close()
+	return resp, body, err
+}
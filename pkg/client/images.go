@@ -6,17 +6,27 @@ import (
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/config"
 	"github.com/emc-advanced-dev/unik/pkg/types"
-	"github.com/layer-x/layerx-commons/lxhttpclient"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
 type images struct {
-	unikIP string
+	unikIP     string
+	httpClient *http.Client
 }
 
 func (i *images) All() ([]*types.Image, error) {
-	resp, body, err := lxhttpclient.Get(i.unikIP, "/images", nil)
+	return i.List(nil)
+}
+
+// List returns the images that have all of the given tags
+func (i *images) List(tags map[string]string) ([]*types.Image, error) {
+	if grpcApi != nil {
+		return grpcListImages(tags)
+	}
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/images"+tagQuery(tags), nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -30,8 +40,41 @@ func (i *images) All() ([]*types.Image, error) {
 	return images, nil
 }
 
+func (i *images) Tag(id string, tags map[string]string) (*types.Image, error) {
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/images/"+id+"/tags", nil, types.TagRequest{Tags: tags})
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var image types.Image
+	if err := json.Unmarshal(body, &image); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Image", string(body)), err)
+	}
+	return &image, nil
+}
+
+func (i *images) Untag(id, key string) (*types.Image, error) {
+	resp, body, err := httpDelete(i.httpClient, i.unikIP, "/images/"+id+"/tags/"+url.QueryEscape(key), nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var image types.Image
+	if err := json.Unmarshal(body, &image); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Image", string(body)), err)
+	}
+	return &image, nil
+}
+
 func (i *images) Get(id string) (*types.Image, error) {
-	resp, body, err := lxhttpclient.Get(i.unikIP, "/images/"+id, nil)
+	if grpcApi != nil {
+		return grpcGetImage(id)
+	}
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/images/"+id, nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -45,17 +88,178 @@ func (i *images) Get(id string) (*types.Image, error) {
 	return &image, nil
 }
 
-func (i *images) Build(name, sourceTar, base, lang, provider, args string, mounts []string, force, noCleanup bool) (*types.Image, error) {
+func (i *images) Diff(from, to string) (*types.ImageDiff, error) {
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/images/"+from+"/diff/"+to, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var diff types.ImageDiff
+	if err := json.Unmarshal(body, &diff); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ImageDiff", string(body)), err)
+	}
+	return &diff, nil
+}
+
+func (i *images) Validate(name string, boot bool) (*types.ValidationReport, error) {
 	query := buildQuery(map[string]interface{}{
-		"base":       base,
-		"lang":       lang,
-		"provider":   provider,
-		"args":       args,
-		"mounts":     strings.Join(mounts, ","),
-		"force":      force,
-		"no_cleanup": noCleanup,
+		"boot": boot,
 	})
-	resp, body, err := lxhttpclient.PostFile(i.unikIP, "/images/"+name+"/create"+query, "tarfile", sourceTar)
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/images/"+name+"/validate"+query, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var report types.ValidationReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ValidationReport", string(body)), err)
+	}
+	return &report, nil
+}
+
+// Digest returns the sha256 of the boot disk of an image, which Sign signs
+func (i *images) Digest(name string) (*types.ImageDigest, error) {
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/images/"+name+"/digest", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var digest types.ImageDigest
+	if err := json.Unmarshal(body, &digest); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ImageDigest", string(body)), err)
+	}
+	return &digest, nil
+}
+
+// Sign stores a base64 signature of the digest of an image with the daemon,
+// which checks it with publicKeyPem first
+func (i *images) Sign(name, signature, publicKeyPem string) (*types.Image, error) {
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/images/"+name+"/signature", nil, types.SignImageRequest{Signature: signature, PublicKey: publicKeyPem})
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var image types.Image
+	if err := json.Unmarshal(body, &image); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Image", string(body)), err)
+	}
+	return &image, nil
+}
+
+// Signature returns the signature the daemon keeps for a signed image
+func (i *images) Signature(name string) (*types.ImageSignature, error) {
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/images/"+name+"/signature", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var signature types.ImageSignature
+	if err := json.Unmarshal(body, &signature); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ImageSignature", string(body)), err)
+	}
+	return &signature, nil
+}
+
+// GC runs the daemon's image garbage collector now and returns what it deleted
+func (i *images) GC() (*types.ImageGCStatus, error) {
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/admin/gc/images", nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var status types.ImageGCStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ImageGCStatus", string(body)), err)
+	}
+	return &status, nil
+}
+
+// Prune deletes the unused images selected by opts, or with opts.DryRun lists them
+func (i *images) Prune(opts types.PruneOptions) (*types.PruneResult, error) {
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/images/prune", nil, opts)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var result types.PruneResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.PruneResult", string(body)), err)
+	}
+	return &result, nil
+}
+
+// GCStatus returns what the last run of the daemon's image garbage collector did
+func (i *images) GCStatus() (*types.ImageGCStatus, error) {
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/admin/gc/status", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var status types.ImageGCStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ImageGCStatus", string(body)), err)
+	}
+	return &status, nil
+}
+
+// BuildOptions are the parameters of a build other than the name of the image
+// and its sources. Zero fields take the defaults of the daemon and compiler
+type BuildOptions struct {
+	Base     string
+	Lang     string
+	Provider string
+	// Args are passed to the unikernel at runtime
+	Args   string
+	Mounts []string
+	Force  bool
+	// NoCleanup keeps the uploaded sources and build artifacts on the daemon host
+	NoCleanup bool
+	Tags      map[string]string
+	Arch      types.Architecture
+	// Squash copies the volumes into the boot partition instead of attaching them
+	Squash bool
+	// MemoryMb, VCPUs and NetworkMode are the defaults of instances of the image
+	MemoryMb    int
+	VCPUs       int
+	NetworkMode types.NetworkMode
+	// BaseImage is an image to build on
+	BaseImage string
+	// Target is the target of compilers that build for several
+	Target string
+	// Timeout fails the build if it takes longer. 0 never times out
+	Timeout time.Duration
+}
+
+func (i *images) Build(name, sourceTar string, opts BuildOptions) (*types.Image, error) {
+	if grpcApi != nil && grpcFits(sourceTar) {
+		return grpcBuildImage(name, sourceTar, opts)
+	}
+	params, err := buildParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	params["no_cleanup"] = opts.NoCleanup
+	if opts.Timeout > 0 {
+		params["timeout"] = opts.Timeout
+	}
+	query := buildQuery(params)
+	resp, body, err := httpPostFile(i.httpClient, i.unikIP, "/images/"+name+"/create"+query, "tarfile", sourceTar)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -69,11 +273,79 @@ func (i *images) Build(name, sourceTar, base, lang, provider, args string, mount
 	return &image, nil
 }
 
+// BuildPlan validates a build like Build, without uploading the sources or
+// building anything. sourcesSize is the size of the sources in bytes, which the
+// size of the image is estimated from. NoCleanup and Timeout of opts are unused
+func (i *images) BuildPlan(name string, opts BuildOptions, sourcesSize int64) (*types.BuildPlan, error) {
+	params, err := buildParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	params["dry_run"] = true
+	params["sources_size"] = sourcesSize
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/images/"+name+"/create"+buildQuery(params), nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), err)
+	}
+	var plan types.BuildPlan
+	if err := json.Unmarshal(body, &plan); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.BuildPlan", string(body)), err)
+	}
+	return &plan, nil
+}
+
+// the query parameters of builds and their dry runs
+func buildParams(opts BuildOptions) (map[string]interface{}, error) {
+	tagsJson, err := json.Marshal(opts.Tags)
+	if err != nil {
+		return nil, errors.New("marshalling tags", err)
+	}
+	return map[string]interface{}{
+		"tags":         string(tagsJson),
+		"base":         opts.Base,
+		"lang":         opts.Lang,
+		"provider":     opts.Provider,
+		"args":         opts.Args,
+		"mounts":       strings.Join(opts.Mounts, ","),
+		"force":        opts.Force,
+		"arch":         opts.Arch,
+		"squash":       opts.Squash,
+		"memory":       opts.MemoryMb,
+		"vcpus":        opts.VCPUs,
+		"network_mode": opts.NetworkMode,
+		"base_image":   opts.BaseImage,
+		"target":       opts.Target,
+	}, nil
+}
+
+// Rename changes the name of an image. images identified by their name (e.g. on
+// qemu) get a new id too
+func (i *images) Rename(id, newName string) (*types.Image, error) {
+	resp, body, err := httpPatch(i.httpClient, i.unikIP, "/images/"+id, types.RenameImageRequest{Name: newName})
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), err)
+	}
+	var image types.Image
+	if err := json.Unmarshal(body, &image); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Image", string(body)), err)
+	}
+	return &image, nil
+}
+
 func (i *images) Delete(id string, force bool) error {
+	if grpcApi != nil {
+		return grpcDeleteImage(id, force)
+	}
 	query := buildQuery(map[string]interface{}{
 		"force": force,
 	})
-	resp, body, err := lxhttpclient.Delete(i.unikIP, "/images/"+id+query, nil)
+	resp, body, err := httpDelete(i.httpClient, i.unikIP, "/images/"+id+query, nil)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -83,8 +355,14 @@ func (i *images) Delete(id string, force bool) error {
 	return nil
 }
 
+// DeleteAll deletes every image of provider, or of all providers if it is empty
+func (i *images) DeleteAll(provider string, force bool) (*types.BatchResult, error) {
+	query := batchQuery(provider, map[string]interface{}{"force": force})
+	return batchResult(httpDelete(i.httpClient, i.unikIP, "/images"+query, nil))
+}
+
 func (i *images) Push(c config.HubConfig, imageName string) error {
-	resp, body, err := lxhttpclient.Post(i.unikIP, "/images/push/"+imageName, nil, c)
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/images/push/"+imageName, nil, c)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -99,7 +377,7 @@ func (i *images) Pull(c config.HubConfig, imageName, provider string, force bool
 		"provider": provider,
 		"force":    force,
 	})
-	resp, body, err := lxhttpclient.Post(i.unikIP, "/images/pull/"+imageName+query, nil, c)
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/images/pull/"+imageName+query, nil, c)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -110,7 +388,7 @@ func (i *images) Pull(c config.HubConfig, imageName, provider string, force bool
 }
 
 func (i *images) RemoteDelete(c config.HubConfig, imageName string) error {
-	resp, body, err := lxhttpclient.Post(i.unikIP, "/images/remote-delete/"+imageName, nil, c)
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/images/remote-delete/"+imageName, nil, c)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -4,34 +4,160 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/emc-advanced-dev/pkg/errors"
-	"github.com/emc-advanced-dev/unik/pkg/daemon"
 	"github.com/emc-advanced-dev/unik/pkg/types"
-	"github.com/layer-x/layerx-commons/lxhttpclient"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type instances struct {
-	unikIP string
+	unikIP     string
+	httpClient *http.Client
+}
+
+// InstancePage selects which instances the daemon returns, and in which order.
+// Sort is one of name, created, state or provider; Order is asc or desc.
+// A Limit of 0 returns all instances.
+type InstancePage struct {
+	Sort   string
+	Order  string
+	Limit  int
+	Offset int
+}
+
+// InstanceFilter selects the instances a list returns. An instance is listed
+// if it has all of Tags.
+// InstanceFilter selects the instances returned by List. Zero fields select all instances.
+type InstanceFilter struct {
+	Tags map[string]string
+	// Image is the name or id of the image of the instances
+	Image    string
+	Provider string
+	// States selects instances in any of these states
+	States        []types.InstanceState
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Group selects the instances run with run --group
+	Group string
+}
+
+func (f InstanceFilter) addTo(query url.Values) {
+	for key, value := range f.Tags {
+		query.Add("tag", key+"="+value)
+	}
+	if f.Image != "" {
+		query.Set("image", f.Image)
+	}
+	if f.Provider != "" {
+		query.Set("provider", f.Provider)
+	}
+	if len(f.States) > 0 {
+		states := []string{}
+		for _, state := range f.States {
+			states = append(states, string(state))
+		}
+		query.Set("state", strings.Join(states, ","))
+	}
+	if !f.CreatedAfter.IsZero() {
+		query.Set("created_after", f.CreatedAfter.Format(time.RFC3339))
+	}
+	if !f.CreatedBefore.IsZero() {
+		query.Set("created_before", f.CreatedBefore.Format(time.RFC3339))
+	}
+	if f.Group != "" {
+		query.Set("group", f.Group)
+	}
+}
+
+func (p InstancePage) addTo(query url.Values) {
+	if p.Sort != "" {
+		query.Set("sort", p.Sort)
+	}
+	if p.Order != "" {
+		query.Set("order", p.Order)
+	}
+	if p.Limit > 0 {
+		query.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Offset > 0 {
+		query.Set("offset", strconv.Itoa(p.Offset))
+	}
 }
 
 func (i *instances) All() ([]*types.Instance, error) {
-	resp, body, err := lxhttpclient.Get(i.unikIP, "/instances", nil)
+	instances, _, err := i.List(InstanceFilter{}, InstancePage{})
+	return instances, err
+}
+
+// List returns one page of the instances matching filter, along with the total
+// number of matching instances
+func (i *instances) List(filter InstanceFilter, page InstancePage) ([]*types.Instance, int, error) {
+	if grpcApi != nil {
+		return grpcListInstances(filter, page)
+	}
+	query := url.Values{}
+	filter.addTo(query)
+	page.addTo(query)
+	path := "/instances"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	resp, body, err := httpGet(i.httpClient, i.unikIP, path, nil)
 	if err != nil {
-		return nil, errors.New("request failed", err)
+		return nil, 0, errors.New("request failed", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+		return nil, 0, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
 	}
 	var instances []*types.Instance
 	if err := json.Unmarshal(body, &instances); err != nil {
-		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type []*types.Instance", string(body)), err)
+		return nil, 0, errors.New(fmt.Sprintf("response body %s did not unmarshal to type []*types.Instance", string(body)), err)
+	}
+	total, err := strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	if err != nil {
+		total = len(instances)
+	}
+	return instances, total, nil
+}
+
+func (i *instances) Tag(id string, tags map[string]string) (*types.Instance, error) {
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/"+id+"/tags", nil, types.TagRequest{Tags: tags})
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var instance types.Instance
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Instance", string(body)), err)
+	}
+	return &instance, nil
+}
+
+func (i *instances) Untag(id, key string) (*types.Instance, error) {
+	resp, body, err := httpDelete(i.httpClient, i.unikIP, "/instances/"+id+"/tags/"+url.QueryEscape(key), nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
 	}
-	return instances, nil
+	var instance types.Instance
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Instance", string(body)), err)
+	}
+	return &instance, nil
 }
 
 func (i *instances) Get(id string) (*types.Instance, error) {
-	resp, body, err := lxhttpclient.Get(i.unikIP, "/instances/"+id, nil)
+	if grpcApi != nil {
+		return grpcGetInstance(id)
+	}
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/instances/"+id, nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -45,11 +171,66 @@ func (i *instances) Get(id string) (*types.Instance, error) {
 	return &instance, nil
 }
 
+// Network lists the network interfaces of an instance
+func (i *instances) Network(id string) ([]types.NetworkInterface, error) {
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/instances/"+id+"/network", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var interfaces []types.NetworkInterface
+	if err := json.Unmarshal(body, &interfaces); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type []types.NetworkInterface", string(body)), err)
+	}
+	return interfaces, nil
+}
+
+// Metrics samples the cpu and memory usage of a running instance
+func (i *instances) Metrics(id string) (*types.InstanceMetrics, error) {
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/instances/"+id+"/metrics", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var metrics types.InstanceMetrics
+	if err := json.Unmarshal(body, &metrics); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.InstanceMetrics", string(body)), err)
+	}
+	return &metrics, nil
+}
+
+// Benchmark measures the network throughput and disk write speed of a running
+// instance, running the iperf3 test for duration
+func (i *instances) Benchmark(id string, duration time.Duration) (*types.BenchmarkResult, error) {
+	query := buildQuery(map[string]interface{}{
+		"duration": duration,
+	})
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/"+id+"/benchmark"+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var result types.BenchmarkResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.BenchmarkResult", string(body)), err)
+	}
+	return &result, nil
+}
+
 func (i *instances) Delete(id string, force bool) error {
+	if grpcApi != nil {
+		return grpcDeleteInstance(id, force)
+	}
 	query := buildQuery(map[string]interface{}{
 		"force": force,
 	})
-	resp, body, err := lxhttpclient.Delete(i.unikIP, "/instances/"+id+query, nil)
+	resp, body, err := httpDelete(i.httpClient, i.unikIP, "/instances/"+id+query, nil)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -59,8 +240,18 @@ func (i *instances) Delete(id string, force bool) error {
 	return nil
 }
 
+// DeleteAll deletes every instance of provider, or of all providers if it is
+// empty. the instances that could not be deleted are listed in the result
+func (i *instances) DeleteAll(provider string, force bool) (*types.BatchResult, error) {
+	query := batchQuery(provider, map[string]interface{}{"force": force})
+	return batchResult(httpDelete(i.httpClient, i.unikIP, "/instances"+query, nil))
+}
+
 func (i *instances) GetLogs(id string) (string, error) {
-	resp, body, err := lxhttpclient.Get(i.unikIP, "/instances/"+id+"/logs", nil)
+	if grpcApi != nil {
+		return grpcGetInstanceLogs(id)
+	}
+	resp, body, err := httpGet(i.httpClient, i.unikIP, "/instances/"+id+"/logs", nil)
 	if err != nil {
 		return "", errors.New("request failed", err)
 	}
@@ -75,7 +266,7 @@ func (i *instances) AttachLogs(id string, deleteOnDisconnect bool) (io.ReadClose
 		"follow": true,
 		"delete": deleteOnDisconnect,
 	})
-	resp, err := lxhttpclient.GetAsync(i.unikIP, "/instances/"+id+"/logs"+query, nil)
+	resp, err := httpGetAsync(i.httpClient, i.unikIP, "/instances/"+id+"/logs"+query, nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -85,17 +276,33 @@ func (i *instances) AttachLogs(id string, deleteOnDisconnect bool) (io.ReadClose
 	return resp.Body, nil
 }
 
-func (i *instances) Run(instanceName, imageName string, mountPointsToVols, env map[string]string, memoryMb int, noCleanup, debugMode bool) (*types.Instance, error) {
-	runInstanceRequest := daemon.RunInstanceRequest{
-		InstanceName: instanceName,
-		ImageName:    imageName,
-		Mounts:       mountPointsToVols,
-		Env:          env,
-		MemoryMb:     memoryMb,
-		NoCleanup:    noCleanup,
-		DebugMode:    debugMode,
+// Run runs an instance of request.ImageName. Zero fields of request take the
+// defaults of the image and provider
+func (i *instances) Run(request types.RunInstanceRequest) (*types.Instance, error) {
+	if grpcApi != nil {
+		return grpcRunInstance(request)
+	}
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/run", nil, request)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), err)
+	}
+	var instance types.Instance
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Instance", string(body)), err)
 	}
-	resp, body, err := lxhttpclient.Post(i.unikIP, "/instances/run", nil, runInstanceRequest)
+	return &instance, nil
+}
+
+// Clone runs a copy of an instance. provider may be empty to clone onto the source instance's provider
+func (i *instances) Clone(id, newName, provider string) (*types.Instance, error) {
+	query := buildQuery(map[string]interface{}{
+		"name":     newName,
+		"provider": provider,
+	})
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/"+id+"/clone"+query, nil, nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -110,7 +317,10 @@ func (i *instances) Run(instanceName, imageName string, mountPointsToVols, env m
 }
 
 func (i *instances) Start(id string) error {
-	resp, body, err := lxhttpclient.Post(i.unikIP, "/instances/"+id+"/start", nil, nil)
+	if grpcApi != nil {
+		return grpcStartInstance(id)
+	}
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/"+id+"/start", nil, nil)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -120,8 +330,95 @@ func (i *instances) Start(id string) error {
 	return nil
 }
 
-func (i *instances) Stop(id string) error {
-	resp, body, err := lxhttpclient.Post(i.unikIP, "/instances/"+id+"/stop", nil, nil)
+// Restart stops an instance, giving it timeout to shut down as Stop does, and
+// starts it again with the same id
+func (i *instances) Restart(id string, timeout time.Duration) (*types.Instance, error) {
+	query := ""
+	if timeout > 0 {
+		query = buildQuery(map[string]interface{}{"timeout": timeout.String()})
+	}
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/"+id+"/restart"+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), err)
+	}
+	var instance types.Instance
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Instance", string(body)), err)
+	}
+	return &instance, nil
+}
+
+// Suspend saves the state of an instance to the snapshot snapshotName (the
+// default of the provider if empty) and stops it
+func (i *instances) Suspend(id, snapshotName string) (*types.Instance, error) {
+	return i.snapshotAction(id, "suspend", snapshotName)
+}
+
+// Resume starts a suspended instance from the snapshot snapshotName, or from the
+// one it was suspended to if empty. the instance may have a new id afterwards
+func (i *instances) Resume(id, snapshotName string) (*types.Instance, error) {
+	return i.snapshotAction(id, "resume", snapshotName)
+}
+
+// Migrate moves a running instance to another host. destination is the HOST:PORT
+// qemu sends the state of the instance to, and destinationDaemon the address of
+// the daemon of that host (HOST:3000 if empty). it returns the instance on the
+// destination daemon, with its id there
+func (i *instances) Migrate(id, destination, destinationDaemon string, timeout time.Duration) (*types.Instance, error) {
+	params := map[string]interface{}{"destination": destination}
+	if destinationDaemon != "" {
+		params["destination_daemon"] = destinationDaemon
+	}
+	if timeout > 0 {
+		params["timeout"] = timeout.String()
+	}
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/"+id+"/migrate"+buildQuery(params), nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), err)
+	}
+	var instance types.Instance
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Instance", string(body)), err)
+	}
+	return &instance, nil
+}
+
+func (i *instances) snapshotAction(id, action, snapshotName string) (*types.Instance, error) {
+	query := ""
+	if snapshotName != "" {
+		query = buildQuery(map[string]interface{}{"snapshot_name": snapshotName})
+	}
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/"+id+"/"+action+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), err)
+	}
+	var instance types.Instance
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Instance", string(body)), err)
+	}
+	return &instance, nil
+}
+
+// Stop stops an instance. with a timeout, instances of providers that support it
+// are asked to shut down first, and only forced to stop once the timeout runs out.
+func (i *instances) Stop(id string, timeout time.Duration) error {
+	if grpcApi != nil {
+		return grpcStopInstance(id, timeout)
+	}
+	query := ""
+	if timeout > 0 {
+		query = buildQuery(map[string]interface{}{"timeout": timeout.String()})
+	}
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/instances/"+id+"/stop"+query, nil, nil)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -130,3 +427,52 @@ func (i *instances) Stop(id string) error {
 	}
 	return nil
 }
+
+// StopAll stops every instance of provider (or of all providers) that is not
+// stopped already, as Stop
+func (i *instances) StopAll(provider string, timeout time.Duration) (*types.BatchResult, error) {
+	params := map[string]interface{}{}
+	if timeout > 0 {
+		params["timeout"] = timeout.String()
+	}
+	return batchResult(httpPost(i.httpClient, i.unikIP, "/instances/stop"+batchQuery(provider, params), nil, nil))
+}
+
+// StopGroup stops the instances of group that are running, as StopAll
+func (i *instances) StopGroup(group string, timeout time.Duration) (*types.BatchResult, error) {
+	return batchResult(httpPost(i.httpClient, i.unikIP, "/groups/"+url.PathEscape(group)+"/stop"+groupQuery(map[string]interface{}{}, timeout), nil, nil))
+}
+
+// RestartGroup restarts the running instances of group one at a time
+func (i *instances) RestartGroup(group string, timeout time.Duration) (*types.BatchResult, error) {
+	return batchResult(httpPost(i.httpClient, i.unikIP, "/groups/"+url.PathEscape(group)+"/restart"+groupQuery(map[string]interface{}{}, timeout), nil, nil))
+}
+
+// ScaleGroup starts or stops instances of group until to of them are running,
+// running new instances like the oldest one of the group if needed. timeout is
+// the time each stopped instance is given to shut down
+func (i *instances) ScaleGroup(group string, to int, timeout time.Duration) (*types.ScaleResult, error) {
+	query := groupQuery(map[string]interface{}{"to": to}, timeout)
+	resp, body, err := httpPost(i.httpClient, i.unikIP, "/groups/"+url.PathEscape(group)+"/scale"+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var result types.ScaleResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ScaleResult", string(body)), err)
+	}
+	return &result, nil
+}
+
+func groupQuery(params map[string]interface{}, timeout time.Duration) string {
+	if timeout > 0 {
+		params["timeout"] = timeout.String()
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return buildQuery(params)
+}
@@ -140,7 +140,14 @@ var _ = Describe("Instances", func() {
 									env := map[string]string{"FOO": "BAR"}
 									memoryMb := 128
 									mountPointsToVols := map[string]string{"/volume": volume.Id}
-									instance, err := c.Instances().Run(instanceName, image.Name, mountPointsToVols, env, memoryMb, noCleanup, false)
+									instance, err := c.Instances().Run(types.RunInstanceRequest{
+										InstanceName: instanceName,
+										ImageName:    image.Name,
+										Mounts:       mountPointsToVols,
+										Env:          env,
+										MemoryMb:     memoryMb,
+										NoCleanup:    noCleanup,
+									})
 									Expect(err).ToNot(HaveOccurred())
 									instances, err := c.Instances().All()
 									Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+type manifests struct {
+	unikIP     string
+	httpClient *http.Client
+}
+
+func (m *manifests) All() ([]*types.ImageManifest, error) {
+	resp, body, err := httpGet(m.httpClient, m.unikIP, "/manifests", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var manifests []*types.ImageManifest
+	if err := json.Unmarshal(body, &manifests); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type []*types.ImageManifest", string(body)), err)
+	}
+	return manifests, nil
+}
+
+func (m *manifests) Get(name string) (*types.ImageManifest, error) {
+	resp, body, err := httpGet(m.httpClient, m.unikIP, "/manifests/"+name, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var manifest types.ImageManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ImageManifest", string(body)), err)
+	}
+	return &manifest, nil
+}
+
+// Create ties the images (given by name or id for each architecture) together under name
+func (m *manifests) Create(name string, images map[types.Architecture]string) (*types.ImageManifest, error) {
+	createManifestRequest := types.CreateManifestRequest{
+		Name:   name,
+		Images: images,
+	}
+	resp, body, err := httpPost(m.httpClient, m.unikIP, "/manifests", nil, createManifestRequest)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var manifest types.ImageManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.ImageManifest", string(body)), err)
+	}
+	return &manifest, nil
+}
+
+func (m *manifests) Delete(name string) error {
+	resp, body, err := httpDelete(m.httpClient, m.unikIP, "/manifests/"+name, nil)
+	if err != nil {
+		return errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	return nil
+}
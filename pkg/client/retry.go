@@ -0,0 +1,128 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	DefaultMaxRetries     = 4
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 8 * time.Second
+)
+
+// RetryTransport wraps an http.RoundTripper and retries requests that fail
+// because the daemon is unreachable (connection refused, e.g. during a restart).
+// Timeouts and 5xx responses are retried too, but only for GET, HEAD and DELETE
+// requests, since repeating any other request could e.g. create a second instance.
+// Requests whose body cannot be read again (http.Request.GetBody), such as the
+// streamed uploads of builds and volumes, are never retried. The wait between attempts starts at InitialBackoff and doubles up to MaxBackoff.
+type RetryTransport struct {
+	Base           http.RoundTripper
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// NewRetryTransport returns a RetryTransport with the default retries that sends
+// requests with base, or with the transport SetTLS configures if base is nil
+func NewRetryTransport(base http.RoundTripper) *RetryTransport {
+	if base == nil {
+		base = daemonTransport
+	}
+	return &RetryTransport{
+		Base:           base,
+		MaxRetries:     DefaultMaxRetries,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+	}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		try := req
+		if attempt > 0 && req.GetBody != nil {
+			// a RoundTripper must not modify the request it is given
+			try = req.Clone(req.Context())
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			try.Body = body
+		}
+		resp, err := t.Base.RoundTrip(try)
+		if attempt >= t.MaxRetries || !t.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		logrus.WithFields(logrus.Fields{
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"backoff": backoff,
+			"err":     err,
+		}).Debugf("retrying request to unik daemon")
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > t.MaxBackoff {
+			backoff = t.MaxBackoff
+		}
+	}
+}
+
+func (t *RetryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		if isConnectionRefused(err) {
+			return true
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return isIdempotent(req.Method)
+		}
+		return false
+	}
+	if resp.StatusCode == http.StatusServiceUnavailable && (req.URL.Path == "/healthz" || req.URL.Path == "/readyz") {
+		// an unhealthy daemon answers health checks with 503; asking again won't help
+		return false
+	}
+	return resp.StatusCode >= 500 && isIdempotent(req.Method)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "DELETE":
+		return true
+	}
+	return false
+}
+
+func isConnectionRefused(err error) bool {
+	opErr, ok := err.(*net.OpError)
+	if !ok {
+		return false
+	}
+	if sysErr, ok := opErr.Err.(*os.SyscallError); ok {
+		return sysErr.Err == syscall.ECONNREFUSED
+	}
+	return opErr.Err == syscall.ECONNREFUSED
+}
+
+// retryTransport is the RetryTransport of the UnikClients made from now on, nil
+// if they don't retry
+var retryTransport = NewRetryTransport(nil)
+
+// SetRetryTransport sets the RetryTransport of the UnikClients made from now on.
+// Pass nil to disable retrying altogether. Clients retry with the default
+// RetryTransport if this is never called.
+func SetRetryTransport(transport *RetryTransport) {
+	retryTransport = transport
+}
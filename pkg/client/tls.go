@@ -0,0 +1,48 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// SetTLS makes requests to https:// hosts present the client certificate in
+// certFile and keyFile, and verify the certificate of the daemon with the ca in
+// caFile. any of them may be empty. the settings apply to daemonTransport, which
+// all requests to the daemon go through
+func SetTLS(certFile, keyFile, caFile string) error {
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil
+	}
+	tlsConfig := &tls.Config{}
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return errors.New("tls_cert and tls_key must be given together", nil)
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return errors.New("loading client certificate "+certFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return errors.New("reading ca "+caFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.New("no pem certificates found in "+caFile, nil)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	daemonTransport.TLSClientConfig = tlsConfig
+	return nil
+}
+
+// daemonTransport sends the requests of all UnikClients. it is a transport of
+// its own so that SetTLS leaves http.DefaultTransport alone
+var daemonTransport = http.DefaultTransport.(*http.Transport).Clone()
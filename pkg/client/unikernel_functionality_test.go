@@ -129,7 +129,14 @@ var _ = Describe("Unikernel Functionality", func() {
 							noCleanup := false
 							env := map[string]string{"KEY": "VAL"}
 							memoryMb := 256
-							instance, err := c.Instances().Run(instanceName, image.Name, mountPointsToVols, env, memoryMb, noCleanup, false)
+							instance, err := c.Instances().Run(types.RunInstanceRequest{
+								InstanceName: instanceName,
+								ImageName:    image.Name,
+								Mounts:       mountPointsToVols,
+								Env:          env,
+								MemoryMb:     memoryMb,
+								NoCleanup:    noCleanup,
+							})
 							Expect(err).ToNot(HaveOccurred())
 							instanceIp, err := helpers.WaitForIp(daemonUrl, instance.Id, ipTimeout)
 							Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var user, token string
+
+// SetUser makes all requests to the daemon on behalf of name, which the daemon
+// enforces its per-user quotas for. Requests without a user count as "anonymous".
+func SetUser(name string) {
+	user = name
+}
+
+// SetToken sends token as a bearer token with all requests to the daemon, e.g. a
+// user token of a daemon with a user_token_key, or for a proxy in front of it that
+// authenticates clients
+func SetToken(bearerToken string) {
+	token = bearerToken
+}
+
+// userTransport adds the types.UserHeader and the bearer token to every request
+type userTransport struct {
+	base http.RoundTripper
+}
+
+func (t *userTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if user == "" && token == "" {
+		return base.RoundTrip(req)
+	}
+	// a RoundTripper must not modify the request it is given
+	withUser := new(http.Request)
+	*withUser = *req
+	withUser.Header = make(http.Header, len(req.Header)+2)
+	for key, values := range req.Header {
+		withUser.Header[key] = values
+	}
+	if user != "" {
+		withUser.Header.Set(types.UserHeader, user)
+	}
+	if token != "" {
+		withUser.Header.Set("Authorization", "Bearer "+token)
+	}
+	return base.RoundTrip(withUser)
+}
@@ -4,18 +4,78 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/types"
-	"github.com/layer-x/layerx-commons/lxhttpclient"
 )
 
 type volumes struct {
-	unikIP string
+	unikIP     string
+	httpClient *http.Client
+}
+
+// VolumeFilter narrows down the volumes returned by the daemon.
+// Zero values are ignored. A volume is listed if it has all of Tags.
+type VolumeFilter struct {
+	Provider     string
+	Attached     bool
+	Unattached   bool
+	NameContains string
+	Tags         map[string]string
+	// SizeGt and SizeLt select volumes larger or smaller than this many bytes. 0 means no bound
+	SizeGt int64
+	SizeLt int64
+	// Sort is name (the default), size, created or provider. Order is asc (the default) or desc
+	Sort  string
+	Order string
+}
+
+func (f VolumeFilter) query() string {
+	query := url.Values{}
+	if f.Provider != "" {
+		query.Set("provider", f.Provider)
+	}
+	if f.Attached {
+		query.Set("attached", "true")
+	}
+	if f.Unattached {
+		query.Set("unattached", "true")
+	}
+	if f.NameContains != "" {
+		query.Set("name_contains", f.NameContains)
+	}
+	for key, value := range f.Tags {
+		query.Add("tag", key+"="+value)
+	}
+	if f.SizeGt > 0 {
+		query.Set("size_gt", strconv.FormatInt(f.SizeGt, 10))
+	}
+	if f.SizeLt > 0 {
+		query.Set("size_lt", strconv.FormatInt(f.SizeLt, 10))
+	}
+	if f.Sort != "" {
+		query.Set("sort", f.Sort)
+	}
+	if f.Order != "" {
+		query.Set("order", f.Order)
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
 }
 
 func (v *volumes) All() ([]*types.Volume, error) {
-	resp, body, err := lxhttpclient.Get(v.unikIP, "/volumes", nil)
+	return v.List(VolumeFilter{})
+}
+
+func (v *volumes) List(filter VolumeFilter) ([]*types.Volume, error) {
+	if grpcApi != nil {
+		return grpcListVolumes(filter)
+	}
+	resp, body, err := httpGet(v.httpClient, v.unikIP, "/volumes"+filter.query(), nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -30,7 +90,10 @@ func (v *volumes) All() ([]*types.Volume, error) {
 }
 
 func (v *volumes) Get(id string) (*types.Volume, error) {
-	resp, body, err := lxhttpclient.Get(v.unikIP, "/volumes/"+id, nil)
+	if grpcApi != nil {
+		return grpcGetVolume(id)
+	}
+	resp, body, err := httpGet(v.httpClient, v.unikIP, "/volumes/"+id, nil)
 	if err != nil {
 		return nil, errors.New("request failed", err)
 	}
@@ -44,11 +107,30 @@ func (v *volumes) Get(id string) (*types.Volume, error) {
 	return &volume, nil
 }
 
+// Describe returns a volume along with the details of its filesystem
+func (v *volumes) Describe(id string) (*types.VolumeDetails, error) {
+	resp, body, err := httpGet(v.httpClient, v.unikIP, "/volumes/"+id+"/describe", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var details types.VolumeDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.VolumeDetails", string(body)), err)
+	}
+	return &details, nil
+}
+
 func (v *volumes) Delete(id string, force bool) error {
+	if grpcApi != nil {
+		return grpcDeleteVolume(id, force)
+	}
 	query := buildQuery(map[string]interface{}{
 		"force": force,
 	})
-	resp, body, err := lxhttpclient.Delete(v.unikIP, "/volumes/"+id+query, nil)
+	resp, body, err := httpDelete(v.httpClient, v.unikIP, "/volumes/"+id+query, nil)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -58,22 +140,60 @@ func (v *volumes) Delete(id string, force bool) error {
 	return nil
 }
 
-func (v *volumes) Create(name, dataTar, provider string, raw bool, size int, volType string, noCleanup bool) (*types.Volume, error) {
-	query := buildQuery(map[string]interface{}{
-		"size":       size,
-		"provider":   provider,
-		"type":       volType,
-		"no_cleanup": noCleanup,
-		"raw":        raw,
-	})
+// DeleteAll deletes every volume of provider, or of all providers if it is empty
+func (v *volumes) DeleteAll(provider string, force bool) (*types.BatchResult, error) {
+	query := batchQuery(provider, map[string]interface{}{"force": force})
+	return batchResult(httpDelete(v.httpClient, v.unikIP, "/volumes"+query, nil))
+}
+
+// CreateVolumeOptions are the parameters of a new volume other than its name.
+// Zero fields take the defaults of the daemon and provider
+type CreateVolumeOptions struct {
+	// DataTar is the tarball uploaded as the data of the volume. an empty volume
+	// of SizeMb is created without it
+	DataTar  string
+	Provider string
+	// Raw uses the data as the image of the volume as is, rather than as a
+	// folder to copy onto a new filesystem
+	Raw    bool
+	SizeMb int
+	// Type is the filesystem of the volume, ext2 or FAT
+	Type           string
+	Mode           types.VolumeMode
+	PartitionTable string
+	NoCleanup      bool
+	Tags           map[string]string
+}
+
+func (v *volumes) Create(name string, opts CreateVolumeOptions) (*types.Volume, error) {
+	if grpcApi != nil && (opts.DataTar == "" || grpcFits(opts.DataTar)) {
+		return grpcCreateVolume(name, opts)
+	}
+	params := map[string]interface{}{
+		"size":            opts.SizeMb,
+		"provider":        opts.Provider,
+		"type":            opts.Type,
+		"volume_mode":     opts.Mode,
+		"partition_table": opts.PartitionTable,
+		"no_cleanup":      opts.NoCleanup,
+		"raw":             opts.Raw,
+	}
+	if len(opts.Tags) > 0 {
+		tagsJson, err := json.Marshal(opts.Tags)
+		if err != nil {
+			return nil, errors.New("marshalling tags", err)
+		}
+		params["tags"] = string(tagsJson)
+	}
+	query := buildQuery(params)
 	//no data provided
 	var (
 		resp *http.Response
 		body []byte
 		err  error
 	)
-	if dataTar == "" {
-		resp, body, err = lxhttpclient.Post(v.unikIP, "/volumes/"+name+query, nil, nil)
+	if opts.DataTar == "" {
+		resp, body, err = httpPost(v.httpClient, v.unikIP, "/volumes/"+name+query, nil, nil)
 		if err != nil {
 			return nil, errors.New("request failed", err)
 		}
@@ -81,7 +201,7 @@ func (v *volumes) Create(name, dataTar, provider string, raw bool, size int, vol
 			return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), err)
 		}
 	} else {
-		resp, body, err = lxhttpclient.PostFile(v.unikIP, "/volumes/"+name+query, "tarfile", dataTar)
+		resp, body, err = httpPostFile(v.httpClient, v.unikIP, "/volumes/"+name+query, "tarfile", opts.DataTar)
 		if err != nil {
 			return nil, errors.New("request failed", err)
 		}
@@ -96,11 +216,116 @@ func (v *volumes) Create(name, dataTar, provider string, raw bool, size int, vol
 	return &volume, nil
 }
 
+func (v *volumes) Tag(id string, tags map[string]string) (*types.Volume, error) {
+	resp, body, err := httpPost(v.httpClient, v.unikIP, "/volumes/"+id+"/tags", nil, types.TagRequest{Tags: tags})
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var volume types.Volume
+	if err := json.Unmarshal(body, &volume); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Volume", string(body)), err)
+	}
+	return &volume, nil
+}
+
+func (v *volumes) Untag(id, key string) (*types.Volume, error) {
+	resp, body, err := httpDelete(v.httpClient, v.unikIP, "/volumes/"+id+"/tags/"+url.QueryEscape(key), nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var volume types.Volume
+	if err := json.Unmarshal(body, &volume); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Volume", string(body)), err)
+	}
+	return &volume, nil
+}
+
+// Rename changes the name of a detached volume. volumes identified by their name
+// (e.g. on qemu) get a new id too
+func (v *volumes) Rename(id, newName string) (*types.Volume, error) {
+	resp, body, err := httpPatch(v.httpClient, v.unikIP, "/volumes/"+id, types.RenameVolumeRequest{Name: newName})
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), err)
+	}
+	var volume types.Volume
+	if err := json.Unmarshal(body, &volume); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Volume", string(body)), err)
+	}
+	return &volume, nil
+}
+
+// ScheduleBackup has the daemon back up a volume to destination (a directory on
+// the daemon host or an s3://bucket/prefix url) whenever cronExpression matches,
+// keeping the newest retention backups (all of them if 0)
+func (v *volumes) ScheduleBackup(id, cronExpression, destination string, retention int) (*types.BackupSchedule, error) {
+	resp, body, err := httpPost(v.httpClient, v.unikIP, "/volumes/"+id+"/backups/schedules", nil, types.CreateBackupScheduleRequest{
+		CronExpression: cronExpression,
+		Destination:    destination,
+		Retention:      retention,
+	})
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var schedule types.BackupSchedule
+	if err := json.Unmarshal(body, &schedule); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.BackupSchedule", string(body)), err)
+	}
+	return &schedule, nil
+}
+
+// Backups returns the backup schedules of a volume and the backups made for it,
+// or those of all volumes if id is empty
+func (v *volumes) Backups(id string) (*types.VolumeBackups, error) {
+	path := "/backups"
+	if id != "" {
+		path = "/volumes/" + id + "/backups"
+	}
+	resp, body, err := httpGet(v.httpClient, v.unikIP, path, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var backups types.VolumeBackups
+	if err := json.Unmarshal(body, &backups); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.VolumeBackups", string(body)), err)
+	}
+	return &backups, nil
+}
+
+// DeleteBackupSchedule stops the backups of a schedule. backups already made are kept
+func (v *volumes) DeleteBackupSchedule(scheduleId string) error {
+	resp, body, err := httpDelete(v.httpClient, v.unikIP, "/backups/schedules/"+scheduleId, nil)
+	if err != nil {
+		return errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	return nil
+}
+
 func (v *volumes) Attach(id, instanceId, mountPoint string) error {
+	if grpcApi != nil {
+		return grpcAttachVolume(id, instanceId, mountPoint)
+	}
 	query := buildQuery(map[string]interface{}{
 		"mount": mountPoint,
 	})
-	resp, body, err := lxhttpclient.Post(v.unikIP, "/volumes/"+id+"/attach/"+instanceId+query, nil, nil)
+	resp, body, err := httpPost(v.httpClient, v.unikIP, "/volumes/"+id+"/attach/"+instanceId+query, nil, nil)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -111,7 +336,10 @@ func (v *volumes) Attach(id, instanceId, mountPoint string) error {
 }
 
 func (v *volumes) Detach(id string) error {
-	resp, body, err := lxhttpclient.Post(v.unikIP, "/volumes/"+id+"/detach", nil, nil)
+	if grpcApi != nil {
+		return grpcDetachVolume(id)
+	}
+	resp, body, err := httpPost(v.httpClient, v.unikIP, "/volumes/"+id+"/detach", nil, nil)
 	if err != nil {
 		return errors.New("request failed", err)
 	}
@@ -120,3 +348,84 @@ func (v *volumes) Detach(id string) error {
 	}
 	return nil
 }
+
+// Clone copies a volume to the new volume newName, on provider, or on the
+// provider of the volume if provider is empty
+func (v *volumes) Clone(id, newName, provider string) (*types.Volume, error) {
+	query := buildQuery(map[string]interface{}{
+		"name":     newName,
+		"provider": provider,
+	})
+	resp, body, err := httpPost(v.httpClient, v.unikIP, "/volumes/"+id+"/clone"+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var volume types.Volume
+	if err := json.Unmarshal(body, &volume); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Volume", string(body)), err)
+	}
+	return &volume, nil
+}
+
+// Trim discards the unused blocks of the filesystem of a detached volume stored
+// on the daemon host. compact also rewrites raw volume files with qemu-img
+func (v *volumes) Trim(id string, compact bool) (*types.VolumeTrimResult, error) {
+	query := buildQuery(map[string]interface{}{
+		"compact": compact,
+	})
+	resp, body, err := httpPost(v.httpClient, v.unikIP, "/volumes/"+id+"/trim"+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var result types.VolumeTrimResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.VolumeTrimResult", string(body)), err)
+	}
+	return &result, nil
+}
+
+// Defrag defragments the filesystem of a detached volume if e4defrag scores its
+// fragmentation above threshold
+func (v *volumes) Defrag(id string, threshold int) (*types.VolumeDefragResult, error) {
+	query := buildQuery(map[string]interface{}{
+		"threshold": threshold,
+	})
+	resp, body, err := httpPost(v.httpClient, v.unikIP, "/volumes/"+id+"/defrag"+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var result types.VolumeDefragResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.VolumeDefragResult", string(body)), err)
+	}
+	return &result, nil
+}
+
+// Migrate copies a volume to another provider. this blocks until the data has
+// been copied; follow events to watch the progress of the migration.
+func (v *volumes) Migrate(id, provider string) (*types.VolumeMigration, error) {
+	query := buildQuery(map[string]interface{}{
+		"provider": provider,
+	})
+	resp, body, err := httpPost(v.httpClient, v.unikIP, "/volumes/"+id+"/migrate"+query, nil, nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var migration types.VolumeMigration
+	if err := json.Unmarshal(body, &migration); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.VolumeMigration", string(body)), err)
+	}
+	return &migration, nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+type webhooks struct {
+	unikIP     string
+	httpClient *http.Client
+}
+
+func (w *webhooks) All() ([]*types.Webhook, error) {
+	resp, body, err := httpGet(w.httpClient, w.unikIP, "/webhooks", nil)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var webhooks []*types.Webhook
+	if err := json.Unmarshal(body, &webhooks); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type []*types.Webhook", string(body)), err)
+	}
+	return webhooks, nil
+}
+
+func (w *webhooks) Create(url, secret string, events []string) (*types.Webhook, error) {
+	createWebhookRequest := types.CreateWebhookRequest{
+		URL:    url,
+		Secret: secret,
+		Events: events,
+	}
+	resp, body, err := httpPost(w.httpClient, w.unikIP, "/webhooks", nil, createWebhookRequest)
+	if err != nil {
+		return nil, errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	var webhook types.Webhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Webhook", string(body)), err)
+	}
+	return &webhook, nil
+}
+
+func (w *webhooks) Delete(id string) error {
+	resp, body, err := httpDelete(w.httpClient, w.unikIP, "/webhooks/"+id, nil)
+	if err != nil {
+		return errors.New("request failed", err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New(fmt.Sprintf("failed with status %v: %s", resp.StatusCode, string(body)), nil)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package compilers_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCompilers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Compilers Suite")
+}
@@ -32,10 +32,49 @@ type MirageCompiler struct {
 	Type Type
 }
 
+// the mirage targets (mirage configure -t) that can be asked for with build --target
+const (
+	TargetXen    = "xen"
+	TargetUkvm   = "ukvm"
+	TargetVirtio = "virtio"
+	TargetUnix   = "unix"
+)
+
+// target returns the mirage target to configure the sources for. each type of
+// compiler builds for the one target its provider boots; target only has to be
+// given to make sure of it
+func (c *MirageCompiler) target(requested string) (string, error) {
+	var target string
+	switch c.Type {
+	case XenType:
+		target = TargetXen
+	case UKVMType:
+		target = TargetUkvm
+	case VirtioType:
+		target = TargetVirtio
+	default:
+		return "", errors.New("unknown type", nil)
+	}
+	switch requested {
+	case "", target:
+		return target, nil
+	case TargetUnix:
+		return "", errors.New("mirage target unix builds a native executable, not a bootable unikernel. build it with mirage configure -t unix && make instead", nil)
+	case TargetXen, TargetUkvm, TargetVirtio:
+		return "", errors.New("mirage target "+requested+" does not boot on this provider, which boots "+target+" unikernels", nil)
+	}
+	return "", errors.New("unknown mirage target "+requested+", expected "+strings.Join([]string{TargetXen, TargetVirtio, TargetUkvm, TargetUnix}, "|"), nil)
+}
+
 func (c *MirageCompiler) CompileRawImage(params types.CompileImageParams) (*types.RawImage, error) {
 
 	sourcesDir := params.SourcesDir
 
+	target, err := c.target(params.Target)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := grantOpamPermissions(sourcesDir); err != nil {
 		return nil, err
 	}
@@ -52,18 +91,21 @@ func (c *MirageCompiler) CompileRawImage(params types.CompileImageParams) (*type
 			}
 		}
 		containerToUse = "compilers-mirage-ocaml-xen"
-		args = append([]string{"configure", "-t", "xen"}, args...)
 
 	case VirtioType:
+		// the solo5 toolchain of the ukvm container also builds virtio kernels
 		containerToUse = "compilers-mirage-ocaml-ukvm"
-		args = append([]string{"configure", "-t", "virtio"}, args...)
+		// arguments can only be introspected on xen, use them when they are given
+		if manifestArgs, err := parseMirageManifest(sourcesDir); err == nil {
+			args = manifestArgs
+		}
 
 	case UKVMType:
 		containerToUse = "compilers-mirage-ocaml-ukvm"
-		args = append([]string{"configure", "-t", "ukvm"}, args...)
 	default:
 		return nil, errors.New("unknown type", nil)
 	}
+	args = append([]string{"configure", "-t", target}, args...)
 
 	if err := unikutil.NewContainer(containerToUse).WithEntrypoint("mirage").WithVolume(sourcesDir, "/opt/code").Run(args...); err != nil {
 		return nil, err
@@ -81,16 +123,19 @@ func (c *MirageCompiler) CompileRawImage(params types.CompileImageParams) (*type
 		return nil, err
 	}
 
-	if len(matches) != 1 {
+	var disks []string
+	switch {
+	case len(matches) == 1:
+		disks, err = getDisks(sourcesDir, matches[0])
+		if err != nil {
+			return nil, err
+		}
+	// only the xen target is sure to generate one
+	case len(matches) == 0 && c.Type != XenType:
+	default:
 		return nil, errors.New("XL file count is wrong", nil)
 	}
 
-	xlFile := matches[0]
-
-	disks, err := getDisks(sourcesDir, xlFile)
-	if err != nil {
-		return nil, err
-	}
 	switch c.Type {
 	case XenType:
 		return c.packageForXen(sourcesDir, disks, params.NoCleanup)
@@ -119,7 +164,7 @@ func (c *MirageCompiler) packageForXen(sourcesDir string, disks []string, cleanu
 	}
 
 	// TODO: ukvm package zipfile for ukvm
-	imgFile, err := compilers.BuildBootableImage(unikernelfile, "", false, cleanup)
+	imgFile, err := compilers.BuildBootableImage(unikernelfile, "", false, cleanup, unikos.DiskNaming_IDE)
 
 	if err != nil {
 		return nil, err
@@ -136,40 +181,52 @@ func (c *MirageCompiler) packageForXen(sourcesDir string, disks []string, cleanu
 }
 
 func (c *MirageCompiler) packageForUkvm(sourcesDir string, disks []string, cleanup bool) (*types.RawImage, error) {
-	return c.packageUnikernel(sourcesDir, disks, cleanup, "ukvm")
+	return c.packageUnikernel(sourcesDir, disks, cleanup, TargetUkvm)
 }
+
+// packageForVirtio puts the *.virtio kernel on a boot disk, like the kernels of
+// the other qemu compilers. qemu boots it with -kernel from the program.bin next
+// to the disk, and the empty cmdline, as mirage takes no command line
 func (c *MirageCompiler) packageForVirtio(sourcesDir string, disks []string, cleanup bool) (*types.RawImage, error) {
-	// for qemu we create an empty cmdline file
-	r, err := c.packageUnikernel(sourcesDir, disks, cleanup, "virtio")
+	kernel, err := getKernelFile(sourcesDir, TargetVirtio)
 	if err != nil {
-		return r, err
+		return nil, err
 	}
-	return r, err
+
+	imgFile, err := compilers.BuildBootableImage(kernel, "", false, cleanup, unikos.DiskNaming_VirtIO)
+	if err != nil {
+		return nil, errors.New("creating boot volume from virtio kernel", err)
+	}
+	if err := unikos.CopyFile(kernel, filepath.Join(filepath.Dir(imgFile), "program.bin")); err != nil {
+		return nil, errors.New("copying kernel to image dir", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(imgFile), "cmdline"), nil, 0644); err != nil {
+		return nil, errors.New("creating empty cmdline for image", err)
+	}
+
+	res := &types.RawImage{}
+	for _, disk := range disks {
+		res.RunSpec.DeviceMappings = append(res.RunSpec.DeviceMappings, types.DeviceMapping{MountPoint: TargetVirtio + ":" + disk, DeviceName: disk})
+	}
+	res.RunSpec.Compiler = compilers.MIRAGE_OCAML_QEMU.String()
+	res.LocalImagePath = imgFile
+	res.StageSpec = types.StageSpec{
+		ImageFormat: types.ImageFormat_RAW,
+	}
+	res.RunSpec.DefaultInstanceMemory = 256
+
+	return res, nil
 }
 
 func (c *MirageCompiler) packageUnikernel(sourcesDir string, disks []string, cleanup bool, unikernel string) (*types.RawImage, error) {
 	// find ukvm-bin -> the monitor
 	// find *.ukvm -> the unikernel
 
-	matches, err := filepath.Glob(filepath.Join(sourcesDir, "*."+unikernel))
+	kernel, err := getKernelFile(sourcesDir, unikernel)
 	if err != nil {
 		return nil, err
 	}
 
-	// filter non relevant Makefile.ukvm
-	var potentialMatches []string
-	for _, m := range matches {
-		if !strings.HasSuffix(m, "Makefile."+unikernel) {
-			potentialMatches = append(potentialMatches, m)
-		}
-	}
-
-	if len(potentialMatches) != 1 {
-		return nil, errors.New(fmt.Sprintf("Ukvm kernel file count is wrong: %v", potentialMatches), nil)
-	}
-
-	kernel := potentialMatches[0]
-
 	// place them in the image directory
 
 	tmpImageDir, err := ioutil.TempDir("", "")
@@ -181,31 +238,16 @@ func (c *MirageCompiler) packageUnikernel(sourcesDir string, disks []string, cle
 		return nil, errors.New("copying bootable image to image dir", err)
 	}
 
-	if unikernel == "ukvm" {
-		monitor := filepath.Join(sourcesDir, "ukvm-bin")
-		if err := unikos.CopyFile(monitor, filepath.Join(tmpImageDir, "ukvm-bin")); err != nil {
-			return nil, errors.New("copying bootable image to image dir", err)
-		}
-	} else {
-		// it is virtio for qemu
-		// mirage doesn't have command line, so create an empty one
-		// this is the hint for qemu to use PV mode
-		f, err := os.Create(filepath.Join(tmpImageDir, "cmdline"))
-		if err != nil {
-			return nil, errors.New("creating empty cmdline for image", err)
-		}
-		f.Close()
+	monitor := filepath.Join(sourcesDir, "ukvm-bin")
+	if err := unikos.CopyFile(monitor, filepath.Join(tmpImageDir, "ukvm-bin")); err != nil {
+		return nil, errors.New("copying bootable image to image dir", err)
 	}
 
 	res := &types.RawImage{}
 	for _, disk := range disks {
 		res.RunSpec.DeviceMappings = append(res.RunSpec.DeviceMappings, types.DeviceMapping{MountPoint: unikernel + ":" + disk, DeviceName: disk})
 	}
-	if unikernel == "ukvm" {
-		res.RunSpec.Compiler = compilers.MIRAGE_OCAML_UKVM.String()
-	} else {
-		res.RunSpec.Compiler = compilers.MIRAGE_OCAML_QEMU.String()
-	}
+	res.RunSpec.Compiler = compilers.MIRAGE_OCAML_UKVM.String()
 	res.LocalImagePath = tmpImageDir
 	res.StageSpec = types.StageSpec{
 		ImageFormat: types.ImageFormat_Folder,
@@ -215,6 +257,28 @@ func (c *MirageCompiler) packageUnikernel(sourcesDir string, disks []string, cle
 	return res, nil
 }
 
+// getKernelFile finds the *.ukvm or *.virtio kernel make built
+func getKernelFile(sourcesDir, target string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(sourcesDir, "*."+target))
+	if err != nil {
+		return "", err
+	}
+
+	// filter non relevant Makefile.ukvm
+	var potentialMatches []string
+	for _, m := range matches {
+		if !strings.HasSuffix(m, "Makefile."+target) {
+			potentialMatches = append(potentialMatches, m)
+		}
+	}
+
+	if len(potentialMatches) != 1 {
+		return "", errors.New(fmt.Sprintf("%s kernel file count is wrong: %v", target, potentialMatches), nil)
+	}
+
+	return potentialMatches[0], nil
+}
+
 func (r *MirageCompiler) Usage() *compilers.CompilerUsage {
 	return nil
 }
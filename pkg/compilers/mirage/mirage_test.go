@@ -120,5 +120,36 @@ var _ = Describe("Mirage", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(res).To(Equal(filepath.Join(sourcesDir, "file.xen")))
 		})
+		It("should find virtio kernel file but not its makefile", func() {
+
+			for _, name := range []string{"Makefile.virtio", "www.virtio"} {
+				f, _ := os.Create(filepath.Join(sourcesDir, name))
+				f.Close()
+			}
+
+			res, err := getKernelFile(sourcesDir, "virtio")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(filepath.Join(sourcesDir, "www.virtio")))
+		})
+	})
+	Describe("Selecting the target", func() {
+		It("should default to the target of the provider", func() {
+			target, err := (&MirageCompiler{Type: VirtioType}).target("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(Equal("virtio"))
+		})
+		It("should accept the target of the provider", func() {
+			target, err := (&MirageCompiler{Type: XenType}).target("xen")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(Equal("xen"))
+		})
+		It("should refuse targets the provider does not boot", func() {
+			_, err := (&MirageCompiler{Type: VirtioType}).target("xen")
+			Expect(err).To(HaveOccurred())
+			_, err = (&MirageCompiler{Type: XenType}).target("unix")
+			Expect(err).To(HaveOccurred())
+			_, err = (&MirageCompiler{Type: XenType}).target("solo5")
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
@@ -8,7 +8,8 @@ import (
 )
 
 const (
-	Rump = "rump"
+	Rump   = "rump"
+	Hermit = "hermit"
 )
 
 type CompilerType string
@@ -35,6 +36,7 @@ var (
 	RUMP_C_QEMU       = compilerName("rump", "c", "qemu")
 	RUMP_C_PHOTON     = compilerName("rump", "c", "photon")
 	RUMP_C_OPENSTACK  = compilerName("rump", "c", "openstack")
+	RUMP_C_LIBVIRT    = compilerName("rump", "c", "libvirt")
 
 	RUMP_GO_XEN        = compilerName("rump", "go", "xen")
 	RUMP_GO_AWS        = compilerName("rump", "go", "aws")
@@ -43,6 +45,7 @@ var (
 	RUMP_GO_QEMU       = compilerName("rump", "go", "qemu")
 	RUMP_GO_PHOTON     = compilerName("rump", "go", "photon")
 	RUMP_GO_OPENSTACK  = compilerName("rump", "go", "openstack")
+	RUMP_GO_LIBVIRT    = compilerName("rump", "go", "libvirt")
 	RUMP_GO_GCLOUD     = compilerName("rump", "go", "gcloud")
 
 	RUMP_NODEJS_XEN        = compilerName("rump", "nodejs", "xen")
@@ -51,6 +54,7 @@ var (
 	RUMP_NODEJS_VSPHERE    = compilerName("rump", "nodejs", "vsphere")
 	RUMP_NODEJS_QEMU       = compilerName("rump", "nodejs", "qemu")
 	RUMP_NODEJS_OPENSTACK  = compilerName("rump", "nodejs", "openstack")
+	RUMP_NODEJS_LIBVIRT    = compilerName("rump", "nodejs", "libvirt")
 
 	RUMP_PYTHON_XEN        = compilerName("rump", "python", "xen")
 	RUMP_PYTHON_AWS        = compilerName("rump", "python", "aws")
@@ -58,6 +62,7 @@ var (
 	RUMP_PYTHON_VSPHERE    = compilerName("rump", "python", "vsphere")
 	RUMP_PYTHON_QEMU       = compilerName("rump", "python", "qemu")
 	RUMP_PYTHON_OPENSTACK  = compilerName("rump", "python", "openstack")
+	RUMP_PYTHON_LIBVIRT    = compilerName("rump", "python", "libvirt")
 
 	RUMP_JAVA_XEN        = compilerName("rump", "java", "xen")
 	RUMP_JAVA_AWS        = compilerName("rump", "java", "aws")
@@ -65,6 +70,7 @@ var (
 	RUMP_JAVA_VSPHERE    = compilerName("rump", "java", "vsphere")
 	RUMP_JAVA_QEMU       = compilerName("rump", "java", "qemu")
 	RUMP_JAVA_OPENSTACK  = compilerName("rump", "java", "openstack")
+	RUMP_JAVA_LIBVIRT    = compilerName("rump", "java", "libvirt")
 
 	OSV_JAVA_XEN        = compilerName("osv", "java", "xen")
 	OSV_JAVA_AWS        = compilerName("osv", "java", "aws")
@@ -75,14 +81,20 @@ var (
 
 	OSV_NODEJS_QEMU      = compilerName("osv", "nodejs", "qemu")
 	OSV_NODEJS_OPENSTACK = compilerName("osv", "nodejs", "openstack")
+	OSV_NODEJS_LIBVIRT   = compilerName("osv", "nodejs", "libvirt")
 
 	OSV_NATIVE_QEMU      = compilerName("osv", "native", "qemu")
 	OSV_NATIVE_OPENSTACK = compilerName("osv", "native", "openstack")
+	OSV_NATIVE_LIBVIRT   = compilerName("osv", "native", "libvirt")
 
 	INCLUDEOS_CPP_QEMU       = compilerName("includeos", "cpp", "qemu")
 	INCLUDEOS_CPP_XEN        = compilerName("includeos", "cpp", "xen")
 	INCLUDEOS_CPP_VIRTUALBOX = compilerName("includeos", "cpp", "virtualbox")
 	INCLUDEOS_CPP_OPENSTACK  = compilerName("includeos", "cpp", "openstack")
+	INCLUDEOS_CPP_LIBVIRT    = compilerName("includeos", "cpp", "libvirt")
+
+	HERMIT_RUST_QEMU    = compilerName("hermit", "rust", "qemu")
+	HERMIT_RUST_LIBVIRT = compilerName("hermit", "rust", "libvirt")
 
 	MIRAGE_OCAML_XEN  = compilerName("mirage", "ocaml", "xen")
 	MIRAGE_OCAML_UKVM = compilerName("mirage", "ocaml", "ukvm")
@@ -97,6 +109,7 @@ var compilers = []CompilerType{
 	RUMP_C_QEMU,
 	RUMP_C_PHOTON,
 	RUMP_C_OPENSTACK,
+	RUMP_C_LIBVIRT,
 
 	RUMP_GO_XEN,
 	RUMP_GO_AWS,
@@ -105,6 +118,7 @@ var compilers = []CompilerType{
 	RUMP_GO_QEMU,
 	RUMP_GO_PHOTON,
 	RUMP_GO_OPENSTACK,
+	RUMP_GO_LIBVIRT,
 	RUMP_GO_GCLOUD,
 
 	RUMP_NODEJS_XEN,
@@ -113,6 +127,7 @@ var compilers = []CompilerType{
 	RUMP_NODEJS_VSPHERE,
 	RUMP_NODEJS_QEMU,
 	RUMP_NODEJS_OPENSTACK,
+	RUMP_NODEJS_LIBVIRT,
 
 	RUMP_PYTHON_XEN,
 	RUMP_PYTHON_AWS,
@@ -120,6 +135,7 @@ var compilers = []CompilerType{
 	RUMP_PYTHON_VSPHERE,
 	RUMP_PYTHON_QEMU,
 	RUMP_PYTHON_OPENSTACK,
+	RUMP_PYTHON_LIBVIRT,
 
 	RUMP_JAVA_XEN,
 	RUMP_JAVA_AWS,
@@ -127,6 +143,7 @@ var compilers = []CompilerType{
 	RUMP_JAVA_VSPHERE,
 	RUMP_JAVA_QEMU,
 	RUMP_JAVA_OPENSTACK,
+	RUMP_JAVA_LIBVIRT,
 
 	OSV_JAVA_XEN,
 	OSV_JAVA_AWS,
@@ -137,20 +154,37 @@ var compilers = []CompilerType{
 
 	OSV_NODEJS_QEMU,
 	OSV_NODEJS_OPENSTACK,
+	OSV_NODEJS_LIBVIRT,
 
 	OSV_NATIVE_QEMU,
 	OSV_NATIVE_OPENSTACK,
+	OSV_NATIVE_LIBVIRT,
 
 	INCLUDEOS_CPP_QEMU,
 	INCLUDEOS_CPP_XEN,
 	INCLUDEOS_CPP_VIRTUALBOX,
 	INCLUDEOS_CPP_OPENSTACK,
+	INCLUDEOS_CPP_LIBVIRT,
+
+	HERMIT_RUST_QEMU,
+	HERMIT_RUST_LIBVIRT,
 
 	MIRAGE_OCAML_XEN,
 	MIRAGE_OCAML_UKVM,
 	MIRAGE_OCAML_QEMU,
 }
 
+// Register adds a compiler type to the ones ValidateCompiler accepts, for
+// compilers loaded from plugins
+func Register(compiler CompilerType) {
+	for _, known := range compilers {
+		if known == compiler {
+			return
+		}
+	}
+	compilers = append(compilers, compiler)
+}
+
 func ValidateCompiler(base, language, provider string) (CompilerType, error) {
 	baseMatch := false
 	languageMatch := false
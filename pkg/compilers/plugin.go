@@ -0,0 +1,178 @@
+package compilers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"plugin"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// PluginSymbol is the name of the variable a compiler plugin exports, of a type
+// implementing CompilerPlugin
+const PluginSymbol = "Compiler"
+
+// memory given to instances of images built by plugins that only Build, in MB
+const defaultPluginInstanceMemory = 512
+
+// BuildArgs are the settings of a build, besides its sources
+type BuildArgs struct {
+	// Args are the arguments the application is run with
+	Args      string
+	MntPoints []string
+	NoCleanup bool
+	SizeMB    int
+	// SquashDirs are folders to copy into the boot partition instead of
+	// attaching volumes, keyed by the mount point they are copied to
+	SquashDirs map[string]string
+}
+
+// CompilerPlugin is a compiler loaded from a shared library at runtime
+// (daemon --compiler-plugin-dir), rather than built into the daemon. Name is the
+// compiler type it builds, as base-language-provider (e.g. rump-go-qemu); a
+// plugin named like a built in compiler replaces it. Build compiles the sources
+// in srcDir and returns the path of the raw boot disk it made, which the
+// provider then stages as its image.
+type CompilerPlugin interface {
+	Name() string
+	Build(srcDir string, args BuildArgs) (string, error)
+	SupportedArch() []types.Architecture
+}
+
+// RawImagePlugin is implemented by plugins whose images need more than a raw
+// disk booted with the defaults of the provider, e.g. mapped volumes. it is
+// called instead of Build.
+type RawImagePlugin interface {
+	BuildRawImage(srcDir string, args BuildArgs) (*types.RawImage, error)
+}
+
+// UsagePlugin is implemented by plugins that describe how to prepare projects
+// for them, as Compiler.Usage
+type UsagePlugin interface {
+	Usage() *CompilerUsage
+}
+
+// PluginCompiler runs a CompilerPlugin as a Compiler
+type PluginCompiler struct {
+	Plugin CompilerPlugin
+}
+
+func (c *PluginCompiler) CompileRawImage(params types.CompileImageParams) (*types.RawImage, error) {
+	args := BuildArgs{
+		Args:       params.Args,
+		MntPoints:  params.MntPoints,
+		NoCleanup:  params.NoCleanup,
+		SizeMB:     params.SizeMB,
+		SquashDirs: params.SquashDirs,
+	}
+	if rawImagePlugin, ok := c.Plugin.(RawImagePlugin); ok {
+		return rawImagePlugin.BuildRawImage(params.SourcesDir, args)
+	}
+	imagePath, err := c.Plugin.Build(params.SourcesDir, args)
+	if err != nil {
+		return nil, err
+	}
+	return &types.RawImage{
+		LocalImagePath: imagePath,
+		StageSpec:      types.StageSpec{ImageFormat: types.ImageFormat_RAW},
+		RunSpec: types.RunSpec{
+			DefaultInstanceMemory: defaultPluginInstanceMemory,
+			Compiler:              CompilerType(c.Plugin.Name()).Base(),
+		},
+	}, nil
+}
+
+func (c *PluginCompiler) Usage() *CompilerUsage {
+	if usagePlugin, ok := c.Plugin.(UsagePlugin); ok {
+		return usagePlugin.Usage()
+	}
+	return nil
+}
+
+// SupportsArch reports whether the plugin builds images for arch
+func (c *PluginCompiler) SupportsArch(arch types.Architecture) bool {
+	for _, supported := range c.Plugin.SupportedArch() {
+		if supported == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPlugins opens every .so file in dir as a compiler plugin, and registers
+// the compiler types they build. plugins have to be built (go build
+// -buildmode=plugin) with the same version of go and of the unik packages they
+// import as the daemon, or they fail to load.
+func LoadPlugins(dir string) (map[CompilerType]*PluginCompiler, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.New("reading compiler plugin dir "+dir, err)
+	}
+	loaded := make(map[CompilerType]*PluginCompiler)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".so" {
+			continue
+		}
+		compilerPlugin, err := LoadPlugin(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		compilerType := CompilerType(compilerPlugin.Name())
+		if _, ok := loaded[compilerType]; ok {
+			return nil, errors.New("more than one plugin in "+dir+" builds "+compilerType.String(), nil)
+		}
+		Register(compilerType)
+		loaded[compilerType] = &PluginCompiler{Plugin: compilerPlugin}
+		logrus.WithFields(logrus.Fields{"plugin": file.Name(), "arch": compilerPlugin.SupportedArch()}).Infof("loaded compiler plugin %s", compilerType)
+	}
+	return loaded, nil
+}
+
+// LoadPlugin opens the compiler plugin in the shared library at path
+func LoadPlugin(path string) (CompilerPlugin, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, errors.New("opening compiler plugin "+path, err)
+	}
+	symbol, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, errors.New("compiler plugin "+path+" does not export "+PluginSymbol, err)
+	}
+	var compilerPlugin CompilerPlugin
+	switch symbol := symbol.(type) {
+	// var Compiler compilers.CompilerPlugin = ...
+	case *CompilerPlugin:
+		compilerPlugin = *symbol
+	// var Compiler myCompiler, with the methods on *myCompiler
+	case CompilerPlugin:
+		compilerPlugin = symbol
+	default:
+		return nil, errors.New("symbol "+PluginSymbol+" of compiler plugin "+path+" does not implement compilers.CompilerPlugin", nil)
+	}
+	if compilerPlugin == nil {
+		return nil, errors.New("symbol "+PluginSymbol+" of compiler plugin "+path+" is nil", nil)
+	}
+	if err := validatePluginName(compilerPlugin.Name()); err != nil {
+		return nil, errors.New("compiler plugin "+path, err)
+	}
+	if len(compilerPlugin.SupportedArch()) == 0 {
+		return nil, errors.New("compiler plugin "+path+" supports no architecture", nil)
+	}
+	return compilerPlugin, nil
+}
+
+func validatePluginName(name string) error {
+	parts := strings.Split(name, "-")
+	if len(parts) != 3 {
+		return errors.New("name "+name+" is not of the form base-language-provider", nil)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return errors.New("name "+name+" is not of the form base-language-provider", nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package compilers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+var _ = Describe("Compiler plugins", func() {
+	var pluginDir string
+
+	BeforeEach(func() {
+		var err error
+		pluginDir, err = ioutil.TempDir("", "unik.compiler-plugins.")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(pluginDir)
+	})
+
+	buildPlugin := func(name, pkg string) {
+		cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", filepath.Join(pluginDir, name), pkg)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			Skip("cannot build plugins here: " + string(out))
+		}
+	}
+
+	Describe("LoadPlugins", func() {
+		It("loads and registers the compilers built with -buildmode=plugin", func() {
+			buildPlugin("testplugin.so", "./testdata/testplugin")
+			Expect(ioutil.WriteFile(filepath.Join(pluginDir, "README"), []byte("not a plugin"), 0644)).To(Succeed())
+
+			loaded, err := compilers.LoadPlugins(pluginDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(HaveLen(1))
+			compiler, ok := loaded["testos-test-qemu"]
+			Expect(ok).To(BeTrue())
+			Expect(compiler.SupportsArch(types.Architecture_ARM64)).To(BeTrue())
+			Expect(compiler.SupportsArch(types.Architecture_AMD64)).To(BeFalse())
+
+			compilerType, err := compilers.ValidateCompiler("testos", "test", "qemu")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(compilerType).To(Equal(compilers.CompilerType("testos-test-qemu")))
+
+			srcDir, err := ioutil.TempDir("", "unik.plugin-sources.")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(srcDir)
+			Expect(ioutil.WriteFile(filepath.Join(srcDir, "program.bin"), []byte("kernel "), 0644)).To(Succeed())
+
+			rawImage, err := compiler.CompileRawImage(types.CompileImageParams{SourcesDir: srcDir, Args: "-v"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rawImage.LocalImagePath).To(Equal(filepath.Join(srcDir, "boot.img")))
+			Expect(rawImage.StageSpec.ImageFormat).To(Equal(types.ImageFormat_RAW))
+			Expect(rawImage.RunSpec.Compiler).To(Equal("testos"))
+			data, err := ioutil.ReadFile(rawImage.LocalImagePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("kernel -v"))
+		})
+
+		It("loads the reference rump plugin", func() {
+			buildPlugin("rump-go-qemu.so", "./plugins/rump-go-qemu")
+
+			loaded, err := compilers.LoadPlugins(pluginDir)
+			Expect(err).ToNot(HaveOccurred())
+			compiler, ok := loaded[compilers.RUMP_GO_QEMU]
+			Expect(ok).To(BeTrue())
+			Expect(compiler.SupportsArch(types.Architecture_AMD64)).To(BeTrue())
+			_, ok = compiler.Plugin.(compilers.RawImagePlugin)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("refuses two plugins for the same compiler", func() {
+			buildPlugin("a.so", "./testdata/testplugin")
+			// a copy of the plugin builds the same compiler
+			data, err := ioutil.ReadFile(filepath.Join(pluginDir, "a.so"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(pluginDir, "b.so"), data, 0644)).To(Succeed())
+
+			_, err = compilers.LoadPlugins(pluginDir)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("fails on shared libraries that are not plugins", func() {
+			Expect(ioutil.WriteFile(filepath.Join(pluginDir, "broken.so"), []byte("not elf"), 0644)).To(Succeed())
+			_, err := compilers.LoadPlugins(pluginDir)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
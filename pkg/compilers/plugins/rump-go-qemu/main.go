@@ -0,0 +1,64 @@
+// rump-go-qemu is the rump go compiler for qemu packaged as a compiler plugin,
+// as a reference for writing plugins. build it with the same go and unik sources
+// as the daemon:
+//
+//	go build -buildmode=plugin -o /path/to/plugins/rump-go-qemu.so ./pkg/compilers/plugins/rump-go-qemu
+//
+// and start the daemon with --compiler-plugin-dir /path/to/plugins. it replaces
+// the built in rump-go-qemu compiler.
+package main
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	"github.com/emc-advanced-dev/unik/pkg/compilers/rump"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+type rumpGoQemuPlugin struct {
+	compiler *rump.RumpGoCompiler
+}
+
+// Compiler is the symbol the daemon looks up
+var Compiler compilers.CompilerPlugin = &rumpGoQemuPlugin{
+	compiler: &rump.RumpGoCompiler{
+		RumCompilerBase: rump.RumCompilerBase{
+			DockerImage: "compilers-rump-go-hw",
+			CreateImage: rump.CreateImageQemu,
+		},
+		BootstrapType: rump.BootstrapTypeNoStub,
+	},
+}
+
+func (p *rumpGoQemuPlugin) Name() string {
+	return compilers.RUMP_GO_QEMU.String()
+}
+
+func (p *rumpGoQemuPlugin) SupportedArch() []types.Architecture {
+	return []types.Architecture{types.Architecture_AMD64}
+}
+
+func (p *rumpGoQemuPlugin) Build(srcDir string, args compilers.BuildArgs) (string, error) {
+	rawImage, err := p.BuildRawImage(srcDir, args)
+	if err != nil {
+		return "", err
+	}
+	return rawImage.LocalImagePath, nil
+}
+
+// BuildRawImage keeps the device mappings of the volumes, which Build can't return
+func (p *rumpGoQemuPlugin) BuildRawImage(srcDir string, args compilers.BuildArgs) (*types.RawImage, error) {
+	return p.compiler.CompileRawImage(types.CompileImageParams{
+		SourcesDir: srcDir,
+		Args:       args.Args,
+		MntPoints:  args.MntPoints,
+		NoCleanup:  args.NoCleanup,
+		SizeMB:     args.SizeMB,
+		SquashDirs: args.SquashDirs,
+	})
+}
+
+func (p *rumpGoQemuPlugin) Usage() *compilers.CompilerUsage {
+	return p.compiler.Usage()
+}
+
+func main() {}
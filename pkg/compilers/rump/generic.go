@@ -23,6 +23,16 @@ func execContainer(imageName string, cmds []string, binds map[string]string, pri
 type RumCompilerBase struct {
 	DockerImage string
 	CreateImage func(kernel, args string, mntPoints, bakedEnv []string, noCleanup bool) (*types.RawImage, error)
+	// CreateKernelImage, if set, is used instead of CreateImage for images that
+	// need no boot partition, i.e. have no volumes squashed into it
+	CreateKernelImage func(kernel, args string, mntPoints, bakedEnv []string, noCleanup bool) (*types.RawImage, error)
+}
+
+func (r *RumCompilerBase) createImage(params types.CompileImageParams) func(kernel, args string, mntPoints, bakedEnv []string, noCleanup bool) (*types.RawImage, error) {
+	if r.CreateKernelImage != nil && len(params.SquashDirs) == 0 {
+		return r.CreateKernelImage
+	}
+	return r.CreateImage
 }
 
 func (r *RumCompilerBase) runContainer(localFolder string, envPairs []string) error {
@@ -41,8 +41,11 @@ func (r *RumpCCompiler) CompileRawImage(params types.CompileImageParams) (*types
 	}
 
 	resultFile := path.Join(sourcesDir, "program.bin")
+	if err := compilers.SquashVolumes(sourcesDir, params.SquashDirs); err != nil {
+		return nil, err
+	}
 
-	return r.CreateImage(resultFile, params.Args, params.MntPoints, nil, params.NoCleanup)
+	return r.createImage(params)(resultFile, params.Args, params.MntPoints, nil, params.NoCleanup)
 }
 
 func (r *RumpCCompiler) Usage() *compilers.CompilerUsage {
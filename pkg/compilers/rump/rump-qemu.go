@@ -8,12 +8,73 @@ import (
 	"path/filepath"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/compilers"
 	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
 func CreateImageQemu(kernel string, args string, mntPoints, bakedEnv []string, noCleanup bool) (*types.RawImage, error) {
+	res, cmdline, err := qemuRumpConfig(args, mntPoints, bakedEnv, true)
+	if err != nil {
+		return nil, err
+	}
+
+	imgFile, err := compilers.BuildBootableImage(kernel, cmdline, true, noCleanup, unikos.DiskNaming_VirtIO)
+	if err != nil {
+		return nil, err
+	}
+
+	//copy kernel for qemu
+	if err := unikos.CopyFile(kernel, filepath.Join(filepath.Dir(imgFile), "program.bin")); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(imgFile), "cmdline"), []byte(cmdline), 0644); err != nil {
+		return nil, err
+	}
+
+	res.LocalImagePath = imgFile
+	res.StageSpec.ImageFormat = types.ImageFormat_RAW
+	res.RunSpec.DefaultInstanceMemory = 512
+	return res, nil
+
+}
+
+// CreateImageQemuDirect makes an image that qemu boots with -kernel program.bin
+// alone, without building a boot disk (with grub) for it. the kernel has no boot
+// partition to mount, so it's only for applications that need nothing but the
+// kernel: the first volume becomes /dev/ld0a
+func CreateImageQemuDirect(kernel string, args string, mntPoints, bakedEnv []string, noCleanup bool) (*types.RawImage, error) {
+	res, cmdline, err := qemuRumpConfig(args, mntPoints, bakedEnv, false)
+	if err != nil {
+		return nil, err
+	}
+
+	imageDir, err := ioutil.TempDir("", "rump-kernel-image.")
+	if err != nil {
+		return nil, errors.New("creating tmpdir", err)
+	}
+	if noCleanup {
+		logrus.Infof("--no-cleanup: keeping kernel image directory %s", imageDir)
+	}
+	kernelFile := filepath.Join(imageDir, "program.bin")
+	if err := unikos.CopyFile(kernel, kernelFile); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(imageDir, "cmdline"), []byte(cmdline), 0644); err != nil {
+		return nil, err
+	}
+
+	res.LocalImagePath = kernelFile
+	res.StageSpec.ImageFormat = types.ImageFormat_Kernel
+	res.RunSpec.DefaultInstanceMemory = 512
+	return res, nil
+}
+
+// qemuRumpConfig returns the rump json command line of a qemu image, and the
+// image with its volumes mapped. the volumes follow the boot disk, if there is one
+func qemuRumpConfig(args string, mntPoints, bakedEnv []string, bootDisk bool) (*types.RawImage, string, error) {
 	// create rump config
 	var c rumpConfig
 	if bakedEnv != nil {
@@ -29,19 +90,23 @@ func CreateImageQemu(kernel string, args string, mntPoints, bakedEnv []string, n
 	}
 	c = setRumpCmdLine(c, "program.bin", argv, false)
 
-	bootBlk := blk{
-		Source:     "dev",
-		Path:       "/dev/ld0e",
-		FSType:     "blk",
-		MountPoint: "/bootpart",
+	firstVolume := '0'
+	if bootDisk {
+		bootBlk := blk{
+			Source:     "dev",
+			Path:       "/dev/ld0e",
+			FSType:     "blk",
+			MountPoint: "/bootpart",
+		}
+		c.Blk = append(c.Blk, bootBlk)
+		firstVolume = '1'
 	}
-	c.Blk = append(c.Blk, bootBlk)
 
 	res := &types.RawImage{}
 	res.RunSpec.Compiler = compilers.Rump
 
 	for i, mntPoint := range mntPoints {
-		deviceMapped := fmt.Sprintf("ld%ca", '1'+i)
+		deviceMapped := fmt.Sprintf("ld%ca", firstVolume+rune(i))
 		blk := blk{
 			Source:     "dev",
 			Path:       "/dev/" + deviceMapped,
@@ -64,28 +129,9 @@ func CreateImageQemu(kernel string, args string, mntPoints, bakedEnv []string, n
 
 	cmdline, err := toRumpJson(c)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	logrus.Debugf("writing rump json config: %s", cmdline)
-
-	imgFile, err := compilers.BuildBootableImage(kernel, cmdline, true, noCleanup)
-	if err != nil {
-		return nil, err
-	}
-
-	//copy kernel for qemu
-	if err := unikos.CopyFile(kernel, filepath.Join(filepath.Dir(imgFile), "program.bin")); err != nil {
-		return nil, err
-	}
-
-	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(imgFile), "cmdline"), []byte(cmdline), 0644); err != nil {
-		return nil, err
-	}
-
-	res.LocalImagePath = imgFile
-	res.StageSpec.ImageFormat = types.ImageFormat_RAW
-	res.RunSpec.DefaultInstanceMemory = 512
-	return res, nil
-
+	return res, cmdline, nil
 }
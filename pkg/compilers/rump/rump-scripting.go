@@ -66,6 +66,9 @@ func (r *RumpScriptCompiler) CompileRawImage(params types.CompileImageParams) (*
 	}
 
 	resultFile := path.Join(sourcesDir, "program.bin")
+	if err := compilers.SquashVolumes(sourcesDir, params.SquashDirs); err != nil {
+		return nil, err
+	}
 
 	//build args string
 	args := r.RunScriptArgs
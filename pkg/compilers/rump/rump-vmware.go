@@ -6,6 +6,7 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
@@ -70,7 +71,7 @@ func createImageVmware(kernel string, args string, mntPoints, bakedEnv []string,
 		return nil, err
 	}
 
-	imgFile, err := compilers.BuildBootableImage(kernel, cmdline, true, noCleanup)
+	imgFile, err := compilers.BuildBootableImage(kernel, cmdline, true, noCleanup, unikos.DiskNaming_IDE)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
@@ -72,7 +73,7 @@ func createImageXen(kernel, args string, mntPoints, bakedEnv []string, noCleanup
 	if err != nil {
 		return nil, err
 	}
-	imgFile, err := compilers.BuildBootableImage(kernel, cmdline, false, noCleanup)
+	imgFile, err := compilers.BuildBootableImage(kernel, cmdline, false, noCleanup, unikos.DiskNaming_IDE)
 
 	if err != nil {
 		return nil, err
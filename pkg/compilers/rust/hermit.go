@@ -0,0 +1,135 @@
+package rust
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	unikutil "github.com/emc-advanced-dev/unik/pkg/util"
+	"gopkg.in/yaml.v2"
+)
+
+// the container expects the project in /opt/code, builds it with
+// cargo build --target x86_64-unknown-hermit --release and copies the binary
+// to /opt/code/program.bin
+const hermitDockerImage = "compilers-rust-hermit"
+
+// RustHermitCompiler builds rust applications linked with the hermit kernel
+// (hermit-rs) into unikernels, booted without a bootloader like rump images
+type RustHermitCompiler struct {
+	DockerImage string
+}
+
+// NewRustHermitCompiler returns the hermit compiler, building in its default container
+func NewRustHermitCompiler() *RustHermitCompiler {
+	return &RustHermitCompiler{DockerImage: hermitDockerImage}
+}
+
+// manifest.yaml in the root of the project, optional
+type hermitProjectConfig struct {
+	// path of the Cargo.toml to build, relative to the root of the project. default Cargo.toml
+	CargoToml string `yaml:"cargo_toml"`
+	// cargo features to enable
+	Features []string `yaml:"features"`
+	// the binary to boot, if the package builds more than one. default the first
+	Binary string `yaml:"binary"`
+}
+
+func (r *RustHermitCompiler) CompileRawImage(params types.CompileImageParams) (*types.RawImage, error) {
+	sourcesDir := params.SourcesDir
+	config, err := readHermitConfig(sourcesDir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(sourcesDir, config.CargoToml)); err != nil {
+		return nil, errors.New("the rust compiler requires "+config.CargoToml+" in your project", err)
+	}
+	if len(params.SquashDirs) > 0 || len(params.MntPoints) > 0 {
+		return nil, errors.New("hermit images cannot mount volumes", nil)
+	}
+
+	env := map[string]string{
+		"CARGO_TOML":     config.CargoToml,
+		"CARGO_FEATURES": strings.Join(config.Features, ","),
+		"BINARY":         config.Binary,
+	}
+	logrus.WithFields(logrus.Fields{"cargo-toml": config.CargoToml, "features": config.Features, "binary": config.Binary}).Debugf("building rust project for hermit")
+	if err := unikutil.NewContainer(r.DockerImage).WithVolume(sourcesDir, "/opt/code").WithEnvs(env).Run(); err != nil {
+		return nil, errors.New("building rust project", err)
+	}
+
+	kernel := filepath.Join(sourcesDir, "program.bin")
+	if _, err := os.Stat(kernel); err != nil {
+		return nil, errors.New("cargo build did not produce a binary", err)
+	}
+	logrus.Debugf("finished kernel binary at %s", kernel)
+
+	cmdline := params.Args
+	imgFile, err := compilers.BuildBootableImage(kernel, cmdline, true, params.NoCleanup, unikos.DiskNaming_VirtIO)
+	if err != nil {
+		return nil, errors.New("creating boot volume from kernel binary", err)
+	}
+	// qemu boots the kernel next to the image with its command line
+	if err := unikos.CopyFile(kernel, filepath.Join(filepath.Dir(imgFile), "program.bin")); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(imgFile), "cmdline"), []byte(cmdline), 0644); err != nil {
+		return nil, err
+	}
+
+	res := &types.RawImage{}
+	res.LocalImagePath = imgFile
+	res.StageSpec.ImageFormat = types.ImageFormat_RAW
+	res.RunSpec.DefaultInstanceMemory = 512
+	res.RunSpec.Compiler = compilers.Hermit
+	return res, nil
+}
+
+func readHermitConfig(sourcesDir string) (*hermitProjectConfig, error) {
+	config := &hermitProjectConfig{}
+	data, err := ioutil.ReadFile(filepath.Join(sourcesDir, "manifest.yaml"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.New("failed to read manifest.yaml file", err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, errors.New("failed to parse yaml manifest.yaml file", err)
+		}
+	}
+	if config.CargoToml == "" {
+		config.CargoToml = "Cargo.toml"
+	}
+	if filepath.IsAbs(config.CargoToml) || strings.HasPrefix(filepath.Clean(config.CargoToml), "..") {
+		return nil, errors.New("cargo_toml must be a path inside the project, not "+config.CargoToml, nil)
+	}
+	for _, feature := range config.Features {
+		if feature == "" || strings.ContainsAny(feature, ", ") {
+			return nil, errors.New("invalid cargo feature "+feature, nil)
+		}
+	}
+	return config, nil
+}
+
+func (r *RustHermitCompiler) Usage() *compilers.CompilerUsage {
+	return &compilers.CompilerUsage{
+		PrepareApplication: `
+Make your application a rust binary crate that depends on the "hermit" crate, as
+described at https://github.com/hermit-os/hermit-rs. It is built with
+	cargo build --target x86_64-unknown-hermit --release
+in a container that has the hermit target installed.
+`,
+		ConfigurationFiles: map[string]string{
+			"/manifest.yaml": `optional:
+cargo_toml: Cargo.toml   # path of the Cargo.toml to build, relative to the project root
+features: [feature1]     # cargo features to enable
+binary: my-app           # the binary to boot, if the package builds more than one
+`,
+		},
+	}
+}
@@ -0,0 +1,37 @@
+// testplugin is the compiler plugin the plugin tests build and load. its
+// "image" is a copy of program.bin from the sources
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+type testPlugin struct{}
+
+var Compiler testPlugin
+
+func (p *testPlugin) Name() string {
+	return "testos-test-qemu"
+}
+
+func (p *testPlugin) SupportedArch() []types.Architecture {
+	return []types.Architecture{types.Architecture_ARM64}
+}
+
+func (p *testPlugin) Build(srcDir string, args compilers.BuildArgs) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(srcDir, "program.bin"))
+	if err != nil {
+		return "", err
+	}
+	imagePath := filepath.Join(srcDir, "boot.img")
+	if err := ioutil.WriteFile(imagePath, append(data, []byte(args.Args)...), 0644); err != nil {
+		return "", err
+	}
+	return imagePath, nil
+}
+
+func main() {}
@@ -0,0 +1,73 @@
+package compilers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	unikutil "github.com/emc-advanced-dev/unik/pkg/util"
+)
+
+// how long a compiler whose containers were killed has to return before its
+// build is given up on
+var killGracePeriod = 30 * time.Second
+
+// BuildTimeoutError is returned by CompileWithTimeout when the compiler did not
+// finish within the timeout
+type BuildTimeoutError struct {
+	Timeout time.Duration
+	Elapsed time.Duration
+}
+
+func (e *BuildTimeoutError) Error() string {
+	return fmt.Sprintf("build killed after %v (timeout %v)", e.Elapsed, e.Timeout)
+}
+
+type compileResult struct {
+	rawImage *types.RawImage
+	err      error
+}
+
+// CompileWithTimeout compiles params with compiler, giving up after timeout.
+// when it expires, the containers mounting the sources of the build are killed,
+// which makes the compiler return, and a *BuildTimeoutError is returned. a raw
+// image the compiler still makes afterwards is removed unless params.NoCleanup.
+// a timeout of 0 does not time out
+func CompileWithTimeout(compiler Compiler, params types.CompileImageParams, timeout time.Duration) (*types.RawImage, error) {
+	if timeout <= 0 {
+		return compiler.CompileRawImage(params)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	started := time.Now()
+	done := make(chan compileResult, 1)
+	go func() {
+		rawImage, err := compiler.CompileRawImage(params)
+		done <- compileResult{rawImage: rawImage, err: err}
+	}()
+	select {
+	case result := <-done:
+		return result.rawImage, result.err
+	case <-ctx.Done():
+	}
+	elapsed := time.Since(started).Round(time.Millisecond)
+	killed := unikutil.KillContainersUsing(params.SourcesDir)
+	log.WithFields(log.Fields{"timeout": timeout, "killed": killed}).Warnf("build timed out, killed %d compiler containers", len(killed))
+
+	cleanup := func(result compileResult) {
+		if result.err == nil && result.rawImage != nil && !params.NoCleanup {
+			os.Remove(result.rawImage.LocalImagePath)
+		}
+	}
+	select {
+	case result := <-done:
+		cleanup(result)
+	case <-time.After(killGracePeriod):
+		log.Warnf("compiler still running %v after its build timed out, leaving it to finish", killGracePeriod)
+		go func() { cleanup(<-done) }()
+	}
+	return nil, &BuildTimeoutError{Timeout: timeout, Elapsed: elapsed}
+}
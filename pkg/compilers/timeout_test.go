@@ -0,0 +1,76 @@
+package compilers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// slowCompiler writes boot.img into the sources after sleeping for delay
+type slowCompiler struct {
+	delay time.Duration
+}
+
+func (c *slowCompiler) CompileRawImage(params types.CompileImageParams) (*types.RawImage, error) {
+	time.Sleep(c.delay)
+	imagePath := filepath.Join(params.SourcesDir, "boot.img")
+	if err := ioutil.WriteFile(imagePath, []byte("kernel"), 0644); err != nil {
+		return nil, err
+	}
+	return &types.RawImage{LocalImagePath: imagePath}, nil
+}
+
+func (c *slowCompiler) Usage() *compilers.CompilerUsage {
+	return nil
+}
+
+var _ = Describe("CompileWithTimeout", func() {
+	var srcDir string
+
+	BeforeEach(func() {
+		var err error
+		srcDir, err = ioutil.TempDir("", "unik.timeout-sources.")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(srcDir)
+	})
+
+	It("returns the image of compilers that finish in time", func() {
+		rawImage, err := compilers.CompileWithTimeout(&slowCompiler{}, types.CompileImageParams{SourcesDir: srcDir}, time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = os.Stat(rawImage.LocalImagePath)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("does not time out without a timeout", func() {
+		_, err := compilers.CompileWithTimeout(&slowCompiler{delay: 50 * time.Millisecond}, types.CompileImageParams{SourcesDir: srcDir}, 0)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("fails with a BuildTimeoutError and removes the image of compilers that take too long", func() {
+		_, err := compilers.CompileWithTimeout(&slowCompiler{delay: 200 * time.Millisecond}, types.CompileImageParams{SourcesDir: srcDir}, 20*time.Millisecond)
+		Expect(err).To(HaveOccurred())
+		timeoutErr, ok := err.(*compilers.BuildTimeoutError)
+		Expect(ok).To(BeTrue())
+		Expect(timeoutErr.Timeout).To(Equal(20 * time.Millisecond))
+		Expect(timeoutErr.Elapsed).To(BeNumerically(">=", 20*time.Millisecond))
+		_, err = os.Stat(filepath.Join(srcDir, "boot.img"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("keeps the image of compilers that take too long with NoCleanup", func() {
+		_, err := compilers.CompileWithTimeout(&slowCompiler{delay: 200 * time.Millisecond}, types.CompileImageParams{SourcesDir: srcDir, NoCleanup: true}, 20*time.Millisecond)
+		Expect(err).To(BeAssignableToTypeOf(&compilers.BuildTimeoutError{}))
+		_, err = os.Stat(filepath.Join(srcDir, "boot.img"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -6,19 +6,44 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/pkg/errors"
 
 	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	unikutil "github.com/emc-advanced-dev/unik/pkg/util"
 )
 
-func BuildBootableImage(kernel, cmdline string, usePartitionTables, noCleanup bool) (string, error) {
+// SquashVolumes copies each of squashDirs into dir at the path of its mount point.
+// dir must be the folder of the kernel given to BuildBootableImage, whose contents
+// end up in the boot partition.
+func SquashVolumes(dir string, squashDirs map[string]string) error {
+	mntPoints := []string{}
+	for mntPoint := range squashDirs {
+		mntPoints = append(mntPoints, mntPoint)
+	}
+	sort.Strings(mntPoints)
+	for _, mntPoint := range mntPoints {
+		dest := filepath.Join(dir, mntPoint)
+		logrus.WithFields(logrus.Fields{"src": squashDirs[mntPoint], "dst": dest}).Debug("squashing volume into boot partition")
+		if err := unikos.CopyDir(squashDirs[mntPoint], dest); err != nil {
+			return errors.New("copying dir "+squashDirs[mntPoint]+" to "+dest, err)
+		}
+	}
+	return nil
+}
+
+// BuildBootableImage builds a disk image booting kernel with cmdline. With usePartitionTables,
+// grub is installed to it as the first disk of a hypervisor that names disks according to naming.
+func BuildBootableImage(kernel, cmdline string, usePartitionTables, noCleanup bool, naming unikos.DiskNamingConvention) (string, error) {
 	directory, err := ioutil.TempDir("", "bootable-image-directory.")
 	if err != nil {
 		return "", errors.New("creating tmpdir", err)
 	}
-	if !noCleanup {
+	if noCleanup {
+		logrus.Infof("--no-cleanup: keeping bootable image build directory %s", directory)
+	} else {
 		defer os.RemoveAll(directory)
 	}
 	kernelBaseName := "program.bin"
@@ -44,6 +69,7 @@ func BuildBootableImage(kernel, cmdline string, usePartitionTables, noCleanup bo
 		"-a", cmdline,
 		"-o", filepath.Base(tmpResultFile.Name()),
 		fmt.Sprintf("-part=%v", usePartitionTables),
+		"-disk-naming", string(naming),
 	}
 	binds := map[string]string{directory: contextDir, "/dev/": "/dev/"}
 
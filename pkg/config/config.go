@@ -3,6 +3,76 @@ package config
 type DaemonConfig struct {
 	Providers Providers `yaml:"providers"`
 	Version   string    `yaml:"version"`
+	// how long instances deleted because their ttl ran out are still listed (with state "expired"), e.g. "10m"
+	ExpiredInstanceGracePeriod string          `yaml:"expired_instance_grace_period"`
+	Webhooks                   []WebhookConfig `yaml:"webhooks"`
+	Quotas                     QuotaConfig     `yaml:"quotas"`
+	ImageGC                    GCPolicy        `yaml:"image_gc"`
+	AuditLog                   AuditLogConfig  `yaml:"audit_log"`
+	StateBackend               StateBackend    `yaml:"state_backend"`
+	// refuse to run images that are not signed, or whose signature no longer matches
+	RequireSignedImages bool `yaml:"require_signed_images"`
+	// directory of the PEM encoded public keys images may be signed with. signatures
+	// made with any other key are refused
+	TrustedKeysDir string `yaml:"trusted_keys_dir"`
+	// directory of compiler plugins (.so files) to load at startup
+	CompilerPluginDir string `yaml:"compiler_plugin_dir"`
+	// platform names (build --platform) to the provider they build for, added to
+	// (or replacing) the default aliases, e.g. kvm: qemu
+	PlatformAliases map[string]string `yaml:"platform_aliases"`
+	// address to serve the gRPC api on as well, e.g. ":3001". none if empty
+	GrpcAddr string `yaml:"grpc_addr"`
+	// key of the bearer tokens (unik daemon user-token) requests must carry. the
+	// user a request is made for is then the one of its token. if empty, it is
+	// whoever the X-Unik-User header claims
+	UserTokenKey string `yaml:"user_token_key"`
+}
+
+// StateBackend says where the daemon keeps the state of its providers. Type
+// "file" (the default) keeps it in json files in the unik home. "etcd" keeps it
+// in etcd, under EtcdPrefix (default /unik); daemons sharing an etcd then
+// elect a leader, and the others wait until it goes away.
+type StateBackend struct {
+	Type          string   `yaml:"type"`
+	EtcdEndpoints []string `yaml:"etcd_endpoints"`
+	EtcdPrefix    string   `yaml:"etcd_prefix"`
+}
+
+// AuditLogConfig makes the daemon write a json line for every request that is
+// not a GET to Path. the file is rotated once it would grow past MaxSizeMB, and
+// MaxBackups rotated files are kept. leave Path empty for no audit log.
+type AuditLogConfig struct {
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+}
+
+// GCPolicy makes the daemon delete images that no instance uses, oldest first,
+// while any of its thresholds is exceeded. 0 means no threshold.
+type GCPolicy struct {
+	MaxImages  int `yaml:"max_images"`
+	MaxAgeDays int `yaml:"max_age_days"`
+	// free space on the filesystem of the unik home. only images stored on the daemon host are deleted for it
+	MinFreeDiskGB float64 `yaml:"min_free_disk_gb"`
+}
+
+// QuotaConfig limits what each user may create through the daemon. Users are
+// told apart by their bearer tokens if the daemon has a UserTokenKey, or else
+// by the X-Unik-User header of their requests. 0 means no limit.
+type QuotaConfig struct {
+	MaxInstancesPerUser int `yaml:"max_instances_per_user"`
+	MaxVolumesPerUser   int `yaml:"max_volumes_per_user"`
+	MaxImageSizeMB      int `yaml:"max_image_size_mb"`
+	// images and volumes of a user together
+	MaxTotalStorageGB int `yaml:"max_total_storage_gb"`
+}
+
+// WebhookConfig registers a url to be notified of instance and volume events.
+// Payloads are signed with an HMAC-SHA256 of the body keyed with Secret.
+type WebhookConfig struct {
+	URL    string   `yaml:"url"`
+	Secret string   `yaml:"secret"`
+	Events []string `yaml:"events"` //e.g. instance_started, volume_attached. leave empty for all events
 }
 
 type Providers struct {
@@ -15,12 +85,18 @@ type Providers struct {
 	Xen        []Xen        `yaml:"xen"`
 	Openstack  []Openstack  `yaml:"openstack"`
 	Ukvm       []Ukvm       `yaml:"ukvm"`
+	Libvirt    []Libvirt    `yaml:"libvirt"`
 }
 
 type Aws struct {
 	Name   string `yaml:"name"`
 	Region string `yaml:"region"`
 	Zone   string `yaml:"zone"`
+	// s3://bucket/prefix to keep the backing images of volumes at, e.g. for archival. optional
+	VolumeStore string `yaml:"volume_store"`
+	// images are uploaded to s3 in parts of this size (minimum 5), this many parts at a time
+	UploadPartSizeMb  int `yaml:"upload_part_size_mb"`
+	UploadConcurrency int `yaml:"upload_concurrency"`
 }
 
 type Gcloud struct {
@@ -55,6 +131,31 @@ type Qemu struct {
 	Name         string `yaml:"name"`
 	NoGraphic    bool   `yaml:"no_graphic"`
 	DebuggerPort int    `yaml:"debugger_port"`
+	// TapDevice is the tap device instances run with network mode host are attached to.
+	// optional; without it qemu creates one and runs /etc/qemu-ifup
+	TapDevice string `yaml:"tap_device"`
+	// Bridge is the bridge instances run with network mode bridge join. optional; defaults to br0
+	Bridge string `yaml:"bridge"`
+	// StopTimeout is how long stopping an instance waits for it to shut down after an
+	// acpi power off before killing it, e.g. "10s". "0s" kills instances right away; defaults to 10s
+	StopTimeout string `yaml:"stop_timeout"`
+	// VncPorts is the range of ports instances get their vnc display from, e.g. "5900-5999"
+	// (the default). ports must be 5900 or above, as qemu numbers displays from 5900
+	VncPorts string `yaml:"vnc_ports"`
+	// VncListen is the address vnc displays listen on. defaults to 127.0.0.1; set it to
+	// 0.0.0.0 to reach the displays from other hosts
+	VncListen string `yaml:"vnc_listen"`
+}
+
+type Libvirt struct {
+	Name string `yaml:"name"`
+	// URI of the libvirtd to connect to, e.g. qemu:///system (the default)
+	// or qemu+tcp://host/system
+	URI string `yaml:"uri"`
+	// Network is the libvirt network the tap device of instances is attached to. defaults to "default"
+	Network string `yaml:"network"`
+	// DomainType is the type of the domains instances run as, kvm (the default) or qemu
+	DomainType string `yaml:"domain_type"`
 }
 
 type Ukvm struct {
@@ -94,8 +195,25 @@ const (
 	HostOnlyAdapter = VirtualboxAdapterType("host_only")
 )
 
+// ClientConfig is the configuration of the unik cli, kept in ~/.unik/client-config.yaml
 type ClientConfig struct {
-	Host string `yaml:"host"`
+	// Host is the host:port of the unik daemon
+	Host string `yaml:"host" json:"host"`
+	// NoRetry makes commands fail right away if the daemon can't be reached, like --no-retry
+	NoRetry bool `yaml:"no_retry,omitempty" json:"no_retry"`
+	// User is sent with every request, so the daemon can enforce its per-user quotas
+	User string `yaml:"user,omitempty" json:"user"`
+	// TLSCert and TLSKey are a client certificate to present to the daemon (or a
+	// proxy in front of it), and TLSCA the ca to verify its certificate with.
+	// they are used for hosts given as https://HOST:PORT
+	TLSCert string `yaml:"tls_cert,omitempty" json:"tls_cert"`
+	TLSKey  string `yaml:"tls_key,omitempty" json:"tls_key"`
+	TLSCA   string `yaml:"tls_ca,omitempty" json:"tls_ca"`
+	// Token is sent as a bearer token with every request
+	Token string `yaml:"token,omitempty" json:"token"`
+	// GrpcHost is the host:port of the gRPC api of the daemon used with --grpc.
+	// the host of Host with port 3001 if empty
+	GrpcHost string `yaml:"grpc_host,omitempty" json:"grpc_host"`
 }
 
 type HubConfig struct {
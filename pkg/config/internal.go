@@ -2,6 +2,15 @@ package config
 
 type _config struct {
 	UnikHome string
+	// where local providers keep volume files, e.g. a shared nfs mount. defaults to UnikHome
+	VolumeStore string
 }
 
 var Internal _config
+
+func (c _config) VolumesHome() string {
+	if c.VolumeStore != "" {
+		return c.VolumeStore
+	}
+	return c.UnikHome
+}
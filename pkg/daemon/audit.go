@@ -0,0 +1,195 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"github.com/go-martini/martini"
+)
+
+// audit logs are rotated at this size unless the config says otherwise
+const defaultAuditLogMaxSizeMB = 100
+
+// AuditRecord is one line of the audit log
+type AuditRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	// the user of the bearer token, with a user_token_key in the daemon config
+	User string `json:"user,omitempty"`
+	// the UserHeader of requests without an authenticated user, which any client can set
+	ClaimedUser string `json:"claimed_user,omitempty"`
+	// GRPC for calls to the gRPC api, whose Path is the name of the call
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// sha256 of the request body (the request message of a gRPC call), empty for
+	// requests without one
+	RequestBodyHash string `json:"request_body_hash,omitempty"`
+	// the http status of REST requests
+	ResponseStatus int `json:"response_status,omitempty"`
+	// the status code of gRPC calls, e.g. OK or NotFound
+	GrpcCode   string `json:"grpc_code,omitempty"`
+	RemoteAddr string `json:"remote_addr"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// the Method of the AuditRecords of gRPC calls
+const grpcAuditMethod = "GRPC"
+
+// auditLog writes an AuditRecord for every request that is not a GET, as json
+// lines. once the file would grow past maxSize, it is renamed to PATH.1 (PATH.1
+// to PATH.2 and so on), and files beyond maxBackups are removed
+type auditLog struct {
+	lock       sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newAuditLog(cfg config.AuditLogConfig) (*auditLog, error) {
+	maxSizeMB := cfg.MaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultAuditLogMaxSizeMB
+	}
+	l := &auditLog{
+		path:       cfg.Path,
+		maxSize:    int64(maxSizeMB) << 20,
+		maxBackups: cfg.MaxBackups,
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return nil, errors.New("creating directory of "+l.path, err)
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *auditLog) open() error {
+	// the audit log may name users and what they did; keep it private
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.New("opening "+l.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.New("statting "+l.path, err)
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+func (l *auditLog) write(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.New("marshalling audit record", err)
+	}
+	data = append(data, '\n')
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		return errors.New("writing to "+l.path, err)
+	}
+	return nil
+}
+
+func (l *auditLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		logrus.WithError(err).Warnf("closing audit log %s", l.path)
+	}
+	os.Remove(backupName(l.path, l.maxBackups))
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		os.Rename(backupName(l.path, i), backupName(l.path, i+1))
+	}
+	if l.maxBackups > 0 {
+		if err := os.Rename(l.path, backupName(l.path, 1)); err != nil {
+			return errors.New("rotating "+l.path, err)
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return errors.New("removing full audit log "+l.path, err)
+	}
+	return l.open()
+}
+
+func backupName(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+func (l *auditLog) handler(users *userAuthenticator) martini.Handler {
+	return func(res http.ResponseWriter, req *http.Request, c martini.Context) {
+		if req.Method == "GET" {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		// the body is hashed as the handler reads it, so uploads aren't held in memory
+		var body *hashingReader
+		if req.Body != nil {
+			body = &hashingReader{body: req.Body, hash: sha256.New()}
+			req.Body = body
+		}
+		c.Next()
+		record := &AuditRecord{
+			Timestamp:      start.UTC(),
+			Method:         req.Method,
+			Path:           req.URL.RequestURI(),
+			ResponseStatus: res.(martini.ResponseWriter).Status(),
+			RemoteAddr:     req.RemoteAddr,
+			DurationMs:     int64(time.Since(start) / time.Millisecond),
+		}
+		if user, authenticated, _ := users.user(req); authenticated {
+			record.User = user
+		} else {
+			record.ClaimedUser = req.Header.Get(types.UserHeader)
+		}
+		if body != nil {
+			// read what the handler left, so the hash covers the whole body
+			io.Copy(ioutil.Discard, body)
+			if body.read > 0 {
+				record.RequestBodyHash = hex.EncodeToString(body.hash.Sum(nil))
+			}
+		}
+		if err := l.write(record); err != nil {
+			logrus.WithError(err).Errorf("failed to write audit record for %s %s", req.Method, req.URL.Path)
+		}
+	}
+}
+
+type hashingReader struct {
+	body io.ReadCloser
+	hash hash.Hash
+	read int64
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.hash.Write(p[:n])
+	r.read += int64(n)
+	return n, err
+}
+
+func (r *hashingReader) Close() error {
+	return r.body.Close()
+}
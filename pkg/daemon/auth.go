@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/go-martini/martini"
+)
+
+// the header of the user tokens the daemon issues and accepts: a jwt signed with HS256
+var userTokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// userTokenClaims are the claims of a user token. Subject is the user
+type userTokenClaims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// NewUserToken returns a bearer token that makes requests for user to a daemon
+// whose user_token_key is key. the token expires after ttl, or never if ttl is 0
+func NewUserToken(key, user string, ttl time.Duration) (string, error) {
+	if key == "" {
+		return "", errors.New("no user token key given", nil)
+	}
+	if user == "" {
+		return "", errors.New("no user given", nil)
+	}
+	claims := userTokenClaims{Subject: user, IssuedAt: time.Now().Unix()}
+	if ttl != 0 {
+		claims.ExpiresAt = time.Now().Add(ttl).Unix()
+	}
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", errors.New("marshalling token claims", err)
+	}
+	signed := userTokenHeader + "." + base64.RawURLEncoding.EncodeToString(data)
+	return signed + "." + signUserToken([]byte(key), signed), nil
+}
+
+func signUserToken(key []byte, signed string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// userAuthenticator tells who a request is made for. without a key, that is
+// whoever the UserHeader claims; with one, the subject of the bearer token,
+// which every request but the health checks must carry
+type userAuthenticator struct {
+	key []byte
+}
+
+func newUserAuthenticator(key string) *userAuthenticator {
+	if key == "" {
+		return &userAuthenticator{}
+	}
+	return &userAuthenticator{key: []byte(key)}
+}
+
+// user returns the user of req, and whether that user was authenticated
+func (a *userAuthenticator) user(req *http.Request) (string, bool, error) {
+	return a.userOf(req.Header.Get("Authorization"), req.Header.Get(types.UserHeader))
+}
+
+// userOf is user, for a request with the authorization and claimedUser headers
+func (a *userAuthenticator) userOf(authorization, claimedUser string) (string, bool, error) {
+	if a.key == nil {
+		if claimedUser != "" {
+			return claimedUser, false, nil
+		}
+		return anonymousUser, false, nil
+	}
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == "" || token == authorization {
+		return "", false, errors.New("a bearer token is required", nil)
+	}
+	user, err := a.verify(token)
+	if err != nil {
+		return "", false, err
+	}
+	return user, true, nil
+}
+
+// verify returns the user of a user token signed with a.key
+func (a *userAuthenticator) verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != userTokenHeader {
+		return "", errors.New("bearer token is not a user token", nil)
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signUserToken(a.key, parts[0]+"."+parts[1]))) {
+		return "", errors.New("bearer token is not signed with the user token key", nil)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.New("decoding token claims", err)
+	}
+	var claims userTokenClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return "", errors.New("failed to unmarshal token claims", err)
+	}
+	if claims.ExpiresAt != 0 && time.Now().Unix() >= claims.ExpiresAt {
+		return "", errors.New("bearer token expired at "+time.Unix(claims.ExpiresAt, 0).Format(time.RFC3339), nil)
+	}
+	if claims.Subject == "" {
+		return "", errors.New("bearer token names no user", nil)
+	}
+	return claims.Subject, nil
+}
+
+// handler turns away requests without a valid token with 401, if there is a key
+func (a *userAuthenticator) handler() martini.Handler {
+	return func(res http.ResponseWriter, req *http.Request, c martini.Context) {
+		if a.key == nil || req.URL.Path == "/healthz" || req.URL.Path == "/readyz" {
+			c.Next()
+			return
+		}
+		if _, _, err := a.user(req); err != nil {
+			logrus.WithError(err).Warnf("refusing %s %s from %s", req.Method, req.URL.Path, req.RemoteAddr)
+			res.WriteHeader(http.StatusUnauthorized)
+			if err := respond(res, err); err != nil {
+				logrus.WithError(err).Errorf("failed to reply to http request")
+			}
+			return
+		}
+		c.Next()
+	}
+}
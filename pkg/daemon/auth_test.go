@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("user tokens", func() {
+	request := func(token, user string) *http.Request {
+		req, err := http.NewRequest("POST", "http://localhost:3000/volumes/data", nil)
+		Expect(err).NotTo(HaveOccurred())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if user != "" {
+			req.Header.Set(types.UserHeader, user)
+		}
+		return req
+	}
+
+	It("should take the user from a token signed with the key", func() {
+		token, err := NewUserToken("secret", "alice", time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		user, authenticated, err := newUserAuthenticator("secret").user(request(token, "bob"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authenticated).To(BeTrue())
+		Expect(user).To(Equal("alice"))
+	})
+
+	It("should refuse requests without a valid token", func() {
+		users := newUserAuthenticator("secret")
+		_, _, err := users.user(request("", "alice"))
+		Expect(err).To(HaveOccurred())
+
+		forged, err := NewUserToken("other secret", "alice", time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = users.user(request(forged, ""))
+		Expect(err).To(MatchError(ContainSubstring("not signed with the user token key")))
+
+		expired, err := NewUserToken("secret", "alice", -time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = users.user(request(expired, ""))
+		Expect(err).To(MatchError(ContainSubstring("expired")))
+	})
+
+	It("should take the claimed user without a key", func() {
+		user, authenticated, err := newUserAuthenticator("").user(request("", "alice"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authenticated).To(BeFalse())
+		Expect(user).To(Equal("alice"))
+	})
+})
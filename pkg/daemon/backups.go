@@ -0,0 +1,375 @@
+package daemon
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/providers/aws"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"github.com/pborman/uuid"
+)
+
+// failed backups are kept in the record, for unik backups to show, up to this many per schedule
+const maxFailedBackupRecords = 10
+
+// the record of schedules and backups is kept in the daemon state, as the one record of its kind
+const (
+	backupRecords   = "volume-backups"
+	backupRecordKey = "schedules"
+)
+
+// volumeBackupsFile is where daemons before the daemon state kept the record of backups
+func volumeBackupsFile() string {
+	return filepath.Join(config.Internal.UnikHome, "volume-backups.json")
+}
+
+type backupRecord struct {
+	Schedules []*types.BackupSchedule `json:"Schedules"`
+	Backups   []*types.VolumeBackup   `json:"Backups"`
+}
+
+// backupManager backs up volumes on the schedules created through the api, which
+// are persisted in the daemon state along with the backups made for them. every minute,
+// the volumes whose schedule matches are copied to its destination, and the
+// oldest backups beyond its retention deleted. the volume file is copied as the
+// provider keeps it, in its own format.
+type backupManager struct {
+	lock      sync.Mutex
+	state     state.State
+	record    backupRecord
+	providers providers.Providers
+	notify    func(types.Event)
+	// region of the s3 client for s3:// destinations. the region of the environment if empty
+	s3Region string
+	// schedules with a backup in progress, which are skipped if they match again
+	running  map[string]bool
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newBackupManager(_providers providers.Providers, s3Region string, daemonState state.State, notify func(types.Event)) (*backupManager, error) {
+	m := &backupManager{
+		state:     daemonState,
+		providers: _providers,
+		notify:    notify,
+		s3Region:  s3Region,
+		running:   make(map[string]bool),
+		done:      make(chan struct{}),
+	}
+	if _, err := getRecord(daemonState, backupRecords, backupRecordKey, &m.record); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// run checks the schedules at the start of every minute until stop is called
+func (m *backupManager) run() {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		select {
+		case <-m.done:
+			timer.Stop()
+			return
+		case minute := <-timer.C:
+			m.startDue(minute.Truncate(time.Minute))
+		}
+	}
+}
+
+func (m *backupManager) stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+// startDue starts a backup for every schedule that matches minute
+func (m *backupManager) startDue(minute time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, schedule := range m.record.Schedules {
+		cron, err := parseCron(schedule.CronExpression)
+		if err != nil || !cron.matches(minute) {
+			continue
+		}
+		if m.running[schedule.Id] {
+			logrus.Warnf("backup of volume %s is still running, skipping the one due at %s", schedule.VolumeName, minute.Format(time.RFC3339))
+			continue
+		}
+		m.running[schedule.Id] = true
+		go m.backup(*schedule)
+	}
+}
+
+func validateBackupDestination(destination string) error {
+	if strings.HasPrefix(destination, "s3://") {
+		_, _, err := aws.ParseS3URL(destination)
+		return err
+	}
+	if !filepath.IsAbs(destination) {
+		return errors.New("destination "+destination+" must be an absolute path on the daemon host or an s3://bucket/prefix url", nil)
+	}
+	return nil
+}
+
+func (m *backupManager) createSchedule(volumeName, cronExpression, destination string, retention int) (*types.BackupSchedule, error) {
+	cron, err := parseCron(cronExpression)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateBackupDestination(destination); err != nil {
+		return nil, err
+	}
+	if retention < 0 {
+		return nil, errors.New("retention must not be negative", nil)
+	}
+	schedule := &types.BackupSchedule{
+		Id:             uuid.New(),
+		VolumeName:     volumeName,
+		CronExpression: cronExpression,
+		Destination:    destination,
+		Retention:      retention,
+		Created:        time.Now(),
+	}
+	if err := m.modify(func() {
+		m.record.Schedules = append(m.record.Schedules, schedule)
+	}); err != nil {
+		return nil, err
+	}
+	scheduled := *schedule
+	scheduled.NextRun = cron.next(time.Now())
+	return &scheduled, nil
+}
+
+// deleteSchedule stops backing up on a schedule. the backups made for it are kept
+func (m *backupManager) deleteSchedule(id string) error {
+	m.lock.Lock()
+	found := false
+	for _, schedule := range m.record.Schedules {
+		found = found || schedule.Id == id
+	}
+	m.lock.Unlock()
+	if !found {
+		return errors.New("no backup schedule with id "+id, nil)
+	}
+	return m.modify(func() {
+		schedules := []*types.BackupSchedule{}
+		for _, schedule := range m.record.Schedules {
+			if schedule.Id != id {
+				schedules = append(schedules, schedule)
+			}
+		}
+		m.record.Schedules = schedules
+	})
+}
+
+// list returns the schedules and backups of a volume, or of all volumes if
+// volumeName is empty. backups are sorted newest first
+func (m *backupManager) list(volumeName string) *types.VolumeBackups {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	listed := &types.VolumeBackups{Schedules: []*types.BackupSchedule{}, Backups: []*types.VolumeBackup{}}
+	for _, schedule := range m.record.Schedules {
+		if volumeName != "" && schedule.VolumeName != volumeName {
+			continue
+		}
+		scheduled := *schedule
+		if cron, err := parseCron(schedule.CronExpression); err == nil {
+			scheduled.NextRun = cron.next(time.Now())
+		}
+		listed.Schedules = append(listed.Schedules, &scheduled)
+	}
+	for _, backup := range m.record.Backups {
+		if volumeName == "" || backup.VolumeName == volumeName {
+			listed.Backups = append(listed.Backups, backup)
+		}
+	}
+	sort.Slice(listed.Backups, func(i, j int) bool {
+		return listed.Backups[i].Started.After(listed.Backups[j].Started)
+	})
+	return listed
+}
+
+// renameVolume moves the schedules and backups of a volume to its new name
+func (m *backupManager) renameVolume(oldName, newName string) error {
+	return m.modify(func() {
+		for _, schedule := range m.record.Schedules {
+			if schedule.VolumeName == oldName {
+				schedule.VolumeName = newName
+			}
+		}
+		for _, backup := range m.record.Backups {
+			if backup.VolumeName == oldName {
+				backup.VolumeName = newName
+			}
+		}
+	})
+}
+
+// backup copies the volume of schedule to its destination, records the backup
+// and publishes a volume_backup_completed or volume_backup_failed event
+func (m *backupManager) backup(schedule types.BackupSchedule) {
+	backup := &types.VolumeBackup{
+		Id:         uuid.New(),
+		ScheduleId: schedule.Id,
+		VolumeName: schedule.VolumeName,
+		Started:    time.Now(),
+	}
+	logrus.WithFields(logrus.Fields{"volume": schedule.VolumeName, "destination": schedule.Destination}).Infof("backing up volume")
+	if err := m.copyVolume(backup, schedule.Destination); err != nil {
+		logrus.WithError(err).Warnf("backup of volume %s failed", schedule.VolumeName)
+		backup.Error = err.Error()
+	}
+	backup.Completed = time.Now()
+
+	var expired []*types.VolumeBackup
+	if err := m.modify(func() {
+		delete(m.running, schedule.Id)
+		m.record.Backups = append(m.record.Backups, backup)
+		expired = m.expire(schedule)
+	}); err != nil {
+		logrus.WithError(err).Warnf("failed to record backup of volume %s", schedule.VolumeName)
+	}
+	for _, old := range expired {
+		if err := m.deleteBackup(old.Location); err != nil {
+			logrus.WithError(err).Warnf("failed to delete expired backup %s of volume %s", old.Location, old.VolumeName)
+		}
+	}
+	m.notify(types.NewVolumeBackupEvent(backup))
+}
+
+// expire drops the backups of schedule beyond its retention, and the oldest of
+// its failed backups, from the record. the backups whose data should be deleted
+// are returned. m.lock must be held
+func (m *backupManager) expire(schedule types.BackupSchedule) []*types.VolumeBackup {
+	var succeeded, failed int
+	var expired []*types.VolumeBackup
+	kept := []*types.VolumeBackup{}
+	// newest first, so that the oldest are the ones expired
+	for i := len(m.record.Backups) - 1; i >= 0; i-- {
+		backup := m.record.Backups[i]
+		if backup.ScheduleId == schedule.Id && backup.Error != "" {
+			failed++
+			if failed > maxFailedBackupRecords {
+				continue
+			}
+		} else if backup.ScheduleId == schedule.Id {
+			succeeded++
+			if schedule.Retention > 0 && succeeded > schedule.Retention {
+				expired = append(expired, backup)
+				continue
+			}
+		}
+		kept = append([]*types.VolumeBackup{backup}, kept...)
+	}
+	m.record.Backups = kept
+	return expired
+}
+
+// copyVolume copies the file of the volume of backup to a file named after the
+// volume and the time in the destination directory or bucket, and sets the
+// location and size of backup
+func (m *backupManager) copyVolume(backup *types.VolumeBackup, destination string) error {
+	provider, err := m.providers.ProviderForVolume(backup.VolumeName)
+	if err != nil {
+		return err
+	}
+	volume, err := provider.GetVolume(backup.VolumeName)
+	if err != nil {
+		return errors.New("retrieving volume "+backup.VolumeName, err)
+	}
+	backup.VolumeId = volume.Id
+	localVolumes, ok := provider.(providers.LocalVolumeProvider)
+	if !ok {
+		return errors.New("volume "+volume.Name+" is not stored on the daemon host and cannot be backed up", nil)
+	}
+	volumeFile, format, err := localVolumes.GetVolumeFile(volume.Id)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(volumeFile)
+	if err != nil {
+		return errors.New("statting volume file", err)
+	}
+	name := volume.Name + "-" + backup.Started.UTC().Format("20060102T150405Z") + "." + string(format)
+
+	if strings.HasPrefix(destination, "s3://") {
+		bucket, prefix, err := aws.ParseS3URL(destination)
+		if err != nil {
+			return err
+		}
+		key := path.Join(prefix, name)
+		if err := aws.UploadFileToS3(m.s3Region, volumeFile, bucket, key); err != nil {
+			return errors.New("uploading volume "+volume.Name+" to "+destination, err)
+		}
+		backup.Location = "s3://" + bucket + "/" + key
+	} else {
+		location := filepath.Join(destination, name)
+		if err := copyBackupFile(volumeFile, location); err != nil {
+			return errors.New("copying volume "+volume.Name+" to "+destination, err)
+		}
+		backup.Location = location
+	}
+	backup.SizeBytes = info.Size()
+	return nil
+}
+
+// copyBackupFile copies src to dst through a temporary file, so that a backup
+// that fails midway never looks complete
+func copyBackupFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".partial"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+func (m *backupManager) deleteBackup(location string) error {
+	if strings.HasPrefix(location, "s3://") {
+		bucket, key, err := aws.ParseS3URL(location)
+		if err != nil {
+			return err
+		}
+		return aws.DeleteFromS3(m.s3Region, bucket, key)
+	}
+	if err := os.Remove(location); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (m *backupManager) modify(change func()) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	change()
+	return putRecord(m.state, backupRecords, backupRecordKey, m.record)
+}
@@ -0,0 +1,155 @@
+package daemon
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// batchProviders returns the providers a batch operation runs on: the one named,
+// or all of them if name is empty
+func (d *UnikDaemon) batchProviders(name string) (providers.Providers, error) {
+	if name == "" {
+		return d.providers, nil
+	}
+	provider, ok := d.providers[name]
+	if !ok {
+		return nil, errors.New(name+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+	}
+	return providers.Providers{name: provider}, nil
+}
+
+func newBatchResult() *types.BatchResult {
+	return &types.BatchResult{Succeeded: []string{}, Failed: make(map[string]string)}
+}
+
+// addToBatch records whether the operation on name succeeded in result
+func addToBatch(result *types.BatchResult, name string, err error) {
+	if err != nil {
+		result.Failed[name] = err.Error()
+		return
+	}
+	result.Succeeded = append(result.Succeeded, name)
+}
+
+// stopInstance stops an instance, shutting it down gracefully within timeout if
+// the provider can
+func (d *UnikDaemon) stopInstance(provider providers.Provider, instanceId string, timeout time.Duration) error {
+	d.monitor.setStoppedByUser(instanceId, true)
+	var err error
+	if stopper, ok := provider.(providers.GracefulStopper); ok && timeout > 0 {
+		err = stopper.GracefulStop(instanceId, timeout)
+	} else {
+		if timeout > 0 {
+			logrus.Warnf("the provider of instance %s cannot shut it down gracefully, stopping it right away", instanceId)
+		}
+		err = provider.StopInstance(instanceId)
+	}
+	if err != nil {
+		d.monitor.setStoppedByUser(instanceId, false)
+		return errors.New("could not stop instance "+instanceId, err)
+	}
+	d.notifyInstance(types.EventType_InstanceStopped, provider, instanceId)
+	return nil
+}
+
+func (d *UnikDaemon) deleteInstance(provider providers.Provider, instance *types.Instance, force bool) error {
+	if err := provider.DeleteInstance(instance.Id, force); err != nil {
+		return err
+	}
+	d.quotas.releaseInstance(provider, instance.Name)
+	d.notify(types.NewInstanceEvent(types.EventType_InstanceDeleted, instance))
+	return nil
+}
+
+func (d *UnikDaemon) deleteVolume(provider providers.Provider, volume *types.Volume, force bool) error {
+	if err := provider.DeleteVolume(volume.Id, force); err != nil {
+		return errors.New("could not delete volume", err)
+	}
+	d.quotas.releaseVolume(provider, volume.Name)
+	return nil
+}
+
+func (d *UnikDaemon) deleteImage(provider providers.Provider, image *types.Image, force bool) error {
+	if err := provider.DeleteImage(image.Id, force); err != nil {
+		return err
+	}
+	d.quotas.releaseImage(provider, image.Name)
+	d.removeImageSignature(image.Id)
+	return nil
+}
+
+// stopAllInstances stops every instance of providers that is not stopped
+// already. instances that fail to stop do not keep the others from being
+// stopped; their errors are all returned in the result
+func (d *UnikDaemon) stopAllInstances(providers providers.Providers, timeout time.Duration) (*types.BatchResult, error) {
+	result := newBatchResult()
+	for _, provider := range providers {
+		instances, err := provider.ListInstances()
+		if err != nil {
+			return nil, errors.New("could not get instance list", err)
+		}
+		for _, instance := range instances {
+			switch instance.State {
+			case types.InstanceState_Stopped, types.InstanceState_Terminated, types.InstanceState_Expired:
+				continue
+			}
+			logrus.WithFields(logrus.Fields{"instance": instance.Name, "timeout": timeout}).Infof("stopping instance %s", instance.Name)
+			addToBatch(result, instance.Name, d.stopInstance(provider, instance.Id, timeout))
+		}
+	}
+	return result, nil
+}
+
+// deleteAllInstances deletes every instance of providers, collecting the errors
+// of the ones that could not be deleted rather than stopping at the first
+func (d *UnikDaemon) deleteAllInstances(providers providers.Providers, force bool) (*types.BatchResult, error) {
+	result := newBatchResult()
+	for _, provider := range providers {
+		instances, err := provider.ListInstances()
+		if err != nil {
+			return nil, errors.New("could not get instance list", err)
+		}
+		for _, instance := range instances {
+			logrus.WithFields(logrus.Fields{"instance": instance.Name, "force": force}).Infof("deleting instance %s", instance.Name)
+			addToBatch(result, instance.Name, d.deleteInstance(provider, instance, force))
+		}
+	}
+	return result, nil
+}
+
+// deleteAllVolumes deletes every volume of providers, as deleteAllInstances
+func (d *UnikDaemon) deleteAllVolumes(providers providers.Providers, force bool) (*types.BatchResult, error) {
+	result := newBatchResult()
+	for _, provider := range providers {
+		volumes, err := provider.ListVolumes()
+		if err != nil {
+			return nil, errors.New("could not get volume list", err)
+		}
+		for _, volume := range volumes {
+			logrus.WithFields(logrus.Fields{"volume": volume.Name, "force": force}).Infof("deleting volume %s", volume.Name)
+			addToBatch(result, volume.Name, d.deleteVolume(provider, volume, force))
+		}
+	}
+	return result, nil
+}
+
+// deleteAllImages deletes every image of providers, as deleteAllInstances
+func (d *UnikDaemon) deleteAllImages(providers providers.Providers, force bool) (*types.BatchResult, error) {
+	result := newBatchResult()
+	for _, provider := range providers {
+		images, err := provider.ListImages()
+		if err != nil {
+			return nil, errors.New("could not get image list", err)
+		}
+		for _, image := range images {
+			logrus.WithFields(logrus.Fields{"image": image.Name, "force": force}).Infof("deleting image %s", image.Name)
+			addToBatch(result, image.Name, d.deleteImage(provider, image, force))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const (
+	// port of the iperf3 server run in the instance
+	benchmarkPort = 5201
+	// size of the file written by the disk test, in MiB
+	benchmarkWriteMiB = 100
+	benchmarkFile     = "/tmp/test"
+	// time the iperf3 server gets to start listening
+	benchmarkServerStartup = time.Second
+	// time the disk test gets at least
+	minBenchmarkDiskTimeout = time.Minute
+	// time the short commands run in instances get to exit
+	guestCommandTimeout = 10 * time.Second
+)
+
+// benchmarkInstance measures the network throughput between the daemon host and
+// an instance, with iperf3, and how fast it writes to its disk, with dd. both
+// run in the instance through the GuestExecer of its provider. a test that
+// fails does not keep the other from running; its error is in the result
+func benchmarkInstance(execer providers.GuestExecer, instance *types.Instance, duration time.Duration) *types.BenchmarkResult {
+	result := &types.BenchmarkResult{Instance: instance.Name, Duration: duration, Errors: make(map[string]string)}
+	if err := benchmarkNetwork(execer, instance, duration, result); err != nil {
+		logrus.WithError(err).Warnf("network benchmark of instance %s failed", instance.Name)
+		result.Errors["network"] = err.Error()
+	}
+	if err := benchmarkDisk(execer, instance, duration, result); err != nil {
+		logrus.WithError(err).Warnf("disk benchmark of instance %s failed", instance.Name)
+		result.Errors["disk"] = err.Error()
+	}
+	return result
+}
+
+func benchmarkNetwork(execer providers.GuestExecer, instance *types.Instance, duration time.Duration, result *types.BenchmarkResult) error {
+	address, err := benchmarkAddress(instance)
+	if err != nil {
+		return err
+	}
+	result.NetworkAddress = address
+	// -1 makes the server exit after the one test
+	if _, err := execer.GuestExec(instance.Id, "iperf3", []string{"-s", "-1", "-p", strconv.Itoa(benchmarkPort)}, 0); err != nil {
+		return errors.New("starting iperf3 server in instance", err)
+	}
+	time.Sleep(benchmarkServerStartup)
+
+	host, port, _ := net.SplitHostPort(address)
+	seconds := int(duration.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), duration+30*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "iperf3", "-c", host, "-p", port, "-t", strconv.Itoa(seconds), "-J").Output()
+	if err != nil {
+		return errors.New("running iperf3 client to "+address+": "+strings.TrimSpace(string(out)), err)
+	}
+	sent, received, err := parseIperf3Output(out)
+	if err != nil {
+		return err
+	}
+	result.SentMbps = sent / 1e6
+	result.ReceivedMbps = received / 1e6
+	return nil
+}
+
+// benchmarkAddress is where the daemon host reaches the iperf3 server of
+// instance: the host port forwarded to it, or else the address of the instance
+func benchmarkAddress(instance *types.Instance) (string, error) {
+	for _, mapping := range instance.Ports {
+		if mapping.GuestPort == benchmarkPort && mapping.Protocol == "tcp" {
+			return net.JoinHostPort("127.0.0.1", strconv.Itoa(mapping.HostPort)), nil
+		}
+	}
+	if instance.IpAddress == "" {
+		return "", errors.New("instance "+instance.Name+" has no ip address, and no host port forwarded to port "+strconv.Itoa(benchmarkPort), nil)
+	}
+	return net.JoinHostPort(instance.IpAddress, strconv.Itoa(benchmarkPort)), nil
+}
+
+// parseIperf3Output returns the bits per second sent and received by an
+// iperf3 client run with -J
+func parseIperf3Output(out []byte) (float64, float64, error) {
+	var report struct {
+		End struct {
+			SumSent struct {
+				BitsPerSecond float64 `json:"bits_per_second"`
+			} `json:"sum_sent"`
+			SumReceived struct {
+				BitsPerSecond float64 `json:"bits_per_second"`
+			} `json:"sum_received"`
+		} `json:"end"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return 0, 0, errors.New("parsing iperf3 output", err)
+	}
+	if report.Error != "" {
+		return 0, 0, errors.New("iperf3: "+report.Error, nil)
+	}
+	return report.End.SumSent.BitsPerSecond, report.End.SumReceived.BitsPerSecond, nil
+}
+
+func benchmarkDisk(execer providers.GuestExecer, instance *types.Instance, duration time.Duration, result *types.BenchmarkResult) error {
+	timeout := duration
+	if timeout < minBenchmarkDiskTimeout {
+		timeout = minBenchmarkDiskTimeout
+	}
+	// conv=fsync so the time includes writing the file out of the page cache
+	dd, err := execer.GuestExec(instance.Id, "dd", []string{"if=/dev/zero", "of=" + benchmarkFile, "bs=1M", fmt.Sprintf("count=%d", benchmarkWriteMiB), "conv=fsync"}, timeout)
+	if err != nil {
+		return errors.New("running dd in instance", err)
+	}
+	if rm, err := execer.GuestExec(instance.Id, "rm", []string{"-f", benchmarkFile}, guestCommandTimeout); err != nil || rm.ExitCode != 0 {
+		logrus.WithError(err).Warnf("failed to remove %s from instance %s", benchmarkFile, instance.Name)
+	}
+	if dd.ExitCode != 0 {
+		return errors.New(fmt.Sprintf("dd exited with status %d: %s", dd.ExitCode, strings.TrimSpace(dd.Stderr)), nil)
+	}
+	bytes, seconds, err := parseDdOutput(dd.Stderr)
+	if err != nil {
+		return err
+	}
+	result.DiskWriteBytes = bytes
+	if seconds > 0 {
+		result.DiskWriteMiBps = float64(bytes) / seconds / (1 << 20)
+	}
+	return nil
+}
+
+// the summary line of dd, e.g. "104857600 bytes (105 MB, 100 MiB) copied, 0.52 s, 201 MB/s"
+// from gnu dd, or "104857600 bytes (100.0MB) copied, 0.52 seconds, 192.3MB/s" from busybox
+var ddSummary = regexp.MustCompile(`(\d+) bytes .*copied, ([0-9.]+) s`)
+
+// parseDdOutput returns the bytes dd wrote and the seconds it took, from its stderr
+func parseDdOutput(stderr string) (int64, float64, error) {
+	match := ddSummary.FindStringSubmatch(stderr)
+	if match == nil {
+		return 0, 0, errors.New("no summary in the output of dd: "+strings.TrimSpace(stderr), nil)
+	}
+	bytes, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("parsing bytes written by dd", err)
+	}
+	seconds, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0, 0, errors.New("parsing time dd took", err)
+	}
+	return bytes, seconds, nil
+}
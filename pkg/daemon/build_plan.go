@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"strconv"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// completeBuildPlan fails the dry run of a build if staging the image would
+// fail on its name, and estimates the size of the image from the size of the
+// sources the client reported (in bytes)
+func completeBuildPlan(plan *types.BuildPlan, provider providers.Provider, force bool, baseImage *types.Image, sourcesSize string) error {
+	images, err := provider.ListImages()
+	if err != nil {
+		return errors.New("retrieving image list for existing image", err)
+	}
+	for _, image := range images {
+		if image.Name == plan.Name {
+			if !force {
+				return errors.New("an image already exists with name '"+plan.Name+"', try again with --force", nil)
+			}
+			plan.ReplacesImage = true
+		}
+	}
+
+	var sourcesBytes int64
+	if sourcesSize != "" {
+		sourcesBytes, err = strconv.ParseInt(sourcesSize, 10, 64)
+		if err != nil || sourcesBytes < 0 {
+			return errors.New("sources_size must be a non-negative number of bytes", err)
+		}
+	}
+	plan.SourcesSizeMB = (sourcesBytes + 1<<20 - 1) >> 20
+	contentBytes := sourcesBytes
+	if baseImage != nil {
+		plan.BaseImage = baseImage.Name
+		// the files of the base image are copied under the sources
+		contentBytes += baseImage.SizeMb << 20
+	}
+	plan.EstimatedSizeMB = unikos.BootImageSizeMB(contentBytes)
+	return nil
+}
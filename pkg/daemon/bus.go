@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const (
+	// buffer per subscriber; events for subscribers that fall this far behind are dropped
+	subscriberBufferSize = 64
+	// number of past events kept around for clients that aren't following the stream
+	eventHistorySize = 100
+)
+
+// eventBus fans events out to any number of subscribers, e.g. webhooks and
+// clients connected to /events. publishing never blocks on a slow subscriber.
+type eventBus struct {
+	lock        sync.RWMutex
+	subscribers map[chan types.Event]struct{}
+	history     []types.Event
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		subscribers: make(map[chan types.Event]struct{}),
+	}
+}
+
+// subscribe returns a channel receiving all events published from now on,
+// and a function to cancel the subscription, which closes the channel
+func (b *eventBus) subscribe() (<-chan types.Event, func()) {
+	events := make(chan types.Event, subscriberBufferSize)
+	b.lock.Lock()
+	b.subscribers[events] = struct{}{}
+	b.lock.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.lock.Lock()
+			delete(b.subscribers, events)
+			b.lock.Unlock()
+			close(events)
+		})
+	}
+	return events, unsubscribe
+}
+
+// recent returns up to eventHistorySize of the last published events, oldest first
+func (b *eventBus) recent() []types.Event {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	events := make([]types.Event, len(b.history))
+	copy(events, b.history)
+	return events
+}
+
+func (b *eventBus) publish(event types.Event) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.history = append(b.history, event)
+	if len(b.history) > eventHistorySize {
+		b.history = b.history[len(b.history)-eventHistorySize:]
+	}
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			logrus.Warnf("event subscriber is not keeping up, dropping %s event for %s", event.Type, event.Id)
+		}
+	}
+}
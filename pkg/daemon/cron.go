@@ -0,0 +1,184 @@
+package daemon
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// how far ahead cronSchedule.next looks for a matching minute. far enough for
+// expressions that only match on the 29th of february
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule is a parsed cron expression of the usual five fields: minute,
+// hour, day of month, month and day of week. each field is a set of the values
+// it matches, as the bits of a uint64
+type cronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// as in cron, a day matches if either day field does unless one of them is *
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	// 7 is sunday as well as 0
+	{"day of week", 0, 7},
+}
+
+// parseCron parses a cron expression, e.g. "0 2 * * *". fields are lists of
+// values, ranges (1-5) and steps (*/15, 0-30/10); the @daily style aliases are
+// understood too. times are matched in the time zone of the daemon host
+func parseCron(expr string) (*cronSchedule, error) {
+	if alias, ok := cronAliases[strings.TrimSpace(expr)]; ok {
+		expr = alias
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, errors.New("cron expression "+expr+" must have 5 fields: minute hour day-of-month month day-of-week", nil)
+	}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, errors.New("invalid cron expression "+expr, err)
+		}
+		sets[i] = set
+	}
+	// sunday
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	schedule := &cronSchedule{
+		minute:        sets[0],
+		hour:          sets[1],
+		dayOfMonth:    sets[2],
+		month:         sets[3],
+		dayOfWeek:     sets[4],
+		anyDayOfMonth: strings.HasPrefix(fields[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(fields[4], "*"),
+	}
+	if schedule.next(time.Now()).IsZero() {
+		return nil, errors.New("cron expression "+expr+" never matches", nil)
+	}
+	return schedule, nil
+}
+
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, errors.New("invalid step in "+bounds.name+" field "+field, err)
+			}
+			rangePart = part[:i]
+		}
+		low, high := bounds.min, bounds.max
+		if rangePart != "*" {
+			if i := strings.Index(rangePart, "-"); i >= 0 {
+				var err error
+				if low, err = cronValue(rangePart[:i], bounds); err != nil {
+					return 0, err
+				}
+				if high, err = cronValue(rangePart[i+1:], bounds); err != nil {
+					return 0, err
+				}
+				if low > high {
+					return 0, errors.New("range "+rangePart+" of "+bounds.name+" field ends before it starts", nil)
+				}
+			} else {
+				value, err := cronValue(rangePart, bounds)
+				if err != nil {
+					return 0, err
+				}
+				low = value
+				// 5/10 means from 5 to the end, every 10
+				if step == 1 {
+					high = value
+				}
+			}
+		}
+		for value := low; value <= high; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+	return set, nil
+}
+
+func cronValue(s string, bounds cronField) (int, error) {
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.New("invalid "+bounds.name+" "+s, err)
+	}
+	if value < bounds.min || value > bounds.max {
+		return 0, errors.New(bounds.name+" "+s+" must be between "+strconv.Itoa(bounds.min)+" and "+strconv.Itoa(bounds.max), nil)
+	}
+	return value, nil
+}
+
+// matches reports whether the schedule fires in the minute of t
+func (s *cronSchedule) matches(t time.Time) bool {
+	return s.minute&(1<<uint(t.Minute())) != 0 && s.hour&(1<<uint(t.Hour())) != 0 &&
+		s.month&(1<<uint(t.Month())) != 0 && s.matchesDay(t)
+}
+
+// next returns the first minute after t the schedule fires in, or the zero time
+// if there is none within cronSearchLimit
+func (s *cronSchedule) next(t time.Time) time.Time {
+	limit := t.Add(cronSearchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) != 0 {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	}
+	return dayOfMonth || dayOfWeek
+}
@@ -1,10 +1,13 @@
 package daemon
 
 import (
+	"context"
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"sort"
@@ -22,11 +25,12 @@ import (
 	"github.com/emc-advanced-dev/unik/pkg/compilers/mirage"
 	"github.com/emc-advanced-dev/unik/pkg/compilers/osv"
 	"github.com/emc-advanced-dev/unik/pkg/compilers/rump"
+	"github.com/emc-advanced-dev/unik/pkg/compilers/rust"
 	"github.com/emc-advanced-dev/unik/pkg/config"
-	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/providers"
 	"github.com/emc-advanced-dev/unik/pkg/providers/aws"
 	"github.com/emc-advanced-dev/unik/pkg/providers/gcloud"
+	"github.com/emc-advanced-dev/unik/pkg/providers/libvirt"
 	"github.com/emc-advanced-dev/unik/pkg/providers/openstack"
 	"github.com/emc-advanced-dev/unik/pkg/providers/photon"
 	"github.com/emc-advanced-dev/unik/pkg/providers/qemu"
@@ -45,6 +49,29 @@ type UnikDaemon struct {
 	server    *martini.ClassicMartini
 	providers providers.Providers `json:"providers"`
 	compilers map[compilers.CompilerType]compilers.Compiler
+	monitor   *instanceMonitor
+	reaper    *instanceReaper
+	webhooks  *webhookManager
+	bus       *eventBus
+	quotas    *quotaManager
+	state     state.State
+	imageGC   *imageCollector
+	backups   *backupManager
+	started   time.Time
+	requests  *requestTracker
+	done      chan struct{}
+	// refuse to run images that are not signed (--require-signed-images)
+	requireSignedImages bool
+	// the public keys images may be signed with, by fingerprint
+	trustedKeys map[string]crypto.PublicKey
+	// platform name to provider name, see ResolvePlatformAlias
+	platformAliases map[string]string
+	// address the gRPC api is served on, none if empty
+	grpcAddr string
+	// who requests are made for
+	users *userAuthenticator
+	// nil without an audit_log path in the config
+	audit *auditLog
 }
 
 const (
@@ -58,6 +85,7 @@ const (
 	ukvm_provider       = "ukvm"
 	gcloud_provider     = "gcloud"
 	openstack_provider  = "openstack"
+	libvirt_provider    = "libvirt"
 )
 
 func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
@@ -70,16 +98,20 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 	os.Setenv("TMPDIR", tmpDir)
 	os.MkdirAll(tmpDir, 0755)
 
+	openState, err := stateOpener(config.StateBackend)
+	if err != nil {
+		return nil, errors.New("setting up state backend", err)
+	}
+
 	_providers := make(providers.Providers)
 	_compilers := make(map[compilers.CompilerType]compilers.Compiler)
 
 	for _, awsConfig := range config.Providers.Aws {
 		logrus.Infof("Bootstrapping provider %s with config %v", aws_provider, awsConfig)
 		p := aws.NewAwsProvier(awsConfig)
-		s, err := state.BasicStateFromFile(aws.AwsStateFile())
+		s, err := openState(aws_provider, aws.AwsStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read aws state file at %s, creating blank aws state", aws.AwsStateFile())
-			s = state.NewBasicState(aws.AwsStateFile())
+			return nil, errors.New("opening aws state", err)
 		}
 		p = p.WithState(s)
 		_providers[aws_provider] = p
@@ -91,10 +123,9 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		if err != nil {
 			return nil, errors.New("initializing vsphere provider", err)
 		}
-		s, err := state.BasicStateFromFile(vsphere.VsphereStateFile())
+		s, err := openState(vsphere_provider, vsphere.VsphereStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read vsphere state file at %s, creating blank vsphere state", vsphere.VsphereStateFile())
-			s = state.NewBasicState(vsphere.VsphereStateFile())
+			return nil, errors.New("opening vsphere state", err)
 		}
 		p = p.WithState(s)
 		_providers[vsphere_provider] = p
@@ -106,10 +137,9 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		if err != nil {
 			return nil, errors.New("initializing virtualbox provider", err)
 		}
-		s, err := state.BasicStateFromFile(virtualbox.VirtualboxStateFile())
+		s, err := openState(virtualbox_provider, virtualbox.VirtualboxStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read virtualbox state file at %s, creating blank virtualbox state", virtualbox.VirtualboxStateFile())
-			s = state.NewBasicState(virtualbox.VirtualboxStateFile())
+			return nil, errors.New("opening virtualbox state", err)
 		}
 		p = p.WithState(s)
 		_providers[virtualbox_provider] = p
@@ -122,26 +152,39 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		if err != nil {
 			return nil, errors.New("initializing qemu provider", err)
 		}
-		s, err := state.BasicStateFromFile(qemu.QemuStateFile())
+		s, err := openState(qemu_provider, qemu.QemuStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read qemu state file at %s, creating blank qemu state", qemu.QemuStateFile())
-			s = state.NewBasicState(qemu.QemuStateFile())
+			return nil, errors.New("opening qemu state", err)
 		}
 		p = p.WithState(s)
 		_providers[qemu_provider] = p
 		break
 	}
 
+	for _, libvirtConfig := range config.Providers.Libvirt {
+		logrus.Infof("Bootstrapping provider %s with config %v", libvirt_provider, libvirtConfig)
+		p, err := libvirt.NewLibvirtProvider(libvirtConfig)
+		if err != nil {
+			return nil, errors.New("initializing libvirt provider", err)
+		}
+		s, err := openState(libvirt_provider, libvirt.LibvirtStateFile())
+		if err != nil {
+			return nil, errors.New("opening libvirt state", err)
+		}
+		p = p.WithState(s)
+		_providers[libvirt_provider] = p
+		break
+	}
+
 	for _, photonConfig := range config.Providers.Photon {
 		logrus.Infof("Bootstrapping provider %s with config %v", photon_provider, photonConfig)
 		p, err := photon.NewPhotonProvider(photonConfig)
 		if err != nil {
 			return nil, errors.New("initializing photon provider", err)
 		}
-		s, err := state.BasicStateFromFile(photon.PhotonStateFile())
+		s, err := openState(photon_provider, photon.PhotonStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read photon state file at %s, creating blank photon state", photon.PhotonStateFile())
-			s = state.NewBasicState(photon.PhotonStateFile())
+			return nil, errors.New("opening photon state", err)
 		}
 		p = p.WithState(s)
 		_providers[photon_provider] = p
@@ -161,10 +204,9 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		if err != nil {
 			return nil, errors.New("initializing openstack provider", err)
 		}
-		s, err := state.BasicStateFromFile(openstack.OpenstackStateFile())
+		s, err := openState(openstack_provider, openstack.OpenstackStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read openstack state file at %s, creating blank openstack state", openstack.OpenstackStateFile())
-			s = state.NewBasicState(openstack.OpenstackStateFile())
+			return nil, errors.New("opening openstack state", err)
 		}
 		p = p.WithState(s)
 		_providers[openstack_provider] = p
@@ -177,10 +219,9 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		if err != nil {
 			return nil, errors.New("initializing xen provider", err)
 		}
-		s, err := state.BasicStateFromFile(xen.XenStateFile())
+		s, err := openState(xen_provider, xen.XenStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read xen state file at %s, creating blank state", xen.XenStateFile())
-			s = state.NewBasicState(xen.XenStateFile())
+			return nil, errors.New("opening xen state", err)
 		}
 		p = p.WithState(s)
 		_providers[xen_provider] = p
@@ -193,10 +234,9 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		if err != nil {
 			return nil, errors.New("initializing ukvm provider", err)
 		}
-		s, err := state.BasicStateFromFile(ukvm.UkvmStateFile())
+		s, err := openState(ukvm_provider, ukvm.UkvmStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read ukvm state file at %s, creating blank state", ukvm.UkvmStateFile())
-			s = state.NewBasicState(ukvm.UkvmStateFile())
+			return nil, errors.New("opening ukvm state", err)
 		}
 		p = p.WithState(s)
 		_providers[ukvm_provider] = p
@@ -209,10 +249,9 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		if err != nil {
 			return nil, errors.New("initializing gcloud provider", err)
 		}
-		s, err := state.BasicStateFromFile(gcloud.GcloudStateFile())
+		s, err := openState(gcloud_provider, gcloud.GcloudStateFile())
 		if err != nil {
-			logrus.WithError(err).Warnf("failed to read gcloud state file at %s, creating blank state", gcloud.GcloudStateFile())
-			s = state.NewBasicState(gcloud.GcloudStateFile())
+			return nil, errors.New("opening gcloud state", err)
 		}
 		p = p.WithState(s)
 		_providers[gcloud_provider] = p
@@ -253,13 +292,21 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		BootstrapType: rump.BootstrapTypeUDP,
 	}
 	_compilers[compilers.RUMP_GO_QEMU] = &rump.RumpGoCompiler{
+		RumCompilerBase: rump.RumCompilerBase{
+			DockerImage:       "compilers-rump-go-hw",
+			CreateImage:       rump.CreateImageQemu,
+			CreateKernelImage: rump.CreateImageQemuDirect,
+		},
+		BootstrapType: rump.BootstrapTypeNoStub,
+	}
+	_compilers[compilers.RUMP_GO_OPENSTACK] = &rump.RumpGoCompiler{
 		RumCompilerBase: rump.RumCompilerBase{
 			DockerImage: "compilers-rump-go-hw",
 			CreateImage: rump.CreateImageQemu,
 		},
 		BootstrapType: rump.BootstrapTypeNoStub,
 	}
-	_compilers[compilers.RUMP_GO_OPENSTACK] = &rump.RumpGoCompiler{
+	_compilers[compilers.RUMP_GO_LIBVIRT] = &rump.RumpGoCompiler{
 		RumCompilerBase: rump.RumCompilerBase{
 			DockerImage: "compilers-rump-go-hw",
 			CreateImage: rump.CreateImageQemu,
@@ -278,6 +325,7 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 	_compilers[compilers.INCLUDEOS_CPP_QEMU] = &includeos.IncludeosQemuCompiler{}
 	_compilers[compilers.INCLUDEOS_CPP_VIRTUALBOX] = &includeos.IncludeosVirtualboxCompiler{}
 	_compilers[compilers.INCLUDEOS_CPP_OPENSTACK] = &includeos.IncludeosQemuCompiler{}
+	_compilers[compilers.INCLUDEOS_CPP_LIBVIRT] = &includeos.IncludeosQemuCompiler{}
 
 	//rump nodejs
 	_compilers[compilers.RUMP_NODEJS_XEN] = &rump.RumpScriptCompiler{
@@ -326,11 +374,16 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 		},
 		RunScriptArgs: "/bootpart/node-wrapper.js",
 	}
+	_compilers[compilers.RUMP_NODEJS_LIBVIRT] = _compilers[compilers.RUMP_NODEJS_QEMU]
+
+	//hermit rust
+	_compilers[compilers.HERMIT_RUST_QEMU] = rust.NewRustHermitCompiler()
+	_compilers[compilers.HERMIT_RUST_LIBVIRT] = _compilers[compilers.HERMIT_RUST_QEMU]
 
 	//mirage ocaml
 	_compilers[compilers.MIRAGE_OCAML_XEN] = &mirage.MirageCompiler{Type: mirage.XenType}
 	_compilers[compilers.MIRAGE_OCAML_UKVM] = &mirage.MirageCompiler{Type: mirage.UKVMType}
-	// _compilers[compilers.MIRAGE_OCAML_QEMU] = &mirage.MirageCompiler{Type: mirage.VirtioType}
+	_compilers[compilers.MIRAGE_OCAML_QEMU] = &mirage.MirageCompiler{Type: mirage.VirtioType}
 
 	//rump python
 	_compilers[compilers.RUMP_PYTHON_XEN] = rump.NewRumpPythonCompiler("compilers-rump-python3-xen", rump.CreateImageXenAddStub, rump.BootstrapTypeUDP)
@@ -339,6 +392,7 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 	_compilers[compilers.RUMP_PYTHON_VSPHERE] = rump.NewRumpPythonCompiler("compilers-rump-python3-hw", rump.CreateImageVmwareAddStub, rump.BootstrapTypeUDP)
 	_compilers[compilers.RUMP_PYTHON_QEMU] = rump.NewRumpPythonCompiler("compilers-rump-python3-hw-no-stub", rump.CreateImageQemu, rump.BootstrapTypeNoStub)
 	_compilers[compilers.RUMP_PYTHON_OPENSTACK] = rump.NewRumpPythonCompiler("compilers-rump-python3-hw-no-stub", rump.CreateImageQemu, rump.BootstrapTypeNoStub)
+	_compilers[compilers.RUMP_PYTHON_LIBVIRT] = rump.NewRumpPythonCompiler("compilers-rump-python3-hw-no-stub", rump.CreateImageQemu, rump.BootstrapTypeNoStub)
 
 	//rump java
 	_compilers[compilers.RUMP_JAVA_XEN] = rump.NewRumpJavaCompiler("compilers-rump-java-xen", rump.CreateImageXen, rump.BootstrapTypeUDP)
@@ -347,14 +401,19 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 	_compilers[compilers.RUMP_JAVA_VSPHERE] = rump.NewRumpJavaCompiler("compilers-rump-java-hw", rump.CreateImageVmware, rump.BootstrapTypeUDP)
 	_compilers[compilers.RUMP_JAVA_QEMU] = rump.NewRumpJavaCompiler("compilers-rump-java-hw", rump.CreateImageQemu, rump.BootstrapTypeNoStub)
 	_compilers[compilers.RUMP_JAVA_OPENSTACK] = rump.NewRumpJavaCompiler("compilers-rump-java-hw", rump.CreateImageQemu, rump.BootstrapTypeNoStub)
+	_compilers[compilers.RUMP_JAVA_LIBVIRT] = rump.NewRumpJavaCompiler("compilers-rump-java-hw", rump.CreateImageQemu, rump.BootstrapTypeNoStub)
 
 	//rump c
 	_compilers[compilers.RUMP_C_XEN] = rump.NewRumpCCompiler("compilers-rump-c-xen", rump.CreateImageXenAddStub)
 	_compilers[compilers.RUMP_C_AWS] = rump.NewRumpCCompiler("compilers-rump-c-xen", rump.CreateImageXenAddStub)
 	_compilers[compilers.RUMP_C_VIRTUALBOX] = rump.NewRumpCCompiler("compilers-rump-c-hw", rump.CreateImageVirtualBoxAddStub)
 	_compilers[compilers.RUMP_C_VSPHERE] = rump.NewRumpCCompiler("compilers-rump-c-hw", rump.CreateImageVmwareAddStub)
-	_compilers[compilers.RUMP_C_QEMU] = rump.NewRumpCCompiler("compilers-rump-c-hw", rump.CreateImageQemu)
+	rumpCQemu := rump.NewRumpCCompiler("compilers-rump-c-hw", rump.CreateImageQemu)
+	// qemu boots rump kernels that need no boot partition with -kernel alone
+	rumpCQemu.CreateKernelImage = rump.CreateImageQemuDirect
+	_compilers[compilers.RUMP_C_QEMU] = rumpCQemu
 	_compilers[compilers.RUMP_C_OPENSTACK] = rump.NewRumpCCompiler("compilers-rump-c-hw", rump.CreateImageQemu)
+	_compilers[compilers.RUMP_C_LIBVIRT] = rump.NewRumpCCompiler("compilers-rump-c-hw", rump.CreateImageQemu)
 
 	//osv java
 	osvJavaXenCompiler := &osv.OSvJavaCompiler{
@@ -377,6 +436,7 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 	}
 	_compilers[compilers.OSV_NODEJS_QEMU] = osvNodeQemuCompiler
 	_compilers[compilers.OSV_NODEJS_OPENSTACK] = osvNodeQemuCompiler
+	_compilers[compilers.OSV_NODEJS_LIBVIRT] = osvNodeQemuCompiler
 
 	// osv native
 	osvNativeQemuCompiler := &osv.OSvNativeCompiler{
@@ -384,11 +444,101 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 	}
 	_compilers[compilers.OSV_NATIVE_QEMU] = osvNativeQemuCompiler
 	_compilers[compilers.OSV_NATIVE_OPENSTACK] = osvNativeQemuCompiler
+	_compilers[compilers.OSV_NATIVE_LIBVIRT] = osvNativeQemuCompiler
+
+	if config.CompilerPluginDir != "" {
+		plugins, err := compilers.LoadPlugins(config.CompilerPluginDir)
+		if err != nil {
+			return nil, errors.New("loading compiler plugins", err)
+		}
+		for compilerType, compiler := range plugins {
+			if _, ok := _compilers[compilerType]; ok {
+				logrus.Warnf("compiler plugin %s replaces the built in compiler", compilerType)
+			}
+			_compilers[compilerType] = compiler
+		}
+	}
+
+	daemonState, err := openState("daemon", daemonStateFile())
+	if err != nil {
+		return nil, errors.New("opening daemon state", err)
+	}
+	if err := importDaemonFiles(daemonState); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := newWebhookManager(config.Webhooks, daemonState)
+	if err != nil {
+		return nil, errors.New("initializing webhooks", err)
+	}
+
+	quotas := newQuotaManager(config.Quotas, daemonState, _providers)
+	if err := quotas.importUsageFile(quotaUsageFile()); err != nil {
+		return nil, errors.New("initializing quotas", err)
+	}
+
+	trustedKeys, err := loadTrustedKeys(config.TrustedKeysDir)
+	if err != nil {
+		return nil, errors.New("loading trusted keys", err)
+	}
+	if config.RequireSignedImages && len(trustedKeys) == 0 {
+		return nil, errors.New("signed images are required but no trusted keys were given; put the public keys images may be signed with in --trusted-keys-dir", nil)
+	}
 
 	d := &UnikDaemon{
 		server:    lxmartini.QuietMartini(),
 		providers: _providers,
 		compilers: _compilers,
+		webhooks:  webhooks,
+		bus:       newEventBus(),
+		quotas:    quotas,
+		state:     daemonState,
+		requests:  &requestTracker{},
+		done:      make(chan struct{}),
+
+		requireSignedImages: config.RequireSignedImages,
+		trustedKeys:         trustedKeys,
+		platformAliases:     newPlatformAliases(config.PlatformAliases),
+		grpcAddr:            config.GrpcAddr,
+		users:               newUserAuthenticator(config.UserTokenKey),
+	}
+	if err := d.importManifestsFile(manifestsFile()); err != nil {
+		return nil, errors.New("initializing manifests", err)
+	}
+	if config.AuditLog.Path != "" {
+		auditLog, err := newAuditLog(config.AuditLog)
+		if err != nil {
+			return nil, errors.New("opening audit log", err)
+		}
+		d.audit = auditLog
+		d.server.Use(auditLog.handler(d.users))
+	}
+	d.server.Use(d.users.handler())
+	d.server.Use(d.requests.handler())
+	webhookEvents, _ := d.bus.subscribe()
+	go d.webhooks.listen(webhookEvents)
+	d.monitor = newInstanceMonitor(d.providers, d.notify)
+
+	gracePeriod := defaultExpiredInstanceGracePeriod
+	if config.ExpiredInstanceGracePeriod != "" {
+		gracePeriod, err = time.ParseDuration(config.ExpiredInstanceGracePeriod)
+		if err != nil {
+			return nil, errors.New("invalid expired_instance_grace_period "+config.ExpiredInstanceGracePeriod, err)
+		}
+	}
+	d.reaper = newInstanceReaper(d.providers, gracePeriod, d.notify)
+	d.imageGC = newImageCollector(d.providers, config.ImageGC, d.manifestImages, func(provider providers.Provider, image *types.Image) {
+		d.quotas.releaseImage(provider, image.Name)
+		d.removeImageSignature(image.Id)
+	})
+
+	// s3 backups use the region of the first aws provider
+	var s3Region string
+	if len(config.Providers.Aws) > 0 {
+		s3Region = config.Providers.Aws[0].Region
+	}
+	if d.backups, err = newBackupManager(d.providers, s3Region, daemonState, d.notify); err != nil {
+		return nil, errors.New("initializing volume backups", err)
 	}
 
 	d.initialize()
@@ -397,16 +547,52 @@ func NewUnikDaemon(config config.DaemonConfig) (*UnikDaemon, error) {
 }
 
 func (d *UnikDaemon) Run(port int) {
-	d.server.RunOnAddr(fmt.Sprintf(":%v", port))
+	d.started = time.Now()
+	go d.monitor.run(instanceMonitorInterval)
+	go d.reaper.run(instanceReaperInterval)
+	go d.imageGC.run(imageGCInterval)
+	go d.backups.run()
+	server := &http.Server{Addr: fmt.Sprintf(":%v", port), Handler: d.server}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-d.done
+		// lets the reply to the shutdown request go out before the connections close
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logrus.WithError(err).Warnf("closing http server")
+		}
+	}()
+	if d.grpcAddr != "" {
+		if err := d.serveGrpc(d.grpcAddr); err != nil {
+			logrus.WithError(err).Fatalf("running grpc server")
+		}
+	}
+	logrus.Infof("listening on %s", server.Addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		logrus.WithError(err).Fatalf("running http server")
+	}
+	<-stopped
+	logrus.Infof("daemon stopped")
 }
 
+// Stop shuts the daemon down right away, without waiting for requests in flight
 func (d *UnikDaemon) Stop() error {
-	return d.server.Close()
+	if !d.requests.beginShutdown() {
+		return errors.New("daemon is shutting down already", nil)
+	}
+	d.shutdown(0)
+	close(d.done)
+	return nil
 }
 
 func (d *UnikDaemon) initialize() {
 	handle := func(res http.ResponseWriter, action func() (interface{}, int, error)) {
 		jsonObject, statusCode, err := action()
+		if _, ok := err.(*types.QuotaExceeded); ok {
+			statusCode = http.StatusTooManyRequests
+		}
 		res.WriteHeader(statusCode)
 		if err != nil {
 			if err := respond(res, err); err != nil {
@@ -423,272 +609,458 @@ func (d *UnikDaemon) initialize() {
 		}
 	}
 
+	//health
+	d.server.Get("/healthz", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			status, code := d.checkHealth(false)
+			return status, code, nil
+		})
+	})
+	d.server.Get("/readyz", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			status, code := d.checkHealth(true)
+			return status, code, nil
+		})
+	})
+	d.server.Post(shutdownPath, func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			timeout := defaultShutdownTimeout
+			if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+				var err error
+				if timeout, err = time.ParseDuration(timeoutStr); err != nil {
+					return nil, http.StatusBadRequest, errors.New("invalid timeout "+timeoutStr, err)
+				}
+			}
+			wait := strings.ToLower(req.URL.Query().Get("wait")) == "true"
+			if !d.requests.beginShutdown() {
+				return nil, http.StatusConflict, errors.New("daemon is shutting down already", nil)
+			}
+			logrus.WithFields(logrus.Fields{"timeout": timeout, "wait": wait}).Infof("shutting down daemon")
+			if !wait {
+				go func() {
+					d.shutdown(timeout)
+					close(d.done)
+				}()
+				return nil, http.StatusAccepted, nil
+			}
+			result := d.shutdown(timeout)
+			close(d.done)
+			return result, http.StatusOK, nil
+		})
+	})
+	d.server.Get("/admin/status", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			return d.status(), http.StatusOK, nil
+		})
+	})
+
 	//images
 	d.server.Get("/images", func(res http.ResponseWriter, req *http.Request) {
 		handle(res, func() (interface{}, int, error) {
-			allImages := []*types.Image{}
-			for _, provider := range d.providers {
-				images, err := provider.ListImages()
-				if err != nil {
-					return nil, http.StatusInternalServerError, errors.New("could not get image list", err)
-				}
-				allImages = append(allImages, images...)
+			tags, err := tagFilter(req.URL.Query())
+			if err != nil {
+				return nil, http.StatusBadRequest, err
 			}
-			logrus.WithFields(logrus.Fields{
-				"images": allImages,
-			}).Debugf("Listing all images")
-			return allImages, http.StatusOK, nil
+			return d.serveListImages(tags)
 		})
 	})
 	d.server.Get("/images/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			return d.serveGetImage(params["image_name"])
+		})
+	})
+	d.server.Post("/images/:image_name/tags", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
 			imageName := params["image_name"]
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			defer req.Body.Close()
+			var tagRequest types.TagRequest
+			if err := json.Unmarshal(body, &tagRequest); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			if err := types.ValidateTags(tagRequest.Tags); err != nil {
+				return nil, http.StatusBadRequest, errors.New("invalid tags", err)
+			}
 			provider, err := d.providers.ProviderForImage(imageName)
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
-			image, err := provider.GetImage(imageName)
+			logrus.WithFields(logrus.Fields{"image": imageName, "tags": tagRequest.Tags}).Infof("tagging image")
+			image, err := modifyImageTags(provider, imageName, addTags(tagRequest.Tags))
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
 			return image, http.StatusOK, nil
 		})
 	})
-	d.server.Post("/images/:name/create", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Delete("/images/:image_name/tags/:key", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
-			name := params["name"]
-			if name == "" {
-				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
-			}
-			err := req.ParseMultipartForm(0)
+			imageName := params["image_name"]
+			provider, err := d.providers.ProviderForImage(imageName)
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
-			logrus.WithFields(logrus.Fields{
-				"req": req,
-			}).Debugf("parsing multipart form")
-			logrus.WithFields(logrus.Fields{
-				"form": req.Form,
-			}).Debugf("parsing form file marked 'tarfile'")
-			sourceTar, _, err := req.FormFile("tarfile")
+			logrus.WithFields(logrus.Fields{"image": imageName, "key": params["key"]}).Infof("untagging image")
+			image, err := modifyImageTags(provider, imageName, removeTag(params["key"]))
 			if err != nil {
-				return nil, http.StatusBadRequest, errors.New("parsing form file marked 'tarfile", err)
-			}
-			defer sourceTar.Close()
-
-			noCleanupStr := req.FormValue("no_cleanup")
-			var noCleanup bool
-			if strings.ToLower(noCleanupStr) == "true" {
-				noCleanup = true
+				return nil, http.StatusInternalServerError, err
 			}
-
-			sourcesDir, err := ioutil.TempDir("", "unpacked.sources.dir.")
+			return image, http.StatusOK, nil
+		})
+	})
+	d.server.Get("/images/:image_name/diff/:other_image", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			logrus.Infof("diffing image %s against %s", params["image_name"], params["other_image"])
+			diff, err := d.diffImages(params["image_name"], params["other_image"])
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("creating tmp dir for src files", err)
-			}
-
-			if !noCleanup {
-				defer os.RemoveAll(sourcesDir)
-			}
-
-			logrus.Debugf("extracting uploaded files to " + sourcesDir)
-			if err := unikos.ExtractTar(sourceTar, sourcesDir); err != nil {
-				return nil, http.StatusInternalServerError, errors.New("extracting sources", err)
-			}
-			forceStr := req.FormValue("force")
-			var force bool
-			if strings.ToLower(forceStr) == "true" {
-				force = true
-			}
-			args := req.FormValue("args")
-			providerName := req.FormValue("provider")
-			if _, ok := d.providers[providerName]; !ok {
-				return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
-			}
-
-			base := req.FormValue("base")
-			if base == "" {
-				return nil, http.StatusBadRequest, errors.New("must provide 'base' parameter", nil)
-			}
-			lang := req.FormValue("lang")
-			if lang == "" {
-				return nil, http.StatusBadRequest, errors.New("must provide 'lang' parameter", nil)
+				return nil, http.StatusInternalServerError, err
 			}
-			compilerName, err := compilers.ValidateCompiler(base, lang, providerName)
+			return diff, http.StatusOK, nil
+		})
+	})
+	d.server.Get("/images/:image_name/validate", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			boot := strings.ToLower(req.URL.Query().Get("boot")) == "true"
+			logrus.WithField("boot", boot).Infof("validating image %s", params["image_name"])
+			report, err := d.validateImage(params["image_name"], boot)
 			if err != nil {
-				return nil, http.StatusBadRequest, errors.New("invalid base - lang - provider match", err)
-			}
-
-			compiler, ok := d.compilers[compilerName]
-			if !ok {
-				return nil, http.StatusBadRequest, errors.New("unikernel type "+compilerName.String()+" not available for "+providerName+"infrastructure", nil)
-			}
-			mntStr := req.FormValue("mounts")
-
-			var mountPoints []string
-			if len(mntStr) > 0 {
-				mountPoints = strings.Split(mntStr, ",")
-			}
-
-			logrus.WithFields(logrus.Fields{
-				"force":        force,
-				"mount-points": mountPoints,
-				"name":         name,
-				"args":         args,
-				"compiler":     compilerName,
-				"provider":     providerName,
-				"noCleanup":    noCleanup,
-			}).Debugf("compiling raw image")
-
-			compileParams := types.CompileImageParams{
-				SourcesDir: sourcesDir,
-				Args:       args,
-				MntPoints:  mountPoints,
-				NoCleanup:  noCleanup,
+				return nil, http.StatusInternalServerError, err
 			}
-
-			rawImage, err := compiler.CompileRawImage(compileParams)
+			return report, http.StatusOK, nil
+		})
+	})
+	d.server.Get("/images/:image_name/digest", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			imageName := params["image_name"]
+			provider, err := d.providers.ProviderForImage(imageName)
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("failed to compile raw image", err)
-			}
-			logrus.Debugf("raw image compiled and saved to " + rawImage.LocalImagePath)
-
-			if !noCleanup {
-				defer os.Remove(rawImage.LocalImagePath)
+				return nil, http.StatusInternalServerError, err
 			}
-
-			stageParams := types.StageImageParams{
-				Name:      name,
-				RawImage:  rawImage,
-				Force:     force,
-				NoCleanup: noCleanup,
+			image, err := provider.GetImage(imageName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
 			}
-
-			image, err := d.providers[providerName].Stage(stageParams)
+			digest, err := digestImage(provider, image)
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("failed staging image", err)
+				return nil, http.StatusInternalServerError, err
 			}
-			return image, http.StatusCreated, nil
+			return &types.ImageDigest{Image: image.Name, Digest: digest}, http.StatusOK, nil
 		})
 	})
-	d.server.Delete("/images/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Get("/images/:image_name/signature", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
 			imageName := params["image_name"]
-			if imageName == "" {
-				logrus.WithFields(logrus.Fields{
-					"request": fmt.Sprintf("%v", req),
-				}).Errorf("image must be named")
-				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
-			}
-			logrus.WithFields(logrus.Fields{
-				"request": req,
-			}).Infof("deleting image " + imageName)
-			forceStr := req.URL.Query().Get("force")
-			force := false
-			if strings.ToLower(forceStr) == "true" {
-				force = true
-			}
 			provider, err := d.providers.ProviderForImage(imageName)
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
-			err = provider.DeleteImage(imageName, force)
+			image, err := provider.GetImage(imageName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			signature, err := d.loadImageSignature(image.Id)
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
-			return nil, http.StatusNoContent, nil
+			if signature == nil {
+				return nil, http.StatusNotFound, errors.New("image "+image.Name+" is not signed", nil)
+			}
+			return signature, http.StatusOK, nil
 		})
 	})
-	d.server.Post("/images/push/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Post("/images/:image_name/signature", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
 			imageName := params["image_name"]
-			if imageName == "" {
-				logrus.WithFields(logrus.Fields{
-					"request": fmt.Sprintf("%v", req),
-				}).Errorf("image must be named")
-				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
-			}
-			var c config.HubConfig
 			body, err := ioutil.ReadAll(req.Body)
 			if err != nil {
 				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
 			}
-			if err := json.Unmarshal(body, &c); err != nil {
+			defer req.Body.Close()
+			var signRequest types.SignImageRequest
+			if err := json.Unmarshal(body, &signRequest); err != nil {
 				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
 			}
-			logrus.WithFields(logrus.Fields{
-				"request": req,
-			}).Infof("pushing image " + imageName + " to " + c.URL)
+			publicKey, err := util.ParsePublicKeyPEM([]byte(signRequest.PublicKey))
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			if err := d.checkTrustedKey(publicKey); err != nil {
+				return nil, http.StatusForbidden, err
+			}
 			provider, err := d.providers.ProviderForImage(imageName)
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
-			err = provider.PushImage(types.PushImagePararms{
-				ImageName: imageName,
-				Config:    c,
-			})
+			image, err := d.signImage(provider, imageName, publicKey, signRequest.Signature)
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusBadRequest, err
 			}
-			return nil, http.StatusAccepted, nil
+			logrus.WithFields(logrus.Fields{"image": image.Name, "key": image.SignatureKeyFingerprint}).Infof("image signed")
+			return image, http.StatusOK, nil
 		})
 	})
-	d.server.Post("/images/pull/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Post("/images/:name/create", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
-			imageName := params["image_name"]
-			if imageName == "" {
+			// dry runs validate the build without uploading the sources
+			var sources io.Reader
+			if strings.ToLower(req.URL.Query().Get("dry_run")) == "true" {
+				if err := req.ParseForm(); err != nil {
+					return nil, http.StatusBadRequest, errors.New("parsing form", err)
+				}
+			} else {
+				err := req.ParseMultipartForm(0)
+				if err != nil {
+					return nil, http.StatusInternalServerError, err
+				}
 				logrus.WithFields(logrus.Fields{
-					"request": fmt.Sprintf("%v", req),
-				}).Errorf("image must be named")
-				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
+					"form": req.Form,
+				}).Debugf("parsing form file marked 'tarfile'")
+				sourceTar, _, err := req.FormFile("tarfile")
+				if err != nil {
+					return nil, http.StatusBadRequest, errors.New("parsing form file marked 'tarfile", err)
+				}
+				defer sourceTar.Close()
+				sources = sourceTar
 			}
-			var c config.HubConfig
+			return d.serveBuildImage(d.requestUser(req), params["name"], req.Form, sources)
+		})
+	})
+	d.server.Patch("/images/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			imageName := params["image_name"]
 			body, err := ioutil.ReadAll(req.Body)
 			if err != nil {
 				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
 			}
-			if err := json.Unmarshal(body, &c); err != nil {
+			defer req.Body.Close()
+			var renameRequest types.RenameImageRequest
+			if err := json.Unmarshal(body, &renameRequest); err != nil {
 				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
 			}
-			logrus.WithFields(logrus.Fields{
-				"request": req,
-			}).Infof("pushing image " + imageName + " to " + c.URL)
-			providerName := req.URL.Query().Get("provider")
-			provider, ok := d.providers[providerName]
-			if !ok {
-				return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+			if renameRequest.Name == "" {
+				return nil, http.StatusBadRequest, errors.New("must give the new Name of the image", nil)
 			}
-			forceStr := req.URL.Query().Get("force")
-			force := false
-			if strings.ToLower(forceStr) == "true" {
-				force = true
+			provider, err := d.providers.ProviderForImage(imageName)
+			if err != nil {
+				return nil, http.StatusNotFound, err
 			}
-			err = provider.PullImage(types.PullImagePararms{
-				ImageName: imageName,
-				Config:    c,
-				Force:     force,
-			})
+			taken, err := d.imageNameTaken(renameRequest.Name)
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
-			return nil, http.StatusAccepted, nil
+			if taken {
+				return nil, http.StatusConflict, errors.New("an image or manifest is already named "+renameRequest.Name, nil)
+			}
+			if _, ok := provider.(providers.ImageRenamer); !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of image "+imageName+" cannot rename images", nil)
+			}
+			image, err := d.renameImage(provider, imageName, renameRequest.Name)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"image": imageName, "name": image.Name}).Infof("image renamed")
+			return image, http.StatusOK, nil
 		})
 	})
-	d.server.Post("/images/remote-delete/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Delete("/images", func(res http.ResponseWriter, req *http.Request) {
 		handle(res, func() (interface{}, int, error) {
-			imageName := params["image_name"]
-			if imageName == "" {
-				logrus.WithFields(logrus.Fields{
-					"request": fmt.Sprintf("%v", req),
-				}).Errorf("image must be named")
-				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
+			if strings.ToLower(req.URL.Query().Get("all")) != "true" {
+				return nil, http.StatusBadRequest, errors.New("deleting all images can only be done with all=true", nil)
 			}
-			var c config.HubConfig
-			body, err := ioutil.ReadAll(req.Body)
+			providersToUse, err := d.batchProviders(req.URL.Query().Get("provider"))
 			if err != nil {
-				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+				return nil, http.StatusBadRequest, err
 			}
-			if err := json.Unmarshal(body, &c); err != nil {
+			force := strings.ToLower(req.URL.Query().Get("force")) == "true"
+			result, err := d.deleteAllImages(providersToUse, force)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"succeeded": result.Succeeded, "failed": result.Failed}).Infof("deleting all images done")
+			return result, http.StatusOK, nil
+		})
+	})
+	d.server.Delete("/images/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			status, err := d.serveDeleteImage(params["image_name"], strings.ToLower(req.URL.Query().Get("force")) == "true")
+			return nil, status, err
+		})
+	})
+	//manifests
+	d.server.Get("/manifests", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			manifests, err := d.listManifests()
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return manifests, http.StatusOK, nil
+		})
+	})
+	d.server.Get("/manifests/:name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			manifest, err := d.getManifest(params["name"])
+			if err != nil {
+				return nil, http.StatusNotFound, err
+			}
+			return manifest, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/manifests", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			defer req.Body.Close()
+			var manifestRequest types.CreateManifestRequest
+			if err := json.Unmarshal(body, &manifestRequest); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			manifest, err := d.createManifest(manifestRequest)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("creating manifest", err)
+			}
+			logrus.WithFields(logrus.Fields{"manifest": manifest.Name, "images": manifest.Images}).Infof("manifest created")
+			return manifest, http.StatusCreated, nil
+		})
+	})
+	d.server.Delete("/manifests/:name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			if err := d.deleteManifest(params["name"]); err != nil {
+				return nil, http.StatusNotFound, err
+			}
+			logrus.WithField("manifest", params["name"]).Infof("manifest deleted")
+			return nil, http.StatusNoContent, nil
+		})
+	})
+	d.server.Post("/admin/gc/images", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			logrus.Infof("running image garbage collection")
+			status := d.imageGC.collect()
+			logrus.WithFields(logrus.Fields{
+				"deleted":     status.ImagesDeleted,
+				"bytes-freed": status.BytesFreed,
+			}).Infof("image garbage collection finished")
+			return status, http.StatusOK, nil
+		})
+	})
+	d.server.Get("/admin/gc/status", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			return d.imageGC.lastStatus(), http.StatusOK, nil
+		})
+	})
+	d.server.Post("/images/prune", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			defer req.Body.Close()
+			var opts types.PruneOptions
+			if err := json.Unmarshal(body, &opts); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			logrus.WithFields(logrus.Fields{"older-than": opts.OlderThan, "untagged": opts.Untagged, "dry-run": opts.DryRun}).Infof("pruning images")
+			result, err := d.pruneImages(opts)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return result, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/images/push/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			imageName := params["image_name"]
+			if imageName == "" {
+				logrus.WithFields(logrus.Fields{
+					"request": fmt.Sprintf("%v", req),
+				}).Errorf("image must be named")
+				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
+			}
+			var c config.HubConfig
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			if err := json.Unmarshal(body, &c); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			logrus.WithFields(logrus.Fields{
+				"request": req,
+			}).Infof("pushing image " + imageName + " to " + c.URL)
+			provider, err := d.providers.ProviderForImage(imageName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			err = provider.PushImage(types.PushImagePararms{
+				ImageName: imageName,
+				Config:    c,
+			})
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return nil, http.StatusAccepted, nil
+		})
+	})
+	d.server.Post("/images/pull/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			imageName := params["image_name"]
+			if imageName == "" {
+				logrus.WithFields(logrus.Fields{
+					"request": fmt.Sprintf("%v", req),
+				}).Errorf("image must be named")
+				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
+			}
+			var c config.HubConfig
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			if err := json.Unmarshal(body, &c); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			logrus.WithFields(logrus.Fields{
+				"request": req,
+			}).Infof("pushing image " + imageName + " to " + c.URL)
+			providerName := req.URL.Query().Get("provider")
+			provider, ok := d.providers[providerName]
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+			}
+			forceStr := req.URL.Query().Get("force")
+			force := false
+			if strings.ToLower(forceStr) == "true" {
+				force = true
+			}
+			err = d.pullImage(provider, d.requestUser(req), types.PullImagePararms{
+				ImageName: imageName,
+				Config:    c,
+				Force:     force,
+			})
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return nil, http.StatusAccepted, nil
+		})
+	})
+	d.server.Post("/images/remote-delete/:image_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			imageName := params["image_name"]
+			if imageName == "" {
+				logrus.WithFields(logrus.Fields{
+					"request": fmt.Sprintf("%v", req),
+				}).Errorf("image must be named")
+				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
+			}
+			var c config.HubConfig
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			if err := json.Unmarshal(body, &c); err != nil {
 				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
 			}
 			logrus.WithFields(logrus.Fields{
@@ -705,422 +1077,950 @@ func (d *UnikDaemon) initialize() {
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
-			return nil, http.StatusAccepted, nil
+			return nil, http.StatusAccepted, nil
+		})
+	})
+
+	//Instances
+	d.server.Get("/instances", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			page, err := parseListPage(req.URL.Query(), instanceSortFields)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("invalid page", err)
+			}
+			filter, err := parseInstanceFilter(req.URL.Query())
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("invalid instance filter", err)
+			}
+			instances, total, status, err := d.serveListInstances(page, filter)
+			if err != nil {
+				return nil, status, err
+			}
+			res.Header().Set(totalCountHeader, strconv.Itoa(total))
+			if link := page.linkHeader(req.URL, total); link != "" {
+				res.Header().Set("Link", link)
+			}
+			return instances, status, nil
+		})
+	})
+	d.server.Get("/instances/:instance_id", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			return d.serveGetInstance(params["instance_id"])
+		})
+	})
+	d.server.Get("/instances/:instance_id/network", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			inspector, ok := provider.(providers.NetworkInspector)
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of instance "+instanceId+" cannot list its network interfaces", nil)
+			}
+			interfaces, err := inspector.GetInstanceNetwork(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("listing network interfaces of instance "+instanceId, err)
+			}
+			return interfaces, http.StatusOK, nil
+		})
+	})
+	d.server.Get("/instances/:instance_id/metrics", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			reporter, ok := provider.(providers.MetricsReporter)
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of instance "+instanceId+" cannot report its cpu and memory usage", nil)
+			}
+			instance, err := provider.GetInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusNotFound, errors.New("retrieving instance "+instanceId, err)
+			}
+			if instance.State != types.InstanceState_Running {
+				return nil, http.StatusConflict, errors.New("instance "+instance.Name+" is "+string(instance.State)+", only running instances have metrics", nil)
+			}
+			metrics, err := reporter.GetInstanceMetrics(instance.Id)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("sampling metrics of instance "+instance.Name, err)
+			}
+			return metrics, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/instances/:instance_id/benchmark", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			duration := 10 * time.Second
+			if durationStr := req.URL.Query().Get("duration"); durationStr != "" {
+				var err error
+				duration, err = time.ParseDuration(durationStr)
+				if err != nil || duration <= 0 {
+					return nil, http.StatusBadRequest, errors.New("duration must be a positive duration, e.g. 30s", err)
+				}
+			}
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusNotFound, err
+			}
+			execer, ok := provider.(providers.GuestExecer)
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of instance "+instanceId+" cannot run commands in it", nil)
+			}
+			instance, err := provider.GetInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusNotFound, errors.New("retrieving instance "+instanceId, err)
+			}
+			if instance.State != types.InstanceState_Running {
+				return nil, http.StatusConflict, errors.New("instance "+instance.Name+" is "+string(instance.State)+", only running instances can be benchmarked", nil)
+			}
+			logrus.WithFields(logrus.Fields{"instance": instance.Name, "duration": duration}).Info("benchmarking instance")
+			return benchmarkInstance(execer, instance, duration), http.StatusOK, nil
+		})
+	})
+	d.server.Post("/instances/:instance_id/tags", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			defer req.Body.Close()
+			var tagRequest types.TagRequest
+			if err := json.Unmarshal(body, &tagRequest); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			if err := types.ValidateTags(tagRequest.Tags); err != nil {
+				return nil, http.StatusBadRequest, errors.New("invalid tags", err)
+			}
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"instance": instanceId, "tags": tagRequest.Tags}).Infof("tagging instance")
+			instance, err := modifyInstanceTags(provider, instanceId, addTags(tagRequest.Tags))
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return instance, http.StatusOK, nil
+		})
+	})
+	d.server.Delete("/instances/:instance_id/tags/:key", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"instance": instanceId, "key": params["key"]}).Infof("untagging instance")
+			instance, err := modifyInstanceTags(provider, instanceId, removeTag(params["key"]))
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return instance, http.StatusOK, nil
+		})
+	})
+	d.server.Delete("/instances", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			if strings.ToLower(req.URL.Query().Get("all")) != "true" {
+				return nil, http.StatusBadRequest, errors.New("deleting all instances can only be done with all=true", nil)
+			}
+			providersToUse, err := d.batchProviders(req.URL.Query().Get("provider"))
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			force := strings.ToLower(req.URL.Query().Get("force")) == "true"
+			result, err := d.deleteAllInstances(providersToUse, force)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"succeeded": result.Succeeded, "failed": result.Failed}).Infof("deleting all instances done")
+			return result, http.StatusOK, nil
+		})
+	})
+	d.server.Delete("/instances/:instance_id", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			status, err := d.serveDeleteInstance(params["instance_id"], strings.ToLower(req.URL.Query().Get("force")) == "true")
+			return nil, status, err
+		})
+	})
+	d.server.Get("/instances/:instance_id/logs", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			follow := req.URL.Query().Get("follow")
+			res.Write([]byte("getting logs for " + instanceId + "...\n"))
+			if strings.ToLower(follow) == "true" {
+				provider, err := d.providers.ProviderForInstance(instanceId)
+				if err != nil {
+					return nil, http.StatusInternalServerError, err
+				}
+				if f, ok := res.(http.Flusher); ok {
+					f.Flush()
+				} else {
+					return nil, http.StatusInternalServerError, errors.New("not a flusher", nil)
+				}
+
+				deleteOnDisconnect := req.URL.Query().Get("delete")
+				if strings.ToLower(deleteOnDisconnect) == "true" {
+					logrus.Warnf("INSTANCE %v WILL BE TERMINTED ON CLIENT DISCONNECT!", instanceId)
+					defer provider.DeleteInstance(instanceId, true)
+				}
+
+				output := ioutils.NewWriteFlusher(res)
+				logFn := func() (string, error) {
+					return provider.GetInstanceLogs(instanceId)
+				}
+				if err := streamOutput(logFn, output); err != nil {
+					logrus.WithError(err).WithFields(logrus.Fields{
+						"instanceId": instanceId,
+					}).Warnf("streaming logs stopped")
+					return nil, http.StatusInternalServerError, err
+				}
+				return nil, 0, nil
+			}
+			return d.serveInstanceLogs(instanceId)
+		})
+	})
+	d.server.Post("/instances/run", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			defer req.Body.Close()
+			var runInstanceRequest types.RunInstanceRequest
+			if err := json.Unmarshal(body, &runInstanceRequest); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			return d.serveRunInstance(d.requestUser(req), runInstanceRequest)
+		})
+	})
+	d.server.Post("/instances/:instance_id/clone", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			newName := req.URL.Query().Get("name")
+			if newName == "" {
+				return nil, http.StatusBadRequest, errors.New("must provide a name for the clone in URL query", nil)
+			}
+			providerName := req.URL.Query().Get("provider")
+			logrus.WithFields(logrus.Fields{
+				"source":   instanceId,
+				"name":     newName,
+				"provider": providerName,
+			}).Infof("cloning instance %s", instanceId)
+			sourceProvider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			targetProvider := sourceProvider
+			if providerName != "" {
+				var ok bool
+				targetProvider, ok = d.providers[providerName]
+				if !ok {
+					return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+				}
+			}
+			instance, err := d.cloneInstance(sourceProvider, targetProvider, d.requestUser(req), instanceId, newName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return instance, http.StatusCreated, nil
+		})
+	})
+	d.server.Post("/instances/:instance_id/start", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			status, err := d.serveStartInstance(params["instance_id"])
+			return nil, status, err
+		})
+	})
+	d.server.Post("/instances/stop", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			if strings.ToLower(req.URL.Query().Get("all")) != "true" {
+				return nil, http.StatusBadRequest, errors.New("stopping all instances can only be done with all=true", nil)
+			}
+			providersToUse, err := d.batchProviders(req.URL.Query().Get("provider"))
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			var timeout time.Duration
+			if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+				parsed, err := time.ParseDuration(timeoutStr)
+				if err != nil || parsed < 0 {
+					return nil, http.StatusBadRequest, errors.New("timeout must be a non-negative duration, e.g. 30s", err)
+				}
+				timeout = parsed
+			}
+			result, err := d.stopAllInstances(providersToUse, timeout)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"succeeded": result.Succeeded, "failed": result.Failed}).Infof("stopping all instances done")
+			return result, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/instances/:instance_id/stop", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			var timeout time.Duration
+			if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+				parsed, err := time.ParseDuration(timeoutStr)
+				if err != nil || parsed < 0 {
+					return nil, http.StatusBadRequest, errors.New("timeout must be a non-negative duration, e.g. 30s", err)
+				}
+				timeout = parsed
+			}
+			status, err := d.serveStopInstance(params["instance_id"], timeout)
+			return nil, status, err
+		})
+	})
+	d.server.Post("/instances/:instance_id/restart", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			logrus.WithFields(logrus.Fields{
+				"request": req,
+			}).Infof("restarting instance %s", instanceId)
+			var timeout time.Duration
+			if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+				parsed, err := time.ParseDuration(timeoutStr)
+				if err != nil || parsed < 0 {
+					return nil, http.StatusBadRequest, errors.New("timeout must be a non-negative duration, e.g. 30s", err)
+				}
+				timeout = parsed
+			}
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			instance, err := d.restartInstance(provider, instanceId, timeout)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return instance, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/groups/:group/stop", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			group := params["group"]
+			logrus.WithFields(logrus.Fields{
+				"request": req,
+			}).Infof("stopping group %s", group)
+			var timeout time.Duration
+			if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+				parsed, err := time.ParseDuration(timeoutStr)
+				if err != nil || parsed < 0 {
+					return nil, http.StatusBadRequest, errors.New("timeout must be a non-negative duration, e.g. 30s", err)
+				}
+				timeout = parsed
+			}
+			result, err := d.stopGroup(group, timeout)
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			return result, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/groups/:group/restart", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			group := params["group"]
+			logrus.WithFields(logrus.Fields{
+				"request": req,
+			}).Infof("restarting group %s", group)
+			var timeout time.Duration
+			if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+				parsed, err := time.ParseDuration(timeoutStr)
+				if err != nil || parsed < 0 {
+					return nil, http.StatusBadRequest, errors.New("timeout must be a non-negative duration, e.g. 30s", err)
+				}
+				timeout = parsed
+			}
+			result, err := d.restartGroup(group, timeout)
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			return result, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/groups/:group/scale", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			group := params["group"]
+			to, err := strconv.Atoi(req.URL.Query().Get("to"))
+			if err != nil || to < 0 {
+				return nil, http.StatusBadRequest, errors.New("to must be a non-negative number of instances", err)
+			}
+			logrus.WithFields(logrus.Fields{
+				"request": req, "to": to,
+			}).Infof("scaling group %s", group)
+			var timeout time.Duration
+			if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+				parsed, err := time.ParseDuration(timeoutStr)
+				if err != nil || parsed < 0 {
+					return nil, http.StatusBadRequest, errors.New("timeout must be a non-negative duration, e.g. 30s", err)
+				}
+				timeout = parsed
+			}
+			result, err := d.scaleGroup(group, to, timeout, d.requestUser(req))
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			logrus.WithFields(logrus.Fields{"group": group, "running": result.Running, "started": result.Started, "ran": result.Ran, "stopped": result.Stopped, "failed": result.Failed}).Infof("scaled group")
+			return result, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/instances/:instance_id/suspend", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			snapshotName := req.URL.Query().Get("snapshot_name")
+			logrus.WithFields(logrus.Fields{
+				"request":       req,
+				"snapshot-name": snapshotName,
+			}).Infof("suspending instance %s", instanceId)
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			suspender, ok := provider.(providers.Suspender)
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of instance "+instanceId+" cannot suspend instances", nil)
+			}
+			instance, err := suspender.SuspendInstance(instanceId, snapshotName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("could not suspend instance "+instanceId, err)
+			}
+			d.notify(types.NewInstanceEvent(types.EventType_InstanceSuspended, instance))
+			return instance, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/instances/:instance_id/resume", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			snapshotName := req.URL.Query().Get("snapshot_name")
+			logrus.WithFields(logrus.Fields{
+				"request":       req,
+				"snapshot-name": snapshotName,
+			}).Infof("resuming instance %s", instanceId)
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			suspender, ok := provider.(providers.Suspender)
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of instance "+instanceId+" cannot resume instances", nil)
+			}
+			instance, err := suspender.ResumeInstance(instanceId, snapshotName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("could not resume instance "+instanceId, err)
+			}
+			d.notify(types.NewInstanceEvent(types.EventType_InstanceResumed, instance))
+			return instance, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/instances/:instance_id/migrate", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			instanceId := params["instance_id"]
+			destination := req.URL.Query().Get("destination")
+			if destination == "" {
+				return nil, http.StatusBadRequest, errors.New("must provide the destination to migrate to in URL query", nil)
+			}
+			destinationHost, _, err := net.SplitHostPort(destination)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("destination must be HOST:PORT", err)
+			}
+			// the daemon of the destination host, on the default port unless given
+			destinationDaemon := req.URL.Query().Get("destination_daemon")
+			if destinationDaemon == "" {
+				destinationDaemon = net.JoinHostPort(destinationHost, "3000")
+			}
+			timeout := defaultMigrationTimeout
+			if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+				parsed, err := time.ParseDuration(timeoutStr)
+				if err != nil || parsed <= 0 {
+					return nil, http.StatusBadRequest, errors.New("timeout must be a positive duration, e.g. 10m", err)
+				}
+				timeout = parsed
+			}
+			logrus.WithFields(logrus.Fields{
+				"request":            req,
+				"destination":        destination,
+				"destination-daemon": destinationDaemon,
+			}).Infof("migrating instance %s", instanceId)
+			provider, err := d.providers.ProviderForInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			migrator, ok := provider.(providers.Migrator)
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of instance "+instanceId+" cannot migrate instances", nil)
+			}
+			instance, err := provider.GetInstance(instanceId)
+			if err != nil {
+				return nil, http.StatusNotFound, err
+			}
+			if len(instance.Mounts) > 0 {
+				return nil, http.StatusConflict, errors.New("instance "+instance.Name+" has volumes mounted, which cannot be migrated", nil)
+			}
+			// the restart policy must not start the instance once it has left
+			d.monitor.setStoppedByUser(instance.Id, true)
+			defer d.monitor.setStoppedByUser(instance.Id, false)
+			migrated, err := d.migrateInstance(migrator, instance, destination, destinationDaemon, timeout)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			d.notify(types.NewInstanceEvent(types.EventType_InstanceMigrated, migrated))
+			return migrated, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/instances/incoming", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			defer req.Body.Close()
+			var incomingRequest types.IncomingMigrationRequest
+			if err := json.Unmarshal(body, &incomingRequest); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			if incomingRequest.Port < 1 || incomingRequest.Port > 65535 {
+				return nil, http.StatusBadRequest, errors.New(fmt.Sprintf("invalid port %d to wait for the migration on", incomingRequest.Port), nil)
+			}
+			logrus.WithFields(logrus.Fields{
+				"image": incomingRequest.Params.ImageId,
+				"port":  incomingRequest.Port,
+			}).Infof("accepting migration of instance %s", incomingRequest.Params.Name)
+			// the images of the two hosts are matched by name
+			provider, err := d.providers.ProviderForImage(incomingRequest.Params.ImageId)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("no image named "+incomingRequest.Params.ImageId+" to run the migrated instance from", err)
+			}
+			if _, ok := provider.(providers.Migrator); !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of image "+incomingRequest.Params.ImageId+" cannot accept migrated instances", nil)
+			}
+			instance, err := d.runIncoming(provider, d.requestUser(req), incomingRequest)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			d.notify(types.NewInstanceEvent(types.EventType_InstanceStarted, instance))
+			return instance, http.StatusCreated, nil
+		})
+	})
+
+	//Volumes
+	d.server.Get("/volumes", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			filter, err := parseVolumeFilter(req.URL.Query())
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("invalid volume filter", err)
+			}
+			page, err := parseListPage(req.URL.Query(), volumeSortFields)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("invalid sort", err)
+			}
+			return d.serveListVolumes(page, filter)
+		})
+	})
+	d.server.Get("/volumes/:volume_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			return d.serveGetVolume(params["volume_name"])
+		})
+	})
+	d.server.Get("/volumes/:volume_name/describe", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			volumeName := params["volume_name"]
+			provider, err := d.providers.ProviderForVolume(volumeName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			details, err := d.describeVolume(provider, volumeName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("could not describe volume", err)
+			}
+			return details, http.StatusOK, nil
+		})
+	})
+	d.server.Post("/volumes/:volume_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			var data io.Reader
+			if strings.Contains(req.Header.Get("Content-type"), "multipart/form-data") {
+				err := req.ParseMultipartForm(0)
+				if err != nil {
+					return nil, http.StatusInternalServerError, err
+				}
+				dataTar, _, err := req.FormFile("tarfile")
+				if err != nil {
+					return nil, http.StatusInternalServerError, errors.New("failed to retrieve form-data for tarfe", err)
+				}
+				defer dataTar.Close()
+				data = dataTar
+			} else if err := req.ParseForm(); err != nil {
+				return nil, http.StatusBadRequest, errors.New("parsing form", err)
+			}
+			return d.serveCreateVolume(d.requestUser(req), params["volume_name"], req.Form, data)
+		})
+	})
+	d.server.Post("/volumes/:volume_name/tags", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			volumeName := params["volume_name"]
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
+			}
+			defer req.Body.Close()
+			var tagRequest types.TagRequest
+			if err := json.Unmarshal(body, &tagRequest); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			if err := types.ValidateTags(tagRequest.Tags); err != nil {
+				return nil, http.StatusBadRequest, errors.New("invalid tags", err)
+			}
+			provider, err := d.providers.ProviderForVolume(volumeName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"volume": volumeName, "tags": tagRequest.Tags}).Infof("tagging volume")
+			volume, err := modifyVolumeTags(provider, volumeName, addTags(tagRequest.Tags))
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return volume, http.StatusOK, nil
+		})
+	})
+	d.server.Delete("/volumes/:volume_name/tags/:key", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			volumeName := params["volume_name"]
+			provider, err := d.providers.ProviderForVolume(volumeName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"volume": volumeName, "key": params["key"]}).Infof("untagging volume")
+			volume, err := modifyVolumeTags(provider, volumeName, removeTag(params["key"]))
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return volume, http.StatusOK, nil
 		})
 	})
-
-	//Instances
-	d.server.Get("/instances", func(res http.ResponseWriter, req *http.Request) {
+	d.server.Get("/volumes/:volume_name/backups", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
-			allInstances := []*types.Instance{}
-			for _, provider := range d.providers {
-				instances, err := provider.ListInstances()
-				if err != nil {
-					return nil, http.StatusInternalServerError, errors.New("could not get instance list", err)
+			volumeName := params["volume_name"]
+			// backups outlive their volume, so they are listed by name if it is gone
+			if provider, err := d.providers.ProviderForVolume(volumeName); err == nil {
+				if volume, err := provider.GetVolume(volumeName); err == nil {
+					volumeName = volume.Name
 				}
-				allInstances = append(allInstances, instances...)
 			}
-			logrus.WithFields(logrus.Fields{
-				"instances": allInstances,
-			}).Debugf("Listing all instances")
-			return allInstances, http.StatusOK, nil
+			return d.backups.list(volumeName), http.StatusOK, nil
 		})
 	})
-	d.server.Get("/instances/:instance_id", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Post("/volumes/:volume_name/backups/schedules", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
-			instanceId := params["instance_id"]
-			provider, err := d.providers.ProviderForInstance(instanceId)
+			volumeName := params["volume_name"]
+			body, err := ioutil.ReadAll(req.Body)
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
 			}
-			instance, err := provider.GetInstance(instanceId)
+			defer req.Body.Close()
+			var scheduleRequest types.CreateBackupScheduleRequest
+			if err := json.Unmarshal(body, &scheduleRequest); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			provider, err := d.providers.ProviderForVolume(volumeName)
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusNotFound, err
 			}
-			return instance, http.StatusOK, nil
-		})
-	})
-	d.server.Delete("/instances/:instance_id", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
-		handle(res, func() (interface{}, int, error) {
-			instanceId := params["instance_id"]
-			logrus.WithFields(logrus.Fields{
-				"request": req,
-			}).Infof("deleting instance " + instanceId)
-			provider, err := d.providers.ProviderForInstance(instanceId)
+			volume, err := provider.GetVolume(volumeName)
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusNotFound, errors.New("could not get volume", err)
 			}
-			forceStr := req.URL.Query().Get("force")
-			force := false
-			if strings.ToLower(forceStr) == "true" {
-				force = true
+			if _, ok := provider.(providers.LocalVolumeProvider); !ok {
+				return nil, http.StatusBadRequest, errors.New("volume "+volume.Name+" is not stored on the daemon host and cannot be backed up", nil)
 			}
-			err = provider.DeleteInstance(instanceId, force)
+			schedule, err := d.backups.createSchedule(volume.Name, scheduleRequest.CronExpression, scheduleRequest.Destination, scheduleRequest.Retention)
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusBadRequest, err
 			}
-			return nil, http.StatusNoContent, nil
+			logrus.WithField("schedule", schedule).Infof("scheduled backups of volume %s", volume.Name)
+			return schedule, http.StatusCreated, nil
 		})
 	})
-	d.server.Get("/instances/:instance_id/logs", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Get("/backups", func(res http.ResponseWriter, req *http.Request) {
 		handle(res, func() (interface{}, int, error) {
-			instanceId := params["instance_id"]
-			follow := req.URL.Query().Get("follow")
-			res.Write([]byte("getting logs for " + instanceId + "...\n"))
-			provider, err := d.providers.ProviderForInstance(instanceId)
-			if err != nil {
-				return nil, http.StatusInternalServerError, err
-			}
-			if strings.ToLower(follow) == "true" {
-				if f, ok := res.(http.Flusher); ok {
-					f.Flush()
-				} else {
-					return nil, http.StatusInternalServerError, errors.New("not a flusher", nil)
-				}
-
-				deleteOnDisconnect := req.URL.Query().Get("delete")
-				if strings.ToLower(deleteOnDisconnect) == "true" {
-					logrus.Warnf("INSTANCE %v WILL BE TERMINTED ON CLIENT DISCONNECT!", instanceId)
-					defer provider.DeleteInstance(instanceId, true)
-				}
-
-				output := ioutils.NewWriteFlusher(res)
-				logFn := func() (string, error) {
-					return provider.GetInstanceLogs(instanceId)
-				}
-				if err := streamOutput(logFn, output); err != nil {
-					logrus.WithError(err).WithFields(logrus.Fields{
-						"instanceId": instanceId,
-					}).Warnf("streaming logs stopped")
-					return nil, http.StatusInternalServerError, err
-				}
-				return nil, 0, nil
-			}
-			logs, err := provider.GetInstanceLogs(instanceId)
-			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("failed to perform get logs request", err)
+			return d.backups.list(""), http.StatusOK, nil
+		})
+	})
+	d.server.Delete("/backups/schedules/:schedule_id", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			scheduleId := params["schedule_id"]
+			if err := d.backups.deleteSchedule(scheduleId); err != nil {
+				return nil, http.StatusNotFound, err
 			}
-			return logs, http.StatusOK, nil
+			logrus.Infof("deleted backup schedule %s", scheduleId)
+			return nil, http.StatusNoContent, nil
 		})
 	})
-	d.server.Post("/instances/run", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Patch("/volumes/:volume_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
+			volumeName := params["volume_name"]
 			body, err := ioutil.ReadAll(req.Body)
 			if err != nil {
 				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
 			}
 			defer req.Body.Close()
-			var runInstanceRequest RunInstanceRequest
-			if err := json.Unmarshal(body, &runInstanceRequest); err != nil {
+			var renameRequest types.RenameVolumeRequest
+			if err := json.Unmarshal(body, &renameRequest); err != nil {
 				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
 			}
-
-			logrus.WithFields(logrus.Fields{
-				"request": runInstanceRequest,
-			}).Debugf("recieved run request")
-
-			if runInstanceRequest.ImageName == "" {
-				return nil, http.StatusBadRequest, errors.New("image must be named", nil)
+			if renameRequest.Name == "" {
+				return nil, http.StatusBadRequest, errors.New("must give the new Name of the volume", nil)
 			}
-
-			provider, err := d.providers.ProviderForImage(runInstanceRequest.ImageName)
+			provider, err := d.providers.ProviderForVolume(volumeName)
 			if err != nil {
-				return nil, http.StatusBadRequest, err
+				return nil, http.StatusNotFound, err
 			}
-
-			params := types.RunInstanceParams{
-				Name:                 runInstanceRequest.InstanceName,
-				ImageId:              runInstanceRequest.ImageName,
-				MntPointsToVolumeIds: runInstanceRequest.Mounts,
-				Env:                  runInstanceRequest.Env,
-				InstanceMemory:       runInstanceRequest.MemoryMb,
-				NoCleanup:            runInstanceRequest.NoCleanup,
-				DebugMode:            runInstanceRequest.DebugMode,
+			volume, err := provider.GetVolume(volumeName)
+			if err != nil {
+				return nil, http.StatusNotFound, err
 			}
-
-			instance, err := provider.RunInstance(params)
+			if volume.Attachment != "" {
+				// some providers attach volumes by the path of their files, which has the name in it
+				return nil, http.StatusConflict, errors.New("volume "+volume.Name+" is attached to instance "+volume.Attachment+", detach it before renaming it", nil)
+			}
+			taken, err := d.volumeNameTaken(renameRequest.Name)
 			if err != nil {
 				return nil, http.StatusInternalServerError, err
 			}
-			return instance, http.StatusCreated, nil
+			if taken {
+				return nil, http.StatusConflict, errors.New("a volume is already named "+renameRequest.Name, nil)
+			}
+			if _, ok := provider.(providers.VolumeRenamer); !ok {
+				return nil, http.StatusBadRequest, errors.New("the provider of volume "+volume.Name+" cannot rename volumes", nil)
+			}
+			renamed, err := d.renameVolume(provider, volume, renameRequest.Name)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			logrus.WithFields(logrus.Fields{"volume": volume.Name, "name": renamed.Name}).Infof("volume renamed")
+			return renamed, http.StatusOK, nil
 		})
 	})
-	d.server.Post("/instances/:instance_id/start", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Delete("/volumes", func(res http.ResponseWriter, req *http.Request) {
 		handle(res, func() (interface{}, int, error) {
-			instanceId := params["instance_id"]
-			logrus.WithFields(logrus.Fields{
-				"request": req,
-			}).Infof("starting instance " + instanceId)
-			provider, err := d.providers.ProviderForInstance(instanceId)
+			if strings.ToLower(req.URL.Query().Get("all")) != "true" {
+				return nil, http.StatusBadRequest, errors.New("deleting all volumes can only be done with all=true", nil)
+			}
+			providersToUse, err := d.batchProviders(req.URL.Query().Get("provider"))
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusBadRequest, err
 			}
-			err = provider.StartInstance(instanceId)
+			force := strings.ToLower(req.URL.Query().Get("force")) == "true"
+			result, err := d.deleteAllVolumes(providersToUse, force)
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("could not start instance "+instanceId, err)
+				return nil, http.StatusInternalServerError, err
 			}
-			return nil, http.StatusOK, nil
+			logrus.WithFields(logrus.Fields{"succeeded": result.Succeeded, "failed": result.Failed}).Infof("deleting all volumes done")
+			return result, http.StatusOK, nil
 		})
 	})
-	d.server.Post("/instances/:instance_id/stop", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Delete("/volumes/:volume_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
-			instanceId := params["instance_id"]
-			logrus.WithFields(logrus.Fields{
-				"request": req,
-			}).Infof("stopping instance " + instanceId)
-			provider, err := d.providers.ProviderForInstance(instanceId)
-			if err != nil {
-				return nil, http.StatusInternalServerError, err
-			}
-			err = provider.StopInstance(instanceId)
+			status, err := d.serveDeleteVolume(params["volume_name"], strings.ToLower(req.URL.Query().Get("force")) == "true")
+			return nil, status, err
+		})
+	})
+	d.server.Post("/volumes/:volume_name/attach/:instance_id", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			volumeName := params["volume_name"]
+			status, err := d.serveAttachVolume(volumeName, params["instance_id"], req.URL.Query().Get("mount"))
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("could not stop instance "+instanceId, err)
+				return nil, status, err
 			}
-			return nil, http.StatusOK, nil
+			return volumeName, status, nil
 		})
 	})
-
-	//Volumes
-	d.server.Get("/volumes", func(res http.ResponseWriter, req *http.Request) {
+	d.server.Post("/volumes/:volume_name/detach", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
-			logrus.Debugf("listing volumes started")
-			allVolumes := []*types.Volume{}
-			for _, provider := range d.providers {
-				volumes, err := provider.ListVolumes()
-				if err != nil {
-					return nil, http.StatusInternalServerError, errors.New("could not retrieve volumes", err)
-				}
-				allVolumes = append(allVolumes, volumes...)
+			volumeName := params["volume_name"]
+			status, err := d.serveDetachVolume(volumeName)
+			if err != nil {
+				return nil, status, err
 			}
-			logrus.WithFields(logrus.Fields{
-				"volumes": allVolumes,
-			}).Infof("volumes")
-			return allVolumes, http.StatusOK, nil
+			return volumeName, status, nil
 		})
 	})
-	d.server.Get("/volumes/:volume_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Post("/volumes/:volume_name/migrate", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
 			volumeName := params["volume_name"]
-			provider, err := d.providers.ProviderForVolume(volumeName)
-			if err != nil {
-				return nil, http.StatusInternalServerError, err
+			targetProviderName := req.URL.Query().Get("provider")
+			targetProvider, ok := d.providers[targetProviderName]
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New(targetProviderName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
 			}
-			volume, err := provider.GetVolume(volumeName)
+			sourceProvider, err := d.providers.ProviderForVolume(volumeName)
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("could not get volume", err)
+				return nil, http.StatusBadRequest, err
+			}
+			var sourceProviderName string
+			for name, provider := range d.providers {
+				if provider == sourceProvider {
+					sourceProviderName = name
+				}
+			}
+			if sourceProviderName == targetProviderName {
+				return nil, http.StatusBadRequest, errors.New("volume "+volumeName+" is already on provider "+targetProviderName, nil)
 			}
 			logrus.WithFields(logrus.Fields{
-				"volume": volume,
-			}).Infof("volume retrieved")
-			return volume, http.StatusOK, nil
+				"volume": volumeName,
+				"from":   sourceProviderName,
+				"to":     targetProviderName,
+			}).Infof("migrating volume %s", volumeName)
+			migration, err := d.migrateVolume(sourceProvider, sourceProviderName, targetProvider, targetProviderName, d.requestUser(req), volumeName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return migration, http.StatusCreated, nil
 		})
 	})
-	d.server.Post("/volumes/:volume_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Post("/volumes/:volume_name/clone", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
 			volumeName := params["volume_name"]
-			var imagePath string
-			var provider providers.Provider
-			var noCleanup bool
-			var raw bool
-
-			logrus.WithField("req", req).Info("received request to create volume")
-
-			typeStr := req.FormValue("type")
-			typeStr = strings.ToLower(typeStr)
-
-			if strings.Contains(req.Header.Get("Content-type"), "multipart/form-data") {
-
-				if strings.ToLower(req.FormValue("raw")) == "true" {
-					raw = true
-				}
-				if strings.ToLower(req.FormValue("no_cleanup")) == "true" {
-					noCleanup = true
-				}
-
-				logrus.Info("received request with form-data")
-				err := req.ParseMultipartForm(0)
-				if err != nil {
-					return nil, http.StatusInternalServerError, err
-				}
-				logrus.WithFields(logrus.Fields{
-					"req": req,
-				}).Debugf("parsing multipart form")
-
-				providerName := req.FormValue("provider")
-				if _, ok := d.providers[providerName]; !ok {
-					return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
-				}
-				provider = d.providers[providerName]
-				dataTar, header, err := req.FormFile("tarfile")
-				if err != nil {
-					return nil, http.StatusInternalServerError, errors.New("failed to retrieve form-data for tarfe", err)
-				}
-				defer dataTar.Close()
-
-				if !raw {
-					logrus.WithFields(logrus.Fields{
-						"form": req.Form,
-					}).Debugf("seeking form file marked 'tarfile'")
-					logrus.WithFields(logrus.Fields{
-						"tarred-data": header.Filename,
-						"name":        volumeName,
-						"provider":    providerName,
-					}).Debugf("creating volume started")
-
-					sizeStr := req.URL.Query().Get("size")
-					if sizeStr == "" {
-						sizeStr = "0"
-					}
-					size, err := strconv.Atoi(sizeStr)
-					if err != nil {
-						return nil, http.StatusBadRequest, errors.New("could not parse given size", err)
-					}
-					imagePath, err = util.BuildRawDataImageWithType(dataTar, unikos.MegaBytes(size), typeStr, provider.GetConfig().UsePartitionTables)
-					if err != nil {
-						return nil, http.StatusInternalServerError, errors.New("creating raw volume image", err)
-					}
-				} else {
-					imagePathFile, err := ioutil.TempFile("", "")
-					if err != nil {
-						return nil, http.StatusInternalServerError, errors.New("creating temp file for volume image", err)
-					}
-					_, err = io.Copy(imagePathFile, dataTar)
-					imagePathFile.Close()
-					if err != nil {
-						return nil, http.StatusInternalServerError, errors.New("creating temp file for volume image", err)
-					}
-					imagePath = imagePathFile.Name()
-				}
-
-			} else {
-				if strings.ToLower(req.URL.Query().Get("raw")) == "true" {
-					raw = true
-				}
-				if strings.ToLower(req.URL.Query().Get("no_cleanup")) == "true" {
-					noCleanup = true
-				}
-
-				if raw == true {
-					return nil, http.StatusBadRequest, errors.New("Raw volume was requested but no data provided", nil)
-				}
-				logrus.Info("received request for empty volume")
-				sizeStr := req.URL.Query().Get("size")
-				size, err := strconv.Atoi(sizeStr)
-				if err != nil {
-					return nil, http.StatusBadRequest, errors.New("could not parse given size", err)
-				}
-				logrus.WithFields(logrus.Fields{
-					"size": size,
-					"name": volumeName,
-				}).Debugf("creating empty volume started")
-				imagePath, err = util.BuildEmptyDataVolumeWithType(unikos.MegaBytes(size), typeStr)
-				if err != nil {
-					return nil, http.StatusInternalServerError, errors.New("failed building raw image", err)
-				}
-				providerName := req.URL.Query().Get("provider")
-				if _, ok := d.providers[providerName]; !ok {
-					return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+			newName := req.URL.Query().Get("name")
+			if newName == "" {
+				return nil, http.StatusBadRequest, errors.New("must provide the name of the new volume in URL query", nil)
+			}
+			sourceProvider, err := d.providers.ProviderForVolume(volumeName)
+			if err != nil {
+				return nil, http.StatusNotFound, err
+			}
+			var sourceProviderName string
+			for name, provider := range d.providers {
+				if provider == sourceProvider {
+					sourceProviderName = name
 				}
-				provider = d.providers[providerName]
-				logrus.WithFields(logrus.Fields{
-					"image": imagePath,
-				}).Infof("raw image created")
-
 			}
-
-			if !noCleanup {
-				defer os.RemoveAll(imagePath)
+			// the clone stays on the provider of the volume unless told otherwise
+			targetProviderName := req.URL.Query().Get("provider")
+			if targetProviderName == "" {
+				targetProviderName = sourceProviderName
 			}
-
-			params := types.CreateVolumeParams{
-				Name:      volumeName,
-				ImagePath: imagePath,
-				NoCleanup: noCleanup,
+			targetProvider, ok := d.providers[targetProviderName]
+			if !ok {
+				return nil, http.StatusBadRequest, errors.New(targetProviderName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
 			}
-
-			volume, err := provider.CreateVolume(params)
+			volume, err := sourceProvider.GetVolume(volumeName)
+			if err != nil {
+				return nil, http.StatusNotFound, err
+			}
+			taken, err := d.volumeNameTaken(newName)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			if taken {
+				return nil, http.StatusConflict, errors.New("a volume named "+newName+" already exists", nil)
+			}
+			clone, err := d.cloneVolume(sourceProvider, sourceProviderName, targetProvider, targetProviderName, d.requestUser(req), volume, newName)
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("could not create volume", err)
+				return nil, http.StatusInternalServerError, err
 			}
 			logrus.WithFields(logrus.Fields{
-				"volume": volume,
-			}).Infof("volume created")
-			return volume, http.StatusCreated, nil
+				"volume": volume.Name,
+				"clone":  clone,
+			}).Infof("volume cloned")
+			return clone, http.StatusCreated, nil
 		})
 	})
-	d.server.Delete("/volumes/:volume_name", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Post("/volumes/:volume_name/trim", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
 			volumeName := params["volume_name"]
+			compact := strings.ToLower(req.URL.Query().Get("compact")) == "true"
 			provider, err := d.providers.ProviderForVolume(volumeName)
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusNotFound, err
 			}
-			forceStr := req.URL.Query().Get("force")
-			force := false
-			if strings.ToLower(forceStr) == "true" {
-				force = true
+			volume, err := provider.GetVolume(volumeName)
+			if err != nil {
+				return nil, http.StatusNotFound, err
 			}
-
-			logrus.WithFields(logrus.Fields{
-				"force": force, "name": volumeName,
-			}).Debugf("deleting volume started")
-			err = provider.DeleteVolume(volumeName, force)
+			if volume.Attachment != "" {
+				return nil, http.StatusConflict, errors.New("volume "+volume.Name+" is attached to instance "+volume.Attachment+", detach it before trimming", nil)
+			}
+			logrus.WithFields(logrus.Fields{"volume": volume.Name, "compact": compact}).Infof("trimming volume")
+			result, err := d.trimVolume(provider, volume, compact)
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("could not delete volume", err)
+				return nil, http.StatusInternalServerError, err
 			}
-			logrus.WithFields(logrus.Fields{
-				"volume": volumeName,
-			}).Infof("volume deleted")
-			return nil, http.StatusNoContent, nil
+			logrus.WithFields(logrus.Fields{"volume": volume.Name, "trimmed": result.TrimmedBytes, "reclaimed": result.ReclaimedBytes}).Infof("volume trimmed")
+			return result, http.StatusOK, nil
 		})
 	})
-	d.server.Post("/volumes/:volume_name/attach/:instance_id", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+	d.server.Post("/volumes/:volume_name/defrag", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
 		handle(res, func() (interface{}, int, error) {
 			volumeName := params["volume_name"]
+			threshold := defaultDefragThreshold
+			if thresholdStr := req.URL.Query().Get("threshold"); thresholdStr != "" {
+				var err error
+				threshold, err = strconv.Atoi(thresholdStr)
+				if err != nil || threshold < 0 {
+					return nil, http.StatusBadRequest, errors.New("threshold must be a non-negative fragmentation score", err)
+				}
+			}
 			provider, err := d.providers.ProviderForVolume(volumeName)
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusNotFound, err
 			}
-			instanceId := params["instance_id"]
-			mount := req.URL.Query().Get("mount")
-			if mount == "" {
-				return nil, http.StatusBadRequest, errors.New("must provide a mount point in URL query", nil)
+			volume, err := provider.GetVolume(volumeName)
+			if err != nil {
+				return nil, http.StatusNotFound, err
 			}
-			logrus.WithFields(logrus.Fields{
-				"instance": instanceId,
-				"volume":   volumeName,
-				"mount":    mount,
-			}).Debugf("attaching volume to instance")
-			err = provider.AttachVolume(volumeName, instanceId, mount)
+			if volume.Attachment != "" {
+				return nil, http.StatusConflict, errors.New("volume "+volume.Name+" is attached to instance "+volume.Attachment+", detach it before defragmenting", nil)
+			}
+			logrus.WithFields(logrus.Fields{"volume": volume.Name, "threshold": threshold}).Infof("defragmenting volume")
+			result, err := d.defragVolume(provider, volume, threshold)
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("could not attach volume to instance", err)
+				return nil, http.StatusInternalServerError, err
 			}
-			logrus.WithFields(logrus.Fields{
-				"instance": instanceId,
-				"volume":   volumeName,
-				"mount":    mount,
-			}).Infof("volume attached")
-			return volumeName, http.StatusAccepted, nil
+			logrus.WithFields(logrus.Fields{"volume": volume.Name, "before": result.ScoreBefore, "after": result.ScoreAfter, "defragmented": result.Defragmented}).Infof("volume defragmented")
+			return result, http.StatusOK, nil
 		})
 	})
-	d.server.Post("/volumes/:volume_name/detach", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+
+	//events
+	d.server.Get("/events", d.streamEvents)
+
+	//webhooks
+	d.server.Get("/webhooks", func(res http.ResponseWriter, req *http.Request) {
 		handle(res, func() (interface{}, int, error) {
-			volumeName := params["volume_name"]
-			provider, err := d.providers.ProviderForVolume(volumeName)
+			return d.webhooks.list(), http.StatusOK, nil
+		})
+	})
+	d.server.Post("/webhooks", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			body, err := ioutil.ReadAll(req.Body)
 			if err != nil {
-				return nil, http.StatusInternalServerError, err
+				return nil, http.StatusBadRequest, errors.New("could not read request body", err)
 			}
-			logrus.WithFields(logrus.Fields{
-				"volume": volumeName,
-			}).Debugf("detaching volume from any instance")
-			err = provider.DetachVolume(volumeName)
+			defer req.Body.Close()
+			var createWebhookRequest types.CreateWebhookRequest
+			if err := json.Unmarshal(body, &createWebhookRequest); err != nil {
+				return nil, http.StatusBadRequest, errors.New("failed to parse request json", err)
+			}
+			webhook, err := d.webhooks.create(createWebhookRequest.URL, createWebhookRequest.Secret, createWebhookRequest.Events)
 			if err != nil {
-				return nil, http.StatusInternalServerError, errors.New("could not detach volume from instance", err)
+				return nil, http.StatusBadRequest, errors.New("could not create webhook", err)
 			}
-			logrus.WithFields(logrus.Fields{
-				"volume": volumeName,
-			}).Infof("volume detached")
-			return volumeName, http.StatusAccepted, nil
+			logrus.WithField("webhook", webhook).Infof("webhook created")
+			return webhook, http.StatusCreated, nil
+		})
+	})
+	d.server.Delete("/webhooks/:webhook_id", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			webhookId := params["webhook_id"]
+			if err := d.webhooks.remove(webhookId); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			logrus.WithField("webhook", webhookId).Infof("webhook deleted")
+			return nil, http.StatusNoContent, nil
 		})
 	})
 
@@ -1139,6 +2039,20 @@ func (d *UnikDaemon) initialize() {
 			return []string(availableCompilers), http.StatusOK, nil
 		})
 	})
+	d.server.Get("/platforms", func(res http.ResponseWriter, req *http.Request) {
+		handle(res, func() (interface{}, int, error) {
+			return d.availablePlatforms(), http.StatusOK, nil
+		})
+	})
+	d.server.Get("/platforms/:platform", func(res http.ResponseWriter, req *http.Request, params martini.Params) {
+		handle(res, func() (interface{}, int, error) {
+			providerName, err := d.ResolvePlatformAlias(params["platform"])
+			if err != nil {
+				return nil, http.StatusNotFound, err
+			}
+			return &types.PlatformAlias{Platform: params["platform"], Provider: providerName}, http.StatusOK, nil
+		})
+	})
 	d.server.Get("/available_providers", func(res http.ResponseWriter, req *http.Request) {
 		handle(res, func() (interface{}, int, error) {
 			logrus.Debugf("listing available providers")
@@ -1247,6 +2161,8 @@ func streamOutput(outputFunc func() (string, error), w io.Writer) (err error) {
 
 func respond(res http.ResponseWriter, message interface{}) error {
 	switch message.(type) {
+	case *types.QuotaExceeded:
+		// marshalled as json below, so clients can tell which quota was hit
 	case string:
 		messageString := message.(string)
 		data := []byte(messageString)
@@ -0,0 +1,12 @@
+package daemon
+
+import "syscall"
+
+// freeDiskBytes returns the space available to unprivileged users on the filesystem of dir
+func freeDiskBytes(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
@@ -0,0 +1,9 @@
+// +build !linux
+
+package daemon
+
+import "github.com/emc-advanced-dev/pkg/errors"
+
+func freeDiskBytes(dir string) (int64, error) {
+	return 0, errors.New("checking free disk space is only supported on linux", nil)
+}
@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// how often an idle /events stream sends a comment line, so dead connections get noticed
+const eventStreamKeepAlive = 30 * time.Second
+
+func (d *UnikDaemon) notify(event types.Event) {
+	logrus.WithFields(logrus.Fields{"event": event.Type, "id": event.Id}).Debugf("publishing event")
+	d.bus.publish(event)
+}
+
+func (d *UnikDaemon) notifyInstance(eventType types.EventType, provider providers.Provider, instanceId string) {
+	instance, err := provider.GetInstance(instanceId)
+	if err != nil {
+		logrus.WithError(err).Debugf("could not look up instance %s for %s event", instanceId, eventType)
+		instance = &types.Instance{Id: instanceId}
+	}
+	d.notify(types.NewInstanceEvent(eventType, instance))
+}
+
+func (d *UnikDaemon) notifyVolume(eventType types.EventType, provider providers.Provider, volumeId string) {
+	volume, err := provider.GetVolume(volumeId)
+	if err != nil {
+		logrus.WithError(err).Debugf("could not look up volume %s for %s event", volumeId, eventType)
+		volume = &types.Volume{Id: volumeId}
+	}
+	d.notify(types.NewVolumeEvent(eventType, volume))
+}
+
+// streamEvents serves GET /events as a server-sent events stream. every event
+// is sent as a single line of json until the client disconnects.
+// with ?follow=false the recent event history is returned as a json list instead.
+func (d *UnikDaemon) streamEvents(res http.ResponseWriter, req *http.Request) {
+	if strings.ToLower(req.URL.Query().Get("follow")) == "false" {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+		if err := respond(res, d.bus.recent()); err != nil {
+			logrus.WithError(err).Errorf("failed to reply to http request")
+		}
+		return
+	}
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		res.WriteHeader(http.StatusInternalServerError)
+		res.Write([]byte("streaming is not supported"))
+		return
+	}
+	var closed <-chan bool
+	if notifier, ok := res.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	events, unsubscribe := d.bus.subscribe()
+	defer unsubscribe()
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	logrus.WithField("remote", req.RemoteAddr).Infof("client subscribed to events")
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-closed:
+			logrus.WithField("remote", req.RemoteAddr).Infof("client unsubscribed from events")
+			return
+		case <-keepAlive.C:
+			if _, err := res.Write([]byte(": keep-alive\n\n")); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				logrus.WithError(err).Errorf("failed to marshal %s event", event.Type)
+				continue
+			}
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				logrus.WithError(err).Debugf("writing event to client failed")
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const (
+	instanceReaperInterval            = time.Minute
+	defaultExpiredInstanceGracePeriod = 10 * time.Minute
+)
+
+// instanceReaper deletes instances whose ttl has run out. Deleted instances
+// are kept in the state of their provider (with state "expired") for a grace
+// period so that users can still see what happened to them in the instance
+// list, also after the daemon restarts.
+type instanceReaper struct {
+	providers   providers.Providers
+	gracePeriod time.Duration
+	notify      func(types.Event)
+	done        chan struct{}
+	stopOnce    sync.Once
+}
+
+func newInstanceReaper(providers providers.Providers, gracePeriod time.Duration, notify func(types.Event)) *instanceReaper {
+	return &instanceReaper{
+		providers:   providers,
+		gracePeriod: gracePeriod,
+		notify:      notify,
+		done:        make(chan struct{}),
+	}
+}
+
+func (r *instanceReaper) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.reap()
+		}
+	}
+}
+
+func (r *instanceReaper) stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+// expiredInstances returns the instances of provider that were deleted within the grace period
+func (r *instanceReaper) expiredInstances(provider providers.Provider) []*types.Instance {
+	instances := []*types.Instance{}
+	for _, instance := range provider.GetState().GetExpiredInstances() {
+		if time.Since(instance.ExpiresAt) <= r.gracePeriod {
+			instances = append(instances, instance)
+		}
+	}
+	return instances
+}
+
+func (r *instanceReaper) reap() {
+	now := time.Now()
+
+	for providerName, provider := range r.providers {
+		if err := provider.GetState().ModifyExpiredInstances(func(instances map[string]*types.Instance) error {
+			for id, instance := range instances {
+				if now.Sub(instance.ExpiresAt) > r.gracePeriod {
+					logrus.WithField("instance", id).Debugf("purging expired instance %s", instance.Name)
+					delete(instances, id)
+				}
+			}
+			return nil
+		}); err != nil {
+			logrus.WithError(err).Warnf("failed to purge expired instances of provider %s", providerName)
+		}
+
+		for _, instance := range provider.GetState().GetInstances() {
+			if instance.ExpiresAt.IsZero() || instance.ExpiresAt.After(now) {
+				continue
+			}
+			logrus.WithFields(logrus.Fields{
+				"event":     "instance_expired",
+				"instance":  instance.Id,
+				"name":      instance.Name,
+				"provider":  providerName,
+				"expiresAt": instance.ExpiresAt,
+			}).Infof("ttl for instance %s has run out, deleting it", instance.Name)
+			r.notify(types.NewInstanceEvent(types.EventType_InstanceExpired, instance))
+			if err := provider.DeleteInstance(instance.Id, true); err != nil {
+				logrus.WithError(err).Warnf("failed to delete expired instance %s", instance.Id)
+				continue
+			}
+			expired := *instance
+			expired.State = types.InstanceState_Expired
+			expired.IpAddress = ""
+			if err := provider.GetState().ModifyExpiredInstances(func(instances map[string]*types.Instance) error {
+				instances[expired.Id] = &expired
+				return nil
+			}); err != nil {
+				logrus.WithError(err).Warnf("failed to record expired instance %s", instance.Id)
+			}
+		}
+	}
+}
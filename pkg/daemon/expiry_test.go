@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("instanceReaper", func() {
+	var (
+		dir         string
+		first, next *fakeProvider
+		reaper      *instanceReaper
+		events      []types.Event
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.expiry.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(dir, "first"), 0755)).To(Succeed())
+		Expect(os.Mkdir(filepath.Join(dir, "next"), 0755)).To(Succeed())
+		first = newFakeProvider(filepath.Join(dir, "first"))
+		next = newFakeProvider(filepath.Join(dir, "next"))
+		events = nil
+		reaper = newInstanceReaper(providers.Providers{"first": first, "next": next}, time.Hour, func(event types.Event) {
+			events = append(events, event)
+		})
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	addInstance := func(provider *fakeProvider, id string, expiresAt time.Time) {
+		Expect(provider.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+			instances[id] = &types.Instance{Id: id, Name: id, State: types.InstanceState_Running, IpAddress: "10.0.0.1", ExpiresAt: expiresAt}
+			return nil
+		})).To(Succeed())
+	}
+
+	It("should delete instances whose ttl has run out and list them as expired", func() {
+		addInstance(first, "expired", time.Now().Add(-time.Minute))
+		addInstance(first, "alive", time.Now().Add(time.Hour))
+		reaper.reap()
+
+		Expect(first.State.GetInstances()).To(HaveLen(1))
+		Expect(first.State.GetInstances()).To(HaveKey("alive"))
+		expired := reaper.expiredInstances(first)
+		Expect(expired).To(HaveLen(1))
+		Expect(expired[0].Id).To(Equal("expired"))
+		Expect(expired[0].State).To(Equal(types.InstanceState_Expired))
+		Expect(expired[0].IpAddress).To(BeEmpty())
+
+		// the instance_expired event has the instance as it was
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Type).To(Equal(types.EventType_InstanceExpired))
+		Expect(events[0].Payload.(*types.Instance).State).To(Equal(types.InstanceState_Running))
+	})
+
+	It("should keep expired instances in the state saved for the provider", func() {
+		addInstance(first, "expired", time.Now().Add(-time.Minute))
+		reaper.reap()
+
+		reloaded, err := state.BasicStateFromFile(filepath.Join(dir, "first", "state.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reloaded.GetExpiredInstances()).To(HaveKey("expired"))
+		Expect(reloaded.GetInstances()).To(BeEmpty())
+	})
+
+	It("should only list the expired instances of the provider asked for", func() {
+		addInstance(first, "expired", time.Now().Add(-time.Minute))
+		reaper.reap()
+		Expect(reaper.expiredInstances(first)).To(HaveLen(1))
+		Expect(reaper.expiredInstances(next)).To(BeEmpty())
+	})
+
+	It("should purge expired instances after the grace period", func() {
+		addInstance(first, "expired", time.Now().Add(-2*time.Hour))
+		reaper.reap()
+		Expect(reaper.expiredInstances(first)).To(BeEmpty())
+		reaper.reap()
+		Expect(first.State.GetExpiredInstances()).To(BeEmpty())
+	})
+})
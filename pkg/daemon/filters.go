@@ -0,0 +1,174 @@
+package daemon
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+type volumeFilter struct {
+	provider     string
+	attached     bool
+	unattached   bool
+	nameContains string
+	tags         map[string]string
+	// sizeGt and sizeLt are in bytes. 0 means no bound
+	sizeGt int64
+	sizeLt int64
+}
+
+func parseVolumeFilter(query url.Values) (volumeFilter, error) {
+	filter := volumeFilter{
+		provider:     query.Get("provider"),
+		attached:     strings.ToLower(query.Get("attached")) == "true",
+		unattached:   strings.ToLower(query.Get("unattached")) == "true",
+		nameContains: query.Get("name_contains"),
+	}
+	tags, err := tagFilter(query)
+	if err != nil {
+		return filter, err
+	}
+	filter.tags = tags
+	if filter.attached && filter.unattached {
+		return filter, errors.New("attached and unattached filters are mutually exclusive", nil)
+	}
+	if filter.sizeGt, err = parseSizeParam(query, "size_gt"); err != nil {
+		return filter, err
+	}
+	if filter.sizeLt, err = parseSizeParam(query, "size_lt"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseSizeParam(query url.Values, name string) (int64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, errors.New(name+" must be a non-negative number of bytes", err)
+	}
+	return size, nil
+}
+
+func (f volumeFilter) matches(volume *types.Volume) bool {
+	if f.attached && volume.Attachment == "" {
+		return false
+	}
+	if f.unattached && volume.Attachment != "" {
+		return false
+	}
+	if f.nameContains != "" && !strings.Contains(volume.Name, f.nameContains) {
+		return false
+	}
+	if !types.HasTags(volume.Tags, f.tags) {
+		return false
+	}
+	size := volume.SizeMb << 20
+	if f.sizeGt > 0 && size <= f.sizeGt {
+		return false
+	}
+	if f.sizeLt > 0 && size >= f.sizeLt {
+		return false
+	}
+	return true
+}
+
+// instanceFilter selects the instances listed by GET /instances. zero fields select all instances.
+type instanceFilter struct {
+	tags     map[string]string
+	provider string
+	// image is the name or id of the image of the instances. imageIds holds the
+	// ids it stands for, see resolveImage
+	image         string
+	imageIds      map[string]bool
+	states        map[types.InstanceState]bool
+	createdAfter  time.Time
+	createdBefore time.Time
+	group         string
+}
+
+func parseInstanceFilter(query url.Values) (instanceFilter, error) {
+	filter := instanceFilter{
+		provider: query.Get("provider"),
+		image:    query.Get("image"),
+		group:    query.Get("group"),
+	}
+	tags, err := tagFilter(query)
+	if err != nil {
+		return filter, err
+	}
+	filter.tags = tags
+	if states := query.Get("state"); states != "" {
+		filter.states = make(map[types.InstanceState]bool)
+		for _, state := range strings.Split(states, ",") {
+			filter.states[types.InstanceState(strings.ToLower(state))] = true
+		}
+	}
+	if filter.createdAfter, err = parseTimeParam(query, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.createdBefore, err = parseTimeParam(query, "created_before"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseTimeParam(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New(name+" must be an RFC 3339 time, e.g. 2017-03-01T12:00:00Z", err)
+	}
+	return t, nil
+}
+
+// resolveImage sets the ids of the images the image filter stands for: instances
+// refer to their image by id, so images named f.image are selected along with
+// the image whose id is f.image
+func (f *instanceFilter) resolveImage(images []*types.Image) {
+	if f.image == "" {
+		return
+	}
+	f.imageIds = map[string]bool{f.image: true}
+	for _, image := range images {
+		if image.Name == f.image {
+			f.imageIds[image.Id] = true
+		}
+	}
+}
+
+func (f instanceFilter) matches(instance *types.Instance) bool {
+	if f.imageIds != nil && !f.imageIds[instance.ImageId] {
+		return false
+	}
+	// instances of the reaper are not listed by provider, so check those too
+	if f.provider != "" && !strings.EqualFold(string(instance.Infrastructure), f.provider) {
+		return false
+	}
+	if f.states != nil && !f.states[instance.State] {
+		return false
+	}
+	if f.group != "" && instance.Group != f.group {
+		return false
+	}
+	if !f.createdAfter.IsZero() && !instance.Created.After(f.createdAfter) {
+		return false
+	}
+	if !f.createdBefore.IsZero() && !instance.Created.Before(f.createdBefore) {
+		return false
+	}
+	if !types.HasTags(instance.Tags, f.tags) {
+		return false
+	}
+	return true
+}
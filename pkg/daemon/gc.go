@@ -0,0 +1,158 @@
+package daemon
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const imageGCInterval = 10 * time.Minute
+
+// imageCollector deletes images that no instance uses and no manifest lists, oldest first, while
+// the number or age of images or the free disk space is beyond its policy.
+type imageCollector struct {
+	providers providers.Providers
+	policy    config.GCPolicy
+	// listed returns the ids of images that are in use besides those of instances
+	listed func() map[string]bool
+	// deleted is called with every image the collector deletes
+	deleted  func(provider providers.Provider, image *types.Image)
+	lock     sync.Mutex
+	status   types.ImageGCStatus
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newImageCollector(providers providers.Providers, policy config.GCPolicy, listed func() map[string]bool, deleted func(provider providers.Provider, image *types.Image)) *imageCollector {
+	return &imageCollector{
+		providers: providers,
+		policy:    policy,
+		listed:    listed,
+		deleted:   deleted,
+		done:      make(chan struct{}),
+	}
+}
+
+func (c *imageCollector) run(interval time.Duration) {
+	if c.policy == (config.GCPolicy{}) {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.collect()
+		}
+	}
+}
+
+func (c *imageCollector) stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *imageCollector) lastStatus() types.ImageGCStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.status
+}
+
+type gcCandidate struct {
+	image    *types.Image
+	provider providers.Provider
+	local    bool
+}
+
+// collect runs one pass of the collector and returns what it did
+func (c *imageCollector) collect() types.ImageGCStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	status := types.ImageGCStatus{LastRun: time.Now(), ImagesDeleted: []string{}}
+
+	inUse := imagesInUse(c.providers, c.listed())
+	total := 0
+	var candidates []gcCandidate
+	for _, provider := range c.providers {
+		_, local := provider.(providers.LocalImageProvider)
+		for _, image := range provider.GetState().GetImages() {
+			total++
+			if inUse[image.Id] || inUse[image.Name] {
+				continue
+			}
+			candidates = append(candidates, gcCandidate{image: image, provider: provider, local: local})
+		}
+	}
+	sort.Sort(gcCandidatesByAge(candidates))
+
+	var errs []string
+	var freeBytes int64
+	checkDisk := c.policy.MinFreeDiskGB > 0
+	if checkDisk {
+		var err error
+		if freeBytes, err = freeDiskBytes(config.Internal.UnikHome); err != nil {
+			errs = append(errs, "checking free disk space: "+err.Error())
+			checkDisk = false
+		}
+	}
+	minFreeBytes := int64(c.policy.MinFreeDiskGB * float64(1<<30))
+
+	for _, candidate := range candidates {
+		image := candidate.image
+		tooOld := c.policy.MaxAgeDays > 0 && time.Since(image.Created) > time.Duration(c.policy.MaxAgeDays)*24*time.Hour
+		tooMany := c.policy.MaxImages > 0 && total > c.policy.MaxImages
+		lowDisk := checkDisk && candidate.local && freeBytes < minFreeBytes
+		if !tooOld && !tooMany && !lowDisk {
+			continue
+		}
+		logrus.WithFields(logrus.Fields{
+			"image":    image.Name,
+			"created":  image.Created,
+			"too-old":  tooOld,
+			"too-many": tooMany,
+			"low-disk": lowDisk,
+		}).Infof("garbage collecting unused image %s", image.Name)
+		if err := candidate.provider.DeleteImage(image.Id, false); err != nil {
+			logrus.WithError(err).Warnf("failed to garbage collect image %s", image.Name)
+			errs = append(errs, image.Name+": "+err.Error())
+			continue
+		}
+		c.deleted(candidate.provider, image)
+		total--
+		if candidate.local {
+			freeBytes += image.SizeMb << 20
+		}
+		status.ImagesDeleted = append(status.ImagesDeleted, image.Name)
+		status.BytesFreed += image.SizeMb << 20
+	}
+	status.Error = strings.Join(errs, "; ")
+	c.status = status
+	return status
+}
+
+// imagesInUse adds the ids of the images instances run from to listed
+func imagesInUse(_providers providers.Providers, listed map[string]bool) map[string]bool {
+	for _, provider := range _providers {
+		for _, instance := range provider.GetState().GetInstances() {
+			listed[instance.ImageId] = true
+		}
+	}
+	return listed
+}
+
+type gcCandidatesByAge []gcCandidate
+
+func (s gcCandidatesByAge) Len() int      { return len(s) }
+func (s gcCandidatesByAge) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s gcCandidatesByAge) Less(i, j int) bool {
+	return s[i].image.Created.Before(s[j].image.Created)
+}
@@ -0,0 +1,177 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+type groupMember struct {
+	instance *types.Instance
+	provider providers.Provider
+}
+
+type groupMembersByAge []groupMember
+
+func (m groupMembersByAge) Len() int      { return len(m) }
+func (m groupMembersByAge) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m groupMembersByAge) Less(i, j int) bool {
+	return m[i].instance.Created.Before(m[j].instance.Created)
+}
+
+// groupMembers returns the instances of group on all providers, oldest first
+func (d *UnikDaemon) groupMembers(group string) ([]groupMember, error) {
+	var members []groupMember
+	for _, provider := range d.providers {
+		instances, err := provider.ListInstances()
+		if err != nil {
+			return nil, errors.New("could not get instance list", err)
+		}
+		for _, instance := range instances {
+			if instance.Group == group {
+				members = append(members, groupMember{instance: instance, provider: provider})
+			}
+		}
+	}
+	if len(members) == 0 {
+		return nil, errors.New("no instance is in group "+group, nil)
+	}
+	sort.Sort(groupMembersByAge(members))
+	return members, nil
+}
+
+// instance states that count as up when scaling a group
+func groupMemberUp(instance *types.Instance) bool {
+	return instance.State == types.InstanceState_Running || instance.State == types.InstanceState_Pending
+}
+
+// instance states a group member cannot be started from
+func groupMemberGone(instance *types.Instance) bool {
+	return instance.State == types.InstanceState_Terminated || instance.State == types.InstanceState_Expired
+}
+
+// stopGroup stops the members of group that are up, as stopAllInstances
+func (d *UnikDaemon) stopGroup(group string, timeout time.Duration) (*types.BatchResult, error) {
+	members, err := d.groupMembers(group)
+	if err != nil {
+		return nil, err
+	}
+	result := newBatchResult()
+	for _, member := range members {
+		if !groupMemberUp(member.instance) {
+			continue
+		}
+		logrus.WithFields(logrus.Fields{"group": group, "instance": member.instance.Name}).Infof("stopping instance %s", member.instance.Name)
+		addToBatch(result, member.instance.Name, d.stopInstance(member.provider, member.instance.Id, timeout))
+	}
+	return result, nil
+}
+
+// restartGroup restarts the members of group that are up, one after the other so
+// the group is never down as a whole
+func (d *UnikDaemon) restartGroup(group string, timeout time.Duration) (*types.BatchResult, error) {
+	members, err := d.groupMembers(group)
+	if err != nil {
+		return nil, err
+	}
+	result := newBatchResult()
+	for _, member := range members {
+		if !groupMemberUp(member.instance) {
+			continue
+		}
+		logrus.WithFields(logrus.Fields{"group": group, "instance": member.instance.Name}).Infof("restarting instance %s", member.instance.Name)
+		_, err := d.restartInstance(member.provider, member.instance.Id, timeout)
+		addToBatch(result, member.instance.Name, err)
+	}
+	return result, nil
+}
+
+// scaleGroup starts or stops members of group until to of them are up. stopped
+// members are started first, oldest first; if there are not enough, new members
+// are cloned from the oldest one (see cloneInstance) on its provider. when there
+// are too many, the newest are stopped. members are never deleted, so scaling a
+// group down and up again reuses its instances.
+func (d *UnikDaemon) scaleGroup(group string, to int, timeout time.Duration, user string) (*types.ScaleResult, error) {
+	members, err := d.groupMembers(group)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.ScaleResult{Group: group, Started: []string{}, Ran: []string{}, Stopped: []string{}, Failed: make(map[string]string)}
+	var up, down []groupMember
+	for _, member := range members {
+		if groupMemberUp(member.instance) {
+			up = append(up, member)
+		} else if !groupMemberGone(member.instance) {
+			down = append(down, member)
+		}
+	}
+	result.Running = len(up)
+
+	for i := len(up) - 1; i >= to; i-- {
+		instance := up[i].instance
+		logrus.WithFields(logrus.Fields{"group": group, "instance": instance.Name}).Infof("scaling down, stopping instance %s", instance.Name)
+		if err := d.stopInstance(up[i].provider, instance.Id, timeout); err != nil {
+			result.Failed[instance.Name] = err.Error()
+			continue
+		}
+		result.Stopped = append(result.Stopped, instance.Name)
+		result.Running--
+	}
+
+	for _, member := range down {
+		if result.Running >= to {
+			break
+		}
+		instance := member.instance
+		logrus.WithFields(logrus.Fields{"group": group, "instance": instance.Name}).Infof("scaling up, starting instance %s", instance.Name)
+		if err := d.startInstance(member.provider, instance.Id); err != nil {
+			result.Failed[instance.Name] = err.Error()
+			continue
+		}
+		result.Started = append(result.Started, instance.Name)
+		result.Running++
+	}
+
+	source := members[0]
+	for result.Running < to {
+		name, err := d.newGroupMemberName(group)
+		if err != nil {
+			return nil, err
+		}
+		logrus.WithFields(logrus.Fields{"group": group, "instance": name, "source": source.instance.Name}).Infof("scaling up, running instance %s", name)
+		instance, err := d.cloneInstance(source.provider, source.provider, user, source.instance.Id, name)
+		if err != nil {
+			// the next clone of the same instance would fail the same way
+			result.Failed[name] = err.Error()
+			break
+		}
+		result.Ran = append(result.Ran, instance.Name)
+		result.Running++
+	}
+	return result, nil
+}
+
+// newGroupMemberName returns the first group-N that names no instance
+func (d *UnikDaemon) newGroupMemberName(group string) (string, error) {
+	names := make(map[string]bool)
+	for _, provider := range d.providers {
+		instances, err := provider.ListInstances()
+		if err != nil {
+			return "", errors.New("could not get instance list", err)
+		}
+		for _, instance := range instances {
+			names[instance.Name] = true
+		}
+	}
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s-%d", group, i)
+		if !names[name] {
+			return name, nil
+		}
+	}
+}
@@ -0,0 +1,2612 @@
+// gRPC definition of the unik daemon api. mirrors the REST api served by
+// pkg/daemon, which serves it with --grpc-addr; see docs/grpc.md.
+// regenerate unik.pb.go and unik_grpc.pb.go after changing it with
+//   protoc --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. pkg/daemon/grpc/unik.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: pkg/daemon/grpc/unik.proto
+
+package grpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{0}
+}
+
+type Image struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	SizeMb         int64                  `protobuf:"varint,3,opt,name=size_mb,json=sizeMb,proto3" json:"size_mb,omitempty"`
+	Infrastructure string                 `protobuf:"bytes,4,opt,name=infrastructure,proto3" json:"infrastructure,omitempty"`
+	CreatedUnix    int64                  `protobuf:"varint,5,opt,name=created_unix,json=createdUnix,proto3" json:"created_unix,omitempty"`
+	Tags           map[string]string      `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Architecture   string                 `protobuf:"bytes,7,opt,name=architecture,proto3" json:"architecture,omitempty"`
+	Json           []byte                 `protobuf:"bytes,8,opt,name=json,proto3" json:"json,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Image) Reset() {
+	*x = Image{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Image) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Image) ProtoMessage() {}
+
+func (x *Image) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Image.ProtoReflect.Descriptor instead.
+func (*Image) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Image) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Image) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Image) GetSizeMb() int64 {
+	if x != nil {
+		return x.SizeMb
+	}
+	return 0
+}
+
+func (x *Image) GetInfrastructure() string {
+	if x != nil {
+		return x.Infrastructure
+	}
+	return ""
+}
+
+func (x *Image) GetCreatedUnix() int64 {
+	if x != nil {
+		return x.CreatedUnix
+	}
+	return 0
+}
+
+func (x *Image) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Image) GetArchitecture() string {
+	if x != nil {
+		return x.Architecture
+	}
+	return ""
+}
+
+func (x *Image) GetJson() []byte {
+	if x != nil {
+		return x.Json
+	}
+	return nil
+}
+
+type Instance struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	State          string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	IpAddress      string                 `protobuf:"bytes,4,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	ImageId        string                 `protobuf:"bytes,5,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	Infrastructure string                 `protobuf:"bytes,6,opt,name=infrastructure,proto3" json:"infrastructure,omitempty"`
+	CreatedUnix    int64                  `protobuf:"varint,7,opt,name=created_unix,json=createdUnix,proto3" json:"created_unix,omitempty"`
+	RestartCount   int32                  `protobuf:"varint,8,opt,name=restart_count,json=restartCount,proto3" json:"restart_count,omitempty"`
+	ExpiresAtUnix  int64                  `protobuf:"varint,9,opt,name=expires_at_unix,json=expiresAtUnix,proto3" json:"expires_at_unix,omitempty"`
+	Tags           map[string]string      `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Group          string                 `protobuf:"bytes,11,opt,name=group,proto3" json:"group,omitempty"`
+	Json           []byte                 `protobuf:"bytes,12,opt,name=json,proto3" json:"json,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Instance) Reset() {
+	*x = Instance{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Instance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Instance) ProtoMessage() {}
+
+func (x *Instance) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Instance.ProtoReflect.Descriptor instead.
+func (*Instance) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Instance) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Instance) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Instance) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Instance) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *Instance) GetImageId() string {
+	if x != nil {
+		return x.ImageId
+	}
+	return ""
+}
+
+func (x *Instance) GetInfrastructure() string {
+	if x != nil {
+		return x.Infrastructure
+	}
+	return ""
+}
+
+func (x *Instance) GetCreatedUnix() int64 {
+	if x != nil {
+		return x.CreatedUnix
+	}
+	return 0
+}
+
+func (x *Instance) GetRestartCount() int32 {
+	if x != nil {
+		return x.RestartCount
+	}
+	return 0
+}
+
+func (x *Instance) GetExpiresAtUnix() int64 {
+	if x != nil {
+		return x.ExpiresAtUnix
+	}
+	return 0
+}
+
+func (x *Instance) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Instance) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Instance) GetJson() []byte {
+	if x != nil {
+		return x.Json
+	}
+	return nil
+}
+
+type Volume struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	SizeMb         int64                  `protobuf:"varint,3,opt,name=size_mb,json=sizeMb,proto3" json:"size_mb,omitempty"`
+	Attachment     string                 `protobuf:"bytes,4,opt,name=attachment,proto3" json:"attachment,omitempty"`
+	Infrastructure string                 `protobuf:"bytes,5,opt,name=infrastructure,proto3" json:"infrastructure,omitempty"`
+	CreatedUnix    int64                  `protobuf:"varint,6,opt,name=created_unix,json=createdUnix,proto3" json:"created_unix,omitempty"`
+	Tags           map[string]string      `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Mode           string                 `protobuf:"bytes,8,opt,name=mode,proto3" json:"mode,omitempty"`
+	Json           []byte                 `protobuf:"bytes,9,opt,name=json,proto3" json:"json,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Volume) Reset() {
+	*x = Volume{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Volume) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Volume) ProtoMessage() {}
+
+func (x *Volume) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Volume.ProtoReflect.Descriptor instead.
+func (*Volume) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Volume) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Volume) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Volume) GetSizeMb() int64 {
+	if x != nil {
+		return x.SizeMb
+	}
+	return 0
+}
+
+func (x *Volume) GetAttachment() string {
+	if x != nil {
+		return x.Attachment
+	}
+	return ""
+}
+
+func (x *Volume) GetInfrastructure() string {
+	if x != nil {
+		return x.Infrastructure
+	}
+	return ""
+}
+
+func (x *Volume) GetCreatedUnix() int64 {
+	if x != nil {
+		return x.CreatedUnix
+	}
+	return 0
+}
+
+func (x *Volume) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Volume) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Volume) GetJson() []byte {
+	if x != nil {
+		return x.Json
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	TimestampUnix int64                  `protobuf:"varint,3,opt,name=timestamp_unix,json=timestampUnix,proto3" json:"timestamp_unix,omitempty"`
+	// json encoding of the updated image, instance or volume
+	Payload       []byte `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetTimestampUnix() int64 {
+	if x != nil {
+		return x.TimestampUnix
+	}
+	return 0
+}
+
+func (x *Event) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type BuildImageRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// gzipped tarball of the application sources
+	Sources     []byte            `protobuf:"bytes,2,opt,name=sources,proto3" json:"sources,omitempty"`
+	Base        string            `protobuf:"bytes,3,opt,name=base,proto3" json:"base,omitempty"`
+	Lang        string            `protobuf:"bytes,4,opt,name=lang,proto3" json:"lang,omitempty"`
+	Provider    string            `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
+	Args        string            `protobuf:"bytes,6,opt,name=args,proto3" json:"args,omitempty"`
+	MountPoints []string          `protobuf:"bytes,7,rep,name=mount_points,json=mountPoints,proto3" json:"mount_points,omitempty"`
+	Force       bool              `protobuf:"varint,8,opt,name=force,proto3" json:"force,omitempty"`
+	NoCleanup   bool              `protobuf:"varint,9,opt,name=no_cleanup,json=noCleanup,proto3" json:"no_cleanup,omitempty"`
+	Tags        map[string]string `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Arch        string            `protobuf:"bytes,11,opt,name=arch,proto3" json:"arch,omitempty"`
+	Squash      bool              `protobuf:"varint,12,opt,name=squash,proto3" json:"squash,omitempty"`
+	MemoryMb    int32             `protobuf:"varint,13,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
+	Vcpus       int32             `protobuf:"varint,14,opt,name=vcpus,proto3" json:"vcpus,omitempty"`
+	NetworkMode string            `protobuf:"bytes,15,opt,name=network_mode,json=networkMode,proto3" json:"network_mode,omitempty"`
+	BaseImage   string            `protobuf:"bytes,16,opt,name=base_image,json=baseImage,proto3" json:"base_image,omitempty"`
+	Target      string            `protobuf:"bytes,17,opt,name=target,proto3" json:"target,omitempty"`
+	// 0 never times out
+	TimeoutMs     int64 `protobuf:"varint,18,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildImageRequest) Reset() {
+	*x = BuildImageRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildImageRequest) ProtoMessage() {}
+
+func (x *BuildImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildImageRequest.ProtoReflect.Descriptor instead.
+func (*BuildImageRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BuildImageRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetSources() []byte {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *BuildImageRequest) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetArgs() string {
+	if x != nil {
+		return x.Args
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetMountPoints() []string {
+	if x != nil {
+		return x.MountPoints
+	}
+	return nil
+}
+
+func (x *BuildImageRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+func (x *BuildImageRequest) GetNoCleanup() bool {
+	if x != nil {
+		return x.NoCleanup
+	}
+	return false
+}
+
+func (x *BuildImageRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *BuildImageRequest) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetSquash() bool {
+	if x != nil {
+		return x.Squash
+	}
+	return false
+}
+
+func (x *BuildImageRequest) GetMemoryMb() int32 {
+	if x != nil {
+		return x.MemoryMb
+	}
+	return 0
+}
+
+func (x *BuildImageRequest) GetVcpus() int32 {
+	if x != nil {
+		return x.Vcpus
+	}
+	return 0
+}
+
+func (x *BuildImageRequest) GetNetworkMode() string {
+	if x != nil {
+		return x.NetworkMode
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetBaseImage() string {
+	if x != nil {
+		return x.BaseImage
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *BuildImageRequest) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+type ListImagesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// selects the images with all of these tags
+	Tags          map[string]string `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListImagesRequest) Reset() {
+	*x = ListImagesRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListImagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListImagesRequest) ProtoMessage() {}
+
+func (x *ListImagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListImagesRequest.ProtoReflect.Descriptor instead.
+func (*ListImagesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListImagesRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListImagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Images        []*Image               `protobuf:"bytes,1,rep,name=images,proto3" json:"images,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListImagesResponse) Reset() {
+	*x = ListImagesResponse{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListImagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListImagesResponse) ProtoMessage() {}
+
+func (x *ListImagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListImagesResponse.ProtoReflect.Descriptor instead.
+func (*ListImagesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListImagesResponse) GetImages() []*Image {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+type GetImageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NameOrId      string                 `protobuf:"bytes,1,opt,name=name_or_id,json=nameOrId,proto3" json:"name_or_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetImageRequest) Reset() {
+	*x = GetImageRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetImageRequest) ProtoMessage() {}
+
+func (x *GetImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetImageRequest.ProtoReflect.Descriptor instead.
+func (*GetImageRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetImageRequest) GetNameOrId() string {
+	if x != nil {
+		return x.NameOrId
+	}
+	return ""
+}
+
+type DeleteImageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NameOrId      string                 `protobuf:"bytes,1,opt,name=name_or_id,json=nameOrId,proto3" json:"name_or_id,omitempty"`
+	Force         bool                   `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteImageRequest) Reset() {
+	*x = DeleteImageRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteImageRequest) ProtoMessage() {}
+
+func (x *DeleteImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteImageRequest.ProtoReflect.Descriptor instead.
+func (*DeleteImageRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteImageRequest) GetNameOrId() string {
+	if x != nil {
+		return x.NameOrId
+	}
+	return ""
+}
+
+func (x *DeleteImageRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type RestartPolicy struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Mode           string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	MaxRestarts    int32                  `protobuf:"varint,2,opt,name=max_restarts,json=maxRestarts,proto3" json:"max_restarts,omitempty"`
+	BackoffSeconds int32                  `protobuf:"varint,3,opt,name=backoff_seconds,json=backoffSeconds,proto3" json:"backoff_seconds,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RestartPolicy) Reset() {
+	*x = RestartPolicy{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestartPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestartPolicy) ProtoMessage() {}
+
+func (x *RestartPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestartPolicy.ProtoReflect.Descriptor instead.
+func (*RestartPolicy) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RestartPolicy) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *RestartPolicy) GetMaxRestarts() int32 {
+	if x != nil {
+		return x.MaxRestarts
+	}
+	return 0
+}
+
+func (x *RestartPolicy) GetBackoffSeconds() int32 {
+	if x != nil {
+		return x.BackoffSeconds
+	}
+	return 0
+}
+
+type PortMapping struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HostPort      int32                  `protobuf:"varint,1,opt,name=host_port,json=hostPort,proto3" json:"host_port,omitempty"`
+	GuestPort     int32                  `protobuf:"varint,2,opt,name=guest_port,json=guestPort,proto3" json:"guest_port,omitempty"`
+	Protocol      string                 `protobuf:"bytes,3,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PortMapping) Reset() {
+	*x = PortMapping{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PortMapping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortMapping) ProtoMessage() {}
+
+func (x *PortMapping) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortMapping.ProtoReflect.Descriptor instead.
+func (*PortMapping) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PortMapping) GetHostPort() int32 {
+	if x != nil {
+		return x.HostPort
+	}
+	return 0
+}
+
+func (x *PortMapping) GetGuestPort() int32 {
+	if x != nil {
+		return x.GuestPort
+	}
+	return 0
+}
+
+func (x *PortMapping) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+type IPv6Config struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	PrefixLength  int32                  `protobuf:"varint,2,opt,name=prefix_length,json=prefixLength,proto3" json:"prefix_length,omitempty"`
+	Gateway       string                 `protobuf:"bytes,3,opt,name=gateway,proto3" json:"gateway,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IPv6Config) Reset() {
+	*x = IPv6Config{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IPv6Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IPv6Config) ProtoMessage() {}
+
+func (x *IPv6Config) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IPv6Config.ProtoReflect.Descriptor instead.
+func (*IPv6Config) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *IPv6Config) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *IPv6Config) GetPrefixLength() int32 {
+	if x != nil {
+		return x.PrefixLength
+	}
+	return 0
+}
+
+func (x *IPv6Config) GetGateway() string {
+	if x != nil {
+		return x.Gateway
+	}
+	return ""
+}
+
+type StaticIPConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Gateway       string                 `protobuf:"bytes,2,opt,name=gateway,proto3" json:"gateway,omitempty"`
+	Dns           []string               `protobuf:"bytes,3,rep,name=dns,proto3" json:"dns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StaticIPConfig) Reset() {
+	*x = StaticIPConfig{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StaticIPConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StaticIPConfig) ProtoMessage() {}
+
+func (x *StaticIPConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StaticIPConfig.ProtoReflect.Descriptor instead.
+func (*StaticIPConfig) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StaticIPConfig) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *StaticIPConfig) GetGateway() string {
+	if x != nil {
+		return x.Gateway
+	}
+	return ""
+}
+
+func (x *StaticIPConfig) GetDns() []string {
+	if x != nil {
+		return x.Dns
+	}
+	return nil
+}
+
+type RunInstanceRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	InstanceName   string                 `protobuf:"bytes,1,opt,name=instance_name,json=instanceName,proto3" json:"instance_name,omitempty"`
+	ImageName      string                 `protobuf:"bytes,2,opt,name=image_name,json=imageName,proto3" json:"image_name,omitempty"`
+	Mounts         map[string]string      `protobuf:"bytes,3,rep,name=mounts,proto3" json:"mounts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Env            map[string]string      `protobuf:"bytes,4,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	MemoryMb       int32                  `protobuf:"varint,5,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
+	NoCleanup      bool                   `protobuf:"varint,6,opt,name=no_cleanup,json=noCleanup,proto3" json:"no_cleanup,omitempty"`
+	DebugMode      bool                   `protobuf:"varint,7,opt,name=debug_mode,json=debugMode,proto3" json:"debug_mode,omitempty"`
+	RestartPolicy  *RestartPolicy         `protobuf:"bytes,8,opt,name=restart_policy,json=restartPolicy,proto3" json:"restart_policy,omitempty"`
+	TtlMs          int64                  `protobuf:"varint,9,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"`
+	Vcpus          int32                  `protobuf:"varint,10,opt,name=vcpus,proto3" json:"vcpus,omitempty"`
+	NetworkMode    string                 `protobuf:"bytes,11,opt,name=network_mode,json=networkMode,proto3" json:"network_mode,omitempty"`
+	Tags           map[string]string      `protobuf:"bytes,12,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Ports          []*PortMapping         `protobuf:"bytes,13,rep,name=ports,proto3" json:"ports,omitempty"`
+	Cmdline        string                 `protobuf:"bytes,14,opt,name=cmdline,proto3" json:"cmdline,omitempty"`
+	CmdlineMode    string                 `protobuf:"bytes,15,opt,name=cmdline_mode,json=cmdlineMode,proto3" json:"cmdline_mode,omitempty"`
+	ReadOnlyMounts []string               `protobuf:"bytes,16,rep,name=read_only_mounts,json=readOnlyMounts,proto3" json:"read_only_mounts,omitempty"`
+	UserData       string                 `protobuf:"bytes,17,opt,name=user_data,json=userData,proto3" json:"user_data,omitempty"`
+	MetaData       string                 `protobuf:"bytes,18,opt,name=meta_data,json=metaData,proto3" json:"meta_data,omitempty"`
+	Ipv6           *IPv6Config            `protobuf:"bytes,19,opt,name=ipv6,proto3" json:"ipv6,omitempty"`
+	StaticIp       *StaticIPConfig        `protobuf:"bytes,20,opt,name=static_ip,json=staticIp,proto3" json:"static_ip,omitempty"`
+	Group          string                 `protobuf:"bytes,21,opt,name=group,proto3" json:"group,omitempty"`
+	VolumeMode     string                 `protobuf:"bytes,22,opt,name=volume_mode,json=volumeMode,proto3" json:"volume_mode,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RunInstanceRequest) Reset() {
+	*x = RunInstanceRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunInstanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunInstanceRequest) ProtoMessage() {}
+
+func (x *RunInstanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunInstanceRequest.ProtoReflect.Descriptor instead.
+func (*RunInstanceRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RunInstanceRequest) GetInstanceName() string {
+	if x != nil {
+		return x.InstanceName
+	}
+	return ""
+}
+
+func (x *RunInstanceRequest) GetImageName() string {
+	if x != nil {
+		return x.ImageName
+	}
+	return ""
+}
+
+func (x *RunInstanceRequest) GetMounts() map[string]string {
+	if x != nil {
+		return x.Mounts
+	}
+	return nil
+}
+
+func (x *RunInstanceRequest) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *RunInstanceRequest) GetMemoryMb() int32 {
+	if x != nil {
+		return x.MemoryMb
+	}
+	return 0
+}
+
+func (x *RunInstanceRequest) GetNoCleanup() bool {
+	if x != nil {
+		return x.NoCleanup
+	}
+	return false
+}
+
+func (x *RunInstanceRequest) GetDebugMode() bool {
+	if x != nil {
+		return x.DebugMode
+	}
+	return false
+}
+
+func (x *RunInstanceRequest) GetRestartPolicy() *RestartPolicy {
+	if x != nil {
+		return x.RestartPolicy
+	}
+	return nil
+}
+
+func (x *RunInstanceRequest) GetTtlMs() int64 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
+func (x *RunInstanceRequest) GetVcpus() int32 {
+	if x != nil {
+		return x.Vcpus
+	}
+	return 0
+}
+
+func (x *RunInstanceRequest) GetNetworkMode() string {
+	if x != nil {
+		return x.NetworkMode
+	}
+	return ""
+}
+
+func (x *RunInstanceRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *RunInstanceRequest) GetPorts() []*PortMapping {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+func (x *RunInstanceRequest) GetCmdline() string {
+	if x != nil {
+		return x.Cmdline
+	}
+	return ""
+}
+
+func (x *RunInstanceRequest) GetCmdlineMode() string {
+	if x != nil {
+		return x.CmdlineMode
+	}
+	return ""
+}
+
+func (x *RunInstanceRequest) GetReadOnlyMounts() []string {
+	if x != nil {
+		return x.ReadOnlyMounts
+	}
+	return nil
+}
+
+func (x *RunInstanceRequest) GetUserData() string {
+	if x != nil {
+		return x.UserData
+	}
+	return ""
+}
+
+func (x *RunInstanceRequest) GetMetaData() string {
+	if x != nil {
+		return x.MetaData
+	}
+	return ""
+}
+
+func (x *RunInstanceRequest) GetIpv6() *IPv6Config {
+	if x != nil {
+		return x.Ipv6
+	}
+	return nil
+}
+
+func (x *RunInstanceRequest) GetStaticIp() *StaticIPConfig {
+	if x != nil {
+		return x.StaticIp
+	}
+	return nil
+}
+
+func (x *RunInstanceRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *RunInstanceRequest) GetVolumeMode() string {
+	if x != nil {
+		return x.VolumeMode
+	}
+	return ""
+}
+
+type ListInstancesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// selects the instances with all of these tags
+	Tags              map[string]string `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Image             string            `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	Provider          string            `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+	States            []string          `protobuf:"bytes,4,rep,name=states,proto3" json:"states,omitempty"`
+	CreatedAfterUnix  int64             `protobuf:"varint,5,opt,name=created_after_unix,json=createdAfterUnix,proto3" json:"created_after_unix,omitempty"`
+	CreatedBeforeUnix int64             `protobuf:"varint,6,opt,name=created_before_unix,json=createdBeforeUnix,proto3" json:"created_before_unix,omitempty"`
+	Group             string            `protobuf:"bytes,7,opt,name=group,proto3" json:"group,omitempty"`
+	Sort              string            `protobuf:"bytes,8,opt,name=sort,proto3" json:"sort,omitempty"`
+	Order             string            `protobuf:"bytes,9,opt,name=order,proto3" json:"order,omitempty"`
+	Limit             int32             `protobuf:"varint,10,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset            int32             `protobuf:"varint,11,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ListInstancesRequest) Reset() {
+	*x = ListInstancesRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInstancesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInstancesRequest) ProtoMessage() {}
+
+func (x *ListInstancesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInstancesRequest.ProtoReflect.Descriptor instead.
+func (*ListInstancesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListInstancesRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListInstancesRequest) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *ListInstancesRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ListInstancesRequest) GetStates() []string {
+	if x != nil {
+		return x.States
+	}
+	return nil
+}
+
+func (x *ListInstancesRequest) GetCreatedAfterUnix() int64 {
+	if x != nil {
+		return x.CreatedAfterUnix
+	}
+	return 0
+}
+
+func (x *ListInstancesRequest) GetCreatedBeforeUnix() int64 {
+	if x != nil {
+		return x.CreatedBeforeUnix
+	}
+	return 0
+}
+
+func (x *ListInstancesRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *ListInstancesRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListInstancesRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+func (x *ListInstancesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListInstancesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListInstancesResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Instances []*Instance            `protobuf:"bytes,1,rep,name=instances,proto3" json:"instances,omitempty"`
+	// number of instances matching the request, on all pages
+	Total         int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInstancesResponse) Reset() {
+	*x = ListInstancesResponse{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInstancesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInstancesResponse) ProtoMessage() {}
+
+func (x *ListInstancesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInstancesResponse.ProtoReflect.Descriptor instead.
+func (*ListInstancesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListInstancesResponse) GetInstances() []*Instance {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+func (x *ListInstancesResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetInstanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NameOrId      string                 `protobuf:"bytes,1,opt,name=name_or_id,json=nameOrId,proto3" json:"name_or_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInstanceRequest) Reset() {
+	*x = GetInstanceRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInstanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInstanceRequest) ProtoMessage() {}
+
+func (x *GetInstanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInstanceRequest.ProtoReflect.Descriptor instead.
+func (*GetInstanceRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetInstanceRequest) GetNameOrId() string {
+	if x != nil {
+		return x.NameOrId
+	}
+	return ""
+}
+
+type InstanceIdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstanceIdRequest) Reset() {
+	*x = InstanceIdRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstanceIdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstanceIdRequest) ProtoMessage() {}
+
+func (x *InstanceIdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstanceIdRequest.ProtoReflect.Descriptor instead.
+func (*InstanceIdRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *InstanceIdRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+type StopInstanceRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	// time the instance is given to shut down before it is forced to stop
+	TimeoutMs     int64 `protobuf:"varint,2,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopInstanceRequest) Reset() {
+	*x = StopInstanceRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopInstanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopInstanceRequest) ProtoMessage() {}
+
+func (x *StopInstanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopInstanceRequest.ProtoReflect.Descriptor instead.
+func (*StopInstanceRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StopInstanceRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *StopInstanceRequest) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+type DeleteInstanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Force         bool                   `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteInstanceRequest) Reset() {
+	*x = DeleteInstanceRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteInstanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteInstanceRequest) ProtoMessage() {}
+
+func (x *DeleteInstanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteInstanceRequest.ProtoReflect.Descriptor instead.
+func (*DeleteInstanceRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DeleteInstanceRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *DeleteInstanceRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type InstanceLogs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Logs          string                 `protobuf:"bytes,1,opt,name=logs,proto3" json:"logs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstanceLogs) Reset() {
+	*x = InstanceLogs{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstanceLogs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstanceLogs) ProtoMessage() {}
+
+func (x *InstanceLogs) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstanceLogs.ProtoReflect.Descriptor instead.
+func (*InstanceLogs) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *InstanceLogs) GetLogs() string {
+	if x != nil {
+		return x.Logs
+	}
+	return ""
+}
+
+type CreateVolumeRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Name     string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Provider string                 `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	SizeMb   int32                  `protobuf:"varint,3,opt,name=size_mb,json=sizeMb,proto3" json:"size_mb,omitempty"`
+	// optional tarball of data to copy onto the volume
+	Data           []byte            `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Type           string            `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`
+	Raw            bool              `protobuf:"varint,6,opt,name=raw,proto3" json:"raw,omitempty"`
+	NoCleanup      bool              `protobuf:"varint,7,opt,name=no_cleanup,json=noCleanup,proto3" json:"no_cleanup,omitempty"`
+	Mode           string            `protobuf:"bytes,8,opt,name=mode,proto3" json:"mode,omitempty"`
+	PartitionTable string            `protobuf:"bytes,9,opt,name=partition_table,json=partitionTable,proto3" json:"partition_table,omitempty"`
+	Tags           map[string]string `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateVolumeRequest) Reset() {
+	*x = CreateVolumeRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateVolumeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateVolumeRequest) ProtoMessage() {}
+
+func (x *CreateVolumeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateVolumeRequest.ProtoReflect.Descriptor instead.
+func (*CreateVolumeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *CreateVolumeRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateVolumeRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *CreateVolumeRequest) GetSizeMb() int32 {
+	if x != nil {
+		return x.SizeMb
+	}
+	return 0
+}
+
+func (x *CreateVolumeRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *CreateVolumeRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CreateVolumeRequest) GetRaw() bool {
+	if x != nil {
+		return x.Raw
+	}
+	return false
+}
+
+func (x *CreateVolumeRequest) GetNoCleanup() bool {
+	if x != nil {
+		return x.NoCleanup
+	}
+	return false
+}
+
+func (x *CreateVolumeRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *CreateVolumeRequest) GetPartitionTable() string {
+	if x != nil {
+		return x.PartitionTable
+	}
+	return ""
+}
+
+func (x *CreateVolumeRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListVolumesRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Provider     string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Attached     bool                   `protobuf:"varint,2,opt,name=attached,proto3" json:"attached,omitempty"`
+	Unattached   bool                   `protobuf:"varint,3,opt,name=unattached,proto3" json:"unattached,omitempty"`
+	NameContains string                 `protobuf:"bytes,4,opt,name=name_contains,json=nameContains,proto3" json:"name_contains,omitempty"`
+	// selects the volumes with all of these tags
+	Tags          map[string]string `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	SizeGt        int64             `protobuf:"varint,6,opt,name=size_gt,json=sizeGt,proto3" json:"size_gt,omitempty"`
+	SizeLt        int64             `protobuf:"varint,7,opt,name=size_lt,json=sizeLt,proto3" json:"size_lt,omitempty"`
+	Sort          string            `protobuf:"bytes,8,opt,name=sort,proto3" json:"sort,omitempty"`
+	Order         string            `protobuf:"bytes,9,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVolumesRequest) Reset() {
+	*x = ListVolumesRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVolumesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVolumesRequest) ProtoMessage() {}
+
+func (x *ListVolumesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVolumesRequest.ProtoReflect.Descriptor instead.
+func (*ListVolumesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListVolumesRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ListVolumesRequest) GetAttached() bool {
+	if x != nil {
+		return x.Attached
+	}
+	return false
+}
+
+func (x *ListVolumesRequest) GetUnattached() bool {
+	if x != nil {
+		return x.Unattached
+	}
+	return false
+}
+
+func (x *ListVolumesRequest) GetNameContains() string {
+	if x != nil {
+		return x.NameContains
+	}
+	return ""
+}
+
+func (x *ListVolumesRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListVolumesRequest) GetSizeGt() int64 {
+	if x != nil {
+		return x.SizeGt
+	}
+	return 0
+}
+
+func (x *ListVolumesRequest) GetSizeLt() int64 {
+	if x != nil {
+		return x.SizeLt
+	}
+	return 0
+}
+
+func (x *ListVolumesRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListVolumesRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+type ListVolumesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Volumes       []*Volume              `protobuf:"bytes,1,rep,name=volumes,proto3" json:"volumes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVolumesResponse) Reset() {
+	*x = ListVolumesResponse{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVolumesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVolumesResponse) ProtoMessage() {}
+
+func (x *ListVolumesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVolumesResponse.ProtoReflect.Descriptor instead.
+func (*ListVolumesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListVolumesResponse) GetVolumes() []*Volume {
+	if x != nil {
+		return x.Volumes
+	}
+	return nil
+}
+
+type GetVolumeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NameOrId      string                 `protobuf:"bytes,1,opt,name=name_or_id,json=nameOrId,proto3" json:"name_or_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetVolumeRequest) Reset() {
+	*x = GetVolumeRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetVolumeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVolumeRequest) ProtoMessage() {}
+
+func (x *GetVolumeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVolumeRequest.ProtoReflect.Descriptor instead.
+func (*GetVolumeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetVolumeRequest) GetNameOrId() string {
+	if x != nil {
+		return x.NameOrId
+	}
+	return ""
+}
+
+type DeleteVolumeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NameOrId      string                 `protobuf:"bytes,1,opt,name=name_or_id,json=nameOrId,proto3" json:"name_or_id,omitempty"`
+	Force         bool                   `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteVolumeRequest) Reset() {
+	*x = DeleteVolumeRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteVolumeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteVolumeRequest) ProtoMessage() {}
+
+func (x *DeleteVolumeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteVolumeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteVolumeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DeleteVolumeRequest) GetNameOrId() string {
+	if x != nil {
+		return x.NameOrId
+	}
+	return ""
+}
+
+func (x *DeleteVolumeRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type AttachVolumeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Volume        string                 `protobuf:"bytes,1,opt,name=volume,proto3" json:"volume,omitempty"`
+	InstanceId    string                 `protobuf:"bytes,2,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	MountPoint    string                 `protobuf:"bytes,3,opt,name=mount_point,json=mountPoint,proto3" json:"mount_point,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachVolumeRequest) Reset() {
+	*x = AttachVolumeRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachVolumeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachVolumeRequest) ProtoMessage() {}
+
+func (x *AttachVolumeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachVolumeRequest.ProtoReflect.Descriptor instead.
+func (*AttachVolumeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *AttachVolumeRequest) GetVolume() string {
+	if x != nil {
+		return x.Volume
+	}
+	return ""
+}
+
+func (x *AttachVolumeRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *AttachVolumeRequest) GetMountPoint() string {
+	if x != nil {
+		return x.MountPoint
+	}
+	return ""
+}
+
+type DetachVolumeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Volume        string                 `protobuf:"bytes,1,opt,name=volume,proto3" json:"volume,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DetachVolumeRequest) Reset() {
+	*x = DetachVolumeRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DetachVolumeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetachVolumeRequest) ProtoMessage() {}
+
+func (x *DetachVolumeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetachVolumeRequest.ProtoReflect.Descriptor instead.
+func (*DetachVolumeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DetachVolumeRequest) GetVolume() string {
+	if x != nil {
+		return x.Volume
+	}
+	return ""
+}
+
+type StreamEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_grpc_unik_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_grpc_unik_proto_rawDescGZIP(), []int{29}
+}
+
+var File_pkg_daemon_grpc_unik_proto protoreflect.FileDescriptor
+
+const file_pkg_daemon_grpc_unik_proto_rawDesc = "" +
+	"\n" +
+	"\x1apkg/daemon/grpc/unik.proto\x12\x04unik\"\a\n" +
+	"\x05Empty\"\xab\x02\n" +
+	"\x05Image\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x17\n" +
+	"\asize_mb\x18\x03 \x01(\x03R\x06sizeMb\x12&\n" +
+	"\x0einfrastructure\x18\x04 \x01(\tR\x0einfrastructure\x12!\n" +
+	"\fcreated_unix\x18\x05 \x01(\x03R\vcreatedUnix\x12)\n" +
+	"\x04tags\x18\x06 \x03(\v2\x15.unik.Image.TagsEntryR\x04tags\x12\"\n" +
+	"\farchitecture\x18\a \x01(\tR\farchitecture\x12\x12\n" +
+	"\x04json\x18\b \x01(\fR\x04json\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa7\x03\n" +
+	"\bInstance\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\x04 \x01(\tR\tipAddress\x12\x19\n" +
+	"\bimage_id\x18\x05 \x01(\tR\aimageId\x12&\n" +
+	"\x0einfrastructure\x18\x06 \x01(\tR\x0einfrastructure\x12!\n" +
+	"\fcreated_unix\x18\a \x01(\x03R\vcreatedUnix\x12#\n" +
+	"\rrestart_count\x18\b \x01(\x05R\frestartCount\x12&\n" +
+	"\x0fexpires_at_unix\x18\t \x01(\x03R\rexpiresAtUnix\x12,\n" +
+	"\x04tags\x18\n" +
+	" \x03(\v2\x18.unik.Instance.TagsEntryR\x04tags\x12\x14\n" +
+	"\x05group\x18\v \x01(\tR\x05group\x12\x12\n" +
+	"\x04json\x18\f \x01(\fR\x04json\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbd\x02\n" +
+	"\x06Volume\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x17\n" +
+	"\asize_mb\x18\x03 \x01(\x03R\x06sizeMb\x12\x1e\n" +
+	"\n" +
+	"attachment\x18\x04 \x01(\tR\n" +
+	"attachment\x12&\n" +
+	"\x0einfrastructure\x18\x05 \x01(\tR\x0einfrastructure\x12!\n" +
+	"\fcreated_unix\x18\x06 \x01(\x03R\vcreatedUnix\x12*\n" +
+	"\x04tags\x18\a \x03(\v2\x16.unik.Volume.TagsEntryR\x04tags\x12\x12\n" +
+	"\x04mode\x18\b \x01(\tR\x04mode\x12\x12\n" +
+	"\x04json\x18\t \x01(\fR\x04json\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"l\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12%\n" +
+	"\x0etimestamp_unix\x18\x03 \x01(\x03R\rtimestampUnix\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\"\xb9\x04\n" +
+	"\x11BuildImageRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\asources\x18\x02 \x01(\fR\asources\x12\x12\n" +
+	"\x04base\x18\x03 \x01(\tR\x04base\x12\x12\n" +
+	"\x04lang\x18\x04 \x01(\tR\x04lang\x12\x1a\n" +
+	"\bprovider\x18\x05 \x01(\tR\bprovider\x12\x12\n" +
+	"\x04args\x18\x06 \x01(\tR\x04args\x12!\n" +
+	"\fmount_points\x18\a \x03(\tR\vmountPoints\x12\x14\n" +
+	"\x05force\x18\b \x01(\bR\x05force\x12\x1d\n" +
+	"\n" +
+	"no_cleanup\x18\t \x01(\bR\tnoCleanup\x125\n" +
+	"\x04tags\x18\n" +
+	" \x03(\v2!.unik.BuildImageRequest.TagsEntryR\x04tags\x12\x12\n" +
+	"\x04arch\x18\v \x01(\tR\x04arch\x12\x16\n" +
+	"\x06squash\x18\f \x01(\bR\x06squash\x12\x1b\n" +
+	"\tmemory_mb\x18\r \x01(\x05R\bmemoryMb\x12\x14\n" +
+	"\x05vcpus\x18\x0e \x01(\x05R\x05vcpus\x12!\n" +
+	"\fnetwork_mode\x18\x0f \x01(\tR\vnetworkMode\x12\x1d\n" +
+	"\n" +
+	"base_image\x18\x10 \x01(\tR\tbaseImage\x12\x16\n" +
+	"\x06target\x18\x11 \x01(\tR\x06target\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\x12 \x01(\x03R\ttimeoutMs\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x83\x01\n" +
+	"\x11ListImagesRequest\x125\n" +
+	"\x04tags\x18\x01 \x03(\v2!.unik.ListImagesRequest.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"9\n" +
+	"\x12ListImagesResponse\x12#\n" +
+	"\x06images\x18\x01 \x03(\v2\v.unik.ImageR\x06images\"/\n" +
+	"\x0fGetImageRequest\x12\x1c\n" +
+	"\n" +
+	"name_or_id\x18\x01 \x01(\tR\bnameOrId\"H\n" +
+	"\x12DeleteImageRequest\x12\x1c\n" +
+	"\n" +
+	"name_or_id\x18\x01 \x01(\tR\bnameOrId\x12\x14\n" +
+	"\x05force\x18\x02 \x01(\bR\x05force\"o\n" +
+	"\rRestartPolicy\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x12!\n" +
+	"\fmax_restarts\x18\x02 \x01(\x05R\vmaxRestarts\x12'\n" +
+	"\x0fbackoff_seconds\x18\x03 \x01(\x05R\x0ebackoffSeconds\"e\n" +
+	"\vPortMapping\x12\x1b\n" +
+	"\thost_port\x18\x01 \x01(\x05R\bhostPort\x12\x1d\n" +
+	"\n" +
+	"guest_port\x18\x02 \x01(\x05R\tguestPort\x12\x1a\n" +
+	"\bprotocol\x18\x03 \x01(\tR\bprotocol\"e\n" +
+	"\n" +
+	"IPv6Config\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12#\n" +
+	"\rprefix_length\x18\x02 \x01(\x05R\fprefixLength\x12\x18\n" +
+	"\agateway\x18\x03 \x01(\tR\agateway\"V\n" +
+	"\x0eStaticIPConfig\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x18\n" +
+	"\agateway\x18\x02 \x01(\tR\agateway\x12\x10\n" +
+	"\x03dns\x18\x03 \x03(\tR\x03dns\"\xf0\a\n" +
+	"\x12RunInstanceRequest\x12#\n" +
+	"\rinstance_name\x18\x01 \x01(\tR\finstanceName\x12\x1d\n" +
+	"\n" +
+	"image_name\x18\x02 \x01(\tR\timageName\x12<\n" +
+	"\x06mounts\x18\x03 \x03(\v2$.unik.RunInstanceRequest.MountsEntryR\x06mounts\x123\n" +
+	"\x03env\x18\x04 \x03(\v2!.unik.RunInstanceRequest.EnvEntryR\x03env\x12\x1b\n" +
+	"\tmemory_mb\x18\x05 \x01(\x05R\bmemoryMb\x12\x1d\n" +
+	"\n" +
+	"no_cleanup\x18\x06 \x01(\bR\tnoCleanup\x12\x1d\n" +
+	"\n" +
+	"debug_mode\x18\a \x01(\bR\tdebugMode\x12:\n" +
+	"\x0erestart_policy\x18\b \x01(\v2\x13.unik.RestartPolicyR\rrestartPolicy\x12\x15\n" +
+	"\x06ttl_ms\x18\t \x01(\x03R\x05ttlMs\x12\x14\n" +
+	"\x05vcpus\x18\n" +
+	" \x01(\x05R\x05vcpus\x12!\n" +
+	"\fnetwork_mode\x18\v \x01(\tR\vnetworkMode\x126\n" +
+	"\x04tags\x18\f \x03(\v2\".unik.RunInstanceRequest.TagsEntryR\x04tags\x12'\n" +
+	"\x05ports\x18\r \x03(\v2\x11.unik.PortMappingR\x05ports\x12\x18\n" +
+	"\acmdline\x18\x0e \x01(\tR\acmdline\x12!\n" +
+	"\fcmdline_mode\x18\x0f \x01(\tR\vcmdlineMode\x12(\n" +
+	"\x10read_only_mounts\x18\x10 \x03(\tR\x0ereadOnlyMounts\x12\x1b\n" +
+	"\tuser_data\x18\x11 \x01(\tR\buserData\x12\x1b\n" +
+	"\tmeta_data\x18\x12 \x01(\tR\bmetaData\x12$\n" +
+	"\x04ipv6\x18\x13 \x01(\v2\x10.unik.IPv6ConfigR\x04ipv6\x121\n" +
+	"\tstatic_ip\x18\x14 \x01(\v2\x14.unik.StaticIPConfigR\bstaticIp\x12\x14\n" +
+	"\x05group\x18\x15 \x01(\tR\x05group\x12\x1f\n" +
+	"\vvolume_mode\x18\x16 \x01(\tR\n" +
+	"volumeMode\x1a9\n" +
+	"\vMountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a6\n" +
+	"\bEnvEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9f\x03\n" +
+	"\x14ListInstancesRequest\x128\n" +
+	"\x04tags\x18\x01 \x03(\v2$.unik.ListInstancesRequest.TagsEntryR\x04tags\x12\x14\n" +
+	"\x05image\x18\x02 \x01(\tR\x05image\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\x12\x16\n" +
+	"\x06states\x18\x04 \x03(\tR\x06states\x12,\n" +
+	"\x12created_after_unix\x18\x05 \x01(\x03R\x10createdAfterUnix\x12.\n" +
+	"\x13created_before_unix\x18\x06 \x01(\x03R\x11createdBeforeUnix\x12\x14\n" +
+	"\x05group\x18\a \x01(\tR\x05group\x12\x12\n" +
+	"\x04sort\x18\b \x01(\tR\x04sort\x12\x14\n" +
+	"\x05order\x18\t \x01(\tR\x05order\x12\x14\n" +
+	"\x05limit\x18\n" +
+	" \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\v \x01(\x05R\x06offset\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"[\n" +
+	"\x15ListInstancesResponse\x12,\n" +
+	"\tinstances\x18\x01 \x03(\v2\x0e.unik.InstanceR\tinstances\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"2\n" +
+	"\x12GetInstanceRequest\x12\x1c\n" +
+	"\n" +
+	"name_or_id\x18\x01 \x01(\tR\bnameOrId\"4\n" +
+	"\x11InstanceIdRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\"U\n" +
+	"\x13StopInstanceRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\x02 \x01(\x03R\ttimeoutMs\"N\n" +
+	"\x15DeleteInstanceRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x14\n" +
+	"\x05force\x18\x02 \x01(\bR\x05force\"\"\n" +
+	"\fInstanceLogs\x12\x12\n" +
+	"\x04logs\x18\x01 \x01(\tR\x04logs\"\xe6\x02\n" +
+	"\x13CreateVolumeRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\x12\x17\n" +
+	"\asize_mb\x18\x03 \x01(\x05R\x06sizeMb\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12\x12\n" +
+	"\x04type\x18\x05 \x01(\tR\x04type\x12\x10\n" +
+	"\x03raw\x18\x06 \x01(\bR\x03raw\x12\x1d\n" +
+	"\n" +
+	"no_cleanup\x18\a \x01(\bR\tnoCleanup\x12\x12\n" +
+	"\x04mode\x18\b \x01(\tR\x04mode\x12'\n" +
+	"\x0fpartition_table\x18\t \x01(\tR\x0epartitionTable\x127\n" +
+	"\x04tags\x18\n" +
+	" \x03(\v2#.unik.CreateVolumeRequest.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xde\x02\n" +
+	"\x12ListVolumesRequest\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x1a\n" +
+	"\battached\x18\x02 \x01(\bR\battached\x12\x1e\n" +
+	"\n" +
+	"unattached\x18\x03 \x01(\bR\n" +
+	"unattached\x12#\n" +
+	"\rname_contains\x18\x04 \x01(\tR\fnameContains\x126\n" +
+	"\x04tags\x18\x05 \x03(\v2\".unik.ListVolumesRequest.TagsEntryR\x04tags\x12\x17\n" +
+	"\asize_gt\x18\x06 \x01(\x03R\x06sizeGt\x12\x17\n" +
+	"\asize_lt\x18\a \x01(\x03R\x06sizeLt\x12\x12\n" +
+	"\x04sort\x18\b \x01(\tR\x04sort\x12\x14\n" +
+	"\x05order\x18\t \x01(\tR\x05order\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"=\n" +
+	"\x13ListVolumesResponse\x12&\n" +
+	"\avolumes\x18\x01 \x03(\v2\f.unik.VolumeR\avolumes\"0\n" +
+	"\x10GetVolumeRequest\x12\x1c\n" +
+	"\n" +
+	"name_or_id\x18\x01 \x01(\tR\bnameOrId\"I\n" +
+	"\x13DeleteVolumeRequest\x12\x1c\n" +
+	"\n" +
+	"name_or_id\x18\x01 \x01(\tR\bnameOrId\x12\x14\n" +
+	"\x05force\x18\x02 \x01(\bR\x05force\"u\n" +
+	"\x13AttachVolumeRequest\x12\x16\n" +
+	"\x06volume\x18\x01 \x01(\tR\x06volume\x12\x1f\n" +
+	"\vinstance_id\x18\x02 \x01(\tR\n" +
+	"instanceId\x12\x1f\n" +
+	"\vmount_point\x18\x03 \x01(\tR\n" +
+	"mountPointJ\x04\b\x04\x10\x05\"-\n" +
+	"\x13DetachVolumeRequest\x12\x16\n" +
+	"\x06volume\x18\x01 \x01(\tR\x06volume\"\x15\n" +
+	"\x13StreamEventsRequest2\x9a\b\n" +
+	"\x04Unik\x122\n" +
+	"\n" +
+	"BuildImage\x12\x17.unik.BuildImageRequest\x1a\v.unik.Image\x12?\n" +
+	"\n" +
+	"ListImages\x12\x17.unik.ListImagesRequest\x1a\x18.unik.ListImagesResponse\x12.\n" +
+	"\bGetImage\x12\x15.unik.GetImageRequest\x1a\v.unik.Image\x124\n" +
+	"\vDeleteImage\x12\x18.unik.DeleteImageRequest\x1a\v.unik.Empty\x127\n" +
+	"\vRunInstance\x12\x18.unik.RunInstanceRequest\x1a\x0e.unik.Instance\x12H\n" +
+	"\rListInstances\x12\x1a.unik.ListInstancesRequest\x1a\x1b.unik.ListInstancesResponse\x127\n" +
+	"\vGetInstance\x12\x18.unik.GetInstanceRequest\x1a\x0e.unik.Instance\x125\n" +
+	"\rStartInstance\x12\x17.unik.InstanceIdRequest\x1a\v.unik.Empty\x126\n" +
+	"\fStopInstance\x12\x19.unik.StopInstanceRequest\x1a\v.unik.Empty\x12:\n" +
+	"\x0eDeleteInstance\x12\x1b.unik.DeleteInstanceRequest\x1a\v.unik.Empty\x12>\n" +
+	"\x0fGetInstanceLogs\x12\x17.unik.InstanceIdRequest\x1a\x12.unik.InstanceLogs\x127\n" +
+	"\fCreateVolume\x12\x19.unik.CreateVolumeRequest\x1a\f.unik.Volume\x12B\n" +
+	"\vListVolumes\x12\x18.unik.ListVolumesRequest\x1a\x19.unik.ListVolumesResponse\x121\n" +
+	"\tGetVolume\x12\x16.unik.GetVolumeRequest\x1a\f.unik.Volume\x126\n" +
+	"\fDeleteVolume\x12\x19.unik.DeleteVolumeRequest\x1a\v.unik.Empty\x126\n" +
+	"\fAttachVolume\x12\x19.unik.AttachVolumeRequest\x1a\v.unik.Empty\x126\n" +
+	"\fDetachVolume\x12\x19.unik.DetachVolumeRequest\x1a\v.unik.Empty\x128\n" +
+	"\fStreamEvents\x12\x19.unik.StreamEventsRequest\x1a\v.unik.Event0\x01B2Z0github.com/emc-advanced-dev/unik/pkg/daemon/grpcb\x06proto3"
+
+var (
+	file_pkg_daemon_grpc_unik_proto_rawDescOnce sync.Once
+	file_pkg_daemon_grpc_unik_proto_rawDescData []byte
+)
+
+func file_pkg_daemon_grpc_unik_proto_rawDescGZIP() []byte {
+	file_pkg_daemon_grpc_unik_proto_rawDescOnce.Do(func() {
+		file_pkg_daemon_grpc_unik_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_daemon_grpc_unik_proto_rawDesc), len(file_pkg_daemon_grpc_unik_proto_rawDesc)))
+	})
+	return file_pkg_daemon_grpc_unik_proto_rawDescData
+}
+
+var file_pkg_daemon_grpc_unik_proto_msgTypes = make([]protoimpl.MessageInfo, 41)
+var file_pkg_daemon_grpc_unik_proto_goTypes = []any{
+	(*Empty)(nil),                 // 0: unik.Empty
+	(*Image)(nil),                 // 1: unik.Image
+	(*Instance)(nil),              // 2: unik.Instance
+	(*Volume)(nil),                // 3: unik.Volume
+	(*Event)(nil),                 // 4: unik.Event
+	(*BuildImageRequest)(nil),     // 5: unik.BuildImageRequest
+	(*ListImagesRequest)(nil),     // 6: unik.ListImagesRequest
+	(*ListImagesResponse)(nil),    // 7: unik.ListImagesResponse
+	(*GetImageRequest)(nil),       // 8: unik.GetImageRequest
+	(*DeleteImageRequest)(nil),    // 9: unik.DeleteImageRequest
+	(*RestartPolicy)(nil),         // 10: unik.RestartPolicy
+	(*PortMapping)(nil),           // 11: unik.PortMapping
+	(*IPv6Config)(nil),            // 12: unik.IPv6Config
+	(*StaticIPConfig)(nil),        // 13: unik.StaticIPConfig
+	(*RunInstanceRequest)(nil),    // 14: unik.RunInstanceRequest
+	(*ListInstancesRequest)(nil),  // 15: unik.ListInstancesRequest
+	(*ListInstancesResponse)(nil), // 16: unik.ListInstancesResponse
+	(*GetInstanceRequest)(nil),    // 17: unik.GetInstanceRequest
+	(*InstanceIdRequest)(nil),     // 18: unik.InstanceIdRequest
+	(*StopInstanceRequest)(nil),   // 19: unik.StopInstanceRequest
+	(*DeleteInstanceRequest)(nil), // 20: unik.DeleteInstanceRequest
+	(*InstanceLogs)(nil),          // 21: unik.InstanceLogs
+	(*CreateVolumeRequest)(nil),   // 22: unik.CreateVolumeRequest
+	(*ListVolumesRequest)(nil),    // 23: unik.ListVolumesRequest
+	(*ListVolumesResponse)(nil),   // 24: unik.ListVolumesResponse
+	(*GetVolumeRequest)(nil),      // 25: unik.GetVolumeRequest
+	(*DeleteVolumeRequest)(nil),   // 26: unik.DeleteVolumeRequest
+	(*AttachVolumeRequest)(nil),   // 27: unik.AttachVolumeRequest
+	(*DetachVolumeRequest)(nil),   // 28: unik.DetachVolumeRequest
+	(*StreamEventsRequest)(nil),   // 29: unik.StreamEventsRequest
+	nil,                           // 30: unik.Image.TagsEntry
+	nil,                           // 31: unik.Instance.TagsEntry
+	nil,                           // 32: unik.Volume.TagsEntry
+	nil,                           // 33: unik.BuildImageRequest.TagsEntry
+	nil,                           // 34: unik.ListImagesRequest.TagsEntry
+	nil,                           // 35: unik.RunInstanceRequest.MountsEntry
+	nil,                           // 36: unik.RunInstanceRequest.EnvEntry
+	nil,                           // 37: unik.RunInstanceRequest.TagsEntry
+	nil,                           // 38: unik.ListInstancesRequest.TagsEntry
+	nil,                           // 39: unik.CreateVolumeRequest.TagsEntry
+	nil,                           // 40: unik.ListVolumesRequest.TagsEntry
+}
+var file_pkg_daemon_grpc_unik_proto_depIdxs = []int32{
+	30, // 0: unik.Image.tags:type_name -> unik.Image.TagsEntry
+	31, // 1: unik.Instance.tags:type_name -> unik.Instance.TagsEntry
+	32, // 2: unik.Volume.tags:type_name -> unik.Volume.TagsEntry
+	33, // 3: unik.BuildImageRequest.tags:type_name -> unik.BuildImageRequest.TagsEntry
+	34, // 4: unik.ListImagesRequest.tags:type_name -> unik.ListImagesRequest.TagsEntry
+	1,  // 5: unik.ListImagesResponse.images:type_name -> unik.Image
+	35, // 6: unik.RunInstanceRequest.mounts:type_name -> unik.RunInstanceRequest.MountsEntry
+	36, // 7: unik.RunInstanceRequest.env:type_name -> unik.RunInstanceRequest.EnvEntry
+	10, // 8: unik.RunInstanceRequest.restart_policy:type_name -> unik.RestartPolicy
+	37, // 9: unik.RunInstanceRequest.tags:type_name -> unik.RunInstanceRequest.TagsEntry
+	11, // 10: unik.RunInstanceRequest.ports:type_name -> unik.PortMapping
+	12, // 11: unik.RunInstanceRequest.ipv6:type_name -> unik.IPv6Config
+	13, // 12: unik.RunInstanceRequest.static_ip:type_name -> unik.StaticIPConfig
+	38, // 13: unik.ListInstancesRequest.tags:type_name -> unik.ListInstancesRequest.TagsEntry
+	2,  // 14: unik.ListInstancesResponse.instances:type_name -> unik.Instance
+	39, // 15: unik.CreateVolumeRequest.tags:type_name -> unik.CreateVolumeRequest.TagsEntry
+	40, // 16: unik.ListVolumesRequest.tags:type_name -> unik.ListVolumesRequest.TagsEntry
+	3,  // 17: unik.ListVolumesResponse.volumes:type_name -> unik.Volume
+	5,  // 18: unik.Unik.BuildImage:input_type -> unik.BuildImageRequest
+	6,  // 19: unik.Unik.ListImages:input_type -> unik.ListImagesRequest
+	8,  // 20: unik.Unik.GetImage:input_type -> unik.GetImageRequest
+	9,  // 21: unik.Unik.DeleteImage:input_type -> unik.DeleteImageRequest
+	14, // 22: unik.Unik.RunInstance:input_type -> unik.RunInstanceRequest
+	15, // 23: unik.Unik.ListInstances:input_type -> unik.ListInstancesRequest
+	17, // 24: unik.Unik.GetInstance:input_type -> unik.GetInstanceRequest
+	18, // 25: unik.Unik.StartInstance:input_type -> unik.InstanceIdRequest
+	19, // 26: unik.Unik.StopInstance:input_type -> unik.StopInstanceRequest
+	20, // 27: unik.Unik.DeleteInstance:input_type -> unik.DeleteInstanceRequest
+	18, // 28: unik.Unik.GetInstanceLogs:input_type -> unik.InstanceIdRequest
+	22, // 29: unik.Unik.CreateVolume:input_type -> unik.CreateVolumeRequest
+	23, // 30: unik.Unik.ListVolumes:input_type -> unik.ListVolumesRequest
+	25, // 31: unik.Unik.GetVolume:input_type -> unik.GetVolumeRequest
+	26, // 32: unik.Unik.DeleteVolume:input_type -> unik.DeleteVolumeRequest
+	27, // 33: unik.Unik.AttachVolume:input_type -> unik.AttachVolumeRequest
+	28, // 34: unik.Unik.DetachVolume:input_type -> unik.DetachVolumeRequest
+	29, // 35: unik.Unik.StreamEvents:input_type -> unik.StreamEventsRequest
+	1,  // 36: unik.Unik.BuildImage:output_type -> unik.Image
+	7,  // 37: unik.Unik.ListImages:output_type -> unik.ListImagesResponse
+	1,  // 38: unik.Unik.GetImage:output_type -> unik.Image
+	0,  // 39: unik.Unik.DeleteImage:output_type -> unik.Empty
+	2,  // 40: unik.Unik.RunInstance:output_type -> unik.Instance
+	16, // 41: unik.Unik.ListInstances:output_type -> unik.ListInstancesResponse
+	2,  // 42: unik.Unik.GetInstance:output_type -> unik.Instance
+	0,  // 43: unik.Unik.StartInstance:output_type -> unik.Empty
+	0,  // 44: unik.Unik.StopInstance:output_type -> unik.Empty
+	0,  // 45: unik.Unik.DeleteInstance:output_type -> unik.Empty
+	21, // 46: unik.Unik.GetInstanceLogs:output_type -> unik.InstanceLogs
+	3,  // 47: unik.Unik.CreateVolume:output_type -> unik.Volume
+	24, // 48: unik.Unik.ListVolumes:output_type -> unik.ListVolumesResponse
+	3,  // 49: unik.Unik.GetVolume:output_type -> unik.Volume
+	0,  // 50: unik.Unik.DeleteVolume:output_type -> unik.Empty
+	0,  // 51: unik.Unik.AttachVolume:output_type -> unik.Empty
+	0,  // 52: unik.Unik.DetachVolume:output_type -> unik.Empty
+	4,  // 53: unik.Unik.StreamEvents:output_type -> unik.Event
+	36, // [36:54] is the sub-list for method output_type
+	18, // [18:36] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_pkg_daemon_grpc_unik_proto_init() }
+func file_pkg_daemon_grpc_unik_proto_init() {
+	if File_pkg_daemon_grpc_unik_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_daemon_grpc_unik_proto_rawDesc), len(file_pkg_daemon_grpc_unik_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   41,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_daemon_grpc_unik_proto_goTypes,
+		DependencyIndexes: file_pkg_daemon_grpc_unik_proto_depIdxs,
+		MessageInfos:      file_pkg_daemon_grpc_unik_proto_msgTypes,
+	}.Build()
+	File_pkg_daemon_grpc_unik_proto = out.File
+	file_pkg_daemon_grpc_unik_proto_goTypes = nil
+	file_pkg_daemon_grpc_unik_proto_depIdxs = nil
+}
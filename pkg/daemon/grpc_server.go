@@ -0,0 +1,606 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikgrpc "github.com/emc-advanced-dev/unik/pkg/daemon/grpc"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcApi serves the gRPC api of pkg/daemon/grpc. calls are made to the same
+// functions as the requests of the REST api, and go through intercept, so both
+// apis share authentication, quotas, the audit log and the requests waited for
+// on shutdown
+type grpcApi struct {
+	unikgrpc.UnimplementedUnikServer
+	d *UnikDaemon
+}
+
+func newGrpcServer(d *UnikDaemon) *grpc.Server {
+	api := &grpcApi{d: d}
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(types.GrpcMaxMessageSize),
+		grpc.MaxSendMsgSize(types.GrpcMaxMessageSize),
+		grpc.UnaryInterceptor(api.intercept),
+		grpc.StreamInterceptor(api.interceptStream),
+	)
+	unikgrpc.RegisterUnikServer(server, api)
+	return server
+}
+
+// serveGrpc serves the gRPC api on addr until the daemon stops
+func (d *UnikDaemon) serveGrpc(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.New("listening on "+addr, err)
+	}
+	server := newGrpcServer(d)
+	go func() {
+		<-d.done
+		server.GracefulStop()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logrus.WithError(err).Errorf("running grpc server")
+		}
+	}()
+	logrus.Infof("serving grpc on %s", listener.Addr())
+	return nil
+}
+
+// grpcUserKey is the context key of the user a call is made for
+type grpcUserKey struct{}
+
+// grpcUser returns the user the call of ctx is made for, as requestUser does for
+// REST requests
+func grpcUser(ctx context.Context) string {
+	user, _ := ctx.Value(grpcUserKey{}).(string)
+	return user
+}
+
+// user tells who the call of ctx is made for, from its UserHeader and
+// authorization metadata
+func (a *grpcApi) user(ctx context.Context) (string, bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return a.d.users.userOf(firstMetadata(md, "authorization"), firstMetadata(md, types.UserHeader))
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func grpcRemote(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
+
+// intercept does for calls what the middleware of the REST api does for
+// requests: it turns calls without a valid user token away, counts the calls in
+// flight until the daemon shuts down and writes the audit log
+func (a *grpcApi) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	user, authenticated, err := a.user(ctx)
+	var reply interface{}
+	if err != nil {
+		logrus.WithError(err).Warnf("refusing grpc call %s from %s", info.FullMethod, grpcRemote(ctx))
+		err = status.Error(codes.Unauthenticated, err.Error())
+	} else if a.d.requests.stopping() {
+		err = status.Error(codes.Unavailable, "daemon is shutting down")
+	} else {
+		a.d.requests.inFlight.Add(1)
+		reply, err = handler(context.WithValue(ctx, grpcUserKey{}, user), req)
+		a.d.requests.inFlight.Done()
+	}
+	if a.d.audit != nil && !grpcReadOnly(info.FullMethod) {
+		record := &AuditRecord{
+			Timestamp:  start.UTC(),
+			Method:     grpcAuditMethod,
+			Path:       info.FullMethod,
+			GrpcCode:   status.Code(err).String(),
+			RemoteAddr: grpcRemote(ctx),
+			DurationMs: int64(time.Since(start) / time.Millisecond),
+		}
+		if authenticated {
+			record.User = user
+		} else {
+			md, _ := metadata.FromIncomingContext(ctx)
+			record.ClaimedUser = firstMetadata(md, types.UserHeader)
+		}
+		if message, ok := req.(proto.Message); ok {
+			if data, err := proto.Marshal(message); err == nil && len(data) > 0 {
+				hash := sha256.Sum256(data)
+				record.RequestBodyHash = hex.EncodeToString(hash[:])
+			}
+		}
+		if err := a.d.audit.write(record); err != nil {
+			logrus.WithError(err).Errorf("failed to write audit record for grpc call %s", info.FullMethod)
+		}
+	}
+	return reply, err
+}
+
+// interceptStream authenticates streams like intercept does calls. streams last
+// until the client goes away, so they are not waited for on shutdown, nor audited
+// (like GET /events)
+func (a *grpcApi) interceptStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, _, err := a.user(stream.Context()); err != nil {
+		logrus.WithError(err).Warnf("refusing grpc call %s from %s", info.FullMethod, grpcRemote(stream.Context()))
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if a.d.requests.stopping() {
+		return status.Error(codes.Unavailable, "daemon is shutting down")
+	}
+	return handler(srv, stream)
+}
+
+// grpcReadOnly reports whether the call named fullMethod only reads, like the
+// GET requests that are left out of the audit log
+func grpcReadOnly(fullMethod string) bool {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	return strings.HasPrefix(name, "List") || strings.HasPrefix(name, "Get")
+}
+
+// grpcError is the gRPC status error of err, which the REST api answers with httpStatus
+func grpcError(httpStatus int, err error) error {
+	if _, ok := err.(*types.QuotaExceeded); ok {
+		httpStatus = http.StatusTooManyRequests
+	}
+	return status.Error(grpcCode(httpStatus), err.Error())
+}
+
+// grpcCode is the gRPC status of a REST status that is not the expected one
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if httpStatus >= 500 {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// grpcQuery holds the parameters of a call under the names of the REST api, for
+// the functions that take them as the form of a request. empty values are left out
+type grpcQuery url.Values
+
+func (q grpcQuery) set(key string, value interface{}) {
+	str := fmt.Sprintf("%v", value)
+	if str == "" || str == "0" || str == "false" {
+		return
+	}
+	url.Values(q).Set(key, str)
+}
+
+func (q grpcQuery) setTags(tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	tagsJson, err := json.Marshal(tags)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	url.Values(q).Set("tags", string(tagsJson))
+	return nil
+}
+
+// addTags selects resources with all of tags, as ?tag=key=value
+func (q grpcQuery) addTags(tags map[string]string) {
+	for key, value := range tags {
+		url.Values(q).Add("tag", key+"="+value)
+	}
+}
+
+func grpcImage(image *types.Image) (*unikgrpc.Image, error) {
+	data, err := json.Marshal(image)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &unikgrpc.Image{
+		Id:             image.Id,
+		Name:           image.Name,
+		SizeMb:         image.SizeMb,
+		Infrastructure: string(image.Infrastructure),
+		CreatedUnix:    image.Created.Unix(),
+		Tags:           image.Tags,
+		Architecture:   string(image.Architecture),
+		Json:           data,
+	}, nil
+}
+
+func grpcInstance(instance *types.Instance) (*unikgrpc.Instance, error) {
+	data, err := json.Marshal(instance)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var expiresAt int64
+	if !instance.ExpiresAt.IsZero() {
+		expiresAt = instance.ExpiresAt.Unix()
+	}
+	return &unikgrpc.Instance{
+		Id:             instance.Id,
+		Name:           instance.Name,
+		State:          string(instance.State),
+		IpAddress:      instance.IpAddress,
+		ImageId:        instance.ImageId,
+		Infrastructure: string(instance.Infrastructure),
+		CreatedUnix:    instance.Created.Unix(),
+		RestartCount:   int32(instance.RestartCount),
+		ExpiresAtUnix:  expiresAt,
+		Tags:           instance.Tags,
+		Group:          instance.Group,
+		Json:           data,
+	}, nil
+}
+
+func grpcVolume(volume *types.Volume) (*unikgrpc.Volume, error) {
+	data, err := json.Marshal(volume)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &unikgrpc.Volume{
+		Id:             volume.Id,
+		Name:           volume.Name,
+		SizeMb:         volume.SizeMb,
+		Attachment:     volume.Attachment,
+		Infrastructure: string(volume.Infrastructure),
+		CreatedUnix:    volume.Created.Unix(),
+		Tags:           volume.Tags,
+		Mode:           string(volume.Mode),
+		Json:           data,
+	}, nil
+}
+
+func (a *grpcApi) BuildImage(ctx context.Context, req *unikgrpc.BuildImageRequest) (*unikgrpc.Image, error) {
+	query := grpcQuery{}
+	if err := query.setTags(req.Tags); err != nil {
+		return nil, err
+	}
+	query.set("base", req.Base)
+	query.set("lang", req.Lang)
+	query.set("provider", req.Provider)
+	query.set("args", req.Args)
+	query.set("mounts", strings.Join(req.MountPoints, ","))
+	query.set("force", req.Force)
+	query.set("no_cleanup", req.NoCleanup)
+	query.set("arch", req.Arch)
+	query.set("squash", req.Squash)
+	query.set("memory", req.MemoryMb)
+	query.set("vcpus", req.Vcpus)
+	query.set("network_mode", req.NetworkMode)
+	query.set("base_image", req.BaseImage)
+	query.set("target", req.Target)
+	if req.TimeoutMs > 0 {
+		query.set("timeout", time.Duration(req.TimeoutMs)*time.Millisecond)
+	}
+	result, code, err := a.d.serveBuildImage(grpcUser(ctx), req.Name, url.Values(query), bytes.NewReader(req.Sources))
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	image, ok := result.(*types.Image)
+	if !ok {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("build returned %T instead of an image", result))
+	}
+	return grpcImage(image)
+}
+
+func (a *grpcApi) ListImages(ctx context.Context, req *unikgrpc.ListImagesRequest) (*unikgrpc.ListImagesResponse, error) {
+	query := grpcQuery{}
+	query.addTags(req.Tags)
+	tags, err := tagFilter(url.Values(query))
+	if err != nil {
+		return nil, grpcError(http.StatusBadRequest, err)
+	}
+	images, code, err := a.d.serveListImages(tags)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	response := &unikgrpc.ListImagesResponse{}
+	for _, image := range images {
+		converted, err := grpcImage(image)
+		if err != nil {
+			return nil, err
+		}
+		response.Images = append(response.Images, converted)
+	}
+	return response, nil
+}
+
+func (a *grpcApi) GetImage(ctx context.Context, req *unikgrpc.GetImageRequest) (*unikgrpc.Image, error) {
+	image, code, err := a.d.serveGetImage(req.NameOrId)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	return grpcImage(image)
+}
+
+func (a *grpcApi) DeleteImage(ctx context.Context, req *unikgrpc.DeleteImageRequest) (*unikgrpc.Empty, error) {
+	if code, err := a.d.serveDeleteImage(req.NameOrId, req.Force); err != nil {
+		return nil, grpcError(code, err)
+	}
+	return &unikgrpc.Empty{}, nil
+}
+
+func (a *grpcApi) RunInstance(ctx context.Context, req *unikgrpc.RunInstanceRequest) (*unikgrpc.Instance, error) {
+	runRequest := types.RunInstanceRequest{
+		InstanceName:   req.InstanceName,
+		ImageName:      req.ImageName,
+		Mounts:         req.Mounts,
+		Env:            req.Env,
+		MemoryMb:       int(req.MemoryMb),
+		VCPUs:          int(req.Vcpus),
+		NetworkMode:    types.NetworkMode(req.NetworkMode),
+		NoCleanup:      req.NoCleanup,
+		DebugMode:      req.DebugMode,
+		Ttl:            time.Duration(req.TtlMs) * time.Millisecond,
+		Tags:           req.Tags,
+		Cmdline:        req.Cmdline,
+		CmdlineMode:    unikos.CmdlineMode(req.CmdlineMode),
+		ReadOnlyMounts: req.ReadOnlyMounts,
+		UserData:       req.UserData,
+		MetaData:       req.MetaData,
+		Group:          req.Group,
+		VolumeMode:     types.VolumeMode(req.VolumeMode),
+	}
+	if policy := req.RestartPolicy; policy != nil {
+		runRequest.RestartPolicy = &types.RestartPolicy{
+			Mode:           types.RestartMode(policy.Mode),
+			MaxRestarts:    int(policy.MaxRestarts),
+			BackoffSeconds: int(policy.BackoffSeconds),
+		}
+	}
+	for _, port := range req.Ports {
+		runRequest.Ports = append(runRequest.Ports, types.PortMapping{HostPort: int(port.HostPort), GuestPort: int(port.GuestPort), Protocol: port.Protocol})
+	}
+	if ipv6 := req.Ipv6; ipv6 != nil {
+		runRequest.IPv6 = &types.IPv6Config{Address: ipv6.Address, PrefixLength: int(ipv6.PrefixLength), Gateway: ipv6.Gateway}
+	}
+	if staticIP := req.StaticIp; staticIP != nil {
+		runRequest.StaticIP = &types.StaticIPConfig{Address: staticIP.Address, Gateway: staticIP.Gateway, DNS: staticIP.Dns}
+	}
+	instance, code, err := a.d.serveRunInstance(grpcUser(ctx), runRequest)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	return grpcInstance(instance)
+}
+
+func (a *grpcApi) ListInstances(ctx context.Context, req *unikgrpc.ListInstancesRequest) (*unikgrpc.ListInstancesResponse, error) {
+	query := grpcQuery{}
+	query.addTags(req.Tags)
+	query.set("image", req.Image)
+	query.set("provider", req.Provider)
+	query.set("state", strings.Join(req.States, ","))
+	if req.CreatedAfterUnix != 0 {
+		query.set("created_after", time.Unix(req.CreatedAfterUnix, 0).Format(time.RFC3339))
+	}
+	if req.CreatedBeforeUnix != 0 {
+		query.set("created_before", time.Unix(req.CreatedBeforeUnix, 0).Format(time.RFC3339))
+	}
+	query.set("group", req.Group)
+	query.set("sort", req.Sort)
+	query.set("order", req.Order)
+	query.set("limit", req.Limit)
+	query.set("offset", req.Offset)
+	page, err := parseListPage(url.Values(query), instanceSortFields)
+	if err != nil {
+		return nil, grpcError(http.StatusBadRequest, errors.New("invalid page", err))
+	}
+	filter, err := parseInstanceFilter(url.Values(query))
+	if err != nil {
+		return nil, grpcError(http.StatusBadRequest, errors.New("invalid instance filter", err))
+	}
+	instances, total, code, err := a.d.serveListInstances(page, filter)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	response := &unikgrpc.ListInstancesResponse{Total: int32(total)}
+	for _, instance := range instances {
+		converted, err := grpcInstance(instance)
+		if err != nil {
+			return nil, err
+		}
+		response.Instances = append(response.Instances, converted)
+	}
+	return response, nil
+}
+
+func (a *grpcApi) GetInstance(ctx context.Context, req *unikgrpc.GetInstanceRequest) (*unikgrpc.Instance, error) {
+	instance, code, err := a.d.serveGetInstance(req.NameOrId)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	return grpcInstance(instance)
+}
+
+func (a *grpcApi) StartInstance(ctx context.Context, req *unikgrpc.InstanceIdRequest) (*unikgrpc.Empty, error) {
+	if code, err := a.d.serveStartInstance(req.InstanceId); err != nil {
+		return nil, grpcError(code, err)
+	}
+	return &unikgrpc.Empty{}, nil
+}
+
+func (a *grpcApi) StopInstance(ctx context.Context, req *unikgrpc.StopInstanceRequest) (*unikgrpc.Empty, error) {
+	if code, err := a.d.serveStopInstance(req.InstanceId, time.Duration(req.TimeoutMs)*time.Millisecond); err != nil {
+		return nil, grpcError(code, err)
+	}
+	return &unikgrpc.Empty{}, nil
+}
+
+func (a *grpcApi) DeleteInstance(ctx context.Context, req *unikgrpc.DeleteInstanceRequest) (*unikgrpc.Empty, error) {
+	if code, err := a.d.serveDeleteInstance(req.InstanceId, req.Force); err != nil {
+		return nil, grpcError(code, err)
+	}
+	return &unikgrpc.Empty{}, nil
+}
+
+func (a *grpcApi) GetInstanceLogs(ctx context.Context, req *unikgrpc.InstanceIdRequest) (*unikgrpc.InstanceLogs, error) {
+	logs, code, err := a.d.serveInstanceLogs(req.InstanceId)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	return &unikgrpc.InstanceLogs{Logs: logs}, nil
+}
+
+func (a *grpcApi) CreateVolume(ctx context.Context, req *unikgrpc.CreateVolumeRequest) (*unikgrpc.Volume, error) {
+	query := grpcQuery{}
+	if err := query.setTags(req.Tags); err != nil {
+		return nil, err
+	}
+	// the size is read even if it is 0
+	url.Values(query).Set("size", strconv.Itoa(int(req.SizeMb)))
+	query.set("provider", req.Provider)
+	query.set("type", req.Type)
+	query.set("volume_mode", req.Mode)
+	query.set("partition_table", req.PartitionTable)
+	query.set("no_cleanup", req.NoCleanup)
+	query.set("raw", req.Raw)
+	var data io.Reader
+	if len(req.Data) > 0 {
+		data = bytes.NewReader(req.Data)
+	}
+	volume, code, err := a.d.serveCreateVolume(grpcUser(ctx), req.Name, url.Values(query), data)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	return grpcVolume(volume)
+}
+
+func (a *grpcApi) ListVolumes(ctx context.Context, req *unikgrpc.ListVolumesRequest) (*unikgrpc.ListVolumesResponse, error) {
+	query := grpcQuery{}
+	query.set("provider", req.Provider)
+	query.set("attached", req.Attached)
+	query.set("unattached", req.Unattached)
+	query.set("name_contains", req.NameContains)
+	query.addTags(req.Tags)
+	query.set("size_gt", req.SizeGt)
+	query.set("size_lt", req.SizeLt)
+	query.set("sort", req.Sort)
+	query.set("order", req.Order)
+	filter, err := parseVolumeFilter(url.Values(query))
+	if err != nil {
+		return nil, grpcError(http.StatusBadRequest, errors.New("invalid volume filter", err))
+	}
+	page, err := parseListPage(url.Values(query), volumeSortFields)
+	if err != nil {
+		return nil, grpcError(http.StatusBadRequest, errors.New("invalid sort", err))
+	}
+	volumes, code, err := a.d.serveListVolumes(page, filter)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	response := &unikgrpc.ListVolumesResponse{}
+	for _, volume := range volumes {
+		converted, err := grpcVolume(volume)
+		if err != nil {
+			return nil, err
+		}
+		response.Volumes = append(response.Volumes, converted)
+	}
+	return response, nil
+}
+
+func (a *grpcApi) GetVolume(ctx context.Context, req *unikgrpc.GetVolumeRequest) (*unikgrpc.Volume, error) {
+	volume, code, err := a.d.serveGetVolume(req.NameOrId)
+	if err != nil {
+		return nil, grpcError(code, err)
+	}
+	return grpcVolume(volume)
+}
+
+func (a *grpcApi) DeleteVolume(ctx context.Context, req *unikgrpc.DeleteVolumeRequest) (*unikgrpc.Empty, error) {
+	if code, err := a.d.serveDeleteVolume(req.NameOrId, req.Force); err != nil {
+		return nil, grpcError(code, err)
+	}
+	return &unikgrpc.Empty{}, nil
+}
+
+func (a *grpcApi) AttachVolume(ctx context.Context, req *unikgrpc.AttachVolumeRequest) (*unikgrpc.Empty, error) {
+	if code, err := a.d.serveAttachVolume(req.Volume, req.InstanceId, req.MountPoint); err != nil {
+		return nil, grpcError(code, err)
+	}
+	return &unikgrpc.Empty{}, nil
+}
+
+func (a *grpcApi) DetachVolume(ctx context.Context, req *unikgrpc.DetachVolumeRequest) (*unikgrpc.Empty, error) {
+	if code, err := a.d.serveDetachVolume(req.Volume); err != nil {
+		return nil, grpcError(code, err)
+	}
+	return &unikgrpc.Empty{}, nil
+}
+
+// StreamEvents sends the events published from now on, like GET /events, until
+// the client goes away or the daemon stops
+func (a *grpcApi) StreamEvents(req *unikgrpc.StreamEventsRequest, stream unikgrpc.Unik_StreamEventsServer) error {
+	events, unsubscribe := a.d.bus.subscribe()
+	defer unsubscribe()
+	remote := grpcRemote(stream.Context())
+	logrus.WithField("remote", remote).Infof("grpc client subscribed to events")
+	defer logrus.WithField("remote", remote).Infof("grpc client unsubscribed from events")
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-a.d.done:
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			payload, err := json.Marshal(event.Payload)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			if err := stream.Send(&unikgrpc.Event{
+				Type:          string(event.Type),
+				Id:            event.Id,
+				TimestampUnix: event.Timestamp.Unix(),
+				Payload:       payload,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	unikgrpc "github.com/emc-advanced-dev/unik/pkg/daemon/grpc"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var _ = Describe("grpc api", func() {
+	var (
+		dir      string
+		d        *UnikDaemon
+		provider *fakeProvider
+		server   *grpc.Server
+		conn     *grpc.ClientConn
+		api      unikgrpc.UnikClient
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.grpc.")
+		Expect(err).NotTo(HaveOccurred())
+		provider = newFakeProvider(dir)
+		d = &UnikDaemon{
+			providers: providers.Providers{"fake": provider},
+			bus:       newEventBus(),
+			requests:  &requestTracker{},
+			done:      make(chan struct{}),
+			users:     newUserAuthenticator(""),
+		}
+		d.quotas = newQuotaManager(config.QuotaConfig{}, state.NewBasicState(filepath.Join(dir, "daemon-state.json")), d.providers)
+		d.monitor = newInstanceMonitor(d.providers, d.notify)
+
+		listener := bufconn.Listen(1 << 20)
+		server = newGrpcServer(d)
+		go server.Serve(listener)
+		conn, err = grpc.NewClient("passthrough:///bufconn",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		api = unikgrpc.NewUnikClient(conn)
+	})
+	AfterEach(func() {
+		conn.Close()
+		server.Stop()
+		os.RemoveAll(dir)
+	})
+
+	createVolume := func(name string) *types.Volume {
+		image := filepath.Join(dir, name+".data")
+		Expect(ioutil.WriteFile(image, make([]byte, 1<<20), 0644)).To(Succeed())
+		volume, err := provider.CreateVolume(types.CreateVolumeParams{Name: name, ImagePath: image})
+		Expect(err).NotTo(HaveOccurred())
+		return volume
+	}
+
+	It("should answer with what the daemon returns", func() {
+		volume := createVolume("data")
+		response, err := api.ListVolumes(context.Background(), &unikgrpc.ListVolumesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Volumes).To(HaveLen(1))
+		Expect(response.Volumes[0].Id).To(Equal(volume.Id))
+		Expect(response.Volumes[0].Name).To(Equal("data"))
+		var listed types.Volume
+		Expect(json.Unmarshal(response.Volumes[0].Json, &listed)).To(Succeed())
+		Expect(listed.Id).To(Equal(volume.Id))
+
+		got, err := api.GetVolume(context.Background(), &unikgrpc.GetVolumeRequest{NameOrId: "data"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.Id).To(Equal(volume.Id))
+	})
+
+	It("should do what the REST api does", func() {
+		Expect(provider.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+			instances["instance-id"] = &types.Instance{Id: "instance-id", Name: "app", State: types.InstanceState_Stopped}
+			return nil
+		})).To(Succeed())
+		_, err := api.StartInstance(context.Background(), &unikgrpc.InstanceIdRequest{InstanceId: "instance-id"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(provider.started).To(Equal([]string{"instance-id"}))
+
+		instance, err := api.GetInstance(context.Background(), &unikgrpc.GetInstanceRequest{NameOrId: "app"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance.State).To(Equal(string(types.InstanceState_Running)))
+	})
+
+	It("should return errors with the status closest to their http status", func() {
+		_, err := api.CreateVolume(context.Background(), &unikgrpc.CreateVolumeRequest{Name: "empty", Provider: "fake"})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(status.Convert(err).Message()).To(ContainSubstring("size of an empty volume must be larger than zero"))
+
+		_, err = api.GetVolume(context.Background(), &unikgrpc.GetVolumeRequest{NameOrId: "missing"})
+		Expect(status.Code(err)).To(Equal(codes.Internal))
+	})
+
+	It("should turn away calls without a valid user token", func() {
+		d.users = newUserAuthenticator("secret")
+		_, err := api.ListVolumes(context.Background(), &unikgrpc.ListVolumesRequest{})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		ctx := metadata.AppendToOutgoingContext(context.Background(), types.UserHeader, "alice")
+		_, err = api.ListVolumes(ctx, &unikgrpc.ListVolumesRequest{})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		token, err := NewUserToken("secret", "alice", 0)
+		Expect(err).NotTo(HaveOccurred())
+		ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+		_, err = api.ListVolumes(ctx, &unikgrpc.ListVolumesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should audit the calls that change something, for the user of the token", func() {
+		auditPath := filepath.Join(dir, "audit.log")
+		audit, err := newAuditLog(config.AuditLogConfig{Path: auditPath})
+		Expect(err).NotTo(HaveOccurred())
+		d.audit = audit
+		d.users = newUserAuthenticator("secret")
+		token, err := NewUserToken("secret", "alice", 0)
+		Expect(err).NotTo(HaveOccurred())
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token, types.UserHeader, "mallory")
+
+		_, err = api.ListVolumes(ctx, &unikgrpc.ListVolumesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = api.DeleteVolume(ctx, &unikgrpc.DeleteVolumeRequest{NameOrId: "missing"})
+		Expect(err).To(HaveOccurred())
+
+		data, err := ioutil.ReadFile(auditPath)
+		Expect(err).NotTo(HaveOccurred())
+		var record AuditRecord
+		Expect(json.Unmarshal(data, &record)).To(Succeed())
+		Expect(record.User).To(Equal("alice"))
+		Expect(record.ClaimedUser).To(BeEmpty())
+		Expect(record.Method).To(Equal(grpcAuditMethod))
+		Expect(record.Path).To(Equal("/unik.Unik/DeleteVolume"))
+		Expect(record.GrpcCode).To(Equal(codes.Internal.String()))
+		Expect(record.RequestBodyHash).NotTo(BeEmpty())
+	})
+
+	Describe("StreamEvents", func() {
+		subscribers := func() int {
+			d.bus.lock.RLock()
+			defer d.bus.lock.RUnlock()
+			return len(d.bus.subscribers)
+		}
+
+		It("should send the events published after it is called", func() {
+			stream, err := api.StreamEvents(context.Background(), &unikgrpc.StreamEventsRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(subscribers).Should(Equal(1))
+			d.notify(types.NewVolumeEvent(types.EventType_VolumeAttached, &types.Volume{Id: "vol-id", Name: "data"}))
+
+			event, err := stream.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.Type).To(Equal(string(types.EventType_VolumeAttached)))
+			Expect(event.Id).To(Equal("vol-id"))
+			var volume types.Volume
+			Expect(json.Unmarshal(event.Payload, &volume)).To(Succeed())
+			Expect(volume.Name).To(Equal("data"))
+		})
+
+		It("should end the stream when the daemon stops", func() {
+			stream, err := api.StreamEvents(context.Background(), &unikgrpc.StreamEventsRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(subscribers).Should(Equal(1))
+			close(d.done)
+			_, err = stream.Recv()
+			Expect(err).To(Equal(io.EOF))
+			Eventually(subscribers).Should(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// Version of the daemon, reported by /healthz. release builds set it with
+// -ldflags "-X github.com/emc-advanced-dev/unik/pkg/daemon.Version=..."
+var Version = "dev"
+
+// checkHealth reports the daemon as unhealthy if no provider is configured or the
+// state of one cannot be read. ready additionally needs working loop devices,
+// which the daemon mounts images and volumes with
+func (d *UnikDaemon) checkHealth(ready bool) (*types.HealthStatus, int) {
+	status := &types.HealthStatus{Status: "ok", Version: Version, Errors: make(map[string]string)}
+	if len(d.providers) == 0 {
+		status.Errors["providers"] = "no providers are configured"
+	}
+	for name, provider := range d.providers {
+		if err := provider.GetState().Check(); err != nil {
+			status.Errors[name] = err.Error()
+		}
+	}
+	if ready {
+		if err := unikos.CheckLoopDevices(); err != nil {
+			status.Errors["loop_devices"] = err.Error()
+		}
+	}
+	if len(status.Errors) > 0 {
+		status.Status = "unavailable"
+		return status, http.StatusServiceUnavailable
+	}
+	return status, http.StatusOK
+}
+
+// status counts the resources in the state of each provider; providers are
+// not asked for theirs, so this stays quick
+func (d *UnikDaemon) status() *types.DaemonStatus {
+	status := &types.DaemonStatus{
+		Version:       Version,
+		Started:       d.started,
+		UptimeSeconds: int64(time.Since(d.started).Seconds()),
+		Providers:     make(map[string]types.ResourceCounts),
+		StateDir:      config.Internal.UnikHome,
+		FreeDiskBytes: -1,
+		Goroutines:    runtime.NumGoroutine(),
+	}
+	for name, provider := range d.providers {
+		state := provider.GetState()
+		status.Providers[name] = types.ResourceCounts{
+			Instances: len(state.GetInstances()),
+			Volumes:   len(state.GetVolumes()),
+			Images:    len(state.GetImages()),
+		}
+	}
+	if freeBytes, err := freeDiskBytes(config.Internal.UnikHome); err == nil {
+		status.FreeDiskBytes = freeBytes
+	}
+	return status
+}
@@ -0,0 +1,580 @@
+package daemon
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// diffImages compares the root filesystems of two images, which may belong to
+// different providers. both images are mounted read-only while they are compared.
+func (d *UnikDaemon) diffImages(fromImage, toImage string) (*types.ImageDiff, error) {
+	tmpDir, err := ioutil.TempDir("", "unik.image-diff.")
+	if err != nil {
+		return nil, errors.New("creating temporary directory", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fromDir, releaseFrom, err := d.mountImage(fromImage, filepath.Join(tmpDir, "from.img"))
+	if err != nil {
+		return nil, err
+	}
+	defer releaseFrom()
+	toDir, releaseTo, err := d.mountImage(toImage, filepath.Join(tmpDir, "to.img"))
+	if err != nil {
+		return nil, err
+	}
+	defer releaseTo()
+
+	changes, err := unikos.DiffDirs(fromDir, toDir)
+	if err != nil {
+		return nil, errors.New("comparing contents of "+fromImage+" and "+toImage, err)
+	}
+	diff := &types.ImageDiff{From: fromImage, To: toImage, Changes: changes}
+	for _, change := range changes {
+		diff.SizeDelta += change.SizeDelta
+	}
+	return diff, nil
+}
+
+// mountImage mounts the boot disk of an image read-only. images that aren't raw
+// disks are converted to rawFile first.
+func (d *UnikDaemon) mountImage(imageName, rawFile string) (string, func(), error) {
+	provider, err := d.providers.ProviderForImage(imageName)
+	if err != nil {
+		return "", nil, err
+	}
+	localImages, ok := provider.(providers.LocalImageProvider)
+	if !ok {
+		return "", nil, errors.New("images of this provider are not stored on the daemon host, cannot inspect "+imageName, nil)
+	}
+	imageFile, format, err := localImages.GetImageFile(imageName)
+	if err != nil {
+		return "", nil, err
+	}
+	if format == types.ImageFormat_Kernel {
+		return "", nil, errors.New("image "+imageName+" is a kernel booted without a boot disk, cannot inspect it", nil)
+	}
+	if format != types.ImageFormat_RAW {
+		logrus.Debugf("converting %s image %s to raw", format, imageName)
+		if err := common.ConvertRawImage(format, types.ImageFormat_RAW, imageFile, rawFile); err != nil {
+			return "", nil, errors.New("converting "+imageName+" to raw", err)
+		}
+		imageFile = rawFile
+	}
+	mntpoint, release, err := unikos.MountImageReadOnly(imageFile)
+	if err != nil {
+		return "", nil, errors.New("mounting image "+imageName, err)
+	}
+	return mntpoint, func() {
+		if err := release(); err != nil {
+			logrus.WithError(err).Warnf("failed to unmount image %s", imageName)
+		}
+	}, nil
+}
+
+// mergeBaseImage copies the files of the boot disk of a base image into sourcesDir,
+// as a layer under the sources uploaded for the image built on it: files of the
+// sources replace those of the base image
+func (d *UnikDaemon) mergeBaseImage(baseImage, sourcesDir string) error {
+	tmpDir, err := ioutil.TempDir("", "unik.base-image.")
+	if err != nil {
+		return errors.New("creating temporary directory", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	baseDir, release, err := d.mountImage(baseImage, filepath.Join(tmpDir, "base.img"))
+	if err != nil {
+		return err
+	}
+	defer release()
+	copied, err := unikos.MergeDir(baseDir, sourcesDir)
+	if err != nil {
+		return errors.New("copying contents of base image "+baseImage, err)
+	}
+	logrus.WithFields(logrus.Fields{"base-image": baseImage, "files": len(copied)}).Debugf("copied files of base image into sources")
+	return nil
+}
+
+// inheritedMountPoints returns the mount points of a base image (other than its
+// root) that mountPoints lacks, after mountPoints
+func inheritedMountPoints(baseImage *types.Image, mountPoints []string) []string {
+	merged := append([]string{}, mountPoints...)
+	have := make(map[string]bool)
+	for _, mntPoint := range mountPoints {
+		have[mntPoint] = true
+	}
+	for _, mapping := range baseImage.RunSpec.DeviceMappings {
+		if mapping.MountPoint == "/" || have[mapping.MountPoint] {
+			continue
+		}
+		have[mapping.MountPoint] = true
+		merged = append(merged, mapping.MountPoint)
+	}
+	return merged
+}
+
+// imageNameTaken reports whether an image of any provider, or a manifest, is named
+// (or, for images named by their id, identified by) name
+func (d *UnikDaemon) imageNameTaken(name string) (bool, error) {
+	for _, provider := range d.providers {
+		images, err := provider.ListImages()
+		if err != nil {
+			return false, errors.New("listing images", err)
+		}
+		for _, image := range images {
+			if image.Name == name || image.Id == name {
+				return true, nil
+			}
+		}
+	}
+	if _, err := d.getManifest(name); err == nil {
+		return true, nil
+	}
+	return false, nil
+}
+
+// renameImage renames an image on its provider, and updates what refers to it: the
+// quota usage and the images built on it by name, and if the id changed with the
+// name, the instances run from it, the manifests listing it and its signature
+func (d *UnikDaemon) renameImage(provider providers.Provider, imageName, newName string) (*types.Image, error) {
+	renamer, ok := provider.(providers.ImageRenamer)
+	if !ok {
+		return nil, errors.New("the provider of image "+imageName+" cannot rename images", nil)
+	}
+	image, err := provider.GetImage(imageName)
+	if err != nil {
+		return nil, err
+	}
+	oldId, oldName := image.Id, image.Name
+	renamed, err := renamer.RenameImage(oldId, newName)
+	if err != nil {
+		return nil, errors.New("renaming image "+oldName, err)
+	}
+	d.quotas.renameImage(provider, oldName, renamed.Name)
+	if err := provider.GetState().ModifyImages(func(images map[string]*types.Image) error {
+		for _, image := range images {
+			if image.BaseImage == oldName {
+				image.BaseImage = newName
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.New("updating images built on "+oldName, err)
+	}
+	if renamed.Id == oldId {
+		return renamed, nil
+	}
+	if err := provider.GetState().ModifyInstances(func(instances map[string]*types.Instance) error {
+		for _, instance := range instances {
+			if instance.ImageId == oldId {
+				instance.ImageId = renamed.Id
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.New("updating instances of image "+oldName, err)
+	}
+	if err := d.renameManifestImage(oldId, renamed.Id); err != nil {
+		return nil, errors.New("updating manifests listing image "+oldName, err)
+	}
+	if err := d.renameImageSignature(oldId, renamed); err != nil {
+		return nil, errors.New("moving signature of image "+oldName, err)
+	}
+	return renamed, nil
+}
+
+// stageImage stages an image on provider for user, within their quota
+func (d *UnikDaemon) stageImage(provider providers.Provider, user string, params types.StageImageParams) (*types.Image, error) {
+	sizeMb, err := fileSizeMb(params.RawImage.LocalImagePath)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := d.quotas.reserveImage(user, provider, params.Name, sizeMb)
+	if err != nil {
+		return nil, err
+	}
+	image, err := provider.Stage(params)
+	if err != nil {
+		d.quotas.cancel(reservation)
+		return nil, err
+	}
+	d.quotas.commit(reservation)
+	return image, nil
+}
+
+// pullImage pulls an image to provider for user. the size of the image is only
+// known once it is pulled, so an image that takes user over their quota is deleted again
+func (d *UnikDaemon) pullImage(provider providers.Provider, user string, params types.PullImagePararms) error {
+	if err := provider.PullImage(params); err != nil {
+		return err
+	}
+	image, err := provider.GetImage(params.ImageName)
+	if err != nil {
+		return errors.New("retrieving pulled image "+params.ImageName, err)
+	}
+	reservation, err := d.quotas.reserveImage(user, provider, image.Name, image.SizeMb)
+	if err != nil {
+		if deleteErr := provider.DeleteImage(image.Id, true); deleteErr != nil {
+			logrus.WithError(deleteErr).Warnf("failed to delete image %s pulled beyond the quota of %s", image.Name, user)
+		}
+		return err
+	}
+	d.quotas.commit(reservation)
+	return nil
+}
+
+// modifyImage applies modify to the image with imageId in the state of provider
+// and returns the updated image
+func modifyImage(provider providers.Provider, imageId string, modify func(image *types.Image)) (*types.Image, error) {
+	var updated *types.Image
+	if err := provider.GetState().ModifyImages(func(images map[string]*types.Image) error {
+		for _, stored := range images {
+			if stored.Id == imageId {
+				updated = stored
+			}
+		}
+		if updated == nil {
+			return errors.New("image "+imageId+" not found in state", nil)
+		}
+		modify(updated)
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying image map in state", err)
+	}
+	return updated, nil
+}
+
+// serveListImages lists the images with all of tags, for GET /images and ListImages
+func (d *UnikDaemon) serveListImages(tags map[string]string) ([]*types.Image, int, error) {
+	allImages := []*types.Image{}
+	for _, provider := range d.providers {
+		images, err := provider.ListImages()
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("could not get image list", err)
+		}
+		for _, image := range images {
+			if types.HasTags(image.Tags, tags) {
+				allImages = append(allImages, image)
+			}
+		}
+	}
+	logrus.WithFields(logrus.Fields{
+		"images": allImages,
+	}).Debugf("Listing all images")
+	return allImages, http.StatusOK, nil
+}
+
+func (d *UnikDaemon) serveGetImage(imageName string) (*types.Image, int, error) {
+	provider, err := d.providers.ProviderForImage(imageName)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	image, err := provider.GetImage(imageName)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return image, http.StatusOK, nil
+}
+
+// serveBuildImage builds image name from the sources in the tar archive
+// sources, with the build parameters of POST /images/:name/create in form. it
+// returns the image, or the plan of the build for a dry run, which takes no sources
+func (d *UnikDaemon) serveBuildImage(user, name string, form url.Values, sources io.Reader) (interface{}, int, error) {
+	if name == "" {
+		return nil, http.StatusBadRequest, errors.New("image must be named", nil)
+	}
+	// dry runs validate the build without uploading the sources
+	dryRun := strings.ToLower(form.Get("dry_run")) == "true"
+
+	noCleanupStr := form.Get("no_cleanup")
+	var noCleanup bool
+	if strings.ToLower(noCleanupStr) == "true" {
+		noCleanup = true
+	}
+
+	sourcesDir, err := ioutil.TempDir("", "unpacked.sources.dir.")
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("creating tmp dir for src files", err)
+	}
+
+	// with --no-cleanup, the paths of what was kept are returned with the image
+	var kept []string
+	if noCleanup {
+		logrus.Infof("--no-cleanup: keeping uploaded sources in %s", sourcesDir)
+		kept = append(kept, sourcesDir)
+	} else {
+		defer os.RemoveAll(sourcesDir)
+	}
+	// with --no-cleanup, failures say where the sources were left so they can be inspected
+	failedMsg := func(msg string) string {
+		if noCleanup {
+			return msg + " (sources and build artifacts were kept in " + sourcesDir + " on the daemon host)"
+		}
+		return msg
+	}
+
+	if !dryRun {
+		logrus.Debugf("extracting uploaded files to %s", sourcesDir)
+		if err := unikos.ExtractTar(ioutil.NopCloser(sources), sourcesDir); err != nil {
+			return nil, http.StatusInternalServerError, errors.New("extracting sources", err)
+		}
+	}
+	forceStr := form.Get("force")
+	var force bool
+	if strings.ToLower(forceStr) == "true" {
+		force = true
+	}
+	args := form.Get("args")
+	providerName := form.Get("provider")
+	if _, ok := d.providers[providerName]; !ok {
+		return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+	}
+
+	base := form.Get("base")
+	if base == "" {
+		return nil, http.StatusBadRequest, errors.New("must provide 'base' parameter", nil)
+	}
+	lang := form.Get("lang")
+	if lang == "" {
+		return nil, http.StatusBadRequest, errors.New("must provide 'lang' parameter", nil)
+	}
+	arch, err := types.ParseArchitecture(form.Get("arch"))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if providerArch := providerArchitecture(d.providers[providerName]); arch != providerArch {
+		return nil, http.StatusBadRequest, errors.New("compilers only build images for the architecture of provider "+providerName+" ("+string(providerArch)+")", nil)
+	}
+	var memoryMb int
+	if memoryStr := form.Get("memory"); memoryStr != "" {
+		memoryMb, err = strconv.Atoi(memoryStr)
+		if err != nil || memoryMb < 0 {
+			return nil, http.StatusBadRequest, errors.New("memory must be a non-negative number of MB", err)
+		}
+	}
+	var vcpus int
+	if vcpusStr := form.Get("vcpus"); vcpusStr != "" {
+		vcpus, err = strconv.Atoi(vcpusStr)
+		if err != nil {
+			return nil, http.StatusBadRequest, errors.New("vcpus must be a number", err)
+		}
+		if err := types.ValidateVCPUs(vcpus); err != nil {
+			return nil, http.StatusBadRequest, errors.New("invalid vcpus", err)
+		}
+	}
+	networkMode, err := types.ParseNetworkMode(form.Get("network_mode"))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	var buildTimeout time.Duration
+	if timeoutStr := form.Get("timeout"); timeoutStr != "" {
+		buildTimeout, err = time.ParseDuration(timeoutStr)
+		if err != nil || buildTimeout < 0 {
+			return nil, http.StatusBadRequest, errors.New("timeout must be a non-negative duration, e.g. 15m", err)
+		}
+	}
+	compilerName, err := compilers.ValidateCompiler(base, lang, providerName)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid base - lang - provider match", err)
+	}
+
+	compiler, ok := d.compilers[compilerName]
+	if !ok {
+		return nil, http.StatusBadRequest, errors.New("unikernel type "+compilerName.String()+" not available for "+providerName+"infrastructure", nil)
+	}
+	if plugin, ok := compiler.(*compilers.PluginCompiler); ok && !plugin.SupportsArch(arch) {
+		return nil, http.StatusBadRequest, errors.New("compiler plugin "+compilerName.String()+" does not build images for "+string(arch), nil)
+	}
+	tags, err := formTags(form)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	mntStr := form.Get("mounts")
+
+	var mountPoints []string
+	if len(mntStr) > 0 {
+		mountPoints = strings.Split(mntStr, ",")
+	}
+
+	squash := strings.ToLower(form.Get("squash")) == "true"
+	var baseImage *types.Image
+	if baseImageName := form.Get("base_image"); baseImageName != "" {
+		if squash {
+			return nil, http.StatusBadRequest, errors.New("images with a base image cannot be squashed", nil)
+		}
+		baseImage, err = d.providers[providerName].GetImage(baseImageName)
+		if err != nil {
+			return nil, http.StatusBadRequest, errors.New("base image "+baseImageName+" not found on provider "+providerName, err)
+		}
+		if !dryRun {
+			if err := d.mergeBaseImage(baseImage.Name, sourcesDir); err != nil {
+				return nil, http.StatusInternalServerError, errors.New("layering sources on base image "+baseImage.Name, err)
+			}
+		}
+		mountPoints = inheritedMountPoints(baseImage, mountPoints)
+		if memoryMb == 0 {
+			memoryMb = baseImage.DefaultMemoryMB
+		}
+		if vcpus == 0 {
+			vcpus = baseImage.DefaultVCPUs
+		}
+		if networkMode == "" {
+			networkMode = baseImage.NetworkMode
+		}
+	}
+	var squashDir string
+	var squashDirs map[string]string
+	if squash {
+		if compilerName.Base() != compilers.Rump {
+			return nil, http.StatusBadRequest, errors.New("squash is only supported for images with base "+compilers.Rump, nil)
+		}
+	}
+	if dryRun {
+		plan := &types.BuildPlan{
+			Name:         name,
+			Compiler:     compilerName.String(),
+			Provider:     providerName,
+			Architecture: arch,
+			Args:         args,
+			MountPoints:  mountPoints,
+			Squash:       squash,
+			Tags:         tags,
+			MemoryMB:     memoryMb,
+			VCPUs:        vcpus,
+			NetworkMode:  networkMode,
+			Target:       form.Get("target"),
+		}
+		if err := completeBuildPlan(plan, d.providers[providerName], force, baseImage, form.Get("sources_size")); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		logrus.WithField("plan", plan).Infof("dry run of build of image %s", name)
+		return plan, http.StatusOK, nil
+	}
+	if squash {
+		squashDir, squashDirs, err = takeSquashDirs(sourcesDir, mountPoints)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if noCleanup {
+			logrus.Infof("--no-cleanup: keeping squashed volume folders in %s", squashDir)
+			kept = append(kept, squashDir)
+		} else {
+			defer os.RemoveAll(squashDir)
+		}
+		// the volumes are part of the image, none are attached at run time
+		mountPoints = nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"force":        force,
+		"mount-points": mountPoints,
+		"name":         name,
+		"args":         args,
+		"compiler":     compilerName,
+		"provider":     providerName,
+		"noCleanup":    noCleanup,
+		"squash":       squash,
+		"base-image":   form.Get("base_image"),
+		"target":       form.Get("target"),
+		"timeout":      buildTimeout,
+	}).Debugf("compiling raw image")
+
+	compileParams := types.CompileImageParams{
+		SourcesDir: sourcesDir,
+		Args:       args,
+		MntPoints:  mountPoints,
+		NoCleanup:  noCleanup,
+		SquashDirs: squashDirs,
+		Target:     form.Get("target"),
+	}
+
+	rawImage, err := compilers.CompileWithTimeout(compiler, compileParams, buildTimeout)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New(failedMsg("failed to compile raw image"), err)
+	}
+	logrus.Debugf("raw image compiled and saved to %s", rawImage.LocalImagePath)
+	if squash {
+		warnIfSquashedNearlyFull(squashDir, rawImage.LocalImagePath)
+	}
+
+	if noCleanup {
+		logrus.Infof("--no-cleanup: keeping raw image %s", rawImage.LocalImagePath)
+	} else {
+		defer os.Remove(rawImage.LocalImagePath)
+	}
+
+	stageParams := types.StageImageParams{
+		Name:      name,
+		RawImage:  rawImage,
+		Force:     force,
+		NoCleanup: noCleanup,
+	}
+
+	image, err := d.stageImage(d.providers[providerName], user, stageParams)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New(failedMsg("failed staging image"), err)
+	}
+	if len(tags) > 0 {
+		image, err = modifyImageTags(d.providers[providerName], image.Id, addTags(tags))
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("tagging image", err)
+		}
+	}
+	image, err = modifyImage(d.providers[providerName], image.Id, func(image *types.Image) {
+		image.Architecture = arch
+		image.DefaultMemoryMB = memoryMb
+		image.DefaultVCPUs = vcpus
+		image.NetworkMode = networkMode
+		if baseImage != nil {
+			image.BaseImage = baseImage.Name
+		}
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("recording architecture and defaults of image", err)
+	}
+	d.notify(types.NewImageEvent(types.EventType_BuildCompleted, image))
+	if noCleanup {
+		// staging may have moved the raw image (gcloud renames it into its upload dir)
+		if _, err := os.Stat(rawImage.LocalImagePath); err == nil {
+			kept = append(kept, rawImage.LocalImagePath)
+		}
+		built := *image
+		built.KeptArtifacts = kept
+		return &built, http.StatusCreated, nil
+	}
+	return image, http.StatusCreated, nil
+}
+
+func (d *UnikDaemon) serveDeleteImage(imageName string, force bool) (int, error) {
+	if imageName == "" {
+		return http.StatusBadRequest, errors.New("image must be named", nil)
+	}
+	logrus.WithField("force", force).Infof("deleting image %s", imageName)
+	provider, err := d.providers.ProviderForImage(imageName)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	image, err := provider.GetImage(imageName)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := d.deleteImage(provider, image, force); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusNoContent, nil
+}
@@ -0,0 +1,454 @@
+package daemon
+
+import (
+	"fmt"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// runInstance runs an instance on the provider and records the settings the
+// daemon is responsible for (restart policy, ttl, mounts, tags, ports, group) in the provider's state.
+// the instance counts towards the quota of user
+func (d *UnikDaemon) runInstance(provider providers.Provider, user string, runInstanceRequest types.RunInstanceRequest) (*types.Instance, error) {
+	if d.requireSignedImages {
+		if err := d.checkImageSignature(provider, runInstanceRequest.ImageName); err != nil {
+			return nil, errors.New("the daemon only runs signed images", err)
+		}
+	}
+	// fall back to the memory, vcpus and network mode the image was built with
+	if image, err := provider.GetImage(runInstanceRequest.ImageName); err == nil {
+		if runInstanceRequest.MemoryMb == 0 {
+			runInstanceRequest.MemoryMb = image.DefaultMemoryMB
+		}
+		if runInstanceRequest.VCPUs == 0 {
+			runInstanceRequest.VCPUs = image.DefaultVCPUs
+		}
+		if runInstanceRequest.NetworkMode == "" {
+			runInstanceRequest.NetworkMode = image.NetworkMode
+		}
+	}
+	params := types.RunInstanceParams{
+		Name:                 runInstanceRequest.InstanceName,
+		ImageId:              runInstanceRequest.ImageName,
+		MntPointsToVolumeIds: runInstanceRequest.Mounts,
+		Env:                  runInstanceRequest.Env,
+		InstanceMemory:       runInstanceRequest.MemoryMb,
+		VCPUs:                runInstanceRequest.VCPUs,
+		NetworkMode:          runInstanceRequest.NetworkMode,
+		NoCleanup:            runInstanceRequest.NoCleanup,
+		DebugMode:            runInstanceRequest.DebugMode,
+		Tags:                 runInstanceRequest.Tags,
+		PortMappings:         runInstanceRequest.Ports,
+		Cmdline:              runInstanceRequest.Cmdline,
+		CmdlineMode:          runInstanceRequest.CmdlineMode,
+		ReadOnlyMntPoints:    runInstanceRequest.ReadOnlyMounts,
+		UserData:             runInstanceRequest.UserData,
+		MetaData:             runInstanceRequest.MetaData,
+		IPv6:                 runInstanceRequest.IPv6,
+		StaticIP:             runInstanceRequest.StaticIP,
+		VolumeMode:           runInstanceRequest.VolumeMode,
+	}
+	// the nocloud data source needs meta-data; without one, the instance gets its name
+	if params.UserData != "" && params.MetaData == "" {
+		params.MetaData = fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n", params.Name, params.Name)
+	}
+
+	reservation, err := d.quotas.reserveInstance(user, provider, params.Name)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := provider.RunInstance(params)
+	if err != nil {
+		d.quotas.cancel(reservation)
+		return nil, err
+	}
+	d.quotas.commit(reservation)
+
+	policy := runInstanceRequest.RestartPolicy
+	if policy != nil && policy.Mode == types.RestartMode_Never {
+		policy = nil
+	}
+	var expiresAt time.Time
+	if runInstanceRequest.Ttl > 0 {
+		expiresAt = time.Now().Add(runInstanceRequest.Ttl)
+	}
+	mounts := make(map[string]string)
+	for mntPoint, volumeNameOrId := range runInstanceRequest.Mounts {
+		volumeId := volumeNameOrId
+		if volume, err := provider.GetVolume(volumeNameOrId); err == nil {
+			volumeId = volume.Id
+		}
+		mounts[mntPoint] = volumeId
+	}
+
+	if err := provider.GetState().ModifyInstances(func(instances map[string]*types.Instance) error {
+		if stored, ok := instances[instance.Id]; ok {
+			stored.RestartPolicy = policy
+			stored.ExpiresAt = expiresAt
+			stored.Mounts = mounts
+			stored.Tags = runInstanceRequest.Tags
+			stored.Ports = runInstanceRequest.Ports
+			stored.Group = runInstanceRequest.Group
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.New("saving run settings for instance "+instance.Id, err)
+	}
+	instance.RestartPolicy = policy
+	instance.ExpiresAt = expiresAt
+	instance.Mounts = mounts
+	instance.Tags = runInstanceRequest.Tags
+	instance.Ports = runInstanceRequest.Ports
+	instance.Group = runInstanceRequest.Group
+	return instance, nil
+}
+
+func (d *UnikDaemon) startInstance(provider providers.Provider, instanceId string) error {
+	if err := provider.StartInstance(instanceId); err != nil {
+		return errors.New("could not start instance "+instanceId, err)
+	}
+	d.monitor.setStoppedByUser(instanceId, false)
+	d.notifyInstance(types.EventType_InstanceStarted, provider, instanceId)
+	return nil
+}
+
+// restartInstance stops and starts an instance, shutting it down gracefully
+// within timeout if the provider can, and returns it once it runs again
+func (d *UnikDaemon) restartInstance(provider providers.Provider, instanceId string, timeout time.Duration) (*types.Instance, error) {
+	// the restart policy must not start the instance while it is down
+	d.monitor.setStoppedByUser(instanceId, true)
+	defer d.monitor.setStoppedByUser(instanceId, false)
+	var err error
+	if restarter, ok := provider.(providers.Restarter); ok {
+		err = restarter.RestartInstance(instanceId, timeout)
+	} else {
+		if stopper, ok := provider.(providers.GracefulStopper); ok && timeout > 0 {
+			err = stopper.GracefulStop(instanceId, timeout)
+		} else {
+			err = provider.StopInstance(instanceId)
+		}
+		if err == nil {
+			err = provider.StartInstance(instanceId)
+		}
+	}
+	if err != nil {
+		return nil, errors.New("could not restart instance "+instanceId, err)
+	}
+	instance, err := provider.GetInstance(instanceId)
+	if err != nil {
+		return nil, errors.New("retrieving restarted instance "+instanceId, err)
+	}
+	d.notify(types.NewInstanceEvent(types.EventType_InstanceRestarted, instance))
+	return instance, nil
+}
+
+// cloneInstance runs a new instance from the same image as the source, with the
+// same restart policy, mount points, tags and group. each volume mounted on the source is
+// copied for the clone, so the two instances never share a volume. this requires
+// the provider to implement providers.VolumeCloner; instances without volumes
+// can be cloned on any provider. the clone and its volumes count towards the quota of user.
+// if the clone fails to run, the volumes copied for it are deleted again.
+func (d *UnikDaemon) cloneInstance(sourceProvider, targetProvider providers.Provider, user, sourceId, newName string) (_ *types.Instance, err error) {
+	source, err := sourceProvider.GetInstance(sourceId)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+sourceId, err)
+	}
+	image, err := sourceProvider.GetImage(source.ImageId)
+	if err != nil {
+		return nil, errors.New("retrieving image "+source.ImageId+" of instance "+source.Name, err)
+	}
+	if targetProvider != sourceProvider {
+		//images are specific to a provider; the target needs an image with the same name
+		imageName := image.Name
+		image, err = targetProvider.GetImage(imageName)
+		if err != nil {
+			return nil, errors.New("target provider has no image named "+imageName, err)
+		}
+		if len(source.Mounts) > 0 {
+			return nil, errors.New("instances with volumes can only be cloned on their own provider", nil)
+		}
+	}
+
+	mounts := make(map[string]string)
+	var clonedVolumes []*types.Volume
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, volume := range clonedVolumes {
+			if deleteErr := d.deleteVolume(sourceProvider, volume, true); deleteErr != nil {
+				logrus.WithError(deleteErr).Warnf("failed to delete volume %s copied for failed clone %s", volume.Name, newName)
+			}
+		}
+	}()
+	if len(source.Mounts) > 0 {
+		cloner, ok := sourceProvider.(providers.VolumeCloner)
+		if !ok {
+			return nil, errors.New("instance "+source.Name+" has volumes attached, but its provider cannot copy volumes", nil)
+		}
+		for mntPoint, volumeId := range source.Mounts {
+			volume, err := sourceProvider.GetVolume(volumeId)
+			if err != nil {
+				return nil, errors.New("retrieving volume "+volumeId+" mounted at "+mntPoint, err)
+			}
+			clonedName := newName + "-" + volume.Name
+			reservation, err := d.quotas.reserveVolume(user, sourceProvider, clonedName, volume.SizeMb)
+			if err != nil {
+				return nil, err
+			}
+			cloned, err := cloner.CloneVolume(volume.Id, clonedName)
+			d.settleVolume(reservation, sourceProvider, clonedName)
+			if err != nil {
+				// a copy that failed halfway may be left on the provider
+				if leftover, getErr := sourceProvider.GetVolume(clonedName); getErr == nil {
+					clonedVolumes = append(clonedVolumes, leftover)
+				}
+				return nil, errors.New("copying volume "+volume.Name, err)
+			}
+			clonedVolumes = append(clonedVolumes, cloned)
+			logrus.WithFields(logrus.Fields{"source": volume.Name, "clone": cloned.Name, "mount": mntPoint}).Infof("copied volume for cloned instance")
+			mounts[mntPoint] = cloned.Id
+		}
+	}
+
+	instance, err := d.runInstance(targetProvider, user, types.RunInstanceRequest{
+		InstanceName:  newName,
+		ImageName:     image.Id,
+		Mounts:        mounts,
+		RestartPolicy: source.RestartPolicy,
+		Tags:          source.Tags,
+		Group:         source.Group,
+	})
+	if _, ok := err.(*types.QuotaExceeded); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("running clone of instance "+source.Name, err)
+	}
+	d.notify(types.NewInstanceEvent(types.EventType_InstanceStarted, instance))
+	return instance, nil
+}
+
+// serveListInstances returns the page of the instances that filter selects, and
+// how many instances it selects, for GET /instances and ListInstances
+func (d *UnikDaemon) serveListInstances(page listPage, filter instanceFilter) ([]*types.Instance, int, int, error) {
+	providersToList := d.providers
+	if filter.provider != "" {
+		provider, ok := d.providers[filter.provider]
+		if !ok {
+			return nil, 0, http.StatusBadRequest, errors.New(filter.provider+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+		}
+		providersToList = providers.Providers{filter.provider: provider}
+	}
+	if filter.image != "" {
+		images := []*types.Image{}
+		for _, provider := range d.providers {
+			for _, image := range provider.GetState().GetImages() {
+				images = append(images, image)
+			}
+		}
+		filter.resolveImage(images)
+	}
+	listed := []*types.Instance{}
+	for _, provider := range providersToList {
+		instances, err := provider.ListInstances()
+		if err != nil {
+			return nil, 0, http.StatusInternalServerError, errors.New("could not get instance list", err)
+		}
+		listed = append(listed, instances...)
+		listed = append(listed, d.reaper.expiredInstances(provider)...)
+	}
+	allInstances := []*types.Instance{}
+	for _, instance := range listed {
+		if filter.matches(instance) {
+			allInstances = append(allInstances, instance)
+		}
+	}
+	sortInstances(allInstances, page.sort, page.desc)
+	start, end := page.bounds(len(allInstances))
+	logrus.WithFields(logrus.Fields{
+		"instances": allInstances[start:end],
+	}).Debugf("Listing all instances")
+	return allInstances[start:end], len(allInstances), http.StatusOK, nil
+}
+
+func (d *UnikDaemon) serveGetInstance(instanceId string) (*types.Instance, int, error) {
+	provider, err := d.providers.ProviderForInstance(instanceId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	instance, err := provider.GetInstance(instanceId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return instance, http.StatusOK, nil
+}
+
+// serveRunInstance validates runInstanceRequest and runs the instance, for POST
+// /instances/run and RunInstance
+func (d *UnikDaemon) serveRunInstance(user string, runInstanceRequest types.RunInstanceRequest) (*types.Instance, int, error) {
+	var err error
+	if err := types.ValidateTags(runInstanceRequest.Tags); err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid tags", err)
+	}
+	if err := types.ValidatePortMappings(runInstanceRequest.Ports); err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid port mappings", err)
+	}
+	if runInstanceRequest.VCPUs != 0 {
+		if err := types.ValidateVCPUs(runInstanceRequest.VCPUs); err != nil {
+			return nil, http.StatusBadRequest, errors.New("invalid vcpus", err)
+		}
+	}
+	if _, err := types.ParseNetworkMode(string(runInstanceRequest.NetworkMode)); err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid network mode", err)
+	}
+	if mode := runInstanceRequest.VolumeMode; mode != "" && !mode.IsFolder() {
+		return nil, http.StatusBadRequest, errors.New("invalid volume mode "+string(mode)+": folder volumes can be shared as "+string(types.VolumeMode_VirtioFS)+" or "+string(types.VolumeMode_9P), nil)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request": runInstanceRequest,
+	}).Debugf("recieved run request")
+
+	if runInstanceRequest.ImageName == "" {
+		return nil, http.StatusBadRequest, errors.New("image must be named", nil)
+	}
+	imageName, err := d.resolveImage(runInstanceRequest.ImageName)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if imageName != runInstanceRequest.ImageName {
+		logrus.WithFields(logrus.Fields{"manifest": runInstanceRequest.ImageName, "image": imageName}).Infof("resolved manifest to image")
+		runInstanceRequest.ImageName = imageName
+	}
+
+	if policy := runInstanceRequest.RestartPolicy; policy != nil {
+		mode, err := types.ParseRestartMode(string(policy.Mode))
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		policy.Mode = mode
+		if policy.MaxRestarts < 0 || policy.BackoffSeconds < 0 {
+			return nil, http.StatusBadRequest, errors.New("max restarts and restart backoff must not be negative", nil)
+		}
+	}
+	if runInstanceRequest.Ttl < 0 {
+		return nil, http.StatusBadRequest, errors.New("ttl must not be negative", nil)
+	}
+	if runInstanceRequest.CmdlineMode, err = unikos.ParseCmdlineMode(string(runInstanceRequest.CmdlineMode)); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if runInstanceRequest.CmdlineMode == unikos.CmdlineGoTemplate {
+		if err := unikos.ValidateCmdlineTemplate(runInstanceRequest.Cmdline); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	provider, err := d.providers.ProviderForImage(runInstanceRequest.ImageName)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if len(runInstanceRequest.Ports) > 0 && !provider.GetConfig().SupportsPortMappings {
+		return nil, http.StatusBadRequest, errors.New("the provider of image "+runInstanceRequest.ImageName+" cannot forward ports", nil)
+	}
+	if runInstanceRequest.Cmdline != "" && !provider.GetConfig().SupportsRuntimeCmdline {
+		return nil, http.StatusBadRequest, errors.New("the provider of image "+runInstanceRequest.ImageName+" cannot change the command line of its instances", nil)
+	}
+	for _, mntPoint := range runInstanceRequest.ReadOnlyMounts {
+		if _, ok := runInstanceRequest.Mounts[mntPoint]; !ok {
+			return nil, http.StatusBadRequest, errors.New("no volume is mounted at read-only mount point "+mntPoint, nil)
+		}
+	}
+	if len(runInstanceRequest.ReadOnlyMounts) > 0 && !provider.GetConfig().SupportsReadOnlyVolumes {
+		return nil, http.StatusBadRequest, errors.New("the provider of image "+runInstanceRequest.ImageName+" cannot attach volumes read-only", nil)
+	}
+	if (runInstanceRequest.UserData != "" || runInstanceRequest.MetaData != "") && !provider.GetConfig().SupportsCloudInit {
+		return nil, http.StatusBadRequest, errors.New("the provider of image "+runInstanceRequest.ImageName+" cannot attach cloud-init data to its instances", nil)
+	}
+	if runInstanceRequest.IPv6 != nil {
+		if err := runInstanceRequest.IPv6.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if !provider.GetConfig().SupportsIPv6 {
+			return nil, http.StatusBadRequest, errors.New("the provider of image "+runInstanceRequest.ImageName+" cannot give its instances an ipv6 address", nil)
+		}
+	}
+	if runInstanceRequest.StaticIP != nil {
+		if err := runInstanceRequest.StaticIP.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if !provider.GetConfig().SupportsStaticIP {
+			return nil, http.StatusBadRequest, errors.New("the provider of image "+runInstanceRequest.ImageName+" cannot give its instances a static ip", nil)
+		}
+	}
+
+	instance, err := d.runInstance(provider, user, runInstanceRequest)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	d.notify(types.NewInstanceEvent(types.EventType_InstanceStarted, instance))
+	return instance, http.StatusCreated, nil
+}
+
+func (d *UnikDaemon) serveStartInstance(instanceId string) (int, error) {
+	logrus.Infof("starting instance %s", instanceId)
+	provider, err := d.providers.ProviderForInstance(instanceId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := d.startInstance(provider, instanceId); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (d *UnikDaemon) serveStopInstance(instanceId string, timeout time.Duration) (int, error) {
+	logrus.WithField("timeout", timeout).Infof("stopping instance %s", instanceId)
+	if timeout < 0 {
+		return http.StatusBadRequest, errors.New("timeout must be a non-negative duration, e.g. 30s", nil)
+	}
+	provider, err := d.providers.ProviderForInstance(instanceId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := d.stopInstance(provider, instanceId, timeout); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (d *UnikDaemon) serveDeleteInstance(instanceId string, force bool) (int, error) {
+	logrus.WithField("force", force).Infof("deleting instance %s", instanceId)
+	provider, err := d.providers.ProviderForInstance(instanceId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	instance, err := provider.GetInstance(instanceId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := d.deleteInstance(provider, instance, force); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusNoContent, nil
+}
+
+// serveInstanceLogs returns the logs of an instance so far
+func (d *UnikDaemon) serveInstanceLogs(instanceId string) (string, int, error) {
+	provider, err := d.providers.ProviderForInstance(instanceId)
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	logs, err := provider.GetInstanceLogs(instanceId)
+	if err != nil {
+		return "", http.StatusInternalServerError, errors.New("failed to perform get logs request", err)
+	}
+	return logs, http.StatusOK, nil
+}
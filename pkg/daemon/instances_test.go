@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cloningProvider is a fakeProvider that copies volumes, by their state only
+type cloningProvider struct {
+	*fakeProvider
+}
+
+func (p *cloningProvider) CloneVolume(id, newName string) (*types.Volume, error) {
+	source, err := p.GetVolume(id)
+	if err != nil {
+		return nil, err
+	}
+	clone := &types.Volume{Id: newName, Name: newName, SizeMb: source.SizeMb}
+	return clone, p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		volumes[clone.Id] = clone
+		return nil
+	})
+}
+
+var _ = Describe("cloneInstance", func() {
+	var (
+		dir      string
+		provider *cloningProvider
+		d        *UnikDaemon
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.instances.")
+		Expect(err).NotTo(HaveOccurred())
+		provider = &cloningProvider{newFakeProvider(dir)}
+		Expect(provider.State.ModifyImages(func(images map[string]*types.Image) error {
+			images["app"] = &types.Image{Id: "app", Name: "app"}
+			return nil
+		})).To(Succeed())
+		Expect(provider.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+			volumes["data"] = &types.Volume{Id: "data", Name: "data", SizeMb: 8, Attachment: "web"}
+			return nil
+		})).To(Succeed())
+		Expect(provider.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+			instances["web"] = &types.Instance{Id: "web", Name: "web", ImageId: "app", Mounts: map[string]string{"/data": "data"}}
+			return nil
+		})).To(Succeed())
+		_providers := providers.Providers{"fake": provider}
+		d = &UnikDaemon{
+			providers: _providers,
+			quotas:    newQuotaManager(config.QuotaConfig{MaxVolumesPerUser: 2}, state.NewBasicState(filepath.Join(dir, "daemon-state.json")), _providers),
+		}
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should delete the volumes copied for a clone that failed to run", func() {
+		// fakeProvider can't run instances
+		_, err := d.cloneInstance(provider, provider, "alice", "web", "web-copy")
+		Expect(err).To(HaveOccurred())
+		Expect(provider.State.GetVolumes()).NotTo(HaveKey("web-copy-data"))
+		Expect(d.quotas.state.GetRecords(quotaVolumes)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,252 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// the kind of records manifests are kept as in the daemon state, by name
+const manifestRecords = "manifests"
+
+// manifestsFile is where daemons before the daemon state kept manifests
+func manifestsFile() string {
+	return filepath.Join(config.Internal.UnikHome, "manifests.json")
+}
+
+// importManifestsFile moves the manifests of a manifests.json to the daemon state
+func (d *UnikDaemon) importManifestsFile(manifestsFile string) error {
+	data, err := ioutil.ReadFile(manifestsFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.New("reading manifests file "+manifestsFile, err)
+	}
+	manifests := make(map[string]*types.ImageManifest)
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return errors.New("failed to unmarshal manifests file "+manifestsFile, err)
+	}
+	if err := d.modifyManifests(func(stored map[string]*types.ImageManifest) error {
+		for name, manifest := range manifests {
+			stored[name] = manifest
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	logrus.Infof("moved manifests from %s to the daemon state", manifestsFile)
+	return os.Remove(manifestsFile)
+}
+
+func decodeManifests(records map[string]json.RawMessage) (map[string]*types.ImageManifest, error) {
+	manifests := make(map[string]*types.ImageManifest)
+	for name, data := range records {
+		var manifest types.ImageManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, errors.New("failed to unmarshal manifest "+name, err)
+		}
+		manifests[name] = &manifest
+	}
+	return manifests, nil
+}
+
+func (d *UnikDaemon) readManifests() (map[string]*types.ImageManifest, error) {
+	return decodeManifests(d.state.GetRecords(manifestRecords))
+}
+
+// modifyManifests applies modify to the manifests by name, and stores those that changed
+func (d *UnikDaemon) modifyManifests(modify func(manifests map[string]*types.ImageManifest) error) error {
+	return d.state.ModifyRecords(manifestRecords, func(records map[string]json.RawMessage) error {
+		manifests, err := decodeManifests(records)
+		if err != nil {
+			return err
+		}
+		if err := modify(manifests); err != nil {
+			return err
+		}
+		for name := range records {
+			if _, ok := manifests[name]; !ok {
+				delete(records, name)
+			}
+		}
+		for name, manifest := range manifests {
+			data, err := json.Marshal(manifest)
+			if err != nil {
+				return errors.New("marshalling manifest "+name, err)
+			}
+			records[name] = data
+		}
+		return nil
+	})
+}
+
+func (d *UnikDaemon) listManifests() ([]*types.ImageManifest, error) {
+	manifests, err := d.readManifests()
+	if err != nil {
+		return nil, err
+	}
+	list := []*types.ImageManifest{}
+	for _, manifest := range manifests {
+		list = append(list, manifest)
+	}
+	sort.Sort(manifestsByName(list))
+	return list, nil
+}
+
+func (d *UnikDaemon) getManifest(name string) (*types.ImageManifest, error) {
+	manifests, err := d.readManifests()
+	if err != nil {
+		return nil, err
+	}
+	manifest, ok := manifests[name]
+	if !ok {
+		return nil, errors.New("manifest "+name+" not found", nil)
+	}
+	return manifest, nil
+}
+
+// manifestImages returns the ids of the images listed in manifests, which must
+// not be garbage collected or pruned
+func (d *UnikDaemon) manifestImages() map[string]bool {
+	images := make(map[string]bool)
+	manifests, err := d.readManifests()
+	if err != nil {
+		logrus.WithError(err).Warnf("failed to read manifests")
+	}
+	for _, manifest := range manifests {
+		for _, imageId := range manifest.Images {
+			images[imageId] = true
+		}
+	}
+	return images
+}
+
+// createManifest saves a manifest after checking that each of its images exists
+// and was built for the architecture it is listed under
+func (d *UnikDaemon) createManifest(request types.CreateManifestRequest) (*types.ImageManifest, error) {
+	if request.Name == "" {
+		return nil, errors.New("manifest must be named", nil)
+	}
+	if len(request.Images) == 0 {
+		return nil, errors.New("manifest must list at least one image", nil)
+	}
+	if _, err := d.providers.ProviderForImage(request.Name); err == nil {
+		return nil, errors.New("an image is already named "+request.Name, nil)
+	}
+	manifest := &types.ImageManifest{
+		Name:    request.Name,
+		Images:  make(map[types.Architecture]string),
+		Created: time.Now(),
+	}
+	for archName, imageName := range request.Images {
+		arch, err := types.ParseArchitecture(string(archName))
+		if err != nil {
+			return nil, err
+		}
+		provider, err := d.providers.ProviderForImage(imageName)
+		if err != nil {
+			return nil, err
+		}
+		image, err := provider.GetImage(imageName)
+		if err != nil {
+			return nil, err
+		}
+		if imageArchitecture(image) != arch {
+			return nil, errors.New("image "+image.Name+" is built for "+string(imageArchitecture(image))+", not "+string(arch), nil)
+		}
+		manifest.Images[arch] = image.Id
+	}
+
+	if err := d.modifyManifests(func(manifests map[string]*types.ImageManifest) error {
+		if _, ok := manifests[manifest.Name]; ok {
+			return errors.New("manifest "+manifest.Name+" already exists", nil)
+		}
+		manifests[manifest.Name] = manifest
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (d *UnikDaemon) deleteManifest(name string) error {
+	return d.modifyManifests(func(manifests map[string]*types.ImageManifest) error {
+		if _, ok := manifests[name]; !ok {
+			return errors.New("manifest "+name+" not found", nil)
+		}
+		delete(manifests, name)
+		return nil
+	})
+}
+
+// renameManifestImage points the manifests listing the image with oldId to newId
+func (d *UnikDaemon) renameManifestImage(oldId, newId string) error {
+	return d.modifyManifests(func(manifests map[string]*types.ImageManifest) error {
+		for _, manifest := range manifests {
+			for arch, imageId := range manifest.Images {
+				if imageId == oldId {
+					manifest.Images[arch] = newId
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// resolveImage returns the image to run for imageName. names of images are returned
+// as they are; the name of a manifest resolves to its image for the architecture
+// of the provider the image belongs to.
+func (d *UnikDaemon) resolveImage(imageName string) (string, error) {
+	if _, err := d.providers.ProviderForImage(imageName); err == nil {
+		return imageName, nil
+	}
+	manifest, err := d.getManifest(imageName)
+	if err != nil {
+		// not a manifest either, let the caller report the missing image
+		return imageName, nil
+	}
+	available := []string{}
+	for arch, imageId := range manifest.Images {
+		provider, err := d.providers.ProviderForImage(imageId)
+		if err != nil {
+			continue
+		}
+		if providerArchitecture(provider) == arch {
+			return imageId, nil
+		}
+		available = append(available, string(arch))
+	}
+	sort.Strings(available)
+	return "", errors.New("manifest "+manifest.Name+" has no image for the architecture of its providers. Available: "+strings.Join(available, "|"), nil)
+}
+
+func imageArchitecture(image *types.Image) types.Architecture {
+	if image.Architecture == "" {
+		return types.Architecture_AMD64
+	}
+	return image.Architecture
+}
+
+func providerArchitecture(provider providers.Provider) types.Architecture {
+	if arch := provider.GetConfig().Architecture; arch != "" {
+		return arch
+	}
+	return types.Architecture_AMD64
+}
+
+type manifestsByName []*types.ImageManifest
+
+func (s manifestsByName) Len() int           { return len(s) }
+func (s manifestsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s manifestsByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("manifests", func() {
+	var (
+		dir      string
+		provider *fakeProvider
+		d        *UnikDaemon
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.manifests.")
+		Expect(err).NotTo(HaveOccurred())
+		provider = newFakeProvider(dir)
+		Expect(provider.State.ModifyImages(func(images map[string]*types.Image) error {
+			for _, name := range []string{"app-v1", "app-v2"} {
+				images[name] = &types.Image{Id: name, Name: name, Created: time.Now().Add(-48 * time.Hour)}
+			}
+			return nil
+		})).To(Succeed())
+		d = &UnikDaemon{
+			providers: providers.Providers{"fake": provider},
+			state:     state.NewBasicState(filepath.Join(dir, "daemon-state.json")),
+		}
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should keep manifests in the daemon state", func() {
+		_, err := d.createManifest(types.CreateManifestRequest{Name: "release", Images: map[types.Architecture]string{types.Architecture_AMD64: "app-v2"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.state.GetRecords(manifestRecords)).To(HaveKey("release"))
+		resolved, err := d.resolveImage("release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved).To(Equal("app-v2"))
+
+		Expect(d.deleteManifest("release")).To(Succeed())
+		Expect(d.state.GetRecords(manifestRecords)).To(BeEmpty())
+	})
+
+	It("should not garbage collect the images of a manifest", func() {
+		_, err := d.createManifest(types.CreateManifestRequest{Name: "release", Images: map[types.Architecture]string{types.Architecture_AMD64: "app-v2"}})
+		Expect(err).NotTo(HaveOccurred())
+		collector := newImageCollector(d.providers, config.GCPolicy{MaxAgeDays: 1}, d.manifestImages, func(providers.Provider, *types.Image) {})
+		status := collector.collect()
+		Expect(status.ImagesDeleted).To(Equal([]string{"app-v1"}))
+		Expect(provider.State.GetImages()).To(HaveKey("app-v2"))
+	})
+})
@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"github.com/layer-x/layerx-commons/lxhttpclient"
+)
+
+// how long a migration may take unless the request says otherwise
+const defaultMigrationTimeout = 10 * time.Minute
+
+// migrateInstance moves a running instance to the daemon at destinationDaemon.
+// that daemon runs the instance with the same parameters, waiting for its state
+// on the port of destination (HOST:PORT), and the provider then sends the state
+// there. the instance is removed from this daemon once the other has taken over;
+// the instance on the other daemon is returned. volumes are not copied, so the
+// instance must have none
+func (d *UnikDaemon) migrateInstance(migrator providers.Migrator, instance *types.Instance, destination, destinationDaemon string, timeout time.Duration) (*types.Instance, error) {
+	_, portStr, err := net.SplitHostPort(destination)
+	if err != nil {
+		return nil, errors.New("destination must be HOST:PORT", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, errors.New("invalid destination port "+portStr, err)
+	}
+	params, err := migrator.RunParams(instance.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, body, err := lxhttpclient.Post(destinationDaemon, "/instances/incoming", nil, types.IncomingMigrationRequest{
+		Params:        params,
+		Port:          port,
+		RestartPolicy: instance.RestartPolicy,
+		Tags:          instance.Tags,
+	})
+	if err != nil {
+		return nil, errors.New("asking the daemon at "+destinationDaemon+" to accept instance "+instance.Name, err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.New(fmt.Sprintf("the daemon at %s could not accept instance %s: failed with status %v: %s", destinationDaemon, instance.Name, resp.StatusCode, string(body)), nil)
+	}
+	var target types.Instance
+	if err := json.Unmarshal(body, &target); err != nil {
+		return nil, errors.New(fmt.Sprintf("response body %s did not unmarshal to type *types.Instance", string(body)), err)
+	}
+	logrus.WithFields(logrus.Fields{"instance": instance.Name, "destination": destination, "target": target.Id}).Infof("migrating instance")
+
+	if err := migrator.MigrateInstance(instance.Id, "tcp:"+destination, timeout); err != nil {
+		// the instance keeps running here; the one waiting for it is of no use
+		if _, _, deleteErr := lxhttpclient.Delete(destinationDaemon, "/instances/"+target.Id+"?force=true", nil); deleteErr != nil {
+			logrus.WithError(deleteErr).Warnf("failed to delete instance %s waiting on the daemon at %s", target.Name, destinationDaemon)
+		}
+		return nil, errors.New("migrating instance "+instance.Name+" to "+destination, err)
+	}
+	return &target, nil
+}
+
+// runIncoming runs the instance of an IncomingMigrationRequest, waiting for its
+// state on the port of the request on all interfaces. the instance counts towards the quota of user
+func (d *UnikDaemon) runIncoming(provider providers.Provider, user string, request types.IncomingMigrationRequest) (*types.Instance, error) {
+	if d.requireSignedImages {
+		if err := d.checkImageSignature(provider, request.Params.ImageId); err != nil {
+			return nil, errors.New("the daemon only runs signed images", err)
+		}
+	}
+	params := request.Params
+	params.MntPointsToVolumeIds = nil
+	params.ReadOnlyMntPoints = nil
+	params.IncomingMigration = fmt.Sprintf("tcp::%d", request.Port)
+	reservation, err := d.quotas.reserveInstance(user, provider, params.Name)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := provider.RunInstance(params)
+	if err != nil {
+		d.quotas.cancel(reservation)
+		return nil, err
+	}
+	d.quotas.commit(reservation)
+	if err := provider.GetState().ModifyInstances(func(instances map[string]*types.Instance) error {
+		if stored, ok := instances[instance.Id]; ok {
+			stored.RestartPolicy = request.RestartPolicy
+			stored.Tags = request.Tags
+			stored.Ports = params.PortMappings
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.New("saving run settings for instance "+instance.Id, err)
+	}
+	instance.RestartPolicy = request.RestartPolicy
+	instance.Tags = request.Tags
+	instance.Ports = params.PortMappings
+	return instance, nil
+}
@@ -0,0 +1,215 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const (
+	migrationStageExport = "exporting"
+	migrationStageUpload = "uploading"
+	// how often progress events are published while a volume is being uploaded
+	migrationProgressInterval = 2 * time.Second
+)
+
+// the kind of records completed migrations are kept as in the daemon state
+const migrationRecords = "volume-migrations"
+
+// volumeMigrationsFile is where daemons before the daemon state recorded migrations
+func volumeMigrationsFile() string {
+	return filepath.Join(config.Internal.UnikHome, "volume-migrations.json")
+}
+
+func migrationKey(migration *types.VolumeMigration) string {
+	return fmt.Sprintf("%s-%d", migration.VolumeName, migration.Started.UnixNano())
+}
+
+// recordMigration adds a completed migration to the daemon state
+func (d *UnikDaemon) recordMigration(migration *types.VolumeMigration) error {
+	return putRecord(d.state, migrationRecords, migrationKey(migration), migration)
+}
+
+// migrateVolume copies the data of a volume to a new volume of the same name
+// and size on targetProvider. the volume is exported to a temporary raw image,
+// which the target provider then creates (and if remote, uploads) the new volume from.
+// progress is published as volume_migration_progress events. the new volume
+// counts towards the quota of user.
+func (d *UnikDaemon) migrateVolume(sourceProvider providers.Provider, sourceProviderName string, targetProvider providers.Provider, targetProviderName, user, volumeName string) (*types.VolumeMigration, error) {
+	volume, err := sourceProvider.GetVolume(volumeName)
+	if err != nil {
+		return nil, errors.New("retrieving volume "+volumeName, err)
+	}
+	if volume.Attachment != "" {
+		return nil, errors.New("volume "+volume.Name+" is attached to instance "+volume.Attachment+", detach it before migrating", nil)
+	}
+	if _, err := targetProvider.GetVolume(volume.Name); err == nil {
+		return nil, errors.New("volume "+volume.Name+" already exists on "+targetProviderName, nil)
+	}
+	reservation, err := d.quotas.reserveVolume(user, targetProvider, volume.Name, volume.SizeMb)
+	if err != nil {
+		return nil, err
+	}
+	defer d.settleVolume(reservation, targetProvider, volume.Name)
+	migration := &types.VolumeMigration{
+		VolumeName:     volume.Name,
+		SourceProvider: sourceProviderName,
+		SourceVolumeId: volume.Id,
+		TargetProvider: targetProviderName,
+		Started:        time.Now(),
+	}
+
+	tmpDir, err := ioutil.TempDir("", "unik.volume-migration.")
+	if err != nil {
+		return nil, errors.New("creating temporary directory", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	rawImage := filepath.Join(tmpDir, "data.img")
+
+	logrus.WithFields(logrus.Fields{"volume": volume.Name, "from": sourceProviderName, "to": targetProviderName}).Infof("exporting volume to raw image")
+	if err := d.exportVolume(sourceProvider, sourceProviderName, volume, rawImage); err != nil {
+		return nil, err
+	}
+	ids := readFilesystemIds(volume, rawImage)
+
+	logrus.WithFields(logrus.Fields{"volume": volume.Name, "to": targetProviderName}).Infof("creating volume from exported image")
+	newVolume, err := d.uploadWithProgress(volume, targetProvider, rawImage, volume.Name)
+	if err != nil {
+		return nil, errors.New("creating volume "+volume.Name+" on "+targetProviderName, err)
+	}
+	if err := keepFilesystemIds(targetProvider, newVolume, ids); err != nil {
+		return nil, err
+	}
+	if len(volume.Tags) > 0 {
+		if newVolume, err = modifyVolumeTags(targetProvider, newVolume.Id, addTags(volume.Tags)); err != nil {
+			return nil, errors.New("copying tags of volume "+volume.Name, err)
+		}
+	}
+
+	migration.TargetVolumeId = newVolume.Id
+	migration.SizeMb = newVolume.SizeMb
+	migration.Completed = time.Now()
+	if err := d.recordMigration(migration); err != nil {
+		return nil, errors.New("recording migration of volume "+volume.Name, err)
+	}
+	d.notify(types.NewVolumeMigratedEvent(migration))
+	return migration, nil
+}
+
+// exportVolume writes the data of a volume stored on the daemon host to
+// rawImage, a raw disk image, publishing progress
+func (d *UnikDaemon) exportVolume(sourceProvider providers.Provider, sourceProviderName string, volume *types.Volume, rawImage string) error {
+	localVolumes, ok := sourceProvider.(providers.LocalVolumeProvider)
+	if !ok {
+		return errors.New("volumes of provider "+sourceProviderName+" are not stored on the daemon host and cannot be exported", nil)
+	}
+	volumeFile, format, err := localVolumes.GetVolumeFile(volume.Id)
+	if err != nil {
+		return err
+	}
+	if format == types.ImageFormat_RAW {
+		err = d.exportWithProgress(volume, volumeFile, rawImage)
+	} else {
+		d.notify(types.NewMigrationProgressEvent(volume.Id, &types.MigrationProgress{VolumeName: volume.Name, Stage: migrationStageExport}))
+		err = common.ConvertRawImage(format, types.ImageFormat_RAW, volumeFile, rawImage)
+	}
+	if err != nil {
+		return errors.New("exporting volume "+volume.Name+" to raw image", err)
+	}
+	return nil
+}
+
+// exportWithProgress copies a raw volume file, publishing the number of bytes copied so far
+func (d *UnikDaemon) exportWithProgress(volume *types.Volume, volumeFile, rawImage string) error {
+	in, err := os.Open(volumeFile)
+	if err != nil {
+		return errors.New("opening volume file", err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return errors.New("statting volume file", err)
+	}
+	out, err := os.Create(rawImage)
+	if err != nil {
+		return errors.New("creating raw image", err)
+	}
+	defer out.Close()
+
+	progress := &migrationProgressWriter{
+		notify:     d.notify,
+		volumeId:   volume.Id,
+		progress:   types.MigrationProgress{VolumeName: volume.Name, Stage: migrationStageExport, BytesTotal: info.Size()},
+		started:    time.Now(),
+		lastUpdate: time.Now(),
+	}
+	if _, err := io.Copy(out, io.TeeReader(in, progress)); err != nil {
+		return errors.New("copying volume file", err)
+	}
+	progress.publish()
+	return out.Close()
+}
+
+// uploadWithProgress creates the volume newName from the export of volume on the
+// target provider. providers don't report how far along they are, so until they
+// return the elapsed time is published along with the size of the data being uploaded.
+func (d *UnikDaemon) uploadWithProgress(volume *types.Volume, targetProvider providers.Provider, rawImage, newName string) (*types.Volume, error) {
+	info, err := os.Stat(rawImage)
+	if err != nil {
+		return nil, errors.New("statting raw image", err)
+	}
+	started := time.Now()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(migrationProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				d.notify(types.NewMigrationProgressEvent(volume.Id, &types.MigrationProgress{
+					VolumeName: volume.Name,
+					Stage:      migrationStageUpload,
+					BytesTotal: info.Size(),
+					Elapsed:    time.Since(started),
+				}))
+			}
+		}
+	}()
+	return targetProvider.CreateVolume(types.CreateVolumeParams{Name: newName, ImagePath: rawImage})
+}
+
+type migrationProgressWriter struct {
+	notify     func(types.Event)
+	volumeId   string
+	progress   types.MigrationProgress
+	started    time.Time
+	lastUpdate time.Time
+}
+
+func (w *migrationProgressWriter) Write(p []byte) (int, error) {
+	w.progress.BytesDone += int64(len(p))
+	if time.Since(w.lastUpdate) >= migrationProgressInterval {
+		w.publish()
+	}
+	return len(p), nil
+}
+
+func (w *migrationProgressWriter) publish() {
+	w.lastUpdate = time.Now()
+	progress := w.progress
+	progress.Elapsed = time.Since(w.started)
+	w.notify(types.NewMigrationProgressEvent(w.volumeId, &progress))
+}
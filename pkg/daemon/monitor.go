@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const instanceMonitorInterval = 5 * time.Second
+
+// instanceMonitor polls the providers for instances that carry a restart
+// policy and restarts the ones that have gone down.
+type instanceMonitor struct {
+	providers     providers.Providers
+	lock          sync.Mutex
+	stoppedByUser map[string]bool
+	downSince     map[string]time.Time
+	lastState     map[string]types.InstanceState
+	notify        func(types.Event)
+	done          chan struct{}
+	stopOnce      sync.Once
+}
+
+func newInstanceMonitor(providers providers.Providers, notify func(types.Event)) *instanceMonitor {
+	return &instanceMonitor{
+		providers:     providers,
+		stoppedByUser: make(map[string]bool),
+		downSince:     make(map[string]time.Time),
+		lastState:     make(map[string]types.InstanceState),
+		notify:        notify,
+		done:          make(chan struct{}),
+	}
+}
+
+func (m *instanceMonitor) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+func (m *instanceMonitor) stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+// setStoppedByUser records that an instance was stopped on purpose, so that
+// an "always" policy doesn't immediately bring it back up.
+func (m *instanceMonitor) setStoppedByUser(id string, stopped bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if stopped {
+		m.stoppedByUser[id] = true
+	} else {
+		delete(m.stoppedByUser, id)
+	}
+}
+
+func (m *instanceMonitor) check() {
+	for providerName, provider := range m.providers {
+		instances, err := provider.ListInstances()
+		if err != nil {
+			logrus.WithError(err).Warnf("instance monitor: listing instances for provider %s", providerName)
+			continue
+		}
+		for _, instance := range instances {
+			m.detectCrash(instance)
+			if m.needsRestart(instance) {
+				m.restart(provider, instance)
+			}
+		}
+	}
+}
+
+func (m *instanceMonitor) detectCrash(instance *types.Instance) {
+	m.lock.Lock()
+	previous, seen := m.lastState[instance.Id]
+	m.lastState[instance.Id] = instance.State
+	m.lock.Unlock()
+	if seen && previous != types.InstanceState_Error && instance.State == types.InstanceState_Error {
+		m.notify(types.NewInstanceEvent(types.EventType_InstanceCrashed, instance))
+	}
+}
+
+func (m *instanceMonitor) needsRestart(instance *types.Instance) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	policy := instance.RestartPolicy
+	if policy == nil || policy.Mode == types.RestartMode_Never {
+		return false
+	}
+	var down bool
+	switch instance.State {
+	case types.InstanceState_Error:
+		down = true
+	case types.InstanceState_Stopped:
+		down = policy.Mode == types.RestartMode_Always && !m.stoppedByUser[instance.Id]
+	}
+	if !down {
+		delete(m.downSince, instance.Id)
+		return false
+	}
+	if policy.MaxRestarts > 0 && instance.RestartCount >= policy.MaxRestarts {
+		return false
+	}
+	since, ok := m.downSince[instance.Id]
+	if !ok {
+		since = time.Now()
+		m.downSince[instance.Id] = since
+	}
+	return time.Since(since) >= time.Duration(policy.BackoffSeconds)*time.Second
+}
+
+func (m *instanceMonitor) restart(provider providers.Provider, instance *types.Instance) {
+	logrus.WithFields(logrus.Fields{
+		"instance": instance.Id,
+		"state":    instance.State,
+		"policy":   instance.RestartPolicy,
+		"restarts": instance.RestartCount,
+	}).Infof("restarting instance %s", instance.Name)
+
+	m.lock.Lock()
+	// wait out another backoff period before trying again, whether or not this attempt succeeds
+	m.downSince[instance.Id] = time.Now()
+	m.lock.Unlock()
+
+	// failed attempts count towards MaxRestarts too, so a broken instance isn't retried forever
+	if err := provider.GetState().ModifyInstances(func(instances map[string]*types.Instance) error {
+		if stored, ok := instances[instance.Id]; ok {
+			stored.RestartCount++
+		}
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Warnf("instance monitor: failed to record restart of instance %s", instance.Id)
+	}
+	if err := provider.StartInstance(instance.Id); err != nil {
+		logrus.WithError(err).Warnf("instance monitor: failed to restart instance %s", instance.Id)
+	}
+}
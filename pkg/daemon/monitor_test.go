@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("instanceMonitor", func() {
+	var (
+		dir      string
+		provider *fakeProvider
+		monitor  *instanceMonitor
+		events   []types.Event
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.monitor.")
+		Expect(err).NotTo(HaveOccurred())
+		provider = newFakeProvider(dir)
+		events = nil
+		monitor = newInstanceMonitor(providers.Providers{"fake": provider}, func(event types.Event) {
+			events = append(events, event)
+		})
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	setInstance := func(instanceState types.InstanceState, policy *types.RestartPolicy) {
+		Expect(provider.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+			instance, ok := instances["instance"]
+			if !ok {
+				instance = &types.Instance{Id: "instance", Name: "instance"}
+				instances[instance.Id] = instance
+			}
+			instance.State = instanceState
+			instance.RestartPolicy = policy
+			return nil
+		})).To(Succeed())
+	}
+	restartCount := func() int {
+		instance, err := provider.GetInstance("instance")
+		Expect(err).NotTo(HaveOccurred())
+		return instance.RestartCount
+	}
+
+	It("should restart instances that crash with an on-failure policy", func() {
+		setInstance(types.InstanceState_Error, &types.RestartPolicy{Mode: types.RestartMode_OnFailure})
+		monitor.check()
+		Expect(provider.started).To(Equal([]string{"instance"}))
+		Expect(restartCount()).To(Equal(1))
+	})
+
+	It("should only restart stopped instances with an always policy", func() {
+		setInstance(types.InstanceState_Stopped, &types.RestartPolicy{Mode: types.RestartMode_OnFailure})
+		monitor.check()
+		Expect(provider.started).To(BeEmpty())
+
+		setInstance(types.InstanceState_Stopped, &types.RestartPolicy{Mode: types.RestartMode_Always})
+		monitor.check()
+		Expect(provider.started).To(Equal([]string{"instance"}))
+	})
+
+	It("should not restart instances stopped by the user", func() {
+		setInstance(types.InstanceState_Stopped, &types.RestartPolicy{Mode: types.RestartMode_Always})
+		monitor.setStoppedByUser("instance", true)
+		monitor.check()
+		Expect(provider.started).To(BeEmpty())
+	})
+
+	It("should not restart instances without a policy", func() {
+		setInstance(types.InstanceState_Error, nil)
+		monitor.check()
+		setInstance(types.InstanceState_Error, &types.RestartPolicy{Mode: types.RestartMode_Never})
+		monitor.check()
+		Expect(provider.started).To(BeEmpty())
+	})
+
+	It("should wait out the backoff before restarting", func() {
+		setInstance(types.InstanceState_Error, &types.RestartPolicy{Mode: types.RestartMode_OnFailure, BackoffSeconds: 30})
+		monitor.check()
+		Expect(provider.started).To(BeEmpty())
+
+		// the instance has been down for longer than the backoff
+		monitor.downSince["instance"] = time.Now().Add(-31 * time.Second)
+		monitor.check()
+		Expect(provider.started).To(Equal([]string{"instance"}))
+
+		// the next attempt waits for another backoff period
+		setInstance(types.InstanceState_Error, &types.RestartPolicy{Mode: types.RestartMode_OnFailure, BackoffSeconds: 30})
+		monitor.check()
+		Expect(provider.started).To(HaveLen(1))
+	})
+
+	It("should stop restarting after MaxRestarts attempts, failed ones included", func() {
+		provider.startErr = errors.New("qemu crashed on start", nil)
+		setInstance(types.InstanceState_Error, &types.RestartPolicy{Mode: types.RestartMode_OnFailure, MaxRestarts: 2})
+		for i := 0; i < 5; i++ {
+			monitor.check()
+		}
+		Expect(provider.started).To(HaveLen(2))
+		Expect(restartCount()).To(Equal(2))
+	})
+
+	It("should publish an instance_crashed event when a running instance goes into error", func() {
+		setInstance(types.InstanceState_Running, &types.RestartPolicy{Mode: types.RestartMode_Never})
+		monitor.check()
+		Expect(events).To(BeEmpty())
+		setInstance(types.InstanceState_Error, &types.RestartPolicy{Mode: types.RestartMode_Never})
+		monitor.check()
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Type).To(Equal(types.EventType_InstanceCrashed))
+	})
+})
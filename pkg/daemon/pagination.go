@@ -0,0 +1,160 @@
+package daemon
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const totalCountHeader = "X-Total-Count"
+
+var instanceSortFields = []string{"name", "created", "state", "provider"}
+
+var volumeSortFields = []string{"name", "size", "created", "provider"}
+
+// listPage is the slice of a sorted list requested with the sort, order, limit
+// and offset query parameters. a limit of 0 means no limit.
+type listPage struct {
+	sort   string
+	desc   bool
+	limit  int
+	offset int
+}
+
+func parseListPage(query url.Values, sortFields []string) (listPage, error) {
+	page := listPage{sort: query.Get("sort")}
+	if page.sort == "" {
+		page.sort = sortFields[0]
+	}
+	if !contains(sortFields, page.sort) {
+		return page, errors.New("cannot sort by "+page.sort+". Available: "+strings.Join(sortFields, "|"), nil)
+	}
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+	case "desc":
+		page.desc = true
+	default:
+		return page, errors.New("order must be asc or desc", nil)
+	}
+	var err error
+	if limit := query.Get("limit"); limit != "" {
+		if page.limit, err = strconv.Atoi(limit); err != nil || page.limit < 0 {
+			return page, errors.New("limit must be a non-negative integer", err)
+		}
+	}
+	if offset := query.Get("offset"); offset != "" {
+		if page.offset, err = strconv.Atoi(offset); err != nil || page.offset < 0 {
+			return page, errors.New("offset must be a non-negative integer", err)
+		}
+	}
+	return page, nil
+}
+
+// bounds returns the indexes of the page in a list of total items
+func (p listPage) bounds(total int) (int, int) {
+	start := p.offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if p.limit > 0 && start+p.limit < total {
+		end = start + p.limit
+	}
+	return start, end
+}
+
+// linkHeader returns an RFC 5988 Link header pointing at the next and previous
+// pages of the list at u, or "" if everything fits on one page
+func (p listPage) linkHeader(u *url.URL, total int) string {
+	if p.limit == 0 {
+		return ""
+	}
+	links := []string{}
+	pageUrl := func(offset int) string {
+		query := u.Query()
+		query.Set("offset", strconv.Itoa(offset))
+		query.Set("limit", strconv.Itoa(p.limit))
+		return u.Path + "?" + query.Encode()
+	}
+	if p.offset+p.limit < total {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageUrl(p.offset+p.limit)))
+	}
+	if p.offset > 0 {
+		prev := p.offset - p.limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageUrl(prev)))
+	}
+	return strings.Join(links, ", ")
+}
+
+func sortInstances(instances []*types.Instance, field string, desc bool) {
+	var sorted sort.Interface = instancesBy{instances, field}
+	if desc {
+		sorted = sort.Reverse(sorted)
+	}
+	sort.Stable(sorted)
+}
+
+type instancesBy struct {
+	instances []*types.Instance
+	field     string
+}
+
+func (s instancesBy) Len() int      { return len(s.instances) }
+func (s instancesBy) Swap(i, j int) { s.instances[i], s.instances[j] = s.instances[j], s.instances[i] }
+func (s instancesBy) Less(i, j int) bool {
+	a, b := s.instances[i], s.instances[j]
+	switch s.field {
+	case "created":
+		return a.Created.Before(b.Created)
+	case "state":
+		return a.State < b.State
+	case "provider":
+		return a.Infrastructure < b.Infrastructure
+	}
+	return a.Name < b.Name
+}
+
+func sortVolumes(volumes []*types.Volume, field string, desc bool) {
+	var sorted sort.Interface = volumesBy{volumes, field}
+	if desc {
+		sorted = sort.Reverse(sorted)
+	}
+	sort.Stable(sorted)
+}
+
+type volumesBy struct {
+	volumes []*types.Volume
+	field   string
+}
+
+func (s volumesBy) Len() int      { return len(s.volumes) }
+func (s volumesBy) Swap(i, j int) { s.volumes[i], s.volumes[j] = s.volumes[j], s.volumes[i] }
+func (s volumesBy) Less(i, j int) bool {
+	a, b := s.volumes[i], s.volumes[j]
+	switch s.field {
+	case "size":
+		return a.SizeMb < b.SizeMb
+	case "created":
+		return a.Created.Before(b.Created)
+	case "provider":
+		return a.Infrastructure < b.Infrastructure
+	}
+	return a.Name < b.Name
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// defaultPlatformAliases are the platform names build --platform takes without
+// any platform_aliases in the daemon config
+var defaultPlatformAliases = map[string]string{
+	"qemu": qemu_provider,
+	"kvm":  qemu_provider,
+	"xen":  xen_provider,
+	"aws":  aws_provider,
+	"ec2":  aws_provider,
+	"gcp":  gcloud_provider,
+	"gce":  gcloud_provider,
+	"vbox": virtualbox_provider,
+}
+
+// newPlatformAliases returns the default aliases with the configured ones added.
+// a configured alias replaces the default one of the same name
+func newPlatformAliases(configured map[string]string) map[string]string {
+	aliases := make(map[string]string)
+	for platform, providerName := range defaultPlatformAliases {
+		aliases[platform] = providerName
+	}
+	for platform, providerName := range configured {
+		aliases[strings.ToLower(platform)] = providerName
+	}
+	return aliases
+}
+
+// ResolvePlatformAlias returns the name of the provider builds for platform are
+// made on. it fails for platforms that are not aliases, and for aliases of
+// providers the daemon was not configured with
+func (d *UnikDaemon) ResolvePlatformAlias(platform string) (string, error) {
+	providerName, ok := d.platformAliases[strings.ToLower(platform)]
+	if !ok {
+		known := []string{}
+		for alias := range d.availablePlatforms() {
+			known = append(known, alias)
+		}
+		sort.Strings(known)
+		return "", errors.New(platform+" is not a known platform. Available: "+strings.Join(known, "|"), nil)
+	}
+	if _, ok := d.providers[providerName]; !ok {
+		return "", errors.New("platform "+platform+" is built on provider "+providerName+", which is not configured on this daemon", nil)
+	}
+	return providerName, nil
+}
+
+// availablePlatforms returns the aliases of the providers the daemon has
+func (d *UnikDaemon) availablePlatforms() map[string]string {
+	available := make(map[string]string)
+	for platform, providerName := range d.platformAliases {
+		if _, ok := d.providers[providerName]; ok {
+			available[platform] = providerName
+		}
+	}
+	return available
+}
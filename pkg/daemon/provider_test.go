@@ -0,0 +1,172 @@
+package daemon
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// fakeProvider keeps its resources in a state saved in dir, and its volumes as
+// raw files in dir
+type fakeProvider struct {
+	common.ProviderState
+	dir string
+	// newFilesystem makes CreateVolume give the volumes it creates from an image
+	// a filesystem of their own, as providers that copy files rather than disks do
+	newFilesystem bool
+	// started are the ids StartInstance was called with. it fails with startErr if set
+	started  []string
+	startErr error
+}
+
+func newFakeProvider(dir string) *fakeProvider {
+	return &fakeProvider{ProviderState: common.ProviderState{State: state.NewBasicState(filepath.Join(dir, "state.json"))}, dir: dir}
+}
+
+func (p *fakeProvider) GetConfig() providers.ProviderConfig { return providers.ProviderConfig{} }
+
+func (p *fakeProvider) Stage(params types.StageImageParams) (*types.Image, error) {
+	return nil, errors.New("not implemented", nil)
+}
+
+func (p *fakeProvider) ListImages() ([]*types.Image, error) {
+	var images []*types.Image
+	for _, image := range p.State.GetImages() {
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func (p *fakeProvider) GetImage(nameOrIdPrefix string) (*types.Image, error) {
+	return common.GetImage(p, nameOrIdPrefix)
+}
+
+func (p *fakeProvider) DeleteImage(id string, force bool) error {
+	image, err := p.GetImage(id)
+	if err != nil {
+		return err
+	}
+	return p.State.RemoveImage(image)
+}
+
+func (p *fakeProvider) RunInstance(params types.RunInstanceParams) (*types.Instance, error) {
+	return nil, errors.New("not implemented", nil)
+}
+
+func (p *fakeProvider) ListInstances() ([]*types.Instance, error) {
+	var instances []*types.Instance
+	for _, instance := range p.State.GetInstances() {
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func (p *fakeProvider) GetInstance(nameOrIdPrefix string) (*types.Instance, error) {
+	return common.GetInstance(p, nameOrIdPrefix)
+}
+
+func (p *fakeProvider) DeleteInstance(id string, force bool) error {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return err
+	}
+	return p.State.RemoveInstance(instance)
+}
+
+func (p *fakeProvider) StartInstance(id string) error {
+	p.started = append(p.started, id)
+	if p.startErr != nil {
+		return p.startErr
+	}
+	return p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+		instances[id].State = types.InstanceState_Running
+		return nil
+	})
+}
+
+func (p *fakeProvider) StopInstance(id string) error {
+	return errors.New("not implemented", nil)
+}
+
+func (p *fakeProvider) GetInstanceLogs(id string) (string, error) {
+	return "", errors.New("not implemented", nil)
+}
+
+func (p *fakeProvider) CreateVolume(params types.CreateVolumeParams) (*types.Volume, error) {
+	volumeFile := filepath.Join(p.dir, params.Name+".img")
+	if err := unikos.CopyFile(params.ImagePath, volumeFile); err != nil {
+		return nil, err
+	}
+	if p.newFilesystem {
+		if err := unikos.RunLogCommand("mkfs.ext2", "-q", "-F", volumeFile); err != nil {
+			return nil, err
+		}
+	}
+	volume := &types.Volume{Id: params.Name, Name: params.Name, Created: time.Now()}
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		volumes[volume.Id] = volume
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return volume, nil
+}
+
+func (p *fakeProvider) ListVolumes() ([]*types.Volume, error) {
+	var volumes []*types.Volume
+	for _, volume := range p.State.GetVolumes() {
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
+
+func (p *fakeProvider) GetVolume(nameOrIdPrefix string) (*types.Volume, error) {
+	return common.GetVolume(p, nameOrIdPrefix)
+}
+
+func (p *fakeProvider) DeleteVolume(id string, force bool) error {
+	volume, err := p.GetVolume(id)
+	if err != nil {
+		return err
+	}
+	return p.State.RemoveVolume(volume)
+}
+
+func (p *fakeProvider) AttachVolume(id, instanceId, mntPoint string) error {
+	return errors.New("not implemented", nil)
+}
+
+func (p *fakeProvider) DetachVolume(id string) error {
+	return errors.New("not implemented", nil)
+}
+
+func (p *fakeProvider) PullImage(params types.PullImagePararms) error {
+	return errors.New("not implemented", nil)
+}
+
+func (p *fakeProvider) PushImage(params types.PushImagePararms) error {
+	return errors.New("not implemented", nil)
+}
+
+func (p *fakeProvider) RemoteDeleteImage(params types.RemoteDeleteImagePararms) error {
+	return errors.New("not implemented", nil)
+}
+
+// GetImageFile makes the images of fakeProvider raw files in dir, named like their ids
+func (p *fakeProvider) GetImageFile(id string) (string, types.ImageFormat, error) {
+	return filepath.Join(p.dir, id+".image"), types.ImageFormat_RAW, nil
+}
+
+func (p *fakeProvider) GetVolumeFile(id string) (string, types.ImageFormat, error) {
+	volume, err := p.GetVolume(id)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(p.dir, volume.Name+".img"), types.ImageFormat_RAW, nil
+}
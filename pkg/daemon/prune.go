@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// PruneImages deletes the images selected by opts that no instance uses and no
+// manifest lists, and returns their names. Images that fail to be deleted are left out.
+func (d *UnikDaemon) PruneImages(opts types.PruneOptions) ([]string, error) {
+	result, err := d.pruneImages(opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.ImagesDeleted, nil
+}
+
+func (d *UnikDaemon) pruneImages(opts types.PruneOptions) (*types.PruneResult, error) {
+	inUse := imagesInUse(d.providers, d.manifestImages())
+	var candidates []gcCandidate
+	for _, provider := range d.providers {
+		images, err := provider.ListImages()
+		if err != nil {
+			return nil, errors.New("could not get image list", err)
+		}
+		for _, image := range images {
+			if inUse[image.Id] || inUse[image.Name] || !pruneSelects(opts, image) {
+				continue
+			}
+			candidates = append(candidates, gcCandidate{image: image, provider: provider})
+		}
+	}
+	sort.Sort(gcCandidatesByAge(candidates))
+
+	result := &types.PruneResult{ImagesDeleted: []string{}, DryRun: opts.DryRun}
+	var errs []string
+	for _, candidate := range candidates {
+		image := candidate.image
+		if !opts.DryRun {
+			logrus.WithFields(logrus.Fields{"image": image.Name, "created": image.Created}).Infof("pruning image %s", image.Name)
+			if err := candidate.provider.DeleteImage(image.Id, false); err != nil {
+				errs = append(errs, image.Name+": "+err.Error())
+				continue
+			}
+			d.quotas.releaseImage(candidate.provider, image.Name)
+			d.removeImageSignature(image.Id)
+		}
+		result.ImagesDeleted = append(result.ImagesDeleted, image.Name)
+		result.BytesFreed += image.SizeMb << 20
+	}
+	result.Error = strings.Join(errs, "; ")
+	return result, nil
+}
+
+func pruneSelects(opts types.PruneOptions, image *types.Image) bool {
+	if opts.Untagged && len(image.Tags) > 0 {
+		return false
+	}
+	if opts.OlderThan > 0 && time.Since(image.Created) <= opts.OlderThan {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,344 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const (
+	quotaExceededCode = "QuotaExceeded"
+	// requests without a user all count against this user
+	anonymousUser = "anonymous"
+)
+
+// the kinds of records the usage of each resource type is kept as in the daemon state
+const (
+	quotaInstances = "quota-instances"
+	quotaVolumes   = "quota-volumes"
+	quotaImages    = "quota-images"
+)
+
+// quotaUsageFile is where daemons before the daemon state kept quota usage
+func quotaUsageFile() string {
+	return filepath.Join(config.Internal.UnikHome, "quota-usage.json")
+}
+
+// requestUser returns the user quota is taken from for req. requests whose user
+// can't be authenticated never get this far
+func (d *UnikDaemon) requestUser(req *http.Request) string {
+	user, _, _ := d.users.user(req)
+	return user
+}
+
+type ownedResource struct {
+	User   string `json:"User"`
+	SizeMb int64  `json:"SizeMb"`
+}
+
+// quotaReservation holds the quota of a resource being created, from the
+// check until the resource exists (commit) or its creation failed (cancel)
+type quotaReservation struct {
+	kind     string
+	key      string
+	resource ownedResource
+}
+
+// quotaManager enforces the quotas of the daemon config. usage is kept in the daemon
+// state by provider and resource name, since the ids of some providers change (those of
+// qemu instances are pids), and resources deleted without going through the daemon
+// (e.g. instances whose ttl ran out) are dropped from it whenever quota is reserved.
+type quotaManager struct {
+	lock      sync.Mutex
+	config    config.QuotaConfig
+	state     state.State
+	providers providers.Providers
+	pending   map[*quotaReservation]bool
+}
+
+func newQuotaManager(quotaConfig config.QuotaConfig, daemonState state.State, _providers providers.Providers) *quotaManager {
+	return &quotaManager{
+		config:    quotaConfig,
+		state:     daemonState,
+		providers: _providers,
+		pending:   make(map[*quotaReservation]bool),
+	}
+}
+
+// importUsageFile moves the usage of a quota-usage.json, which is by id, to the daemon state
+func (m *quotaManager) importUsageFile(usageFile string) error {
+	data, err := ioutil.ReadFile(usageFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.New("reading quota usage file "+usageFile, err)
+	}
+	var usage struct {
+		Instances map[string]ownedResource `json:"Instances"`
+		Volumes   map[string]ownedResource `json:"Volumes"`
+		Images    map[string]ownedResource `json:"Images"`
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return errors.New("failed to unmarshal quota usage file "+usageFile, err)
+	}
+	for providerName, provider := range m.providers {
+		s := provider.GetState()
+		for id, instance := range s.GetInstances() {
+			if resource, ok := usage.Instances[id]; ok {
+				if err := putRecord(m.state, quotaInstances, providerName+"/"+instance.Name, resource); err != nil {
+					return err
+				}
+			}
+		}
+		for id, volume := range s.GetVolumes() {
+			if resource, ok := usage.Volumes[id]; ok {
+				if err := putRecord(m.state, quotaVolumes, providerName+"/"+volume.Name, resource); err != nil {
+					return err
+				}
+			}
+		}
+		for id, image := range s.GetImages() {
+			if resource, ok := usage.Images[id]; ok {
+				if err := putRecord(m.state, quotaImages, providerName+"/"+image.Name, resource); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	logrus.Infof("moved quota usage from %s to the daemon state", usageFile)
+	return os.Remove(usageFile)
+}
+
+func (m *quotaManager) enabled() bool {
+	return m.config != config.QuotaConfig{}
+}
+
+// key names a resource in the usage records
+func (m *quotaManager) key(provider providers.Provider, name string) string {
+	for providerName, p := range m.providers {
+		if p == provider {
+			return providerName + "/" + name
+		}
+	}
+	return name
+}
+
+func (m *quotaManager) usage(kind string) map[string]ownedResource {
+	usage := make(map[string]ownedResource)
+	for key, data := range m.state.GetRecords(kind) {
+		var resource ownedResource
+		if err := json.Unmarshal(data, &resource); err != nil {
+			logrus.WithError(err).Warnf("ignoring unreadable quota usage of %s", key)
+			continue
+		}
+		usage[key] = resource
+	}
+	return usage
+}
+
+// prune drops resources that no longer exist on any provider. it is called with
+// m.lock held, so no reservation is committed between listing resources and dropping them
+func (m *quotaManager) prune() {
+	existing := map[string]map[string]bool{
+		quotaInstances: make(map[string]bool),
+		quotaVolumes:   make(map[string]bool),
+		quotaImages:    make(map[string]bool),
+	}
+	for providerName, provider := range m.providers {
+		for _, instance := range provider.GetState().GetInstances() {
+			existing[quotaInstances][providerName+"/"+instance.Name] = true
+		}
+		for _, volume := range provider.GetState().GetVolumes() {
+			existing[quotaVolumes][providerName+"/"+volume.Name] = true
+		}
+		for _, image := range provider.GetState().GetImages() {
+			existing[quotaImages][providerName+"/"+image.Name] = true
+		}
+	}
+	for kind, keys := range existing {
+		if err := m.state.ModifyRecords(kind, func(records map[string]json.RawMessage) error {
+			for key := range records {
+				if !keys[key] {
+					delete(records, key)
+				}
+			}
+			return nil
+		}); err != nil {
+			logrus.WithError(err).Warnf("failed to prune %s", kind)
+		}
+	}
+}
+
+// owned returns what user has of kind, counting reservations
+func (m *quotaManager) owned(kind, user string) (count, sizeMb int64) {
+	for _, resource := range m.usage(kind) {
+		if resource.User == user {
+			count++
+			sizeMb += resource.SizeMb
+		}
+	}
+	for reservation := range m.pending {
+		if reservation.kind == kind && reservation.resource.User == user {
+			count++
+			sizeMb += reservation.resource.SizeMb
+		}
+	}
+	return count, sizeMb
+}
+
+func exceeded(user, quota string, limit, usage int64) *types.QuotaExceeded {
+	return &types.QuotaExceeded{Code: quotaExceededCode, User: user, Quota: quota, Limit: limit, Usage: usage}
+}
+
+// check returns a *QuotaExceeded if user may not have another resource of kind
+func (m *quotaManager) check(kind, user string, sizeMb int64) error {
+	count, _ := m.owned(kind, user)
+	switch kind {
+	case quotaInstances:
+		if limit := int64(m.config.MaxInstancesPerUser); limit > 0 && count+1 > limit {
+			return exceeded(user, "max_instances_per_user", limit, count+1)
+		}
+		return nil
+	case quotaVolumes:
+		if limit := int64(m.config.MaxVolumesPerUser); limit > 0 && count+1 > limit {
+			return exceeded(user, "max_volumes_per_user", limit, count+1)
+		}
+	case quotaImages:
+		if limit := int64(m.config.MaxImageSizeMB); limit > 0 && sizeMb > limit {
+			return exceeded(user, "max_image_size_mb", limit, sizeMb)
+		}
+	}
+	if m.config.MaxTotalStorageGB == 0 {
+		return nil
+	}
+	limit := int64(m.config.MaxTotalStorageGB) * 1024
+	_, volumesMb := m.owned(quotaVolumes, user)
+	_, imagesMb := m.owned(quotaImages, user)
+	if usage := volumesMb + imagesMb + sizeMb; usage > limit {
+		return exceeded(user, "max_total_storage_gb (in MB)", limit, usage)
+	}
+	return nil
+}
+
+// reserve checks and takes the quota for the resource name of kind on provider in
+// one step, so concurrent requests can't both get the last of a quota. the
+// reservation must be committed once the resource exists, or else cancelled
+func (m *quotaManager) reserve(kind, user string, provider providers.Provider, name string, sizeMb int64) (*quotaReservation, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.prune()
+	if m.enabled() {
+		if err := m.check(kind, user, sizeMb); err != nil {
+			return nil, err
+		}
+	}
+	reservation := &quotaReservation{kind: kind, key: m.key(provider, name), resource: ownedResource{User: user, SizeMb: sizeMb}}
+	m.pending[reservation] = true
+	return reservation, nil
+}
+
+func (m *quotaManager) reserveInstance(user string, provider providers.Provider, name string) (*quotaReservation, error) {
+	return m.reserve(quotaInstances, user, provider, name, 0)
+}
+
+func (m *quotaManager) reserveVolume(user string, provider providers.Provider, name string, sizeMb int64) (*quotaReservation, error) {
+	return m.reserve(quotaVolumes, user, provider, name, sizeMb)
+}
+
+func (m *quotaManager) reserveImage(user string, provider providers.Provider, name string, sizeMb int64) (*quotaReservation, error) {
+	return m.reserve(quotaImages, user, provider, name, sizeMb)
+}
+
+// commit records the usage of a reservation whose resource was created
+func (m *quotaManager) commit(reservation *quotaReservation) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.pending, reservation)
+	if err := putRecord(m.state, reservation.kind, reservation.key, reservation.resource); err != nil {
+		logrus.WithError(err).Warnf("failed to record quota usage of %s", reservation.key)
+	}
+}
+
+// cancel gives back the quota of a resource that could not be created
+func (m *quotaManager) cancel(reservation *quotaReservation) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.pending, reservation)
+}
+
+func (m *quotaManager) release(kind string, provider providers.Provider, name string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := m.key(provider, name)
+	if err := m.state.ModifyRecords(kind, func(records map[string]json.RawMessage) error {
+		delete(records, key)
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Warnf("failed to release quota usage of %s", key)
+	}
+}
+
+func (m *quotaManager) releaseInstance(provider providers.Provider, name string) {
+	m.release(quotaInstances, provider, name)
+}
+
+func (m *quotaManager) releaseVolume(provider providers.Provider, name string) {
+	m.release(quotaVolumes, provider, name)
+}
+
+func (m *quotaManager) releaseImage(provider providers.Provider, name string) {
+	m.release(quotaImages, provider, name)
+}
+
+// rename moves the usage of a resource of kind that was renamed
+func (m *quotaManager) rename(kind string, provider providers.Provider, oldName, newName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	oldKey, newKey := m.key(provider, oldName), m.key(provider, newName)
+	if err := m.state.ModifyRecords(kind, func(records map[string]json.RawMessage) error {
+		if usage, ok := records[oldKey]; ok {
+			delete(records, oldKey)
+			records[newKey] = usage
+		}
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Warnf("failed to move quota usage of %s", oldKey)
+	}
+}
+
+func (m *quotaManager) renameImage(provider providers.Provider, oldName, newName string) {
+	m.rename(quotaImages, provider, oldName, newName)
+}
+
+func (m *quotaManager) renameVolume(provider providers.Provider, oldName, newName string) {
+	m.rename(quotaVolumes, provider, oldName, newName)
+}
+
+func fileSizeMb(file string) (int64, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, errors.New("statting "+file, err)
+	}
+	return (info.Size() + 1<<20 - 1) >> 20, nil
+}
+
+// dirSizeMb is fileSizeMb for the files in dir
+func dirSizeMb(dir string) (int64, error) {
+	size, err := unikos.DirSize(dir)
+	if err != nil {
+		return 0, errors.New("measuring "+dir, err)
+	}
+	return (size + 1<<20 - 1) >> 20, nil
+}
@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("quotas", func() {
+	var (
+		dir         string
+		provider    *fakeProvider
+		daemonState state.State
+		quotas      *quotaManager
+	)
+	addInstance := func(id, name string) {
+		Expect(provider.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+			instances[id] = &types.Instance{Id: id, Name: name}
+			return nil
+		})).To(Succeed())
+	}
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.quotas.")
+		Expect(err).NotTo(HaveOccurred())
+		provider = newFakeProvider(dir)
+		daemonState = state.NewBasicState(filepath.Join(dir, "daemon-state.json"))
+		quotas = newQuotaManager(config.QuotaConfig{MaxInstancesPerUser: 1}, daemonState, providers.Providers{"fake": provider})
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should count reservations until they are cancelled", func() {
+		reservation, err := quotas.reserveInstance("alice", provider, "web")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = quotas.reserveInstance("alice", provider, "worker")
+		Expect(err).To(BeAssignableToTypeOf(&types.QuotaExceeded{}))
+		_, err = quotas.reserveInstance("bob", provider, "worker")
+		Expect(err).NotTo(HaveOccurred())
+
+		quotas.cancel(reservation)
+		_, err = quotas.reserveInstance("alice", provider, "worker")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should keep counting an instance whose id changed until it is gone", func() {
+		reservation, err := quotas.reserveInstance("alice", provider, "web")
+		Expect(err).NotTo(HaveOccurred())
+		addInstance("100", "web")
+		quotas.commit(reservation)
+
+		// qemu instances get a new id when they are restarted
+		Expect(provider.State.RemoveInstance(&types.Instance{Id: "100"})).To(Succeed())
+		addInstance("200", "web")
+		_, err = quotas.reserveInstance("alice", provider, "worker")
+		Expect(err).To(BeAssignableToTypeOf(&types.QuotaExceeded{}))
+
+		Expect(provider.State.RemoveInstance(&types.Instance{Id: "200"})).To(Succeed())
+		_, err = quotas.reserveInstance("alice", provider, "worker")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should keep usage in the daemon state", func() {
+		reservation, err := quotas.reserveInstance("alice", provider, "web")
+		Expect(err).NotTo(HaveOccurred())
+		addInstance("100", "web")
+		quotas.commit(reservation)
+		Expect(daemonState.GetRecords(quotaInstances)).To(HaveKey("fake/web"))
+
+		restarted := newQuotaManager(config.QuotaConfig{MaxInstancesPerUser: 1}, daemonState, providers.Providers{"fake": provider})
+		_, err = restarted.reserveInstance("alice", provider, "worker")
+		Expect(err).To(BeAssignableToTypeOf(&types.QuotaExceeded{}))
+	})
+
+	It("should give back the quota of an instance that failed to run", func() {
+		d := &UnikDaemon{quotas: quotas}
+		_, err := d.runInstance(provider, "alice", types.RunInstanceRequest{InstanceName: "web", ImageName: "app"})
+		Expect(err).To(HaveOccurred())
+		_, err = quotas.reserveInstance("alice", provider, "web")
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
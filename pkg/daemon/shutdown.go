@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/go-martini/martini"
+)
+
+// defaultShutdownTimeout is how long POST /admin/shutdown waits for requests
+// in flight unless it is given a timeout
+const defaultShutdownTimeout = 30 * time.Second
+
+const shutdownPath = "/admin/shutdown"
+
+// requestTracker counts the requests in flight, and turns new ones away with 503
+// once the daemon is shutting down
+type requestTracker struct {
+	inFlight     sync.WaitGroup
+	shuttingDown int32
+}
+
+func (t *requestTracker) handler() martini.Handler {
+	return func(res http.ResponseWriter, req *http.Request, c martini.Context) {
+		if req.URL.Path == shutdownPath {
+			// the shutdown request waits for the others, so it isn't one of them
+			c.Next()
+			return
+		}
+		if t.stopping() {
+			res.WriteHeader(http.StatusServiceUnavailable)
+			res.Write([]byte("daemon is shutting down"))
+			return
+		}
+		if isStream(req) {
+			// streams last until the client goes away; the server closes them on exit
+			c.Next()
+			return
+		}
+		t.inFlight.Add(1)
+		defer t.inFlight.Done()
+		c.Next()
+	}
+}
+
+// isStream reports whether req follows events or the logs of an instance
+func isStream(req *http.Request) bool {
+	follow := strings.ToLower(req.URL.Query().Get("follow"))
+	if req.URL.Path == "/events" {
+		return follow != "false"
+	}
+	return strings.HasSuffix(req.URL.Path, "/logs") && follow == "true"
+}
+
+// stopping reports whether the daemon is shutting down
+func (t *requestTracker) stopping() bool {
+	return atomic.LoadInt32(&t.shuttingDown) == 1
+}
+
+// beginShutdown returns false if the daemon is shutting down already
+func (t *requestTracker) beginShutdown() bool {
+	return atomic.CompareAndSwapInt32(&t.shuttingDown, 0, 1)
+}
+
+// wait returns false if requests are still in flight after timeout
+func (t *requestTracker) wait(timeout time.Duration) bool {
+	drained := make(chan struct{})
+	go func() {
+		t.inFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// shutdown waits up to timeout for the requests in flight, stops the background
+// work of the daemon and writes the state of every provider to disk. Run returns
+// once exit is closed
+func (d *UnikDaemon) shutdown(timeout time.Duration) *types.ShutdownResult {
+	result := &types.ShutdownResult{Errors: make(map[string]string)}
+	if result.Drained = d.requests.wait(timeout); !result.Drained {
+		logrus.Warnf("requests still in flight after %s, shutting down anyway", timeout)
+	}
+	d.monitor.stop()
+	d.reaper.stop()
+	d.imageGC.stop()
+	d.backups.stop()
+	for name, provider := range d.providers {
+		if err := provider.GetState().Flush(); err != nil {
+			logrus.WithError(err).Errorf("failed to write state of provider %s", name)
+			result.Errors[name] = err.Error()
+		}
+	}
+	if err := d.state.Flush(); err != nil {
+		logrus.WithError(err).Errorf("failed to write daemon state")
+		result.Errors["daemon"] = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,237 @@
+package daemon
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"github.com/emc-advanced-dev/unik/pkg/util"
+)
+
+const digestPrefix = "sha256:"
+
+// the kind of records the detached signatures of images are kept as in the daemon state, by image id
+const signatureRecords = "image-signatures"
+
+// imageSignaturesDir is where daemons before the daemon state kept signatures, one file per image id
+func imageSignaturesDir() string {
+	return filepath.Join(config.Internal.UnikHome, "image-signatures")
+}
+
+// digestImage returns the sha256 of the boot disk of an image, as the provider
+// stores it. only images stored on the daemon host can be digested
+func digestImage(provider providers.Provider, image *types.Image) (string, error) {
+	localImages, ok := provider.(providers.LocalImageProvider)
+	if !ok {
+		return "", errors.New("images of this provider are not stored on the daemon host, cannot digest "+image.Name, nil)
+	}
+	imageFile, _, err := localImages.GetImageFile(image.Id)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(imageFile)
+	if err != nil {
+		return "", errors.New("opening image file "+imageFile, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.New("reading image file "+imageFile, err)
+	}
+	return digestPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func decodeDigest(digest string) ([]byte, error) {
+	if !strings.HasPrefix(digest, digestPrefix) {
+		return nil, errors.New("digest "+digest+" is not a sha256 digest", nil)
+	}
+	return hex.DecodeString(strings.TrimPrefix(digest, digestPrefix))
+}
+
+// verifyDigest checks a base64 signature of digest
+func verifyDigest(publicKey crypto.PublicKey, digest, signature string) error {
+	digestBytes, err := decodeDigest(digest)
+	if err != nil {
+		return err
+	}
+	signatureBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return errors.New("signature is not base64", err)
+	}
+	return util.VerifyDigest(publicKey, digestBytes, signatureBytes)
+}
+
+// loadTrustedKeys reads the PEM encoded public keys in dir, by fingerprint. there
+// are none if dir is empty
+func loadTrustedKeys(dir string) (map[string]crypto.PublicKey, error) {
+	keys := make(map[string]crypto.PublicKey)
+	if dir == "" {
+		return keys, nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.New("reading trusted keys directory "+dir, err)
+	}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.New("reading trusted key "+path, err)
+		}
+		key, err := util.ParsePublicKeyPEM(data)
+		if err != nil {
+			return nil, errors.New("trusted key "+path+" is not a PEM encoded public key", err)
+		}
+		fingerprint, err := util.PublicKeyFingerprint(key)
+		if err != nil {
+			return nil, err
+		}
+		keys[fingerprint] = key
+		logrus.WithFields(logrus.Fields{"key": fingerprint, "file": path}).Infof("trusting image signing key")
+	}
+	return keys, nil
+}
+
+// checkTrustedKey fails unless images may be signed with publicKey. any key may
+// sign images if the daemon was given no trusted keys, but then it cannot be
+// made to require signed images
+func (d *UnikDaemon) checkTrustedKey(publicKey crypto.PublicKey) error {
+	if len(d.trustedKeys) == 0 {
+		return nil
+	}
+	fingerprint, err := util.PublicKeyFingerprint(publicKey)
+	if err != nil {
+		return err
+	}
+	if _, ok := d.trustedKeys[fingerprint]; !ok {
+		return errors.New("key "+fingerprint+" is not one of the keys trusted by the daemon", nil)
+	}
+	return nil
+}
+
+// signImage records a signature of the digest of an image, once it has been
+// checked against the image with publicKey. the fingerprint of the key is
+// stored on the image
+func (d *UnikDaemon) signImage(provider providers.Provider, imageName string, publicKey crypto.PublicKey, signature string) (*types.Image, error) {
+	image, err := provider.GetImage(imageName)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := digestImage(provider, image)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDigest(publicKey, digest, signature); err != nil {
+		return nil, errors.New("signature does not match image "+image.Name+" ("+digest+")", err)
+	}
+	fingerprint, err := util.PublicKeyFingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKeyPem, err := util.EncodePublicKeyPEM(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.saveImageSignature(&types.ImageSignature{
+		Image:       image.Name,
+		ImageId:     image.Id,
+		Digest:      digest,
+		Signature:   strings.TrimSpace(signature),
+		PublicKey:   string(publicKeyPem),
+		Fingerprint: fingerprint,
+		Signed:      time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	return modifyImage(provider, image.Id, func(image *types.Image) {
+		image.SignatureKeyFingerprint = fingerprint
+	})
+}
+
+// checkImageSignature fails unless the image is signed with a trusted key and its
+// boot disk still has the digest that was signed. the signature is verified with
+// the trusted key, not with the key stored next to it
+func (d *UnikDaemon) checkImageSignature(provider providers.Provider, imageName string) error {
+	image, err := provider.GetImage(imageName)
+	if err != nil {
+		return err
+	}
+	if image.SignatureKeyFingerprint == "" {
+		return errors.New("image "+image.Name+" is not signed", nil)
+	}
+	signature, err := d.loadImageSignature(image.Id)
+	if err != nil {
+		return err
+	}
+	if signature == nil {
+		return errors.New("image "+image.Name+" has no signature in the daemon state", nil)
+	}
+	if signature.Fingerprint != image.SignatureKeyFingerprint {
+		return errors.New("signature of image "+image.Name+" was made with another key than "+image.SignatureKeyFingerprint, nil)
+	}
+	publicKey, ok := d.trustedKeys[signature.Fingerprint]
+	if !ok {
+		return errors.New("image "+image.Name+" was signed with key "+signature.Fingerprint+", which is not trusted by the daemon", nil)
+	}
+	digest, err := digestImage(provider, image)
+	if err != nil {
+		return err
+	}
+	if err := verifyDigest(publicKey, digest, signature.Signature); err != nil {
+		return errors.New("image "+image.Name+" changed since it was signed", err)
+	}
+	return nil
+}
+
+func (d *UnikDaemon) saveImageSignature(signature *types.ImageSignature) error {
+	if err := putRecord(d.state, signatureRecords, signature.ImageId, signature); err != nil {
+		return errors.New("saving signature of image "+signature.Image, err)
+	}
+	return nil
+}
+
+// loadImageSignature returns the signature of an image, nil if it is not signed
+func (d *UnikDaemon) loadImageSignature(imageId string) (*types.ImageSignature, error) {
+	var signature types.ImageSignature
+	ok, err := getRecord(d.state, signatureRecords, imageId, &signature)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &signature, nil
+}
+
+func (d *UnikDaemon) removeImageSignature(imageId string) {
+	if err := deleteRecord(d.state, signatureRecords, imageId); err != nil {
+		logrus.WithError(err).Warnf("failed to remove signature of image %s", imageId)
+	}
+}
+
+// renameImageSignature moves the signature of an image whose id changed with its name
+func (d *UnikDaemon) renameImageSignature(oldId string, image *types.Image) error {
+	signature, err := d.loadImageSignature(oldId)
+	if err != nil || signature == nil {
+		return err
+	}
+	signature.Image = image.Name
+	signature.ImageId = image.Id
+	if err := d.saveImageSignature(signature); err != nil {
+		return err
+	}
+	d.removeImageSignature(oldId)
+	return nil
+}
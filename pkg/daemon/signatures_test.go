@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"github.com/emc-advanced-dev/unik/pkg/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("image signatures", func() {
+	var (
+		dir                string
+		provider           *fakeProvider
+		d                  *UnikDaemon
+		trusted, untrusted *ecdsa.PrivateKey
+		unikHome           string
+	)
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		return key
+	}
+	sign := func(key *ecdsa.PrivateKey) string {
+		digest, err := digestImage(provider, &types.Image{Id: "image-id", Name: "app"})
+		Expect(err).NotTo(HaveOccurred())
+		digestBytes, err := decodeDigest(digest)
+		Expect(err).NotTo(HaveOccurred())
+		signature, err := util.SignDigest(key, digestBytes)
+		Expect(err).NotTo(HaveOccurred())
+		return base64.StdEncoding.EncodeToString(signature)
+	}
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.signatures.")
+		Expect(err).NotTo(HaveOccurred())
+		unikHome = config.Internal.UnikHome
+		config.Internal.UnikHome = dir
+		provider = newFakeProvider(dir)
+		Expect(provider.State.ModifyImages(func(images map[string]*types.Image) error {
+			images["image-id"] = &types.Image{Id: "image-id", Name: "app"}
+			return nil
+		})).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "image-id.image"), []byte("boot disk"), 0644)).To(Succeed())
+
+		trusted, untrusted = newKey(), newKey()
+		keysDir := filepath.Join(dir, "trusted-keys")
+		Expect(os.Mkdir(keysDir, 0755)).To(Succeed())
+		publicKeyPem, err := util.EncodePublicKeyPEM(trusted.Public())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(keysDir, "release.pem"), publicKeyPem, 0644)).To(Succeed())
+		trustedKeys, err := loadTrustedKeys(keysDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trustedKeys).To(HaveLen(1))
+		d = &UnikDaemon{requireSignedImages: true, trustedKeys: trustedKeys, state: state.NewBasicState(filepath.Join(dir, "daemon-state.json"))}
+	})
+	AfterEach(func() {
+		config.Internal.UnikHome = unikHome
+		os.RemoveAll(dir)
+	})
+
+	It("should run images signed with a trusted key", func() {
+		Expect(d.checkTrustedKey(trusted.Public())).To(Succeed())
+		_, err := d.signImage(provider, "app", trusted.Public(), sign(trusted))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.checkImageSignature(provider, "app")).To(Succeed())
+
+		Expect(ioutil.WriteFile(filepath.Join(dir, "image-id.image"), []byte("changed boot disk"), 0644)).To(Succeed())
+		Expect(d.checkImageSignature(provider, "app")).NotTo(Succeed())
+	})
+
+	It("should refuse keys that are not trusted", func() {
+		Expect(d.checkTrustedKey(untrusted.Public())).NotTo(Succeed())
+	})
+
+	It("should not verify signatures with the key stored next to them", func() {
+		// as if the signature had been made before the key was trusted, or
+		// written to the daemon state by hand
+		_, err := (&UnikDaemon{state: d.state}).signImage(provider, "app", untrusted.Public(), sign(untrusted))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.checkImageSignature(provider, "app")).To(MatchError(ContainSubstring("not trusted")))
+	})
+
+	It("should not start without trusted keys when signed images are required", func() {
+		_, err := NewUnikDaemon(config.DaemonConfig{RequireSignedImages: true})
+		Expect(err).To(HaveOccurred())
+	})
+})
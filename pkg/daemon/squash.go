@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+)
+
+// SquashDir is the folder of the uploaded sources holding the contents of the
+// volumes of a build with squash=true, one subfolder per mount point
+const SquashDir = ".unik-squash"
+
+// squashWarnRatio is the share of the image taken by squashed volumes above
+// which the daemon warns that the image is nearly full
+const squashWarnRatio = 0.9
+
+// takeSquashDirs moves the squashed volumes out of sourcesDir, so they are not
+// compiled along with the sources, and returns the folder moved to (which the
+// caller must remove) and the folder holding each mount point's contents.
+func takeSquashDirs(sourcesDir string, mountPoints []string) (string, map[string]string, error) {
+	if len(mountPoints) == 0 {
+		return "", nil, errors.New("squash requires at least one mount point", nil)
+	}
+	squashDir, err := ioutil.TempDir("", "squashed.volumes.dir.")
+	if err != nil {
+		return "", nil, errors.New("creating tmp dir for squashed volumes", err)
+	}
+	// the uploaded folder replaces the empty tmp dir
+	if err := os.Remove(squashDir); err != nil {
+		return "", nil, errors.New("removing "+squashDir, err)
+	}
+	if err := os.Rename(filepath.Join(sourcesDir, SquashDir), squashDir); err != nil {
+		return "", nil, errors.New("squash requires the sources to contain "+SquashDir, err)
+	}
+	squashDirs := make(map[string]string)
+	for _, mntPoint := range mountPoints {
+		dir := filepath.Join(squashDir, mntPoint)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			os.RemoveAll(squashDir)
+			return "", nil, errors.New("no contents were uploaded for mount point "+mntPoint, err)
+		}
+		squashDirs[mntPoint] = dir
+	}
+	return squashDir, squashDirs, nil
+}
+
+// warnIfSquashedNearlyFull warns if the squashed volumes take more than
+// squashWarnRatio of the image they were copied into
+func warnIfSquashedNearlyFull(squashDir, imageFile string) {
+	squashedBytes, err := unikos.DirSize(squashDir)
+	if err != nil {
+		logrus.WithError(err).Warnf("failed to get size of squashed volumes in %s", squashDir)
+		return
+	}
+	info, err := os.Stat(imageFile)
+	if err != nil {
+		logrus.WithError(err).Warnf("failed to stat image %s", imageFile)
+		return
+	}
+	if float64(squashedBytes) > squashWarnRatio*float64(info.Size()) {
+		logrus.WithFields(logrus.Fields{
+			"squashed-bytes": squashedBytes,
+			"image-bytes":    info.Size(),
+		}).Warnf("squashed volumes take more than %v%% of the image; it has little space left", squashWarnRatio*100)
+	}
+}
@@ -0,0 +1,184 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const (
+	defaultEtcdPrefix = "/unik"
+	// a daemon that stops renewing its lease is replaced after this long
+	etcdLeaseTTL = 15 * time.Second
+)
+
+// daemonStateFile keeps what the daemon records besides the state of its
+// providers, such as quota usage
+func daemonStateFile() string {
+	return filepath.Join(config.Internal.UnikHome, "daemon-state.json")
+}
+
+// putRecord stores v as the record key of kind in the daemon state
+func putRecord(s state.State, kind, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.New("marshalling "+kind+" "+key, err)
+	}
+	return s.ModifyRecords(kind, func(records map[string]json.RawMessage) error {
+		records[key] = data
+		return nil
+	})
+}
+
+// getRecord unmarshals the record key of kind into v, and reports whether there is one
+func getRecord(s state.State, kind, key string, v interface{}) (bool, error) {
+	data, ok := s.GetRecords(kind)[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, errors.New("failed to unmarshal "+kind+" "+key, err)
+	}
+	return true, nil
+}
+
+func deleteRecord(s state.State, kind, key string) error {
+	return s.ModifyRecords(kind, func(records map[string]json.RawMessage) error {
+		delete(records, key)
+		return nil
+	})
+}
+
+// importDaemonFile hands the contents of a file of the unik home, in which
+// daemons before the daemon state kept what is now in it, to load, and removes
+// the file once loaded. there is nothing to do if the file doesn't exist
+func importDaemonFile(file string, load func(data []byte) error) error {
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.New("reading "+file, err)
+	}
+	if err := load(data); err != nil {
+		return errors.New("importing "+file, err)
+	}
+	logrus.Infof("moved %s to the daemon state", file)
+	return os.Remove(file)
+}
+
+// importDaemonFiles moves the webhooks, backups, migrations and image signatures
+// that daemons before the daemon state kept in the unik home to daemonState
+func importDaemonFiles(daemonState state.State) error {
+	if err := importDaemonFile(webhooksFile(), func(data []byte) error {
+		var webhooks []*types.Webhook
+		if err := json.Unmarshal(data, &webhooks); err != nil {
+			return err
+		}
+		for _, webhook := range webhooks {
+			if err := putRecord(daemonState, webhookRecords, webhook.Id, webhook); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := importDaemonFile(volumeBackupsFile(), func(data []byte) error {
+		var record backupRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		return putRecord(daemonState, backupRecords, backupRecordKey, record)
+	}); err != nil {
+		return err
+	}
+	if err := importDaemonFile(volumeMigrationsFile(), func(data []byte) error {
+		var migrations []*types.VolumeMigration
+		if err := json.Unmarshal(data, &migrations); err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			if err := putRecord(daemonState, migrationRecords, migrationKey(migration), migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(imageSignaturesDir(), "*.sig"))
+	if err != nil {
+		return errors.New("listing image signatures", err)
+	}
+	for _, file := range files {
+		if err := importDaemonFile(file, func(data []byte) error {
+			var signature types.ImageSignature
+			if err := json.Unmarshal(data, &signature); err != nil {
+				return err
+			}
+			return putRecord(daemonState, signatureRecords, signature.ImageId, signature)
+		}); err != nil {
+			return err
+		}
+	}
+	if len(files) > 0 {
+		os.Remove(imageSignaturesDir())
+	}
+	return nil
+}
+
+// stateOpener returns what opens the state of a provider: its json file, or its
+// keys in etcd. with etcd, this blocks until the daemon is elected leader, and
+// the daemon exits if it loses the leadership, so only one daemon uses the state
+func stateOpener(backend config.StateBackend) (func(provider, saveFile string) (state.State, error), error) {
+	switch backend.Type {
+	case "", "file":
+		return func(provider, saveFile string) (state.State, error) {
+			s, err := state.BasicStateFromFile(saveFile)
+			if err != nil {
+				logrus.WithError(err).Warnf("failed to read %s state file at %s, creating blank %s state", provider, saveFile, provider)
+				s = state.NewBasicState(saveFile)
+			}
+			return s, nil
+		}, nil
+	case "etcd":
+	default:
+		return nil, errors.New("unknown state backend "+backend.Type+". Available: file|etcd", nil)
+	}
+	// a call must give up in time for the lease to be renewed before it runs out
+	client, err := state.NewEtcdClient(backend.EtcdEndpoints, etcdLeaseTTL/3)
+	if err != nil {
+		return nil, err
+	}
+	prefix := backend.EtcdPrefix
+	if prefix == "" {
+		prefix = defaultEtcdPrefix
+	}
+	hostname, _ := os.Hostname()
+	id := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	logrus.WithFields(logrus.Fields{"endpoints": backend.EtcdEndpoints, "daemon": id}).Infof("waiting to become the leader of the daemons using %s", prefix)
+	leadership, err := client.Campaign(path.Join(prefix, "leader"), id, etcdLeaseTTL)
+	if err != nil {
+		return nil, errors.New("electing leader", err)
+	}
+	logrus.Infof("daemon %s is the leader", id)
+	go func() {
+		<-leadership.Lost()
+		// another daemon may take over the state now; it must not be written to any more
+		logrus.Fatalf("daemon %s lost the leadership, exiting", id)
+	}()
+	return func(provider, saveFile string) (state.State, error) {
+		return state.EtcdStateFromPrefix(client, path.Join(prefix, provider))
+	}, nil
+}
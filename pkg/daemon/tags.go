@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// tagFilter parses the tag=key=value query parameters of a list request
+func tagFilter(query url.Values) (map[string]string, error) {
+	tags, err := types.ParseTags(query["tag"])
+	if err != nil {
+		return nil, errors.New("invalid tag filter", err)
+	}
+	return tags, nil
+}
+
+// formTags parses and validates the tags form value of a create request,
+// given as a json object of strings
+func formTags(form url.Values) (map[string]string, error) {
+	var tags map[string]string
+	tagsStr := form.Get("tags")
+	if tagsStr == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(tagsStr), &tags); err != nil {
+		return nil, errors.New("tags must be a json object of strings", err)
+	}
+	if err := types.ValidateTags(tags); err != nil {
+		return nil, errors.New("invalid tags", err)
+	}
+	return tags, nil
+}
+
+// applyTags returns a copy of existing with modify applied to it, or an error
+// if the result breaks the tag rules. existing is left as it is either way.
+func applyTags(existing map[string]string, modify func(tags map[string]string)) (map[string]string, error) {
+	tags := make(map[string]string)
+	for key, value := range existing {
+		tags[key] = value
+	}
+	modify(tags)
+	if err := types.ValidateTags(tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// modifyImageTags applies modify to the tags of an image and saves the image to its provider's state
+func modifyImageTags(provider providers.Provider, imageId string, modify func(tags map[string]string)) (*types.Image, error) {
+	image, err := provider.GetImage(imageId)
+	if err != nil {
+		return nil, errors.New("retrieving image "+imageId, err)
+	}
+	var updated *types.Image
+	if err := provider.GetState().ModifyImages(func(images map[string]*types.Image) error {
+		for _, stored := range images {
+			if stored.Id == image.Id {
+				updated = stored
+			}
+		}
+		if updated == nil {
+			return errors.New("image "+image.Id+" not found in state", nil)
+		}
+		tags, err := applyTags(updated.Tags, modify)
+		if err != nil {
+			return err
+		}
+		updated.Tags = tags
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying image map in state", err)
+	}
+	return updated, nil
+}
+
+// modifyInstanceTags applies modify to the tags of an instance, saves the instance
+// to its provider's state, and copies the result to the provider if it keeps
+// tags of its own
+func modifyInstanceTags(provider providers.Provider, instanceId string, modify func(tags map[string]string)) (*types.Instance, error) {
+	instance, err := provider.GetInstance(instanceId)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+instanceId, err)
+	}
+	var updated *types.Instance
+	if err := provider.GetState().ModifyInstances(func(instances map[string]*types.Instance) error {
+		stored, ok := instances[instance.Id]
+		if !ok {
+			return errors.New("instance "+instance.Id+" not found in state", nil)
+		}
+		tags, err := applyTags(stored.Tags, modify)
+		if err != nil {
+			return err
+		}
+		stored.Tags = tags
+		updated = stored
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying instance map in state", err)
+	}
+	if syncer, ok := provider.(providers.TagSyncer); ok {
+		if err := syncer.SyncTags(updated.Id, updated.Tags); err != nil {
+			return nil, errors.New("syncing tags of instance "+updated.Name+" with provider", err)
+		}
+	}
+	return updated, nil
+}
+
+// modifyVolumeTags applies modify to the tags of a volume, saves the volume
+// to its provider's state, and copies the result to the provider if it keeps
+// tags of its own
+func modifyVolumeTags(provider providers.Provider, volumeId string, modify func(tags map[string]string)) (*types.Volume, error) {
+	volume, err := provider.GetVolume(volumeId)
+	if err != nil {
+		return nil, errors.New("retrieving volume "+volumeId, err)
+	}
+	var updated *types.Volume
+	if err := provider.GetState().ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		stored, ok := volumes[volume.Id]
+		if !ok {
+			return errors.New("volume "+volume.Id+" not found in state", nil)
+		}
+		tags, err := applyTags(stored.Tags, modify)
+		if err != nil {
+			return err
+		}
+		stored.Tags = tags
+		updated = stored
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying volume map in state", err)
+	}
+	if syncer, ok := provider.(providers.VolumeTagSyncer); ok {
+		if err := syncer.SyncVolumeTags(updated.Id, updated.Tags); err != nil {
+			return nil, errors.New("syncing tags of volume "+updated.Name+" with provider", err)
+		}
+	}
+	return updated, nil
+}
+
+func addTags(tags map[string]string) func(map[string]string) {
+	return func(existing map[string]string) {
+		for key, value := range tags {
+			existing[key] = value
+		}
+	}
+}
+
+func removeTag(key string) func(map[string]string) {
+	return func(existing map[string]string) {
+		delete(existing, key)
+	}
+}
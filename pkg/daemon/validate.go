@@ -0,0 +1,187 @@
+package daemon
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+const (
+	validationCheckLoop       = "loop"
+	validationCheckFilesystem = "filesystem"
+	validationCheckKernel     = "kernel"
+	validationCheckGrub       = "grub config"
+	validationCheckBoot       = "boot"
+
+	// how long an image is booted in qemu before its console output is checked
+	validationBootTime = 5 * time.Second
+)
+
+var kernelPanicRegexp = regexp.MustCompile(`(?i)kernel panic|panic:|\bpanic\(`)
+
+// validateImage checks that the boot disk of an image can be looped, has a
+// consistent filesystem holding a kernel and a grub config that parses, and,
+// if boot is set, that it boots in qemu without the kernel panicking.
+func (d *UnikDaemon) validateImage(imageName string, boot bool) (*types.ValidationReport, error) {
+	provider, err := d.providers.ProviderForImage(imageName)
+	if err != nil {
+		return nil, err
+	}
+	localImages, ok := provider.(providers.LocalImageProvider)
+	if !ok {
+		return nil, errors.New("images of this provider are not stored on the daemon host, cannot validate "+imageName, nil)
+	}
+	imageFile, format, err := localImages.GetImageFile(imageName)
+	if err != nil {
+		return nil, err
+	}
+	if format == types.ImageFormat_Kernel {
+		return nil, errors.New("image "+imageName+" is a kernel booted without a boot disk, cannot validate it", nil)
+	}
+	if format != types.ImageFormat_RAW {
+		tmpDir, err := ioutil.TempDir("", "unik.validate-image.")
+		if err != nil {
+			return nil, errors.New("creating temporary directory", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		rawFile := filepath.Join(tmpDir, "image.img")
+		logrus.Debugf("converting %s image %s to raw", format, imageName)
+		if err := common.ConvertRawImage(format, types.ImageFormat_RAW, imageFile, rawFile); err != nil {
+			return nil, errors.New("converting "+imageName+" to raw", err)
+		}
+		imageFile = rawFile
+	}
+
+	report := &types.ValidationReport{Image: imageName}
+	// each check runs only if the ones before it passed
+	runCheck := func(name string, check func() error) {
+		result := &types.ValidationCheck{Name: name}
+		report.Checks = append(report.Checks, result)
+		for _, previous := range report.Checks[:len(report.Checks)-1] {
+			if !previous.Passed {
+				result.Skipped = true
+				result.Message = "skipped because the " + previous.Name + " check did not pass"
+				return
+			}
+		}
+		if err := check(); err != nil {
+			result.Message = err.Error()
+			return
+		}
+		result.Passed = true
+	}
+
+	var offset unikos.Bytes
+	runCheck(validationCheckLoop, func() error {
+		disk := unikos.NewReadOnlyLoDevice(imageFile)
+		dev, err := disk.Acquire()
+		if err != nil {
+			return errors.New("loop mounting image", err)
+		}
+		defer disk.Release()
+		parts, err := unikos.ListParts(dev)
+		if err != nil && err != unikos.ErrNoPartitionTable {
+			return errors.New("listing partitions", err)
+		}
+		if len(parts) > 0 {
+			offset = parts[0].Offset().ToBytes()
+		}
+		return nil
+	})
+	runCheck(validationCheckFilesystem, func() error {
+		return unikos.CheckExt2(imageFile, offset)
+	})
+
+	var mntpoint string
+	var release func() error
+	runCheck(validationCheckKernel, func() error {
+		if mntpoint, release, err = unikos.MountImageReadOnly(imageFile); err != nil {
+			return errors.New("mounting boot partition", err)
+		}
+		info, err := os.Stat(filepath.Join(mntpoint, "boot", unikos.ProgramName))
+		if err != nil {
+			return errors.New("boot/"+unikos.ProgramName+" is missing", err)
+		}
+		if info.Size() == 0 {
+			return errors.New("boot/"+unikos.ProgramName+" is empty", nil)
+		}
+		return nil
+	})
+	runCheck(validationCheckGrub, func() error {
+		menu, err := ioutil.ReadFile(filepath.Join(mntpoint, "boot", "grub", "menu.lst"))
+		if err != nil {
+			return errors.New("reading boot/grub/menu.lst", err)
+		}
+		entry, err := unikos.ParseGrubMenu(string(menu))
+		if err != nil {
+			return errors.New("parsing boot/grub/menu.lst", err)
+		}
+		if _, err := os.Stat(filepath.Join(mntpoint, entry.Kernel)); err != nil {
+			return errors.New("kernel "+entry.Kernel+" of boot entry "+entry.Title+" does not exist", err)
+		}
+		return nil
+	})
+	if release != nil {
+		if err := release(); err != nil {
+			logrus.WithError(err).Warnf("failed to unmount image %s", imageName)
+		}
+	}
+	if boot {
+		runCheck(validationCheckBoot, func() error {
+			return bootCheck(imageFile)
+		})
+	}
+
+	report.Valid = true
+	for _, check := range report.Checks {
+		report.Valid = report.Valid && check.Passed
+	}
+	return report, nil
+}
+
+// bootCheck boots a raw image in qemu for validationBootTime and fails if its
+// console shows a kernel panic, or qemu exits before then
+func bootCheck(imageFile string) error {
+	var console bytes.Buffer
+	cmd := exec.Command("qemu-system-x86_64", "-m", "256", "-snapshot", "-no-reboot",
+		"-drive", "file="+imageFile+",format=raw,if=ide",
+		"-display", "none", "-serial", "stdio", "-monitor", "none")
+	cmd.Stdout = &console
+	cmd.Stderr = &console
+	if err := cmd.Start(); err != nil {
+		return errors.New("starting qemu-system-x86_64, make sure it's in your path", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return errors.New("qemu exited during boot: "+lastLines(console.String(), 10), err)
+	case <-time.After(validationBootTime):
+		cmd.Process.Kill()
+		<-exited
+	}
+	if kernelPanicRegexp.MatchString(console.String()) {
+		return errors.New("kernel panicked during boot: "+lastLines(console.String(), 10), nil)
+	}
+	return nil
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,721 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"github.com/emc-advanced-dev/unik/pkg/util"
+)
+
+// describeVolume returns a volume with the details of its filesystem. the
+// filesystem can only be read for volumes stored on the daemon host; volumes
+// that aren't raw images are converted to a temporary raw image first.
+// a filesystem that can't be read (e.g. FAT) leaves those details empty.
+func (d *UnikDaemon) describeVolume(provider providers.Provider, volumeName string) (*types.VolumeDetails, error) {
+	volume, err := provider.GetVolume(volumeName)
+	if err != nil {
+		return nil, errors.New("retrieving volume "+volumeName, err)
+	}
+	details := &types.VolumeDetails{Volume: volume}
+	localVolumes, ok := provider.(providers.LocalVolumeProvider)
+	if !ok {
+		return details, nil
+	}
+	volumeFile, format, err := localVolumes.GetVolumeFile(volume.Id)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(volumeFile)
+	if err != nil {
+		return nil, errors.New("statting volume file "+volumeFile, err)
+	}
+	details.Modified = info.ModTime()
+
+	if format != types.ImageFormat_RAW {
+		tmpDir, err := ioutil.TempDir("", "unik.describe-volume.")
+		if err != nil {
+			return nil, errors.New("creating temporary directory", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		rawFile := filepath.Join(tmpDir, "volume.img")
+		if err := common.ConvertRawImage(format, types.ImageFormat_RAW, volumeFile, rawFile); err != nil {
+			return nil, errors.New("converting volume "+volume.Name+" to raw", err)
+		}
+		volumeFile = rawFile
+	}
+	var offset unikos.Bytes
+	if parts, err := unikos.ListParts(unikos.BlockDevice(volumeFile)); err == nil && len(parts) > 0 {
+		offset = parts[0].Offset().ToBytes()
+	}
+	fsInfo, err := unikos.ReadExt2Info(volumeFile, offset)
+	if err != nil {
+		logrus.WithError(err).WithField("volume", volume.Name).Debugf("volume has no readable ext filesystem")
+		return details, nil
+	}
+	details.FilesystemType = fsInfo.FilesystemType
+	details.Filesystem = fsInfo
+	return details, nil
+}
+
+// volumeNameTaken reports whether a volume of any provider is named (or, for
+// volumes named by their id, identified by) name
+func (d *UnikDaemon) volumeNameTaken(name string) (bool, error) {
+	for _, provider := range d.providers {
+		volumes, err := provider.ListVolumes()
+		if err != nil {
+			return false, errors.New("listing volumes", err)
+		}
+		for _, volume := range volumes {
+			if volume.Name == name || volume.Id == name {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// renameVolume renames a detached volume on its provider, moving the quota usage of
+// its owner to the new name. if its id changed with the name, the instances that
+// were run with it mounted are moved to the new id
+func (d *UnikDaemon) renameVolume(provider providers.Provider, volume *types.Volume, newName string) (*types.Volume, error) {
+	renamer, ok := provider.(providers.VolumeRenamer)
+	if !ok {
+		return nil, errors.New("the provider of volume "+volume.Name+" cannot rename volumes", nil)
+	}
+	oldId, oldName := volume.Id, volume.Name
+	renamed, err := renamer.RenameVolume(oldId, newName)
+	if err != nil {
+		return nil, errors.New("renaming volume "+oldName, err)
+	}
+	d.quotas.renameVolume(provider, oldName, renamed.Name)
+	if err := d.backups.renameVolume(oldName, renamed.Name); err != nil {
+		logrus.WithError(err).Warnf("failed to move backup schedules of volume %s", oldName)
+	}
+	if renamed.Id == oldId {
+		return renamed, nil
+	}
+	if err := provider.GetState().ModifyInstances(func(instances map[string]*types.Instance) error {
+		for _, instance := range instances {
+			for mountPoint, volumeId := range instance.Mounts {
+				if volumeId == oldId {
+					instance.Mounts[mountPoint] = renamed.Id
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.New("updating instances mounting volume "+oldName, err)
+	}
+	return renamed, nil
+}
+
+// createVolume creates a volume on provider for user, within their quota
+func (d *UnikDaemon) createVolume(provider providers.Provider, user string, params types.CreateVolumeParams) (*types.Volume, error) {
+	var sizeMb int64
+	var err error
+	if params.Mode.IsFolder() {
+		sizeMb, err = dirSizeMb(params.ImagePath)
+	} else {
+		sizeMb, err = fileSizeMb(params.ImagePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := d.quotas.reserveVolume(user, provider, params.Name, sizeMb)
+	if err != nil {
+		return nil, err
+	}
+	volume, err := provider.CreateVolume(params)
+	if err != nil {
+		d.quotas.cancel(reservation)
+		return nil, err
+	}
+	d.quotas.commit(reservation)
+	return volume, nil
+}
+
+// settleVolume commits the quota reservation of the volume name if provider has
+// it, even if what created it failed afterwards, and cancels it otherwise
+func (d *UnikDaemon) settleVolume(reservation *quotaReservation, provider providers.Provider, name string) {
+	if _, err := provider.GetVolume(name); err == nil {
+		d.quotas.commit(reservation)
+	} else {
+		d.quotas.cancel(reservation)
+	}
+}
+
+// cloneVolume copies a detached volume to the new volume newName on
+// targetProvider. on its own provider, providers implementing VolumeCloner make
+// the copy themselves (a snapshot on aws, a reflink or btrfs copy on qemu and
+// libvirt); otherwise the volume is exported to a raw image which the new
+// volume is created from, as for migrations. the copy has the filesystem, size
+// and tags of the volume, and the label and uuid of its filesystem (see
+// keepFilesystemIds), and is ready to be attached. it counts towards the quota of user
+func (d *UnikDaemon) cloneVolume(sourceProvider providers.Provider, sourceProviderName string, targetProvider providers.Provider, targetProviderName, user string, volume *types.Volume, newName string) (*types.Volume, error) {
+	if volume.Attachment != "" {
+		return nil, errors.New("volume "+volume.Name+" is attached to instance "+volume.Attachment+", detach it before cloning", nil)
+	}
+	reservation, err := d.quotas.reserveVolume(user, targetProvider, newName, volume.SizeMb)
+	if err != nil {
+		return nil, err
+	}
+	defer d.settleVolume(reservation, targetProvider, newName)
+	var clone *types.Volume
+	var ids unikos.FilesystemIds
+	if cloner, ok := sourceProvider.(providers.VolumeCloner); ok && sourceProvider == targetProvider {
+		ids = localFilesystemIds(sourceProvider, volume)
+		logrus.WithFields(logrus.Fields{"volume": volume.Name, "provider": sourceProviderName}).Infof("cloning volume to %s", newName)
+		clone, err = cloner.CloneVolume(volume.Id, newName)
+		if err != nil {
+			return nil, errors.New("cloning volume "+volume.Name, err)
+		}
+	} else {
+		tmpDir, err := ioutil.TempDir("", "unik.volume-clone.")
+		if err != nil {
+			return nil, errors.New("creating temporary directory", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		rawImage := filepath.Join(tmpDir, "data.img")
+		logrus.WithFields(logrus.Fields{"volume": volume.Name, "from": sourceProviderName, "to": targetProviderName}).Infof("exporting volume to raw image")
+		if err := d.exportVolume(sourceProvider, sourceProviderName, volume, rawImage); err != nil {
+			return nil, err
+		}
+		ids = readFilesystemIds(volume, rawImage)
+		logrus.WithFields(logrus.Fields{"volume": newName, "to": targetProviderName}).Infof("creating volume from exported image")
+		clone, err = d.uploadWithProgress(volume, targetProvider, rawImage, newName)
+		if err != nil {
+			return nil, errors.New("creating volume "+newName+" on "+targetProviderName, err)
+		}
+	}
+	if err := keepFilesystemIds(targetProvider, clone, ids); err != nil {
+		return nil, err
+	}
+	if len(volume.Tags) > 0 {
+		tagged, err := modifyVolumeTags(targetProvider, clone.Id, addTags(volume.Tags))
+		if err != nil {
+			return nil, errors.New("copying tags of volume "+volume.Name, err)
+		}
+		clone = tagged
+	}
+	return clone, nil
+}
+
+// localFilesystemIds reads the label and uuid of the filesystem of a volume that
+// is a raw file on the daemon host. they are empty for other volumes
+func localFilesystemIds(provider providers.Provider, volume *types.Volume) unikos.FilesystemIds {
+	localVolumes, ok := provider.(providers.LocalVolumeProvider)
+	if !ok {
+		return unikos.FilesystemIds{}
+	}
+	volumeFile, format, err := localVolumes.GetVolumeFile(volume.Id)
+	if err != nil || format != types.ImageFormat_RAW {
+		return unikos.FilesystemIds{}
+	}
+	return readFilesystemIds(volume, volumeFile)
+}
+
+// readFilesystemIds reads the label and uuid of the filesystem of rawImage, a raw
+// image of volume. if blkid can't read them (e.g. there is no blkid on the daemon
+// host) they are empty, and copies of the volume keep whatever they get
+func readFilesystemIds(volume *types.Volume, rawImage string) unikos.FilesystemIds {
+	ids, err := unikos.ReadFilesystemIds(rawImage)
+	if err != nil {
+		logrus.WithError(err).Warnf("could not read the filesystem label and uuid of volume %s", volume.Name)
+	}
+	return ids
+}
+
+// keepFilesystemIds gives the filesystem of copy, a copy of another volume, ids,
+// the label and uuid of the source, so fstab entries and mounts by uuid find
+// it. copies stored on the daemon host are checked and changed if the provider
+// made a new filesystem for them; qcow2 volume files are checked as a temporary
+// raw image, which is converted back if it is changed. copies stored elsewhere
+// are left as the provider made them
+func keepFilesystemIds(provider providers.Provider, copy *types.Volume, ids unikos.FilesystemIds) error {
+	if ids == (unikos.FilesystemIds{}) {
+		return nil
+	}
+	localVolumes, ok := provider.(providers.LocalVolumeProvider)
+	if !ok {
+		logrus.Debugf("volume %s is not stored on the daemon host, not checking its label and uuid", copy.Name)
+		return nil
+	}
+	volumeFile, format, err := localVolumes.GetVolumeFile(copy.Id)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case types.ImageFormat_RAW:
+		if err := unikos.SetFilesystemIds(volumeFile, ids); err != nil {
+			return errors.New("giving volume "+copy.Name+" the label "+ids.Label+" and uuid "+ids.UUID+" of its source", err)
+		}
+	case types.ImageFormat_QCOW2:
+		tmpDir, err := ioutil.TempDir("", "unik.volume-ids.")
+		if err != nil {
+			return errors.New("creating temporary directory", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		rawFile := filepath.Join(tmpDir, "volume.img")
+		if err := common.ConvertRawImage(format, types.ImageFormat_RAW, volumeFile, rawFile); err != nil {
+			return errors.New("converting volume "+copy.Name+" to raw", err)
+		}
+		current, err := unikos.ReadFilesystemIds(rawFile)
+		if err != nil {
+			return errors.New("reading the label and uuid of volume "+copy.Name, err)
+		}
+		if current == ids {
+			return nil
+		}
+		if err := unikos.SetFilesystemIds(rawFile, ids); err != nil {
+			return errors.New("giving volume "+copy.Name+" the label "+ids.Label+" and uuid "+ids.UUID+" of its source", err)
+		}
+		if err := convertVolumeFile(types.ImageFormat_RAW, rawFile, format, volumeFile); err != nil {
+			return errors.New("converting volume "+copy.Name+" back to "+string(format), err)
+		}
+	default:
+		logrus.Debugf("volume %s is a %s file, not checking its label and uuid", copy.Name, format)
+	}
+	return nil
+}
+
+// trimVolume discards the blocks of the filesystem of a detached volume that no
+// longer hold data (see unikos.TrimImage), so the volume file on the daemon host
+// shrinks. raw volume files are trimmed in place, and with compact rewritten
+// with qemu-img convert. qcow2 volume files are trimmed as a temporary raw image,
+// which is converted back to qcow2. other formats are not trimmed. the daemon
+// has to be able to set up loop devices and mount filesystems (root on linux)
+func (d *UnikDaemon) trimVolume(provider providers.Provider, volume *types.Volume, compact bool) (*types.VolumeTrimResult, error) {
+	localVolumes, ok := provider.(providers.LocalVolumeProvider)
+	if !ok {
+		return nil, errors.New("volumes of this provider are not stored on the daemon host, cannot trim "+volume.Name, nil)
+	}
+	volumeFile, format, err := localVolumes.GetVolumeFile(volume.Id)
+	if err != nil {
+		return nil, err
+	}
+	allocatedBefore, err := unikos.AllocatedSize(volumeFile)
+	if err != nil {
+		return nil, errors.New("statting volume file "+volumeFile, err)
+	}
+	result := &types.VolumeTrimResult{Volume: volume.Name}
+	var trimmed unikos.Bytes
+	switch format {
+	case types.ImageFormat_RAW:
+		trimmed, err = unikos.TrimImage(volumeFile)
+		if err != nil {
+			return nil, errors.New("trimming volume "+volume.Name, err)
+		}
+		if compact {
+			if err := convertVolumeFile(format, volumeFile, format, volumeFile); err != nil {
+				return nil, errors.New("compacting volume "+volume.Name, err)
+			}
+			result.Compacted = true
+		}
+	case types.ImageFormat_QCOW2:
+		tmpDir, err := ioutil.TempDir("", "unik.volume-trim.")
+		if err != nil {
+			return nil, errors.New("creating temporary directory", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		rawFile := filepath.Join(tmpDir, "volume.img")
+		if err := common.ConvertRawImage(format, types.ImageFormat_RAW, volumeFile, rawFile); err != nil {
+			return nil, errors.New("converting volume "+volume.Name+" to raw", err)
+		}
+		trimmed, err = unikos.TrimImage(rawFile)
+		if err != nil {
+			return nil, errors.New("trimming volume "+volume.Name, err)
+		}
+		if err := convertVolumeFile(types.ImageFormat_RAW, rawFile, format, volumeFile); err != nil {
+			return nil, errors.New("converting trimmed volume "+volume.Name+" back to "+string(format), err)
+		}
+		result.Compacted = true
+	default:
+		return nil, errors.New("volume "+volume.Name+" is stored as "+string(format)+", only raw and qcow2 volumes can be trimmed", nil)
+	}
+	allocatedAfter, err := unikos.AllocatedSize(volumeFile)
+	if err != nil {
+		return nil, errors.New("statting volume file "+volumeFile, err)
+	}
+	result.TrimmedBytes = int64(trimmed)
+	if allocatedAfter < allocatedBefore {
+		result.ReclaimedBytes = int64(allocatedBefore - allocatedAfter)
+	}
+	return result, nil
+}
+
+// fragmentation score (of e4defrag -c) above which volumes are defragmented
+// when POST /volumes/:volume_name/defrag is given no threshold
+const defaultDefragThreshold = 20
+
+// defragVolume defragments the ext filesystem of a volume stored on the daemon
+// host if its fragmentation score is above threshold (see unikos.DefragImage).
+// qcow2 volumes are measured and defragmented as a raw copy, which is converted
+// back only if it was defragmented
+func (d *UnikDaemon) defragVolume(provider providers.Provider, volume *types.Volume, threshold int) (*types.VolumeDefragResult, error) {
+	localVolumes, ok := provider.(providers.LocalVolumeProvider)
+	if !ok {
+		return nil, errors.New("volumes of this provider are not stored on the daemon host, cannot defragment "+volume.Name, nil)
+	}
+	volumeFile, format, err := localVolumes.GetVolumeFile(volume.Id)
+	if err != nil {
+		return nil, err
+	}
+	defragFile := volumeFile
+	switch format {
+	case types.ImageFormat_RAW:
+	case types.ImageFormat_QCOW2:
+		tmpDir, err := ioutil.TempDir("", "unik.volume-defrag.")
+		if err != nil {
+			return nil, errors.New("creating temporary directory", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		defragFile = filepath.Join(tmpDir, "volume.img")
+		if err := common.ConvertRawImage(format, types.ImageFormat_RAW, volumeFile, defragFile); err != nil {
+			return nil, errors.New("converting volume "+volume.Name+" to raw", err)
+		}
+	default:
+		return nil, errors.New("volume "+volume.Name+" is stored as "+string(format)+", only raw and qcow2 volumes can be defragmented", nil)
+	}
+	defrag, err := unikos.DefragImage(defragFile, threshold)
+	switch err {
+	case nil:
+	case unikos.ErrNotDefragmentable:
+		return nil, errors.New(fmt.Sprintf("volume %s (%s) has a fragmentation score of %d, above %d, but cannot be defragmented: %v", volume.Name, defrag.FilesystemType, defrag.ScoreBefore, threshold, err), nil)
+	case unikos.ErrXfsDefrag:
+		return nil, errors.New("volume "+volume.Name+" is xfs: "+err.Error(), nil)
+	default:
+		return nil, errors.New("defragmenting volume "+volume.Name, err)
+	}
+	if defrag.Defragmented && defragFile != volumeFile {
+		if err := convertVolumeFile(types.ImageFormat_RAW, defragFile, format, volumeFile); err != nil {
+			return nil, errors.New("converting defragmented volume "+volume.Name+" back to "+string(format), err)
+		}
+	}
+	return &types.VolumeDefragResult{
+		Volume:         volume.Name,
+		FilesystemType: defrag.FilesystemType,
+		Threshold:      threshold,
+		ScoreBefore:    defrag.ScoreBefore,
+		ScoreAfter:     defrag.ScoreAfter,
+		Defragmented:   defrag.Defragmented,
+	}, nil
+}
+
+// convertVolumeFile writes input, converted with qemu-img, over file. unlike
+// common.ConvertRawImage, the result is renamed into place rather than copied,
+// so it stays sparse: qemu-img writes nothing for blocks of zeroes
+func convertVolumeFile(inputFormat types.ImageFormat, input string, format types.ImageFormat, file string) error {
+	dir := filepath.Dir(file)
+	tmpFile, err := ioutil.TempFile(dir, ".unik.volume-convert.")
+	if err != nil {
+		return errors.New("creating temporary file next to "+file, err)
+	}
+	tmpFile.Close()
+	container := util.NewContainer("qemu-util").WithVolume(filepath.Dir(input), filepath.Dir(input)).WithVolume(dir, dir)
+	if err := container.Run("qemu-img", "convert", "-f", string(inputFormat), "-O", string(format), input, tmpFile.Name()); err != nil {
+		os.Remove(tmpFile.Name())
+		return errors.New("converting "+input+" to "+string(format), err)
+	}
+	if err := os.Rename(tmpFile.Name(), file); err != nil {
+		os.Remove(tmpFile.Name())
+		return errors.New("replacing "+file, err)
+	}
+	return nil
+}
+
+// serveListVolumes returns the volumes that filter selects, sorted as page says.
+// volumes are sorted, but not paginated
+func (d *UnikDaemon) serveListVolumes(page listPage, filter volumeFilter) ([]*types.Volume, int, error) {
+	logrus.Debugf("listing volumes started")
+	providersToList := d.providers
+	if filter.provider != "" {
+		provider, ok := d.providers[filter.provider]
+		if !ok {
+			return nil, http.StatusBadRequest, errors.New(filter.provider+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+		}
+		providersToList = providers.Providers{filter.provider: provider}
+	}
+	allVolumes := []*types.Volume{}
+	for _, provider := range providersToList {
+		volumes, err := provider.ListVolumes()
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("could not retrieve volumes", err)
+		}
+		for _, volume := range volumes {
+			if filter.matches(volume) {
+				allVolumes = append(allVolumes, volume)
+			}
+		}
+	}
+	sortVolumes(allVolumes, page.sort, page.desc)
+	logrus.WithFields(logrus.Fields{
+		"volumes": allVolumes,
+	}).Infof("volumes")
+	return allVolumes, http.StatusOK, nil
+}
+
+func (d *UnikDaemon) serveGetVolume(volumeName string) (*types.Volume, int, error) {
+	provider, err := d.providers.ProviderForVolume(volumeName)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	volume, err := provider.GetVolume(volumeName)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not get volume", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"volume": volume,
+	}).Infof("volume retrieved")
+	return volume, http.StatusOK, nil
+}
+
+// serveCreateVolume creates volume volumeName with the parameters of POST
+// /volumes/:volume_name in form, from the tar archive (or raw image) data. the
+// volume is empty if data is nil
+func (d *UnikDaemon) serveCreateVolume(user, volumeName string, form url.Values, data io.Reader) (*types.Volume, int, error) {
+	var imagePath string
+	var provider providers.Provider
+	var noCleanup bool
+	var raw bool
+
+	logrus.WithField("form", form).Info("received request to create volume")
+
+	typeStr := form.Get("type")
+	typeStr = strings.ToLower(typeStr)
+	tags, err := formTags(form)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	volumeMode, err := types.ParseVolumeMode(strings.ToLower(form.Get("volume_mode")))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if volumeMode.IsFolder() && form.Get("provider") != qemu_provider {
+		return nil, http.StatusBadRequest, errors.New(string(volumeMode)+" volumes can only be created on the "+qemu_provider+" provider", nil)
+	}
+	partitionTable := strings.ToLower(form.Get("partition_table"))
+	if partitionTable != "" {
+		if _, err := unikos.GetPartitioner(partitionTable, unikos.SectorSize512); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if volumeMode.IsFolder() {
+			return nil, http.StatusBadRequest, errors.New(string(volumeMode)+" volumes are folders, they have no partition table", nil)
+		}
+		if p, ok := d.providers[form.Get("provider")]; ok && !p.GetConfig().UsePartitionTables {
+			return nil, http.StatusBadRequest, errors.New("volumes of provider "+form.Get("provider")+" have no partition table", nil)
+		}
+	}
+
+	if data != nil {
+
+		if strings.ToLower(form.Get("raw")) == "true" {
+			raw = true
+		}
+		if strings.ToLower(form.Get("no_cleanup")) == "true" {
+			noCleanup = true
+		}
+
+		logrus.Info("received request with volume data")
+		providerName := form.Get("provider")
+		if _, ok := d.providers[providerName]; !ok {
+			return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+		}
+		provider = d.providers[providerName]
+		if raw && volumeMode.IsFolder() {
+			return nil, http.StatusBadRequest, errors.New(string(volumeMode)+" volumes are created from a folder, not a raw image", nil)
+		}
+		if volumeMode.IsFolder() {
+			imagePath, err = util.BuildDataDir(ioutil.NopCloser(data))
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("extracting volume data", err)
+			}
+		} else if !raw {
+			logrus.WithFields(logrus.Fields{
+				"name":     volumeName,
+				"provider": providerName,
+			}).Debugf("creating volume started")
+
+			sizeStr := form.Get("size")
+			if sizeStr == "" {
+				sizeStr = "0"
+			}
+			size, err := strconv.Atoi(sizeStr)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not parse given size", err)
+			}
+			imagePath, err = util.BuildRawDataImageWithType(ioutil.NopCloser(data), unikos.MegaBytes(size), typeStr, provider.GetConfig().UsePartitionTables, partitionTable)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("creating raw volume image", err)
+			}
+		} else {
+			imagePathFile, err := ioutil.TempFile("", "")
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("creating temp file for volume image", err)
+			}
+			_, err = io.Copy(imagePathFile, data)
+			imagePathFile.Close()
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("creating temp file for volume image", err)
+			}
+			imagePath = imagePathFile.Name()
+		}
+
+	} else {
+		if strings.ToLower(form.Get("raw")) == "true" {
+			raw = true
+		}
+		if strings.ToLower(form.Get("no_cleanup")) == "true" {
+			noCleanup = true
+		}
+
+		if raw == true {
+			return nil, http.StatusBadRequest, errors.New("Raw volume was requested but no data provided", nil)
+		}
+		logrus.Info("received request for empty volume")
+		providerName := form.Get("provider")
+		if _, ok := d.providers[providerName]; !ok {
+			return nil, http.StatusBadRequest, errors.New(providerName+" is not a known provider. Available: "+strings.Join(d.providers.Keys(), "|"), nil)
+		}
+		provider = d.providers[providerName]
+		if volumeMode.IsFolder() {
+			// a folder takes the space of what is written to it
+			imagePath, err = util.BuildDataDir(nil)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("creating volume folder", err)
+			}
+		} else {
+			sizeStr := form.Get("size")
+			size, err := strconv.Atoi(sizeStr)
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("could not parse given size", err)
+			}
+			if size <= 0 {
+				return nil, http.StatusBadRequest, errors.New("size of an empty volume must be larger than zero", nil)
+			}
+			logrus.WithFields(logrus.Fields{
+				"size": size,
+				"name": volumeName,
+			}).Debugf("creating empty volume started")
+			fstype := unikos.FilesystemType(typeStr)
+			if !provider.GetConfig().UsePartitionTables && (fstype == "" || fstype == unikos.FilesystemExt2 || fstype == unikos.FilesystemFat) {
+				if fstype == "" {
+					fstype = unikos.FilesystemExt2
+				}
+				imagePath, err = util.BuildEmptyVolume(unikos.MegaBytes(size), fstype)
+			} else {
+				imagePath, err = util.BuildEmptyDataVolumeWithType(unikos.MegaBytes(size), typeStr, partitionTable)
+			}
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.New("failed building raw image", err)
+			}
+			logrus.WithFields(logrus.Fields{
+				"image": imagePath,
+			}).Infof("raw image created")
+		}
+
+	}
+
+	if !noCleanup {
+		defer os.RemoveAll(imagePath)
+	}
+
+	params := types.CreateVolumeParams{
+		Name:      volumeName,
+		ImagePath: imagePath,
+		NoCleanup: noCleanup,
+		Mode:      volumeMode,
+	}
+
+	volume, err := d.createVolume(provider, user, params)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not create volume", err)
+	}
+	if len(tags) > 0 {
+		volume, err = modifyVolumeTags(provider, volume.Id, addTags(tags))
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("tagging volume", err)
+		}
+	}
+	logrus.WithFields(logrus.Fields{
+		"volume": volume,
+	}).Infof("volume created")
+	return volume, http.StatusCreated, nil
+}
+
+func (d *UnikDaemon) serveDeleteVolume(volumeName string, force bool) (int, error) {
+	provider, err := d.providers.ProviderForVolume(volumeName)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"force": force, "name": volumeName,
+	}).Debugf("deleting volume started")
+	volume, err := provider.GetVolume(volumeName)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := d.deleteVolume(provider, volume, force); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"volume": volumeName,
+	}).Infof("volume deleted")
+	return http.StatusNoContent, nil
+}
+
+func (d *UnikDaemon) serveAttachVolume(volumeName, instanceId, mount string) (int, error) {
+	provider, err := d.providers.ProviderForVolume(volumeName)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if mount == "" {
+		return http.StatusBadRequest, errors.New("must provide a mount point in URL query", nil)
+	}
+	logrus.WithFields(logrus.Fields{
+		"instance": instanceId,
+		"volume":   volumeName,
+		"mount":    mount,
+	}).Debugf("attaching volume to instance")
+	if err := provider.AttachVolume(volumeName, instanceId, mount); err != nil {
+		return http.StatusInternalServerError, errors.New("could not attach volume to instance", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"instance": instanceId,
+		"volume":   volumeName,
+		"mount":    mount,
+	}).Infof("volume attached")
+	d.notifyVolume(types.EventType_VolumeAttached, provider, volumeName)
+	return http.StatusAccepted, nil
+}
+
+func (d *UnikDaemon) serveDetachVolume(volumeName string) (int, error) {
+	provider, err := d.providers.ProviderForVolume(volumeName)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"volume": volumeName,
+	}).Debugf("detaching volume from any instance")
+	err = provider.DetachVolume(volumeName)
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("could not detach volume from instance", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"volume": volumeName,
+	}).Infof("volume detached")
+	d.notifyVolume(types.EventType_VolumeDetached, provider, volumeName)
+	return http.StatusAccepted, nil
+}
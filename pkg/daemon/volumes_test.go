@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("cloneVolume", func() {
+	const sourceUUID = "6f1bc6e4-1d4b-4a5c-9c6e-7a6f2b0d3e11"
+	var (
+		dir            string
+		d              *UnikDaemon
+		source, target *fakeProvider
+		volume         *types.Volume
+	)
+	BeforeEach(func() {
+		for _, tool := range []string{"blkid", "mkfs.ext2", "tune2fs"} {
+			if _, err := exec.LookPath(tool); err != nil {
+				Skip(tool + " is not installed")
+			}
+		}
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.volumes.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(dir, "source"), 0755)).To(Succeed())
+		Expect(os.Mkdir(filepath.Join(dir, "target"), 0755)).To(Succeed())
+		source = newFakeProvider(filepath.Join(dir, "source"))
+		target = newFakeProvider(filepath.Join(dir, "target"))
+		d = &UnikDaemon{bus: newEventBus(), quotas: newQuotaManager(config.QuotaConfig{}, state.NewBasicState(filepath.Join(dir, "daemon-state.json")), nil)}
+
+		image := filepath.Join(dir, "data.img")
+		Expect(exec.Command("truncate", "-s", "8M", image).Run()).To(Succeed())
+		Expect(exec.Command("mkfs.ext2", "-q", "-F", "-L", "data", "-U", sourceUUID, image).Run()).To(Succeed())
+		volume, err = source.CreateVolume(types.CreateVolumeParams{Name: "data", ImagePath: image})
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	cloneIds := func(clone *types.Volume) unikos.FilesystemIds {
+		cloneFile, _, err := target.GetVolumeFile(clone.Id)
+		Expect(err).NotTo(HaveOccurred())
+		ids, err := unikos.ReadFilesystemIds(cloneFile)
+		Expect(err).NotTo(HaveOccurred())
+		return ids
+	}
+
+	It("should keep the label and uuid of the volume", func() {
+		clone, err := d.cloneVolume(source, "source", target, "target", "anonymous", volume, "copy")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cloneIds(clone)).To(Equal(unikos.FilesystemIds{Label: "data", UUID: sourceUUID}))
+	})
+
+	It("should give the label and uuid of the volume to a clone the provider made a new filesystem for", func() {
+		target.newFilesystem = true
+		clone, err := d.cloneVolume(source, "source", target, "target", "anonymous", volume, "copy")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cloneIds(clone)).To(Equal(unikos.FilesystemIds{Label: "data", UUID: sourceUUID}))
+	})
+
+	It("should refuse to clone an attached volume", func() {
+		volume.Attachment = "instance"
+		_, err := d.cloneVolume(source, "source", target, "target", "anonymous", volume, "copy")
+		Expect(err).To(HaveOccurred())
+	})
+})
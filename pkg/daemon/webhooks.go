@@ -0,0 +1,285 @@
+package daemon
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"github.com/pborman/uuid"
+)
+
+const (
+	webhookSignatureHeader = "X-Unik-Signature"
+	webhookEventHeader     = "X-Unik-Event"
+	webhookTimeout         = 10 * time.Second
+	// events are delivered by webhookWorkers workers, from a queue of up to
+	// webhookQueueSize deliveries; events that don't fit are dropped
+	webhookWorkers   = 4
+	webhookQueueSize = 256
+	// a failed delivery is tried webhookAttempts times in all, waiting
+	// webhookRetryBackoff before the first retry and twice as long before each next one
+	webhookAttempts     = 3
+	webhookRetryBackoff = time.Second
+)
+
+// the kind of records webhooks are kept as in the daemon state, by id
+const webhookRecords = "webhooks"
+
+// webhooksFile is where daemons before the daemon state kept webhooks
+func webhooksFile() string {
+	return filepath.Join(config.Internal.UnikHome, "webhooks.json")
+}
+
+// webhookManager keeps track of registered webhooks and delivers events to them.
+// Webhooks created through the api are persisted in the daemon state; webhooks from the
+// daemon config are loaded on every start.
+type webhookManager struct {
+	lock         sync.RWMutex
+	state        state.State
+	webhooks     map[string]*types.Webhook
+	client       *http.Client
+	deliveries   chan webhookDelivery
+	retryBackoff time.Duration
+}
+
+// webhookDelivery is an event to post to a webhook
+type webhookDelivery struct {
+	webhook   types.Webhook
+	eventType types.EventType
+	body      []byte
+}
+
+func newWebhookManager(configs []config.WebhookConfig, daemonState state.State) (*webhookManager, error) {
+	m := &webhookManager{
+		state:        daemonState,
+		webhooks:     make(map[string]*types.Webhook),
+		client:       &http.Client{Timeout: webhookTimeout},
+		deliveries:   make(chan webhookDelivery, webhookQueueSize),
+		retryBackoff: webhookRetryBackoff,
+	}
+	for id, data := range daemonState.GetRecords(webhookRecords) {
+		var webhook types.Webhook
+		if err := json.Unmarshal(data, &webhook); err != nil {
+			return nil, errors.New("failed to unmarshal webhook "+id, err)
+		}
+		m.webhooks[webhook.Id] = &webhook
+	}
+	for _, webhookConfig := range configs {
+		if err := validateWebhook(webhookConfig.URL, webhookConfig.Events); err != nil {
+			return nil, errors.New("invalid webhook in daemon config", err)
+		}
+		webhook := &types.Webhook{
+			Id:         uuid.New(),
+			URL:        webhookConfig.URL,
+			Secret:     webhookConfig.Secret,
+			Events:     webhookConfig.Events,
+			Created:    time.Now(),
+			FromConfig: true,
+		}
+		m.webhooks[webhook.Id] = webhook
+	}
+	return m, nil
+}
+
+func validateWebhook(rawurl string, events []string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return errors.New("invalid url "+rawurl, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("webhook url "+rawurl+" must be http or https", nil)
+	}
+	for _, event := range events {
+		if !types.IsEventType(event) {
+			return errors.New(event+" is not a known event type", nil)
+		}
+	}
+	return nil
+}
+
+func (m *webhookManager) create(rawurl, secret string, events []string) (*types.Webhook, error) {
+	if err := validateWebhook(rawurl, events); err != nil {
+		return nil, err
+	}
+	webhook := &types.Webhook{
+		Id:      uuid.New(),
+		URL:     rawurl,
+		Secret:  secret,
+		Events:  events,
+		Created: time.Now(),
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.webhooks[webhook.Id] = webhook
+	if err := m.save(); err != nil {
+		delete(m.webhooks, webhook.Id)
+		return nil, err
+	}
+	return redactWebhook(webhook), nil
+}
+
+func (m *webhookManager) list() []*types.Webhook {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	webhooks := []*types.Webhook{}
+	for _, webhook := range m.webhooks {
+		webhooks = append(webhooks, redactWebhook(webhook))
+	}
+	sort.Sort(webhooksByCreated(webhooks))
+	return webhooks
+}
+
+func (m *webhookManager) remove(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	webhook, ok := m.webhooks[id]
+	if !ok {
+		return errors.New("webhook "+id+" not found", nil)
+	}
+	if webhook.FromConfig {
+		return errors.New("webhook "+id+" is defined in the daemon config and cannot be deleted", nil)
+	}
+	delete(m.webhooks, id)
+	return m.save()
+}
+
+// listen delivers events to the webhooks until events is closed, and then
+// waits for the deliveries that were queued
+func (m *webhookManager) listen(events <-chan types.Event) {
+	var workers sync.WaitGroup
+	for i := 0; i < webhookWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for delivery := range m.deliveries {
+				m.deliver(delivery)
+			}
+		}()
+	}
+	for event := range events {
+		m.notify(event)
+	}
+	close(m.deliveries)
+	workers.Wait()
+}
+
+// notify queues the event for delivery to every webhook subscribed to it. if the
+// queue is full, the event is dropped for that webhook rather than holding up
+// the events after it
+func (m *webhookManager) notify(event types.Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logrus.WithError(err).Errorf("failed to marshal %s event for webhooks", event.Type)
+		return
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, webhook := range m.webhooks {
+		if !webhook.Wants(event.Type) {
+			continue
+		}
+		select {
+		case m.deliveries <- webhookDelivery{webhook: *webhook, eventType: event.Type, body: body}:
+		default:
+			logrus.Warnf("webhook delivery queue is full, dropping %s event for webhook %s", event.Type, webhook.URL)
+		}
+	}
+}
+
+// deliver posts the event to the webhook, retrying with backoff if the webhook
+// can't be reached or replies with a server error (or 429). failures are logged
+func (m *webhookManager) deliver(delivery webhookDelivery) {
+	backoff := m.retryBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := m.send(delivery)
+		if err == nil {
+			logrus.WithFields(logrus.Fields{"webhook": delivery.webhook.Id, "event": delivery.eventType}).Debugf("webhook delivered")
+			return
+		}
+		if !retry || attempt == webhookAttempts {
+			logrus.WithError(err).Warnf("failed to deliver %s event to webhook %s after %d attempts", delivery.eventType, delivery.webhook.URL, attempt)
+			return
+		}
+		logrus.WithError(err).Debugf("delivering %s event to webhook %s failed, retrying in %v", delivery.eventType, delivery.webhook.URL, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// send posts the event to the webhook once. retry is set for failures that may
+// pass, as opposed to the webhook refusing the event
+func (m *webhookManager) send(delivery webhookDelivery) (retry bool, err error) {
+	webhook := delivery.webhook
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(delivery.body))
+	if err != nil {
+		return false, errors.New("building request for webhook "+webhook.Id, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, string(delivery.eventType))
+	if webhook.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signPayload(webhook.Secret, delivery.body))
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, errors.New(fmt.Sprintf("webhook replied with status %v", resp.StatusCode), nil)
+	}
+	return false, nil
+}
+
+// signPayload returns the hex encoded HMAC-SHA256 of body keyed with secret
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// must be called with the lock held
+func (m *webhookManager) save() error {
+	return m.state.ModifyRecords(webhookRecords, func(records map[string]json.RawMessage) error {
+		for id := range records {
+			delete(records, id)
+		}
+		for id, webhook := range m.webhooks {
+			if webhook.FromConfig {
+				continue
+			}
+			data, err := json.Marshal(webhook)
+			if err != nil {
+				return errors.New("failed to marshal webhook "+id, err)
+			}
+			records[id] = data
+		}
+		return nil
+	})
+}
+
+func redactWebhook(webhook *types.Webhook) *types.Webhook {
+	webhookCopy := *webhook
+	webhookCopy.Secret = ""
+	return &webhookCopy
+}
+
+type webhooksByCreated []*types.Webhook
+
+func (w webhooksByCreated) Len() int           { return len(w) }
+func (w webhooksByCreated) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
+func (w webhooksByCreated) Less(i, j int) bool { return w[i].Created.Before(w[j].Created) }
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/emc-advanced-dev/unik/pkg/state"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// webhookRequest is a request a test webhook server received
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+var _ = Describe("webhookManager", func() {
+	var (
+		dir      string
+		server   *httptest.Server
+		lock     sync.Mutex
+		received []webhookRequest
+		statuses []int
+		manager  *webhookManager
+		events   chan types.Event
+	)
+	requests := func() []webhookRequest {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]webhookRequest{}, received...)
+	}
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "daemon.webhooks.")
+		Expect(err).NotTo(HaveOccurred())
+		received = nil
+		statuses = nil
+		server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			lock.Lock()
+			defer lock.Unlock()
+			received = append(received, webhookRequest{header: req.Header, body: body})
+			// reply with the statuses given, and 200 once they run out
+			status := http.StatusOK
+			if len(statuses) > 0 {
+				status, statuses = statuses[0], statuses[1:]
+			}
+			res.WriteHeader(status)
+		}))
+		manager, err = newWebhookManager(nil, state.NewBasicState(filepath.Join(dir, "daemon-state.json")))
+		Expect(err).NotTo(HaveOccurred())
+		manager.retryBackoff = time.Millisecond
+		events = make(chan types.Event)
+		go manager.listen(events)
+	})
+	AfterEach(func() {
+		close(events)
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("should sign events with the HMAC-SHA256 of the body keyed with the secret", func() {
+		_, err := manager.create(server.URL, "secret", nil)
+		Expect(err).NotTo(HaveOccurred())
+		events <- types.NewInstanceEvent(types.EventType_InstanceCrashed, &types.Instance{Id: "instance"})
+
+		Eventually(requests).Should(HaveLen(1))
+		request := requests()[0]
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(request.body)
+		Expect(request.header.Get(webhookSignatureHeader)).To(Equal("sha256=" + hex.EncodeToString(mac.Sum(nil))))
+		Expect(request.header.Get(webhookEventHeader)).To(Equal(string(types.EventType_InstanceCrashed)))
+	})
+
+	It("should not sign events for webhooks without a secret", func() {
+		_, err := manager.create(server.URL, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		events <- types.NewInstanceEvent(types.EventType_InstanceCrashed, &types.Instance{Id: "instance"})
+
+		Eventually(requests).Should(HaveLen(1))
+		Expect(requests()[0].header.Get(webhookSignatureHeader)).To(BeEmpty())
+	})
+
+	It("should only deliver the events a webhook is subscribed to", func() {
+		_, err := manager.create(server.URL, "", []string{string(types.EventType_InstanceCrashed)})
+		Expect(err).NotTo(HaveOccurred())
+		events <- types.NewInstanceEvent(types.EventType_InstanceStopped, &types.Instance{Id: "instance"})
+		events <- types.NewInstanceEvent(types.EventType_InstanceCrashed, &types.Instance{Id: "instance"})
+
+		Eventually(requests).Should(HaveLen(1))
+		Consistently(requests, 50*time.Millisecond).Should(HaveLen(1))
+		Expect(requests()[0].header.Get(webhookEventHeader)).To(Equal(string(types.EventType_InstanceCrashed)))
+	})
+
+	It("should retry server errors until the webhook accepts the event", func() {
+		statuses = []int{http.StatusServiceUnavailable, http.StatusInternalServerError}
+		_, err := manager.create(server.URL, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		events <- types.NewInstanceEvent(types.EventType_InstanceCrashed, &types.Instance{Id: "instance"})
+
+		Eventually(requests).Should(HaveLen(3))
+		Consistently(requests, 50*time.Millisecond).Should(HaveLen(3))
+	})
+
+	It("should give up after the last attempt", func() {
+		statuses = []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}
+		_, err := manager.create(server.URL, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		events <- types.NewInstanceEvent(types.EventType_InstanceCrashed, &types.Instance{Id: "instance"})
+
+		Eventually(requests).Should(HaveLen(webhookAttempts))
+		Consistently(requests, 50*time.Millisecond).Should(HaveLen(webhookAttempts))
+	})
+
+	It("should not retry events the webhook refuses", func() {
+		statuses = []int{http.StatusBadRequest}
+		_, err := manager.create(server.URL, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		events <- types.NewInstanceEvent(types.EventType_InstanceCrashed, &types.Instance{Id: "instance"})
+
+		Eventually(requests).Should(HaveLen(1))
+		Consistently(requests, 50*time.Millisecond).Should(HaveLen(1))
+	})
+})
+
+var _ = Describe("webhookManager queue", func() {
+	It("should drop events instead of blocking when the queue is full", func() {
+		dir, err := ioutil.TempDir("", "daemon.webhooks.")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		// nothing takes deliveries off the queue without listen
+		manager, err := newWebhookManager(nil, state.NewBasicState(filepath.Join(dir, "daemon-state.json")))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = manager.create("http://127.0.0.1:1/hook", "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < webhookQueueSize+10; i++ {
+			manager.notify(types.NewInstanceEvent(types.EventType_InstanceCrashed, &types.Instance{Id: "instance"}))
+		}
+		Expect(manager.deliveries).To(HaveLen(webhookQueueSize))
+	})
+})
@@ -0,0 +1,135 @@
+package os
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// EnvCmdlinePrefix starts each environment variable passed on a kernel command
+// line. a variable FOO with the value bar is passed as env=FOO:bar, so a unikernel
+// can tell environment variables apart from its other boot arguments by looking
+// for arguments (argv entries, or space separated words of the command line)
+// that start with env=, and split the rest at the first colon.
+const EnvCmdlinePrefix = "env="
+
+// FormatEnvCmdline returns env as space separated env=KEY:VALUE arguments,
+// sorted by key. kernel command lines are split at spaces and have no quoting,
+// so keys and values must not contain whitespace, and keys must not contain
+// a colon or an equals sign.
+func FormatEnvCmdline(env map[string]string) (string, error) {
+	keys := []string{}
+	for key, value := range env {
+		if key == "" || strings.ContainsAny(key, ":=") || strings.IndexFunc(key, unicode.IsSpace) >= 0 {
+			return "", fmt.Errorf("environment variable name %q cannot be passed on a kernel command line", key)
+		}
+		if strings.IndexFunc(value, unicode.IsSpace) >= 0 {
+			return "", fmt.Errorf("value of environment variable %s contains whitespace, which cannot be passed on a kernel command line", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := []string{}
+	for _, key := range keys {
+		args = append(args, EnvCmdlinePrefix+key+":"+env[key])
+	}
+	return strings.Join(args, " "), nil
+}
+
+// AppendEnvCmdline appends env to a kernel command line, see FormatEnvCmdline
+func AppendEnvCmdline(commandline string, env map[string]string) (string, error) {
+	envArgs, err := FormatEnvCmdline(env)
+	if err != nil {
+		return "", err
+	}
+	if envArgs == "" {
+		return commandline, nil
+	}
+	if commandline == "" {
+		return envArgs, nil
+	}
+	return commandline + " " + envArgs, nil
+}
+
+// CmdlineMode says how a command line given when an instance is run is
+// combined with the command line the image was built with
+type CmdlineMode string
+
+const (
+	// CmdlineReplace boots with the given command line instead of the image's
+	CmdlineReplace CmdlineMode = "replace"
+	// CmdlineAppend adds the given command line after the image's
+	CmdlineAppend CmdlineMode = "append"
+	// CmdlineGoTemplate renders the given command line as a text/template
+	// executed with a CmdlineData, and boots with the result instead of the image's
+	CmdlineGoTemplate CmdlineMode = "template"
+)
+
+// ParseCmdlineMode returns the CmdlineMode named by mode. "" is CmdlineAppend.
+func ParseCmdlineMode(mode string) (CmdlineMode, error) {
+	switch CmdlineMode(strings.ToLower(mode)) {
+	case "", CmdlineAppend:
+		return CmdlineAppend, nil
+	case CmdlineReplace:
+		return CmdlineReplace, nil
+	case CmdlineGoTemplate:
+		return CmdlineGoTemplate, nil
+	}
+	return "", fmt.Errorf("unknown command line mode %q, must be one of %s|%s|%s", mode, CmdlineReplace, CmdlineAppend, CmdlineGoTemplate)
+}
+
+// CmdlineData is the instance metadata a CmdlineGoTemplate command line is rendered with,
+// e.g. "ip={{.IpAddress}} data={{index .MountPoints \"/data\"}}"
+type CmdlineData struct {
+	InstanceName string
+	IpAddress    string
+	// IPv6Address, IPv6PrefixLength and IPv6Gateway are the static ipv6 address
+	// the instance is run with, if any
+	IPv6Address      string
+	IPv6PrefixLength int
+	IPv6Gateway      string
+	// MountPoints maps the mount points of the image to the names of the volumes attached at them
+	MountPoints map[string]string
+	Env         map[string]string
+}
+
+// ValidateCmdlineTemplate returns an error if extra does not parse as a CmdlineGoTemplate command line
+func ValidateCmdlineTemplate(extra string) error {
+	if _, err := template.New("cmdline").Option("missingkey=error").Parse(extra); err != nil {
+		return fmt.Errorf("parsing command line template: %v", err)
+	}
+	return nil
+}
+
+// RenderCmdline combines the command line of an image (base) with one given at
+// run time (extra). a template that fails to render is logged, and its text
+// used as it was given, so callers should check it with ValidateCmdlineTemplate first.
+func RenderCmdline(mode CmdlineMode, base, extra string, data interface{}) string {
+	base, extra = strings.TrimSpace(base), strings.TrimSpace(extra)
+	switch mode {
+	case CmdlineReplace:
+		return extra
+	case CmdlineGoTemplate:
+		t, err := template.New("cmdline").Option("missingkey=error").Parse(extra)
+		if err == nil {
+			var rendered bytes.Buffer
+			if err = t.Execute(&rendered, data); err == nil {
+				return strings.TrimSpace(rendered.String())
+			}
+		}
+		log.WithError(err).Warnf("rendering command line template %q, using it as it is", extra)
+		return extra
+	}
+	if base == "" {
+		return extra
+	}
+	if extra == "" {
+		return base
+	}
+	return base + " " + extra
+}
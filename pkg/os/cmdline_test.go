@@ -0,0 +1,40 @@
+package os
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AppendEnvCmdline", func() {
+	It("should append env=KEY:VALUE arguments sorted by key", func() {
+		cmdline, err := AppendEnvCmdline("console=ttyS0", map[string]string{"PORT": "8080", "DB": "host:5432"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmdline).To(Equal("console=ttyS0 env=DB:host:5432 env=PORT:8080"))
+	})
+	It("should leave the command line alone when there is no env", func() {
+		cmdline, err := AppendEnvCmdline("console=ttyS0", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmdline).To(Equal("console=ttyS0"))
+	})
+	It("should reject keys and values that can't be split back out", func() {
+		_, err := AppendEnvCmdline("", map[string]string{"A:B": "c"})
+		Expect(err).To(HaveOccurred())
+		_, err = AppendEnvCmdline("", map[string]string{"A": "b c"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("RenderCmdline", func() {
+	It("should append, replace or render the run time command line", func() {
+		Expect(RenderCmdline(CmdlineAppend, "console=ttyS0 ", "debug", nil)).To(Equal("console=ttyS0 debug"))
+		Expect(RenderCmdline(CmdlineAppend, "console=ttyS0", "", nil)).To(Equal("console=ttyS0"))
+		Expect(RenderCmdline(CmdlineReplace, "console=ttyS0", "debug", nil)).To(Equal("debug"))
+		data := CmdlineData{IpAddress: "192.168.76.9", MountPoints: map[string]string{"/data": "myVolume"}}
+		Expect(RenderCmdline(CmdlineGoTemplate, "console=ttyS0", `ip={{.IpAddress}} vol={{index .MountPoints "/data"}}`, data)).
+			To(Equal("ip=192.168.76.9 vol=myVolume"))
+	})
+	It("should use a template that fails to render as it was given", func() {
+		Expect(RenderCmdline(CmdlineGoTemplate, "", "ip={{.Nope}}", CmdlineData{})).To(Equal("ip={{.Nope}}"))
+		Expect(ValidateCmdlineTemplate("ip={{.IpAddress")).To(HaveOccurred())
+	})
+})
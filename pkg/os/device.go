@@ -1,18 +1,42 @@
 package os
 
 import (
+	stderrors "errors"
 	"fmt"
 	"math"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/emc-advanced-dev/pkg/errors"
 )
 
+var (
+	// ErrInvalidSize is returned for sizes that are negative or overflow int64 when converted to bytes
+	ErrInvalidSize = stderrors.New("invalid disk size")
+	// ErrImageTooLarge is returned for partitions beyond what an msdos (MBR) or
+	// bsd partition table can address
+	ErrImageTooLarge = stderrors.New("partition is beyond the 2^32 sectors an MBR partition table addresses")
+	// ErrNoPartitionTable is returned by ListParts for disks parted finds no partition table on
+	ErrNoPartitionTable = stderrors.New("disk has no partition table")
+	// ErrAlreadyFormatted is returned by CreateBootImageOnFile for files that
+	// already have a partition table, which it would overwrite
+	ErrAlreadyFormatted = stderrors.New("disk already has a partition table")
+	// ErrXfsDefrag is returned by DefragImage for xfs filesystems
+	ErrXfsDefrag = stderrors.New("xfs filesystems cannot be defragmented with e4defrag, use xfs_fsr")
+	// ErrNotDefragmentable is returned by DefragImage for ext2 and ext3
+	// filesystems fragmented above the threshold: e4defrag only moves files
+	// with extents, which they do not have
+	ErrNotDefragmentable = stderrors.New("e4defrag only defragments files with extents; convert the filesystem to ext4 (tune2fs -O extents) to defragment it")
+)
+
 type DiskSize interface {
 	ToPartedFormat() string
 	ToBytes() Bytes
+	// String is the size in the largest binary unit it has at least one of,
+	// e.g. 1.50 GiB, for logs
+	String() string
 }
 
 type Bytes int64
@@ -25,51 +49,236 @@ func (s Bytes) ToBytes() Bytes {
 	return s
 }
 
+var sizeUnits = []string{"KiB", "MiB", "GiB", "TiB"}
+
+func (s Bytes) String() string {
+	abs := s
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs < 1<<10 {
+		return fmt.Sprintf("%d B", int64(s))
+	}
+	unit := 0
+	for unit < len(sizeUnits)-1 && abs >= 1<<(10*uint(unit+2)) {
+		unit++
+	}
+	return fmt.Sprintf("%.2f %s", float64(s)/float64(int64(1)<<(10*uint(unit+1))), sizeUnits[unit])
+}
+
 // ToMegaBytes returns lowest whole number of size_MB so that size_MB >= (size_B / 1024^2)
 func (s Bytes) ToMegaBytes() MegaBytes {
 	return MegaBytes(int(math.Ceil(float64(s) / float64(MegaBytes(1).ToBytes()))))
 }
 
+// NewBytes validates n and returns it as Bytes
+func NewBytes(n int64) (Bytes, error) {
+	if err := validateSize(n, 0); err != nil {
+		return 0, err
+	}
+	return Bytes(n), nil
+}
+
+type KiloBytes int64
+
+// NewKiloBytes validates n and returns it as KiloBytes
+func NewKiloBytes(n int64) (KiloBytes, error) {
+	if err := validateSize(n, 10); err != nil {
+		return 0, err
+	}
+	return KiloBytes(n), nil
+}
+
+func (s KiloBytes) ToPartedFormat() string {
+	return fmt.Sprintf("%dKiB", uint64(s))
+}
+
+func (s KiloBytes) String() string {
+	return s.ToBytes().String()
+}
+
+func (s KiloBytes) ToBytes() Bytes {
+	return Bytes(s << 10)
+}
+
 type MegaBytes int64
 
+// NewMegaBytes validates n and returns it as MegaBytes
+func NewMegaBytes(n int64) (MegaBytes, error) {
+	if err := validateSize(n, 20); err != nil {
+		return 0, err
+	}
+	return MegaBytes(n), nil
+}
+
 func (s MegaBytes) ToPartedFormat() string {
 	return fmt.Sprintf("%dMiB", uint64(s))
 }
 
+func (s MegaBytes) String() string {
+	return s.ToBytes().String()
+}
+
 func (s MegaBytes) ToBytes() Bytes {
 	return Bytes(s << 20)
 }
 
 type GigaBytes int64
 
+// NewGigaBytes validates n and returns it as GigaBytes
+func NewGigaBytes(n int64) (GigaBytes, error) {
+	if err := validateSize(n, 30); err != nil {
+		return 0, err
+	}
+	return GigaBytes(n), nil
+}
+
 func (s GigaBytes) ToPartedFormat() string {
 	return fmt.Sprintf("%dGiB", uint64(s))
 }
 
+func (s GigaBytes) String() string {
+	return s.ToBytes().String()
+}
+
 func (s GigaBytes) ToBytes() Bytes {
 	return Bytes(s << 30)
 }
 
+type TeraBytes int64
+
+// NewTeraBytes validates n and returns it as TeraBytes
+func NewTeraBytes(n int64) (TeraBytes, error) {
+	if err := validateSize(n, 40); err != nil {
+		return 0, err
+	}
+	return TeraBytes(n), nil
+}
+
+func (s TeraBytes) ToPartedFormat() string {
+	return fmt.Sprintf("%dTiB", uint64(s))
+}
+
+func (s TeraBytes) String() string {
+	return s.ToBytes().String()
+}
+
+func (s TeraBytes) ToBytes() Bytes {
+	return Bytes(s << 40)
+}
+
+// validateSize makes sure n units of 2^shift bytes is non-negative and fits in an int64 byte count
+func validateSize(n int64, shift uint) error {
+	if n < 0 || n > math.MaxInt64>>shift {
+		return ErrInvalidSize
+	}
+	return nil
+}
+
+// Sectors counts sectors of SectorSize bytes, whatever the sector size of the
+// disk they are on. use RoundToSectorSize for sizes that must be whole sectors
+// of a disk with larger ones.
 type Sectors int64
 
+// SectorSize is the size of Sectors and the default sector size of disks, in bytes
 const SectorSize = 512
 
+// SectorSizeBytes is the size of the sectors of a disk: 512 bytes, or 4096 for
+// advanced format (4Kn) disks such as many SSDs and cloud block devices
+type SectorSizeBytes int64
+
+const (
+	SectorSize512 SectorSizeBytes = SectorSize
+	SectorSize4K  SectorSizeBytes = 4096
+)
+
+// ValidateSectorSize returns an error unless sectorSize is 512 or 4096
+func ValidateSectorSize(sectorSize SectorSizeBytes) error {
+	if sectorSize != SectorSize512 && sectorSize != SectorSize4K {
+		return fmt.Errorf("unsupported sector size %d: must be %d or %d", sectorSize, SectorSize512, SectorSize4K)
+	}
+	return nil
+}
+
+// MaxMBRSectors is the largest number of sectors msdos and bsd partition tables
+// address with their 32 bit LBAs: 2 TiB of 512 byte sectors, 16 TiB of 4096 byte sectors
+const MaxMBRSectors = 1 << 32
+
+// checkMBRLimit returns ErrImageTooLarge for partitions ending beyond
+// MaxMBRSectors sectors of sectorSize. gpt has 64 bit LBAs and no such limit
+func checkMBRLimit(end DiskSize, sectorSize SectorSizeBytes) error {
+	if sectorSize == 0 {
+		sectorSize = SectorSize512
+	}
+	if end.ToBytes() > Bytes(MaxMBRSectors)*Bytes(sectorSize) {
+		return ErrImageTooLarge
+	}
+	return nil
+}
+
+// ToPartedFormat is in bytes: parted's s unit is the sector size of the device,
+// which may be 4096 bytes
 func (s Sectors) ToPartedFormat() string {
-	return fmt.Sprintf("%ds", uint64(s))
+	return s.ToBytes().ToPartedFormat()
 }
 
 func (s Sectors) ToBytes() Bytes {
 	return Bytes(s * SectorSize)
 }
 
+func (s Sectors) String() string {
+	return s.ToBytes().String()
+}
+
+// ToSectors returns the number of Sectors in b
 func ToSectors(b DiskSize) (Sectors, error) {
 	inBytes := b.ToBytes()
+	if inBytes < 0 {
+		return 0, ErrInvalidSize
+	}
 	if inBytes%SectorSize != 0 {
 		return 0, errors.New("can't convert to sectors", nil)
 	}
 	return Sectors(inBytes / SectorSize), nil
 }
 
+// RoundToSectorSize rounds b up to a whole number of sectors of sectorSize bytes
+func RoundToSectorSize(b DiskSize, sectorSize SectorSizeBytes) (Bytes, error) {
+	if err := ValidateSectorSize(sectorSize); err != nil {
+		return 0, err
+	}
+	inBytes := b.ToBytes()
+	if inBytes < 0 || inBytes > math.MaxInt64-Bytes(sectorSize) {
+		return 0, ErrInvalidSize
+	}
+	return (inBytes + Bytes(sectorSize) - 1) &^ (Bytes(sectorSize) - 1), nil
+}
+
+// DefaultPartitionAlignMB is the boundary partitions start on, the one parted's
+// --align optimal uses on most disks
+const DefaultPartitionAlignMB = 1
+
+// AlignUp rounds offset up to a multiple of alignMB MiB. SSDs and cloud block
+// devices write to partitions that do not start on such a boundary with
+// read-modify-write cycles. alignMB of 0 or less means DefaultPartitionAlignMB
+func AlignUp(offset Bytes, alignMB int) Bytes {
+	if alignMB <= 0 {
+		alignMB = DefaultPartitionAlignMB
+	}
+	alignment := MegaBytes(alignMB).ToBytes()
+	if rem := offset % alignment; rem > 0 {
+		return offset + alignment - rem
+	}
+	return offset
+}
+
+// alignPartition moves the partition from start to end up to the next
+// DefaultPartitionAlignMB boundary, keeping its size
+func alignPartition(start, end DiskSize) (Bytes, Bytes) {
+	aligned := AlignUp(start.ToBytes(), DefaultPartitionAlignMB)
+	return aligned, end.ToBytes() + aligned - start.ToBytes()
+}
+
 type BlockDevice string
 
 func (b BlockDevice) Name() string {
@@ -100,22 +309,94 @@ func IsExists(f string) bool {
 	return !os.IsNotExist(err)
 }
 
-// ParseSize parses disk size string (e.g. "10GB" or "150MB") into MegaBytes
+// ParseSize parses disk size string (e.g. "10GB", "1GiB" or "150MB") into MegaBytes
 // If no unit string is provided, megabytes are assumed
 func ParseSize(sizeStr string) (MegaBytes, error) {
-	r, _ := regexp.Compile("^([0-9]+)(m|mb|M|MB|g|gb|G|GB)?$")
+	r, _ := regexp.Compile("^([0-9]+)(m|mb|M|MB|Mi|MiB|g|gb|G|GB|Gi|GiB)?$")
 	match := r.FindStringSubmatch(sizeStr)
 	if len(match) != 3 {
 		return -1, fmt.Errorf("%s: unrecognized size", sizeStr)
 	}
-	size, _ := strconv.ParseInt(match[1], 10, 64)
+	size, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %v", sizeStr, ErrInvalidSize)
+	}
 	unit := match[2]
 	switch unit {
-	case "g", "gb", "G", "GB":
+	case "g", "gb", "G", "GB", "Gi", "GiB":
+		if size > math.MaxInt64/1024 {
+			return -1, fmt.Errorf("%s: %v", sizeStr, ErrInvalidSize)
+		}
 		size *= 1024
 	}
 	if size == 0 {
 		return -1, fmt.Errorf("%s: size must be larger than zero", sizeStr)
 	}
-	return MegaBytes(size), nil
+	mb, err := NewMegaBytes(size)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %v", sizeStr, err)
+	}
+	return mb, nil
+}
+
+// ParseHumanSize parses a size in bytes, optionally with a unit suffix such as "500MiB",
+// "1GiB" or "2T". Like ParseSize, units are powers of 1024: KB and KiB are the same.
+func ParseHumanSize(s string) (int64, error) {
+	r, _ := regexp.Compile("^([0-9]+)([kKmMgGtT]?)(i?[bB])?$")
+	match := r.FindStringSubmatch(s)
+	if match == nil {
+		return -1, fmt.Errorf("%s: unrecognized size", s)
+	}
+	size, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %v", s, ErrInvalidSize)
+	}
+	if match[2] == "" && strings.HasPrefix(match[3], "i") {
+		return -1, fmt.Errorf("%s: unrecognized size", s)
+	}
+	shift := map[string]uint{"": 0, "k": 10, "m": 20, "g": 30, "t": 40}[strings.ToLower(match[2])]
+	if err := validateSize(size, shift); err != nil {
+		return -1, fmt.Errorf("%s: %v", s, err)
+	}
+	return size << shift, nil
+}
+
+// partedNoPartitionTable reports whether parted failed because the disk has no
+// partition table ("Error: /dev/loop0: unrecognised disk label")
+func partedNoPartitionTable(out []byte) bool {
+	output := strings.ToLower(string(out))
+	return strings.Contains(output, "unrecognised disk label") || strings.Contains(output, "unrecognized disk label")
+}
+
+// partedDiskLabel returns the partition table type parted printed (--machine)
+// for device, e.g. msdos or bsd. parted prints loop for disks holding a
+// filesystem without a partition table
+func partedDiskLabel(out []byte, device string) string {
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, device+":") {
+			continue
+		}
+		// device:size:transport:logical sector:physical sector:label:model:flags;
+		fields := strings.Split(strings.TrimSuffix(strings.TrimSpace(line), ";"), ":")
+		if len(fields) > 5 {
+			return fields[5]
+		}
+	}
+	return ""
+}
+
+var fstrimTrimmed = regexp.MustCompile(`\((\d+) bytes\) trimmed`)
+
+// parseFstrimOutput reads the bytes fstrim -v reports trimmed, e.g. from
+// "/mnt: 1.2 GiB (1288490188 bytes) trimmed"
+func parseFstrimOutput(out []byte) (Bytes, error) {
+	match := fstrimTrimmed.FindSubmatch(out)
+	if match == nil {
+		return 0, errors.New("unexpected fstrim output: "+strings.TrimSpace(string(out)), nil)
+	}
+	trimmed, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return 0, errors.New("parsing bytes trimmed by fstrim", err)
+	}
+	return Bytes(trimmed), nil
 }
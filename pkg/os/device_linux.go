@@ -3,22 +3,78 @@ package os
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/emc-advanced-dev/pkg/errors"
 
 	log "github.com/Sirupsen/logrus"
 )
 
+// mount fails with this when udev has not yet processed the filesystem that
+// mkfs just wrote on the device
+const superblockError = "can't read superblock"
+
+// how many more times MountDeviceContext runs mount after a superblock error
+const mountRetries = 3
+
+// the wait before the first retry of mount, doubled for every other
+const mountRetryBackoff = 500 * time.Millisecond
+
 func Mount(device BlockDevice) (mntpoint string, err error) {
 	return MountDevice(device.Name())
 }
 func MountDevice(device string) (mntpoint string, err error) {
+	return MountDeviceContext(context.Background(), device)
+}
+
+// MountContext is Mount, killing mount once ctx is done, e.g. when it hangs on
+// a corrupted filesystem
+func MountContext(ctx context.Context, device BlockDevice) (mntpoint string, err error) {
+	return MountDeviceContext(ctx, device.Name())
+}
+
+// MountDeviceContext is MountDevice, killing mount once ctx is done. mount is
+// retried with exponential backoff while it cannot read the superblock, as
+// happens right after mkfs when udev is still processing the device
+func MountDeviceContext(ctx context.Context, device string) (mntpoint string, err error) {
+	defer func() {
+		if err != nil {
+			os.Remove(mntpoint)
+		}
+	}()
+
+	mntpoint, err = ioutil.TempDir("", "stgr.mntpoint.")
+	if err != nil {
+		return
+	}
+	backoff := mountRetryBackoff
+	for attempt := 0; ; attempt++ {
+		var out []byte
+		out, err = runLogCommandOutput(ctx, "mount", device, mntpoint)
+		if err == nil || attempt >= mountRetries || !bytes.Contains(out, []byte(superblockError)) {
+			return
+		}
+		log.WithFields(log.Fields{"device": device, "attempt": attempt + 1}).Warnf("mount could not read the superblock, retrying in %v", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// MountDeviceReadOnly mounts device read-only on a new temporary directory
+func MountDeviceReadOnly(device string) (mntpoint string, err error) {
 	defer func() {
 		if err != nil {
 			os.Remove(mntpoint)
@@ -29,13 +85,240 @@ func MountDevice(device string) (mntpoint string, err error) {
 	if err != nil {
 		return
 	}
-	err = RunLogCommand("mount", device, mntpoint)
+	err = RunLogCommand("mount", "-o", "ro", device, mntpoint)
 	return
 }
 
+// MountImageReadOnly mounts the filesystem of a raw disk image read-only. if the
+// image is partitioned, its first partition is mounted. release unmounts the
+// image and frees the loop devices.
+func MountImageReadOnly(imgFile string) (mntpoint string, release func() error, err error) {
+	disk := NewReadOnlyLoDevice(imgFile)
+	dev, err := disk.Acquire()
+	if err != nil {
+		return "", nil, errors.New("loop mounting image "+imgFile, err)
+	}
+	parts, err := ListParts(dev)
+	disk.Release()
+	if err != nil && err != ErrNoPartitionTable {
+		return "", nil, errors.New("listing partitions of "+imgFile, err)
+	}
+
+	var loDevice Resource = NewReadOnlyLoDevice(imgFile)
+	if len(parts) > 0 {
+		loDevice = NewReadOnlyPartLoDevice(imgFile, parts[0].Offset(), parts[0].Size())
+	}
+	dev, err = loDevice.Acquire()
+	if err != nil {
+		return "", nil, errors.New("loop mounting filesystem of "+imgFile, err)
+	}
+	mntpoint, err = MountDeviceReadOnly(dev.Name())
+	if err != nil {
+		loDevice.Release()
+		return "", nil, errors.New("mounting filesystem of "+imgFile, err)
+	}
+	release = func() error {
+		if err := Umount(mntpoint); err != nil {
+			return err
+		}
+		return loDevice.Release()
+	}
+	return mntpoint, release, nil
+}
+
+// TrimImage discards the blocks the filesystem of a raw disk image no longer
+// uses, e.g. those of deleted files, with fstrim. the filesystem is mounted from a
+// loop device with -o discard, which punches holes in the image file for the
+// discarded blocks. if the image is partitioned, its first partition is trimmed.
+// it returns the bytes fstrim reports trimmed, which include free blocks that
+// were never written
+func TrimImage(imgFile string) (Bytes, error) {
+	loDevice, dev, err := acquireFilesystem(imgFile)
+	if err != nil {
+		return 0, err
+	}
+	defer loDevice.Release()
+	mntpoint, err := ioutil.TempDir("", "stgr.mntpoint.")
+	if err != nil {
+		return 0, err
+	}
+	if err := RunLogCommand("mount", "-o", "discard", dev.Name(), mntpoint); err != nil {
+		os.Remove(mntpoint)
+		return 0, errors.New("mounting filesystem of "+imgFile, err)
+	}
+	defer Umount(mntpoint)
+
+	out, err := exec.Command("fstrim", "-v", mntpoint).CombinedOutput()
+	if err != nil {
+		return 0, errors.New("fstrim failed: "+strings.TrimSpace(string(out)), err)
+	}
+	trimmed, err := parseFstrimOutput(out)
+	if err != nil {
+		return 0, err
+	}
+	log.WithFields(log.Fields{"image": imgFile, "trimmed": trimmed}).Debug("trimmed image")
+	return trimmed, nil
+}
+
+// acquireFilesystem sets up a read-write loop device for the filesystem of a raw
+// disk image: the first partition if the image is partitioned, or else the
+// whole image
+func acquireFilesystem(imgFile string) (Resource, BlockDevice, error) {
+	disk := NewReadOnlyLoDevice(imgFile)
+	dev, err := disk.Acquire()
+	if err != nil {
+		return nil, "", errors.New("loop mounting image "+imgFile, err)
+	}
+	parts, err := ListParts(dev)
+	disk.Release()
+	if err != nil && err != ErrNoPartitionTable {
+		return nil, "", errors.New("listing partitions of "+imgFile, err)
+	}
+
+	var loDevice Resource = NewLoDevice(imgFile)
+	if len(parts) > 0 {
+		loDevice = NewPartLoDevice(imgFile, parts[0].Offset(), parts[0].Size())
+	}
+	dev, err = loDevice.Acquire()
+	if err != nil {
+		return nil, "", errors.New("loop mounting filesystem of "+imgFile, err)
+	}
+	return loDevice, dev, nil
+}
+
+// ReadFilesystemIds reads the label and uuid of the filesystem of a raw disk
+// image with blkid. if the image is partitioned, those of its first partition
+// are read
+func ReadFilesystemIds(imgFile string) (FilesystemIds, error) {
+	fstype, ids, err := readDeviceFilesystemIds(context.Background(), BlockDevice(imgFile))
+	if err != nil || fstype != "" {
+		return ids, err
+	}
+	loDevice, dev, err := acquireFilesystem(imgFile)
+	if err != nil {
+		return FilesystemIds{}, err
+	}
+	defer loDevice.Release()
+	_, ids, err = readDeviceFilesystemIds(context.Background(), dev)
+	return ids, err
+}
+
+// SetFilesystemIds gives the filesystem of a raw disk image the label and uuid
+// of ids, e.g. those ReadFilesystemIds read from the volume it is a copy of.
+// empty ids are left as they are. if the image is partitioned, its first
+// partition is changed
+func SetFilesystemIds(imgFile string, ids FilesystemIds) error {
+	fstype, _, err := readDeviceFilesystemIds(context.Background(), BlockDevice(imgFile))
+	if err != nil {
+		return err
+	}
+	if fstype != "" {
+		return setDeviceFilesystemIds(context.Background(), BlockDevice(imgFile), ids)
+	}
+	loDevice, dev, err := acquireFilesystem(imgFile)
+	if err != nil {
+		return err
+	}
+	defer loDevice.Release()
+	return setDeviceFilesystemIds(context.Background(), dev, ids)
+}
+
+// DefragImage measures the fragmentation of the ext filesystem of a raw disk
+// image with e4defrag -c, mounted read-only, and if its fragmentation score is
+// above threshold, remounts it read-write and defragments it with e4defrag. if
+// the image is partitioned, its first partition is defragmented. the filesystem
+// is mounted with the ext4 driver, which reads ext2 and ext3 too, but e4defrag
+// only moves files that use extents, so only ext4 filesystems are defragmented;
+// for the others, ErrNotDefragmentable is returned with the scores measured.
+// xfs filesystems, which are defragmented with xfs_fsr, fail with ErrXfsDefrag
+func DefragImage(imgFile string, threshold int) (*DefragResult, error) {
+	loDevice, dev, err := acquireFilesystem(imgFile)
+	if err != nil {
+		return nil, err
+	}
+	defer loDevice.Release()
+	out, err := exec.Command("blkid", "-p", "-s", "TYPE", "-o", "value", dev.Name()).Output()
+	if err != nil {
+		return nil, errors.New("reading filesystem type of "+imgFile+" with blkid", err)
+	}
+	result := &DefragResult{FilesystemType: strings.TrimSpace(string(out))}
+	switch result.FilesystemType {
+	case "ext2", "ext3", "ext4":
+	case "xfs":
+		return nil, ErrXfsDefrag
+	default:
+		return nil, errors.New("cannot defragment "+imgFile+": e4defrag only defragments ext filesystems, not "+result.FilesystemType, nil)
+	}
+
+	mntpoint, err := ioutil.TempDir("", "stgr.mntpoint.")
+	if err != nil {
+		return nil, err
+	}
+	if err := RunLogCommand("mount", "-t", "ext4", "-o", "ro", dev.Name(), mntpoint); err != nil {
+		os.Remove(mntpoint)
+		return nil, errors.New("mounting filesystem of "+imgFile, err)
+	}
+	defer Umount(mntpoint)
+
+	result.ScoreBefore, err = fragmentationScore(mntpoint)
+	if err != nil {
+		return nil, err
+	}
+	result.ScoreAfter = result.ScoreBefore
+	if result.ScoreBefore <= threshold {
+		return result, nil
+	}
+	if result.FilesystemType != "ext4" {
+		return result, ErrNotDefragmentable
+	}
+	if err := RunLogCommand("mount", "-o", "remount,rw", mntpoint); err != nil {
+		return nil, errors.New("remounting filesystem of "+imgFile+" read-write", err)
+	}
+	if out, err := exec.Command("e4defrag", mntpoint).CombinedOutput(); err != nil {
+		return nil, errors.New("e4defrag failed: "+strings.TrimSpace(string(out)), err)
+	}
+	result.Defragmented = true
+	result.ScoreAfter, err = fragmentationScore(mntpoint)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"image": imgFile, "before": result.ScoreBefore, "after": result.ScoreAfter}).Debug("defragmented image")
+	return result, nil
+}
+
+// fragmentationScore runs e4defrag -c on the filesystem mounted at mntpoint
+func fragmentationScore(mntpoint string) (int, error) {
+	out, err := exec.Command("e4defrag", "-c", mntpoint).CombinedOutput()
+	if err != nil {
+		return 0, errors.New("e4defrag -c failed: "+strings.TrimSpace(string(out)), err)
+	}
+	return parseE4defragScore(out)
+}
+
+// AllocatedSize returns the space a file takes on its filesystem, less than its
+// size if it is sparse
+func AllocatedSize(file string) (Bytes, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return Bytes(info.Size()), nil
+	}
+	// st_blocks is in 512 byte units whatever the block size of the filesystem
+	return Bytes(stat.Blocks * 512), nil
+}
+
 func Umount(point string) error {
+	return UmountContext(context.Background(), point)
+}
 
-	err := RunLogCommand("umount", point)
+// UmountContext is Umount, killing umount once ctx is done, e.g. when it hangs
+// on a stale nfs mount
+func UmountContext(ctx context.Context, point string) error {
+
+	err := RunLogCommandContext(ctx, "umount", point)
 	if err != nil {
 		return err
 	}
@@ -49,8 +332,17 @@ func Umount(point string) error {
 }
 
 func runParted(device string, args ...string) ([]byte, error) {
-	log.WithFields(log.Fields{"device": device, "args": args}).Debug("running parted")
-	args = append([]string{"--script", "--machine", device}, args...)
+	return runPartedWithOptions(device, nil, args...)
+}
+
+// runPartedAligned runs parted with the partition alignment best for sectorSize
+func runPartedAligned(device string, sectorSize SectorSizeBytes, args ...string) ([]byte, error) {
+	return runPartedWithOptions(device, alignOptions(sectorSize), args...)
+}
+
+func runPartedWithOptions(device string, options []string, args ...string) ([]byte, error) {
+	log.WithFields(log.Fields{"device": device, "options": options, "args": args}).Debug("running parted")
+	args = append(append(append([]string{"--script", "--machine"}, options...), device), args...)
 	out, err := exec.Command("parted", args...).CombinedOutput()
 	if err != nil {
 		log.WithFields(log.Fields{"args": args, "err": err, "out": string(out)}).Error("parted failed")
@@ -58,8 +350,19 @@ func runParted(device string, args ...string) ([]byte, error) {
 	return out, err
 }
 
+// alignOptions has parted align partitions for the best performance on disks with
+// 4096 byte sectors. parted's default alignment suits 512 byte sectors.
+func alignOptions(sectorSize SectorSizeBytes) []string {
+	if sectorSize == SectorSize4K {
+		return []string{"--align", "optimal"}
+	}
+	return nil
+}
+
 type MsDosPartioner struct {
 	Device string
+	// SectorSize of the disk. 0 means SectorSize512
+	SectorSize SectorSizeBytes
 }
 
 func (m *MsDosPartioner) MakeTable() error {
@@ -67,13 +370,20 @@ func (m *MsDosPartioner) MakeTable() error {
 	return err
 }
 
+// MakePart makes a partition from start to size (the end of the partition, as
+// mkpart takes it), moved up to start on a DefaultPartitionAlignMB boundary. it
+// returns ErrImageTooLarge for partitions beyond MaxMBRSectors
 func (m *MsDosPartioner) MakePart(partType string, start, size DiskSize) error {
-	_, err := runParted(m.Device, "mkpart", partType, start.ToPartedFormat(), size.ToPartedFormat())
+	alignedStart, alignedEnd := alignPartition(start, size)
+	if err := checkMBRLimit(alignedEnd, m.SectorSize); err != nil {
+		return err
+	}
+	_, err := runPartedAligned(m.Device, m.SectorSize, "mkpart", partType, alignedStart.ToPartedFormat(), alignedEnd.ToPartedFormat())
 	return err
 }
 
 func (m *MsDosPartioner) MakePartTillEnd(partType string, start DiskSize) error {
-	_, err := runParted(m.Device, "mkpart", partType, start.ToPartedFormat(), "100%")
+	_, err := runPartedAligned(m.Device, m.SectorSize, "mkpart", partType, AlignUp(start.ToBytes(), DefaultPartitionAlignMB).ToPartedFormat(), "100%")
 	return err
 }
 
@@ -84,6 +394,8 @@ func (m *MsDosPartioner) Makebootable(partnum int) error {
 
 type DiskLabelPartioner struct {
 	Device string
+	// SectorSize of the disk. 0 means SectorSize512
+	SectorSize SectorSizeBytes
 }
 
 func (m *DiskLabelPartioner) MakeTable() error {
@@ -91,15 +403,68 @@ func (m *DiskLabelPartioner) MakeTable() error {
 	return err
 }
 
+// MakePart is MsDosPartioner.MakePart for bsd disk labels
 func (m *DiskLabelPartioner) MakePart(partType string, start, size DiskSize) error {
-	_, err := runParted(m.Device, "mkpart", partType, start.ToPartedFormat(), size.ToPartedFormat())
+	alignedStart, alignedEnd := alignPartition(start, size)
+	if err := checkMBRLimit(alignedEnd, m.SectorSize); err != nil {
+		return err
+	}
+	_, err := runPartedAligned(m.Device, m.SectorSize, "mkpart", partType, alignedStart.ToPartedFormat(), alignedEnd.ToPartedFormat())
+	return err
+}
+
+type GPTPartitioner struct {
+	Device string
+	// SectorSize of the disk. 0 means SectorSize512
+	SectorSize SectorSizeBytes
+}
+
+func (m *GPTPartitioner) MakeTable() error {
+	_, err := runParted(m.Device, "mklabel", "gpt")
+	return err
+}
+
+// MakePart is MsDosPartioner.MakePart for gpt. gpt partitions have a name
+// instead of a type; they are all named primary
+func (m *GPTPartitioner) MakePart(partType string, start, size DiskSize) error {
+	alignedStart, alignedEnd := alignPartition(start, size)
+	_, err := runPartedAligned(m.Device, m.SectorSize, "mkpart", "primary", partType, alignedStart.ToPartedFormat(), alignedEnd.ToPartedFormat())
 	return err
 }
 
+// IsAlreadyPartitioned reports whether the disk image file already has a
+// partition table, by attaching it read-only as a loop device
+func IsAlreadyPartitioned(file string) (bool, error) {
+	disk := NewReadOnlyLoDevice(file)
+	dev, err := disk.Acquire()
+	if err != nil {
+		return false, errors.New("loop mounting "+file, err)
+	}
+	defer disk.Release()
+	return hasPartitionTable(dev)
+}
+
+func hasPartitionTable(device BlockDevice) (bool, error) {
+	out, err := runParted(device.Name(), "unit B", "print")
+	if err != nil {
+		if partedNoPartitionTable(out) {
+			return false, nil
+		}
+		return false, errors.New("reading partition table of "+device.Name(), err)
+	}
+	label := partedDiskLabel(out, device.Name())
+	return label != "" && label != "loop", nil
+}
+
+// ListParts returns the partitions of device, or ErrNoPartitionTable if it has
+// no partition table. other failures of parted are ignored, and list no partitions
 func ListParts(device BlockDevice) ([]Part, error) {
 	var parts []Part
 	out, err := runParted(device.Name(), "unit B", "print")
 	if err != nil {
+		if partedNoPartitionTable(out) {
+			return nil, ErrNoPartitionTable
+		}
 		return parts, nil
 	}
 	scanner := bufio.NewScanner(bytes.NewReader(out))
@@ -157,7 +522,7 @@ func ListParts(device BlockDevice) ([]Part, error) {
 
 		//validate Part is consistent:
 		if end-start != size-1 {
-			log.WithFields(log.Fields{"start": start, "end": end, "size": size}).Error("Sizes not consistent")
+			log.WithFields(log.Fields{"start": start.String(), "end": end.String(), "size": size.String()}).Error("Sizes not consistent")
 			return parts, errors.New("Sizes are inconsistent. part not continous?", nil)
 		}
 
@@ -254,16 +619,65 @@ type LoDevice struct {
 	createdDevice BlockDevice
 	offset        DiskSize
 	size          DiskSize
+	readOnly      bool
+	// sectorSize is the logical sector size of the loop device. 0 leaves it to
+	// losetup, which uses 512
+	sectorSize SectorSizeBytes
+	// MaxRetries is how many more times Acquire runs losetup after it fails,
+	// since it sometimes finds no free loop device on a loaded host although
+	// there are some
+	MaxRetries int
 }
 
+// DefaultLoDeviceRetries is the MaxRetries of the loop devices made by NewLoDevice
+// and the others, for 5 attempts in all
+const DefaultLoDeviceRetries = 4
+
+// the wait before the first retry of losetup, doubled for every other
+const loDeviceRetryBackoff = 100 * time.Millisecond
+
 func NewLoDevice(device string) Resource {
-	return &LoDevice{device, BlockDevice(""), nil, nil}
+	return &LoDevice{device, BlockDevice(""), nil, nil, false, 0, DefaultLoDeviceRetries}
+}
+
+// NewSectorSizeLoDevice is NewLoDevice, with logical sectors of sectorSize bytes
+func NewSectorSizeLoDevice(device string, sectorSize SectorSizeBytes) Resource {
+	return &LoDevice{device, BlockDevice(""), nil, nil, false, sectorSize, DefaultLoDeviceRetries}
 }
 func NewPartLoDevice(device string, offset DiskSize, size DiskSize) Part {
-	return &LoDevice{device, BlockDevice(""), offset, size}
+	return &LoDevice{device, BlockDevice(""), offset, size, false, 0, DefaultLoDeviceRetries}
+}
+func NewReadOnlyLoDevice(device string) Resource {
+	return &LoDevice{device, BlockDevice(""), nil, nil, true, 0, DefaultLoDeviceRetries}
+}
+func NewReadOnlyPartLoDevice(device string, offset DiskSize, size DiskSize) Part {
+	return &LoDevice{device, BlockDevice(""), offset, size, true, 0, DefaultLoDeviceRetries}
 }
 
+// Acquire sets up a loop device for the file, trying MaxRetries more times with
+// exponential backoff if losetup fails. the error of the first attempt is returned
+// when they all fail
 func (p *LoDevice) Acquire() (BlockDevice, error) {
+	var firstErr error
+	backoff := loDeviceRetryBackoff
+	for attempt := 0; ; attempt++ {
+		device, err := p.acquire()
+		if err == nil {
+			return device, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if attempt >= p.MaxRetries {
+			return BlockDevice(""), firstErr
+		}
+		log.WithFields(log.Fields{"device": p.device, "attempt": attempt + 1, "error": err}).Warnf("losetup failed, retrying in %v", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (p *LoDevice) acquire() (BlockDevice, error) {
 	log.WithFields(log.Fields{"cmd": "losetup", "device": p.device}).Debug("running losetup -f")
 
 	args := []string{"-f", "--show", p.device}
@@ -276,6 +690,14 @@ func (p *LoDevice) Acquire() (BlockDevice, error) {
 		args = append(args, "--offset", fmt.Sprintf("%d", p.offset.ToBytes()))
 	}
 
+	if p.readOnly {
+		args = append(args, "--read-only")
+	}
+
+	if p.sectorSize != 0 {
+		args = append(args, "--sector-size", fmt.Sprintf("%d", p.sectorSize))
+	}
+
 	out, err := exec.Command("losetup", args...).CombinedOutput()
 
 	if err != nil {
@@ -302,3 +724,29 @@ func (p *LoDevice) Size() DiskSize {
 func (p *LoDevice) Offset() DiskSize {
 	return p.offset
 }
+
+// CheckLoopDevices returns an error if no loop device can be set up, e.g. because
+// the loop module is not loaded or losetup is missing
+func CheckLoopDevices() error {
+	out, err := exec.Command("losetup", "-f").CombinedOutput()
+	if err != nil {
+		return errors.New("finding a free loop device: "+strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// DetectSectorSize returns the physical sector size of device (e.g. /dev/sda or sda),
+// as reported in /sys/block/<dev>/queue/physical_block_size
+func DetectSectorSize(device string) (int, error) {
+	name := filepath.Base(device)
+	sysFile := filepath.Join("/sys/block", name, "queue", "physical_block_size")
+	data, err := ioutil.ReadFile(sysFile)
+	if err != nil {
+		return 0, errors.New("reading "+sysFile, err)
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, errors.New("parsing sector size "+string(data)+" of "+device, err)
+	}
+	return size, nil
+}
@@ -2,6 +2,12 @@
 
 package os
 
+import (
+	"context"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
 func Mount(device BlockDevice) (mntpoint string, err error) {
 	panic("Not supported")
 }
@@ -9,12 +15,53 @@ func MountDevice(device string) (mntpoint string, err error) {
 	panic("Not supported")
 }
 
+func MountContext(ctx context.Context, device BlockDevice) (mntpoint string, err error) {
+	panic("Not supported")
+}
+
+func MountDeviceContext(ctx context.Context, device string) (mntpoint string, err error) {
+	panic("Not supported")
+}
+
+func MountDeviceReadOnly(device string) (mntpoint string, err error) {
+	panic("Not supported")
+}
+
+func MountImageReadOnly(imgFile string) (mntpoint string, release func() error, err error) {
+	panic("Not supported")
+}
+
+func TrimImage(imgFile string) (Bytes, error) {
+	panic("Not supported")
+}
+
+func ReadFilesystemIds(imgFile string) (FilesystemIds, error) {
+	return FilesystemIds{}, errors.New("Not supported", nil)
+}
+
+func SetFilesystemIds(imgFile string, ids FilesystemIds) error {
+	return errors.New("Not supported", nil)
+}
+
+func DefragImage(imgFile string, threshold int) (*DefragResult, error) {
+	panic("Not supported")
+}
+
+func AllocatedSize(file string) (Bytes, error) {
+	panic("Not supported")
+}
+
 func Umount(point string) error {
 	panic("Not supported")
 }
 
+func UmountContext(ctx context.Context, point string) error {
+	panic("Not supported")
+}
+
 type MsDosPartioner struct {
-	Device string
+	Device     string
+	SectorSize SectorSizeBytes
 }
 
 func (m *MsDosPartioner) MakeTable() error {
@@ -36,7 +83,8 @@ func (m *MsDosPartioner) Makebootable(partnum int) error {
 }
 
 type DiskLabelPartioner struct {
-	Device string
+	Device     string
+	SectorSize SectorSizeBytes
 }
 
 func (m *DiskLabelPartioner) MakeTable() error {
@@ -49,6 +97,27 @@ func (m *DiskLabelPartioner) MakePart(partType string, start, size DiskSize) err
 	return nil
 }
 
+type GPTPartitioner struct {
+	Device     string
+	SectorSize SectorSizeBytes
+}
+
+func (m *GPTPartitioner) MakeTable() error {
+	panic("Not supported")
+}
+
+func (m *GPTPartitioner) MakePart(partType string, start, size DiskSize) error {
+	panic("Not supported")
+}
+
+func IsAlreadyPartitioned(file string) (bool, error) {
+	panic("Not supported")
+}
+
+func hasPartitionTable(device BlockDevice) (bool, error) {
+	panic("Not supported")
+}
+
 func ListParts(device BlockDevice) ([]Part, error) {
 	panic("Not supported")
 	return nil, nil
@@ -120,6 +189,7 @@ func (p *DeviceMapperDevice) Get() BlockDevice {
 }
 
 type LoDevice struct {
+	MaxRetries int
 }
 
 func NewLoDevice(device string) Resource {
@@ -128,6 +198,18 @@ func NewLoDevice(device string) Resource {
 	return nil
 }
 
+func NewSectorSizeLoDevice(device string, sectorSize SectorSizeBytes) Resource {
+
+	panic("Not supported")
+	return nil
+}
+
+func NewReadOnlyLoDevice(device string) Resource {
+
+	panic("Not supported")
+	return nil
+}
+
 func (p *LoDevice) Acquire() (BlockDevice, error) {
 
 	panic("Not supported")
@@ -139,3 +221,11 @@ func (p *LoDevice) Release() error {
 	panic("Not supported")
 	return nil
 }
+
+func DetectSectorSize(device string) (int, error) {
+	panic("Not supported")
+}
+
+func CheckLoopDevices() error {
+	return errors.New("loop devices are only supported on linux", nil)
+}
@@ -0,0 +1,244 @@
+package os
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DiskSize", func() {
+	Describe("constructors", func() {
+		It("should reject negative sizes", func() {
+			_, err := NewBytes(-1)
+			Expect(err).To(Equal(ErrInvalidSize))
+			_, err = NewMegaBytes(-1)
+			Expect(err).To(Equal(ErrInvalidSize))
+		})
+		It("should reject sizes that overflow when converted to bytes", func() {
+			_, err := NewKiloBytes(math.MaxInt64 >> 9)
+			Expect(err).To(Equal(ErrInvalidSize))
+			_, err = NewGigaBytes(math.MaxInt64 >> 29)
+			Expect(err).To(Equal(ErrInvalidSize))
+			_, err = NewTeraBytes(math.MaxInt64 >> 39)
+			Expect(err).To(Equal(ErrInvalidSize))
+		})
+		It("should accept valid sizes", func() {
+			gb, err := NewGigaBytes(3)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gb.ToBytes()).To(Equal(Bytes(3 << 30)))
+			tb, err := NewTeraBytes(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tb.ToPartedFormat()).To(Equal("1TiB"))
+		})
+	})
+	Describe("ToSectors", func() {
+		It("should convert aligned sizes", func() {
+			sectors, err := ToSectors(MegaBytes(1))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sectors).To(Equal(Sectors(2048)))
+			Expect(sectors.ToBytes()).To(Equal(MegaBytes(1).ToBytes()))
+			Expect(sectors.ToPartedFormat()).To(Equal("1048576B"))
+		})
+		It("should reject sizes that are not whole sectors", func() {
+			_, err := ToSectors(Bytes(SectorSize + 1))
+			Expect(err).To(HaveOccurred())
+		})
+		It("should convert sizes beyond the MBR limit", func() {
+			sectors, err := ToSectors(TeraBytes(3))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sectors.ToBytes()).To(Equal(TeraBytes(3).ToBytes()))
+		})
+	})
+	Describe("RoundToSectorSize", func() {
+		It("should round up to whole 4096 byte sectors", func() {
+			Expect(RoundToSectorSize(Bytes(SectorSize), SectorSize4K)).To(Equal(Bytes(4096)))
+			Expect(RoundToSectorSize(MegaBytes(1), SectorSize4K)).To(Equal(MegaBytes(1).ToBytes()))
+			Expect(RoundToSectorSize(Bytes(4097), SectorSize512)).To(Equal(Bytes(4608)))
+		})
+		It("should reject unsupported sector sizes", func() {
+			_, err := RoundToSectorSize(MegaBytes(1), 1024)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Describe("checkMBRLimit", func() {
+		It("should allow exactly 2 TiB", func() {
+			Expect(checkMBRLimit(TeraBytes(2), SectorSize512)).To(Succeed())
+			Expect(checkMBRLimit(TeraBytes(2), 0)).To(Succeed())
+		})
+		It("should reject partitions above the MBR limit", func() {
+			Expect(checkMBRLimit(TeraBytes(2).ToBytes()+SectorSize, SectorSize512)).To(Equal(ErrImageTooLarge))
+		})
+		It("should address 16 TiB of 4096 byte sectors", func() {
+			Expect(checkMBRLimit(TeraBytes(16), SectorSize4K)).To(Succeed())
+			Expect(checkMBRLimit(TeraBytes(16).ToBytes()+Bytes(SectorSize4K), SectorSize4K)).To(Equal(ErrImageTooLarge))
+		})
+	})
+	Describe("AlignUp", func() {
+		It("should round offsets up to the next boundary", func() {
+			Expect(AlignUp(Bytes(1), 1)).To(Equal(MegaBytes(1).ToBytes()))
+			Expect(AlignUp(MegaBytes(2).ToBytes()+SectorSize, 1)).To(Equal(MegaBytes(3).ToBytes()))
+			Expect(AlignUp(MegaBytes(5).ToBytes(), 4)).To(Equal(MegaBytes(8).ToBytes()))
+		})
+		It("should leave aligned offsets", func() {
+			Expect(AlignUp(0, 1)).To(Equal(Bytes(0)))
+			Expect(AlignUp(MegaBytes(2).ToBytes(), 1)).To(Equal(MegaBytes(2).ToBytes()))
+		})
+		It("should default to 1 MiB", func() {
+			Expect(AlignUp(Bytes(4096), 0)).To(Equal(MegaBytes(1).ToBytes()))
+		})
+	})
+	Describe("alignPartition", func() {
+		It("should start every partition on a 1 MiB boundary", func() {
+			// partitions laid out back to back, as CreateVolumes does
+			start := MegaBytes(2).ToBytes()
+			for _, size := range []Bytes{MegaBytes(3).ToBytes() + 7, Bytes(SectorSize), MegaBytes(10).ToBytes() + 4096, MegaBytes(1).ToBytes()} {
+				alignedStart, alignedEnd := alignPartition(start, start+size)
+				Expect(alignedStart % MegaBytes(1).ToBytes()).To(Equal(Bytes(0)))
+				Expect(alignedStart).To(BeNumerically(">=", start))
+				Expect(alignedEnd - alignedStart).To(Equal(size))
+				start = alignedEnd
+			}
+		})
+	})
+	Describe("ParseSize", func() {
+		It("should reject sizes that overflow", func() {
+			_, err := ParseSize("9223372036854775807GB")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should parse gigabytes", func() {
+			size, err := ParseSize("2GB")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(MegaBytes(2048)))
+		})
+		It("should parse binary units", func() {
+			size, err := ParseSize("1GiB")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(MegaBytes(1024)))
+			size, err = ParseSize("500MiB")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(MegaBytes(500)))
+		})
+	})
+	Describe("ParseHumanSize", func() {
+		It("should parse bytes and units", func() {
+			size, err := ParseHumanSize("4096")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(4096)))
+			size, err = ParseHumanSize("500MiB")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(500 << 20)))
+			size, err = ParseHumanSize("1G")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(1 << 30)))
+		})
+		It("should reject unknown units and overflows", func() {
+			_, err := ParseHumanSize("1PB")
+			Expect(err).To(HaveOccurred())
+			_, err = ParseHumanSize("9223372036854775807T")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("partedNoPartitionTable", func() {
+	It("should recognise the error parted prints for disks without a partition table", func() {
+		Expect(partedNoPartitionTable([]byte("Error: /dev/loop0: unrecognised disk label\n"))).To(BeTrue())
+		Expect(partedNoPartitionTable([]byte("Error: /dev/loop0: unrecognized disk label\n"))).To(BeTrue())
+	})
+	It("should not mistake other parted errors for it", func() {
+		Expect(partedNoPartitionTable([]byte("Error: Could not stat device /dev/loop9 - No such file or directory.\n"))).To(BeFalse())
+	})
+})
+
+var _ = Describe("DiskSize String", func() {
+	It("should print sizes below a KiB in bytes", func() {
+		Expect(Bytes(0).String()).To(Equal("0 B"))
+		Expect(Bytes(1023).String()).To(Equal("1023 B"))
+	})
+	It("should print sizes in the largest unit they have one of", func() {
+		Expect(Bytes(1536).String()).To(Equal("1.50 KiB"))
+		Expect(MegaBytes(1536).String()).To(Equal("1.50 GiB"))
+		Expect(KiloBytes(2048).String()).To(Equal("2.00 MiB"))
+		Expect(GigaBytes(3).String()).To(Equal("3.00 GiB"))
+		Expect(TeraBytes(2).String()).To(Equal("2.00 TiB"))
+		Expect(TeraBytes(2048).String()).To(Equal("2048.00 TiB"))
+		Expect(Sectors(4096).String()).To(Equal("2.00 MiB"))
+	})
+})
+
+var _ = Describe("partedDiskLabel", func() {
+	It("should read the partition table type of the device", func() {
+		out := "BYT;\n/dev/loop0:1073741824B:loopback:512:512:msdos:Loopback device:;\n1:2097152B:99614719B:97517568B:ext2::boot;\n"
+		Expect(partedDiskLabel([]byte(out), "/dev/loop0")).To(Equal("msdos"))
+	})
+	It("should read loop for a filesystem without a partition table", func() {
+		out := "BYT;\n/dev/loop1:10485760B:loopback:512:512:loop:Loopback device:;\n1:0B:10485759B:10485760B:ext2::;\n"
+		Expect(partedDiskLabel([]byte(out), "/dev/loop1")).To(Equal("loop"))
+	})
+	It("should return nothing when the device is not listed", func() {
+		Expect(partedDiskLabel([]byte("BYT;\n"), "/dev/loop0")).To(Equal(""))
+	})
+})
+
+var _ = Describe("parseFstrimOutput", func() {
+	It("should read the bytes trimmed", func() {
+		trimmed, err := parseFstrimOutput([]byte("/tmp/stgr.mntpoint.123: 1.2 GiB (1288490188 bytes) trimmed\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(trimmed).To(Equal(Bytes(1288490188)))
+	})
+	It("should read nothing trimmed", func() {
+		trimmed, err := parseFstrimOutput([]byte("/mnt: 0 B (0 bytes) trimmed\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(trimmed).To(Equal(Bytes(0)))
+	})
+	It("should fail on other output", func() {
+		_, err := parseFstrimOutput([]byte("fstrim: /mnt: the discard operation is not supported\n"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Mount", func() {
+	It("should mount a filesystem right after mkfs made it", func() {
+		skipWithoutLoopDevices()
+		img, err := ioutil.TempFile("", "mount.img.")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(img.Name())
+		Expect(img.Truncate(16 << 20)).To(Succeed())
+		img.Close()
+
+		loDevice := NewLoDevice(img.Name())
+		dev, err := loDevice.Acquire()
+		if err != nil {
+			Skip("no loop device: " + err.Error())
+		}
+		defer loDevice.Release()
+		Expect(RunLogCommand("mkfs", "-t", "ext4", "-q", dev.Name())).To(Succeed())
+		mntpoint, err := Mount(dev)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Umount(mntpoint)).To(Succeed())
+	})
+})
+
+var _ = Describe("NewSectorSizeLoDevice", func() {
+	It("should attach the file with logical sectors of the sector size", func() {
+		skipWithoutLoopDevices()
+		img, err := ioutil.TempFile("", "sectors.img.")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(img.Name())
+		Expect(img.Truncate(8 << 20)).To(Succeed())
+		img.Close()
+
+		loDevice := NewSectorSizeLoDevice(img.Name(), SectorSize4K)
+		dev, err := loDevice.Acquire()
+		Expect(err).NotTo(HaveOccurred())
+		defer loDevice.Release()
+		logical, err := ioutil.ReadFile(filepath.Join("/sys/block", filepath.Base(dev.Name()), "queue", "logical_block_size"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.TrimSpace(string(logical))).To(Equal("4096"))
+	})
+})
@@ -0,0 +1,151 @@
+package os
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+)
+
+// FileChange describes how a single file differs between two directory trees.
+// Sizes are in bytes; hashes are only set for modified regular files.
+type FileChange struct {
+	Path      string `json:"Path"`
+	Change    string `json:"Change"`
+	OldSize   int64  `json:"OldSize"`
+	NewSize   int64  `json:"NewSize"`
+	SizeDelta int64  `json:"SizeDelta"`
+	OldSha256 string `json:"OldSha256,omitempty"`
+	NewSha256 string `json:"NewSha256,omitempty"`
+}
+
+type treeEntry struct {
+	info os.FileInfo
+	path string
+}
+
+func walkTree(root string) (map[string]treeEntry, error) {
+	entries := make(map[string]treeEntry)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || info.IsDir() {
+			return nil
+		}
+		entries[rel] = treeEntry{info: info, path: path}
+		return nil
+	})
+	return entries, err
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DiffDirs compares the files under oldDir and newDir and returns the files that
+// were added, removed or modified, sorted by path. regular files are compared by
+// size and SHA-256; symlinks by target.
+func DiffDirs(oldDir, newDir string) ([]FileChange, error) {
+	oldTree, err := walkTree(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := walkTree(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []FileChange{}
+	for rel, oldEntry := range oldTree {
+		newEntry, ok := newTree[rel]
+		if !ok {
+			changes = append(changes, FileChange{
+				Path:      rel,
+				Change:    FileRemoved,
+				OldSize:   oldEntry.info.Size(),
+				SizeDelta: -oldEntry.info.Size(),
+			})
+			continue
+		}
+		change, modified, err := compareEntries(rel, oldEntry, newEntry)
+		if err != nil {
+			return nil, err
+		}
+		if modified {
+			changes = append(changes, change)
+		}
+	}
+	for rel, newEntry := range newTree {
+		if _, ok := oldTree[rel]; !ok {
+			changes = append(changes, FileChange{
+				Path:      rel,
+				Change:    FileAdded,
+				NewSize:   newEntry.info.Size(),
+				SizeDelta: newEntry.info.Size(),
+			})
+		}
+	}
+	sort.Sort(changesByPath(changes))
+	return changes, nil
+}
+
+func compareEntries(rel string, oldEntry, newEntry treeEntry) (FileChange, bool, error) {
+	change := FileChange{
+		Path:      rel,
+		Change:    FileModified,
+		OldSize:   oldEntry.info.Size(),
+		NewSize:   newEntry.info.Size(),
+		SizeDelta: newEntry.info.Size() - oldEntry.info.Size(),
+	}
+	oldMode, newMode := oldEntry.info.Mode(), newEntry.info.Mode()
+	if oldMode&os.ModeSymlink != 0 || newMode&os.ModeSymlink != 0 {
+		oldTarget, _ := os.Readlink(oldEntry.path)
+		newTarget, _ := os.Readlink(newEntry.path)
+		return change, oldMode.IsRegular() != newMode.IsRegular() || oldTarget != newTarget, nil
+	}
+	if !oldMode.IsRegular() || !newMode.IsRegular() {
+		return change, oldMode != newMode, nil
+	}
+	oldHash, err := sha256File(oldEntry.path)
+	if err != nil {
+		return change, false, err
+	}
+	newHash, err := sha256File(newEntry.path)
+	if err != nil {
+		return change, false, err
+	}
+	if oldHash == newHash {
+		return change, false, nil
+	}
+	change.OldSha256 = oldHash
+	change.NewSha256 = newHash
+	return change, true, nil
+}
+
+type changesByPath []FileChange
+
+func (c changesByPath) Len() int           { return len(c) }
+func (c changesByPath) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c changesByPath) Less(i, j int) bool { return c[i].Path < c[j].Path }
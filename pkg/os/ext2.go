@@ -0,0 +1,137 @@
+package os
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// Ext2Info is the superblock information of an ext2/3/4 filesystem, as reported by dumpe2fs
+type Ext2Info struct {
+	FilesystemType string `json:"FilesystemType"`
+	VolumeName     string `json:"VolumeName,omitempty"`
+	UUID           string `json:"UUID"`
+	State          string `json:"State"`
+	BlockSize      int64  `json:"BlockSize"`
+	BlockCount     int64  `json:"BlockCount"`
+	FreeBlocks     int64  `json:"FreeBlocks"`
+	InodeCount     int64  `json:"InodeCount"`
+	FreeInodes     int64  `json:"FreeInodes"`
+	MountCount     int64  `json:"MountCount"`
+	LastMounted    string `json:"LastMounted,omitempty"`
+	LastWritten    string `json:"LastWritten,omitempty"`
+}
+
+// ReadExt2Info reads the superblock of the ext filesystem that starts at offset in imgFile
+func ReadExt2Info(imgFile string, offset Bytes) (*Ext2Info, error) {
+	device := imgFile
+	if offset > 0 {
+		device = fmt.Sprintf("%s?offset=%d", imgFile, offset)
+	}
+	out, err := exec.Command("dumpe2fs", "-h", device).Output()
+	if err != nil {
+		return nil, errors.New("running dumpe2fs on "+device, err)
+	}
+	return parseDumpe2fs(out)
+}
+
+func parseDumpe2fs(out []byte) (*Ext2Info, error) {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		keyValue := strings.SplitN(scanner.Text(), ":", 2)
+		if len(keyValue) == 2 {
+			fields[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+		}
+	}
+	if _, ok := fields["Block count"]; !ok {
+		return nil, errors.New("dumpe2fs output has no block count, not an ext filesystem?", nil)
+	}
+	info := &Ext2Info{
+		FilesystemType: "ext2",
+		UUID:           fields["Filesystem UUID"],
+		State:          fields["Filesystem state"],
+		LastMounted:    fields["Last mount time"],
+		LastWritten:    fields["Last write time"],
+	}
+	if name := fields["Filesystem volume name"]; name != "<none>" {
+		info.VolumeName = name
+	}
+	features := strings.Fields(fields["Filesystem features"])
+	for _, feature := range features {
+		if feature == "has_journal" && info.FilesystemType == "ext2" {
+			info.FilesystemType = "ext3"
+		}
+		if feature == "extent" || feature == "extents" {
+			info.FilesystemType = "ext4"
+		}
+	}
+	for key, value := range map[string]*int64{
+		"Block size":  &info.BlockSize,
+		"Block count": &info.BlockCount,
+		"Free blocks": &info.FreeBlocks,
+		"Inode count": &info.InodeCount,
+		"Free inodes": &info.FreeInodes,
+		"Mount count": &info.MountCount,
+	} {
+		if fields[key] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(fields[key], 10, 64)
+		if err != nil {
+			return nil, errors.New("parsing "+key+" "+fields[key], err)
+		}
+		*value = n
+	}
+	return info, nil
+}
+
+// CheckExt2 runs a read-only e2fsck on the ext filesystem that starts at offset
+// in imgFile. the error includes what e2fsck found if the filesystem is not consistent.
+func CheckExt2(imgFile string, offset Bytes) error {
+	device := imgFile
+	if offset > 0 {
+		device = fmt.Sprintf("%s?offset=%d", imgFile, offset)
+	}
+	out, err := exec.Command("e2fsck", "-n", "-f", device).CombinedOutput()
+	if err != nil {
+		return errors.New("e2fsck found problems on "+device+": "+strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// DefragResult is the fragmentation of a filesystem before and after
+// DefragImage. scores are those of e4defrag -c: 0-30 is no problem, 31-55 a
+// little fragmented, 56 and above needs defragmenting
+type DefragResult struct {
+	FilesystemType string `json:"FilesystemType"`
+	ScoreBefore    int    `json:"ScoreBefore"`
+	ScoreAfter     int    `json:"ScoreAfter"`
+	Defragmented   bool   `json:"Defragmented"`
+}
+
+var e4defragScore = regexp.MustCompile(`Fragmentation score\s+(\d+)`)
+
+// parseE4defragScore reads the fragmentation score e4defrag -c prints, e.g.
+// " Fragmentation score				12". e4defrag prints nothing for a filesystem
+// without regular files, which scores 0
+func parseE4defragScore(out []byte) (int, error) {
+	match := e4defragScore.FindSubmatch(out)
+	if match == nil {
+		if len(bytes.TrimSpace(out)) == 0 {
+			return 0, nil
+		}
+		return 0, errors.New("no fragmentation score in the output of e4defrag: "+strings.TrimSpace(string(out)), nil)
+	}
+	score, err := strconv.Atoi(string(match[1]))
+	if err != nil {
+		return 0, errors.New("parsing fragmentation score of e4defrag", err)
+	}
+	return score, nil
+}
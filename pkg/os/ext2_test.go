@@ -0,0 +1,66 @@
+package os
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseDumpe2fs", func() {
+	It("should read the superblock fields", func() {
+		info, err := parseDumpe2fs([]byte(`dumpe2fs 1.42.13 (17-May-2015)
+Filesystem volume name:   <none>
+Filesystem UUID:          4f1c2e1a-4a0b-4bb5-9c3d-0f6a1d2e3b4c
+Filesystem features:      ext_attr resize_inode dir_index filetype sparse_super
+Filesystem state:         clean
+Inode count:              2560
+Block count:              10240
+Free blocks:              9770
+Free inodes:              2549
+Block size:               1024
+Mount count:              3
+Last write time:          Mon Oct 12 10:00:00 2026
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.FilesystemType).To(Equal("ext2"))
+		Expect(info.VolumeName).To(BeEmpty())
+		Expect(info.BlockCount).To(Equal(int64(10240)))
+		Expect(info.InodeCount).To(Equal(int64(2560)))
+		Expect(info.MountCount).To(Equal(int64(3)))
+		Expect(info.LastWritten).To(Equal("Mon Oct 12 10:00:00 2026"))
+	})
+	It("should tell ext4 from ext2", func() {
+		info, err := parseDumpe2fs([]byte("Filesystem features: has_journal extent\nBlock count: 1\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.FilesystemType).To(Equal("ext4"))
+	})
+	It("should fail for output without a superblock", func() {
+		_, err := parseDumpe2fs([]byte("dumpe2fs: Bad magic number in super-block\n"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("parseE4defragScore", func() {
+	It("should read the fragmentation score", func() {
+		score, err := parseE4defragScore([]byte(`<Fragmented files>                             now/best       size/ext
+1. /tmp/stgr.mntpoint.1/data/log                  12/1             42 KB
+
+ Total/best extents				37/26
+ Average size per extent			310 KB
+ Fragmentation score				43
+ [0-30 no problem: 31-55 a little bit fragmented: 56- needs defrag]
+ This directory (/tmp/stgr.mntpoint.1) needs defragmentation.
+ Done.
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(score).To(Equal(43))
+	})
+	It("should score a filesystem without files 0", func() {
+		score, err := parseE4defragScore([]byte("\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(score).To(Equal(0))
+	})
+	It("should fail on other output", func() {
+		_, err := parseE4defragScore([]byte("e4defrag: Filesystem is not ext4 filesystem\n"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,69 @@
+package os
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// GrubMenuEntry is a boot entry of a grub legacy menu.lst, such as the ones written from GrubTemplate
+type GrubMenuEntry struct {
+	Title       string
+	Root        string
+	Kernel      string
+	CommandLine string
+}
+
+// ParseGrubMenu returns the default entry of a grub legacy menu.lst. it fails
+// if the menu has no entries, or the default entry has no root or kernel.
+func ParseGrubMenu(menu string) (*GrubMenuEntry, error) {
+	defaultEntry := 0
+	entries := []*GrubMenuEntry{}
+	scanner := bufio.NewScanner(strings.NewReader(menu))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		command := fields[0]
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(scanner.Text()), command))
+		if strings.Contains(command, "=") {
+			keyValue := strings.SplitN(command, "=", 2)
+			command, value = keyValue[0], keyValue[1]
+		}
+		switch command {
+		case "title":
+			entries = append(entries, &GrubMenuEntry{Title: value})
+		case "default":
+			if _, err := fmt.Sscanf(value, "%d", &defaultEntry); err != nil {
+				return nil, fmt.Errorf("line %d: default must be an entry number, got %q", line, value)
+			}
+		case "fallback", "timeout", "hiddenmenu":
+		case "root", "kernel":
+			if len(entries) == 0 {
+				return nil, fmt.Errorf("line %d: %s before the first title", line, command)
+			}
+			entry := entries[len(entries)-1]
+			if command == "root" {
+				entry.Root = value
+				continue
+			}
+			kernelArgs := strings.SplitN(value, " ", 2)
+			entry.Kernel = kernelArgs[0]
+			if len(kernelArgs) == 2 {
+				entry.CommandLine = strings.TrimSpace(kernelArgs[1])
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("menu has no boot entries")
+	}
+	if defaultEntry < 0 || defaultEntry >= len(entries) {
+		return nil, fmt.Errorf("default entry %d does not exist, the menu has %d", defaultEntry, len(entries))
+	}
+	entry := entries[defaultEntry]
+	if entry.Root == "" || entry.Kernel == "" {
+		return nil, fmt.Errorf("entry %q must set a root and a kernel", entry.Title)
+	}
+	return entry, nil
+}
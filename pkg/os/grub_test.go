@@ -0,0 +1,40 @@
+package os
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseGrubMenu", func() {
+	It("should parse the menus written from GrubTemplate", func() {
+		entry, err := ParseGrubMenu("default=0\nfallback=1\ntimeout=1\nhiddenmenu\n\ntitle Unik\nroot (hd0,0)\nkernel /boot/program.bin console=ttyS0 -- app\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Title).To(Equal("Unik"))
+		Expect(entry.Root).To(Equal("(hd0,0)"))
+		Expect(entry.Kernel).To(Equal("/boot/program.bin"))
+		Expect(entry.CommandLine).To(Equal("console=ttyS0 -- app"))
+	})
+	It("should fail for menus that can't boot", func() {
+		_, err := ParseGrubMenu("default=0\ntimeout=1\n")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseGrubMenu("title Unik\nroot (hd0)\n")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseGrubMenu("default=1\ntitle Unik\nroot (hd0)\nkernel /boot/program.bin\n")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("DiskNamingConvention", func() {
+	It("should name the disk grub is installed to after the hypervisor", func() {
+		Expect(DiskNaming_IDE.PartName(1)).To(Equal("hda1"))
+		Expect(DiskNaming_VirtIO.PartName(1)).To(Equal("vda1"))
+		Expect(DiskNaming_NVMe.PartName(1)).To(Equal("nvme0n1p1"))
+	})
+	It("should default to IDE", func() {
+		naming, err := ParseDiskNamingConvention("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(naming).To(Equal(DiskNaming_IDE))
+		_, err = ParseDiskNamingConvention("scsi")
+		Expect(err).To(HaveOccurred())
+	})
+})
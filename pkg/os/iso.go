@@ -0,0 +1,133 @@
+package os
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// IsolinuxDir is where CreateISOImage copies the ISOLINUX boot loader from
+var IsolinuxDir = "/usr/lib/syslinux"
+
+const IsolinuxTemplate = `default unik
+prompt 0
+timeout 1
+
+label unik
+  kernel /boot/program.bin
+  append {{.CommandLine}}
+`
+
+// CreateISOImage writes a bootable iso image to outputISO, which boots the
+// kernel at progPath with commandline using ISOLINUX. the iso is built with
+// genisoimage, or mkisofs if genisoimage is not installed.
+func CreateISOImage(progPath, commandline, outputISO string) error {
+	isoTool, err := findISOTool()
+	if err != nil {
+		return err
+	}
+
+	isoRoot, err := ioutil.TempDir("", "iso.root.")
+	if err != nil {
+		return errors.New("creating tmp iso root folder", err)
+	}
+	defer os.RemoveAll(isoRoot)
+
+	isolinuxPath := filepath.Join(isoRoot, "isolinux")
+	bootPath := filepath.Join(isoRoot, "boot")
+	for _, dir := range []string{isolinuxPath, bootPath} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.New("creating "+dir, err)
+		}
+	}
+
+	if err := CopyFile(progPath, filepath.Join(bootPath, ProgramName)); err != nil {
+		return errors.New("copying kernel "+progPath, err)
+	}
+	if err := CopyFile(filepath.Join(IsolinuxDir, "isolinux.bin"), filepath.Join(isolinuxPath, "isolinux.bin")); err != nil {
+		return errors.New("copying isolinux.bin from "+IsolinuxDir, err)
+	}
+	// syslinux 5 and later also need ldlinux.c32 next to isolinux.bin
+	if _, err := os.Stat(filepath.Join(IsolinuxDir, "ldlinux.c32")); err == nil {
+		if err := CopyFile(filepath.Join(IsolinuxDir, "ldlinux.c32"), filepath.Join(isolinuxPath, "ldlinux.c32")); err != nil {
+			return errors.New("copying ldlinux.c32 from "+IsolinuxDir, err)
+		}
+	}
+
+	if err := writeIsolinuxConfig(filepath.Join(isolinuxPath, "isolinux.cfg"), commandline); err != nil {
+		return errors.New("writing isolinux.cfg", err)
+	}
+
+	log.WithFields(log.Fields{"tool": isoTool, "kernel": progPath, "iso": outputISO}).Debug("creating iso image")
+	return RunLogCommand(isoTool,
+		"-o", outputISO,
+		"-b", "isolinux/isolinux.bin",
+		"-c", "isolinux/boot.cat",
+		"-no-emul-boot",
+		"-boot-load-size", "4",
+		"-boot-info-table",
+		"-J", "-R",
+		"-V", "unik",
+		isoRoot,
+	)
+}
+
+// CreateNoCloudISO writes an iso image with the user-data and meta-data files of
+// the cloud-init nocloud data source to outputISO. cloud-init (and unikernels
+// that read their configuration the same way) find it by its volume id, cidata.
+func CreateNoCloudISO(userData, metaData []byte, outputISO string) error {
+	isoTool, err := findISOTool()
+	if err != nil {
+		return err
+	}
+
+	isoRoot, err := ioutil.TempDir("", "nocloud.root.")
+	if err != nil {
+		return errors.New("creating tmp iso root folder", err)
+	}
+	defer os.RemoveAll(isoRoot)
+
+	if err := ioutil.WriteFile(filepath.Join(isoRoot, "user-data"), userData, 0644); err != nil {
+		return errors.New("writing user-data", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(isoRoot, "meta-data"), metaData, 0644); err != nil {
+		return errors.New("writing meta-data", err)
+	}
+
+	log.WithFields(log.Fields{"tool": isoTool, "iso": outputISO}).Debug("creating nocloud iso image")
+	return RunLogCommand(isoTool,
+		"-o", outputISO,
+		"-volid", "cidata",
+		"-J", "-R",
+		isoRoot,
+	)
+}
+
+// findISOTool returns genisoimage, or mkisofs if genisoimage is not installed
+func findISOTool() (string, error) {
+	for _, isoTool := range []string{"genisoimage", "mkisofs"} {
+		if _, err := exec.LookPath(isoTool); err == nil {
+			return isoTool, nil
+		}
+	}
+	return "", errors.New("creating an iso image requires genisoimage or mkisofs", nil)
+}
+
+func writeIsolinuxConfig(fname, commandline string) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	t := template.Must(template.New("isolinux").Parse(IsolinuxTemplate))
+
+	return t.Execute(f, struct {
+		CommandLine string
+	}{commandline})
+}
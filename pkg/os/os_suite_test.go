@@ -0,0 +1,25 @@
+package os
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"os"
+	"testing"
+)
+
+func TestOs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Os Suite")
+}
+
+// skipWithoutLoopDevices skips specs that partition, format or mount disk
+// images, which need root and a free loop device
+func skipWithoutLoopDevices() {
+	if os.Geteuid() != 0 {
+		Skip("loop devices need root")
+	}
+	if err := CheckLoopDevices(); err != nil {
+		Skip(err.Error())
+	}
+}
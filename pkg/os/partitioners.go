@@ -0,0 +1,60 @@
+package os
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PartitionerFactory returns a Partitioner of device, a disk with sectors of
+// sectorSize bytes
+type PartitionerFactory func(device string, sectorSize SectorSizeBytes) Partitioner
+
+const (
+	PartitionTableBSD   = "bsd"
+	PartitionTableMsDos = "msdos"
+	PartitionTableGPT   = "gpt"
+	// DefaultPartitionTable is the table of volumes made without one named:
+	// the bsd disk label rump reads
+	DefaultPartitionTable = PartitionTableBSD
+)
+
+// PartitionerRegistry has the factory of the partitioner of each kind of
+// partition table, by the name parted gives it
+var PartitionerRegistry = map[string]PartitionerFactory{
+	PartitionTableBSD: func(device string, sectorSize SectorSizeBytes) Partitioner {
+		return &DiskLabelPartioner{Device: device, SectorSize: sectorSize}
+	},
+	PartitionTableMsDos: func(device string, sectorSize SectorSizeBytes) Partitioner {
+		return &MsDosPartioner{Device: device, SectorSize: sectorSize}
+	},
+	PartitionTableGPT: func(device string, sectorSize SectorSizeBytes) Partitioner {
+		return &GPTPartitioner{Device: device, SectorSize: sectorSize}
+	},
+}
+
+// PartitionTables lists the names of PartitionerRegistry
+func PartitionTables() []string {
+	names := []string{}
+	for name := range PartitionerRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetPartitioner returns the newPartitioner CreateVolumes takes for the
+// partition table name, on disks with sectors of sectorSize bytes. an empty
+// name is DefaultPartitionTable
+func GetPartitioner(name string, sectorSize SectorSizeBytes) (func(device string) Partitioner, error) {
+	if name == "" {
+		name = DefaultPartitionTable
+	}
+	factory, ok := PartitionerRegistry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown partition table %q; must be one of %s", name, strings.Join(PartitionTables(), "|"))
+	}
+	return func(device string) Partitioner {
+		return factory(device, sectorSize)
+	}, nil
+}
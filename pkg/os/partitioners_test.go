@@ -0,0 +1,27 @@
+package os
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetPartitioner", func() {
+	It("should make the partitioner of each partition table", func() {
+		newPartitioner, err := GetPartitioner("msdos", SectorSize4K)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newPartitioner("/dev/loop0")).To(Equal(&MsDosPartioner{Device: "/dev/loop0", SectorSize: SectorSize4K}))
+		newPartitioner, err = GetPartitioner("GPT", SectorSize512)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newPartitioner("/dev/loop0")).To(Equal(&GPTPartitioner{Device: "/dev/loop0", SectorSize: SectorSize512}))
+	})
+	It("should default to a bsd disk label", func() {
+		newPartitioner, err := GetPartitioner("", SectorSize512)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newPartitioner("/dev/loop0")).To(BeAssignableToTypeOf(&DiskLabelPartioner{}))
+	})
+	It("should reject unknown partition tables", func() {
+		_, err := GetPartitioner("apm", SectorSize512)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("bsd|gpt|msdos"))
+	})
+})
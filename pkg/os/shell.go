@@ -1,28 +1,84 @@
 package os
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"path/filepath"
 )
 
+// CommandTimeout bounds the commands run by RunLogCommandContext (mkfs,
+// grub-install, dd...) when their context has no deadline, so a hung tool fails
+// the build instead of blocking it
+var CommandTimeout = 10 * time.Minute
+
+// CommandTimeoutError is returned for commands killed because their context was
+// done before they exited
+type CommandTimeoutError struct {
+	Command string
+	Elapsed time.Duration
+	// Err is the error of the context, context.DeadlineExceeded once the
+	// timeout elapsed
+	Err error
+}
+
+func (e *CommandTimeoutError) Error() string {
+	return fmt.Sprintf("%s killed after %v: %v", e.Command, e.Elapsed, e.Err)
+}
+
 func RunLogCommand(name string, args ...string) error {
+	return RunLogCommandContext(context.Background(), name, args...)
+}
+
+// RunLogCommandContext runs a command as RunLogCommand does, and kills it once
+// ctx is done, or CommandTimeout elapsed if ctx has no deadline of its own
+func RunLogCommandContext(ctx context.Context, name string, args ...string) error {
+	_, err := runLogCommandOutput(ctx, name, args...)
+	return err
+}
+
+// runLogCommandOutput is RunLogCommandContext, also returning the combined
+// output of the command for callers that handle some of its failures
+func runLogCommandOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, CommandTimeout)
+		defer cancel()
+	}
 	log.WithFields(log.Fields{"cmd": name, "args": args}).Debug("running " + name)
 
-	out, err := exec.Command(name, args...).CombinedOutput()
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil && ctx.Err() != nil {
+		timeoutErr := &CommandTimeoutError{Command: name, Elapsed: time.Since(start).Round(time.Millisecond), Err: ctx.Err()}
+		log.WithFields(log.Fields{"out": string(out)}).Error(timeoutErr.Error())
+		return out, timeoutErr
+	}
 	if err != nil {
 		log.WithFields(log.Fields{"out": string(out)}).Error(name + " failed")
 
 	}
-	return err
+	return out, err
 }
 
 func GetDirSize(dir string) (int64, error) {
+	return GetDirSizeContext(context.Background(), dir)
+}
+
+// GetDirSizeContext is GetDirSize, giving up with ctx.Err() once ctx is done.
+// ctx is checked before every file and folder, so a walk stuck on a slow
+// (e.g. nfs) mount stops at the next entry
+func GetDirSizeContext(ctx context.Context, dir string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 
 	stat, err := os.Stat(dir)
 	if err != nil {
@@ -38,7 +94,7 @@ func GetDirSize(dir string) (int64, error) {
 		}
 		var sum int64 = 0
 		for _, obj := range entries {
-			curSize, err := GetDirSize(path.Join(dir, obj.Name()))
+			curSize, err := GetDirSizeContext(ctx, path.Join(dir, obj.Name()))
 			if err != nil {
 				return 0, err
 			}
@@ -59,27 +115,57 @@ func listDir(path string) ([]os.FileInfo, error) {
 	return directory.Readdir(-1)
 }
 
+// CopyErrors are the errors of the files and folders CopyDir could not copy
+type CopyErrors []error
+
+func (e CopyErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d files could not be copied: %s", len(e), strings.Join(messages, "; "))
+}
+
 // https://www.socketloop.com/tutorials/golang-copy-directory-including-sub-directories-files
 
-func CopyDir(source string, dest string) (err error) {
+// CopyDir copies the files and folders of source into dest. a file that cannot
+// be copied does not stop the others from being copied; the errors of all of
+// them are returned as CopyErrors
+func CopyDir(source string, dest string) error {
+	var errs CopyErrors
+	copyDir(source, dest, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func copyDir(source string, dest string, errs *CopyErrors) {
 	// get properties of source dir
 	sourceinfo, err := os.Stat(source)
 	if err != nil {
-		return err
+		*errs = append(*errs, err)
+		return
 	}
 
 	// create dest dir
 
-	err = os.MkdirAll(dest, sourceinfo.Mode())
-	if err != nil {
-		return err
+	if err := os.MkdirAll(dest, sourceinfo.Mode()); err != nil {
+		*errs = append(*errs, err)
+		return
 	}
 
-	directory, _ := os.Open(source)
+	directory, err := os.Open(source)
+	if err != nil {
+		*errs = append(*errs, err)
+		return
+	}
+	defer directory.Close()
 
 	objects, err := directory.Readdir(-1)
 	if err != nil {
-		return err
+		*errs = append(*errs, err)
+		return
 	}
 
 	for _, obj := range objects {
@@ -90,24 +176,67 @@ func CopyDir(source string, dest string) (err error) {
 
 		sfi, err := os.Stat(sourcefilepointer)
 		if err != nil {
-			return err
+			*errs = append(*errs, err)
+			continue
 		}
 		if sfi.IsDir() {
 			// create sub-directories - recursively
-			err = CopyDir(sourcefilepointer, destinationfilepointer)
+			copyDir(sourcefilepointer, destinationfilepointer, errs)
+		} else if err := CopyFile(sourcefilepointer, destinationfilepointer); err != nil {
+			*errs = append(*errs, fmt.Errorf("copying %s: %v", sourcefilepointer, err))
+		}
+	}
+}
+
+// MergeDir copies the files of source that dest does not have into dest, keeping
+// the ones it has. it returns the paths of the copied files, relative to dest
+func MergeDir(source, dest string) ([]string, error) {
+	var copied []string
+	err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		// the lost+found of a mounted filesystem is not part of its contents
+		if rel == "lost+found" && info.IsDir() {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dest, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
 			if err != nil {
 				return err
 			}
-		} else {
-			// perform copy
-			err = CopyFile(sourcefilepointer, destinationfilepointer)
-			if err != nil {
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := copyFileContents(path, target); err != nil {
 				return err
 			}
+			if err := os.Chmod(target, info.Mode()); err != nil {
+				return err
+			}
+		default:
+			log.Warnf("not copying %s, which is not a regular file", path)
+			return nil
 		}
-	}
-
-	return
+		copied = append(copied, rel)
+		return nil
+	})
+	return copied, err
 }
 
 /// http://stackoverflow.com/questions/21060945/simple-way-to-copy-a-file-in-golang/21067803#21067803
@@ -116,6 +245,22 @@ func CopyDir(source string, dest string) (err error) {
 // the same, then return success. Otherise, attempt to create a hard link
 // between the two files. If that fail, copy the file contents from src to dst.
 func CopyFile(src, dst string) error {
+	return CopyFileWithContext(context.Background(), src, dst, nil)
+}
+
+// CopyFileWithProgress is CopyFile, also writing the contents it copies to
+// progress (e.g. a CopyProgressLogger). nothing is written when the file is
+// hard linked
+func CopyFileWithProgress(src, dst string, progress io.Writer) error {
+	return CopyFileWithContext(context.Background(), src, dst, progress)
+}
+
+// CopyFileWithContext is CopyFileWithProgress, giving up once ctx is done. the
+// partly copied dst is removed then. progress may be nil
+func CopyFileWithContext(ctx context.Context, src, dst string, progress io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	sfi, err := os.Stat(src)
 	if err != nil {
 		return err
@@ -141,15 +286,24 @@ func CopyFile(src, dst string) error {
 	if err = os.Link(src, dst); err == nil {
 		return nil
 	}
-	err = copyFileContents(src, dst)
-	return err
+	if err := copyFileContentsContext(ctx, src, dst, progress); err != nil {
+		if ctx.Err() != nil {
+			os.Remove(dst)
+		}
+		return err
+	}
+	return nil
 }
 
 // copyFileContents copies the contents of the file named src to the file named
 // by dst. The file will be created if it does not already exist. If the
 // destination file exists, all it's contents will be replaced by the contents
 // of the source file.
-func copyFileContents(src, dst string) (err error) {
+func copyFileContents(src, dst string) error {
+	return copyFileContentsContext(context.Background(), src, dst, nil)
+}
+
+func copyFileContentsContext(ctx context.Context, src, dst string, progress io.Writer) (err error) {
 	in, err := os.Open(src)
 	if err != nil {
 		return
@@ -165,13 +319,79 @@ func copyFileContents(src, dst string) (err error) {
 			err = cerr
 		}
 	}()
-	if _, err = io.Copy(out, in); err != nil {
+	var reader io.Reader = &contextReader{ctx: ctx, reader: in}
+	if progress != nil {
+		reader = io.TeeReader(reader, progress)
+	}
+	if _, err = io.Copy(out, reader); err != nil {
 		return
 	}
 	err = out.Sync()
 	return
 }
 
+// contextReader fails reads once ctx is done, so copies from it stop between chunks
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+const (
+	copyProgressBytes    = 10 << 20
+	copyProgressInterval = 5 * time.Second
+)
+
+// CopyProgressLogger counts the bytes written to it, and logs how many of the
+// Total bytes of a copy of Name are done every 10MB or 5 seconds
+type CopyProgressLogger struct {
+	Name  string
+	Total int64
+
+	copied     int64
+	loggedAt   int64
+	loggedTime time.Time
+}
+
+func NewCopyProgressLogger(name string, total int64) *CopyProgressLogger {
+	return &CopyProgressLogger{Name: name, Total: total, loggedTime: time.Now()}
+}
+
+func (l *CopyProgressLogger) Write(p []byte) (int, error) {
+	l.copied += int64(len(p))
+	done := l.Total > 0 && l.copied >= l.Total
+	if l.copied-l.loggedAt >= copyProgressBytes || time.Since(l.loggedTime) >= copyProgressInterval || done {
+		fields := log.Fields{"file": l.Name, "copied": l.copied}
+		if l.Total > 0 {
+			fields["total"] = l.Total
+			fields["percent"] = fmt.Sprintf("%.1f", float64(l.copied)*100/float64(l.Total))
+		}
+		log.WithFields(fields).Info("copying file")
+		l.loggedAt = l.copied
+		l.loggedTime = time.Now()
+	}
+	return len(p), nil
+}
+
+// Copied is the number of bytes written to the logger so far
+func (l *CopyProgressLogger) Copied() int64 {
+	return l.copied
+}
+
+// BootImageSizeMB is the size of the boot disk the boot creator makes for the
+// given bytes of kernel and static files: 10% more, and 20MB for the partition
+// table and grub
+func BootImageSizeMB(contentSize int64) int64 {
+	withSlack := float64(contentSize) * 1.1
+	return (int64(withSlack) >> 20) + 20
+}
+
 // http://stackoverflow.com/questions/32482673/golang-how-to-get-directory-total-size
 func DirSize(path string) (int64, error) {
 	var size int64
@@ -0,0 +1,188 @@
+package os
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MergeDir", func() {
+	var source, dest string
+	BeforeEach(func() {
+		var err error
+		source, err = ioutil.TempDir("", "merge.source.")
+		Expect(err).NotTo(HaveOccurred())
+		dest, err = ioutil.TempDir("", "merge.dest.")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(source)
+		os.RemoveAll(dest)
+	})
+	write := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+	read := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+	It("should copy the files dest does not have and keep the ones it has", func() {
+		write(filepath.Join(source, "boot", "program.bin"), "base kernel")
+		write(filepath.Join(source, "etc", "config"), "base config")
+		write(filepath.Join(dest, "etc", "config"), "app config")
+		Expect(os.MkdirAll(filepath.Join(source, "lost+found"), 0700)).To(Succeed())
+
+		copied, err := MergeDir(source, dest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(copied).To(ConsistOf(filepath.Join("boot", "program.bin")))
+		Expect(read(filepath.Join(dest, "boot", "program.bin"))).To(Equal("base kernel"))
+		Expect(read(filepath.Join(dest, "etc", "config"))).To(Equal("app config"))
+		_, err = os.Stat(filepath.Join(dest, "lost+found"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("CopyDir", func() {
+	var source, dest string
+	BeforeEach(func() {
+		var err error
+		source, err = ioutil.TempDir("", "copy.source.")
+		Expect(err).NotTo(HaveOccurred())
+		dest, err = ioutil.TempDir("", "copy.dest.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(source, "a"), []byte("a"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(source, "sub"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(source, "sub", "b"), []byte("b"), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.Chmod(filepath.Join(source, "sub"), 0755)
+		os.RemoveAll(source)
+		os.RemoveAll(dest)
+	})
+	It("should copy folders recursively", func() {
+		Expect(CopyDir(source, dest)).To(Succeed())
+		data, err := ioutil.ReadFile(filepath.Join(dest, "sub", "b"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("b"))
+	})
+	It("should copy the other files and return the errors of those it could not copy", func() {
+		Expect(os.Symlink(filepath.Join(source, "missing"), filepath.Join(source, "dangling"))).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(source, "sub", "c"), []byte("c"), 0644)).To(Succeed())
+
+		err := CopyDir(source, dest)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(CopyErrors)).To(HaveLen(1))
+		Expect(err.Error()).To(ContainSubstring("dangling"))
+		_, err = os.Stat(filepath.Join(dest, "a"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = os.Stat(filepath.Join(dest, "sub", "c"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("should return the error of a read protected source", func() {
+		if os.Geteuid() == 0 {
+			Skip("root can read read protected files")
+		}
+		Expect(os.Chmod(filepath.Join(source, "sub"), 0)).To(Succeed())
+
+		err := CopyDir(source, dest)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("permission denied"))
+		_, err = os.Stat(filepath.Join(dest, "a"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("BootImageSizeMB", func() {
+	It("leaves room for grub and the partition table with no content", func() {
+		Expect(BootImageSizeMB(0)).To(Equal(int64(20)))
+	})
+	It("adds 10% to the content", func() {
+		Expect(BootImageSizeMB(100 << 20)).To(Equal(int64(130)))
+	})
+})
+
+var _ = Describe("CopyFileWithContext", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "copy.")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	It("writes what it copies to the progress sink", func() {
+		src := filepath.Join(dir, "program.bin")
+		contents := bytes.Repeat([]byte("unik"), 1<<20)
+		Expect(ioutil.WriteFile(src, contents, 0644)).To(Succeed())
+		progress := NewCopyProgressLogger(src, int64(len(contents)))
+
+		Expect(copyFileContentsContext(context.Background(), src, filepath.Join(dir, "copy.bin"), progress)).To(Succeed())
+		Expect(progress.Copied()).To(Equal(int64(len(contents))))
+		copied, err := ioutil.ReadFile(filepath.Join(dir, "copy.bin"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(copied).To(Equal(contents))
+	})
+	It("does not copy once the context is done", func() {
+		src := filepath.Join(dir, "program.bin")
+		Expect(ioutil.WriteFile(src, []byte("kernel"), 0644)).To(Succeed())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		Expect(CopyFileWithContext(ctx, src, filepath.Join(dir, "copy.bin"), nil)).To(MatchError(context.Canceled))
+		_, err := os.Stat(filepath.Join(dir, "copy.bin"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("GetDirSizeContext", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "dirsize.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(dir, "sub"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 23), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	It("sums the sizes of the files in the tree", func() {
+		size, err := GetDirSizeContext(context.Background(), dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal(int64(123)))
+	})
+	It("returns the error of a done context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := GetDirSizeContext(ctx, dir)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
+
+var _ = Describe("RunLogCommandContext", func() {
+	It("kills the command once the context is done", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := RunLogCommandContext(ctx, "sleep", "5")
+		Expect(err).To(BeAssignableToTypeOf(&CommandTimeoutError{}))
+		timeoutErr := err.(*CommandTimeoutError)
+		Expect(timeoutErr.Command).To(Equal("sleep"))
+		Expect(timeoutErr.Err).To(Equal(context.DeadlineExceeded))
+		Expect(timeoutErr.Elapsed).To(BeNumerically("<", 5*time.Second))
+	})
+	It("returns the error of a failed command as it is", func() {
+		err := RunLogCommandContext(context.Background(), "false")
+		Expect(err).To(HaveOccurred())
+		Expect(err).NotTo(BeAssignableToTypeOf(&CommandTimeoutError{}))
+	})
+})
@@ -1,12 +1,17 @@
 package os
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
+	"strings"
 	"text/template"
 
 	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/pborman/uuid"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -14,6 +19,17 @@ import (
 type RawVolume struct {
 	Path string `json:"Path"`
 	Size int64  `json:"Size"`
+	// ReadOnly volumes are mounted read-only by the instance, so their contents
+	// never change. Path is then an already formatted filesystem image (such as
+	// a copy of another volume), which is written to the partition as it is
+	// instead of being formatted and filled from a folder.
+	ReadOnly bool `json:"ReadOnly,omitempty"`
+	// Label and UUID are given to the filesystem made for the volume instead of
+	// no label and a random uuid, e.g. for reproducible builds or to mount it
+	// by uuid. a UUID can only be set on ext2 volumes. ReadOnly images keep
+	// the label and uuid they have unless these are set
+	Label string `json:"Label,omitempty"`
+	UUID  string `json:"UUID,omitempty"`
 }
 
 const GrubTemplate = `default=0
@@ -31,14 +47,65 @@ const DeviceMapFile = `(hd0) {{.GrubDevice}}
 
 const ProgramName = "program.bin"
 
-func createSparseFile(filename string, size DiskSize) error {
+// DiskNamingConvention is how the hypervisor an image is built for names its disks,
+// which decides the name of the device grub is installed to
+type DiskNamingConvention string
+
+const (
+	// DiskNaming_IDE disks are named hda, hdb, ...
+	DiskNaming_IDE DiskNamingConvention = "ide"
+	// DiskNaming_VirtIO disks (virtio-blk, e.g. under KVM) are named vda, vdb, ...
+	DiskNaming_VirtIO DiskNamingConvention = "virtio"
+	// DiskNaming_NVMe disks are named nvme0n1, nvme1n1, ...
+	DiskNaming_NVMe DiskNamingConvention = "nvme"
+)
+
+// ParseDiskNamingConvention returns the convention named s. "" means DiskNaming_IDE
+func ParseDiskNamingConvention(s string) (DiskNamingConvention, error) {
+	switch DiskNamingConvention(s) {
+	case "", DiskNaming_IDE:
+		return DiskNaming_IDE, nil
+	case DiskNaming_VirtIO, DiskNaming_NVMe:
+		return DiskNamingConvention(s), nil
+	}
+	return "", fmt.Errorf("unknown disk naming convention %q: must be %s, %s or %s", s, DiskNaming_IDE, DiskNaming_VirtIO, DiskNaming_NVMe)
+}
+
+// DiskName returns the name of the first disk
+func (c DiskNamingConvention) DiskName() string {
+	switch c {
+	case DiskNaming_VirtIO:
+		return "vda"
+	case DiskNaming_NVMe:
+		return "nvme0n1"
+	}
+	return "hda"
+}
+
+// PartName returns the name of partition partNum of the first disk
+func (c DiskNamingConvention) PartName(partNum int) string {
+	if c == DiskNaming_NVMe {
+		return fmt.Sprintf("%sp%d", c.DiskName(), partNum)
+	}
+	return fmt.Sprintf("%s%d", c.DiskName(), partNum)
+}
+
+// createSparseFile creates a sparse file of size bytes, rounded up to a whole number of sectors
+func createSparseFile(filename string, size DiskSize, sectorSize SectorSizeBytes) error {
+	if size.ToBytes() < 1 {
+		return ErrInvalidSize
+	}
+	sizeBytes, err := RoundToSectorSize(size, sectorSize)
+	if err != nil {
+		return err
+	}
 	fd, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer fd.Close()
 
-	_, err = fd.Seek(int64(size.ToBytes())-1, 0)
+	_, err = fd.Seek(int64(sizeBytes)-1, 0)
 	if err != nil {
 		return err
 	}
@@ -49,20 +116,36 @@ func createSparseFile(filename string, size DiskSize) error {
 	return nil
 }
 
-func CreateBootImageWithSize(rootFile string, size DiskSize, progPath, staticFilesDir, commandline string, usePartitionTables bool) error {
-	err := createSparseFile(rootFile, size)
+func CreateBootImageWithSize(rootFile string, size DiskSize, progPath, staticFilesDir, commandline string, usePartitionTables bool, naming DiskNamingConvention) error {
+	return CreateBootImageWithSizeContext(context.Background(), rootFile, size, progPath, staticFilesDir, commandline, usePartitionTables, naming)
+}
+
+// CreateBootImageWithSizeContext is CreateBootImageWithSize, with the context
+// of the build. images with partition tables are made with CreateBootImageOnFileContext
+func CreateBootImageWithSizeContext(ctx context.Context, rootFile string, size DiskSize, progPath, staticFilesDir, commandline string, usePartitionTables bool, naming DiskNamingConvention) error {
+	err := createSparseFile(rootFile, size, SectorSize512)
 	if err != nil {
 		return err
 	}
-	log.WithFields(log.Fields{"imgFile": rootFile, "size": size.ToPartedFormat()}).Debug("created sparse file")
+	log.WithFields(log.Fields{"imgFile": rootFile, "size": size.String()}).Debug("created sparse file")
 
 	if usePartitionTables {
-		return CreateBootImageOnFile(rootFile, progPath, staticFilesDir, commandline)
+		return CreateBootImageOnFileContext(ctx, rootFile, progPath, staticFilesDir, commandline, naming)
 	}
 	return CreateBootImageOnFilePvGrub(rootFile, progPath, staticFilesDir, commandline)
 }
 
-func CreateBootImageOnFile(rootFile string, progPath, staticFilesDir, commandline string) error {
+// CreateBootImageOnFile partitions rootFile and installs grub to it, as the first
+// disk named according to naming. it returns ErrAlreadyFormatted, leaving the
+// file as it is, if rootFile already has a partition table
+func CreateBootImageOnFile(rootFile string, progPath, staticFilesDir, commandline string, naming DiskNamingConvention) error {
+	return CreateBootImageOnFileContext(context.Background(), rootFile, progPath, staticFilesDir, commandline, naming)
+}
+
+// CreateBootImageOnFileContext is CreateBootImageOnFile, killing mkfs, mount and
+// grub-install once ctx is done, so a corrupted filesystem fails the build
+// instead of hanging it
+func CreateBootImageOnFileContext(ctx context.Context, rootFile string, progPath, staticFilesDir, commandline string, naming DiskNamingConvention) error {
 
 	log.WithFields(log.Fields{"imgFile": rootFile}).Debug("attaching sparse file")
 	rootLo := NewLoDevice(rootFile)
@@ -72,11 +155,18 @@ func CreateBootImageOnFile(rootFile string, progPath, staticFilesDir, commandlin
 	}
 	defer rootLo.Release()
 
-	log.Debug("device mapping to 'hda'")
+	partitioned, err := hasPartitionTable(rootLodName)
+	if err != nil {
+		return err
+	}
+	if partitioned {
+		return ErrAlreadyFormatted
+	}
 
-	// use device mapper to rename the lo device to something that grub likes more.
-	// like hda!
-	grubDiskName := "hda"
+	// use device mapper to rename the lo device to something that grub likes more,
+	// the name the disk has on the hypervisor. like hda!
+	grubDiskName := naming.DiskName()
+	log.Debugf("device mapping to '%s'", grubDiskName)
 
 	devTmp, err := ioutil.TempDir("/dev", "unik-tmp")
 	if err != nil {
@@ -91,7 +181,7 @@ func CreateBootImageOnFile(rootFile string, progPath, staticFilesDir, commandlin
 
 	log.Debug("partitioning")
 
-	p := &MsDosPartioner{rootLodName.Name()}
+	p := &MsDosPartioner{Device: rootLodName.Name()}
 	if err := p.MakeTable(); err != nil {
 		return err
 	}
@@ -102,7 +192,7 @@ func CreateBootImageOnFile(rootFile string, progPath, staticFilesDir, commandlin
 	if err := p.Makebootable(1); err != nil {
 		return err
 	}
-	parts, err := ListParts(rootLodName)
+	parts, err := listCreatedParts(rootLodName)
 	if err != nil {
 		return err
 	}
@@ -120,29 +210,30 @@ func CreateBootImageOnFile(rootFile string, progPath, staticFilesDir, commandlin
 	}
 	defer part.Release()
 
-	firstPart := rootDeviceName + "1"
+	firstPart := path.Join(devTmp, naming.PartName(1))
 	if err := os.Link(bootDevice.Name(), firstPart); err != nil {
 		return err
 	}
 
 	bootLabel := "boot"
 	// format the device and mount and copy
-	err = RunLogCommand("mkfs", "-L", bootLabel, "-I", "128", "-t", "ext2", bootDevice.Name())
+	err = RunLogCommandContext(ctx, "mkfs", "-L", bootLabel, "-I", "128", "-t", "ext2", bootDevice.Name())
 	if err != nil {
 		return err
 	}
 
-	mntPoint, err := MountDevice(firstPart)
+	mntPoint, err := MountDeviceContext(ctx, firstPart)
 	if err != nil {
 		return err
 	}
-	defer Umount(mntPoint)
+	// unmount even when ctx is done, within the default timeout of umount
+	defer UmountContext(context.Background(), mntPoint)
 
 	if err := PrepareGrub(mntPoint, rootDeviceName, progPath, staticFilesDir, commandline); err != nil {
 		return err
 	}
 
-	err = RunLogCommand("grub-install", "--no-floppy", "--root-directory="+mntPoint, rootDeviceName)
+	err = RunLogCommandContext(ctx, "grub-install", "--no-floppy", "--root-directory="+mntPoint, rootDeviceName)
 	if err != nil {
 		return err
 	}
@@ -163,7 +254,7 @@ func PrepareGrub(folder, rootDeviceName, kernel, staticFilesDir, commandline str
 
 	// copy program.bin.. skip that for now
 	log.WithFields(log.Fields{"src": kernel, "dst": kernelDst}).Debug("copying file")
-	if err := CopyFile(kernel, kernelDst); err != nil {
+	if err := copyKernel(kernel, kernelDst); err != nil {
 		return err
 	}
 
@@ -181,6 +272,16 @@ func PrepareGrub(folder, rootDeviceName, kernel, staticFilesDir, commandline str
 	return nil
 }
 
+// copyKernel copies the unikernel binary into the boot partition, logging its
+// progress since kernels can be hundreds of MB
+func copyKernel(kernel, kernelDst string) error {
+	info, err := os.Stat(kernel)
+	if err != nil {
+		return err
+	}
+	return CopyFileWithProgress(kernel, kernelDst, NewCopyProgressLogger(kernel, info.Size()))
+}
+
 func CreateBootImageOnFilePvGrub(rootFile string, progPath, staticFilesDir, commandline string) error {
 	log.WithFields(log.Fields{"imgFile": rootFile}).Debug("attaching sparse file")
 	rootLo := NewLoDevice(rootFile)
@@ -223,7 +324,7 @@ func PreparePVGrub(folder, rootDeviceName, kernel, staticFilesDir, commandline s
 
 	// copy program.bin.. skip that for now
 	log.WithFields(log.Fields{"src": kernel, "dst": kernelDst}).Debug("copying file")
-	if err := CopyFile(kernel, kernelDst); err != nil {
+	if err := copyKernel(kernel, kernelDst); err != nil {
 		return err
 	}
 
@@ -281,24 +382,161 @@ func writeBootTemplate(fname, rootDrive, commandline string) error {
 
 }
 
-func formatDeviceAndCopyContents(folder string, volType string, dev BlockDevice) error {
-	var err error
-	switch volType {
-	case "fat":
-		err = RunLogCommand("mkfs.fat", dev.Name())
-	case "ext2":
-		fallthrough
+// FilesystemType is the filesystem volumes are formatted with
+type FilesystemType string
+
+const (
+	FilesystemExt2 FilesystemType = "ext2"
+	FilesystemFat  FilesystemType = "fat"
+)
+
+func formatDevice(fstype FilesystemType, dev BlockDevice) error {
+	return formatDeviceContext(context.Background(), fstype, dev, "", "")
+}
+
+// formatDeviceContext makes a filesystem of fstype on dev, with label and uuid
+// if they are set
+func formatDeviceContext(ctx context.Context, fstype FilesystemType, dev BlockDevice, label, fsUUID string) error {
+	switch fstype {
+	case FilesystemFat:
+		if fsUUID != "" {
+			return errors.New("a uuid can only be set on ext2 volumes", nil)
+		}
+		args := []string{dev.Name()}
+		if label != "" {
+			args = append([]string{"-n", label}, args...)
+		}
+		return RunLogCommandContext(ctx, "mkfs.fat", args...)
+	case FilesystemExt2, "":
+		args := []string{"-I", "128", "-t", "ext2"}
+		if label != "" {
+			args = append(args, "-L", label)
+		}
+		if fsUUID != "" {
+			if uuid.Parse(fsUUID) == nil {
+				return errors.New(fsUUID+" is not a uuid", nil)
+			}
+			args = append(args, "-U", fsUUID)
+		}
+		if err := RunLogCommandContext(ctx, "mkfs", append(args, dev.Name())...); err != nil {
+			return err
+		}
+		if fsUUID != "" {
+			return checkFilesystemUUID(ctx, dev, fsUUID)
+		}
+		return nil
+	}
+	return errors.New("Unknown fs type", nil)
+}
+
+// checkFilesystemUUID fails unless blkid reads fsUUID as the uuid of the filesystem on dev
+func checkFilesystemUUID(ctx context.Context, dev BlockDevice, fsUUID string) error {
+	out, err := exec.CommandContext(ctx, "blkid", "-p", "-s", "UUID", "-o", "value", dev.Name()).Output()
+	if err != nil {
+		return errors.New("reading uuid of "+dev.Name()+" with blkid", err)
+	}
+	if got := strings.TrimSpace(string(out)); !strings.EqualFold(got, fsUUID) {
+		return errors.New("filesystem on "+dev.Name()+" has uuid "+got+", not "+fsUUID, nil)
+	}
+	return nil
+}
+
+// FilesystemIds are the label and uuid of a filesystem, which identify it in
+// fstab and to mount by label or uuid
+type FilesystemIds struct {
+	Label string `json:"Label,omitempty"`
+	UUID  string `json:"UUID,omitempty"`
+}
+
+// readDeviceFilesystemIds reads the type, label and uuid of the filesystem on
+// dev with blkid. the type is empty if dev holds no filesystem blkid knows,
+// e.g. a partition table
+func readDeviceFilesystemIds(ctx context.Context, dev BlockDevice) (FilesystemType, FilesystemIds, error) {
+	out, err := exec.CommandContext(ctx, "blkid", "-p", "-o", "export", dev.Name()).Output()
+	if err != nil {
+		// blkid exits with 2 when it finds nothing on dev
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 2 {
+			return "", FilesystemIds{}, nil
+		}
+		return "", FilesystemIds{}, errors.New("reading filesystem of "+dev.Name()+" with blkid", err)
+	}
+	var fstype FilesystemType
+	var ids FilesystemIds
+	for _, line := range strings.Split(string(out), "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "TYPE":
+			fstype = FilesystemType(kv[1])
+		case "LABEL":
+			ids.Label = kv[1]
+		case "UUID":
+			ids.UUID = kv[1]
+		}
+	}
+	return fstype, ids, nil
+}
+
+// setDeviceFilesystemIds gives the filesystem on dev the label and uuid of ids,
+// unless it has them already. empty ids are left as they are. ext filesystems
+// get both with tune2fs; fat filesystems only take a label
+func setDeviceFilesystemIds(ctx context.Context, dev BlockDevice, ids FilesystemIds) error {
+	fstype, current, err := readDeviceFilesystemIds(ctx, dev)
+	if err != nil {
+		return err
+	}
+	if ids.Label == current.Label {
+		ids.Label = ""
+	}
+	if strings.EqualFold(ids.UUID, current.UUID) {
+		ids.UUID = ""
+	}
+	if ids.Label == "" && ids.UUID == "" {
+		return nil
+	}
+	log.WithFields(log.Fields{"device": dev.Name(), "type": fstype, "from": current, "to": ids}).Debug("setting filesystem label and uuid")
+	switch fstype {
+	case "ext2", "ext3", "ext4":
+		var args []string
+		if ids.Label != "" {
+			args = append(args, "-L", ids.Label)
+		}
+		if ids.UUID != "" {
+			if uuid.Parse(ids.UUID) == nil {
+				return errors.New(ids.UUID+" is not a uuid", nil)
+			}
+			args = append(args, "-U", ids.UUID)
+		}
+		if err := RunLogCommandContext(ctx, "tune2fs", append(args, dev.Name())...); err != nil {
+			return err
+		}
+	case "vfat":
+		if ids.UUID != "" {
+			return errors.New("a uuid can only be set on ext2 volumes", nil)
+		}
+		if err := RunLogCommandContext(ctx, "fatlabel", dev.Name(), ids.Label); err != nil {
+			return err
+		}
 	case "":
-		err = RunLogCommand("mkfs", "-I", "128", "-t", "ext2", dev.Name())
+		return errors.New("no filesystem found on "+dev.Name(), nil)
 	default:
-		return errors.New("Unknown fs type", nil)
+		return errors.New("cannot set the label or uuid of "+string(fstype)+" filesystem on "+dev.Name(), nil)
+	}
+	if ids.UUID != "" {
+		return checkFilesystemUUID(ctx, dev, ids.UUID)
 	}
+	return nil
+}
 
-	if err != nil {
+func formatDeviceAndCopyContents(ctx context.Context, volume RawVolume, volType string, dev BlockDevice) error {
+	folder := volume.Path
+	if err := formatDeviceContext(ctx, FilesystemType(volType), dev, volume.Label, volume.UUID); err != nil {
 		return err
 	}
 
-	mntPoint, err := Mount(dev)
+	mntPoint, err := MountContext(ctx, dev)
 	if err != nil {
 		return err
 	}
@@ -311,13 +549,19 @@ func formatDeviceAndCopyContents(folder string, volType string, dev BlockDevice)
 }
 
 func CreateSingleVolume(rootFile string, volType string, folder RawVolume) error {
+	return CreateSingleVolumeContext(context.Background(), rootFile, volType, folder)
+}
+
+// CreateSingleVolumeContext is CreateSingleVolume, giving up on sizing the
+// folder, and killing mkfs, once ctx is done
+func CreateSingleVolumeContext(ctx context.Context, rootFile string, volType string, folder RawVolume) error {
 	ext2Overhead := MegaBytes(2).ToBytes()
 
 	size := folder.Size
 
 	if size == 0 {
 		var err error
-		size, err = GetDirSize(folder.Path)
+		size, err = GetDirSizeContext(ctx, folder.Path)
 		if err != nil {
 			return err
 		}
@@ -333,14 +577,38 @@ func CreateSingleVolume(rootFile string, volType string, folder RawVolume) error
 		return err
 	}
 
-	if err := createSparseFile(rootFile, sizeVolume); err != nil {
+	if err := createSparseFile(rootFile, sizeVolume, SectorSize512); err != nil {
+		return err
+	}
+
+	return copyToImgFile(ctx, folder, volType, rootFile)
+}
+
+// CreateEmptyVolume creates imgFile as a sparse file of size bytes formatted
+// with fstype, without a partition table. size must be a positive multiple of SectorSize.
+func CreateEmptyVolume(imgFile string, size DiskSize, fstype FilesystemType) error {
+	if size.ToBytes() <= 0 || size.ToBytes()%SectorSize != 0 {
+		return fmt.Errorf("%v: volume size must be a positive multiple of %d bytes", size.ToBytes(), SectorSize)
+	}
+	if err := createSparseFile(imgFile, size, SectorSize512); err != nil {
+		return err
+	}
+	imgLo := NewLoDevice(imgFile)
+	imgLodName, err := imgLo.Acquire()
+	if err != nil {
 		return err
 	}
+	defer imgLo.Release()
 
-	return CopyToImgFile(folder.Path, volType, rootFile)
+	log.WithFields(log.Fields{"imgFile": imgFile, "size": size.String(), "type": fstype}).Debug("formatting empty volume")
+	return formatDevice(fstype, imgLodName)
 }
 
 func CopyToImgFile(folder, volType string, imgfile string) error {
+	return copyToImgFile(context.Background(), RawVolume{Path: folder}, volType, imgfile)
+}
+
+func copyToImgFile(ctx context.Context, volume RawVolume, volType string, imgfile string) error {
 	imgLo := NewLoDevice(imgfile)
 	imgLodName, err := imgLo.Acquire()
 	if err != nil {
@@ -348,23 +616,62 @@ func CopyToImgFile(folder, volType string, imgfile string) error {
 	}
 	defer imgLo.Release()
 
-	return formatDeviceAndCopyContents(folder, volType, imgLodName)
+	return formatDeviceAndCopyContents(ctx, volume, volType, imgLodName)
 
 }
 
-func copyToPart(folder string, volType string, part Resource) error {
+func copyToPart(ctx context.Context, volume RawVolume, volType string, part Resource) error {
 	imgLodName, err := part.Acquire()
 	if err != nil {
 		return err
 	}
 	defer part.Release()
-	return formatDeviceAndCopyContents(folder, volType, imgLodName)
+	return formatDeviceAndCopyContents(ctx, volume, volType, imgLodName)
+}
+
+// writeImageToPart copies the formatted filesystem image of volume into part
+// byte for byte. the copy keeps the label and uuid blkid reads from the image,
+// unless the volume sets others; it fails if they don't come through
+func writeImageToPart(ctx context.Context, volume RawVolume, part Resource) error {
+	_, ids, err := readDeviceFilesystemIds(ctx, BlockDevice(volume.Path))
+	if err != nil {
+		return err
+	}
+	if volume.Label != "" {
+		ids.Label = volume.Label
+	}
+	if volume.UUID != "" {
+		ids.UUID = volume.UUID
+	}
+	dev, err := part.Acquire()
+	if err != nil {
+		return err
+	}
+	defer part.Release()
+	log.WithFields(log.Fields{"image": volume.Path, "device": dev.Name(), "ids": ids}).Debug("writing read-only volume image")
+	if err := RunLogCommandContext(ctx, "dd", "if="+volume.Path, "of="+dev.Name(), "bs=1M", "conv=notrunc"); err != nil {
+		return err
+	}
+	return setDeviceFilesystemIds(ctx, dev, ids)
+}
+
+// CreateVolumes writes volumes to partitions of imgFile, for a disk with sectors of sectorSize bytes.
+// the partition table is written through a loop device with sectors of sectorSize, so
+// imgFile must be attached with that sector size (losetup --sector-size) to be read back.
+// newPartitioner should align partitions for sectorSize (see MsDosPartioner.SectorSize).
+func CreateVolumes(imgFile string, volType string, volumes []RawVolume, newPartitioner func(device string) Partitioner, sectorSize SectorSizeBytes) error {
+	return CreateVolumesContext(context.Background(), imgFile, volType, volumes, newPartitioner, sectorSize)
 }
 
-func CreateVolumes(imgFile string, volType string, volumes []RawVolume, newPartitioner func(device string) Partitioner) error {
+// CreateVolumesContext is CreateVolumes, giving up on sizing the volume
+// folders, and killing mkfs and dd, once ctx is done
+func CreateVolumesContext(ctx context.Context, imgFile string, volType string, volumes []RawVolume, newPartitioner func(device string) Partitioner, sectorSize SectorSizeBytes) error {
 	if len(volumes) == 0 {
 		return nil
 	}
+	if err := ValidateSectorSize(sectorSize); err != nil {
+		return err
+	}
 
 	var sizes []Bytes
 
@@ -375,8 +682,14 @@ func CreateVolumes(imgFile string, volType string, volumes []RawVolume, newParti
 	log.Debug("Calculating sizes")
 
 	for _, v := range volumes {
-		if v.Size == 0 {
-			cursize, err := GetDirSize(v.Path)
+		if v.ReadOnly && v.Size == 0 {
+			info, err := os.Stat(v.Path)
+			if err != nil {
+				return err
+			}
+			sizes = append(sizes, Bytes(info.Size()))
+		} else if v.Size == 0 {
+			cursize, err := GetDirSizeContext(ctx, v.Path)
 			if err != nil {
 				return err
 			}
@@ -386,16 +699,20 @@ func CreateVolumes(imgFile string, volType string, volumes []RawVolume, newParti
 		}
 		totalSize += sizes[len(sizes)-1]
 	}
-	sizeDrive := Bytes((SectorSize + totalSize + totalSize/10) &^ (SectorSize - 1))
+	sizeDrive := Bytes((Bytes(sectorSize) + totalSize + totalSize/10) &^ (Bytes(sectorSize) - 1))
 	sizeDrive += MegaBytes(4).ToBytes()
+	// MakePart moves each partition up by as much as an alignment boundary
+	sizeDrive += MegaBytes(DefaultPartitionAlignMB * len(sizes)).ToBytes()
 
-	log.WithFields(log.Fields{"imgFile": imgFile, "size": totalSize.ToPartedFormat()}).Debug("Creating image file")
-	err := createSparseFile(imgFile, sizeDrive)
+	log.WithFields(log.Fields{"imgFile": imgFile, "size": totalSize.String(), "sectorSize": sectorSize}).Debug("Creating image file")
+	err := createSparseFile(imgFile, sizeDrive, sectorSize)
 	if err != nil {
 		return err
 	}
 
-	imgLo := NewLoDevice(imgFile)
+	// the partition table addresses sectors of the logical sector size of the
+	// device parted writes it on
+	imgLo := NewSectorSizeLoDevice(imgFile, sectorSize)
 	imgLodName, err := imgLo.Acquire()
 	if err != nil {
 		return err
@@ -414,14 +731,17 @@ func CreateVolumes(imgFile string, volType string, volumes []RawVolume, newParti
 		if err != nil {
 			return err
 		}
-		curParts, err := ListParts(imgLodName)
+		curParts, err := listCreatedParts(imgLodName)
 		if err != nil {
 			return err
 		}
 		start = curParts[len(curParts)-1].Offset().ToBytes() + curParts[len(curParts)-1].Size().ToBytes()
 	}
 
-	parts, err := ListParts(imgLodName)
+	parts, err := listCreatedParts(imgLodName)
+	if err != nil {
+		return err
+	}
 
 	if len(parts) != len(volumes) {
 		return errors.New("Not enough parts created!", nil)
@@ -429,8 +749,13 @@ func CreateVolumes(imgFile string, volType string, volumes []RawVolume, newParti
 
 	log.WithFields(log.Fields{"parts": parts, "volsize": sizes}).Debug("Creating volumes")
 	for i, v := range volumes {
-
-		if err := copyToPart(v.Path, volType, parts[i]); err != nil {
+		if v.ReadOnly {
+			if err := writeImageToPart(ctx, v, parts[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := copyToPart(ctx, v, volType, parts[i]); err != nil {
 			return err
 		}
 	}
@@ -438,6 +763,15 @@ func CreateVolumes(imgFile string, volType string, volumes []RawVolume, newParti
 	return nil
 }
 
+// listCreatedParts lists the partitions of a disk that was just partitioned
+func listCreatedParts(device BlockDevice) ([]Part, error) {
+	parts, err := ListParts(device)
+	if err == ErrNoPartitionTable {
+		return nil, errors.New("disk has no partition table, was MakeTable called?", err)
+	}
+	return parts, err
+}
+
 func toPartedVolType(volType string) string {
 	switch volType {
 	case "fat":
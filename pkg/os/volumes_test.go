@@ -0,0 +1,138 @@
+package os
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CreateVolumes", func() {
+	var dataDir, imgFile string
+	BeforeEach(func() {
+		skipWithoutLoopDevices()
+		if _, err := exec.LookPath("parted"); err != nil {
+			Skip("parted is not installed")
+		}
+		var err error
+		dataDir, err = ioutil.TempDir("", "volumes.data.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dataDir, "data.txt"), []byte("test_data"), 0644)).To(Succeed())
+		imgFile = filepath.Join(dataDir, "..", filepath.Base(dataDir)+".img")
+	})
+	AfterEach(func() {
+		os.RemoveAll(dataDir)
+		os.Remove(imgFile)
+	})
+
+	It("should partition and format a disk of 4096 byte sectors", func() {
+		newPartitioner, err := GetPartitioner(PartitionTableMsDos, SectorSize4K)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(CreateVolumes(imgFile, "ext2", []RawVolume{{Path: dataDir}}, newPartitioner, SectorSize4K)).To(Succeed())
+
+		img, err := ioutil.ReadFile(imgFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(img) % int(SectorSize4K)).To(Equal(0))
+		// the start of the first partition of the mbr, at 446, is a sector
+		// number at 8 in its entry
+		start := int64(binary.LittleEndian.Uint32(img[446+8:])) * int64(SectorSize4K)
+		Expect(start % int64(MegaBytes(1).ToBytes())).To(Equal(int64(0)))
+		// the ext2 superblock is 1024 bytes into the partition, its magic at 56
+		Expect(binary.LittleEndian.Uint16(img[start+1024+56:])).To(Equal(uint16(0xef53)))
+
+		disk := NewSectorSizeLoDevice(imgFile, SectorSize4K)
+		dev, err := disk.Acquire()
+		Expect(err).NotTo(HaveOccurred())
+		defer disk.Release()
+		parts, err := ListParts(dev)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parts).To(HaveLen(1))
+		Expect(parts[0].Offset().ToBytes()).To(Equal(Bytes(start)))
+		partDev, err := parts[0].Acquire()
+		Expect(err).NotTo(HaveOccurred())
+		defer parts[0].Release()
+		mntpoint, err := Mount(partDev)
+		Expect(err).NotTo(HaveOccurred())
+		defer Umount(mntpoint)
+		Expect(ioutil.ReadFile(filepath.Join(mntpoint, "data.txt"))).To(Equal([]byte("test_data")))
+	})
+
+	It("should keep the label and uuid of a read-only volume image it copies", func() {
+		source := imgFile + ".source"
+		defer os.Remove(source)
+		Expect(exec.Command("truncate", "-s", "8M", source).Run()).To(Succeed())
+		Expect(exec.Command("mkfs.ext2", "-q", "-F", "-L", "data", "-U", testUUID, source).Run()).To(Succeed())
+
+		newPartitioner, err := GetPartitioner(PartitionTableMsDos, SectorSize)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(CreateVolumes(imgFile, "ext2", []RawVolume{{Path: source, ReadOnly: true}}, newPartitioner, SectorSize)).To(Succeed())
+		Expect(ReadFilesystemIds(imgFile)).To(Equal(FilesystemIds{Label: "data", UUID: testUUID}))
+	})
+})
+
+const testUUID = "6f1bc6e4-1d4b-4a5c-9c6e-7a6f2b0d3e11"
+
+var _ = Describe("FilesystemIds", func() {
+	var imgFile string
+	BeforeEach(func() {
+		for _, tool := range []string{"blkid", "mkfs.ext2", "tune2fs"} {
+			if _, err := exec.LookPath(tool); err != nil {
+				Skip(tool + " is not installed")
+			}
+		}
+		f, err := ioutil.TempFile("", "volumes.ids.")
+		Expect(err).NotTo(HaveOccurred())
+		imgFile = f.Name()
+		Expect(f.Truncate(int64(MegaBytes(8).ToBytes()))).To(Succeed())
+		Expect(f.Close()).To(Succeed())
+		Expect(exec.Command("mkfs.ext2", "-q", "-F", "-L", "data", "-U", testUUID, imgFile).Run()).To(Succeed())
+	})
+	AfterEach(func() {
+		os.Remove(imgFile)
+	})
+
+	It("should read the label and uuid of an image with blkid", func() {
+		Expect(ReadFilesystemIds(imgFile)).To(Equal(FilesystemIds{Label: "data", UUID: testUUID}))
+	})
+
+	It("should give a clone with a new filesystem the ids of its source", func() {
+		source, err := ReadFilesystemIds(imgFile)
+		Expect(err).NotTo(HaveOccurred())
+		clone := imgFile + ".clone"
+		defer os.Remove(clone)
+		Expect(CopyFile(imgFile, clone)).To(Succeed())
+		// a provider making the clone from the data of the source, with a filesystem of its own
+		Expect(exec.Command("mkfs.ext2", "-q", "-F", clone).Run()).To(Succeed())
+		Expect(ReadFilesystemIds(clone)).NotTo(Equal(source))
+
+		Expect(SetFilesystemIds(clone, source)).To(Succeed())
+		Expect(ReadFilesystemIds(clone)).To(Equal(source))
+	})
+
+	It("should leave ids that are not set as they are", func() {
+		Expect(SetFilesystemIds(imgFile, FilesystemIds{Label: "renamed"})).To(Succeed())
+		Expect(ReadFilesystemIds(imgFile)).To(Equal(FilesystemIds{Label: "renamed", UUID: testUUID}))
+	})
+
+	It("should keep the ids of an image it writes to a partition, unless the volume sets others", func() {
+		skipWithoutLoopDevices()
+		part := imgFile + ".part"
+		defer os.Remove(part)
+		Expect(exec.Command("truncate", "-s", "8M", part).Run()).To(Succeed())
+
+		Expect(writeImageToPart(context.Background(), RawVolume{Path: imgFile, ReadOnly: true}, NewLoDevice(part))).To(Succeed())
+		Expect(ReadFilesystemIds(part)).To(Equal(FilesystemIds{Label: "data", UUID: testUUID}))
+
+		Expect(writeImageToPart(context.Background(), RawVolume{Path: imgFile, ReadOnly: true, Label: "copy"}, NewLoDevice(part))).To(Succeed())
+		Expect(ReadFilesystemIds(part)).To(Equal(FilesystemIds{Label: "copy", UUID: testUUID}))
+	})
+
+	It("should refuse an invalid uuid", func() {
+		Expect(SetFilesystemIds(imgFile, FilesystemIds{UUID: "not-a-uuid"})).NotTo(Succeed())
+	})
+})
@@ -41,7 +41,7 @@ func (p *AwsProvider) AttachVolume(id, instanceId, mntPoint string) error {
 	if _, err := p.newEC2().AttachVolume(param); err != nil {
 		return errors.New("failed to attach volume "+volume.Id, err)
 	}
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volume, ok := volumes[volume.Id]
 		if !ok {
 			return errors.New("no record of "+volume.Id+" in the state", nil)
@@ -27,7 +27,11 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-func createDataVolumeFromRawImage(s3svc *s3.S3, ec2svc *ec2.EC2, imgFile string, imageSize int64, imageFormat types.ImageFormat, az string) (string, error) {
+// createDataVolumeFromRawImage uploads imgFile to s3 and imports it as an ebs volume.
+// the upload goes to a temporary bucket, unless archive is set, in which case the
+// image is uploaded to (and kept at) that location.
+func createDataVolumeFromRawImage(uploader *multipartUploader, ec2svc *ec2.EC2, imgFile string, imageSize int64, imageFormat types.ImageFormat, az string, archive *s3Location) (string, error) {
+	s3svc := uploader.s3svc
 	fileInfo, err := os.Stat(imgFile)
 	if err != nil {
 		return "", err
@@ -35,17 +39,26 @@ func createDataVolumeFromRawImage(s3svc *s3.S3, ec2svc *ec2.EC2, imgFile string,
 
 	// upload the image file to aws
 	bucket := fmt.Sprintf("unik-tmp-%d", rand.Int63())
-
-	if err := createBucket(s3svc, bucket); err != nil {
-		return "", err
-	}
-	defer deleteBucket(s3svc, bucket)
-
 	pathInBucket := "disk.img"
+	manifestName := "upload-manifest.xml"
+	if archive != nil {
+		bucket = archive.Bucket
+		pathInBucket = archive.Key
+		manifestName = archive.Key + ".manifest.xml"
+		defer s3svc.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(manifestName),
+		})
+	} else {
+		if err := createBucket(s3svc, bucket); err != nil {
+			return "", err
+		}
+		defer deleteBucket(s3svc, bucket)
+	}
 
-	log.Debug("Uploading image to aws")
+	log.WithFields(log.Fields{"bucket": bucket, "key": pathInBucket}).Debug("Uploading image to aws")
 
-	if err := uploadFileToAws(s3svc, imgFile, fileInfo.Size(), bucket, pathInBucket); err != nil {
+	if err := uploader.uploadFile(imgFile, bucket, pathInBucket); err != nil {
 		return "", err
 	}
 
@@ -86,7 +99,6 @@ func createDataVolumeFromRawImage(s3svc *s3.S3, ec2svc *ec2.EC2, imgFile string,
 	log.Debug("Creating manifest")
 
 	// create manifest
-	manifestName := "upload-manifest.xml"
 
 	deleteManiReq, _ := s3svc.DeleteObjectRequest(&s3.DeleteObjectInput{
 		Bucket: aws.String(bucket),
@@ -202,15 +214,6 @@ func createDataVolumeFromRawImage(s3svc *s3.S3, ec2svc *ec2.EC2, imgFile string,
 
 }
 
-func uploadFileToAws(s3svc *s3.S3, file string, fileSize int64, bucket, path string) error {
-	reader, err := os.Open(file)
-	if err != nil {
-		return nil
-	}
-	defer reader.Close()
-	return uploadToAws(s3svc, reader, fileSize, bucket, path)
-}
-
 func uploadToAws(s3svc *s3.S3, body io.ReadSeeker, size int64, bucket, path string) error {
 
 	// upload
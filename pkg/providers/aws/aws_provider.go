@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/state"
 )
 
@@ -19,19 +20,19 @@ func AwsStateFile() string {
 
 type AwsProvider struct {
 	config config.Aws
-	state  state.State
+	common.ProviderState
 }
 
 func NewAwsProvier(config config.Aws) *AwsProvider {
 	logrus.Infof("state file: %s", AwsStateFile())
 	return &AwsProvider{
-		config: config,
-		state:  state.NewBasicState(AwsStateFile()),
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(AwsStateFile())},
 	}
 }
 
 func (p *AwsProvider) WithState(state state.State) *AwsProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 
@@ -48,9 +49,17 @@ func (p *AwsProvider) newEC2() *ec2.EC2 {
 }
 
 func (p *AwsProvider) newS3() *s3.S3 {
-	sess := session.New(&aws.Config{
-		Region: aws.String(p.config.Region),
-	})
+	return newS3Client(p.config.Region)
+}
+
+// newS3Client returns a client for region, or for the region of the environment
+// (AWS_REGION) if empty
+func newS3Client(region string) *s3.S3 {
+	awsConfig := &aws.Config{}
+	if region != "" {
+		awsConfig.Region = aws.String(region)
+	}
+	sess := session.New(awsConfig)
 	sess.Handlers.Send.PushFront(func(r *request.Request) {
 		if r != nil {
 			logrus.WithFields(logrus.Fields{"params": r.Params}).Debugf("request sent to s3")
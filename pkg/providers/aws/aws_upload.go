@@ -0,0 +1,205 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+const (
+	// s3 rejects parts smaller than 5 MB, except for the last part of an upload
+	minUploadPartSize        = 5 << 20
+	defaultUploadPartSize    = 16 << 20
+	defaultUploadConcurrency = 4
+)
+
+// multipartUploader uploads files to s3 in parts, several parts at a time.
+// a failed part only requires sending that part again, and no single request
+// has to carry the whole image, so large images don't time out.
+type multipartUploader struct {
+	s3svc       *s3.S3
+	partSize    int64
+	concurrency int
+}
+
+func (p *AwsProvider) newUploader(s3svc *s3.S3) *multipartUploader {
+	partSize := int64(p.config.UploadPartSizeMb) << 20
+	if partSize == 0 {
+		partSize = defaultUploadPartSize
+	}
+	if partSize < minUploadPartSize {
+		log.Warnf("upload_part_size_mb %d is below the s3 minimum, using 5 MB parts", p.config.UploadPartSizeMb)
+		partSize = minUploadPartSize
+	}
+	concurrency := p.config.UploadConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultUploadConcurrency
+	}
+	return &multipartUploader{s3svc: s3svc, partSize: partSize, concurrency: concurrency}
+}
+
+func (u *multipartUploader) uploadFile(file, bucket, key string) (err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.New("opening "+file, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.New("statting "+file, err)
+	}
+	size := info.Size()
+	if size <= u.partSize {
+		return uploadToAws(u.s3svc, f, size, bucket, key)
+	}
+
+	created, err := u.s3svc.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ACL:         aws.String("private"),
+		ContentType: aws.String("application/octet-stream"),
+	})
+	if err != nil {
+		return errors.New("starting multipart upload", err)
+	}
+	uploadId := created.UploadId
+	defer func() {
+		if err != nil {
+			log.WithField("key", key).Warnf("aborting multipart upload")
+			u.s3svc.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(bucket),
+				Key:      aws.String(key),
+				UploadId: uploadId,
+			})
+		}
+	}()
+
+	numParts := (size + u.partSize - 1) / u.partSize
+	log.WithFields(log.Fields{"key": key, "size": size, "parts": numParts, "concurrency": u.concurrency}).Debugf("uploading file in parts")
+
+	partNumbers := make(chan int64)
+	var lock sync.Mutex
+	var completed []*s3.CompletedPart
+	var errs []string
+	var wg sync.WaitGroup
+	for i := 0; i < u.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partNumber := range partNumbers {
+				offset := (partNumber - 1) * u.partSize
+				length := u.partSize
+				if offset+length > size {
+					length = size - offset
+				}
+				part, err := u.s3svc.UploadPart(&s3.UploadPartInput{
+					Bucket:        aws.String(bucket),
+					Key:           aws.String(key),
+					UploadId:      uploadId,
+					PartNumber:    aws.Int64(partNumber),
+					Body:          io.NewSectionReader(f, offset, length),
+					ContentLength: aws.Int64(length),
+				})
+				lock.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("part %d: %v", partNumber, err))
+				} else {
+					completed = append(completed, &s3.CompletedPart{ETag: part.ETag, PartNumber: aws.Int64(partNumber)})
+					log.Debugf("uploaded part %d/%d of %s", len(completed), numParts, key)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for partNumber := int64(1); partNumber <= numParts; partNumber++ {
+		partNumbers <- partNumber
+	}
+	close(partNumbers)
+	wg.Wait()
+	if len(errs) > 0 {
+		return errors.New("uploading parts failed: "+strings.Join(errs, "; "), nil)
+	}
+
+	sort.Sort(completedParts(completed))
+	if _, err := u.s3svc.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        uploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	}); err != nil {
+		return errors.New("completing multipart upload", err)
+	}
+	return nil
+}
+
+type completedParts []*s3.CompletedPart
+
+func (p completedParts) Len() int           { return len(p) }
+func (p completedParts) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p completedParts) Less(i, j int) bool { return *p[i].PartNumber < *p[j].PartNumber }
+
+// s3Location is an object in s3, e.g. where a volume's backing image is archived
+type s3Location struct {
+	Bucket string
+	Key    string
+}
+
+// volumeStoreLocation returns where the backing image of volumeName is kept
+// when the provider is configured with a volume_store of the form s3://bucket/prefix
+func (p *AwsProvider) volumeStoreLocation(volumeName string) (*s3Location, error) {
+	if p.config.VolumeStore == "" {
+		return nil, nil
+	}
+	bucket, prefix, err := ParseS3URL(p.config.VolumeStore)
+	if err != nil {
+		return nil, errors.New("invalid volume_store", err)
+	}
+	key := volumeName + ".img"
+	if prefix != "" {
+		key = prefix + "/" + key
+	}
+	return &s3Location{Bucket: bucket, Key: key}, nil
+}
+
+// ParseS3URL splits an s3://bucket/prefix url. the prefix has no leading or
+// trailing slash, and is empty for the root of the bucket
+func ParseS3URL(url string) (string, string, error) {
+	if !strings.HasPrefix(url, "s3://") {
+		return "", "", errors.New(url+" must be an s3://bucket/prefix url", nil)
+	}
+	path := strings.TrimPrefix(url, "s3://")
+	bucket, prefix := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		bucket, prefix = path[:i], strings.Trim(path[i+1:], "/")
+	}
+	if bucket == "" {
+		return "", "", errors.New(url+" does not name a bucket", nil)
+	}
+	return bucket, prefix, nil
+}
+
+// UploadFileToS3 uploads file to bucket/key in region, in parts of the default
+// size, for uploads that do not belong to an aws provider
+func UploadFileToS3(region, file, bucket, key string) error {
+	u := &multipartUploader{s3svc: newS3Client(region), partSize: defaultUploadPartSize, concurrency: defaultUploadConcurrency}
+	return u.uploadFile(file, bucket, key)
+}
+
+// DeleteFromS3 deletes bucket/key in region
+func DeleteFromS3(region, bucket, key string) error {
+	if _, err := newS3Client(region).DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return errors.New("deleting s3://"+bucket+"/"+key, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// CloneVolume snapshots the ebs volume and creates the new volume from the
+// snapshot, in the zone of the provider. the snapshot is deleted once the new
+// volume is available
+func (p *AwsProvider) CloneVolume(id, newName string) (*types.Volume, error) {
+	source, err := p.GetVolume(id)
+	if err != nil {
+		return nil, errors.New("retrieving volume "+id, err)
+	}
+	if _, err := p.GetVolume(newName); err == nil {
+		return nil, errors.New("volume "+newName+" already exists", nil)
+	}
+	ec2svc := p.newEC2()
+
+	logrus.WithField("volume-id", source.Id).Infof("creating snapshot of volume %s", source.Name)
+	snapshot, err := ec2svc.CreateSnapshot(&ec2.CreateSnapshotInput{
+		Description: aws.String("snapshot for clone " + newName + " of volume " + source.Name),
+		VolumeId:    aws.String(source.Id),
+	})
+	if err != nil {
+		return nil, errors.New("creating aws snapshot", err)
+	}
+	defer func() {
+		if _, err := ec2svc.DeleteSnapshot(&ec2.DeleteSnapshotInput{SnapshotId: snapshot.SnapshotId}); err != nil {
+			logrus.WithError(err).Warnf("failed to delete snapshot %s", *snapshot.SnapshotId)
+		}
+	}()
+	if err := ec2svc.WaitUntilSnapshotCompleted(&ec2.DescribeSnapshotsInput{
+		SnapshotIds: []*string{snapshot.SnapshotId},
+	}); err != nil {
+		return nil, errors.New("waiting for snapshot to complete", err)
+	}
+
+	logrus.WithField("snapshot-id", *snapshot.SnapshotId).Infof("creating volume %s from snapshot", newName)
+	created, err := ec2svc.CreateVolume(&ec2.CreateVolumeInput{
+		AvailabilityZone: aws.String(p.config.Zone),
+		SnapshotId:       snapshot.SnapshotId,
+	})
+	if err != nil {
+		return nil, errors.New("creating aws volume from snapshot", err)
+	}
+	if err := ec2svc.WaitUntilVolumeAvailable(&ec2.DescribeVolumesInput{
+		VolumeIds: []*string{created.VolumeId},
+	}); err != nil {
+		return nil, errors.New("waiting for volume to become available", err)
+	}
+	if _, err := ec2svc.CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{created.VolumeId},
+		Tags:      []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(newName)}},
+	}); err != nil {
+		return nil, errors.New("tagging volume", err)
+	}
+
+	volume := &types.Volume{
+		Id:             *created.VolumeId,
+		Name:           newName,
+		SizeMb:         source.SizeMb,
+		Attachment:     "",
+		Infrastructure: types.Infrastructure_AWS,
+		Created:        time.Now(),
+	}
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		volumes[volume.Id] = volume
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying volume map in state", err)
+	}
+	return volume, nil
+}
@@ -18,7 +18,14 @@ func (p *AwsProvider) CreateVolume(params types.CreateVolumeParams) (*types.Volu
 	if err != nil {
 		return nil, errors.New("stat image file", err)
 	}
-	volumeId, err := createDataVolumeFromRawImage(s3svc, ec2svc, params.ImagePath, imageFile.Size(), types.ImageFormat_RAW, p.config.Zone)
+	archive, err := p.volumeStoreLocation(params.Name)
+	if err != nil {
+		return nil, errors.New("invalid volume store", err)
+	}
+	if archive != nil {
+		logrus.Infof("keeping backing image of volume %s at s3://%s/%s", params.Name, archive.Bucket, archive.Key)
+	}
+	volumeId, err := createDataVolumeFromRawImage(p.newUploader(s3svc), ec2svc, params.ImagePath, imageFile.Size(), types.ImageFormat_RAW, p.config.Zone, archive)
 	if err != nil {
 		return nil, errors.New("creating aws boot volume", err)
 	}
@@ -52,14 +59,14 @@ func (p *AwsProvider) CreateVolume(params types.CreateVolumeParams) (*types.Volu
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volumes[volume.Id] = volume
 		return nil
 	}); err != nil {
 		return nil, errors.New("modifying volume map in state", err)
 	}
 
-	return nil, nil
+	return volume, nil
 }
 func (p *AwsProvider) CreateEmptyVolume(name string, size int) (*types.Volume, error) {
 	return nil, nil
@@ -53,7 +53,7 @@ func (p *AwsProvider) DeleteImage(id string, force bool) error {
 	if _, err := ec2svc.DeleteVolume(deleteVolumeParam); err != nil {
 		return errors.New("failed deleting volumme "+*snap.VolumeId, err)
 	}
-	return p.state.RemoveImage(image)
+	return p.State.RemoveImage(image)
 }
 
 func getSnapshotForImage(ec2svc *ec2.EC2, imageId string) (*ec2.Snapshot, error) {
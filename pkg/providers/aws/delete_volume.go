@@ -27,5 +27,5 @@ func (p *AwsProvider) DeleteVolume(id string, force bool) error {
 	if err != nil {
 		return errors.New("failed to terminate volume "+volume.Id, err)
 	}
-	return p.state.RemoveVolume(volume)
+	return p.State.RemoveVolume(volume)
 }
@@ -22,7 +22,7 @@ func (p *AwsProvider) DetachVolume(id string) error {
 	if _, err := p.newEC2().DetachVolume(param); err != nil {
 		return errors.New("failed to detach volume "+volume.Id, err)
 	}
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volume, ok := volumes[volume.Id]
 		if !ok {
 			return errors.New("no record of "+volume.Id+" in the state", nil)
@@ -7,5 +7,6 @@ import (
 func (p *AwsProvider) GetConfig() providers.ProviderConfig {
 	return providers.ProviderConfig{
 		UsePartitionTables: false,
+		SupportsStaticIP:   true,
 	}
 }
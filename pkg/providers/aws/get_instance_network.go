@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// GetInstanceNetwork lists the elastic network interfaces of an instance, named
+// eth0, eth1... by their device index. ec2 does not report the mtu
+func (p *AwsProvider) GetInstanceNetwork(id string) ([]types.NetworkInterface, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+id, err)
+	}
+	output, err := p.newEC2().DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: []*string{aws.String(instance.Id)},
+	})
+	if err != nil {
+		return nil, errors.New("running ec2 describe instances", err)
+	}
+	interfaces := []types.NetworkInterface{}
+	for _, reservation := range output.Reservations {
+		for _, ec2Instance := range reservation.Instances {
+			for _, networkInterface := range ec2Instance.NetworkInterfaces {
+				name := aws.StringValue(networkInterface.NetworkInterfaceId)
+				if networkInterface.Attachment != nil && networkInterface.Attachment.DeviceIndex != nil {
+					name = fmt.Sprintf("eth%d", *networkInterface.Attachment.DeviceIndex)
+				}
+				addresses := []string{}
+				for _, address := range networkInterface.PrivateIpAddresses {
+					if address.PrivateIpAddress != nil {
+						addresses = append(addresses, *address.PrivateIpAddress)
+					}
+					if address.Association != nil && address.Association.PublicIp != nil {
+						addresses = append(addresses, *address.Association.PublicIp)
+					}
+				}
+				interfaces = append(interfaces, types.NetworkInterface{
+					Name:        name,
+					MACAddress:  aws.StringValue(networkInterface.MacAddress),
+					IPAddresses: addresses,
+				})
+			}
+		}
+	}
+	return interfaces, nil
+}
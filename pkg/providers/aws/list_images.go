@@ -11,11 +11,11 @@ import (
 const UNIK_IMAGE_ID = "UNIK_IMAGE_ID"
 
 func (p *AwsProvider) ListImages() ([]*types.Image, error) {
-	if len(p.state.GetImages()) < 1 {
+	if len(p.State.GetImages()) < 1 {
 		return []*types.Image{}, nil
 	}
 	imageIds := []*string{}
-	for imageId := range p.state.GetImages() {
+	for imageId := range p.State.GetImages() {
 		imageIds = append(imageIds, aws.String(imageId))
 	}
 	param := &ec2.DescribeImagesInput{
@@ -28,7 +28,7 @@ func (p *AwsProvider) ListImages() ([]*types.Image, error) {
 	images := []*types.Image{}
 	for _, ec2Image := range output.Images {
 		imageId := *ec2Image.ImageId
-		image, ok := p.state.GetImages()[imageId]
+		image, ok := p.State.GetImages()[imageId]
 		if !ok {
 			logrus.WithFields(logrus.Fields{"ec2Image": ec2Image}).Errorf("found an image that unik has no record of")
 			continue
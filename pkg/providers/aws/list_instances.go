@@ -9,12 +9,12 @@ import (
 )
 
 func (p *AwsProvider) ListInstances() ([]*types.Instance, error) {
-	if len(p.state.GetInstances()) < 1 {
+	if len(p.State.GetInstances()) < 1 {
 		return []*types.Instance{}, nil
 	}
 
 	instanceIds := []*string{}
-	for instanceId := range p.state.GetInstances() {
+	for instanceId := range p.State.GetInstances() {
 		instanceIds = append(instanceIds, aws.String(instanceId))
 	}
 	param := &ec2.DescribeInstancesInput{
@@ -43,7 +43,7 @@ func (p *AwsProvider) ListInstances() ([]*types.Instance, error) {
 			}
 			if instanceState == types.InstanceState_Terminated {
 				logrus.Warnf("instance %s state is terminated, removing it from state", instanceId)
-				if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+				if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 					delete(instances, instanceId)
 					return nil
 				}); err != nil {
@@ -51,7 +51,7 @@ func (p *AwsProvider) ListInstances() ([]*types.Instance, error) {
 				}
 				continue
 			}
-			instance, ok := p.state.GetInstances()[instanceId]
+			instance, ok := p.State.GetInstances()[instanceId]
 			if !ok {
 				logrus.WithFields(logrus.Fields{"ec2Instance": ec2Instance}).Errorf("found an instance that unik has no record of")
 				continue
@@ -60,7 +60,7 @@ func (p *AwsProvider) ListInstances() ([]*types.Instance, error) {
 			if ec2Instance.PublicIpAddress != nil {
 				instance.IpAddress = *ec2Instance.PublicIpAddress
 			}
-			if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+			if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 				instances[instance.Id] = instance
 				return nil
 			}); err != nil {
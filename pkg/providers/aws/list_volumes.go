@@ -9,11 +9,11 @@ import (
 )
 
 func (p *AwsProvider) ListVolumes() ([]*types.Volume, error) {
-	if len(p.state.GetVolumes()) < 1 {
+	if len(p.State.GetVolumes()) < 1 {
 		return []*types.Volume{}, nil
 	}
 	volumeIds := []*string{}
-	for volumeId := range p.state.GetVolumes() {
+	for volumeId := range p.State.GetVolumes() {
 		volumeIds = append(volumeIds, aws.String(volumeId))
 	}
 	param := &ec2.DescribeVolumesInput{
@@ -29,7 +29,7 @@ func (p *AwsProvider) ListVolumes() ([]*types.Volume, error) {
 		if volumeId == "" {
 			continue
 		}
-		volume, ok := p.state.GetVolumes()[volumeId]
+		volume, ok := p.State.GetVolumes()[volumeId]
 		if !ok {
 			logrus.WithFields(logrus.Fields{"ec2Volume": ec2Volume}).Errorf("found a volume that unik has no record of")
 			continue
@@ -42,7 +42,7 @@ func (p *AwsProvider) ListVolumes() ([]*types.Volume, error) {
 		} else {
 			volume.Attachment = ""
 		}
-		if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 			volumes[volume.Id] = volume
 			return nil
 		}); err != nil {
@@ -0,0 +1,36 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// RenameImage renames an image in the state of unik and sets the Name tag of its
+// ami. the names amis are registered with cannot be changed, so that one is kept
+func (p *AwsProvider) RenameImage(id, newName string) (*types.Image, error) {
+	image, err := p.GetImage(id)
+	if err != nil {
+		return nil, errors.New("retrieving image "+id, err)
+	}
+	if _, err := p.newEC2().CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{aws.String(image.Id)},
+		Tags:      []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(newName)}},
+	}); err != nil {
+		return nil, errors.New("tagging ami "+image.Id+" with its new name", err)
+	}
+	var renamed *types.Image
+	if err := p.State.ModifyImages(func(images map[string]*types.Image) error {
+		stored, ok := images[image.Id]
+		if !ok {
+			return errors.New("image "+image.Id+" not found in state", nil)
+		}
+		stored.Name = newName
+		renamed = stored
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying image map in state", err)
+	}
+	return renamed, nil
+}
@@ -0,0 +1,39 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// RenameVolume renames a volume in the state of unik and sets the Name tag of its
+// ebs volume, which keeps its id
+func (p *AwsProvider) RenameVolume(id, newName string) (*types.Volume, error) {
+	volume, err := p.GetVolume(id)
+	if err != nil {
+		return nil, errors.New("retrieving volume "+id, err)
+	}
+	if volume.Attachment != "" {
+		return nil, errors.New("volume "+volume.Name+" is attached to instance "+volume.Attachment+", detach it first", nil)
+	}
+	if _, err := p.newEC2().CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{aws.String(volume.Id)},
+		Tags:      []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(newName)}},
+	}); err != nil {
+		return nil, errors.New("tagging volume "+volume.Id+" with its new name", err)
+	}
+	var renamed *types.Volume
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		stored, ok := volumes[volume.Id]
+		if !ok {
+			return errors.New("volume "+volume.Id+" not found in state", nil)
+		}
+		stored.Name = newName
+		renamed = stored
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying volume map in state", err)
+	}
+	return renamed, nil
+}
@@ -35,7 +35,7 @@ func (p *AwsProvider) RunInstance(params types.RunInstanceParams) (_ *types.Inst
 					InstanceIds: []*string{aws.String(instanceId)},
 				}
 				ec2svc.TerminateInstances(terminateInstanceInput)
-				if cleanupErr := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+				if cleanupErr := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 					delete(instances, instanceId)
 					return nil
 				}); cleanupErr != nil {
@@ -82,6 +82,14 @@ func (p *AwsProvider) RunInstance(params types.RunInstanceParams) (_ *types.Inst
 		InstanceType: aws.String(instanceType),
 		UserData:     aws.String(encodedData),
 	}
+	// the router and dns server of a vpc are its own; only the address can be chosen,
+	// and it must be free in the subnet the instance is launched in
+	if params.StaticIP != nil {
+		if params.StaticIP.Gateway != "" || len(params.StaticIP.DNS) > 0 {
+			logrus.Warnf("aws instances use the router and dns server of their vpc, ignoring the gateway and dns servers of the static ip")
+		}
+		runInstanceInput.PrivateIpAddress = aws.String(params.StaticIP.IP())
+	}
 
 	runInstanceOutput, err := ec2svc.RunInstances(runInstanceInput)
 	if err != nil {
@@ -108,7 +116,7 @@ func (p *AwsProvider) RunInstance(params types.RunInstanceParams) (_ *types.Inst
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
@@ -142,12 +150,10 @@ func (p *AwsProvider) RunInstance(params types.RunInstanceParams) (_ *types.Inst
 		Resources: []*string{
 			aws.String(instanceId),
 		},
-		Tags: []*ec2.Tag{
-			&ec2.Tag{
-				Key:   aws.String("Name"),
-				Value: aws.String(params.Name),
-			},
-		},
+		Tags: append(ec2Tags(params.Tags), &ec2.Tag{
+			Key:   aws.String(nameTagKey),
+			Value: aws.String(params.Name),
+		}),
 	}
 	_, err = ec2svc.CreateTags(tagObjects)
 	if err != nil {
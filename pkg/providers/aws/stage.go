@@ -92,7 +92,7 @@ func (p *AwsProvider) Stage(params types.StageImageParams) (_ *types.Image, err
 		}
 	}
 
-	volumeId, err = createDataVolumeFromRawImage(s3svc, ec2svc, params.RawImage.LocalImagePath, imageSize, params.RawImage.StageSpec.ImageFormat, p.config.Zone)
+	volumeId, err = createDataVolumeFromRawImage(p.newUploader(s3svc), ec2svc, params.RawImage.LocalImagePath, imageSize, params.RawImage.StageSpec.ImageFormat, p.config.Zone, nil)
 	if err != nil {
 		return nil, errors.New("creating aws boot volume", err)
 	}
@@ -200,7 +200,7 @@ func (p *AwsProvider) Stage(params types.StageImageParams) (_ *types.Image, err
 		Infrastructure: types.Infrastructure_AWS,
 		Created:        time.Now(),
 	}
-	if err := p.state.ModifyImages(func(images map[string]*types.Image) error {
+	if err := p.State.ModifyImages(func(images map[string]*types.Image) error {
 		images[imageId] = image
 		return nil
 	}); err != nil {
@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// the Name tag is set by unik when an instance is run or a volume is created,
+// and is never synced away
+const nameTagKey = "Name"
+
+// SyncTags makes the ec2 tags of an instance match tags. tags present on the
+// instance but not in tags are deleted, except for the Name tag.
+func (p *AwsProvider) SyncTags(instanceId string, tags map[string]string) error {
+	return p.syncEc2Tags(instanceId, tags)
+}
+
+// SyncVolumeTags makes the tags of an ebs volume match tags, in the same way as SyncTags
+func (p *AwsProvider) SyncVolumeTags(volumeId string, tags map[string]string) error {
+	return p.syncEc2Tags(volumeId, tags)
+}
+
+func (p *AwsProvider) syncEc2Tags(resourceId string, tags map[string]string) error {
+	ec2svc := p.newEC2()
+	output, err := ec2svc.DescribeTags(&ec2.DescribeTagsInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   aws.String("resource-id"),
+				Values: []*string{aws.String(resourceId)},
+			},
+		},
+	})
+	if err != nil {
+		return errors.New("describing tags of "+resourceId, err)
+	}
+	stale := []*ec2.Tag{}
+	for _, tag := range output.Tags {
+		key := aws.StringValue(tag.Key)
+		if _, ok := tags[key]; !ok && key != nameTagKey {
+			stale = append(stale, &ec2.Tag{Key: tag.Key})
+		}
+	}
+	if len(stale) > 0 {
+		if _, err := ec2svc.DeleteTags(&ec2.DeleteTagsInput{
+			Resources: []*string{aws.String(resourceId)},
+			Tags:      stale,
+		}); err != nil {
+			return errors.New("deleting tags of "+resourceId, err)
+		}
+	}
+	if newTags := ec2Tags(tags); len(newTags) > 0 {
+		if _, err := ec2svc.CreateTags(&ec2.CreateTagsInput{
+			Resources: []*string{aws.String(resourceId)},
+			Tags:      newTags,
+		}); err != nil {
+			return errors.New("tagging "+resourceId, err)
+		}
+	}
+	return nil
+}
+
+// ec2Tags converts unik tags to ec2 tags, sorted by key. a Name tag is left
+// out, as it would conflict with the name of the instance.
+func ec2Tags(tags map[string]string) []*ec2.Tag {
+	keys := []string{}
+	for key := range tags {
+		if key != nameTagKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	ec2tags := []*ec2.Tag{}
+	for _, key := range keys {
+		ec2tags = append(ec2tags, &ec2.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return ec2tags
+}
@@ -0,0 +1,15 @@
+package common
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/state"
+)
+
+// ProviderState keeps the state of a provider. providers embed it to implement
+// providers.Provider's GetState
+type ProviderState struct {
+	State state.State
+}
+
+func (p *ProviderState) GetState() state.State {
+	return p.State
+}
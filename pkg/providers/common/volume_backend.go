@@ -0,0 +1,115 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/util"
+)
+
+// VolumeBackend manages the directories local providers keep their volume files in.
+// every volume gets a directory of its own, so a volume can be copied as a whole.
+type VolumeBackend interface {
+	// CreateVolumeDir creates an empty directory for a new volume
+	CreateVolumeDir(dir string) error
+	// CloneVolumeDir creates dst with a copy of every file in src
+	CloneVolumeDir(src, dst string) error
+	// DeleteVolumeDir removes the directory of a volume and everything in it
+	DeleteVolumeDir(dir string) error
+}
+
+// NewVolumeBackend returns a BtrfsVolumeBackend if volumesDir is on a btrfs
+// filesystem, and a CopyVolumeBackend otherwise
+func NewVolumeBackend(volumesDir string) VolumeBackend {
+	onBtrfs, err := isOnBtrfs(volumesDir)
+	if err != nil {
+		logrus.WithError(err).Warnf("could not determine filesystem of %s, volumes will be copied in full", volumesDir)
+	}
+	if onBtrfs {
+		if _, err := exec.LookPath("btrfs"); err != nil {
+			logrus.Warnf("%s is on btrfs but the btrfs tool is not installed, volumes will be copied in full", volumesDir)
+			return &CopyVolumeBackend{}
+		}
+		logrus.Infof("%s is on btrfs, volumes will be stored as subvolumes", volumesDir)
+		return &BtrfsVolumeBackend{}
+	}
+	return &CopyVolumeBackend{}
+}
+
+// CopyVolumeBackend stores volumes in plain directories. clones are made with
+// cp --reflink=auto, so they are copy-on-write where the filesystem supports it
+// (e.g. xfs) and full copies everywhere else.
+type CopyVolumeBackend struct{}
+
+func (b *CopyVolumeBackend) CreateVolumeDir(dir string) error {
+	return os.MkdirAll(dir, 0755)
+}
+
+func (b *CopyVolumeBackend) CloneVolumeDir(src, dst string) error {
+	files, err := ioutil.ReadDir(src)
+	if err != nil {
+		return errors.New("reading volume directory "+src, err)
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return errors.New("creating volume directory "+dst, err)
+	}
+	for _, file := range files {
+		cmd := exec.Command("cp", "--reflink=auto", "--sparse=always", filepath.Join(src, file.Name()), filepath.Join(dst, file.Name()))
+		util.LogCommand(cmd, true)
+		if err := cmd.Run(); err != nil {
+			os.RemoveAll(dst)
+			return errors.New("copying "+file.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (b *CopyVolumeBackend) DeleteVolumeDir(dir string) error {
+	return os.RemoveAll(dir)
+}
+
+// BtrfsVolumeBackend stores every volume in a btrfs subvolume. cloning a volume
+// takes a snapshot of its subvolume, which is near-instant regardless of size.
+// volume directories created before the backend was in use are not subvolumes;
+// those are copied like CopyVolumeBackend does.
+type BtrfsVolumeBackend struct {
+	CopyVolumeBackend
+}
+
+func (b *BtrfsVolumeBackend) CreateVolumeDir(dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	return runBtrfs("subvolume", "create", dir)
+}
+
+func (b *BtrfsVolumeBackend) CloneVolumeDir(src, dst string) error {
+	if !isSubvolume(src) {
+		logrus.Debugf("%s is not a btrfs subvolume, copying it instead of taking a snapshot", src)
+		return b.CopyVolumeBackend.CloneVolumeDir(src, dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return runBtrfs("subvolume", "snapshot", src, dst)
+}
+
+func (b *BtrfsVolumeBackend) DeleteVolumeDir(dir string) error {
+	if !isSubvolume(dir) {
+		return b.CopyVolumeBackend.DeleteVolumeDir(dir)
+	}
+	return runBtrfs("subvolume", "delete", dir)
+}
+
+func runBtrfs(args ...string) error {
+	cmd := exec.Command("btrfs", args...)
+	util.LogCommand(cmd, true)
+	if err := cmd.Run(); err != nil {
+		return errors.New("running btrfs "+args[0]+" "+args[1], err)
+	}
+	return nil
+}
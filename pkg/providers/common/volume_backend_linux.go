@@ -0,0 +1,36 @@
+package common
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	btrfsSuperMagic = 0x9123683E
+	// the root directory of every btrfs subvolume has this inode number
+	btrfsSubvolumeIno = 256
+)
+
+func isOnBtrfs(dir string) (bool, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return false, err
+	}
+	return stat.Type == btrfsSuperMagic, nil
+}
+
+func isSubvolume(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Ino == btrfsSubvolumeIno
+}
+
+// processRunning tells whether there is a process with pid, which may belong to
+// another user
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
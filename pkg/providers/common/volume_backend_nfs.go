@@ -0,0 +1,110 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+)
+
+const nfsLockFile = ".lock"
+
+// NfsVolumeBackend stores volumes on an nfs export, so volume files live on
+// shared storage instead of local disk. the export is mounted when the daemon
+// starts; a .lock file on the export keeps a second daemon from using it at the
+// same time. volumes are copied in full, like with CopyVolumeBackend.
+type NfsVolumeBackend struct {
+	CopyVolumeBackend
+	// Export is the nfs export to mount, as server:/path
+	Export     string
+	MountPoint string
+	locked     bool
+}
+
+func NewNfsVolumeBackend(export, mountPoint string) (*NfsVolumeBackend, error) {
+	if !strings.Contains(export, ":") {
+		return nil, errors.New("nfs export "+export+" must be given as server:/path", nil)
+	}
+	return &NfsVolumeBackend{Export: export, MountPoint: mountPoint}, nil
+}
+
+// Mount mounts the export and takes the lock on it
+func (b *NfsVolumeBackend) Mount() error {
+	if err := os.MkdirAll(b.MountPoint, 0755); err != nil {
+		return errors.New("creating mount point "+b.MountPoint, err)
+	}
+	logrus.WithFields(logrus.Fields{"export": b.Export, "mountpoint": b.MountPoint}).Infof("mounting nfs volume store")
+	if err := unikos.RunLogCommand("mount", "-t", "nfs", b.Export, b.MountPoint); err != nil {
+		return errors.New("mounting nfs export "+b.Export, err)
+	}
+	if err := b.lock(); err != nil {
+		unikos.Umount(b.MountPoint)
+		return err
+	}
+	return nil
+}
+
+// Unmount releases the lock and unmounts the export
+func (b *NfsVolumeBackend) Unmount() error {
+	if b.locked {
+		if err := os.Remove(b.lockPath()); err != nil {
+			logrus.WithError(err).Warnf("failed to remove nfs lock file %s", b.lockPath())
+		}
+		b.locked = false
+	}
+	return unikos.Umount(b.MountPoint)
+}
+
+func (b *NfsVolumeBackend) lockPath() string {
+	return filepath.Join(b.MountPoint, nfsLockFile)
+}
+
+// lock creates the lock file exclusively. the file records which daemon holds
+// the lock. a lock left by a daemon on this host that is no longer running is
+// taken over; one left by a daemon on another host has to be removed by hand.
+func (b *NfsVolumeBackend) lock() error {
+	f, err := os.OpenFile(b.lockPath(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		owner, _ := ioutil.ReadFile(b.lockPath())
+		if !b.staleLock(string(owner)) {
+			return errors.New(fmt.Sprintf("nfs volume store %s is in use by another unik daemon (%s). if that daemon is no longer running, remove %s on the export", b.Export, strings.TrimSpace(string(owner)), nfsLockFile), nil)
+		}
+		logrus.Warnf("taking over nfs lock file %s of unik daemon (%s), which is no longer running", b.lockPath(), strings.TrimSpace(string(owner)))
+		if err := os.Remove(b.lockPath()); err != nil {
+			return errors.New("removing stale lock file on nfs export", err)
+		}
+		f, err = os.OpenFile(b.lockPath(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	}
+	if err != nil {
+		return errors.New("creating lock file on nfs export", err)
+	}
+	defer f.Close()
+	hostname, _ := os.Hostname()
+	if _, err := fmt.Fprintf(f, "%s pid %d\n", hostname, os.Getpid()); err != nil {
+		return errors.New("writing lock file on nfs export", err)
+	}
+	b.locked = true
+	return nil
+}
+
+// staleLock tells whether owner, the contents of a lock file, is a daemon on this
+// host whose process is gone
+func (b *NfsVolumeBackend) staleLock(owner string) bool {
+	var host string
+	var pid int
+	if _, err := fmt.Sscanf(owner, "%s pid %d", &host, &pid); err != nil {
+		return false
+	}
+	hostname, err := os.Hostname()
+	if err != nil || host != hostname {
+		return false
+	}
+	// the lock isn't taken yet, so if the pid is ours it was left by an earlier
+	// daemon, e.g. one restarted in the same container
+	return pid == os.Getpid() || !processRunning(pid)
+}
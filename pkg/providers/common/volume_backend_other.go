@@ -0,0 +1,16 @@
+// +build !linux
+
+package common
+
+func isOnBtrfs(dir string) (bool, error) {
+	return false, nil
+}
+
+func isSubvolume(dir string) bool {
+	return false
+}
+
+// processRunning can't tell here, so locks are never treated as stale
+func processRunning(pid int) bool {
+	return true
+}
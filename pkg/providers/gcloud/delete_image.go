@@ -29,5 +29,5 @@ func (p *GcloudProvider) DeleteImage(id string, force bool) error {
 	if _, err := p.compute().Images.Delete(p.config.ProjectID, image.Name).Do(); err != nil {
 		return errors.New("deleting image from gcloud", err)
 	}
-	return p.state.RemoveImage(image)
+	return p.State.RemoveImage(image)
 }
@@ -17,5 +17,5 @@ func (p *GcloudProvider) DeleteInstance(id string, force bool) error {
 	if err != nil {
 		return errors.New("failed to terminate instance "+instance.Id, err)
 	}
-	return p.state.RemoveInstance(instance)
+	return p.State.RemoveInstance(instance)
 }
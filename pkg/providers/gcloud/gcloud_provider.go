@@ -6,6 +6,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/state"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2/google"
@@ -18,8 +19,8 @@ func GcloudStateFile() string {
 }
 
 type GcloudProvider struct {
-	config     config.Gcloud
-	state      state.State
+	config config.Gcloud
+	common.ProviderState
 	computeSvc *compute.Service
 	storageSvc *storage.Service
 }
@@ -45,15 +46,15 @@ func NewGcloudProvier(config config.Gcloud) (*GcloudProvider, error) {
 	}
 
 	return &GcloudProvider{
-		config:     config,
-		state:      state.NewBasicState(GcloudStateFile()),
-		computeSvc: computeService,
-		storageSvc: storageSevice,
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(GcloudStateFile())},
+		computeSvc:    computeService,
+		storageSvc:    storageSevice,
 	}, nil
 }
 
 func (p *GcloudProvider) WithState(state state.State) *GcloudProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 
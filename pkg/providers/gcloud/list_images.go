@@ -6,11 +6,11 @@ import (
 
 func (p *GcloudProvider) ListImages() ([]*types.Image, error) {
 	images := []*types.Image{}
-	for _, image := range p.state.GetImages() {
+	for _, image := range p.State.GetImages() {
 		if p.verifyImage(image.Name) {
 			images = append(images, image)
 		} else {
-			p.state.RemoveImage(image)
+			p.State.RemoveImage(image)
 		}
 	}
 	return images, nil
@@ -7,7 +7,7 @@ import (
 )
 
 func (p *GcloudProvider) ListInstances() ([]*types.Instance, error) {
-	if len(p.state.GetInstances()) < 1 {
+	if len(p.State.GetInstances()) < 1 {
 		return []*types.Instance{}, nil
 	}
 
@@ -17,7 +17,7 @@ func (p *GcloudProvider) ListInstances() ([]*types.Instance, error) {
 	}
 
 	updatedInstances := []*types.Instance{}
-	for _, instance := range p.state.GetInstances() {
+	for _, instance := range p.State.GetInstances() {
 		instanceFound := false
 		//find instance in list
 		for _, gInstance := range gInstances.Items {
@@ -28,7 +28,7 @@ func (p *GcloudProvider) ListInstances() ([]*types.Instance, error) {
 				if len(gInstance.NetworkInterfaces) > 0 && len(gInstance.NetworkInterfaces[0].AccessConfigs) > 0 {
 					instance.IpAddress = gInstance.NetworkInterfaces[0].AccessConfigs[0].NatIP
 				}
-				p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+				p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 					instances[instance.Id] = instance
 					return nil
 				})
@@ -39,7 +39,7 @@ func (p *GcloudProvider) ListInstances() ([]*types.Instance, error) {
 		}
 		if !instanceFound {
 			logrus.Warnf("instance %v no longer found, cleaning it from state", instance.Name)
-			p.state.RemoveInstance(instance)
+			p.State.RemoveInstance(instance)
 		}
 	}
 
@@ -30,7 +30,7 @@ func (p *GcloudProvider) RunInstance(params types.RunInstanceParams) (_ *types.I
 				}
 				logrus.Warnf("cleaning up instance %s", instanceId)
 				p.compute().Instances.Delete(p.config.ProjectID, p.config.Zone, instanceId)
-				if cleanupErr := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+				if cleanupErr := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 					delete(instances, instanceId)
 					return nil
 				}); cleanupErr != nil {
@@ -126,7 +126,7 @@ func (p *GcloudProvider) RunInstance(params types.RunInstanceParams) (_ *types.I
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
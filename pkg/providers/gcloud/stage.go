@@ -155,7 +155,7 @@ func (p *GcloudProvider) Stage(params types.StageImageParams) (_ *types.Image, e
 		Infrastructure: types.Infrastructure_GCLOUD,
 		Created:        time.Now(),
 	}
-	if err := p.state.ModifyImages(func(images map[string]*types.Image) error {
+	if err := p.State.ModifyImages(func(images map[string]*types.Image) error {
 		images[image.Id] = image
 		return nil
 	}); err != nil {
@@ -1,12 +1,16 @@
 package providers
 
 import (
+	"time"
+
 	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/state"
 	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
 type Provider interface {
 	GetConfig() ProviderConfig
+	GetState() state.State
 	//Images
 	Stage(params types.StageImageParams) (*types.Image, error)
 	ListImages() ([]*types.Image, error)
@@ -33,8 +37,124 @@ type Provider interface {
 	RemoteDeleteImage(params types.RemoteDeleteImagePararms) error
 }
 
+// VolumeCloner is implemented by providers that can copy a volume,
+// e.g. to give a cloned instance its own copy of the source instance's data
+type VolumeCloner interface {
+	CloneVolume(id, newName string) (*types.Volume, error)
+}
+
+// LocalImageProvider is implemented by providers that keep their images as disk
+// files on the daemon host, so the daemon can inspect their contents
+type LocalImageProvider interface {
+	GetImageFile(id string) (string, types.ImageFormat, error)
+}
+
+// LocalVolumeProvider is implemented by providers that keep their volumes as
+// disk files on the daemon host, so the daemon can export their data
+type LocalVolumeProvider interface {
+	GetVolumeFile(id string) (string, types.ImageFormat, error)
+}
+
+// TagSyncer is implemented by providers whose infrastructure has its own notion
+// of instance tags, so the tags unik records are visible there as well
+type TagSyncer interface {
+	SyncTags(instanceId string, tags map[string]string) error
+}
+
+// GracefulStopper is implemented by providers that can ask an instance to shut
+// itself down (e.g. with an ACPI power off). GracefulStop waits up to timeout for
+// the instance to stop and then stops it like StopInstance.
+type GracefulStopper interface {
+	GracefulStop(instanceId string, timeout time.Duration) error
+}
+
+// ImageRenamer is implemented by providers that can change the name of an image.
+// the id of images that are identified by their name changes with it; the daemon
+// updates the instances, images and manifests referring to the old id
+type ImageRenamer interface {
+	RenameImage(imageId, newName string) (*types.Image, error)
+}
+
+// VolumeRenamer is implemented by providers that can change the name of a volume.
+// like images, volumes identified by their name get a new id; the daemon updates
+// the instances mounting the old id. attached volumes are not renamed
+type VolumeRenamer interface {
+	RenameVolume(volumeId, newName string) (*types.Volume, error)
+}
+
+// Restarter is implemented by providers that restart instances in place, for
+// instances that cannot be started again once stopped. RestartInstance gives the
+// instance up to timeout to shut down before resetting it, and keeps its id.
+type Restarter interface {
+	RestartInstance(instanceId string, timeout time.Duration) error
+}
+
+// Suspender is implemented by providers that can save the state of an instance
+// to a snapshot and stop it, to resume it from the snapshot later. the instance
+// may get a new id when it is resumed.
+type Suspender interface {
+	SuspendInstance(instanceId, snapshotName string) (*types.Instance, error)
+	ResumeInstance(instanceId, snapshotName string) (*types.Instance, error)
+}
+
+// Migrator is implemented by providers that can move a running instance to the
+// host of another daemon. the other daemon runs the instance with the RunParams
+// of the source and IncomingMigration set; MigrateInstance then sends it the
+// state of the instance, and removes the instance here once it has taken over.
+type Migrator interface {
+	RunParams(instanceId string) (types.RunInstanceParams, error)
+	MigrateInstance(instanceId, uri string, timeout time.Duration) error
+}
+
+// NetworkInspector is implemented by providers that can list the network
+// interfaces of an instance
+type NetworkInspector interface {
+	GetInstanceNetwork(instanceId string) ([]types.NetworkInterface, error)
+}
+
+// GuestExecer is implemented by providers that can run commands in an instance,
+// through an agent the unikernel runs
+type GuestExecer interface {
+	// GuestExec runs path with args in the instance and waits up to timeout for
+	// it to exit. with a timeout of 0, it returns once the command is started,
+	// with no result
+	GuestExec(instanceId, path string, args []string, timeout time.Duration) (*types.ExecResult, error)
+}
+
+// MetricsReporter is implemented by providers that can sample the cpu and
+// memory usage of an instance
+type MetricsReporter interface {
+	GetInstanceMetrics(instanceId string) (*types.InstanceMetrics, error)
+}
+
+// VolumeTagSyncer is the TagSyncer for volumes
+type VolumeTagSyncer interface {
+	SyncVolumeTags(volumeId string, tags map[string]string) error
+}
+
 type ProviderConfig struct {
 	UsePartitionTables bool
+	// SupportsPortMappings is set by providers that can forward ports of the
+	// daemon host to instances (types.RunInstanceParams.PortMappings)
+	SupportsPortMappings bool
+	// SupportsRuntimeCmdline is set by providers that boot the kernel of an image
+	// with a command line they can change when an instance is run
+	// (types.RunInstanceParams.Cmdline)
+	SupportsRuntimeCmdline bool
+	// SupportsReadOnlyVolumes is set by providers that can attach the volumes of
+	// an instance read-only when it is run (types.RunInstanceParams.ReadOnlyMntPoints)
+	SupportsReadOnlyVolumes bool
+	// SupportsCloudInit is set by providers that can attach a cloud-init nocloud
+	// iso to instances (types.RunInstanceParams.UserData and MetaData)
+	SupportsCloudInit bool
+	// SupportsIPv6 is set by providers that can give instances a static ipv6
+	// address (types.RunInstanceParams.IPv6)
+	SupportsIPv6 bool
+	// SupportsStaticIP is set by providers that can give instances a fixed ipv4
+	// address (types.RunInstanceParams.StaticIP)
+	SupportsStaticIP bool
+	// Architecture of the machines instances run on. empty means amd64
+	Architecture types.Architecture
 }
 
 type Providers map[string]Provider
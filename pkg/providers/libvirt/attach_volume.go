@@ -0,0 +1,7 @@
+package libvirt
+
+import "github.com/emc-advanced-dev/pkg/errors"
+
+func (p *LibvirtProvider) AttachVolume(id, instanceId, mntPoint string) error {
+	return errors.New("not yet supported for libvirt", nil)
+}
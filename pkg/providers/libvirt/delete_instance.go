@@ -0,0 +1,29 @@
+package libvirt
+
+import (
+	"os"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *LibvirtProvider) DeleteInstance(id string, force bool) error {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return errors.New("retrieving instance "+id, err)
+	}
+	if instance.State == types.InstanceState_Running {
+		if force {
+			if err := p.StopInstance(instance.Id); err != nil {
+				return errors.New("stopping instance for deletion", err)
+			}
+		} else {
+			return errors.New("instance "+instance.Id+" is still running. try again with --force or power off instance first", err)
+		}
+	}
+	if err := p.client.UndefineDomain(instance.Name); err != nil {
+		return errors.New("undefining domain", err)
+	}
+	os.RemoveAll(getInstanceDir(instance.Name))
+	return p.State.RemoveInstance(instance)
+}
@@ -0,0 +1,28 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/pkg/errors"
+	"path/filepath"
+)
+
+func (p *LibvirtProvider) DeleteVolume(id string, force bool) error {
+
+	volume, err := p.GetVolume(id)
+	if err != nil {
+		return errors.New("retrieving volume "+id, err)
+	}
+	if volume.Attachment != "" {
+		if force {
+			if err := p.DetachVolume(volume.Id); err != nil {
+				return errors.New("detaching volume for deletion", err)
+			}
+			return errors.New("volume "+volume.Id+" is attached to instance."+volume.Attachment+", try again with --force or detach volume first", err)
+		}
+	}
+	volumeDir := filepath.Dir(getVolumePath(volume.Name))
+	err = p.volumeBackend.DeleteVolumeDir(volumeDir)
+	if err != nil {
+		return errors.New("could not delete volume at path "+volumeDir, err)
+	}
+	return p.State.RemoveVolume(volume)
+}
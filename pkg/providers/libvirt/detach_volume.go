@@ -0,0 +1,8 @@
+package libvirt
+
+import "github.com/emc-advanced-dev/pkg/errors"
+
+func (p *LibvirtProvider) DetachVolume(id string) error {
+
+	return errors.New("not yet supported for libvirt", nil)
+}
@@ -0,0 +1,14 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/providers"
+)
+
+func (p *LibvirtProvider) GetConfig() providers.ProviderConfig {
+	return providers.ProviderConfig{
+		UsePartitionTables:      true,
+		SupportsRuntimeCmdline:  true,
+		SupportsReadOnlyVolumes: true,
+		SupportsCloudInit:       true,
+	}
+}
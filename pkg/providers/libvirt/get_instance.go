@@ -0,0 +1,10 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *LibvirtProvider) GetInstance(nameOrIdPrefix string) (*types.Instance, error) {
+	return common.GetInstance(p, nameOrIdPrefix)
+}
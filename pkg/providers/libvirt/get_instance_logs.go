@@ -0,0 +1,21 @@
+package libvirt
+
+import (
+	"io/ioutil"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// GetInstanceLogs returns the output of the serial port of the instance, which
+// libvirt writes to a file in the instance's directory
+func (p *LibvirtProvider) GetInstanceLogs(id string) (string, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return "", errors.New("retrieving instance "+id, err)
+	}
+	logs, err := ioutil.ReadFile(getSerialLogPath(instance.Name))
+	if err != nil {
+		return "", errors.New("reading serial log of instance "+instance.Name, err)
+	}
+	return string(logs), nil
+}
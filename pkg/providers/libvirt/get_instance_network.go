@@ -0,0 +1,32 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// GetInstanceNetwork lists the nics of the domain of an instance, named as on the
+// host (e.g. vnet0), with the addresses the libvirt network leased to them
+func (p *LibvirtProvider) GetInstanceNetwork(id string) ([]types.NetworkInterface, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+id, err)
+	}
+	domainInterfaces, err := p.client.DomainInterfaces(instance.Name)
+	if err != nil {
+		return nil, err
+	}
+	interfaces := []types.NetworkInterface{}
+	for _, domainInterface := range domainInterfaces {
+		addresses := domainInterface.Addresses
+		if addresses == nil {
+			addresses = []string{}
+		}
+		interfaces = append(interfaces, types.NetworkInterface{
+			Name:        domainInterface.Name,
+			MACAddress:  domainInterface.MACAddress,
+			IPAddresses: addresses,
+		})
+	}
+	return interfaces, nil
+}
@@ -0,0 +1,35 @@
+package libvirt
+
+import (
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// how often GracefulStop checks whether the domain has shut down
+const gracefulStopPollInterval = 500 * time.Millisecond
+
+// GracefulStop sends an ACPI power off to the domain with virsh shutdown, and
+// destroys it if it has not shut down by the end of timeout
+func (p *LibvirtProvider) GracefulStop(id string, timeout time.Duration) error {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return errors.New("retrieving instance "+id, err)
+	}
+	if err := p.client.ShutdownDomain(instance.Name); err != nil {
+		logrus.WithError(err).Warnf("could not shut down instance %s, forcing it to stop", instance.Name)
+		return p.StopInstance(id)
+	}
+	logrus.Debugf("sent acpi power off to instance %s, waiting up to %s for it to shut down", instance.Name, timeout)
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if state, err := p.client.DomainState(instance.Name); err == nil && state == "shut off" {
+			return nil
+		}
+		time.Sleep(gracefulStopPollInterval)
+	}
+	logrus.Warnf("instance %s did not shut down within %s, forcing it to stop", instance.Name, timeout)
+	return p.StopInstance(id)
+}
@@ -0,0 +1,116 @@
+package libvirt
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
+	"github.com/emc-advanced-dev/unik/pkg/providers/libvirt/virshclient"
+	"github.com/emc-advanced-dev/unik/pkg/state"
+)
+
+type LibvirtProvider struct {
+	config config.Libvirt
+	common.ProviderState
+	volumeBackend common.VolumeBackend
+	client        *virshclient.VirshClient
+}
+
+func LibvirtStateFile() string {
+	return filepath.Join(config.Internal.UnikHome, "libvirt/state.json")
+}
+
+func libvirtImagesDirectory() string {
+	return filepath.Join(config.Internal.UnikHome, "libvirt/images/")
+}
+
+func libvirtInstancesDirectory() string {
+	return filepath.Join(config.Internal.UnikHome, "libvirt/instances/")
+}
+
+func libvirtVolumesDirectory() string {
+	return filepath.Join(config.Internal.VolumesHome(), "libvirt/volumes/")
+}
+
+func NewLibvirtProvider(config config.Libvirt) (*LibvirtProvider, error) {
+	if config.URI == "" {
+		config.URI = "qemu:///system"
+	}
+	if config.Network == "" {
+		config.Network = "default"
+	}
+	if config.DomainType == "" {
+		config.DomainType = "kvm"
+	}
+
+	os.MkdirAll(libvirtImagesDirectory(), 0777)
+	os.MkdirAll(libvirtInstancesDirectory(), 0777)
+	os.MkdirAll(libvirtVolumesDirectory(), 0777)
+
+	client := &virshclient.VirshClient{
+		URI:        config.URI,
+		Network:    config.Network,
+		DomainType: config.DomainType,
+	}
+	version, err := client.Version()
+	if err != nil {
+		return nil, errors.New("libvirtd is not reachable. is virsh installed?", err)
+	}
+	logrus.Debugf("connected to libvirtd at %s:\n%s", config.URI, version)
+
+	p := &LibvirtProvider{
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(LibvirtStateFile())},
+		volumeBackend: common.NewVolumeBackend(libvirtVolumesDirectory()),
+		client:        client,
+	}
+
+	// begin update instances cycle
+	go func() {
+		for {
+			if err := p.syncState(); err != nil {
+				logrus.Error("error updating libvirt state:", err)
+			}
+			time.Sleep(time.Second)
+		}
+	}()
+
+	return p, nil
+}
+
+func (p *LibvirtProvider) WithState(state state.State) *LibvirtProvider {
+	p.State = state
+	return p
+}
+
+func getImagePath(imageName string) string {
+	return filepath.Join(libvirtImagesDirectory(), imageName, "boot.img")
+}
+
+func getKernelPath(imageName string) string {
+	return filepath.Join(libvirtImagesDirectory(), imageName, "program.bin")
+}
+
+func getCmdlinePath(imageName string) string {
+	return filepath.Join(libvirtImagesDirectory(), imageName, "cmdline")
+}
+
+func getInstanceDir(instanceName string) string {
+	return filepath.Join(libvirtInstancesDirectory(), instanceName)
+}
+
+func getSerialLogPath(instanceName string) string {
+	return filepath.Join(getInstanceDir(instanceName), "serial.log")
+}
+
+func getNoCloudIsoPath(instanceName string) string {
+	return filepath.Join(getInstanceDir(instanceName), "nocloud.iso")
+}
+
+func getVolumePath(volumeName string) string {
+	return filepath.Join(libvirtVolumesDirectory(), volumeName, "data.img")
+}
@@ -0,0 +1,13 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *LibvirtProvider) ListImages() ([]*types.Image, error) {
+	images := []*types.Image{}
+	for _, image := range p.State.GetImages() {
+		images = append(images, image)
+	}
+	return images, nil
+}
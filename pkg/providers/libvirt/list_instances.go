@@ -0,0 +1,17 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *LibvirtProvider) ListInstances() ([]*types.Instance, error) {
+	if len(p.State.GetInstances()) < 1 {
+		return []*types.Instance{}, nil
+	}
+	var instances []*types.Instance
+	for _, v := range p.State.GetInstances() {
+		instances = append(instances, v)
+	}
+
+	return instances, nil
+}
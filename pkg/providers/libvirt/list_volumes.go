@@ -0,0 +1,13 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *LibvirtProvider) ListVolumes() ([]*types.Volume, error) {
+	volumes := []*types.Volume{}
+	for _, volume := range p.State.GetVolumes() {
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
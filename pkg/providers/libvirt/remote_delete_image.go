@@ -0,0 +1,10 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *LibvirtProvider) RemoteDeleteImage(params types.RemoteDeleteImagePararms) error {
+	return errors.New("not implemented", nil)
+}
@@ -0,0 +1,215 @@
+package libvirt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
+	"github.com/emc-advanced-dev/unik/pkg/providers/libvirt/virshclient"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *LibvirtProvider) RunInstance(params types.RunInstanceParams) (_ *types.Instance, err error) {
+	logrus.WithFields(logrus.Fields{
+		"image-id": params.ImageId,
+		"mounts":   params.MntPointsToVolumeIds,
+		"env":      params.Env,
+	}).Infof("running instance %s", params.Name)
+
+	if _, err := p.GetInstance(params.Name); err == nil {
+		return nil, errors.New("instance with name "+params.Name+" already exists. libvirt provider requires unique names for instances", nil)
+	}
+	if params.DebugMode {
+		return nil, errors.New("debug mode is not supported by the libvirt provider", nil)
+	}
+	if params.NetworkMode != "" {
+		logrus.Warnf("libvirt instances are attached to libvirt network %s, ignoring network mode %s", p.config.Network, params.NetworkMode)
+	}
+
+	image, err := p.GetImage(params.ImageId)
+	if err != nil {
+		return nil, errors.New("getting image", err)
+	}
+
+	if err := common.VerifyMntsInput(p, image, params.MntPointsToVolumeIds); err != nil {
+		return nil, errors.New("invalid mapping for volume", err)
+	}
+
+	instanceDir := getInstanceDir(params.Name)
+	if err := os.MkdirAll(instanceDir, 0777); err != nil {
+		return nil, errors.New("creating directory for instance", err)
+	}
+	defer func() {
+		if err != nil {
+			if params.NoCleanup {
+				logrus.Warnf("because --no-cleanup flag was provided, not cleaning up failed instance %s", params.Name)
+				return
+			}
+			logrus.WithError(err).Errorf("error encountered, ensuring domain is destroyed")
+			p.client.DestroyDomain(params.Name)
+			p.client.UndefineDomain(params.Name)
+			os.RemoveAll(instanceDir)
+		}
+	}()
+
+	if params.InstanceMemory == 0 {
+		params.InstanceMemory = image.RunSpec.DefaultInstanceMemory
+	}
+
+	domainParams := virshclient.DomainParams{
+		Name:      params.Name,
+		MemoryMb:  params.InstanceMemory,
+		VCPUs:     params.VCPUs,
+		SerialLog: getSerialLogPath(params.Name),
+		VmDir:     instanceDir,
+	}
+
+	cmdlinedata, err := ioutil.ReadFile(getCmdlinePath(image.Name))
+	if err != nil {
+		logrus.Debugf("cmdLine not found, assuming classic bootloader")
+		if len(params.Env) > 0 {
+			logrus.Warnf("the command line of image %s is set in its bootloader at build time, env will not be passed to the instance", image.Name)
+		}
+		if params.Cmdline != "" {
+			return nil, errors.New("the command line of image "+image.Name+" is set in its bootloader at build time and cannot be changed", nil)
+		}
+		domainParams.Disks = append(domainParams.Disks, virshclient.DiskConfig{
+			ImagePath:  getImagePath(image.Name),
+			Format:     "raw",
+			DeviceName: "hda",
+			Bus:        "ide",
+		})
+	} else {
+		// inject env for rump:
+		cmdline := string(cmdlinedata)
+		if compilers.CompilerType(image.RunSpec.Compiler).Base() == compilers.Rump {
+			if params.Cmdline != "" {
+				return nil, errors.New("rump images are configured with json on their command line, which cannot be changed", nil)
+			}
+			cmdline = injectEnv(cmdline, params.Env)
+		} else if cmdline, err = unikos.AppendEnvCmdline(strings.TrimSpace(cmdline), params.Env); err != nil {
+			return nil, errors.New("passing env on the kernel command line", err)
+		}
+		if params.Cmdline != "" {
+			cmdline = unikos.RenderCmdline(params.CmdlineMode, cmdline, params.Cmdline, cmdlineData(params))
+		}
+
+		if _, err := os.Stat(getImagePath(image.Name)); err == nil {
+			domainParams.Disks = append(domainParams.Disks, virshclient.DiskConfig{
+				ImagePath:  getImagePath(image.Name),
+				Format:     "qcow2",
+				DeviceName: "vda",
+				Bus:        "virtio",
+			})
+		}
+		domainParams.Kernel = getKernelPath(image.Name)
+		domainParams.Cmdline = cmdline
+	}
+
+	volumeDisks, err := p.volumeDisks(image, params)
+	if err != nil {
+		return nil, err
+	}
+	domainParams.Disks = append(domainParams.Disks, volumeDisks...)
+
+	if params.UserData != "" || params.MetaData != "" {
+		noCloudIso := getNoCloudIsoPath(params.Name)
+		if err := unikos.CreateNoCloudISO([]byte(params.UserData), []byte(params.MetaData), noCloudIso); err != nil {
+			return nil, errors.New("creating cloud-init iso for instance", err)
+		}
+		domainParams.Disks = append(domainParams.Disks, virshclient.DiskConfig{
+			ImagePath:  noCloudIso,
+			Format:     "raw",
+			DeviceName: "hdc",
+			Bus:        "ide",
+			CdRom:      true,
+			ReadOnly:   true,
+		})
+	}
+
+	logrus.Debugf("creating libvirt domain")
+
+	if err := p.client.DefineDomain(domainParams); err != nil {
+		return nil, errors.New("defining domain for instance", err)
+	}
+	if err := p.client.StartDomain(params.Name); err != nil {
+		return nil, errors.New("starting domain for instance", err)
+	}
+
+	instance := &types.Instance{
+		Id:             params.Name,
+		Name:           params.Name,
+		State:          types.InstanceState_Pending,
+		Infrastructure: types.Infrastructure_LIBVIRT,
+		ImageId:        image.Id,
+		Created:        time.Now(),
+	}
+
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+		instances[instance.Id] = instance
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying instance map in state", err)
+	}
+
+	logrus.WithField("instance", instance).Infof("instance created successfully")
+
+	return instance, nil
+}
+
+// volumeDisks are the disks of the volumes of an instance, named vdb, vdc... in
+// the order of the mount points of the image
+func (p *LibvirtProvider) volumeDisks(image *types.Image, params types.RunInstanceParams) ([]virshclient.DiskConfig, error) {
+	disks := make([]virshclient.DiskConfig, len(params.MntPointsToVolumeIds))
+	for mntPoint, volumeId := range params.MntPointsToVolumeIds {
+		controllerPort, err := common.GetControllerPortForMnt(image, mntPoint)
+		if err != nil {
+			return nil, err
+		}
+		volume, err := p.GetVolume(volumeId)
+		if err != nil {
+			return nil, errors.New("can't get volume "+volumeId, err)
+		}
+		disks[controllerPort] = virshclient.DiskConfig{
+			ImagePath:  getVolumePath(volume.Name),
+			Format:     "qcow2",
+			DeviceName: fmt.Sprintf("vd%c", 'b'+controllerPort),
+			Bus:        "virtio",
+		}
+		for _, readOnlyMntPoint := range params.ReadOnlyMntPoints {
+			if readOnlyMntPoint == mntPoint {
+				disks[controllerPort].ReadOnly = true
+			}
+		}
+	}
+	return disks, nil
+}
+
+// cmdlineData is the instance metadata a command line template is rendered with.
+// libvirt volumes are named by their ids. the ip address is leased by the libvirt
+// network after the instance boots, so it is not known yet
+func cmdlineData(params types.RunInstanceParams) unikos.CmdlineData {
+	return unikos.CmdlineData{
+		InstanceName: params.Name,
+		MountPoints:  params.MntPointsToVolumeIds,
+		Env:          params.Env,
+	}
+}
+
+func injectEnv(cmdline string, env map[string]string) string {
+	// rump json is not really json so we can't parse it
+	var envRumpJson []string
+	for key, value := range env {
+		envRumpJson = append(envRumpJson, fmt.Sprintf("\"env\": \"%s=%s\"", key, value))
+	}
+
+	cmdline = cmdline[:len(cmdline)-2] + "," + strings.Join(envRumpJson, ",") + "}}"
+	return cmdline
+}
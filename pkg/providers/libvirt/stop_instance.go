@@ -0,0 +1,16 @@
+package libvirt
+
+import (
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+func (p *LibvirtProvider) StopInstance(id string) error {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return errors.New("retrieving instance "+id, err)
+	}
+	if err := p.client.DestroyDomain(instance.Name); err != nil {
+		return errors.New("failed to stop instance "+instance.Id, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package libvirt
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/providers/libvirt/virshclient"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// domainStates maps the states virsh domstate prints to instance states
+var domainStates = map[string]types.InstanceState{
+	"running":     types.InstanceState_Running,
+	"idle":        types.InstanceState_Running,
+	"paused":      types.InstanceState_Paused,
+	"in shutdown": types.InstanceState_Running,
+	"shut off":    types.InstanceState_Stopped,
+	"crashed":     types.InstanceState_Error,
+	"pmsuspended": types.InstanceState_Suspended,
+}
+
+func (p *LibvirtProvider) syncState() error {
+	if len(p.State.GetInstances()) < 1 {
+		return nil
+	}
+	for _, instance := range p.State.GetInstances() {
+		domainState, err := p.client.DomainState(instance.Name)
+		if err != nil {
+			if virshclient.IsDomainNotFound(err) {
+				logrus.Warnf("instance found in state that is no longer defined in libvirt")
+				os.RemoveAll(getInstanceDir(instance.Name))
+				p.State.RemoveInstance(instance)
+				continue
+			}
+			return errors.New("retrieving domain for instance id "+instance.Name, err)
+		}
+		state, ok := domainStates[domainState]
+		if !ok {
+			state = types.InstanceState_Unknown
+		}
+
+		ipAddress := instance.IpAddress
+		if state == types.InstanceState_Running && ipAddress == "" {
+			if ipAddress, err = p.client.DomainIpAddress(instance.Name); err != nil {
+				logrus.WithError(err).Debugf("no ip address for instance %s yet", instance.Name)
+			}
+		}
+
+		if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+			if _, ok := instances[instance.Id]; ok {
+				instances[instance.Id].IpAddress = ipAddress
+				instances[instance.Id].State = state
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
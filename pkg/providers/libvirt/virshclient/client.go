@@ -0,0 +1,251 @@
+package virshclient
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// domainXml is the minimal domain unikernels run as: the boot disk and volumes
+// (on virtio, unless the image has a classic bootloader), a cloud-init cd-rom if the
+// instance has one, one virtio nic on a libvirt network (backed by a tap device) and
+// a serial port logging to a file. everything else libvirt would add by default (usb,
+// sound, graphics, balloon) is left out.
+const domainXml = `<domain type='{{html .DomainType}}'>
+  <name>{{html .Name}}</name>
+  <memory unit='MiB'>{{.MemoryMb}}</memory>
+  <vcpu>{{.VCPUs}}</vcpu>
+  <os>
+    <type arch='x86_64'>hvm</type>{{if .Kernel}}
+    <kernel>{{html .Kernel}}</kernel>
+    <cmdline>{{html .Cmdline}}</cmdline>{{else}}
+    <boot dev='hd'/>{{end}}
+  </os>
+  <features>
+    <acpi/>
+  </features>
+  <on_poweroff>destroy</on_poweroff>
+  <on_reboot>restart</on_reboot>
+  <on_crash>destroy</on_crash>
+  <devices>{{range .Disks}}
+    <disk type='file' device='{{if .CdRom}}cdrom{{else}}disk{{end}}'>
+      <driver name='qemu' type='{{html .Format}}'/>
+      <source file='{{html .ImagePath}}'/>
+      <target dev='{{html .DeviceName}}' bus='{{html .Bus}}'/>{{if .ReadOnly}}
+      <readonly/>{{end}}
+    </disk>{{end}}
+    <interface type='network'>
+      <source network='{{html .Network}}'/>
+      <model type='virtio'/>
+    </interface>
+    <serial type='file'>
+      <source path='{{html .SerialLog}}'/>
+      <target port='0'/>
+    </serial>
+    <memballoon model='none'/>
+  </devices>
+</domain>
+`
+
+var domainTemplate = template.Must(template.New("domain").Parse(domainXml))
+
+type VirshClient struct {
+	URI        string
+	Network    string
+	DomainType string
+}
+
+type DomainParams struct {
+	Name     string
+	MemoryMb int
+	VCPUs    int
+	// Kernel and Cmdline are set for images booted without a bootloader
+	Kernel    string
+	Cmdline   string
+	Disks     []DiskConfig
+	SerialLog string
+	VmDir     string
+}
+
+type DiskConfig struct {
+	ImagePath  string
+	Format     string
+	DeviceName string
+	// Bus is virtio, or ide for boot disks with a classic bootloader and cd-roms
+	Bus      string
+	ReadOnly bool
+	CdRom    bool
+}
+
+// DefineDomain writes the domain xml of a vm to its directory and defines it in libvirt
+func (c *VirshClient) DefineDomain(params DomainParams) error {
+	if params.VCPUs < 1 {
+		params.VCPUs = 1
+	}
+	var buf bytes.Buffer
+	if err := domainTemplate.Execute(&buf, struct {
+		DomainParams
+		Network    string
+		DomainType string
+	}{params, c.Network, c.DomainType}); err != nil {
+		return errors.New("rendering domain xml", err)
+	}
+	domainFile := filepath.Join(params.VmDir, "domain.xml")
+	if err := ioutil.WriteFile(domainFile, buf.Bytes(), 0644); err != nil {
+		return errors.New("writing domain xml for vm", err)
+	}
+
+	logrus.Debugf("using domain xml:\n%s", buf.String())
+
+	if _, err := c.virsh("define", domainFile); err != nil {
+		return errors.New("defining domain", err)
+	}
+	return nil
+}
+
+func (c *VirshClient) StartDomain(name string) error {
+	if _, err := c.virsh("start", name); err != nil {
+		return errors.New("starting domain", err)
+	}
+	return nil
+}
+
+// DestroyDomain powers a domain off immediately. domains that are not running are left as they are
+func (c *VirshClient) DestroyDomain(name string) error {
+	if _, err := c.virsh("destroy", name); err != nil && !strings.Contains(err.Error(), "not running") {
+		return errors.New("destroying domain", err)
+	}
+	return nil
+}
+
+// ShutdownDomain asks the domain to shut itself down with an acpi power off, and returns without waiting
+func (c *VirshClient) ShutdownDomain(name string) error {
+	if _, err := c.virsh("shutdown", name); err != nil {
+		return errors.New("shutting down domain", err)
+	}
+	return nil
+}
+
+func (c *VirshClient) UndefineDomain(name string) error {
+	if _, err := c.virsh("undefine", name); err != nil {
+		return errors.New("undefining domain", err)
+	}
+	return nil
+}
+
+// DomainState returns the state of a domain as virsh prints it, e.g. "running" or "shut off"
+func (c *VirshClient) DomainState(name string) (string, error) {
+	out, err := c.virsh("domstate", name)
+	if err != nil {
+		return "", errors.New("getting state of domain", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+var ipv4Address = regexp.MustCompile(`ipv4\s+([0-9.]+)/`)
+
+// DomainIpAddress returns the address the dhcp server of the libvirt network leased
+// to the domain, or "" if it has none yet
+func (c *VirshClient) DomainIpAddress(name string) (string, error) {
+	out, err := c.virsh("domifaddr", name, "--source", "lease")
+	if err != nil {
+		return "", errors.New("getting addresses of domain", err)
+	}
+	if match := ipv4Address.FindStringSubmatch(string(out)); match != nil {
+		return match[1], nil
+	}
+	return "", nil
+}
+
+type DomainInterface struct {
+	Name       string
+	MACAddress string
+	// Addresses are those the dhcp server of the network leased to the interface, without prefix length
+	Addresses []string
+}
+
+// DomainInterfaces lists the nics of a domain with domiflist, and their addresses
+// with domifaddr. interfaces that have no lease yet are listed without addresses
+func (c *VirshClient) DomainInterfaces(name string) ([]DomainInterface, error) {
+	out, err := c.virsh("domiflist", name)
+	if err != nil {
+		return nil, errors.New("listing interfaces of domain", err)
+	}
+	var interfaces []DomainInterface
+	for _, fields := range tableRows(out) {
+		// Interface Type Source Model MAC
+		if len(fields) >= 5 {
+			interfaces = append(interfaces, DomainInterface{Name: fields[0], MACAddress: fields[4]})
+		}
+	}
+
+	out, err = c.virsh("domifaddr", name, "--source", "lease")
+	if err != nil {
+		return nil, errors.New("getting addresses of domain", err)
+	}
+	// Name MAC Protocol Address; further addresses of an interface are listed with - as name and mac
+	var mac string
+	for _, fields := range tableRows(out) {
+		if len(fields) < 4 {
+			continue
+		}
+		if fields[1] != "-" {
+			mac = fields[1]
+		}
+		address := strings.SplitN(fields[3], "/", 2)[0]
+		for i := range interfaces {
+			if strings.EqualFold(interfaces[i].MACAddress, mac) {
+				interfaces[i].Addresses = append(interfaces[i].Addresses, address)
+			}
+		}
+	}
+	return interfaces, nil
+}
+
+// tableRows splits the rows of a table printed by virsh into fields, without the
+// header and the line under it
+func tableRows(out []byte) [][]string {
+	var rows [][]string
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for i, line := range lines {
+		if i < 2 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
+
+// Version checks that libvirtd can be reached, and returns the versions it reports
+func (c *VirshClient) Version() (string, error) {
+	out, err := c.virsh("version")
+	if err != nil {
+		return "", errors.New("connecting to libvirtd at "+c.URI, err)
+	}
+	return string(out), nil
+}
+
+// IsDomainNotFound reports whether err says that libvirt has no such domain
+func IsDomainNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "failed to get domain")
+}
+
+func (c *VirshClient) virsh(args ...string) ([]byte, error) {
+	cmd := exec.Command("virsh", append([]string{"-c", c.URI}, args...)...)
+	logrus.WithField("command", cmd.Args).Debugf("running virsh command")
+	errBuf := &bytes.Buffer{}
+	cmd.Stderr = errBuf
+	out, err := cmd.Output()
+	if err != nil {
+		return out, errors.New(fmt.Sprintf("%s: %s", cmd.Args, errBuf.String()), err)
+	}
+	return out, nil
+}
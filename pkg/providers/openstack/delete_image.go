@@ -42,7 +42,7 @@ func (p *OpenstackProvider) DeleteImage(id string, force bool) error {
 	}
 
 	// Update state.
-	if err := p.state.ModifyImages(func(imageList map[string]*types.Image) error {
+	if err := p.State.ModifyImages(func(imageList map[string]*types.Image) error {
 		delete(imageList, image.Id)
 		return nil
 	}); err != nil {
@@ -27,7 +27,7 @@ func (p *OpenstackProvider) DeleteInstance(id string, force bool) error {
 	}
 
 	// Update state.
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		delete(instances, instance.Id)
 		return nil
 	}); err != nil {
@@ -9,7 +9,7 @@ import (
 
 func (p *OpenstackProvider) ListImages() ([]*types.Image, error) {
 	// Return immediately if no image is managed by unik.
-	managedImages := p.state.GetImages()
+	managedImages := p.State.GetImages()
 	if len(managedImages) < 1 {
 		return []*types.Image{}, nil
 	}
@@ -12,7 +12,7 @@ import (
 
 func (p *OpenstackProvider) ListInstances() ([]*types.Instance, error) {
 	// Return immediately if no instance is managed by unik.
-	managedInstances := p.state.GetInstances()
+	managedInstances := p.State.GetInstances()
 	if len(managedInstances) < 1 {
 		return []*types.Instance{}, nil
 	}
@@ -25,7 +25,7 @@ func (p *OpenstackProvider) ListInstances() ([]*types.Instance, error) {
 	instList, err := fetchInstances(clientNova, managedInstances)
 
 	// Update state.
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		// Clear everything.
 		for k := range instances {
 			delete(instances, k)
@@ -2,6 +2,7 @@ package openstack
 
 import (
 	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"path/filepath"
 
 	"github.com/emc-advanced-dev/unik/pkg/config"
@@ -11,7 +12,7 @@ import (
 
 type OpenstackProvider struct {
 	config config.Openstack
-	state  state.State
+	common.ProviderState
 }
 
 func OpenstackStateFile() string {
@@ -21,15 +22,15 @@ func OpenstackStateFile() string {
 func NewOpenstackProvider(config config.Openstack) (*OpenstackProvider, error) {
 	logrus.Infof("openstack state file: %s", OpenstackStateFile())
 	p := &OpenstackProvider{
-		config: config,
-		state:  state.NewBasicState(OpenstackStateFile()),
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(OpenstackStateFile())},
 	}
 
 	return p, nil
 }
 
 func (p *OpenstackProvider) WithState(state state.State) *OpenstackProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 
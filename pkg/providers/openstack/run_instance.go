@@ -66,7 +66,7 @@ func (p *OpenstackProvider) RunInstance(params types.RunInstanceParams) (_ *type
 	}
 
 	// Update state.
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
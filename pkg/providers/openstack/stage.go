@@ -87,7 +87,7 @@ func (p *OpenstackProvider) Stage(params types.StageImageParams) (_ *types.Image
 	}
 
 	// Update state.
-	if err := p.state.ModifyImages(func(images map[string]*types.Image) error {
+	if err := p.State.ModifyImages(func(images map[string]*types.Image) error {
 		images[createdImage.ID] = image
 		return nil
 	}); err != nil {
@@ -20,5 +20,5 @@ func (p *PhotonProvider) DeleteImage(id string, force bool) error {
 		return errors.New("Delete image", err)
 	}
 
-	return p.state.RemoveImage(image)
+	return p.State.RemoveImage(image)
 }
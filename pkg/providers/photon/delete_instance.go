@@ -27,5 +27,5 @@ func (p *PhotonProvider) DeleteInstance(id string, force bool) error {
 	if err != nil {
 		return errors.New("Delete vm", err)
 	}
-	return p.state.RemoveInstance(instance)
+	return p.State.RemoveInstance(instance)
 }
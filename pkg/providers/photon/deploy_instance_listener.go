@@ -168,7 +168,7 @@ func (p *PhotonProvider) runInstanceListener(image *types.Image) (err error) {
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
@@ -7,12 +7,12 @@ import (
 )
 
 func (p *PhotonProvider) ListInstances() ([]*types.Instance, error) {
-	if len(p.state.GetInstances()) < 1 {
+	if len(p.State.GetInstances()) < 1 {
 		return []*types.Instance{}, nil
 	}
 
 	var instances []*types.Instance
-	for _, instance := range p.state.GetInstances() {
+	for _, instance := range p.State.GetInstances() {
 
 		vm, err := p.client.VMs.Get(instance.Id)
 		if err != nil {
@@ -34,7 +34,7 @@ func (p *PhotonProvider) ListInstances() ([]*types.Instance, error) {
 			instance.State = types.InstanceState_Stopped
 			break
 		}
-		err = p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+		err = p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 			instances[instance.Id] = instance
 			return nil
 		})
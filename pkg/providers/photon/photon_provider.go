@@ -9,13 +9,14 @@ import (
 	"github.com/emc-advanced-dev/pkg/errors"
 
 	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/state"
 	"github.com/vmware/photon-controller-go-sdk/photon"
 )
 
 type PhotonProvider struct {
-	config    config.Photon
-	state     state.State
+	config config.Photon
+	common.ProviderState
 	u         *url.URL
 	client    *photon.Client
 	projectId string
@@ -44,8 +45,8 @@ func NewPhotonProvider(config config.Photon) (*PhotonProvider, error) {
 	os.MkdirAll(photonVolumesDirectory(), 0755)
 
 	p := &PhotonProvider{
-		config: config,
-		state:  state.NewBasicState(PhotonStateFile()),
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(PhotonStateFile())},
 	}
 
 	p.client = photon.NewClient(p.config.PhotonURL, "", nil)
@@ -63,7 +64,7 @@ func NewPhotonProvider(config config.Photon) (*PhotonProvider, error) {
 }
 
 func (p *PhotonProvider) WithState(state state.State) *PhotonProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 
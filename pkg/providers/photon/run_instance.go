@@ -124,7 +124,7 @@ func (p *PhotonProvider) RunInstance(params types.RunInstanceParams) (_ *types.I
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
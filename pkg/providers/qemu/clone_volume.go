@@ -0,0 +1,43 @@
+package qemu
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// CloneVolume copies the qcow2 file backing a volume. on btrfs the copy is a
+// snapshot of the volume's subvolume; elsewhere it is made with cp --reflink=auto,
+// so it is a copy-on-write clone on filesystems that support it (xfs) and a full
+// copy everywhere else.
+func (p *QemuProvider) CloneVolume(id, newName string) (*types.Volume, error) {
+	source, err := p.GetVolume(id)
+	if err != nil {
+		return nil, errors.New("retrieving volume "+id, err)
+	}
+	if _, err := p.GetVolume(newName); err == nil {
+		return nil, errors.New("volume "+newName+" already exists", nil)
+	}
+
+	if err := p.volumeBackend.CloneVolumeDir(filepath.Dir(getVolumePath(source.Name)), filepath.Dir(getVolumePath(newName))); err != nil {
+		return nil, errors.New("copying volume files for "+source.Name, err)
+	}
+
+	volume := &types.Volume{
+		Id:             newName,
+		Name:           newName,
+		SizeMb:         source.SizeMb,
+		Attachment:     "",
+		Infrastructure: types.Infrastructure_QEMU,
+		Created:        time.Now(),
+	}
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		volumes[volume.Id] = volume
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying volume map in state", err)
+	}
+	return volume, nil
+}
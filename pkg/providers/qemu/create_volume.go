@@ -15,9 +15,12 @@ func (p *QemuProvider) CreateVolume(params types.CreateVolumeParams) (_ *types.V
 	if _, volumeErr := p.GetImage(params.Name); volumeErr == nil {
 		return nil, errors.New("volume already exists", nil)
 	}
+	if params.Mode.IsFolder() {
+		return p.createFolderVolume(params)
+	}
 
 	volumePath := getVolumePath(params.Name)
-	if err := os.MkdirAll(filepath.Dir(volumePath), 0755); err != nil {
+	if err := p.volumeBackend.CreateVolumeDir(filepath.Dir(volumePath)); err != nil {
 		return nil, errors.New("creating directory for volume file", err)
 	}
 	defer func() {
@@ -25,7 +28,7 @@ func (p *QemuProvider) CreateVolume(params types.CreateVolumeParams) (_ *types.V
 			if params.NoCleanup {
 				logrus.Warnf("because --no-cleanup flag was provided, not cleaning up failed volume %s at %s", params.Name, volumePath)
 			} else {
-				os.RemoveAll(filepath.Dir(volumePath))
+				p.volumeBackend.DeleteVolumeDir(filepath.Dir(volumePath))
 			}
 		}
 	}()
@@ -49,7 +52,7 @@ func (p *QemuProvider) CreateVolume(params types.CreateVolumeParams) (_ *types.V
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volumes[volume.Id] = volume
 		return nil
 	}); err != nil {
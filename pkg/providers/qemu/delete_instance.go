@@ -1,5 +1,27 @@
 package qemu
 
+import (
+	"os"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
 func (p *QemuProvider) DeleteInstance(id string, force bool) error {
-	return p.StopInstance(id)
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return errors.New("retrieving instance "+id, err)
+	}
+	if !qemuRunning(instance) {
+		// qemu is not running
+		if err := p.State.RemoveInstance(instance); err != nil {
+			return err
+		}
+	} else if err := p.StopInstance(instance.Id); err != nil {
+		return err
+	}
+	os.Remove(getNoCloudIsoPath(instance.Name))
+	os.Remove(getQemuArgsPath(instance.Name))
+	os.Remove(getRunParamsPath(instance.Name))
+	removeVirtiofsSockets(instance.Name)
+	return nil
 }
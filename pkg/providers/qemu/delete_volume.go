@@ -2,7 +2,7 @@ package qemu
 
 import (
 	"github.com/emc-advanced-dev/pkg/errors"
-	"os"
+	"path/filepath"
 )
 
 func (p *QemuProvider) DeleteVolume(id string, force bool) error {
@@ -19,10 +19,10 @@ func (p *QemuProvider) DeleteVolume(id string, force bool) error {
 			return errors.New("volume "+volume.Id+" is attached to instance."+volume.Attachment+", try again with --force or detach volume first", err)
 		}
 	}
-	volumePath := getVolumePath(volume.Name)
-	err = os.Remove(volumePath)
+	volumeDir := filepath.Dir(getVolumePath(volume.Name))
+	err = p.volumeBackend.DeleteVolumeDir(volumeDir)
 	if err != nil {
-		return errors.New("could not delete volume at path "+volumePath, err)
+		return errors.New("could not delete volume at path "+volumeDir, err)
 	}
-	return p.state.RemoveVolume(volume)
+	return p.State.RemoveVolume(volume)
 }
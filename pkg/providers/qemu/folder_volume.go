@@ -0,0 +1,101 @@
+package qemu
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// getSharedFolderPath is the directory a folder (virtiofs or 9p) volume shares
+// with instances
+func getSharedFolderPath(volumeName string) string {
+	return filepath.Join(qemuVolumesDirectory(), volumeName, "share")
+}
+
+// folderShare is a folder volume mounted on an instance. the guest mounts it by
+// its tag, the name of the volume
+type folderShare struct {
+	volumeName string
+	readOnly   bool
+}
+
+func (p *QemuProvider) createFolderVolume(params types.CreateVolumeParams) (_ *types.Volume, err error) {
+	volumeDir := filepath.Dir(getVolumePath(params.Name))
+	if err := p.volumeBackend.CreateVolumeDir(volumeDir); err != nil {
+		return nil, errors.New("creating directory for volume", err)
+	}
+	defer func() {
+		if err != nil {
+			if params.NoCleanup {
+				logrus.Warnf("because --no-cleanup flag was provided, not cleaning up failed volume %s at %s", params.Name, volumeDir)
+			} else {
+				p.volumeBackend.DeleteVolumeDir(volumeDir)
+			}
+		}
+	}()
+	sharePath := getSharedFolderPath(params.Name)
+	logrus.WithField("data", params.ImagePath).Infof("creating %s volume in %s", params.Mode, sharePath)
+	if err := unikos.CopyDir(params.ImagePath, sharePath); err != nil {
+		return nil, errors.New("copying data to shared directory", err)
+	}
+	size, err := unikos.DirSize(sharePath)
+	if err != nil {
+		return nil, errors.New("measuring shared directory", err)
+	}
+
+	volume := &types.Volume{
+		Id:             params.Name,
+		Name:           params.Name,
+		SizeMb:         size >> 20,
+		Attachment:     "",
+		Infrastructure: types.Infrastructure_QEMU,
+		Created:        time.Now(),
+		Mode:           params.Mode,
+	}
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		volumes[volume.Id] = volume
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying volume map in state", err)
+	}
+	return volume, nil
+}
+
+// folderShares returns the folder volumes mounted on an instance, by the mode
+// they are shared in: the VolumeMode of params, or else their own. the shares
+// of each mode are in the order of their mount points
+func (p *QemuProvider) folderShares(params types.RunInstanceParams) (map[types.VolumeMode][]folderShare, error) {
+	mntPoints := []string{}
+	modes := make(map[string]types.VolumeMode)
+	for mntPoint, volumeId := range params.MntPointsToVolumeIds {
+		volume, err := p.GetVolume(volumeId)
+		if err != nil {
+			return nil, err
+		}
+		if !volume.IsFolder() {
+			continue
+		}
+		mntPoints = append(mntPoints, mntPoint)
+		modes[mntPoint] = volume.Mode
+		if params.VolumeMode != "" {
+			modes[mntPoint] = params.VolumeMode
+		}
+	}
+	sort.Strings(mntPoints)
+	shares := make(map[types.VolumeMode][]folderShare)
+	for _, mntPoint := range mntPoints {
+		share := folderShare{volumeName: params.MntPointsToVolumeIds[mntPoint]}
+		for _, readOnlyMntPoint := range params.ReadOnlyMntPoints {
+			if readOnlyMntPoint == mntPoint {
+				share.readOnly = true
+			}
+		}
+		shares[modes[mntPoint]] = append(shares[modes[mntPoint]], share)
+	}
+	return shares, nil
+}
@@ -6,6 +6,12 @@ import (
 
 func (p *QemuProvider) GetConfig() providers.ProviderConfig {
 	return providers.ProviderConfig{
-		UsePartitionTables: true,
+		UsePartitionTables:      true,
+		SupportsPortMappings:    true,
+		SupportsRuntimeCmdline:  true,
+		SupportsReadOnlyVolumes: true,
+		SupportsCloudInit:       true,
+		SupportsIPv6:            true,
+		SupportsStaticIP:        true,
 	}
 }
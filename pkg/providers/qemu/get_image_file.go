@@ -0,0 +1,24 @@
+package qemu
+
+import (
+	"os"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *QemuProvider) GetImageFile(id string) (string, types.ImageFormat, error) {
+	image, err := p.GetImage(id)
+	if err != nil {
+		return "", "", errors.New("retrieving image "+id, err)
+	}
+	// images booted with -kernel have their boot volume converted to qcow2 at stage time,
+	// images with a classic bootloader are kept as they were built
+	if image.StageSpec.ImageFormat == types.ImageFormat_Kernel {
+		return getKernelPath(image.Name), types.ImageFormat_Kernel, nil
+	}
+	if _, err := os.Stat(getKernelPath(image.Name)); err == nil {
+		return getImagePath(image.Name), types.ImageFormat_QCOW2, nil
+	}
+	return getImagePath(image.Name), types.ImageFormat_RAW, nil
+}
@@ -0,0 +1,157 @@
+package qemu
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// clock ticks per second of the cpu times in /proc/PID/stat. USER_HZ is 100 on
+// the architectures qemu runs on
+const userHz = 100
+
+// GetInstanceMetrics samples the cpu time of each vcpu thread of the qemu of an
+// instance, which qmp lists with query-cpus-fast, and the memory of its
+// balloon (query-balloon) and resident set on the daemon host
+func (p *QemuProvider) GetInstanceMetrics(id string) (*types.InstanceMetrics, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+id, err)
+	}
+	pid, err := strconv.Atoi(instance.Id)
+	if err != nil {
+		return nil, errors.New("invalid instance id (should be qemu pid)", err)
+	}
+	var client QMPClient
+	if err := client.Connect(getQmpSocketPath(instance.Name)); err != nil {
+		return nil, err
+	}
+	defer client.Disconnect()
+
+	metrics := &types.InstanceMetrics{Time: time.Now(), VCPUs: []types.VCPUMetrics{}}
+	threads, err := vcpuThreads(&client)
+	if err != nil {
+		return nil, err
+	}
+	indexes := []int{}
+	for index := range threads {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		cpuTime, err := threadCPUTime(pid, threads[index])
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("reading cpu time of vcpu %d", index), err)
+		}
+		metrics.VCPUs = append(metrics.VCPUs, types.VCPUMetrics{Index: index, CPUTime: cpuTime})
+	}
+
+	if result, err := client.Execute("query-balloon", nil); err == nil {
+		var balloon struct {
+			Actual int64 `json:"actual"`
+		}
+		if err := json.Unmarshal(result, &balloon); err != nil {
+			return nil, errors.New("parsing result of query-balloon", err)
+		}
+		metrics.MemoryBytes = balloon.Actual
+	} else if params, err := p.RunParams(instance.Id); err == nil {
+		// instances without a balloon device have the memory they were run with
+		metrics.MemoryBytes = int64(params.InstanceMemory) << 20
+	}
+	metrics.MemoryUsedBytes, err = processRSS(pid)
+	if err != nil {
+		logrus.WithError(err).Warnf("could not read the memory used by the qemu of instance %s", instance.Name)
+	}
+	return metrics, nil
+}
+
+// vcpuThreads returns the host thread of each vcpu, by cpu index. qemu older
+// than 2.12 has query-cpus instead of query-cpus-fast
+func vcpuThreads(client *QMPClient) (map[int]int, error) {
+	threads := make(map[int]int)
+	if result, err := client.Execute("query-cpus-fast", nil); err == nil {
+		var cpus []struct {
+			Index    int `json:"cpu-index"`
+			ThreadId int `json:"thread-id"`
+		}
+		if err := json.Unmarshal(result, &cpus); err != nil {
+			return nil, errors.New("parsing result of query-cpus-fast", err)
+		}
+		for _, cpu := range cpus {
+			threads[cpu.Index] = cpu.ThreadId
+		}
+		return threads, nil
+	}
+	result, err := client.Execute("query-cpus", nil)
+	if err != nil {
+		return nil, err
+	}
+	var cpus []struct {
+		Index    int `json:"CPU"`
+		ThreadId int `json:"thread_id"`
+	}
+	if err := json.Unmarshal(result, &cpus); err != nil {
+		return nil, errors.New("parsing result of query-cpus", err)
+	}
+	for _, cpu := range cpus {
+		threads[cpu.Index] = cpu.ThreadId
+	}
+	return threads, nil
+}
+
+// threadCPUTime returns the user and system time thread threadId of process pid
+// ran for, from its /proc stat
+func threadCPUTime(pid, threadId int) (time.Duration, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/task/%d/stat", pid, threadId))
+	if err != nil {
+		return 0, err
+	}
+	// the command name, in parentheses, may have spaces; the fields after it
+	// start with the state, field 3, so utime (14) and stime (15) are 11 and 12
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 13 {
+		return 0, errors.New("unexpected stat of thread "+strconv.Itoa(threadId)+": "+stat, nil)
+	}
+	var ticks int64
+	for _, field := range fields[11:13] {
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return 0, errors.New("parsing cpu time of thread "+strconv.Itoa(threadId), err)
+		}
+		ticks += n
+	}
+	return time.Duration(ticks) * time.Second / userHz, nil
+}
+
+// processRSS returns the resident set of process pid, from its /proc status
+func processRSS(pid int) (int64, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// VmRSS:	  123456 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "VmRSS:" && fields[2] == "kB" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, errors.New("parsing VmRSS of process "+strconv.Itoa(pid), err)
+			}
+			return kb << 10, nil
+		}
+	}
+	return 0, errors.New("no VmRSS in the status of process "+strconv.Itoa(pid), scanner.Err())
+}
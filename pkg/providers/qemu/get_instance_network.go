@@ -0,0 +1,156 @@
+package qemu
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// how long to wait for a guest agent. most unikernels do not run one, so this is short
+const guestAgentTimeout = 2 * time.Second
+
+// getGuestAgentSocketPath is the unix socket of the virtio serial port a qemu
+// guest agent in the instance would listen on
+func getGuestAgentSocketPath(instanceName string) string {
+	return filepath.Join(qemuInstancesDirectory(), instanceName+".qga")
+}
+
+// guestAgentArgs attach the virtio serial port of the guest agent to an instance
+func guestAgentArgs(instanceName string) []string {
+	return []string{
+		"-chardev", "socket,path=" + getGuestAgentSocketPath(instanceName) + ",server,nowait,id=qga0",
+		"-device", "virtio-serial",
+		"-device", "virtserialport,chardev=qga0,name=org.qemu.guest_agent.0",
+	}
+}
+
+// GetInstanceNetwork asks the guest agent of the instance for its interfaces. for
+// instances without a guest agent, it lists the nic qemu emulates, with the mac
+// address qemu reports over qmp and the ip address unik knows of
+func (p *QemuProvider) GetInstanceNetwork(id string) ([]types.NetworkInterface, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+id, err)
+	}
+	interfaces, err := guestNetworkInterfaces(getGuestAgentSocketPath(instance.Name))
+	if err == nil {
+		return interfaces, nil
+	}
+	logrus.WithError(err).Debugf("no guest agent answered for instance %s, listing the nics of qemu", instance.Name)
+
+	var client QMPClient
+	if err := client.Connect(getQmpSocketPath(instance.Name)); err != nil {
+		return nil, err
+	}
+	defer client.Disconnect()
+	result, err := client.Execute("query-rx-filter", nil)
+	if err != nil {
+		return nil, err
+	}
+	var filters []struct {
+		Name    string `json:"name"`
+		MainMac string `json:"main-mac"`
+	}
+	if err := json.Unmarshal(result, &filters); err != nil {
+		return nil, errors.New("parsing result of query-rx-filter", err)
+	}
+	interfaces = []types.NetworkInterface{}
+	for _, filter := range filters {
+		addresses := []string{}
+		// instances have a single nic, whose address is the one unik knows
+		if instance.IpAddress != "" && len(filters) == 1 {
+			addresses = append(addresses, instance.IpAddress)
+		}
+		interfaces = append(interfaces, types.NetworkInterface{
+			Name:        filter.Name,
+			MACAddress:  filter.MainMac,
+			IPAddresses: addresses,
+		})
+	}
+	return interfaces, nil
+}
+
+// guestAgentSession is a connection to a guest agent, synced so the responses
+// read from it answer the commands sent on it
+type guestAgentSession struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	decoder *json.Decoder
+}
+
+// openGuestAgent connects to the guest agent at socketPath, giving up on the
+// connection once timeout elapsed
+func openGuestAgent(socketPath string, timeout time.Duration) (*guestAgentSession, error) {
+	conn, err := net.DialTimeout("unix", socketPath, guestAgentTimeout)
+	if err != nil {
+		return nil, errors.New("connecting to guest agent socket "+socketPath, err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	session := &guestAgentSession{conn: conn, encoder: json.NewEncoder(conn), decoder: json.NewDecoder(conn)}
+
+	// guest-sync makes sure the response read next is not one left over from an
+	// earlier client that gave up: the agent returns the id it is sent
+	syncId := rand.Int63()
+	result, err := session.execute("guest-sync", map[string]int64{"id": syncId})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var returnedId int64
+	if err := json.Unmarshal(result, &returnedId); err != nil || returnedId != syncId {
+		conn.Close()
+		return nil, errors.New("guest agent did not return the id of guest-sync", err)
+	}
+	return session, nil
+}
+
+func (s *guestAgentSession) execute(cmd string, arguments interface{}) (json.RawMessage, error) {
+	return qmpExecute(s.encoder, s.decoder, cmd, arguments)
+}
+
+func (s *guestAgentSession) close() {
+	s.conn.Close()
+}
+
+// guestNetworkInterfaces runs guest-network-get-interfaces on the guest agent at socketPath
+func guestNetworkInterfaces(socketPath string) ([]types.NetworkInterface, error) {
+	session, err := openGuestAgent(socketPath, guestAgentTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer session.close()
+
+	result, err := session.execute("guest-network-get-interfaces", nil)
+	if err != nil {
+		return nil, err
+	}
+	var guestInterfaces []struct {
+		Name            string `json:"name"`
+		HardwareAddress string `json:"hardware-address"`
+		IpAddresses     []struct {
+			IpAddress string `json:"ip-address"`
+		} `json:"ip-addresses"`
+	}
+	if err := json.Unmarshal(result, &guestInterfaces); err != nil {
+		return nil, errors.New("parsing result of guest-network-get-interfaces", err)
+	}
+	interfaces := []types.NetworkInterface{}
+	for _, guestInterface := range guestInterfaces {
+		addresses := []string{}
+		for _, address := range guestInterface.IpAddresses {
+			addresses = append(addresses, address.IpAddress)
+		}
+		interfaces = append(interfaces, types.NetworkInterface{
+			Name:        guestInterface.Name,
+			MACAddress:  guestInterface.HardwareAddress,
+			IPAddresses: addresses,
+		})
+	}
+	return interfaces, nil
+}
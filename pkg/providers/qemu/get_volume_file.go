@@ -0,0 +1,17 @@
+package qemu
+
+import (
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *QemuProvider) GetVolumeFile(id string) (string, types.ImageFormat, error) {
+	volume, err := p.GetVolume(id)
+	if err != nil {
+		return "", "", errors.New("retrieving volume "+id, err)
+	}
+	if volume.IsFolder() {
+		return "", "", errors.New("volume "+volume.Name+" is a "+string(volume.Mode)+" volume, a directory rather than an image file", nil)
+	}
+	return getVolumePath(volume.Name), types.ImageFormat_QCOW2, nil
+}
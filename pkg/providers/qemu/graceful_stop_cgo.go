@@ -0,0 +1,63 @@
+//go:build cgo
+// +build cgo
+
+package qemu
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// how often GracefulStop checks whether qemu has exited
+const gracefulStopPollInterval = 500 * time.Millisecond
+
+// GracefulStop powers off an instance by pressing its ACPI power button through
+// QMP, so it can flush its buffers and unmount its filesystems, and kills it if
+// it has not shut down by the end of timeout
+func (p *QemuProvider) GracefulStop(id string, timeout time.Duration) error {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return errors.New("retrieving instance "+id, err)
+	}
+	if !qemuRunning(instance) {
+		return p.killInstance(instance)
+	}
+	pid, err := strconv.Atoi(instance.Id)
+	if err != nil {
+		return errors.New("invalid instance id (should be qemu pid)", err)
+	}
+
+	p.exiting.Store(instance.Id, true)
+	defer p.exiting.Delete(instance.Id)
+	socketPath := getQmpSocketPath(instance.Name)
+	if err := powerdown(socketPath); err != nil {
+		logrus.WithError(err).Warnf("could not power off instance %s through qmp, forcing it to stop", instance.Name)
+		return p.killInstance(instance)
+	}
+	logrus.Debugf("sent acpi power off to instance %s, waiting up to %s for it to shut down", instance.Name, timeout)
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if qemuExited(pid) {
+			os.Remove(socketPath)
+			return p.State.RemoveInstance(instance)
+		}
+		time.Sleep(gracefulStopPollInterval)
+	}
+	logrus.Warnf("instance %s did not shut down within %s, forcing it to stop", instance.Name, timeout)
+	return p.killInstance(instance)
+}
+
+// powerdown sends system_powerdown to the qemu listening on socketPath
+func powerdown(socketPath string) error {
+	var client QMPClient
+	if err := client.Connect(socketPath); err != nil {
+		return err
+	}
+	defer client.Disconnect()
+	return client.SendCommand("system_powerdown")
+}
@@ -0,0 +1,14 @@
+//go:build !cgo
+// +build !cgo
+
+package qemu
+
+import (
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+func (p *QemuProvider) GracefulStop(id string, timeout time.Duration) error {
+	return errors.New("Stopping qemu instance is not supported without cgo", nil)
+}
@@ -0,0 +1,82 @@
+package qemu
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// how often guest-exec-status is asked whether a command exited
+const guestExecPollInterval = 200 * time.Millisecond
+
+// GuestExec runs a command in the instance with guest-exec of its guest agent.
+// only unikernels that run a qemu guest agent (and have the command) can do it
+func (p *QemuProvider) GuestExec(id, path string, args []string, timeout time.Duration) (*types.ExecResult, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+id, err)
+	}
+	return guestExec(getGuestAgentSocketPath(instance.Name), path, args, timeout)
+}
+
+func guestExec(socketPath, path string, args []string, timeout time.Duration) (*types.ExecResult, error) {
+	session, err := openGuestAgent(socketPath, guestAgentTimeout+timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer session.close()
+
+	result, err := session.execute("guest-exec", map[string]interface{}{
+		"path":           path,
+		"arg":            args,
+		"capture-output": timeout > 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var started struct {
+		Pid int `json:"pid"`
+	}
+	if err := json.Unmarshal(result, &started); err != nil {
+		return nil, errors.New("parsing result of guest-exec", err)
+	}
+	if timeout == 0 {
+		return nil, nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		result, err := session.execute("guest-exec-status", map[string]int{"pid": started.Pid})
+		if err != nil {
+			return nil, err
+		}
+		var status struct {
+			Exited   bool   `json:"exited"`
+			ExitCode int    `json:"exitcode"`
+			OutData  string `json:"out-data"`
+			ErrData  string `json:"err-data"`
+		}
+		if err := json.Unmarshal(result, &status); err != nil {
+			return nil, errors.New("parsing result of guest-exec-status", err)
+		}
+		if status.Exited {
+			stdout, err := base64.StdEncoding.DecodeString(status.OutData)
+			if err != nil {
+				return nil, errors.New("decoding output of "+path, err)
+			}
+			stderr, err := base64.StdEncoding.DecodeString(status.ErrData)
+			if err != nil {
+				return nil, errors.New("decoding error output of "+path, err)
+			}
+			return &types.ExecResult{ExitCode: status.ExitCode, Stdout: string(stdout), Stderr: string(stderr)}, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New(fmt.Sprintf("%s did not exit within %v", path, timeout), nil)
+		}
+		time.Sleep(guestExecPollInterval)
+	}
+}
@@ -12,22 +12,39 @@ import (
 )
 
 func (p *QemuProvider) ListInstances() ([]*types.Instance, error) {
-	if len(p.state.GetInstances()) < 1 {
+	if len(p.State.GetInstances()) < 1 {
 		return []*types.Instance{}, nil
 	}
 
 	var instances []*types.Instance
-	for _, instance := range p.state.GetInstances() {
+	for _, instance := range p.State.GetInstances() {
+		// qemu has exited, and runs again when the instance is resumed or started
+		if !qemuRunning(instance) {
+			instances = append(instances, instance)
+			continue
+		}
+		if _, ok := p.exiting.Load(instance.Id); ok {
+			instances = append(instances, instance)
+			continue
+		}
 		pid, err := strconv.Atoi(instance.Id)
 		if err != nil {
 			logrus.WithField("instance", instance).Warn("invalid pid - removing instance")
-			p.state.RemoveInstance(instance)
+			p.State.RemoveInstance(instance)
 			continue
 		}
-		if err := detectInstance(pid); err != nil {
-			logrus.WithField("instance", instance).Debug("Instance is not running; removing")
-			p.state.RemoveInstance(instance)
-			continue
+		// the instance stays in the state, so the daemon can restart it
+		if exited, state := qemuExitState(pid); exited {
+			logrus.WithField("instance", instance).Warnf("qemu of instance %s has exited, marking it %s", instance.Name, state)
+			if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+				if stored, ok := instances[instance.Id]; ok {
+					stored.State = state
+				}
+				return nil
+			}); err != nil {
+				return nil, errors.New("modifying instance map in state", err)
+			}
+			instance.State = state
 		}
 		instances = append(instances, instance)
 	}
@@ -35,6 +52,16 @@ func (p *QemuProvider) ListInstances() ([]*types.Instance, error) {
 	return instances, nil
 }
 
+// qemuRunning is false for instances whose qemu has exited, so their id is no
+// longer the pid of a qemu process
+func qemuRunning(instance *types.Instance) bool {
+	switch instance.State {
+	case types.InstanceState_Suspended, types.InstanceState_Stopped, types.InstanceState_Error:
+		return false
+	}
+	return true
+}
+
 func detectInstance(pid int) error {
 	process, err := os.FindProcess(pid)
 	if err != nil {
@@ -45,3 +72,24 @@ func detectInstance(pid int) error {
 	}
 	return nil
 }
+
+// qemuExited reaps qemu if the daemon started it and it has exited, and
+// otherwise checks whether the process is still there
+func qemuExited(pid int) bool {
+	exited, _ := qemuExitState(pid)
+	return exited
+}
+
+// qemuExitState is qemuExited, with the state qemu left its instance in: stopped
+// if qemu exited with status 0, as when the guest powers off, and error if it
+// failed or was killed. qemu started before the daemon was restarted is not a
+// child of the daemon, so how it exited is unknown; its instances are stopped
+func qemuExitState(pid int) (bool, types.InstanceState) {
+	if child, exited, state := reapQemu(pid); child {
+		return exited, state
+	}
+	if detectInstance(pid) != nil {
+		return true, types.InstanceState_Stopped
+	}
+	return false, ""
+}
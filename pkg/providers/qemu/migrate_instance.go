@@ -0,0 +1,140 @@
+package qemu
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// how often MigrateInstance asks qemu how far the migration got
+const migratePollInterval = time.Second
+
+type migrationStatus struct {
+	Status    string `json:"status"`
+	ErrorDesc string `json:"error-desc"`
+	Ram       *struct {
+		Transferred int64 `json:"transferred"`
+		Total       int64 `json:"total"`
+	} `json:"ram"`
+}
+
+// RunParams returns the parameters the instance was run with. the instance it is
+// migrated to is run with the same, so that qemu has the same devices on both ends
+func (p *QemuProvider) RunParams(id string) (types.RunInstanceParams, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return types.RunInstanceParams{}, errors.New("retrieving instance "+id, err)
+	}
+	data, err := ioutil.ReadFile(getRunParamsPath(instance.Name))
+	if err != nil {
+		return types.RunInstanceParams{}, errors.New("reading run parameters of instance "+instance.Name, err)
+	}
+	var params types.RunInstanceParams
+	if err := json.Unmarshal(data, &params); err != nil {
+		return types.RunInstanceParams{}, errors.New("parsing run parameters of instance "+instance.Name, err)
+	}
+	return params, nil
+}
+
+// MigrateInstance sends the state of a running instance to the qemu waiting for
+// it at uri (e.g. tcp:HOST:PORT) and waits up to timeout for the migration to
+// complete. the instance keeps running while its memory is copied. once qemu on
+// the other end has taken over, qemu here quits and the instance is removed from
+// the state. on failure the migration is cancelled and the instance keeps running
+func (p *QemuProvider) MigrateInstance(id, uri string, timeout time.Duration) error {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return errors.New("retrieving instance "+id, err)
+	}
+	if instance.State == types.InstanceState_Suspended {
+		return errors.New("instance "+instance.Name+" is suspended, resume it first", nil)
+	}
+	if !qemuRunning(instance) {
+		return errors.New("instance "+instance.Name+" is not running", nil)
+	}
+	pid, err := strconv.Atoi(instance.Id)
+	if err != nil {
+		return errors.New("invalid instance id (should be qemu pid)", err)
+	}
+
+	var client QMPClient
+	if err := client.Connect(getQmpSocketPath(instance.Name)); err != nil {
+		return err
+	}
+	defer client.Disconnect()
+	if _, err := client.Execute("migrate", map[string]string{"uri": uri}); err != nil {
+		return err
+	}
+	if err := waitForMigration(&client, instance.Name, timeout); err != nil {
+		if _, cancelErr := client.Execute("migrate_cancel", nil); cancelErr != nil {
+			logrus.WithError(cancelErr).Warnf("failed to cancel migration of instance %s", instance.Name)
+		}
+		// qemu pauses the instance for the last of its memory
+		if err := client.SendCommand("cont"); err != nil {
+			logrus.WithError(err).Warnf("failed to continue instance %s", instance.Name)
+		}
+		return err
+	}
+	logrus.Infof("instance %s migrated to %s", instance.Name, uri)
+
+	p.exiting.Store(instance.Id, true)
+	defer p.exiting.Delete(instance.Id)
+	if err := client.SendCommand("quit"); err != nil {
+		logrus.WithError(err).Debugf("no answer to quit from instance %s", instance.Name)
+	}
+	for deadline := time.Now().Add(quitTimeout); !qemuExited(pid); time.Sleep(restartPollInterval) {
+		if time.Now().After(deadline) {
+			logrus.Warnf("qemu of migrated instance %s did not exit", instance.Name)
+			break
+		}
+	}
+	os.Remove(getQmpSocketPath(instance.Name))
+	os.Remove(getGuestAgentSocketPath(instance.Name))
+	os.Remove(getNoCloudIsoPath(instance.Name))
+	os.Remove(getQemuArgsPath(instance.Name))
+	os.Remove(getRunParamsPath(instance.Name))
+	return p.State.RemoveInstance(instance)
+}
+
+func waitForMigration(client *QMPClient, instanceName string, timeout time.Duration) error {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(migratePollInterval) {
+		result, err := client.Execute("query-migrate", nil)
+		if err != nil {
+			return err
+		}
+		var status migrationStatus
+		if err := json.Unmarshal(result, &status); err != nil {
+			return errors.New("parsing result of query-migrate", err)
+		}
+		switch status.Status {
+		case "completed":
+			return nil
+		case "failed", "cancelled":
+			return errors.New("migration "+status.Status+": "+status.ErrorDesc, nil)
+		}
+		if status.Ram != nil {
+			logrus.Debugf("migrating instance %s: %d of %d bytes of memory sent", instanceName, status.Ram.Transferred, status.Ram.Total)
+		}
+	}
+	return errors.New("migration did not complete within "+timeout.String(), nil)
+}
+
+func saveRunParams(params types.RunInstanceParams) error {
+	// the instance runs the regular way once it has been migrated
+	params.IncomingMigration = ""
+	data, err := json.Marshal(params)
+	if err != nil {
+		return errors.New("marshalling run parameters", err)
+	}
+	// user data may hold secrets
+	if err := ioutil.WriteFile(getRunParamsPath(params.Name), data, 0600); err != nil {
+		return errors.New("saving run parameters of instance "+params.Name, err)
+	}
+	return nil
+}
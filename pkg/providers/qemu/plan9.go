@@ -0,0 +1,21 @@
+package qemu
+
+import "fmt"
+
+// plan9QemuArgs export the folder of each share from qemu's own 9p server, on
+// a virtio-9p device. passthrough keeps the owners and permissions of the host
+// files, so the daemon needs to be able to change them
+func plan9QemuArgs(shares []folderShare) []string {
+	var args []string
+	for i, share := range shares {
+		fsdev := fmt.Sprintf("local,id=fsdev%d,path=%s,security_model=passthrough", i, getSharedFolderPath(share.volumeName))
+		if share.readOnly {
+			fsdev += ",readonly=on"
+		}
+		args = append(args,
+			"-fsdev", fsdev,
+			"-device", fmt.Sprintf("virtio-9p-pci,fsdev=fsdev%d,mount_tag=%s", i, share.volumeName),
+		)
+	}
+	return args
+}
@@ -43,7 +43,7 @@ func (p *QemuProvider) PullImage(params types.PullImagePararms) error {
 		return errors.New("renaming tmp image to "+imagePath, err)
 	}
 
-	if err := p.state.ModifyImages(func(images map[string]*types.Image) error {
+	if err := p.State.ModifyImages(func(images map[string]*types.Image) error {
 		images[image.Name] = image
 		return nil
 	}); err != nil {
@@ -3,17 +3,31 @@ package qemu
 import (
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/state"
 )
 
 var debuggerTargetImageName string
 
+// how long StopInstance waits for an instance to shut down, unless the config sets stop_timeout
+const defaultStopTimeout = 10 * time.Second
+
 type QemuProvider struct {
 	config config.Qemu
-	state  state.State
+	common.ProviderState
+	volumeBackend common.VolumeBackend
+	stopTimeout   time.Duration
+	// vnc displays of instances get a port from this range, and listen on vncListen
+	vncFirstPort, vncLastPort int
+	vncListen                 string
+	// ids of instances whose qemu is being told to exit, which ListInstances
+	// leaves alone until they are removed or suspended
+	exiting sync.Map
 }
 
 func QemuStateFile() string {
@@ -29,7 +43,7 @@ func qemuInstancesDirectory() string {
 }
 
 func qemuVolumesDirectory() string {
-	return filepath.Join(config.Internal.UnikHome, "qemu/volumes/")
+	return filepath.Join(config.Internal.VolumesHome(), "qemu/volumes/")
 }
 
 func NewQemuProvider(config config.Qemu) (*QemuProvider, error) {
@@ -46,16 +60,43 @@ func NewQemuProvider(config config.Qemu) (*QemuProvider, error) {
 		return nil, errors.New("establishing debugger tcp listener", err)
 	}
 
+	stopTimeout := defaultStopTimeout
+	if config.StopTimeout != "" {
+		var err error
+		stopTimeout, err = time.ParseDuration(config.StopTimeout)
+		if err != nil || stopTimeout < 0 {
+			return nil, errors.New("invalid stop_timeout "+config.StopTimeout, err)
+		}
+	}
+
+	vncPorts := config.VncPorts
+	if vncPorts == "" {
+		vncPorts = defaultVncPorts
+	}
+	vncFirstPort, vncLastPort, err := parseVncPorts(vncPorts)
+	if err != nil {
+		return nil, errors.New("invalid vnc_ports", err)
+	}
+	vncListen := config.VncListen
+	if vncListen == "" {
+		vncListen = defaultVncListen
+	}
+
 	p := &QemuProvider{
-		config: config,
-		state:  state.NewBasicState(QemuStateFile()),
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(QemuStateFile())},
+		volumeBackend: common.NewVolumeBackend(qemuVolumesDirectory()),
+		stopTimeout:   stopTimeout,
+		vncFirstPort:  vncFirstPort,
+		vncLastPort:   vncLastPort,
+		vncListen:     vncListen,
 	}
 
 	return p, nil
 }
 
 func (p *QemuProvider) WithState(state state.State) *QemuProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 
@@ -74,3 +115,19 @@ func getCmdlinePath(imageName string) string {
 func getVolumePath(volumeName string) string {
 	return filepath.Join(qemuVolumesDirectory(), volumeName, "data.img")
 }
+
+func getNoCloudIsoPath(instanceName string) string {
+	return filepath.Join(qemuInstancesDirectory(), instanceName+".nocloud.iso")
+}
+
+// getQemuArgsPath is where the arguments qemu was started with are kept, to start
+// it the same way when the instance is resumed
+func getQemuArgsPath(instanceName string) string {
+	return filepath.Join(qemuInstancesDirectory(), instanceName+".args.json")
+}
+
+// getRunParamsPath is where the parameters an instance was run with are kept, to
+// run it the same way on the host it is migrated to
+func getRunParamsPath(instanceName string) string {
+	return filepath.Join(qemuInstancesDirectory(), instanceName+".params.json")
+}
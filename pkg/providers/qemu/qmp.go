@@ -0,0 +1,113 @@
+package qemu
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// how long to wait for qemu to answer on its QMP socket
+const qmpTimeout = 5 * time.Second
+
+// getQmpSocketPath is the unix socket qemu listens for QMP commands on for an instance
+func getQmpSocketPath(instanceName string) string {
+	return filepath.Join(qemuInstancesDirectory(), instanceName+".qmp")
+}
+
+type qmpResponse struct {
+	Return json.RawMessage `json:"return"`
+	Event  string          `json:"event"`
+	Error  *struct {
+		Class string `json:"class"`
+		Desc  string `json:"desc"`
+	} `json:"error"`
+}
+
+// QMPClient sends commands to a qemu over its QMP socket
+type QMPClient struct {
+	conn    net.Conn
+	decoder *json.Decoder
+	encoder *json.Encoder
+}
+
+// Connect connects to the qemu listening on socketPath and negotiates
+// capabilities, as qemu only accepts commands after that
+func (c *QMPClient) Connect(socketPath string) error {
+	conn, err := net.DialTimeout("unix", socketPath, qmpTimeout)
+	if err != nil {
+		return errors.New("connecting to qmp socket "+socketPath, err)
+	}
+	c.conn = conn
+	c.decoder = json.NewDecoder(conn)
+	c.encoder = json.NewEncoder(conn)
+
+	conn.SetDeadline(time.Now().Add(qmpTimeout))
+	var greeting map[string]interface{}
+	if err := c.decoder.Decode(&greeting); err != nil {
+		c.Disconnect()
+		return errors.New("reading qmp greeting", err)
+	}
+	if err := c.SendCommand("qmp_capabilities"); err != nil {
+		c.Disconnect()
+		return err
+	}
+	return nil
+}
+
+// SendCommand runs cmd (e.g. system_powerdown) and waits for qemu to answer.
+// events qemu sends in the meantime are skipped
+func (c *QMPClient) SendCommand(cmd string) error {
+	_, err := c.Execute(cmd, nil)
+	return err
+}
+
+// Execute runs cmd with arguments (nil for none) and returns what it returned
+func (c *QMPClient) Execute(cmd string, arguments interface{}) (json.RawMessage, error) {
+	return c.ExecuteTimeout(cmd, arguments, qmpTimeout)
+}
+
+// ExecuteTimeout is Execute for commands that take longer than qmpTimeout, e.g.
+// saving the state of an instance
+func (c *QMPClient) ExecuteTimeout(cmd string, arguments interface{}, timeout time.Duration) (json.RawMessage, error) {
+	if c.conn == nil {
+		return nil, errors.New("qmp client is not connected", nil)
+	}
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	return qmpExecute(c.encoder, c.decoder, cmd, arguments)
+}
+
+// qmpExecute sends a command and reads its response. the guest agent speaks the
+// same protocol as qmp, without the greeting and capabilities
+func qmpExecute(encoder *json.Encoder, decoder *json.Decoder, cmd string, arguments interface{}) (json.RawMessage, error) {
+	request := map[string]interface{}{"execute": cmd}
+	if arguments != nil {
+		request["arguments"] = arguments
+	}
+	if err := encoder.Encode(request); err != nil {
+		return nil, errors.New("sending qmp command "+cmd, err)
+	}
+	for {
+		var response qmpResponse
+		if err := decoder.Decode(&response); err != nil {
+			return nil, errors.New("reading response to qmp command "+cmd, err)
+		}
+		if response.Event != "" {
+			continue
+		}
+		if response.Error != nil {
+			return nil, errors.New("qmp command "+cmd+" failed: "+response.Error.Desc, nil)
+		}
+		return response.Return, nil
+	}
+}
+
+// Disconnect closes the connection to qemu
+func (c *QMPClient) Disconnect() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package qemu
+
+import (
+	"syscall"
+
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// reapQemu waits for qemu without blocking, if it is a child of the daemon.
+// child is false if it is not
+func reapQemu(pid int) (child bool, exited bool, state types.InstanceState) {
+	var status syscall.WaitStatus
+	waited, err := syscall.Wait4(pid, &status, syscall.WNOHANG, nil)
+	if err != nil {
+		return false, false, ""
+	}
+	if waited != pid {
+		return true, false, ""
+	}
+	if status.Exited() && status.ExitStatus() == 0 {
+		return true, true, types.InstanceState_Stopped
+	}
+	return true, true, types.InstanceState_Error
+}
@@ -0,0 +1,8 @@
+package qemu
+
+import "github.com/emc-advanced-dev/unik/pkg/types"
+
+// reapQemu is not supported on windows, where qemu is only detected by its pid
+func reapQemu(pid int) (child bool, exited bool, state types.InstanceState) {
+	return false, false, ""
+}
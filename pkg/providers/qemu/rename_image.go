@@ -0,0 +1,40 @@
+package qemu
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// RenameImage moves the files of an image to the folder of its new name, which
+// is also its new id. running instances keep the files they have open
+func (p *QemuProvider) RenameImage(id, newName string) (*types.Image, error) {
+	image, err := p.GetImage(id)
+	if err != nil {
+		return nil, errors.New("retrieving image "+id, err)
+	}
+	oldName := image.Name
+	if err := os.Rename(filepath.Join(qemuImagesDirectory(), oldName), filepath.Join(qemuImagesDirectory(), newName)); err != nil {
+		return nil, errors.New("moving files of image "+oldName, err)
+	}
+	var renamed *types.Image
+	if err := p.State.ModifyImages(func(images map[string]*types.Image) error {
+		stored, ok := images[oldName]
+		if !ok {
+			return errors.New("image "+oldName+" not found in state", nil)
+		}
+		delete(images, oldName)
+		stored.Id = newName
+		stored.Name = newName
+		images[newName] = stored
+		renamed = stored
+		return nil
+	}); err != nil {
+		// put the files back where the state says they are
+		os.Rename(filepath.Join(qemuImagesDirectory(), newName), filepath.Join(qemuImagesDirectory(), oldName))
+		return nil, errors.New("modifying image map in state", err)
+	}
+	return renamed, nil
+}
@@ -0,0 +1,44 @@
+package qemu
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// RenameVolume moves the folder of a volume to its new name, which is also its
+// new id. the folder is moved as a whole, so btrfs subvolumes stay subvolumes
+func (p *QemuProvider) RenameVolume(id, newName string) (*types.Volume, error) {
+	volume, err := p.GetVolume(id)
+	if err != nil {
+		return nil, errors.New("retrieving volume "+id, err)
+	}
+	if volume.Attachment != "" {
+		return nil, errors.New("volume "+volume.Name+" is attached to instance "+volume.Attachment+", detach it first", nil)
+	}
+	oldName := volume.Name
+	oldDir, newDir := filepath.Dir(getVolumePath(oldName)), filepath.Dir(getVolumePath(newName))
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return nil, errors.New("moving files of volume "+oldName, err)
+	}
+	var renamed *types.Volume
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		stored, ok := volumes[oldName]
+		if !ok {
+			return errors.New("volume "+oldName+" not found in state", nil)
+		}
+		delete(volumes, oldName)
+		stored.Id = newName
+		stored.Name = newName
+		volumes[newName] = stored
+		renamed = stored
+		return nil
+	}); err != nil {
+		// put the files back where the state says they are
+		os.Rename(newDir, oldDir)
+		return nil, errors.New("modifying volume map in state", err)
+	}
+	return renamed, nil
+}
@@ -0,0 +1,70 @@
+package qemu
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// how often RestartInstance checks whether the guest has shut down
+const restartPollInterval = 500 * time.Millisecond
+
+// RestartInstance reboots an instance without stopping qemu, so it keeps its id
+// (the qemu pid). with a timeout, the ACPI power button is pressed first, with
+// qemu told to pause rather than exit when the guest powers off, and the machine
+// is only reset once it has shut down or the timeout runs out
+func (p *QemuProvider) RestartInstance(id string, timeout time.Duration) error {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return errors.New("retrieving instance "+id, err)
+	}
+	var client QMPClient
+	if err := client.Connect(getQmpSocketPath(instance.Name)); err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	if timeout > 0 {
+		if err := shutdownAndPause(&client, timeout); err != nil {
+			logrus.WithError(err).Warnf("instance %s did not shut down, resetting it", instance.Name)
+		}
+		// instances exit again when they power off
+		if _, err := client.Execute("set-action", map[string]string{"shutdown": "poweroff"}); err != nil {
+			logrus.WithError(err).Warnf("failed to restore the shutdown action of instance %s", instance.Name)
+		}
+	}
+	if err := client.SendCommand("system_reset"); err != nil {
+		return err
+	}
+	// a guest that shut down is paused until it is continued
+	return client.SendCommand("cont")
+}
+
+// shutdownAndPause powers off the guest with qemu kept running, and waits up to
+// timeout for it. set-action needs qemu 6.0 or later
+func shutdownAndPause(client *QMPClient, timeout time.Duration) error {
+	if _, err := client.Execute("set-action", map[string]string{"shutdown": "pause"}); err != nil {
+		return err
+	}
+	if err := client.SendCommand("system_powerdown"); err != nil {
+		return err
+	}
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(restartPollInterval) {
+		result, err := client.Execute("query-status", nil)
+		if err != nil {
+			return err
+		}
+		var status struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(result, &status); err != nil {
+			return errors.New("parsing result of query-status", err)
+		}
+		if status.Status == "shutdown" {
+			return nil
+		}
+	}
+	return errors.New("timed out after "+timeout.String(), nil)
+}
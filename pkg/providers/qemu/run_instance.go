@@ -12,6 +12,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/types"
 	"github.com/emc-advanced-dev/unik/pkg/util"
@@ -38,6 +39,7 @@ func (p *QemuProvider) RunInstance(params types.RunInstanceParams) (_ *types.Ins
 	}
 
 	volumeIdInOrder := make([]string, len(params.MntPointsToVolumeIds))
+	readOnlyInOrder := make([]bool, len(params.MntPointsToVolumeIds))
 
 	for mntPoint, volumeId := range params.MntPointsToVolumeIds {
 
@@ -46,34 +48,73 @@ func (p *QemuProvider) RunInstance(params types.RunInstanceParams) (_ *types.Ins
 			return nil, err
 		}
 		volumeIdInOrder[controllerPort] = volumeId
+		for _, readOnlyMntPoint := range params.ReadOnlyMntPoints {
+			if readOnlyMntPoint == mntPoint {
+				readOnlyInOrder[controllerPort] = true
+			}
+		}
 	}
 
 	logrus.Debugf("creating qemu vm")
 
-	volImagesInOrder, err := p.getVolumeImages(volumeIdInOrder)
+	volImagesInOrder, readOnlyImages, err := p.getVolumeImages(volumeIdInOrder, readOnlyInOrder)
 	if err != nil {
 		return nil, errors.New("can't get volumes", err)
 	}
 
-	volArgs := volPathToQemuArgs(volImagesInOrder)
+	volArgs := volPathToQemuArgs(volImagesInOrder, readOnlyImages)
+	shares, err := p.folderShares(params)
+	if err != nil {
+		return nil, errors.New("can't get folder volumes", err)
+	}
 
 	if params.InstanceMemory == 0 {
 		params.InstanceMemory = image.RunSpec.DefaultInstanceMemory
 	}
 
+	netdev, err := p.netdev(params)
+	if err != nil {
+		return nil, err
+	}
 	qemuArgs := []string{"-m", fmt.Sprintf("%v", params.InstanceMemory), "-net",
-		"nic,model=virtio,netdev=mynet0", "-netdev", "user,id=mynet0,net=192.168.76.0/24,dhcpstart=192.168.76.9",
+		"nic,model=virtio,netdev=mynet0", "-netdev", netdev,
+	}
+	if params.VCPUs > 1 {
+		qemuArgs = append(qemuArgs, "-smp", fmt.Sprintf("%v", params.VCPUs))
 	}
 
 	cmdlinedata, err := ioutil.ReadFile(getCmdlinePath(image.Name))
 	if err != nil {
 		logrus.Debugf("cmdLine not found, assuming classic bootloader")
+		if len(params.Env) > 0 {
+			logrus.Warnf("the command line of image %s is set in its bootloader at build time, env will not be passed to the instance", image.Name)
+		}
+		if params.Cmdline != "" {
+			return nil, errors.New("the command line of image "+image.Name+" is set in its bootloader at build time and cannot be changed", nil)
+		}
+		if params.StaticIP != nil && params.NetworkMode != types.NetworkMode_Nat && params.NetworkMode != "" {
+			logrus.Warnf("the command line of image %s is set in its bootloader at build time, the static ip will not be passed to the instance", image.Name)
+		}
 		qemuArgs = append(qemuArgs, "-drive", fmt.Sprintf("file=%s,format=raw,if=ide", getImagePath(image.Name)))
 	} else {
 		// inject env for rump:
 		cmdline := string(cmdlinedata)
 		if compilers.CompilerType(image.RunSpec.Compiler).Base() == compilers.Rump {
+			if params.Cmdline != "" {
+				return nil, errors.New("rump images are configured with json on their command line, which cannot be changed", nil)
+			}
+			if params.StaticIP != nil && params.NetworkMode != types.NetworkMode_Nat && params.NetworkMode != "" {
+				return nil, errors.New("rump images are configured with json on their command line, a static ip can only be given to them in nat mode", nil)
+			}
 			cmdline = injectEnv(cmdline, params.Env)
+		} else if cmdline, err = unikos.AppendEnvCmdline(strings.TrimSpace(cmdline), params.Env); err != nil {
+			return nil, errors.New("passing env on the kernel command line", err)
+		}
+		if params.Cmdline != "" {
+			cmdline = unikos.RenderCmdline(params.CmdlineMode, cmdline, params.Cmdline, cmdlineData(params))
+		}
+		if params.StaticIP != nil && compilers.CompilerType(image.RunSpec.Compiler).Base() != compilers.Rump {
+			cmdline += " " + params.StaticIP.KernelArg(params.Name)
 		}
 
 		// qemu escape
@@ -94,11 +135,47 @@ func (p *QemuProvider) RunInstance(params types.RunInstanceParams) (_ *types.Ins
 		debuggerTargetImageName = image.Name
 	}
 
+	// instances show their screen on a vnc display rather than a window on the daemon host
+	var vncPort int
 	if p.config.NoGraphic {
 		qemuArgs = append(qemuArgs, "-nographic", "-vga", "none")
+	} else {
+		if vncPort, err = p.allocateVncPort(); err != nil {
+			return nil, errors.New("allocating vnc display for instance", err)
+		}
+		qemuArgs = append(qemuArgs, "-vnc", p.vncArg(vncPort))
+	}
+
+	if params.UserData != "" || params.MetaData != "" {
+		noCloudIso := getNoCloudIsoPath(params.Name)
+		if err := unikos.CreateNoCloudISO([]byte(params.UserData), []byte(params.MetaData), noCloudIso); err != nil {
+			return nil, errors.New("creating cloud-init iso for instance", err)
+		}
+		qemuArgs = append(qemuArgs, "-cdrom", noCloudIso)
 	}
 
+	// Stop with a timeout powers the instance off through qmp
+	qmpSocket := getQmpSocketPath(params.Name)
+	os.Remove(qmpSocket)
+	qemuArgs = append(qemuArgs, "-qmp", "unix:"+qmpSocket+",server,nowait")
+	os.Remove(getGuestAgentSocketPath(params.Name))
+	qemuArgs = append(qemuArgs, guestAgentArgs(params.Name)...)
+
 	qemuArgs = append(qemuArgs, volArgs...)
+	qemuArgs = append(qemuArgs, virtiofsQemuArgs(params.Name, shares[types.VolumeMode_VirtioFS], params.InstanceMemory)...)
+	qemuArgs = append(qemuArgs, plan9QemuArgs(shares[types.VolumeMode_9P])...)
+	if err := saveQemuArgs(params.Name, qemuArgs); err != nil {
+		return nil, err
+	}
+	if err := saveRunParams(params); err != nil {
+		return nil, err
+	}
+	if params.IncomingMigration != "" {
+		qemuArgs = append(qemuArgs, "-incoming", params.IncomingMigration)
+	}
+	if err := startVirtiofsd(params.Name, shares[types.VolumeMode_VirtioFS]); err != nil {
+		return nil, err
+	}
 	cmd := exec.Command("qemu-system-x86_64", qemuArgs...)
 
 	util.LogCommand(cmd, true)
@@ -117,9 +194,16 @@ func (p *QemuProvider) RunInstance(params types.RunInstanceParams) (_ *types.Ins
 		Infrastructure: types.Infrastructure_QEMU,
 		ImageId:        image.Id,
 		Created:        time.Now(),
+		VncPort:        vncPort,
+	}
+	if params.IPv6 != nil {
+		instance.IPv6Addresses = []string{params.IPv6.Address}
+	}
+	if params.StaticIP != nil {
+		instance.IpAddress = params.StaticIP.IP()
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
@@ -131,23 +215,106 @@ func (p *QemuProvider) RunInstance(params types.RunInstanceParams) (_ *types.Ins
 	return instance, nil
 }
 
-func (p *QemuProvider) getVolumeImages(volumeIdInOrder []string) ([]string, error) {
+// guestIpAddress is the address instances get from the dhcp server of qemu
+// user networking. every instance has its own network, so they all get the same one.
+const guestIpAddress = "192.168.76.9"
+
+// netdev is the -netdev option attaching the instance to the network of its network mode.
+// nat (the default) is qemu user networking, host a tap device and bridge qemu-bridge-helper
+func (p *QemuProvider) netdev(params types.RunInstanceParams) (string, error) {
+	if params.NetworkMode != types.NetworkMode_Nat && params.NetworkMode != "" && len(params.PortMappings) > 0 {
+		return "", errors.New("ports can only be forwarded to instances with network mode "+string(types.NetworkMode_Nat), nil)
+	}
+	switch params.NetworkMode {
+	case types.NetworkMode_Host:
+		netdev := "tap,id=mynet0"
+		if p.config.TapDevice != "" {
+			netdev += ",ifname=" + p.config.TapDevice + ",script=no,downscript=no"
+		}
+		return netdev, nil
+	case types.NetworkMode_Bridge:
+		netdev := "bridge,id=mynet0"
+		if p.config.Bridge != "" {
+			netdev += ",br=" + p.config.Bridge
+		}
+		return netdev, nil
+	}
+	// a static ip is what the dhcp server of qemu hands out, so unikernels that do
+	// not read it from the command line get it too
+	network, guestAddress := "192.168.76.0/24", guestIpAddress
+	if params.StaticIP != nil {
+		network, guestAddress = params.StaticIP.Network(), params.StaticIP.IP()
+	}
+	netdev := "user,id=mynet0,net=" + network + ",dhcpstart=" + guestAddress
+	if params.StaticIP != nil && params.StaticIP.Gateway != "" {
+		netdev += ",host=" + params.StaticIP.Gateway
+	}
+	// the router of qemu advertises the network of the address; the guest still
+	// has to configure the address itself, e.g. from a command line template
+	if params.IPv6 != nil {
+		netdev += ",ipv6=on,ipv6-net=" + params.IPv6.Network()
+		if params.IPv6.Gateway != "" {
+			netdev += ",ipv6-host=" + params.IPv6.Gateway
+		}
+	}
+	for _, mapping := range params.PortMappings {
+		netdev += fmt.Sprintf(",hostfwd=%s::%d-%s:%d", mapping.Protocol, mapping.HostPort, guestAddress, mapping.GuestPort)
+	}
+	return netdev, nil
+}
+
+// cmdlineData is the instance metadata a command line template is rendered with.
+// qemu volumes are named by their ids. the ip address is only known in nat mode,
+// where qemu's dhcp server hands it out, or if the instance has a static ip
+func cmdlineData(params types.RunInstanceParams) unikos.CmdlineData {
+	var ipAddress string
+	if params.StaticIP != nil {
+		ipAddress = params.StaticIP.IP()
+	} else if params.NetworkMode == types.NetworkMode_Nat || params.NetworkMode == "" {
+		ipAddress = guestIpAddress
+	}
+	data := unikos.CmdlineData{
+		InstanceName: params.Name,
+		IpAddress:    ipAddress,
+		MountPoints:  params.MntPointsToVolumeIds,
+		Env:          params.Env,
+	}
+	if params.IPv6 != nil {
+		data.IPv6Address = params.IPv6.Address
+		data.IPv6PrefixLength = params.IPv6.PrefixLength
+		data.IPv6Gateway = params.IPv6.Gateway
+	}
+	return data
+}
+
+// getVolumeImages returns the image files of the block volumes of volumeIdInOrder,
+// and whether each is read only. folder volumes are shared as folders instead
+func (p *QemuProvider) getVolumeImages(volumeIdInOrder []string, readOnlyInOrder []bool) ([]string, []bool, error) {
 
 	var volPath []string
-	for _, v := range volumeIdInOrder {
+	var readOnly []bool
+	for i, v := range volumeIdInOrder {
 		v, err := p.GetVolume(v)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if v.IsFolder() {
+			continue
 		}
 		volPath = append(volPath, getVolumePath(v.Name))
+		readOnly = append(readOnly, readOnlyInOrder[i])
 	}
-	return volPath, nil
+	return volPath, readOnly, nil
 }
 
-func volPathToQemuArgs(volPaths []string) []string {
+func volPathToQemuArgs(volPaths []string, readOnly []bool) []string {
 	var res []string
-	for _, v := range volPaths {
-		res = append(res, "-drive", fmt.Sprintf("if=virtio,file=%s,format=qcow2", v))
+	for i, v := range volPaths {
+		drive := fmt.Sprintf("if=virtio,file=%s,format=qcow2", v)
+		if readOnly[i] {
+			drive += ",readonly=on"
+		}
+		res = append(res, "-drive", drive)
 	}
 	return res
 }
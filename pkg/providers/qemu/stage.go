@@ -41,7 +41,18 @@ func (p *QemuProvider) Stage(params types.StageImageParams) (_ *types.Image, err
 	}()
 
 	kernelPath := filepath.Join(filepath.Dir(params.RawImage.LocalImagePath), "program.bin")
-	if _, err := os.Stat(kernelPath); os.IsNotExist(err) {
+	if params.RawImage.StageSpec.ImageFormat == types.ImageFormat_Kernel {
+		// booted with -kernel only, there is no boot volume
+		logrus.WithField("raw-image", params.RawImage).Infof("staging kernel without boot volume")
+		if err := unikos.CopyFile(params.RawImage.LocalImagePath, getKernelPath(params.Name)); err != nil {
+			return nil, errors.New("copying kernel file to image dir", err)
+		}
+		cmdlineFile := filepath.Join(filepath.Dir(params.RawImage.LocalImagePath), "cmdline")
+		if err := unikos.CopyFile(cmdlineFile, getCmdlinePath(params.Name)); err != nil {
+			return nil, errors.New("copying cmdline file to image dir", err)
+		}
+		imagePath = getKernelPath(params.Name)
+	} else if _, err := os.Stat(kernelPath); os.IsNotExist(err) {
 		logrus.Debugf("program.bin does not exist, assuming classic bootloader")
 		if err := unikos.CopyFile(params.RawImage.LocalImagePath, getImagePath(params.Name)); err != nil {
 			return nil, errors.New("copying bootable image to image dir", err)
@@ -85,7 +96,7 @@ func (p *QemuProvider) Stage(params types.StageImageParams) (_ *types.Image, err
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyImages(func(images map[string]*types.Image) error {
+	if err := p.State.ModifyImages(func(images map[string]*types.Image) error {
 		images[params.Name] = image
 		return nil
 	}); err != nil {
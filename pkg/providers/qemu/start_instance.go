@@ -1,11 +1,88 @@
 package qemu
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	"github.com/emc-advanced-dev/unik/pkg/util"
 )
 
+// StartInstance runs qemu again the way the instance was run, for instances
+// whose qemu has exited (stopped or error). the instance gets the pid of the new
+// qemu as its id
 func (p *QemuProvider) StartInstance(id string) error {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return errors.New("retrieving instance "+id, err)
+	}
+	switch instance.State {
+	case types.InstanceState_Stopped, types.InstanceState_Error:
+	case types.InstanceState_Suspended:
+		return errors.New("instance "+instance.Name+" is suspended, resume it instead", nil)
+	default:
+		return errors.New("instance "+instance.Name+" is running already", nil)
+	}
+	started, err := p.startQemu(instance, "")
+	if err != nil {
+		return err
+	}
+	logrus.WithField("instance", started).Infof("instance %s started", started.Name)
+	return nil
+}
+
+// startQemu runs qemu with the arguments saved when the instance was run, loading
+// snapshotName if it is set. the instance, and the volumes attached to it, move
+// to the pid of the new qemu as its id
+func (p *QemuProvider) startQemu(instance *types.Instance, snapshotName string) (*types.Instance, error) {
+	qemuArgs, err := loadQemuArgs(instance.Name)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotName != "" {
+		qemuArgs = append(qemuArgs, "-loadvm", snapshotName)
+	}
+	os.Remove(getQmpSocketPath(instance.Name))
+	os.Remove(getGuestAgentSocketPath(instance.Name))
+	cmd := exec.Command("qemu-system-x86_64", qemuArgs...)
+
+	util.LogCommand(cmd, true)
 
-	return errors.New("not supported", nil)
+	if err := cmd.Start(); err != nil {
+		return nil, errors.New("can't start qemu - make sure it's in your path.", nil)
+	}
 
+	oldId := instance.Id
+	var started *types.Instance
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+		stored, ok := instances[oldId]
+		if !ok {
+			return errors.New("instance "+oldId+" is not in the state", nil)
+		}
+		delete(instances, oldId)
+		stored.Id = fmt.Sprintf("%d", cmd.Process.Pid)
+		stored.State = types.InstanceState_Running
+		if snapshotName != "" {
+			stored.Snapshot = snapshotName
+		}
+		instances[stored.Id] = stored
+		started = stored
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying instance map in state", err)
+	}
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+		for _, volume := range volumes {
+			if volume.Attachment == oldId {
+				volume.Attachment = started.Id
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying volume map in state", err)
+	}
+	return started, nil
 }
@@ -1,3 +1,4 @@
+//go:build cgo
 // +build cgo
 
 package qemu
@@ -11,20 +12,33 @@ import (
 	"strconv"
 )
 
+// StopInstance gives the instance stop_timeout to shut down after an ACPI power
+// off (see GracefulStop) before killing it
 func (p *QemuProvider) StopInstance(id string) error {
+	if p.stopTimeout > 0 {
+		return p.GracefulStop(id, p.stopTimeout)
+	}
 	instance, err := p.GetInstance(id)
 	if err != nil {
 		return errors.New("retrieving instance "+id, err)
 	}
+	return p.killInstance(instance)
+}
 
+func (p *QemuProvider) killInstance(instance *types.Instance) error {
 	// kill qemu
 	pid, err := strconv.Atoi(instance.Id)
 	if err != nil {
 		return errors.New("invalid instance id (should be qemu pid)", err)
 	}
 
-	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
-		logrus.Warn("failed terminating instance, assuming instance has externally terminated", err)
+	// the pid of an instance whose qemu has exited may belong to another process by now
+	if qemuRunning(instance) {
+		p.exiting.Store(instance.Id, true)
+		defer p.exiting.Delete(instance.Id)
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+			logrus.Warn("failed terminating instance, assuming instance has externally terminated", err)
+		}
 	}
 	volumesToDetach := []*types.Volume{}
 	volumes, err := p.ListVolumes()
@@ -37,5 +51,5 @@ func (p *QemuProvider) StopInstance(id string) error {
 		}
 	}
 
-	return p.state.RemoveInstance(instance)
+	return p.State.RemoveInstance(instance)
 }
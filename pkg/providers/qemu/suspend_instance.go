@@ -0,0 +1,139 @@
+package qemu
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// the snapshot instances are suspended to if no name is given
+const defaultSnapshotName = "unik-suspend"
+
+// how long qemu gets to write the memory of an instance to its disk
+const savevmTimeout = 5 * time.Minute
+
+// how long to wait for qemu to exit once it has been told to quit
+const quitTimeout = 10 * time.Second
+
+// SuspendInstance saves the state of an instance with savevm and stops qemu. the
+// snapshot is kept inside the qcow2 disks of the instance, next to their data, so
+// instances that boot from a raw disk cannot be suspended. the instance stays in
+// the state, suspended, until it is resumed or deleted
+func (p *QemuProvider) SuspendInstance(id, snapshotName string) (*types.Instance, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+id, err)
+	}
+	if instance.State == types.InstanceState_Suspended {
+		return nil, errors.New("instance "+instance.Name+" is suspended already", nil)
+	}
+	if !qemuRunning(instance) {
+		return nil, errors.New("instance "+instance.Name+" is not running", nil)
+	}
+	if snapshotName == "" {
+		snapshotName = defaultSnapshotName
+	}
+	pid, err := strconv.Atoi(instance.Id)
+	if err != nil {
+		return nil, errors.New("invalid instance id (should be qemu pid)", err)
+	}
+
+	var client QMPClient
+	if err := client.Connect(getQmpSocketPath(instance.Name)); err != nil {
+		return nil, err
+	}
+	defer client.Disconnect()
+	// savevm is only a monitor command, which qmp passes on
+	result, err := client.ExecuteTimeout("human-monitor-command", map[string]string{"command-line": "savevm " + snapshotName}, savevmTimeout)
+	if err != nil {
+		return nil, errors.New("saving the state of instance "+instance.Name, err)
+	}
+	// the monitor answers with an error message if savevm failed, and nothing otherwise
+	var output string
+	if err := json.Unmarshal(result, &output); err != nil {
+		return nil, errors.New("parsing result of savevm", err)
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		return nil, errors.New("saving the state of instance "+instance.Name+" failed (qemu can only save it to qcow2 disks): "+output, nil)
+	}
+	logrus.Debugf("saved the state of instance %s to snapshot %s", instance.Name, snapshotName)
+	p.exiting.Store(instance.Id, true)
+	defer p.exiting.Delete(instance.Id)
+	if err := client.SendCommand("quit"); err != nil {
+		logrus.WithError(err).Debugf("no answer to quit from instance %s", instance.Name)
+	}
+	for deadline := time.Now().Add(quitTimeout); !qemuExited(pid); time.Sleep(restartPollInterval) {
+		if time.Now().After(deadline) {
+			return nil, errors.New(fmt.Sprintf("qemu of instance %s saved its state but did not exit", instance.Name), nil)
+		}
+	}
+	os.Remove(getQmpSocketPath(instance.Name))
+
+	var suspended *types.Instance
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
+		stored, ok := instances[instance.Id]
+		if !ok {
+			return errors.New("instance "+instance.Id+" is not in the state", nil)
+		}
+		stored.State = types.InstanceState_Suspended
+		stored.Snapshot = snapshotName
+		suspended = stored
+		return nil
+	}); err != nil {
+		return nil, errors.New("modifying instance map in state", err)
+	}
+	return suspended, nil
+}
+
+// ResumeInstance starts qemu again the way the instance was run, loading the
+// snapshot it was suspended to unless another one is named. the instance gets
+// the pid of the new qemu as its id
+func (p *QemuProvider) ResumeInstance(id, snapshotName string) (*types.Instance, error) {
+	instance, err := p.GetInstance(id)
+	if err != nil {
+		return nil, errors.New("retrieving instance "+id, err)
+	}
+	if instance.State != types.InstanceState_Suspended {
+		return nil, errors.New("instance "+instance.Name+" is not suspended", nil)
+	}
+	if snapshotName == "" {
+		snapshotName = instance.Snapshot
+	}
+	resumed, err := p.startQemu(instance, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	logrus.WithField("instance", resumed).Infof("instance %s resumed from snapshot %s", resumed.Name, snapshotName)
+	return resumed, nil
+}
+
+func saveQemuArgs(instanceName string, qemuArgs []string) error {
+	data, err := json.Marshal(qemuArgs)
+	if err != nil {
+		return errors.New("marshalling qemu arguments", err)
+	}
+	if err := ioutil.WriteFile(getQemuArgsPath(instanceName), data, 0644); err != nil {
+		return errors.New("saving qemu arguments of instance "+instanceName, err)
+	}
+	return nil
+}
+
+func loadQemuArgs(instanceName string) ([]string, error) {
+	data, err := ioutil.ReadFile(getQemuArgsPath(instanceName))
+	if err != nil {
+		return nil, errors.New("reading qemu arguments of instance "+instanceName, err)
+	}
+	var qemuArgs []string
+	if err := json.Unmarshal(data, &qemuArgs); err != nil {
+		return nil, errors.New("parsing qemu arguments of instance "+instanceName, err)
+	}
+	return qemuArgs, nil
+}
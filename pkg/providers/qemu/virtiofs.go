@@ -0,0 +1,77 @@
+package qemu
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/util"
+)
+
+// time virtiofsd gets to create its socket before qemu connects to it
+const virtiofsdStartTimeout = 5 * time.Second
+
+func getVirtiofsSocketPath(instanceName string, index int) string {
+	return filepath.Join(qemuInstancesDirectory(), fmt.Sprintf("%s.virtiofs%d.sock", instanceName, index))
+}
+
+// startVirtiofsd starts a virtiofsd for each share, and waits for their sockets.
+// virtiofsd exits once the qemu connected to it does
+func startVirtiofsd(instanceName string, shares []folderShare) error {
+	for i, share := range shares {
+		socketPath := getVirtiofsSocketPath(instanceName, i)
+		os.Remove(socketPath)
+		args := []string{"--socket-path=" + socketPath, "--shared-dir=" + getSharedFolderPath(share.volumeName), "--cache=auto"}
+		if share.readOnly {
+			args = append(args, "--readonly")
+		}
+		cmd := exec.Command("virtiofsd", args...)
+		util.LogCommand(cmd, true)
+		if err := cmd.Start(); err != nil {
+			return errors.New("can't start virtiofsd - make sure it's in your path.", err)
+		}
+		go cmd.Wait()
+
+		deadline := time.Now().Add(virtiofsdStartTimeout)
+		for {
+			if _, err := os.Stat(socketPath); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				cmd.Process.Kill()
+				return errors.New("virtiofsd did not create "+socketPath+" within "+virtiofsdStartTimeout.String(), nil)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// virtiofsQemuArgs attach a vhost-user-fs device for each share. vhost-user
+// needs the memory of the guest to be shared with virtiofsd
+func virtiofsQemuArgs(instanceName string, shares []folderShare, instanceMemory int) []string {
+	if len(shares) == 0 {
+		return nil
+	}
+	args := []string{
+		"-object", fmt.Sprintf("memory-backend-memfd,id=mem,size=%vM,share=on", instanceMemory),
+		"-numa", "node,memdev=mem",
+	}
+	for i, share := range shares {
+		args = append(args,
+			"-chardev", fmt.Sprintf("socket,id=virtiofs%d,path=%s", i, getVirtiofsSocketPath(instanceName, i)),
+			"-device", fmt.Sprintf("vhost-user-fs-pci,chardev=virtiofs%d,tag=%s", i, share.volumeName),
+		)
+	}
+	return args
+}
+
+func removeVirtiofsSockets(instanceName string) {
+	sockets, _ := filepath.Glob(filepath.Join(qemuInstancesDirectory(), instanceName+".virtiofs*.sock"))
+	for _, socket := range sockets {
+		os.Remove(socket)
+	}
+}
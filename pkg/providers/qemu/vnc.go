@@ -0,0 +1,68 @@
+package qemu
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+const (
+	// qemu numbers vnc displays from this port
+	vncBasePort = 5900
+
+	defaultVncPorts  = "5900-5999"
+	defaultVncListen = "127.0.0.1"
+)
+
+// parseVncPorts parses a range of ports given as FIRST-LAST
+func parseVncPorts(ports string) (int, int, error) {
+	bounds := strings.SplitN(ports, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, errors.New("vnc ports must be given as FIRST-LAST, e.g. "+defaultVncPorts, nil)
+	}
+	first, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return 0, 0, errors.New("invalid first vnc port "+bounds[0], err)
+	}
+	last, err := strconv.Atoi(strings.TrimSpace(bounds[1]))
+	if err != nil {
+		return 0, 0, errors.New("invalid last vnc port "+bounds[1], err)
+	}
+	if first < vncBasePort || last > 65535 || first > last {
+		return 0, 0, errors.New(fmt.Sprintf("vnc ports %s must be a range between %d and 65535", ports, vncBasePort), nil)
+	}
+	return first, last, nil
+}
+
+// allocateVncPort returns the first port of the vnc port range that no other
+// instance has and nothing else on the daemon host is listening on
+func (p *QemuProvider) allocateVncPort() (int, error) {
+	instances, err := p.ListInstances()
+	if err != nil {
+		return 0, errors.New("listing instances", err)
+	}
+	used := make(map[int]bool)
+	for _, instance := range instances {
+		used[instance.VncPort] = true
+	}
+	for port := p.vncFirstPort; port <= p.vncLastPort; port++ {
+		if used[port] {
+			continue
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort(p.vncListen, strconv.Itoa(port)))
+		if err != nil {
+			continue
+		}
+		listener.Close()
+		return port, nil
+	}
+	return 0, errors.New(fmt.Sprintf("no free vnc port between %d and %d", p.vncFirstPort, p.vncLastPort), nil)
+}
+
+// vncArg is the -vnc option for a display on port
+func (p *QemuProvider) vncArg(port int) string {
+	return fmt.Sprintf("%s:%d", p.vncListen, port-vncBasePort)
+}
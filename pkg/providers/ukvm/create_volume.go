@@ -49,7 +49,7 @@ func (p *UkvmProvider) CreateVolume(params types.CreateVolumeParams) (_ *types.V
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volumes[volume.Id] = volume
 		return nil
 	}); err != nil {
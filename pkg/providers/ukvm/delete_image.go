@@ -36,5 +36,5 @@ func (p *UkvmProvider) DeleteImage(id string, force bool) error {
 		return errors.New("deleing image directory at "+imageDir, err)
 	}
 
-	return p.state.RemoveImage(image)
+	return p.State.RemoveImage(image)
 }
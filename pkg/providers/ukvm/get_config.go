@@ -6,6 +6,7 @@ import (
 
 func (p *UkvmProvider) GetConfig() providers.ProviderConfig {
 	return providers.ProviderConfig{
-		UsePartitionTables: true,
+		UsePartitionTables:     true,
+		SupportsRuntimeCmdline: true,
 	}
 }
@@ -6,7 +6,7 @@ import (
 
 func (p *UkvmProvider) ListImages() ([]*types.Image, error) {
 	images := []*types.Image{}
-	for _, image := range p.state.GetImages() {
+	for _, image := range p.State.GetImages() {
 		images = append(images, image)
 	}
 	return images, nil
@@ -10,18 +10,18 @@ import (
 )
 
 func (p *UkvmProvider) ListInstances() ([]*types.Instance, error) {
-	if len(p.state.GetInstances()) < 1 {
+	if len(p.State.GetInstances()) < 1 {
 		return []*types.Instance{}, nil
 	}
 
 	var instances []*types.Instance
-	for _, instance := range p.state.GetInstances() {
+	for _, instance := range p.State.GetInstances() {
 		pid, err := strconv.Atoi(instance.Id)
 		if err != nil {
 			return nil, errors.New("invalid id (is not a pid)", err)
 		}
 		if err := detectInstance(pid); err != nil {
-			p.state.RemoveInstance(instance)
+			p.State.RemoveInstance(instance)
 		}
 		instances = append(instances, instance)
 	}
@@ -5,11 +5,13 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/compilers"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/types"
 	"github.com/emc-advanced-dev/unik/pkg/util"
@@ -68,6 +70,21 @@ func (p *UkvmProvider) RunInstance(params types.RunInstanceParams) (_ *types.Ins
 
 	ukvmArgs = append(ukvmArgs, volArgs...)
 	ukvmArgs = append(ukvmArgs, getKernelPath(image.Name))
+	// ukvm passes the arguments after the kernel to the unikernel as its command line
+	envArgs, err := unikos.FormatEnvCmdline(params.Env)
+	if err != nil {
+		return nil, errors.New("passing env on the kernel command line", err)
+	}
+	if params.Cmdline != "" {
+		envArgs = unikos.RenderCmdline(params.CmdlineMode, envArgs, params.Cmdline, unikos.CmdlineData{
+			InstanceName: params.Name,
+			MountPoints:  params.MntPointsToVolumeIds,
+			Env:          params.Env,
+		})
+	}
+	if envArgs != "" {
+		ukvmArgs = append(ukvmArgs, strings.Fields(envArgs)...)
+	}
 	cmd := exec.Command(getUkvmPath(image.Name), ukvmArgs...)
 
 	stdout, err := cmd.StdoutPipe()
@@ -107,7 +124,7 @@ func (p *UkvmProvider) RunInstance(params types.RunInstanceParams) (_ *types.Ins
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
@@ -76,7 +76,7 @@ func (p *UkvmProvider) Stage(params types.StageImageParams) (_ *types.Image, err
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyImages(func(images map[string]*types.Image) error {
+	if err := p.State.ModifyImages(func(images map[string]*types.Image) error {
 		images[params.Name] = image
 		return nil
 	}); err != nil {
@@ -1,3 +1,4 @@
+//go:build cgo
 // +build cgo
 
 package ukvm
@@ -37,5 +38,5 @@ func (p *UkvmProvider) StopInstance(id string) error {
 		}
 	}
 
-	return p.state.RemoveInstance(instance)
+	return p.State.RemoveInstance(instance)
 }
@@ -5,12 +5,13 @@ import (
 	"path/filepath"
 
 	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/state"
 )
 
 type UkvmProvider struct {
 	config config.Ukvm
-	state  state.State
+	common.ProviderState
 }
 
 func UkvmStateFile() string {
@@ -26,7 +27,7 @@ func ukvmInstancesDirectory() string {
 }
 
 func ukvmVolumesDirectory() string {
-	return filepath.Join(config.Internal.UnikHome, "ukvm/volumes/")
+	return filepath.Join(config.Internal.VolumesHome(), "ukvm/volumes/")
 }
 
 func NewUkvmProvider(config config.Ukvm) (*UkvmProvider, error) {
@@ -36,15 +37,15 @@ func NewUkvmProvider(config config.Ukvm) (*UkvmProvider, error) {
 	os.MkdirAll(ukvmVolumesDirectory(), 0777)
 
 	p := &UkvmProvider{
-		config: config,
-		state:  state.NewBasicState(UkvmStateFile()),
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(UkvmStateFile())},
 	}
 
 	return p, nil
 }
 
 func (p *UkvmProvider) WithState(state state.State) *UkvmProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 func getImageDir(imageName string) string {
@@ -35,7 +35,7 @@ func (p *VirtualboxProvider) AttachVolume(id, instanceId, mntPoint string) error
 		return errors.New("attaching disk to vm", err)
 	}
 
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volume, ok := volumes[volume.Id]
 		if !ok {
 			return errors.New("no record of "+volume.Id+" in the state", nil)
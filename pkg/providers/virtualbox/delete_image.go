@@ -36,5 +36,5 @@ func (p *VirtualboxProvider) DeleteImage(id string, force bool) error {
 		return errors.New("deleing image file at "+imagePath, err)
 	}
 
-	return p.state.RemoveImage(image)
+	return p.State.RemoveImage(image)
 }
@@ -37,5 +37,5 @@ func (p *VirtualboxProvider) DeleteInstance(id string, force bool) error {
 	if err := virtualboxclient.DestroyVm(instance.Id); err != nil {
 		return errors.New("destroying vm", err)
 	}
-	return p.state.RemoveInstance(instance)
+	return p.State.RemoveInstance(instance)
 }
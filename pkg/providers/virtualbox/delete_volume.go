@@ -24,5 +24,5 @@ func (p *VirtualboxProvider) DeleteVolume(id string, force bool) error {
 	if err != nil {
 		return errors.New("could not delete volume at path "+volumePath, err)
 	}
-	return p.state.RemoveVolume(volume)
+	return p.State.RemoveVolume(volume)
 }
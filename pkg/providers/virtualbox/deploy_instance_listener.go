@@ -132,7 +132,7 @@ func (p *VirtualboxProvider) runInstanceListener(image *types.Image) (err error)
 	if err := virtualboxclient.AttachDisk(VboxUnikInstanceListener, getVolumePath(instanceListenerVol.Name), controllerPort, image.RunSpec.StorageDriver); err != nil {
 		return errors.New("attaching to vm", err)
 	}
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volume, ok := volumes[instanceListenerVol.Id]
 		if !ok {
 			return errors.New("no record of "+volume.Id+" in the state", nil)
@@ -169,7 +169,7 @@ func (p *VirtualboxProvider) runInstanceListener(image *types.Image) (err error)
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
@@ -50,7 +50,7 @@ func (p *VirtualboxProvider) DetachVolume(id string) error {
 		return errors.New("detaching disk from vm", err)
 	}
 
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volume, ok := volumes[volume.Id]
 		if !ok {
 			return errors.New("no record of "+volume.Id+" in the state", nil)
@@ -0,0 +1,14 @@
+package virtualbox
+
+import (
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *VirtualboxProvider) GetImageFile(id string) (string, types.ImageFormat, error) {
+	image, err := p.GetImage(id)
+	if err != nil {
+		return "", "", errors.New("retrieving image "+id, err)
+	}
+	return getImagePath(image.Name), types.ImageFormat_VMDK, nil
+}
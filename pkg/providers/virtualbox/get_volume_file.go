@@ -0,0 +1,14 @@
+package virtualbox
+
+import (
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+func (p *VirtualboxProvider) GetVolumeFile(id string) (string, types.ImageFormat, error) {
+	volume, err := p.GetVolume(id)
+	if err != nil {
+		return "", "", errors.New("retrieving volume "+id, err)
+	}
+	return getVolumePath(volume.Name), types.ImageFormat_VMDK, nil
+}
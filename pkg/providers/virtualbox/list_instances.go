@@ -5,11 +5,11 @@ import (
 )
 
 func (p *VirtualboxProvider) ListInstances() ([]*types.Instance, error) {
-	if len(p.state.GetInstances()) < 1 {
+	if len(p.State.GetInstances()) < 1 {
 		return []*types.Instance{}, nil
 	}
 	var instances []*types.Instance
-	for _, v := range p.state.GetInstances() {
+	for _, v := range p.State.GetInstances() {
 		instances = append(instances, v)
 	}
 
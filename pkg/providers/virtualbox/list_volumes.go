@@ -6,7 +6,7 @@ import (
 
 func (p *VirtualboxProvider) ListVolumes() ([]*types.Volume, error) {
 	volumes := []*types.Volume{}
-	for _, volume := range p.state.GetVolumes() {
+	for _, volume := range p.State.GetVolumes() {
 		volumes = append(volumes, volume)
 	}
 	return volumes, nil
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/config"
 	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/providers/virtualbox/virtualboxclient"
@@ -35,6 +36,10 @@ func (p *VirtualboxProvider) RunInstance(params types.RunInstanceParams) (_ *typ
 		return nil, errors.New("invalid mapping for volume", err)
 	}
 
+	if err := p.verifyNetworkMode(params.NetworkMode); err != nil {
+		return nil, err
+	}
+
 	instanceDir := getInstanceDir(params.Name)
 
 	portsUsed := []int{}
@@ -67,6 +72,11 @@ func (p *VirtualboxProvider) RunInstance(params types.RunInstanceParams) (_ *typ
 	if err := virtualboxclient.CreateVm(params.Name, virtualboxInstancesDirectory(), params.InstanceMemory, p.config.AdapterName, p.config.VirtualboxAdapterType, image.RunSpec.StorageDriver); err != nil {
 		return nil, errors.New("creating vm", err)
 	}
+	if params.VCPUs > 1 {
+		if err := virtualboxclient.SetCpus(params.Name, params.VCPUs); err != nil {
+			return nil, errors.New("setting vcpus of vm", err)
+		}
+	}
 
 	logrus.Debugf("copying source boot vmdk")
 	instanceBootImage := filepath.Join(instanceDir, "boot.vmdk")
@@ -131,7 +141,7 @@ func (p *VirtualboxProvider) RunInstance(params types.RunInstanceParams) (_ *typ
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
@@ -142,3 +152,22 @@ func (p *VirtualboxProvider) RunInstance(params types.RunInstanceParams) (_ *typ
 
 	return instance, nil
 }
+
+// verifyNetworkMode checks that the configured adapter can give the instance the
+// network mode it asks for. every vm gets a nat adapter besides the configured one,
+// which the instance listener needs, so nat (the default) works with either adapter type
+func (p *VirtualboxProvider) verifyNetworkMode(mode types.NetworkMode) error {
+	var adapterType config.VirtualboxAdapterType
+	switch mode {
+	case types.NetworkMode_Host:
+		adapterType = config.HostOnlyAdapter
+	case types.NetworkMode_Bridge:
+		adapterType = config.BridgedAdapter
+	default:
+		return nil
+	}
+	if adapterType != p.config.VirtualboxAdapterType {
+		return errors.New("network mode "+string(mode)+" requires the virtualbox provider to be configured with adapter_type "+string(adapterType)+", not "+string(p.config.VirtualboxAdapterType), nil)
+	}
+	return nil
+}
@@ -13,16 +13,16 @@ import (
 )
 
 func (p *VirtualboxProvider) syncState() error {
-	if len(p.state.GetInstances()) < 1 {
+	if len(p.State.GetInstances()) < 1 {
 		return nil
 	}
-	for _, instance := range p.state.GetInstances() {
+	for _, instance := range p.State.GetInstances() {
 		vm, err := virtualboxclient.GetVm(instance.Name)
 		if err != nil {
 			if strings.Contains(err.Error(), "Could not find a registered machine") {
 				logrus.Warnf("instance found in state that is no longer registered to Virtualbox")
 				os.RemoveAll(getInstanceDir(instance.Name))
-				p.state.RemoveInstance(instance)
+				p.State.RemoveInstance(instance)
 				continue
 			}
 			return errors.New("retrieving vm for instance id "+instance.Name, err)
@@ -49,7 +49,7 @@ func (p *VirtualboxProvider) syncState() error {
 			return nil
 		})
 
-		if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+		if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 			if _, ok := instances[instance.Id]; ok {
 				instances[instance.Id].IpAddress = ipAddress
 				instances[instance.Id].State = instance.State
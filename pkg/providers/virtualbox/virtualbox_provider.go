@@ -23,15 +23,15 @@ func virtualboxInstancesDirectory() string {
 	return filepath.Join(config.Internal.UnikHome, "virtualbox/instances/")
 }
 func virtualboxVolumesDirectory() string {
-	return filepath.Join(config.Internal.UnikHome, "virtualbox/volumes/")
+	return filepath.Join(config.Internal.VolumesHome(), "virtualbox/volumes/")
 }
 
 const VboxUnikInstanceListener = "VboxUnikInstanceListener"
 const instanceListenerPrefix = "unik_virtualbox"
 
 type VirtualboxProvider struct {
-	config             config.Virtualbox
-	state              state.State
+	config config.Virtualbox
+	common.ProviderState
 	instanceListenerIp string
 }
 
@@ -41,8 +41,8 @@ func NewVirtualboxProvider(config config.Virtualbox) (*VirtualboxProvider, error
 	os.MkdirAll(virtualboxVolumesDirectory(), 0755)
 
 	p := &VirtualboxProvider{
-		config: config,
-		state:  state.NewBasicState(VirtualboxStateFile()),
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(VirtualboxStateFile())},
 	}
 
 	if err := p.deployInstanceListener(config); err != nil && !strings.Contains(err.Error(), "already exists") {
@@ -70,7 +70,7 @@ func NewVirtualboxProvider(config config.Virtualbox) (*VirtualboxProvider, error
 }
 
 func (p *VirtualboxProvider) WithState(state state.State) *VirtualboxProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 
@@ -224,6 +224,14 @@ func CreateVm(vmName, baseFolder string, memoryMb int, adapterName string, adapt
 	return nil
 }
 
+// SetCpus sets the number of virtual cpus of a vm that is powered off
+func SetCpus(vmName string, cpus int) error {
+	if _, err := vboxManage("modifyvm", vmName, "--cpus", fmt.Sprintf("%v", cpus)); err != nil {
+		return errors.New("setting cpus of vm", err)
+	}
+	return nil
+}
+
 func CreateVmNatless(vmName, baseFolder, adapterName string, adapterType config.VirtualboxAdapterType, storageDriver types.StorageDriver) error {
 	var nicArgs []string
 	switch adapterType {
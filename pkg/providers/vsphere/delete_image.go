@@ -33,5 +33,5 @@ func (p *VsphereProvider) DeleteImage(id string, force bool) error {
 	if err := p.getClient().Rmdir(imageDir); err != nil {
 		return errors.New("deleting image file at "+imageDir, err)
 	}
-	return p.state.RemoveImage(image)
+	return p.State.RemoveImage(image)
 }
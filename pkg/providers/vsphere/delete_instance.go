@@ -48,5 +48,5 @@ func (p *VsphereProvider) DeleteInstance(id string, force bool) error {
 	if err != nil {
 		return errors.New("failed to terminate instance "+instance.Id, err)
 	}
-	return p.state.RemoveInstance(instance)
+	return p.State.RemoveInstance(instance)
 }
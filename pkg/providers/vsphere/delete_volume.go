@@ -23,5 +23,5 @@ func (p *VsphereProvider) DeleteVolume(id string, force bool) error {
 	if err != nil {
 		return errors.New("could not delete volume at path "+volumeDir, err)
 	}
-	return p.state.RemoveVolume(volume)
+	return p.State.RemoveVolume(volume)
 }
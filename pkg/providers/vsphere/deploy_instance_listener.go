@@ -129,7 +129,7 @@ func (p *VsphereProvider) runInstanceListener(image *types.Image) (err error) {
 	if err := c.AttachDisk(VsphereUnikInstanceListener, getVolumeDatastorePath(instanceListenerVol.Name), controllerPort, image.RunSpec.StorageDriver); err != nil {
 		return errors.New("attaching disk to vm", err)
 	}
-	if err := p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	if err := p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volume, ok := volumes[instanceListenerVol.Id]
 		if !ok {
 			return errors.New("no record of "+volume.Id+" in the state", nil)
@@ -166,7 +166,7 @@ func (p *VsphereProvider) runInstanceListener(image *types.Image) (err error) {
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
@@ -144,7 +144,7 @@ func (p *VsphereProvider) RunInstance(params types.RunInstanceParams) (_ *types.
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
@@ -80,7 +80,7 @@ func (p *VsphereProvider) Stage(params types.StageImageParams) (_ *types.Image,
 		Created:        time.Now(),
 	}
 
-	err = p.state.ModifyImages(func(images map[string]*types.Image) error {
+	err = p.State.ModifyImages(func(images map[string]*types.Image) error {
 		images[params.Name] = image
 		return nil
 	})
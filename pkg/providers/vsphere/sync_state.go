@@ -11,12 +11,12 @@ import (
 )
 
 func (p *VsphereProvider) syncState() error {
-	if len(p.state.GetInstances()) < 1 {
+	if len(p.State.GetInstances()) < 1 {
 		return nil
 	}
 	c := p.getClient()
 	vms := []*vsphereclient.VirtualMachine{}
-	for instanceId := range p.state.GetInstances() {
+	for instanceId := range p.State.GetInstances() {
 		vm, err := c.GetVmByUuid(instanceId)
 		if err != nil {
 			return errors.New("getting vm info for "+instanceId, err)
@@ -38,7 +38,7 @@ func (p *VsphereProvider) syncState() error {
 		}
 
 		instanceId := vm.Config.UUID
-		instance, ok := p.state.GetInstances()[instanceId]
+		instance, ok := p.State.GetInstances()[instanceId]
 		if !ok {
 			continue
 		}
@@ -70,7 +70,7 @@ func (p *VsphereProvider) syncState() error {
 			return nil
 		})
 
-		if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+		if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 			if _, ok := instances[instance.Id]; ok {
 				instances[instance.Id].IpAddress = ipAddress
 				instances[instance.Id].State = instance.State
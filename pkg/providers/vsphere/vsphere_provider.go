@@ -25,8 +25,8 @@ const VsphereUnikInstanceListener = "VsphereUnikInstanceListener"
 const instanceListenerPrefix = "unik_vsphere"
 
 type VsphereProvider struct {
-	config             config.Vsphere
-	state              state.State
+	config config.Vsphere
+	common.ProviderState
 	u                  *url.URL
 	instanceListenerIp string
 }
@@ -39,9 +39,9 @@ func NewVsphereProvier(config config.Vsphere) (*VsphereProvider, error) {
 	}
 
 	p := &VsphereProvider{
-		config: config,
-		state:  state.NewBasicState(VsphereStateFile()),
-		u:      u,
+		config:        config,
+		ProviderState: common.ProviderState{State: state.NewBasicState(VsphereStateFile())},
+		u:             u,
 	}
 
 	p.getClient().Mkdir("unik")
@@ -73,7 +73,7 @@ func NewVsphereProvier(config config.Vsphere) (*VsphereProvider, error) {
 }
 
 func (p *VsphereProvider) WithState(state state.State) *VsphereProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 
@@ -81,7 +81,7 @@ func (p *VsphereProvider) getClient() *vsphereclient.VsphereClient {
 	return vsphereclient.NewVsphereClient(p.u, p.config.Datastore, p.config.Datacenter)
 }
 
-//just for consistency
+// just for consistency
 func getInstanceDatastoreDir(instanceName string) string {
 	return instanceName
 }
@@ -49,7 +49,7 @@ func (p *XenProvider) CreateVolume(params types.CreateVolumeParams) (_ *types.Vo
 		Created:        time.Now(),
 	}
 
-	err = p.state.ModifyVolumes(func(volumes map[string]*types.Volume) error {
+	err = p.State.ModifyVolumes(func(volumes map[string]*types.Volume) error {
 		volumes[volume.Id] = volume
 		return nil
 	})
@@ -30,11 +30,11 @@ func (p *XenProvider) DeleteInstance(id string, force bool) error {
 			volumesToDetach = append(volumesToDetach, volume)
 		}
 	}
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		delete(instances, instance.Id)
 		return nil
 	}); err != nil {
 		return errors.New("modifying image map in state", err)
 	}
-	return p.state.RemoveInstance(instance)
+	return p.State.RemoveInstance(instance)
 }
@@ -114,7 +114,7 @@ func (p *XenProvider) RunInstance(params types.RunInstanceParams) (_ *types.Inst
 		Created:        time.Now(),
 	}
 
-	if err := p.state.ModifyInstances(func(instances map[string]*types.Instance) error {
+	if err := p.State.ModifyInstances(func(instances map[string]*types.Instance) error {
 		instances[instance.Id] = instance
 		return nil
 	}); err != nil {
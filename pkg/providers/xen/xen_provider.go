@@ -5,12 +5,13 @@ import (
 	"path/filepath"
 
 	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/pkg/providers/common"
 	"github.com/emc-advanced-dev/unik/pkg/providers/xen/xenclient"
 	"github.com/emc-advanced-dev/unik/pkg/state"
 )
 
 type XenProvider struct {
-	state  state.State
+	common.ProviderState
 	client *xenclient.XenClient
 }
 
@@ -27,7 +28,7 @@ func xenInstancesDirectory() string {
 }
 
 func xenVolumesDirectory() string {
-	return filepath.Join(config.Internal.UnikHome, "xen/volumes/")
+	return filepath.Join(config.Internal.VolumesHome(), "xen/volumes/")
 }
 
 func NewXenProvider(config config.Xen) (*XenProvider, error) {
@@ -37,7 +38,7 @@ func NewXenProvider(config config.Xen) (*XenProvider, error) {
 	os.MkdirAll(xenVolumesDirectory(), 0777)
 
 	p := &XenProvider{
-		state: state.NewBasicState(XenStateFile()),
+		ProviderState: common.ProviderState{State: state.NewBasicState(XenStateFile())},
 		client: &xenclient.XenClient{
 			KernelPath: config.KernelPath,
 			XenBridge:  config.XenBridge,
@@ -54,7 +55,7 @@ func NewXenProvider(config config.Xen) (*XenProvider, error) {
 }
 
 func (p *XenProvider) WithState(state state.State) *XenProvider {
-	p.state = state
+	p.State = state
 	return p
 }
 
@@ -14,11 +14,16 @@ type basicState struct {
 	imagesLock    sync.RWMutex
 	instancesLock sync.RWMutex
 	volumesLock   sync.RWMutex
+	expiredLock   sync.RWMutex
+	recordsLock   sync.RWMutex
 	saveLock      sync.Mutex
 	saveFile      string
-	Images        map[string]*types.Image    `json:"Images"`
-	Instances     map[string]*types.Instance `json:"Instances"`
-	Volumes       map[string]*types.Volume   `json:"Volumes"`
+	etcd          *etcdStore                            // set for states kept in etcd rather than in saveFile
+	Images        map[string]*types.Image               `json:"Images"`
+	Instances     map[string]*types.Instance            `json:"Instances"`
+	Volumes       map[string]*types.Volume              `json:"Volumes"`
+	Expired       map[string]*types.Instance            `json:"ExpiredInstances,omitempty"`
+	Records       map[string]map[string]json.RawMessage `json:"Records,omitempty"`
 }
 
 func NewBasicState(saveFile string) *basicState {
@@ -27,6 +32,8 @@ func NewBasicState(saveFile string) *basicState {
 		Images:    make(map[string]*types.Image),
 		Instances: make(map[string]*types.Instance),
 		Volumes:   make(map[string]*types.Volume),
+		Expired:   make(map[string]*types.Instance),
+		Records:   make(map[string]map[string]json.RawMessage),
 	}
 }
 
@@ -49,6 +56,12 @@ func BasicStateFromFile(saveFile string) (*basicState, error) {
 	if s.Volumes == nil {
 		s.Volumes = make(map[string]*types.Volume)
 	}
+	if s.Expired == nil {
+		s.Expired = make(map[string]*types.Instance)
+	}
+	if s.Records == nil {
+		s.Records = make(map[string]map[string]json.RawMessage)
+	}
 	s.saveFile = saveFile
 	return &s, nil
 }
@@ -86,6 +99,27 @@ func (s *basicState) GetVolumes() map[string]*types.Volume {
 	return volumesCopy
 }
 
+func (s *basicState) GetExpiredInstances() map[string]*types.Instance {
+	s.expiredLock.RLock()
+	defer s.expiredLock.RUnlock()
+	instancesCopy := make(map[string]*types.Instance)
+	for id, instance := range s.Expired {
+		instanceCopy := *instance
+		instancesCopy[id] = &instanceCopy
+	}
+	return instancesCopy
+}
+
+func (s *basicState) GetRecords(kind string) map[string]json.RawMessage {
+	s.recordsLock.RLock()
+	defer s.recordsLock.RUnlock()
+	recordsCopy := make(map[string]json.RawMessage)
+	for key, record := range s.Records[kind] {
+		recordsCopy[key] = append(json.RawMessage{}, record...)
+	}
+	return recordsCopy
+}
+
 func (s *basicState) ModifyImages(modify func(images map[string]*types.Image) error) error {
 	s.imagesLock.Lock()
 	defer s.imagesLock.Unlock()
@@ -113,9 +147,39 @@ func (s *basicState) ModifyVolumes(modify func(volumes map[string]*types.Volume)
 	return s.save()
 }
 
+func (s *basicState) ModifyExpiredInstances(modify func(instances map[string]*types.Instance) error) error {
+	s.expiredLock.Lock()
+	defer s.expiredLock.Unlock()
+	if err := modify(s.Expired); err != nil {
+		return errors.New("modifying ExpiredInstances", err)
+	}
+	return s.save()
+}
+
+func (s *basicState) ModifyRecords(kind string, modify func(records map[string]json.RawMessage) error) error {
+	s.recordsLock.Lock()
+	defer s.recordsLock.Unlock()
+	records, ok := s.Records[kind]
+	if !ok {
+		records = make(map[string]json.RawMessage)
+	}
+	if err := modify(records); err != nil {
+		return errors.New("modifying "+kind, err)
+	}
+	if len(records) > 0 {
+		s.Records[kind] = records
+	} else {
+		delete(s.Records, kind)
+	}
+	return s.save()
+}
+
 func (s *basicState) save() error {
 	s.saveLock.Lock()
 	defer s.saveLock.Unlock()
+	if s.etcd != nil {
+		return s.etcd.save(s)
+	}
 	data, err := json.Marshal(s)
 	if err != nil {
 		return errors.New("failed to marshal memory state to json", err)
@@ -128,6 +192,41 @@ func (s *basicState) save() error {
 	return nil
 }
 
+func (s *basicState) Flush() error {
+	s.imagesLock.RLock()
+	defer s.imagesLock.RUnlock()
+	s.instancesLock.RLock()
+	defer s.instancesLock.RUnlock()
+	s.volumesLock.RLock()
+	defer s.volumesLock.RUnlock()
+	s.expiredLock.RLock()
+	defer s.expiredLock.RUnlock()
+	s.recordsLock.RLock()
+	defer s.recordsLock.RUnlock()
+	return s.save()
+}
+
+func (s *basicState) Check() error {
+	s.saveLock.Lock()
+	defer s.saveLock.Unlock()
+	if s.etcd != nil {
+		return s.etcd.check()
+	}
+	data, err := ioutil.ReadFile(s.saveFile)
+	if os.IsNotExist(err) {
+		// nothing has been saved yet
+		return nil
+	}
+	if err != nil {
+		return errors.New("error reading save file "+s.saveFile, err)
+	}
+	var saved basicState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return errors.New("failed to unmarshal save file "+s.saveFile, err)
+	}
+	return nil
+}
+
 func (s *basicState) RemoveImage(image *types.Image) error {
 	if err := s.ModifyImages(func(images map[string]*types.Image) error {
 		delete(images, image.Id)
@@ -0,0 +1,221 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// EtcdClient talks to etcd through the json gateway of its v3 api (etcd 3.4 and
+// later serve it at /v3/). keys and values are sent base64 encoded, which
+// encoding/json does for []byte.
+type EtcdClient struct {
+	endpoints []string
+	// http times out, so that a stuck endpoint can't hold up lease renewals
+	http *http.Client
+	// campaigns block until the daemon becomes the leader, and never time out
+	campaignHttp *http.Client
+	// set once Campaign is won: writes only succeed while leader is the leader key
+	leader *etcdLeaderKey
+}
+
+type etcdLeaderKey struct {
+	key []byte
+	rev string
+}
+
+// NewEtcdClient returns a client whose calls, other than Campaign, give up after timeout
+func NewEtcdClient(endpoints []string, timeout time.Duration) (*EtcdClient, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no etcd endpoints given", nil)
+	}
+	trimmed := []string{}
+	for _, endpoint := range endpoints {
+		trimmed = append(trimmed, strings.TrimSuffix(endpoint, "/"))
+	}
+	return &EtcdClient{
+		endpoints:    trimmed,
+		http:         &http.Client{Timeout: timeout},
+		campaignHttp: &http.Client{},
+	}, nil
+}
+
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// call posts request to path on the first endpoint that answers
+func (c *EtcdClient) call(path string, request, response interface{}) error {
+	return c.callWith(c.http, path, request, response)
+}
+
+func (c *EtcdClient) callWith(client *http.Client, path string, request, response interface{}) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return errors.New("marshalling etcd request", err)
+	}
+	var lastErr error
+	for _, endpoint := range c.endpoints {
+		resp, err := client.Post(endpoint+path, "application/json", bytes.NewReader(data))
+		if err != nil {
+			logrus.WithError(err).Debugf("etcd endpoint %s failed", endpoint)
+			lastErr = err
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return errors.New("reading etcd response", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return errors.New(fmt.Sprintf("etcd %s failed with status %v: %s", path, resp.StatusCode, string(body)), nil)
+		}
+		if response == nil {
+			return nil
+		}
+		if err := json.Unmarshal(body, response); err != nil {
+			return errors.New("parsing etcd response "+string(body), err)
+		}
+		return nil
+	}
+	return errors.New("no etcd endpoint could be reached", lastErr)
+}
+
+func (c *EtcdClient) put(key string, value []byte) error {
+	return c.write(map[string]interface{}{"request_put": map[string]interface{}{"key": []byte(key), "value": value}})
+}
+
+func (c *EtcdClient) delete(key string) error {
+	return c.write(map[string]interface{}{"request_delete_range": map[string]interface{}{"key": []byte(key)}})
+}
+
+// write runs op in a transaction that only succeeds while the leader key this
+// client campaigned for exists, with the revision it was created at. a daemon that
+// lost the leadership, but has not noticed yet, can't overwrite the state of the next one
+func (c *EtcdClient) write(op map[string]interface{}) error {
+	txn := map[string]interface{}{"success": []interface{}{op}}
+	if c.leader != nil {
+		txn["compare"] = []interface{}{map[string]interface{}{
+			"key":             c.leader.key,
+			"target":          "CREATE",
+			"result":          "EQUAL",
+			"create_revision": c.leader.rev,
+		}}
+	}
+	var response struct {
+		Succeeded bool `json:"succeeded"`
+	}
+	if err := c.call("/v3/kv/txn", txn, &response); err != nil {
+		return err
+	}
+	if !response.Succeeded {
+		return errors.New("this daemon is not the leader any more", nil)
+	}
+	return nil
+}
+
+// list returns the keys and values under prefix
+func (c *EtcdClient) list(prefix string) ([]etcdKeyValue, error) {
+	var response struct {
+		Kvs []etcdKeyValue `json:"kvs"`
+	}
+	if err := c.call("/v3/kv/range", map[string]interface{}{
+		"key":       []byte(prefix),
+		"range_end": prefixEnd(prefix),
+	}, &response); err != nil {
+		return nil, err
+	}
+	return response.Kvs, nil
+}
+
+// prefixEnd is the first key after all keys starting with prefix
+func prefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// every byte is 0xff: to the end of the keyspace
+	return []byte{0}
+}
+
+// EtcdLeadership is held by the daemon that won Campaign, as long as its lease is
+// kept alive
+type EtcdLeadership struct {
+	lost chan struct{}
+}
+
+// Lost is closed if the lease of the leader could not be renewed, after which
+// another daemon may become the leader
+func (l *EtcdLeadership) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Campaign blocks until this daemon is the leader of election, and then keeps the
+// lease backing its leadership alive. value identifies the daemon to the others
+func (c *EtcdClient) Campaign(election, value string, ttl time.Duration) (*EtcdLeadership, error) {
+	var grant struct {
+		ID string `json:"ID"`
+	}
+	if err := c.call("/v3/lease/grant", map[string]interface{}{"TTL": int64(ttl.Seconds())}, &grant); err != nil {
+		return nil, errors.New("granting etcd lease", err)
+	}
+	leadership := &EtcdLeadership{lost: make(chan struct{})}
+	// the lease must stay alive while waiting, or the campaign ends with it
+	go c.keepAlive(grant.ID, ttl, leadership.lost)
+	var campaign struct {
+		Leader struct {
+			Key []byte `json:"key"`
+			Rev string `json:"rev"`
+		} `json:"leader"`
+	}
+	if err := c.callWith(c.campaignHttp, "/v3/election/campaign", map[string]interface{}{
+		"name":  []byte(election),
+		"lease": grant.ID,
+		"value": []byte(value),
+	}, &campaign); err != nil {
+		return nil, errors.New("campaigning for "+election, err)
+	}
+	if len(campaign.Leader.Key) == 0 || campaign.Leader.Rev == "" {
+		return nil, errors.New("etcd did not tell the leader key of "+election, nil)
+	}
+	c.leader = &etcdLeaderKey{key: campaign.Leader.Key, rev: campaign.Leader.Rev}
+	return leadership, nil
+}
+
+func (c *EtcdClient) keepAlive(leaseId string, ttl time.Duration, lost chan struct{}) {
+	defer close(lost)
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for range ticker.C {
+		var response struct {
+			Result struct {
+				TTL string `json:"TTL"`
+			} `json:"result"`
+		}
+		if err := c.call("/v3/lease/keepalive", map[string]interface{}{"ID": leaseId}, &response); err != nil {
+			if time.Since(renewed) < ttl {
+				logrus.WithError(err).Warnf("renewing etcd lease %s, trying again", leaseId)
+				continue
+			}
+			logrus.WithError(err).Errorf("renewing etcd lease %s", leaseId)
+			return
+		}
+		renewed = time.Now()
+		if response.Result.TTL == "" || response.Result.TTL == "0" {
+			logrus.Errorf("etcd lease %s has expired", leaseId)
+			return
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// etcdStore keeps a basicState in etcd, one key per resource:
+// PREFIX/images/ID, PREFIX/instances/ID, PREFIX/volumes/ID,
+// PREFIX/expired-instances/ID and PREFIX/records/KIND/KEY. saving only writes
+// the resources that changed since the last save, and deletes removed ones
+type etcdStore struct {
+	client *EtcdClient
+	prefix string
+	// json of every key as last written. guarded by the saveLock of the state
+	saved map[string][]byte
+}
+
+// EtcdStateFromPrefix reads the state stored under prefix (e.g. /unik/qemu)
+func EtcdStateFromPrefix(client *EtcdClient, prefix string) (*basicState, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	kvs, err := client.list(prefix + "/")
+	if err != nil {
+		return nil, errors.New("reading state from etcd under "+prefix, err)
+	}
+	s := NewBasicState("")
+	store := &etcdStore{client: client, prefix: prefix, saved: make(map[string][]byte)}
+	for _, kv := range kvs {
+		key := string(kv.Key)
+		parts := strings.SplitN(strings.TrimPrefix(key, prefix+"/"), "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "images":
+			var image types.Image
+			if err := json.Unmarshal(kv.Value, &image); err != nil {
+				return nil, errors.New("unmarshalling "+key, err)
+			}
+			s.Images[parts[1]] = &image
+		case "instances":
+			var instance types.Instance
+			if err := json.Unmarshal(kv.Value, &instance); err != nil {
+				return nil, errors.New("unmarshalling "+key, err)
+			}
+			s.Instances[parts[1]] = &instance
+		case "volumes":
+			var volume types.Volume
+			if err := json.Unmarshal(kv.Value, &volume); err != nil {
+				return nil, errors.New("unmarshalling "+key, err)
+			}
+			s.Volumes[parts[1]] = &volume
+		case "expired-instances":
+			var instance types.Instance
+			if err := json.Unmarshal(kv.Value, &instance); err != nil {
+				return nil, errors.New("unmarshalling "+key, err)
+			}
+			s.Expired[parts[1]] = &instance
+		case "records":
+			record := strings.SplitN(parts[1], "/", 2)
+			if len(record) != 2 {
+				continue
+			}
+			if s.Records[record[0]] == nil {
+				s.Records[record[0]] = make(map[string]json.RawMessage)
+			}
+			s.Records[record[0]][record[1]] = append(json.RawMessage{}, kv.Value...)
+		default:
+			continue
+		}
+		store.saved[key] = kv.Value
+	}
+	s.etcd = store
+	return s, nil
+}
+
+func (e *etcdStore) save(s *basicState) error {
+	current := make(map[string][]byte)
+	add := func(kind, id string, resource interface{}) error {
+		data, err := json.Marshal(resource)
+		if err != nil {
+			return errors.New("marshalling "+kind+" "+id, err)
+		}
+		current[e.prefix+"/"+kind+"/"+id] = data
+		return nil
+	}
+	for id, image := range s.Images {
+		if err := add("images", id, image); err != nil {
+			return err
+		}
+	}
+	for id, instance := range s.Instances {
+		if err := add("instances", id, instance); err != nil {
+			return err
+		}
+	}
+	for id, volume := range s.Volumes {
+		if err := add("volumes", id, volume); err != nil {
+			return err
+		}
+	}
+	for id, instance := range s.Expired {
+		if err := add("expired-instances", id, instance); err != nil {
+			return err
+		}
+	}
+	for kind, records := range s.Records {
+		for key, record := range records {
+			if err := add("records", kind+"/"+key, record); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, data := range current {
+		if saved, ok := e.saved[key]; ok && bytes.Equal(saved, data) {
+			continue
+		}
+		if err := e.client.put(key, data); err != nil {
+			return errors.New("writing "+key+" to etcd", err)
+		}
+		e.saved[key] = data
+	}
+	for key := range e.saved {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		if err := e.client.delete(key); err != nil {
+			return errors.New("deleting "+key+" from etcd", err)
+		}
+		delete(e.saved, key)
+	}
+	return nil
+}
+
+// check lists the keys of the state, which fails if etcd can't be reached
+func (e *etcdStore) check() error {
+	_, err := e.client.list(e.prefix + "/")
+	return err
+}
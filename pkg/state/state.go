@@ -1,6 +1,8 @@
 package state
 
 import (
+	"encoding/json"
+
 	"github.com/emc-advanced-dev/unik/pkg/types"
 )
 
@@ -11,7 +13,19 @@ type State interface {
 	ModifyImages(modify func(images map[string]*types.Image) error) error
 	ModifyInstances(modify func(instances map[string]*types.Instance) error) error
 	ModifyVolumes(modify func(volumes map[string]*types.Volume) error) error
+	// expired instances were deleted when their ttl ran out, and are kept
+	// here to be listed for a grace period
+	GetExpiredInstances() map[string]*types.Instance
+	ModifyExpiredInstances(modify func(instances map[string]*types.Instance) error) error
+	// records are what the daemon keeps besides images, instances and volumes,
+	// as json by kind (e.g. "manifests") and key
+	GetRecords(kind string) map[string]json.RawMessage
+	ModifyRecords(kind string, modify func(records map[string]json.RawMessage) error) error
 	RemoveImage(image *types.Image) error
 	RemoveInstance(instance *types.Instance) error
 	RemoveVolume(volume *types.Volume) error
+	// Check returns an error if the saved state cannot be read back
+	Check() error
+	// Flush writes the state to its save file
+	Flush() error
 }
@@ -0,0 +1,324 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+)
+
+// the bodies of the requests to and replies from the daemon, shared by pkg/daemon and pkg/client
+
+// largest gRPC message the daemon takes. the sources of a build and the data of
+// a volume are sent in one message, which the daemon holds in memory; larger
+// ones have to go to the REST api, which streams them
+const GrpcMaxMessageSize = 256 << 20
+
+type RunInstanceRequest struct {
+	InstanceName  string             `json:"InstanceName"`
+	ImageName     string             `json:"ImageName"`
+	Mounts        map[string]string  `json:"Mounts"`
+	Env           map[string]string  `json:"Env"`
+	MemoryMb      int                `json:"MemoryMb"`
+	VCPUs         int                `json:"VCPUs,omitempty"`
+	NetworkMode   NetworkMode        `json:"NetworkMode,omitempty"`
+	NoCleanup     bool               `json:"NoCleanup"`
+	DebugMode     bool               `json:"DebugMode"`
+	RestartPolicy *RestartPolicy     `json:"RestartPolicy,omitempty"`
+	Ttl           time.Duration      `json:"Ttl"`
+	Tags          map[string]string  `json:"Tags,omitempty"`
+	Ports         []PortMapping      `json:"Ports,omitempty"`
+	Cmdline       string             `json:"Cmdline,omitempty"`
+	CmdlineMode   unikos.CmdlineMode `json:"CmdlineMode,omitempty"`
+	// ReadOnlyMounts are the mount points of Mounts to attach read-only
+	ReadOnlyMounts []string `json:"ReadOnlyMounts,omitempty"`
+	// UserData and MetaData are the files of a cloud-init nocloud data source for the instance
+	UserData string `json:"UserData,omitempty"`
+	MetaData string `json:"MetaData,omitempty"`
+	// IPv6 is a static ipv6 address for the instance
+	IPv6 *IPv6Config `json:"IPv6,omitempty"`
+	// StaticIP is a fixed ipv4 address for the instance instead of one leased by dhcp
+	StaticIP *StaticIPConfig `json:"StaticIP,omitempty"`
+	// Group puts the instance in a group, which can be stopped, restarted and
+	// scaled as a whole
+	Group string `json:"Group,omitempty"`
+	// VolumeMode shares the folder volumes of the instance in this mode (virtiofs
+	// or 9p) instead of the one each was created with
+	VolumeMode VolumeMode `json:"VolumeMode,omitempty"`
+}
+
+// BenchmarkResult is what POST /instances/:instance_id/benchmark returns. Errors
+// has the error of each test ("network", "disk") that failed; the fields of a
+// failed test are left 0
+type BenchmarkResult struct {
+	Instance string        `json:"Instance"`
+	Duration time.Duration `json:"Duration"`
+	// NetworkAddress is where the daemon host reached the iperf3 server of the instance
+	NetworkAddress string  `json:"NetworkAddress"`
+	SentMbps       float64 `json:"SentMbps"`
+	ReceivedMbps   float64 `json:"ReceivedMbps"`
+	DiskWriteBytes int64   `json:"DiskWriteBytes"`
+	DiskWriteMiBps float64 `json:"DiskWriteMiBps"`
+
+	Errors map[string]string `json:"Errors,omitempty"`
+}
+
+// PlatformAlias is what GET /platforms/:platform returns: the provider a build
+// for Platform is made on
+type PlatformAlias struct {
+	Platform string `json:"Platform"`
+	Provider string `json:"Provider"`
+}
+
+// BuildPlan is what POST /images/:name/create with dry_run=true returns instead
+// of building the image, once the build has been validated
+type BuildPlan struct {
+	Name         string            `json:"Name"`
+	Compiler     string            `json:"Compiler"`
+	Provider     string            `json:"Provider"`
+	Architecture Architecture      `json:"Architecture"`
+	Args         string            `json:"Args"`
+	MountPoints  []string          `json:"MountPoints"`
+	Squash       bool              `json:"Squash"`
+	BaseImage    string            `json:"BaseImage,omitempty"`
+	Tags         map[string]string `json:"Tags,omitempty"`
+	MemoryMB     int               `json:"MemoryMB,omitempty"`
+	VCPUs        int               `json:"VCPUs,omitempty"`
+	NetworkMode  NetworkMode       `json:"NetworkMode,omitempty"`
+	Target       string            `json:"Target,omitempty"`
+	// ReplacesImage is set when an image of that name exists, and is deleted by the build (--force)
+	ReplacesImage bool `json:"ReplacesImage"`
+	// SourcesSizeMB is the size of the sources (including squashed volumes) given by the client
+	SourcesSizeMB int64 `json:"SourcesSizeMB"`
+	// EstimatedSizeMB is the size of a boot disk holding the sources and the base
+	// image, before the kernel compiled from them is added
+	EstimatedSizeMB int64 `json:"EstimatedSizeMB"`
+}
+
+// RenameImageRequest is the body of PATCH /images/:image_name
+type RenameImageRequest struct {
+	Name string `json:"Name"`
+}
+
+// RenameVolumeRequest is the body of PATCH /volumes/:volume_name
+type RenameVolumeRequest struct {
+	Name string `json:"Name"`
+}
+
+// CreateManifestRequest maps architectures to the names or ids of their images
+type CreateManifestRequest struct {
+	Name   string                  `json:"Name"`
+	Images map[Architecture]string `json:"Images"`
+}
+
+// ImageGCStatus describes the last run of the image garbage collector
+type ImageGCStatus struct {
+	LastRun       time.Time `json:"LastRun"`
+	ImagesDeleted []string  `json:"ImagesDeleted"`
+	BytesFreed    int64     `json:"BytesFreed"`
+	Error         string    `json:"Error,omitempty"`
+}
+
+// PruneOptions selects the images to delete with POST /images/prune. Images used
+// by an instance are never pruned.
+type PruneOptions struct {
+	// OlderThan selects images created more than this long ago. 0 selects images of any age
+	OlderThan time.Duration `json:"OlderThan"`
+	// Untagged selects only images without tags
+	Untagged bool `json:"Untagged"`
+	// DryRun reports the images that would be deleted without deleting them
+	DryRun bool `json:"DryRun"`
+}
+
+// PruneResult lists the images deleted (or, for a dry run, to be deleted) by
+// POST /images/prune and the space they took
+type PruneResult struct {
+	ImagesDeleted []string `json:"ImagesDeleted"`
+	BytesFreed    int64    `json:"BytesFreed"`
+	DryRun        bool     `json:"DryRun"`
+	Error         string   `json:"Error,omitempty"`
+}
+
+// BatchResult lists the resources a batch operation (e.g. DELETE
+// /instances?all=true) went through for, and the error of each one it failed for
+type BatchResult struct {
+	Succeeded []string          `json:"Succeeded"`
+	Failed    map[string]string `json:"Failed,omitempty"`
+}
+
+// ScaleResult is what POST /groups/:group/scale did to bring the group to Running
+// instances: the members it started and stopped, the instances it ran, and the
+// error of each instance it failed for
+type ScaleResult struct {
+	Group   string            `json:"Group"`
+	Running int               `json:"Running"`
+	Started []string          `json:"Started"`
+	Ran     []string          `json:"Ran"`
+	Stopped []string          `json:"Stopped"`
+	Failed  map[string]string `json:"Failed,omitempty"`
+}
+
+// VolumeDefragResult is what POST /volumes/:volume_name/defrag did. the scores
+// are the fragmentation scores of e4defrag -c (0-30 no problem, 31-55 a little
+// fragmented, 56 and above needs defragmenting)
+type VolumeDefragResult struct {
+	Volume         string `json:"Volume"`
+	FilesystemType string `json:"FilesystemType"`
+	Threshold      int    `json:"Threshold"`
+	ScoreBefore    int    `json:"ScoreBefore"`
+	ScoreAfter     int    `json:"ScoreAfter"`
+	// Defragmented is set when the score was above Threshold and e4defrag ran
+	Defragmented bool `json:"Defragmented"`
+}
+
+// VolumeTrimResult is what POST /volumes/:volume_name/trim did
+type VolumeTrimResult struct {
+	Volume string `json:"Volume"`
+	// TrimmedBytes are the bytes fstrim discarded, including free blocks that
+	// were never written
+	TrimmedBytes int64 `json:"TrimmedBytes"`
+	// ReclaimedBytes is how much less space the volume file takes on the daemon host
+	ReclaimedBytes int64 `json:"ReclaimedBytes"`
+	// Compacted is set when the volume file was rewritten with qemu-img convert
+	Compacted bool `json:"Compacted"`
+}
+
+// UserHeader names the user a request is made for, e.g. to enforce quotas
+const UserHeader = "X-Unik-User"
+
+// QuotaExceeded is the body of 429 responses to requests that would take a
+// user over one of the daemon's quotas
+type QuotaExceeded struct {
+	Code  string `json:"Code"`
+	User  string `json:"User"`
+	Quota string `json:"Quota"`
+	Limit int64  `json:"Limit"`
+	Usage int64  `json:"Usage"`
+}
+
+func (e *QuotaExceeded) Error() string {
+	return fmt.Sprintf("%s: %s of user %s is %d, the request would take it to %d", e.Code, e.Quota, e.User, e.Limit, e.Usage)
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"URL"`
+	Secret string   `json:"Secret"`
+	Events []string `json:"Events"`
+}
+
+type ImageDiff struct {
+	From      string              `json:"From"`
+	To        string              `json:"To"`
+	Changes   []unikos.FileChange `json:"Changes"`
+	SizeDelta int64               `json:"SizeDelta"`
+}
+
+type TagRequest struct {
+	Tags map[string]string `json:"Tags"`
+}
+
+// VolumeDetails is a volume along with what the daemon can find out about its
+// contents. Filesystem and Modified are only known for volumes stored on the daemon host.
+type VolumeDetails struct {
+	Volume         *Volume          `json:"Volume"`
+	FilesystemType string           `json:"FilesystemType,omitempty"`
+	Filesystem     *unikos.Ext2Info `json:"Filesystem,omitempty"`
+	Modified       time.Time        `json:"Modified"`
+}
+
+// ValidationCheck is the result of one of the checks of an image validation.
+// checks that depend on a check that failed are skipped.
+type ValidationCheck struct {
+	Name    string `json:"Name"`
+	Passed  bool   `json:"Passed"`
+	Skipped bool   `json:"Skipped,omitempty"`
+	Message string `json:"Message,omitempty"`
+}
+
+// ValidationReport is the result of validating the boot disk of an image
+type ValidationReport struct {
+	Image  string             `json:"Image"`
+	Valid  bool               `json:"Valid"`
+	Checks []*ValidationCheck `json:"Checks"`
+}
+
+// IncomingMigrationRequest asks a daemon to run an instance that waits on Port
+// for its state to be migrated from the daemon sending the request
+type IncomingMigrationRequest struct {
+	Params        RunInstanceParams `json:"Params"`
+	Port          int               `json:"Port"`
+	RestartPolicy *RestartPolicy    `json:"RestartPolicy,omitempty"`
+	Tags          map[string]string `json:"Tags,omitempty"`
+}
+
+type CreateBackupScheduleRequest struct {
+	CronExpression string `json:"CronExpression"`
+	Destination    string `json:"Destination"`
+	Retention      int    `json:"Retention"`
+}
+
+// VolumeBackups are the backup schedules of volumes and the backups made for
+// them, newest first
+type VolumeBackups struct {
+	Schedules []*BackupSchedule `json:"Schedules"`
+	Backups   []*VolumeBackup   `json:"Backups"`
+}
+
+// ImageDigest is the sha256 of the boot disk of an image, as "sha256:HEX"
+type ImageDigest struct {
+	Image  string `json:"Image"`
+	Digest string `json:"Digest"`
+}
+
+// SignImageRequest carries a signature of the digest of an image, base64
+// encoded, and the PEM of the public key to verify it with
+type SignImageRequest struct {
+	Signature string `json:"Signature"`
+	PublicKey string `json:"PublicKey"`
+}
+
+// ImageSignature is the detached signature the daemon keeps for a signed image
+type ImageSignature struct {
+	Image       string    `json:"Image"`
+	ImageId     string    `json:"ImageId"`
+	Digest      string    `json:"Digest"`
+	Signature   string    `json:"Signature"`
+	PublicKey   string    `json:"PublicKey"`
+	Fingerprint string    `json:"Fingerprint"`
+	Signed      time.Time `json:"Signed"`
+}
+
+// HealthStatus is the body of GET /healthz and GET /readyz. Errors lists what
+// failed, by provider (or "loop_devices")
+type HealthStatus struct {
+	Status  string            `json:"status"`
+	Version string            `json:"version"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// DaemonStatus is the body of GET /admin/status
+type DaemonStatus struct {
+	Version       string                    `json:"Version"`
+	Started       time.Time                 `json:"Started"`
+	UptimeSeconds int64                     `json:"UptimeSeconds"`
+	Providers     map[string]ResourceCounts `json:"Providers"`
+	StateDir      string                    `json:"StateDir"`
+	// FreeDiskBytes is -1 if the free space of StateDir could not be read
+	FreeDiskBytes int64 `json:"FreeDiskBytes"`
+	Goroutines    int   `json:"Goroutines"`
+}
+
+// ResourceCounts counts what the state of a provider holds
+type ResourceCounts struct {
+	Instances int `json:"Instances"`
+	Volumes   int `json:"Volumes"`
+	Images    int `json:"Images"`
+}
+
+// ShutdownResult is the body of the reply to POST /admin/shutdown
+type ShutdownResult struct {
+	// Drained is false if requests were still in flight when the timeout ran out
+	Drained bool `json:"Drained"`
+	// Errors lists the providers whose state could not be written, if any
+	Errors map[string]string `json:"Errors,omitempty"`
+}
@@ -0,0 +1,123 @@
+package types
+
+import "time"
+
+type EventType string
+
+const (
+	EventType_InstanceStarted   EventType = "instance_started"
+	EventType_InstanceStopped   EventType = "instance_stopped"
+	EventType_InstanceCrashed   EventType = "instance_crashed"
+	EventType_InstanceDeleted   EventType = "instance_deleted"
+	EventType_InstanceExpired   EventType = "instance_expired"
+	EventType_InstanceRestarted EventType = "instance_restarted"
+	EventType_InstanceSuspended EventType = "instance_suspended"
+	EventType_InstanceResumed   EventType = "instance_resumed"
+	EventType_InstanceMigrated  EventType = "instance_migrated"
+	EventType_VolumeAttached    EventType = "volume_attached"
+	EventType_VolumeDetached    EventType = "volume_detached"
+	EventType_BuildCompleted    EventType = "build_completed"
+
+	EventType_VolumeMigrationProgress EventType = "volume_migration_progress"
+	EventType_VolumeMigrated          EventType = "volume_migrated"
+	EventType_VolumeBackupCompleted   EventType = "volume_backup_completed"
+	EventType_VolumeBackupFailed      EventType = "volume_backup_failed"
+)
+
+var EventTypes = []EventType{
+	EventType_InstanceStarted,
+	EventType_InstanceStopped,
+	EventType_InstanceCrashed,
+	EventType_InstanceDeleted,
+	EventType_InstanceExpired,
+	EventType_InstanceRestarted,
+	EventType_InstanceSuspended,
+	EventType_InstanceResumed,
+	EventType_InstanceMigrated,
+	EventType_VolumeAttached,
+	EventType_VolumeDetached,
+	EventType_BuildCompleted,
+	EventType_VolumeMigrationProgress,
+	EventType_VolumeMigrated,
+	EventType_VolumeBackupCompleted,
+	EventType_VolumeBackupFailed,
+}
+
+func IsEventType(eventType string) bool {
+	for _, known := range EventTypes {
+		if string(known) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event describes a lifecycle change of an image, instance or volume managed by the daemon.
+// Id is the id of the resource, Payload is the updated resource itself.
+type Event struct {
+	Type      EventType   `json:"type"`
+	Id        string      `json:"id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Payload   interface{} `json:"payload,omitempty"`
+}
+
+func NewInstanceEvent(eventType EventType, instance *Instance) Event {
+	return Event{Type: eventType, Id: instance.Id, Timestamp: time.Now(), Payload: instance}
+}
+
+func NewVolumeEvent(eventType EventType, volume *Volume) Event {
+	return Event{Type: eventType, Id: volume.Id, Timestamp: time.Now(), Payload: volume}
+}
+
+func NewImageEvent(eventType EventType, image *Image) Event {
+	return Event{Type: eventType, Id: image.Id, Timestamp: time.Now(), Payload: image}
+}
+
+// MigrationProgress is the payload of volume_migration_progress events.
+// BytesTotal is 0 while the size of the current stage isn't known.
+type MigrationProgress struct {
+	VolumeName string        `json:"VolumeName"`
+	Stage      string        `json:"Stage"`
+	BytesDone  int64         `json:"BytesDone"`
+	BytesTotal int64         `json:"BytesTotal"`
+	Elapsed    time.Duration `json:"Elapsed"`
+}
+
+func NewMigrationProgressEvent(volumeId string, progress *MigrationProgress) Event {
+	return Event{Type: EventType_VolumeMigrationProgress, Id: volumeId, Timestamp: time.Now(), Payload: progress}
+}
+
+func NewVolumeMigratedEvent(migration *VolumeMigration) Event {
+	return Event{Type: EventType_VolumeMigrated, Id: migration.SourceVolumeId, Timestamp: time.Now(), Payload: migration}
+}
+
+// NewVolumeBackupEvent is a volume_backup_completed or volume_backup_failed event
+func NewVolumeBackupEvent(backup *VolumeBackup) Event {
+	eventType := EventType_VolumeBackupCompleted
+	if backup.Error != "" {
+		eventType = EventType_VolumeBackupFailed
+	}
+	return Event{Type: eventType, Id: backup.VolumeId, Timestamp: time.Now(), Payload: backup}
+}
+
+type Webhook struct {
+	Id      string    `json:"Id"`
+	URL     string    `json:"URL"`
+	Secret  string    `json:"Secret,omitempty"`
+	Events  []string  `json:"Events"` //empty means all events
+	Created time.Time `json:"Created"`
+	// webhooks defined in the daemon config can't be deleted through the api
+	FromConfig bool `json:"FromConfig"`
+}
+
+func (webhook *Webhook) Wants(eventType EventType) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, e := range webhook.Events {
+		if e == string(eventType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Architecture is the cpu architecture an image is built for
+type Architecture string
+
+const (
+	Architecture_AMD64 Architecture = "amd64"
+	Architecture_ARM64 Architecture = "arm64"
+)
+
+var architectures = []Architecture{Architecture_AMD64, Architecture_ARM64}
+
+// ParseArchitecture returns the architecture named s, case-insensitively. "" means amd64
+func ParseArchitecture(s string) (Architecture, error) {
+	if s == "" {
+		return Architecture_AMD64, nil
+	}
+	for _, arch := range architectures {
+		if strings.ToLower(s) == string(arch) {
+			return arch, nil
+		}
+	}
+	names := []string{}
+	for _, arch := range architectures {
+		names = append(names, string(arch))
+	}
+	return "", fmt.Errorf("unknown architecture %q. Available: %s", s, strings.Join(names, "|"))
+}
+
+// ImageManifest lets one name stand for images of the same program built for
+// different architectures. Images maps each architecture to the id of its image.
+type ImageManifest struct {
+	Name    string                  `json:"Name"`
+	Images  map[Architecture]string `json:"Images"`
+	Created time.Time               `json:"Created"`
+}
@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+// InstanceMetrics is a sample of the resource usage of an instance. the cpu
+// times are cumulative, so utilization is worked out from two samples
+type InstanceMetrics struct {
+	Time  time.Time     `json:"Time"`
+	VCPUs []VCPUMetrics `json:"VCPUs"`
+	// MemoryBytes is the memory of the instance: the size of its memory balloon if
+	// it has one, or else the memory it was run with. 0 if the provider cannot tell
+	MemoryBytes int64 `json:"MemoryBytes"`
+	// MemoryUsedBytes is the memory the instance takes on its host, e.g. the
+	// resident set of its qemu process. 0 if the provider cannot tell
+	MemoryUsedBytes int64 `json:"MemoryUsedBytes"`
+}
+
+// VCPUMetrics is the cpu time a virtual cpu of an instance ran for so far
+type VCPUMetrics struct {
+	Index   int           `json:"Index"`
+	CPUTime time.Duration `json:"CPUTime"`
+}
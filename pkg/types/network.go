@@ -0,0 +1,166 @@
+package types
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// NetworkMode is how an instance is attached to the network of the host running it.
+// only the local hypervisor providers (qemu, virtualbox) honor it; the cloud providers
+// always use their own networking
+type NetworkMode string
+
+const (
+	// NetworkMode_Host attaches the instance to a network shared with the host
+	// (a tap device on qemu, a host-only adapter on virtualbox)
+	NetworkMode_Host NetworkMode = "host"
+	// NetworkMode_Nat puts the instance behind the hypervisor's nat; the default
+	NetworkMode_Nat NetworkMode = "nat"
+	// NetworkMode_Bridge bridges the instance onto the network of the host
+	NetworkMode_Bridge NetworkMode = "bridge"
+)
+
+// ParseNetworkMode parses a network mode. the empty string is returned as is,
+// meaning the default networking of the provider
+func ParseNetworkMode(mode string) (NetworkMode, error) {
+	switch NetworkMode(mode) {
+	case NetworkMode_Host, NetworkMode_Nat, NetworkMode_Bridge, "":
+		return NetworkMode(mode), nil
+	}
+	return "", fmt.Errorf("unknown network mode %q; must be one of %s|%s|%s", mode, NetworkMode_Host, NetworkMode_Nat, NetworkMode_Bridge)
+}
+
+// NetworkInterface is a network interface of an instance, as the provider (or the
+// guest) reports it. fields the provider cannot tell are left empty
+type NetworkInterface struct {
+	Name        string   `json:"Name"`
+	MACAddress  string   `json:"MACAddress"`
+	IPAddresses []string `json:"IPAddresses"`
+	MTU         int      `json:"MTU,omitempty"`
+}
+
+// DefaultIPv6PrefixLength is the prefix length of an ipv6 address given without one
+const DefaultIPv6PrefixLength = 64
+
+// IPv6Config is a static ipv6 address for an instance, with the prefix length of
+// its network and optionally the address of its router
+type IPv6Config struct {
+	Address      string `json:"Address"`
+	PrefixLength int    `json:"PrefixLength"`
+	Gateway      string `json:"Gateway,omitempty"`
+}
+
+// Validate checks that the address and gateway are ipv6 addresses, and that the
+// gateway is in the network of the address
+func (c *IPv6Config) Validate() error {
+	address, err := parseIPv6("address", c.Address)
+	if err != nil {
+		return err
+	}
+	if c.PrefixLength < 1 || c.PrefixLength > 128 {
+		return fmt.Errorf("ipv6 prefix length %d must be between 1 and 128", c.PrefixLength)
+	}
+	if c.Gateway == "" {
+		return nil
+	}
+	gateway, err := parseIPv6("gateway", c.Gateway)
+	if err != nil {
+		return err
+	}
+	network := &net.IPNet{IP: address.Mask(c.mask()), Mask: c.mask()}
+	if !network.Contains(gateway) {
+		return fmt.Errorf("ipv6 gateway %s is not in the network %s of the address", c.Gateway, network)
+	}
+	return nil
+}
+
+// Network returns the network of the address in CIDR notation, e.g. fd00::/64
+func (c *IPv6Config) Network() string {
+	network := &net.IPNet{IP: net.ParseIP(c.Address).Mask(c.mask()), Mask: c.mask()}
+	return network.String()
+}
+
+func (c *IPv6Config) mask() net.IPMask {
+	return net.CIDRMask(c.PrefixLength, 128)
+}
+
+// parseIPv6 parses an ipv6 address, rejecting ipv4 (and ipv4-mapped ipv6) addresses
+func parseIPv6(what, s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("ipv6 %s %q is not an ip address", what, s)
+	}
+	if ip.To4() != nil {
+		return nil, fmt.Errorf("ipv6 %s %q is an ipv4 address", what, s)
+	}
+	return ip, nil
+}
+
+// StaticIPConfig is a fixed ipv4 address for an instance, instead of one leased by dhcp
+type StaticIPConfig struct {
+	// Address is the address with the prefix length of its network, e.g. 192.168.1.100/24
+	Address string   `json:"Address"`
+	Gateway string   `json:"Gateway,omitempty"`
+	DNS     []string `json:"DNS,omitempty"`
+}
+
+// Validate checks that the address is an ipv4 cidr, that the gateway is in its
+// network, and that the dns servers are ip addresses
+func (c *StaticIPConfig) Validate() error {
+	ip, network, err := net.ParseCIDR(c.Address)
+	if err != nil {
+		return fmt.Errorf("static ip %q must be given as address/prefix-length, e.g. 192.168.1.100/24", c.Address)
+	}
+	if ip.To4() == nil {
+		return fmt.Errorf("static ip %q is not an ipv4 address", c.Address)
+	}
+	if c.Gateway != "" {
+		gateway := net.ParseIP(c.Gateway)
+		if gateway == nil || gateway.To4() == nil {
+			return fmt.Errorf("gateway %q is not an ipv4 address", c.Gateway)
+		}
+		if !network.Contains(gateway) {
+			return fmt.Errorf("gateway %s is not in the network %s of the static ip", c.Gateway, network)
+		}
+	}
+	for _, dns := range c.DNS {
+		if net.ParseIP(dns) == nil {
+			return fmt.Errorf("dns server %q is not an ip address", dns)
+		}
+	}
+	return nil
+}
+
+// IP returns the address without its prefix length
+func (c *StaticIPConfig) IP() string {
+	ip, _, _ := net.ParseCIDR(c.Address)
+	return ip.String()
+}
+
+// Network returns the network of the address, e.g. 192.168.1.0/24
+func (c *StaticIPConfig) Network() string {
+	_, network, _ := net.ParseCIDR(c.Address)
+	return network.String()
+}
+
+// Netmask returns the netmask of the network of the address, e.g. 255.255.255.0
+func (c *StaticIPConfig) Netmask() string {
+	_, network, _ := net.ParseCIDR(c.Address)
+	return net.IP(network.Mask).String()
+}
+
+// KernelArg returns the address as the ip= kernel parameter of linux (and the
+// unikernels that follow it): ip=client::gateway:netmask:hostname::off:dns0:dns1
+func (c *StaticIPConfig) KernelArg(hostname string) string {
+	fields := []string{c.IP(), "", c.Gateway, c.Netmask(), hostname, "", "off"}
+	fields = append(fields, c.DNS...)
+	return "ip=" + strings.Join(fields, ":")
+}
+
+// ExecResult is the outcome of a command run in an instance
+type ExecResult struct {
+	ExitCode int    `json:"ExitCode"`
+	Stdout   string `json:"Stdout"`
+	Stderr   string `json:"Stderr"`
+}
@@ -1,6 +1,9 @@
 package types
 
-import "github.com/emc-advanced-dev/unik/pkg/config"
+import (
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+)
 
 type RunInstanceParams struct {
 	Name                 string
@@ -8,8 +11,32 @@ type RunInstanceParams struct {
 	MntPointsToVolumeIds map[string]string
 	Env                  map[string]string
 	InstanceMemory       int
+	VCPUs                int
+	NetworkMode          NetworkMode
 	NoCleanup            bool
 	DebugMode            bool
+	Tags                 map[string]string
+	PortMappings         []PortMapping
+	// Cmdline is combined with the command line of the image as CmdlineMode says
+	Cmdline     string
+	CmdlineMode unikos.CmdlineMode
+	// ReadOnlyMntPoints are the mount points of MntPointsToVolumeIds whose
+	// volumes the instance may not write to
+	ReadOnlyMntPoints []string
+	// UserData and MetaData are given to the instance on a cloud-init nocloud iso.
+	// no iso is attached if both are empty
+	UserData string
+	MetaData string
+	// IPv6 is a static ipv6 address for the instance. nil for none
+	IPv6 *IPv6Config
+	// StaticIP is a fixed ipv4 address for the instance. nil to use dhcp
+	StaticIP *StaticIPConfig
+	// IncomingMigration is where the instance waits for its state to be migrated
+	// from another host (e.g. tcp::4444) instead of booting. empty to boot
+	IncomingMigration string
+	// VolumeMode shares all the folder volumes of the instance in this mode
+	// (virtiofs or 9p). empty shares each in the mode it was created with
+	VolumeMode VolumeMode
 }
 
 type StageImageParams struct {
@@ -20,9 +47,12 @@ type StageImageParams struct {
 }
 
 type CreateVolumeParams struct {
-	Name      string
+	Name string
+	// ImagePath is the raw image of a block volume, or the directory holding
+	// the data of a folder (virtiofs or 9p) volume
 	ImagePath string
 	NoCleanup bool
+	Mode      VolumeMode
 }
 
 type CompileImageParams struct {
@@ -31,6 +61,12 @@ type CompileImageParams struct {
 	MntPoints  []string
 	NoCleanup  bool
 	SizeMB     int
+	// SquashDirs are folders to copy into the boot partition instead of
+	// attaching volumes, keyed by the mount point they are copied to
+	SquashDirs map[string]string
+	// Target selects the output of compilers that build for more than one
+	// target, e.g. the mirage target (xen|virtio|unix). empty for the default
+	Target string
 }
 
 type PullImagePararms struct {
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PortMapping forwards a port on the host running an instance to a port of the instance
+type PortMapping struct {
+	HostPort  int    `json:"HostPort"`
+	GuestPort int    `json:"GuestPort"`
+	Protocol  string `json:"Protocol"` //tcp or udp
+}
+
+func (m PortMapping) String() string {
+	return fmt.Sprintf("%d:%d/%s", m.HostPort, m.GuestPort, m.Protocol)
+}
+
+// ParsePortMapping parses a port mapping given as host:guest, optionally
+// followed by /tcp (the default) or /udp, e.g. 8080:80 or 5353:53/udp
+func ParsePortMapping(s string) (PortMapping, error) {
+	mapping := PortMapping{Protocol: "tcp"}
+	ports := s
+	if i := strings.Index(s, "/"); i >= 0 {
+		ports, mapping.Protocol = s[:i], strings.ToLower(s[i+1:])
+	}
+	if mapping.Protocol != "tcp" && mapping.Protocol != "udp" {
+		return mapping, fmt.Errorf("port mapping %q: protocol must be tcp or udp", s)
+	}
+	hostGuest := strings.Split(ports, ":")
+	if len(hostGuest) != 2 {
+		return mapping, fmt.Errorf("port mapping %q must be given as host:guest", s)
+	}
+	var err error
+	if mapping.HostPort, err = parsePort(hostGuest[0]); err != nil {
+		return mapping, fmt.Errorf("port mapping %q: host %v", s, err)
+	}
+	if mapping.GuestPort, err = parsePort(hostGuest[1]); err != nil {
+		return mapping, fmt.Errorf("port mapping %q: guest %v", s, err)
+	}
+	return mapping, nil
+}
+
+// ValidatePortMappings checks that ports are in range, and that no host port is forwarded twice
+func ValidatePortMappings(mappings []PortMapping) error {
+	hostPorts := make(map[string]bool)
+	for _, mapping := range mappings {
+		if !validPort(mapping.HostPort) || !validPort(mapping.GuestPort) {
+			return fmt.Errorf("port mapping %s: ports must be between 1 and 65535", mapping)
+		}
+		if mapping.Protocol != "tcp" && mapping.Protocol != "udp" {
+			return fmt.Errorf("port mapping %s: protocol must be tcp or udp", mapping)
+		}
+		hostPort := fmt.Sprintf("%d/%s", mapping.HostPort, mapping.Protocol)
+		if hostPorts[hostPort] {
+			return fmt.Errorf("host port %s is forwarded more than once", hostPort)
+		}
+		hostPorts[hostPort] = true
+	}
+	return nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || !validPort(port) {
+		return 0, fmt.Errorf("port %q must be a number between 1 and 65535", s)
+	}
+	return port, nil
+}
+
+func validPort(port int) bool {
+	return port >= 1 && port <= 65535
+}
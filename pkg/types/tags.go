@@ -0,0 +1,59 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTags is the number of tags a single image, instance or volume can have
+	MaxTags = 50
+	// MaxTagKeyLength is the longest tag key allowed, in characters
+	MaxTagKeyLength = 128
+)
+
+// ParseTags parses tags given as key=value, e.g. on the command line
+func ParseTags(tags []string) (map[string]string, error) {
+	parsed := make(map[string]string)
+	for _, tag := range tags {
+		keyValue := strings.SplitN(tag, "=", 2)
+		if len(keyValue) != 2 || keyValue[0] == "" {
+			return nil, fmt.Errorf("tag %q must be given as key=value", tag)
+		}
+		parsed[keyValue[0]] = keyValue[1]
+	}
+	return parsed, nil
+}
+
+// HasTags reports whether tags include every key=value in want
+func HasTags(tags, want map[string]string) bool {
+	for key, value := range want {
+		if tagValue, ok := tags[key]; !ok || tagValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateTags checks tags against the rules shared by every tagged resource:
+// keys must be non-empty, at most MaxTagKeyLength characters and contain no
+// whitespace, and there may be no more than MaxTags tags.
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > MaxTags {
+		return fmt.Errorf("%d tags given, a resource can have at most %d", len(tags), MaxTags)
+	}
+	for key := range tags {
+		if key == "" {
+			return fmt.Errorf("tag keys must not be empty")
+		}
+		if utf8.RuneCountInString(key) > MaxTagKeyLength {
+			return fmt.Errorf("tag key %q is longer than %d characters", key, MaxTagKeyLength)
+		}
+		if strings.IndexFunc(key, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("tag key %q must not contain spaces", key)
+		}
+	}
+	return nil
+}
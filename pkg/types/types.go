@@ -16,6 +16,7 @@ const (
 	InstanceState_Error      InstanceState = "error"
 	InstanceState_Paused     InstanceState = "paused"
 	InstanceState_Suspended  InstanceState = "suspended"
+	InstanceState_Expired    InstanceState = "expired"
 )
 
 type Infrastructure string
@@ -30,16 +31,39 @@ const (
 	Infrastructure_XEN        Infrastructure = "XEN"
 	Infrastructure_OPENSTACK  Infrastructure = "OPENSTACK"
 	Infrastructure_UKVM       Infrastructure = "UKVM"
+	Infrastructure_LIBVIRT    Infrastructure = "LIBVIRT"
 )
 
 type Image struct {
-	Id             string         `json:"Id"`
-	Name           string         `json:"Name"`
-	SizeMb         int64          `json:"SizeMb"`
-	Infrastructure Infrastructure `json:"Infrastructure"`
-	Created        time.Time      `json:"Created"`
-	StageSpec      StageSpec      `json:"StageSpec"`
-	RunSpec        RunSpec        `json:"RunSpec"`
+	Id             string            `json:"Id"`
+	Name           string            `json:"Name"`
+	SizeMb         int64             `json:"SizeMb"`
+	Infrastructure Infrastructure    `json:"Infrastructure"`
+	Created        time.Time         `json:"Created"`
+	StageSpec      StageSpec         `json:"StageSpec"`
+	RunSpec        RunSpec           `json:"RunSpec"`
+	Tags           map[string]string `json:"Tags,omitempty"`
+	// images from before architectures were recorded are amd64
+	Architecture Architecture `json:"Architecture,omitempty"`
+	// DefaultMemoryMB is the memory given to instances run without a memory size.
+	// 0 means the default of the compiler (RunSpec.DefaultInstanceMemory)
+	DefaultMemoryMB int `json:"DefaultMemoryMB,omitempty"`
+	// DefaultVCPUs is the number of virtual cpus given to instances run without
+	// a vcpu count. 0 means a single vcpu
+	DefaultVCPUs int `json:"DefaultVCPUs,omitempty"`
+	// NetworkMode is the network mode of instances run without one.
+	// empty means the default networking of the provider
+	NetworkMode NetworkMode `json:"NetworkMode,omitempty"`
+	// BaseImage is the name of the image this image was built on (build --base-image).
+	// empty for images built from their sources alone
+	BaseImage string `json:"BaseImage,omitempty"`
+	// SignatureKeyFingerprint is the fingerprint of the public key the image was
+	// signed with (unik sign-image). empty for unsigned images
+	SignatureKeyFingerprint string `json:"SignatureKeyFingerprint,omitempty"`
+	// KeptArtifacts are the paths on the daemon host of the sources and build
+	// artifacts that a build with NoCleanup kept. only set on the image the build
+	// returns, not on the image in the state
+	KeptArtifacts []string `json:"KeptArtifacts,omitempty"`
 }
 
 // For Unik Hub
@@ -56,13 +80,27 @@ func (image *Image) String() string {
 }
 
 type Instance struct {
-	Id             string         `json:"Id"`
-	Name           string         `json:"Name"`
-	State          InstanceState  `json:"State"`
-	IpAddress      string         `json:"IpAddress"`
-	ImageId        string         `json:"ImageId"`
-	Infrastructure Infrastructure `json:"Infrastructure"`
-	Created        time.Time      `json:"Created"`
+	Id             string            `json:"Id"`
+	Name           string            `json:"Name"`
+	State          InstanceState     `json:"State"`
+	IpAddress      string            `json:"IpAddress"`
+	ImageId        string            `json:"ImageId"`
+	Infrastructure Infrastructure    `json:"Infrastructure"`
+	Created        time.Time         `json:"Created"`
+	RestartPolicy  *RestartPolicy    `json:"RestartPolicy,omitempty"`
+	RestartCount   int               `json:"RestartCount"`
+	ExpiresAt      time.Time         `json:"ExpiresAt"`        //zero if the instance never expires
+	Mounts         map[string]string `json:"Mounts,omitempty"` //mount point to volume id, as given to run
+	Tags           map[string]string `json:"Tags,omitempty"`
+	Ports          []PortMapping     `json:"Ports,omitempty"`
+	// VncPort is the port of the vnc display of the instance on the daemon host. 0 if it has none
+	VncPort int `json:"VncPort,omitempty"`
+	// IPv6Addresses are the static ipv6 addresses given to the instance with run --ipv6-address
+	IPv6Addresses []string `json:"IPv6Addresses,omitempty"`
+	// Snapshot is the snapshot the instance was last suspended to with unik suspend
+	Snapshot string `json:"Snapshot,omitempty"`
+	// Group is the fleet the instance was run in with run --group, empty for none
+	Group string `json:"Group,omitempty"`
 }
 
 func (instance *Instance) String() string {
@@ -72,13 +110,117 @@ func (instance *Instance) String() string {
 	return fmt.Sprintf("%+v", *instance)
 }
 
+type RestartMode string
+
+const (
+	RestartMode_Always    RestartMode = "always"
+	RestartMode_OnFailure RestartMode = "on-failure"
+	RestartMode_Never     RestartMode = "never"
+)
+
+// RestartPolicy tells the daemon whether (and how often) to restart an
+// instance that has stopped or crashed. MaxRestarts of 0 means no limit.
+type RestartPolicy struct {
+	Mode           RestartMode `json:"Mode"`
+	MaxRestarts    int         `json:"MaxRestarts"`
+	BackoffSeconds int         `json:"BackoffSeconds"`
+}
+
+func ParseRestartMode(mode string) (RestartMode, error) {
+	switch RestartMode(mode) {
+	case RestartMode_Always, RestartMode_OnFailure, RestartMode_Never:
+		return RestartMode(mode), nil
+	case "":
+		return RestartMode_Never, nil
+	}
+	return "", fmt.Errorf("unknown restart policy %q; must be one of %s|%s|%s", mode, RestartMode_Always, RestartMode_OnFailure, RestartMode_Never)
+}
+
+// VolumeMode is how the data of a volume reaches the instances it is mounted on
+type VolumeMode string
+
+const (
+	// VolumeMode_Block volumes are a disk image with a filesystem, attached as a block device
+	VolumeMode_Block VolumeMode = "block"
+	// VolumeMode_VirtioFS volumes are a directory on the host, shared with
+	// instances through virtiofsd and a vhost-user-fs device (qemu only)
+	VolumeMode_VirtioFS VolumeMode = "virtiofs"
+	// VolumeMode_9P volumes are a directory on the host, shared with instances
+	// by qemu itself over virtio-9p
+	VolumeMode_9P VolumeMode = "9p"
+)
+
+func ParseVolumeMode(mode string) (VolumeMode, error) {
+	switch VolumeMode(mode) {
+	case VolumeMode_Block, VolumeMode_VirtioFS, VolumeMode_9P:
+		return VolumeMode(mode), nil
+	case "":
+		return VolumeMode_Block, nil
+	}
+	return "", fmt.Errorf("unknown volume mode %q; must be one of %s|%s|%s", mode, VolumeMode_Block, VolumeMode_VirtioFS, VolumeMode_9P)
+}
+
+// IsFolder is true for the modes of volumes that are a directory on the host
+func (m VolumeMode) IsFolder() bool {
+	return m == VolumeMode_VirtioFS || m == VolumeMode_9P
+}
+
 type Volume struct {
-	Id             string         `json:"Id"`
-	Name           string         `json:"Name"`
-	SizeMb         int64          `json:"SizeMb"`
-	Attachment     string         `json:"Attachment"` //instanceId
-	Infrastructure Infrastructure `json:"Infrastructure"`
-	Created        time.Time      `json:"Created"`
+	Id             string            `json:"Id"`
+	Name           string            `json:"Name"`
+	SizeMb         int64             `json:"SizeMb"`
+	Attachment     string            `json:"Attachment"` //instanceId
+	Infrastructure Infrastructure    `json:"Infrastructure"`
+	Created        time.Time         `json:"Created"`
+	Tags           map[string]string `json:"Tags,omitempty"`
+	// volumes from before modes were recorded are block volumes
+	Mode VolumeMode `json:"Mode,omitempty"`
+}
+
+// IsFolder is true for volumes shared with instances as a host directory
+func (v *Volume) IsFolder() bool {
+	return v.Mode.IsFolder()
+}
+
+// VolumeMigration records a volume copied from one provider to another.
+// the source volume is left in place until it is deleted explicitly.
+type VolumeMigration struct {
+	VolumeName     string    `json:"VolumeName"`
+	SourceProvider string    `json:"SourceProvider"`
+	SourceVolumeId string    `json:"SourceVolumeId"`
+	TargetProvider string    `json:"TargetProvider"`
+	TargetVolumeId string    `json:"TargetVolumeId"`
+	SizeMb         int64     `json:"SizeMb"`
+	Started        time.Time `json:"Started"`
+	Completed      time.Time `json:"Completed"`
+}
+
+// BackupSchedule has the daemon back up a volume whenever CronExpression matches
+// the time, to Destination: a directory on the daemon host or an s3://bucket/prefix
+// url. the newest Retention backups are kept; 0 keeps all of them
+type BackupSchedule struct {
+	Id             string    `json:"Id"`
+	VolumeName     string    `json:"VolumeName"`
+	CronExpression string    `json:"CronExpression"`
+	Destination    string    `json:"Destination"`
+	Retention      int       `json:"Retention"`
+	Created        time.Time `json:"Created"`
+	// NextRun is when the volume is backed up next. zero if the daemon does not know
+	NextRun time.Time `json:"NextRun"`
+}
+
+// VolumeBackup is a copy of the data of a volume made for a BackupSchedule, at
+// Location. if the backup failed, Error says why and Location is empty
+type VolumeBackup struct {
+	Id         string    `json:"Id"`
+	ScheduleId string    `json:"ScheduleId"`
+	VolumeName string    `json:"VolumeName"`
+	VolumeId   string    `json:"VolumeId"`
+	Location   string    `json:"Location,omitempty"`
+	SizeBytes  int64     `json:"SizeBytes"`
+	Started    time.Time `json:"Started"`
+	Completed  time.Time `json:"Completed"`
+	Error      string    `json:"Error,omitempty"`
 }
 
 func (volume *Volume) String() string {
@@ -102,6 +244,8 @@ const (
 	ImageFormat_VHD    ImageFormat = "vhd"
 	ImageFormat_VMDK   ImageFormat = "vmdk"
 	ImageFormat_Folder ImageFormat = "folder"
+	// a kernel booted without a disk, with its cmdline file next to it
+	ImageFormat_Kernel ImageFormat = "kernel"
 )
 
 type XenVirtualizationType string
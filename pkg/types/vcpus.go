@@ -0,0 +1,15 @@
+package types
+
+import "fmt"
+
+// MaxVCPUs is the most virtual cpus an image or instance can be given
+const MaxVCPUs = 256
+
+// ValidateVCPUs checks that n is a usable number of virtual cpus.
+// 0 is not valid here; callers treat it as "not set" before validating
+func ValidateVCPUs(n int) error {
+	if n < 1 || n > MaxVCPUs {
+		return fmt.Errorf("vcpus must be between 1 and %d, got %d", MaxVCPUs, n)
+	}
+	return nil
+}
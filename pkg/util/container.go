@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/pkg/errors"
@@ -103,21 +105,76 @@ func (c *Container) Run(arguments ...string) error {
 
 	LogCommand(cmd, true)
 
+	defer trackRunning(c)()
 	return cmd.Run()
 }
 
 func (c *Container) Output(arguments ...string) ([]byte, error) {
-	return c.BuildCmd(arguments...).Output()
+	cmd := c.BuildCmd(arguments...)
+	defer trackRunning(c)()
+	return cmd.Output()
 }
 
 func (c *Container) CombinedOutput(arguments ...string) ([]byte, error) {
-	return c.BuildCmd(arguments...).CombinedOutput()
+	cmd := c.BuildCmd(arguments...)
+	defer trackRunning(c)()
+	return cmd.CombinedOutput()
 }
 
 func (c *Container) Stop() error {
 	return exec.Command("docker", "stop", c.containerName).Run()
 }
 
+func (c *Container) Kill() error {
+	return exec.Command("docker", "kill", c.containerName).Run()
+}
+
+// the containers being run, by container name
+var running = struct {
+	sync.Mutex
+	containers map[string]*Container
+}{containers: make(map[string]*Container)}
+
+// trackRunning records c as running until the returned func is called
+func trackRunning(c *Container) func() {
+	running.Lock()
+	running.containers[c.containerName] = c
+	running.Unlock()
+	return func() {
+		running.Lock()
+		delete(running.containers, c.containerName)
+		running.Unlock()
+	}
+}
+
+// KillContainersUsing kills the running containers that have dir, or a folder
+// under it, mounted, e.g. the compiler containers of a build given its sources
+// dir. it returns the names of the containers killed
+func KillContainersUsing(dir string) []string {
+	dir = filepath.Clean(dir)
+	running.Lock()
+	var using []*Container
+	for _, c := range running.containers {
+		for hostdir := range c.volumes {
+			hostdir = filepath.Clean(hostdir)
+			if hostdir == dir || strings.HasPrefix(hostdir, dir+string(filepath.Separator)) {
+				using = append(using, c)
+				break
+			}
+		}
+	}
+	running.Unlock()
+	var killed []string
+	for _, c := range using {
+		if err := c.Kill(); err != nil {
+			logrus.WithError(err).Warnf("failed to kill container %s", c.containerName)
+			continue
+		}
+		killed = append(killed, c.containerName)
+	}
+	return killed
+}
+
 func (c *Container) BuildCmd(arguments ...string) *exec.Cmd {
 	if c.containerName == "" {
 		c.containerName = uuid.New()
@@ -14,7 +14,10 @@ import (
 	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 )
 
-func BuildRawDataImageWithType(dataTar io.ReadCloser, size unikos.MegaBytes, volType string, usePartitionTables bool) (string, error) {
+// BuildRawDataImageWithType builds a volume of type volType from dataTar. with
+// usePartitionTables, it is on a partition of a partitionTable (see
+// unikos.PartitionerRegistry; empty for unikos.DefaultPartitionTable)
+func BuildRawDataImageWithType(dataTar io.ReadCloser, size unikos.MegaBytes, volType string, usePartitionTables bool, partitionTable string) (string, error) {
 	buildDir, err := ioutil.TempDir("", ".raw_data_image_folder.")
 	if err != nil {
 		return "", errors.New("creating tmp build folder", err)
@@ -50,6 +53,9 @@ func BuildRawDataImageWithType(dataTar io.ReadCloser, size unikos.MegaBytes, vol
 		)
 	}
 	args = append(args, "-t", volType)
+	if partitionTable != "" {
+		args = append(args, "-partition-table", partitionTable)
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"command": args,
@@ -72,9 +78,11 @@ func BuildRawDataImageWithType(dataTar io.ReadCloser, size unikos.MegaBytes, vol
 }
 
 func BuildRawDataImage(dataTar io.ReadCloser, size unikos.MegaBytes, usePartitionTables bool) (string, error) {
-	return BuildRawDataImageWithType(dataTar, size, "ext2", usePartitionTables)
+	return BuildRawDataImageWithType(dataTar, size, "ext2", usePartitionTables, "")
 }
-func BuildEmptyDataVolumeWithType(size unikos.MegaBytes, volType string) (string, error) {
+// BuildEmptyDataVolumeWithType builds an empty volume of type volType, on a
+// partition of a partitionTable (empty for unikos.DefaultPartitionTable)
+func BuildEmptyDataVolumeWithType(size unikos.MegaBytes, volType string, partitionTable string) (string, error) {
 
 	if size < 1 {
 		return "", errors.New("must specify size > 0", nil)
@@ -97,6 +105,9 @@ func BuildEmptyDataVolumeWithType(size unikos.MegaBytes, volType string) (string
 	tmpResultFile.Close()
 	args := []string{"-v", fmt.Sprintf("%s,%v", filepath.Base(dataFolder), size.ToBytes()), "-o", filepath.Base(tmpResultFile.Name())}
 	args = append(args, "-t", volType)
+	if partitionTable != "" {
+		args = append(args, "-partition-table", partitionTable)
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"command": args,
@@ -117,6 +128,60 @@ func BuildEmptyDataVolumeWithType(size unikos.MegaBytes, volType string) (string
 	return resultFile.Name(), nil
 }
 
+// BuildEmptyVolume creates a blank volume of size formatted with fstype, with no
+// partition table and without copying any data
+func BuildEmptyVolume(size unikos.DiskSize, fstype unikos.FilesystemType) (string, error) {
+	buildDir, err := ioutil.TempDir("", ".empty_volume_folder.")
+	if err != nil {
+		return "", errors.New("creating tmp build folder", err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	container := NewContainer("image-creator").Privileged(true).WithVolume("/dev/", "/dev/").
+		WithVolume(buildDir+"/", "/opt/vol")
+
+	tmpResultFile, err := ioutil.TempFile(buildDir, "empty.volume.result.img.")
+	if err != nil {
+		return "", err
+	}
+	tmpResultFile.Close()
+	args := []string{"-p", "false", "-empty", fmt.Sprintf("%v", int64(size.ToBytes())), "-t", string(fstype), "-o", filepath.Base(tmpResultFile.Name())}
+
+	logrus.WithFields(logrus.Fields{
+		"command": args,
+	}).Debugf("running image-creator container")
+	if err := container.Run(args...); err != nil {
+		return "", errors.New("failed running image-creator for empty volume", err)
+	}
+
+	resultFile, err := ioutil.TempFile("", "empty-volume-result.img.")
+	if err != nil {
+		return "", err
+	}
+	resultFile.Close()
+	if err := os.Rename(tmpResultFile.Name(), resultFile.Name()); err != nil {
+		return "", errors.New("renaming "+tmpResultFile.Name()+" to "+resultFile.Name(), err)
+	}
+	return resultFile.Name(), nil
+}
+
 func BuildEmptyDataVolume(size unikos.MegaBytes) (string, error) {
-	return BuildEmptyDataVolumeWithType(size, "ext2")
+	return BuildEmptyDataVolumeWithType(size, "ext2", "")
+}
+
+// BuildDataDir extracts dataTar to a new tmp folder, the data of a folder
+// (virtiofs or 9p) volume. without dataTar the folder is empty
+func BuildDataDir(dataTar io.ReadCloser) (string, error) {
+	dataFolder, err := ioutil.TempDir("", ".data_folder.")
+	if err != nil {
+		return "", errors.New("creating tmp data folder", err)
+	}
+	if dataTar == nil {
+		return dataFolder, nil
+	}
+	if err := unikos.ExtractTar(dataTar, dataFolder); err != nil {
+		os.RemoveAll(dataFolder)
+		return "", errors.New("extracting data tar", err)
+	}
+	return dataFolder, nil
 }
@@ -0,0 +1,131 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+)
+
+// ParsePrivateKeyPEM reads an unencrypted ecdsa, rsa or ed25519 private key in
+// PKCS#8, SEC 1 ("EC PRIVATE KEY") or PKCS#1 ("RSA PRIVATE KEY") PEM
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in private key", nil)
+	}
+	if block.Headers["Proc-Type"] != "" || block.Type == "ENCRYPTED PRIVATE KEY" || block.Type == "ENCRYPTED COSIGN PRIVATE KEY" {
+		return nil, errors.New("private key is encrypted, decrypt it first (e.g. with openssl pkey)", nil)
+	}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.New("parsing ec private key", err)
+		}
+		return key, nil
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.New("parsing rsa private key", err)
+		}
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("parsing private key", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type", nil)
+	}
+	return signer, nil
+}
+
+// ParsePublicKeyPEM reads an ecdsa, rsa or ed25519 public key in PKIX
+// ("PUBLIC KEY") or PKCS#1 ("RSA PUBLIC KEY") PEM
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in public key", nil)
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.New("parsing rsa public key", err)
+		}
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("parsing public key", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+	return nil, errors.New("unsupported public key type", nil)
+}
+
+// EncodePublicKeyPEM returns the PKIX PEM of key
+func EncodePublicKeyPEM(key crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, errors.New("marshalling public key", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// PublicKeyFingerprint is the hex sha256 of the PKIX encoding of key, prefixed
+// with "SHA256:"
+func PublicKeyFingerprint(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", errors.New("marshalling public key", err)
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// SignDigest signs the sha256 digest of a file. ecdsa signatures are ASN.1 and
+// rsa signatures PKCS#1 v1.5, as openssl dgst -sha256 -sign and cosign sign-blob
+// make them for the file itself. ed25519 signs the digest bytes
+func SignDigest(key crypto.Signer, digest []byte) ([]byte, error) {
+	var opts crypto.SignerOpts = crypto.SHA256
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		opts = crypto.Hash(0)
+	}
+	signature, err := key.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, errors.New("signing digest", err)
+	}
+	return signature, nil
+}
+
+// VerifyDigest checks a signature made by SignDigest
+func VerifyDigest(key crypto.PublicKey, digest, signature []byte) error {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return errors.New("invalid ecdsa signature", nil)
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature); err != nil {
+			return errors.New("invalid rsa signature", err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, digest, signature) {
+			return errors.New("invalid ed25519 signature", nil)
+		}
+	default:
+		return errors.New("unsupported public key type", nil)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+# builds the go httpd example, and runs it with a data volume on virtualbox:
+#   terraform init && terraform apply
+#   curl http://$(terraform output -raw ip_address):8080
+
+provider "unik" {
+  host = "localhost:3000"
+}
+
+locals {
+  sources = "${path.module}/../../docs/examples/example_go_httpd"
+}
+
+resource "unik_image" "httpd" {
+  name          = "httpd"
+  path          = local.sources
+  base          = "rump"
+  language      = "go"
+  provider_name = "virtualbox"
+  mount_points  = ["/data"]
+
+  # rebuild the image when the sources change
+  triggers = {
+    sources = sha1(join("", [for f in fileset(local.sources, "**") : filesha1("${local.sources}/${f}")]))
+  }
+
+  tags = {
+    app = "httpd"
+  }
+}
+
+resource "unik_volume" "data" {
+  name          = "httpd-data"
+  provider_name = "virtualbox"
+  size_mb       = 64
+}
+
+resource "unik_instance" "httpd" {
+  name      = "httpd-1"
+  image     = unik_image.httpd.name
+  memory_mb = 256
+
+  mounts = {
+    "/data" = unik_volume.data.id
+  }
+
+  restart_policy = "on-failure"
+  max_restarts   = 5
+}
+
+output "ip_address" {
+  value = unik_instance.httpd.ip_address
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+
+	"github.com/emc-advanced-dev/unik/terraform-provider-unik/unik"
+)
+
+func main() {
+	plugin.Serve(&plugin.ServeOpts{ProviderFunc: unik.Provider})
+}
@@ -0,0 +1,104 @@
+package unik
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/emc-advanced-dev/unik/pkg/client"
+)
+
+// Provider manages unik images, volumes and instances through the rest api of a unik daemon
+func Provider() *schema.Provider {
+	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"host": {
+				Type:        schema.TypeString,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("UNIK_HOST", nil),
+				Description: "host:port of the unik daemon, as in the host of ~/.unik/client-config.yaml",
+			},
+			"user": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("UNIK_USER", ""),
+				Description: "user to make requests on behalf of, for the per-user quotas of the daemon",
+			},
+		},
+		ResourcesMap: map[string]*schema.Resource{
+			"unik_image":    resourceImage(),
+			"unik_volume":   resourceVolume(),
+			"unik_instance": resourceInstance(),
+		},
+		ConfigureContextFunc: configure,
+	}
+}
+
+// meta is what the resources get from the provider config
+type meta struct {
+	host string
+}
+
+func configure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	// the user is global to the client package, so all providers of a
+	// configuration make requests on behalf of the same user
+	client.SetUser(d.Get("user").(string))
+	return &meta{host: d.Get("host").(string)}, nil
+}
+
+func stringMap(v interface{}) map[string]string {
+	m := make(map[string]string)
+	for key, value := range v.(map[string]interface{}) {
+		m[key] = value.(string)
+	}
+	return m
+}
+
+func stringList(v interface{}) []string {
+	var l []string
+	for _, value := range v.([]interface{}) {
+		l = append(l, value.(string))
+	}
+	return l
+}
+
+type tagger interface {
+	tag(id string, tags map[string]string) error
+	untag(id, key string) error
+}
+
+// updateTags changes the tags of an object from the old to the new value of its "tags" attribute
+func updateTags(d *schema.ResourceData, t tagger) error {
+	if !d.HasChange("tags") {
+		return nil
+	}
+	before, after := d.GetChange("tags")
+	oldTags, newTags := stringMap(before), stringMap(after)
+	for key := range oldTags {
+		if _, ok := newTags[key]; !ok {
+			if err := t.untag(d.Id(), key); err != nil {
+				return err
+			}
+		}
+	}
+	changed := make(map[string]string)
+	for key, value := range newTags {
+		if oldTags[key] != value {
+			changed[key] = value
+		}
+	}
+	if len(changed) > 0 {
+		return t.tag(d.Id(), changed)
+	}
+	return nil
+}
+
+func tagsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeMap,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Description: "tags to annotate the object with; changing them does not replace the object",
+	}
+}
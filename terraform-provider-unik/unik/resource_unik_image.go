@@ -0,0 +1,201 @@
+package unik
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// resourceImage is an image built with unik build. the sources are only read when
+// the image is built, so changes to them are not seen; give something that changes
+// with them (e.g. a hash of the files) in triggers to rebuild the image
+func resourceImage() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createImage,
+		ReadContext:   readImage,
+		UpdateContext: updateImage,
+		DeleteContext: deleteImage,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"path": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "path to the root application sources folder",
+			},
+			"base": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"language": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"provider_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "unik provider to build the image for, as for unik build --provider",
+			},
+			"args": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"mount_points": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"arch": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"memory_mb": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				ForceNew: true,
+			},
+			"vcpus": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				ForceNew: true,
+			},
+			"network_mode": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"base_image": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "image to build on, as for unik build --base-image",
+			},
+			"force": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "overwrite an image of the same name that terraform does not manage",
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "any change to these values rebuilds the image",
+			},
+			"tags": tagsSchema(),
+			"infrastructure": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"size_mb": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func createImage(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	host := m.(*meta).host
+	arch, err := types.ParseArchitecture(d.Get("arch").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	networkMode, err := types.ParseNetworkMode(d.Get("network_mode").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	sourceTar, err := ioutil.TempFile("", "sources.tar.gz.")
+	if err != nil {
+		return diag.FromErr(errors.New("failed to create tmp tar file", err))
+	}
+	sourceTar.Close()
+	defer os.Remove(sourceTar.Name())
+	if err := unikos.Compress(d.Get("path").(string), sourceTar.Name()); err != nil {
+		return diag.FromErr(errors.New("failed to tar sources", err))
+	}
+	image, err := client.UnikClient(host).Images().Build(d.Get("name").(string), sourceTar.Name(), client.BuildOptions{
+		Base:        d.Get("base").(string),
+		Lang:        d.Get("language").(string),
+		Provider:    d.Get("provider_name").(string),
+		Args:        d.Get("args").(string),
+		Mounts:      stringList(d.Get("mount_points")),
+		Force:       d.Get("force").(bool),
+		Tags:        stringMap(d.Get("tags")),
+		Arch:        arch,
+		MemoryMb:    d.Get("memory_mb").(int),
+		VCPUs:       d.Get("vcpus").(int),
+		NetworkMode: networkMode,
+		BaseImage:   d.Get("base_image").(string),
+	})
+	if err != nil {
+		return diag.FromErr(errors.New("building image failed", err))
+	}
+	// the api addresses images by name
+	d.SetId(image.Name)
+	return readImage(ctx, d, m)
+}
+
+func readImage(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	images, err := client.UnikClient(m.(*meta).host).Images().All()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, image := range images {
+		if image.Name == d.Id() {
+			d.Set("name", image.Name)
+			d.Set("infrastructure", string(image.Infrastructure))
+			d.Set("size_mb", int(image.SizeMb))
+			d.Set("tags", image.Tags)
+			return nil
+		}
+	}
+	// deleted outside of terraform
+	d.SetId("")
+	return nil
+}
+
+func updateImage(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if err := updateTags(d, imageTagger(m.(*meta).host)); err != nil {
+		return diag.FromErr(err)
+	}
+	return readImage(ctx, d, m)
+}
+
+func deleteImage(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// without force, so images that still have instances are not deleted from under them
+	if err := client.UnikClient(m.(*meta).host).Images().Delete(d.Id(), false); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+type imageTagger string
+
+func (host imageTagger) tag(id string, tags map[string]string) error {
+	_, err := client.UnikClient(string(host)).Images().Tag(id, tags)
+	return err
+}
+
+func (host imageTagger) untag(id, key string) error {
+	_, err := client.UnikClient(string(host)).Images().Untag(id, key)
+	return err
+}
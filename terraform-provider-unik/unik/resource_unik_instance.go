@@ -0,0 +1,195 @@
+package unik
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// resourceInstance is an instance run with unik run. instances cannot be changed
+// once they run, so any change other than to the tags runs a new instance
+func resourceInstance() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createInstance,
+		ReadContext:   readInstance,
+		UpdateContext: updateInstance,
+		DeleteContext: deleteInstance,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"image": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "name of the image (or manifest) to run",
+			},
+			"mounts": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "volume ids to mount, by mount point",
+			},
+			"env": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"memory_mb": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				ForceNew: true,
+			},
+			"vcpus": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				ForceNew: true,
+			},
+			"network_mode": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"ports": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "ports of the daemon host to forward to the instance, as for unik run --port",
+			},
+			"restart_policy": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "always, on-failure or never",
+			},
+			"max_restarts": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				ForceNew: true,
+			},
+			"restart_backoff": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "seconds to wait before restarting the instance",
+			},
+			"tags": tagsSchema(),
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ip_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"infrastructure": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func createInstance(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	networkMode, err := types.ParseNetworkMode(d.Get("network_mode").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var restartPolicy *types.RestartPolicy
+	if policy := d.Get("restart_policy").(string); policy != "" {
+		mode, err := types.ParseRestartMode(policy)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		restartPolicy = &types.RestartPolicy{
+			Mode:           mode,
+			MaxRestarts:    d.Get("max_restarts").(int),
+			BackoffSeconds: d.Get("restart_backoff").(int),
+		}
+	}
+	ports := []types.PortMapping{}
+	for _, portMapping := range stringList(d.Get("ports")) {
+		mapping, err := types.ParsePortMapping(portMapping)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		ports = append(ports, mapping)
+	}
+	if err := types.ValidatePortMappings(ports); err != nil {
+		return diag.FromErr(err)
+	}
+	instance, err := client.UnikClient(m.(*meta).host).Instances().Run(types.RunInstanceRequest{
+		InstanceName:  d.Get("name").(string),
+		ImageName:     d.Get("image").(string),
+		Mounts:        stringMap(d.Get("mounts")),
+		Env:           stringMap(d.Get("env")),
+		MemoryMb:      d.Get("memory_mb").(int),
+		VCPUs:         d.Get("vcpus").(int),
+		NetworkMode:   networkMode,
+		RestartPolicy: restartPolicy,
+		Tags:          stringMap(d.Get("tags")),
+		Ports:         ports,
+	})
+	if err != nil {
+		return diag.FromErr(errors.New("running instance failed", err))
+	}
+	d.SetId(instance.Id)
+	return readInstance(ctx, d, m)
+}
+
+func readInstance(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	instances, err := client.UnikClient(m.(*meta).host).Instances().All()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, instance := range instances {
+		if instance.Id == d.Id() {
+			d.Set("name", instance.Name)
+			d.Set("state", string(instance.State))
+			d.Set("ip_address", instance.IpAddress)
+			d.Set("infrastructure", string(instance.Infrastructure))
+			d.Set("tags", instance.Tags)
+			return nil
+		}
+	}
+	// deleted outside of terraform
+	d.SetId("")
+	return nil
+}
+
+func updateInstance(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if err := updateTags(d, instanceTagger(m.(*meta).host)); err != nil {
+		return diag.FromErr(err)
+	}
+	return readInstance(ctx, d, m)
+}
+
+func deleteInstance(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// with force, so running instances are deleted too
+	if err := client.UnikClient(m.(*meta).host).Instances().Delete(d.Id(), true); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+type instanceTagger string
+
+func (host instanceTagger) tag(id string, tags map[string]string) error {
+	_, err := client.UnikClient(string(host)).Instances().Tag(id, tags)
+	return err
+}
+
+func (host instanceTagger) untag(id, key string) error {
+	_, err := client.UnikClient(string(host)).Instances().Untag(id, key)
+	return err
+}
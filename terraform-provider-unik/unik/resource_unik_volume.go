@@ -0,0 +1,152 @@
+package unik
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	unikos "github.com/emc-advanced-dev/unik/pkg/os"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// resourceVolume is a volume created with unik create-volume, either empty or
+// with the contents of a local folder
+func resourceVolume() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createVolume,
+		ReadContext:   readVolume,
+		UpdateContext: updateVolume,
+		DeleteContext: deleteVolume,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"provider_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "unik provider to create the volume on, as for unik create-volume --provider",
+			},
+			"size_mb": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "size of the volume. required without data_path",
+			},
+			"data_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "folder whose contents the volume is created with",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "filesystem of the volume, as for unik create-volume --type",
+			},
+			"raw": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+			},
+			"tags": tagsSchema(),
+			"infrastructure": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"attachment": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "id of the instance the volume is attached to",
+			},
+		},
+	}
+}
+
+func createVolume(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	dataTar := ""
+	if dataPath := d.Get("data_path").(string); dataPath != "" {
+		tarFile, err := ioutil.TempFile("", "data.tar.gz.")
+		if err != nil {
+			return diag.FromErr(errors.New("failed to create tmp tar file", err))
+		}
+		tarFile.Close()
+		defer os.Remove(tarFile.Name())
+		if err := unikos.Compress(dataPath, tarFile.Name()); err != nil {
+			return diag.FromErr(errors.New("failed to tar data", err))
+		}
+		dataTar = tarFile.Name()
+	} else if d.Get("size_mb").(int) == 0 {
+		return diag.Errorf("size_mb must be set if data_path is not")
+	}
+	volume, err := client.UnikClient(m.(*meta).host).Volumes().Create(d.Get("name").(string), client.CreateVolumeOptions{
+		DataTar:  dataTar,
+		Provider: d.Get("provider_name").(string),
+		Raw:      d.Get("raw").(bool),
+		SizeMb:   d.Get("size_mb").(int),
+		Type:     d.Get("type").(string),
+		Mode:     types.VolumeMode_Block,
+		Tags:     stringMap(d.Get("tags")),
+	})
+	if err != nil {
+		return diag.FromErr(errors.New("creating volume failed", err))
+	}
+	d.SetId(volume.Id)
+	return readVolume(ctx, d, m)
+}
+
+func readVolume(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	volumes, err := client.UnikClient(m.(*meta).host).Volumes().All()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, volume := range volumes {
+		if volume.Id == d.Id() {
+			d.Set("name", volume.Name)
+			d.Set("size_mb", int(volume.SizeMb))
+			d.Set("infrastructure", string(volume.Infrastructure))
+			d.Set("attachment", volume.Attachment)
+			d.Set("tags", volume.Tags)
+			return nil
+		}
+	}
+	// deleted outside of terraform
+	d.SetId("")
+	return nil
+}
+
+func updateVolume(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if err := updateTags(d, volumeTagger(m.(*meta).host)); err != nil {
+		return diag.FromErr(err)
+	}
+	return readVolume(ctx, d, m)
+}
+
+func deleteVolume(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// instances mounting the volume are destroyed before it, so force is not needed
+	if err := client.UnikClient(m.(*meta).host).Volumes().Delete(d.Id(), false); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+type volumeTagger string
+
+func (host volumeTagger) tag(id string, tags map[string]string) error {
+	_, err := client.UnikClient(string(host)).Volumes().Tag(id, tags)
+	return err
+}
+
+func (host volumeTagger) untag(id, key string) error {
+	_, err := client.UnikClient(string(host)).Volumes().Untag(id, key)
+	return err
+}
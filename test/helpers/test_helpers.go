@@ -5,6 +5,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/emc-advanced-dev/pkg/errors"
 	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/compilers"
 	"github.com/emc-advanced-dev/unik/pkg/config"
 	unikos "github.com/emc-advanced-dev/unik/pkg/os"
 	"github.com/emc-advanced-dev/unik/pkg/types"
@@ -262,7 +263,14 @@ func BuildExampleImage(daemonUrl, exampleName, compiler, provider string, mounts
 		return nil, errors.New("tarring example app", err)
 	}
 	defer os.RemoveAll(testSourceTar.Name())
-	return client.UnikClient(daemonUrl).Images().Build(exampleName, testSourceTar.Name(), compiler, provider, "", mounts, force, noCleanup)
+	return client.UnikClient(daemonUrl).Images().Build(exampleName, testSourceTar.Name(), client.BuildOptions{
+		Base:      compilers.CompilerType(compiler).Base(),
+		Lang:      compilers.CompilerType(compiler).Language(),
+		Provider:  provider,
+		Mounts:    mounts,
+		Force:     force,
+		NoCleanup: noCleanup,
+	})
 }
 
 func BuildTestImage(daemonUrl, appDir, compiler, provider string, mounts []string) (*types.Image, error) {
@@ -273,18 +281,32 @@ func BuildTestImage(daemonUrl, appDir, compiler, provider string, mounts []strin
 		return nil, errors.New("tarring test app", err)
 	}
 	defer os.RemoveAll(testSourceTar.Name())
-	return client.UnikClient(daemonUrl).Images().Build(appDir, testSourceTar.Name(), compiler, provider, "", mounts, force, noCleanup)
+	return client.UnikClient(daemonUrl).Images().Build(appDir, testSourceTar.Name(), client.BuildOptions{
+		Base:      compilers.CompilerType(compiler).Base(),
+		Lang:      compilers.CompilerType(compiler).Language(),
+		Provider:  provider,
+		Mounts:    mounts,
+		Force:     force,
+		NoCleanup: noCleanup,
+	})
 }
 
 func RunExampleInstance(daemonUrl, instanceName, imageName string, mountPointsToVols map[string]string) (*types.Instance, error) {
 	noCleanup := false
 	env := map[string]string{"FOO": "BAR"}
 	memoryMb := 128
-	return client.UnikClient(daemonUrl).Instances().Run(instanceName, imageName, mountPointsToVols, env, memoryMb, noCleanup, false)
+	return client.UnikClient(daemonUrl).Instances().Run(types.RunInstanceRequest{
+		InstanceName: instanceName,
+		ImageName:    imageName,
+		Mounts:       mountPointsToVols,
+		Env:          env,
+		MemoryMb:     memoryMb,
+		NoCleanup:    noCleanup,
+	})
 }
 
 func CreateExampleVolume(daemonUrl, volumeName, provider string, size int) (*types.Volume, error) {
-	return client.UnikClient(daemonUrl).Volumes().Create(volumeName, "", provider, size, false)
+	return client.UnikClient(daemonUrl).Volumes().Create(volumeName, client.CreateVolumeOptions{Provider: provider, SizeMb: size})
 }
 
 func CreateTestDataVolume(daemonUrl, volumeName, provider string) (*types.Volume, error) {
@@ -293,7 +315,7 @@ func CreateTestDataVolume(daemonUrl, volumeName, provider string) (*types.Volume
 		return nil, errors.New("tarring test data volume", err)
 	}
 	defer os.RemoveAll(dataTar.Name())
-	return client.UnikClient(daemonUrl).Volumes().Create(volumeName, dataTar.Name(), provider, 0, false)
+	return client.UnikClient(daemonUrl).Volumes().Create(volumeName, client.CreateVolumeOptions{DataTar: dataTar.Name(), Provider: provider})
 }
 
 func GetProjectRoot() string {
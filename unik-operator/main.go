@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/config"
+	"github.com/emc-advanced-dev/unik/unik-operator/operator"
+)
+
+var clientConfigFile, host, namespace string
+var resync time.Duration
+
+// unik-operator keeps the instances and volumes of a unik daemon in line with
+// UnikInstance and UnikVolume resources. see docs/operator.md
+func main() {
+	flag.StringVar(&clientConfigFile, "client-config", os.Getenv("HOME")+"/.unik/client-config.yaml", "client config file of the daemon, as written by unik target")
+	flag.StringVar(&host, "host", "", "host:port of the unik daemon. defaults to the host of the client config")
+	flag.StringVar(&namespace, "namespace", "", "only manage the resources of this namespace. defaults to all namespaces")
+	flag.DurationVar(&resync, "interval", 10*time.Second, "time after which a resource is reconciled again, to correct changes made on unik")
+	// the kubeconfig flag of controller-runtime is on flag.CommandLine too
+	flag.Parse()
+	ctrl.SetLogger(zap.New())
+
+	if err := run(); err != nil {
+		logrus.Errorf("failed running operator: %v", err)
+		os.Exit(-1)
+	}
+}
+
+func run() error {
+	clientConfig, err := readClientConfig()
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		host = clientConfig.Host
+	}
+	if host == "" {
+		return errors.New("no daemon given with --host or in "+clientConfigFile, nil)
+	}
+	client.SetUser(clientConfig.User)
+	client.SetToken(clientConfig.Token)
+	if err := client.SetTLS(clientConfig.TLSCert, clientConfig.TLSKey, clientConfig.TLSCA); err != nil {
+		return err
+	}
+
+	scheme := runtime.NewScheme()
+	if err := operator.AddToScheme(scheme); err != nil {
+		return errors.New("registering the unik resources", err)
+	}
+	options := ctrl.Options{
+		Scheme: scheme,
+		// the operator has no metrics of its own
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	}
+	if namespace != "" {
+		options.Cache = cache.Options{DefaultNamespaces: map[string]cache.Config{namespace: {}}}
+	}
+	// the kubeconfig of --kubeconfig or $KUBECONFIG, the service account of the
+	// pod, or ~/.kube/config
+	kubeConfig, err := ctrl.GetConfig()
+	if err != nil {
+		return errors.New("reading the kubernetes config", err)
+	}
+	mgr, err := ctrl.NewManager(kubeConfig, options)
+	if err != nil {
+		return errors.New("creating controller manager", err)
+	}
+	unik := operator.NewUnik(host)
+	if err := (&operator.VolumeReconciler{Client: mgr.GetClient(), Unik: unik, Resync: resync}).SetupWithManager(mgr); err != nil {
+		return errors.New("setting up the volume controller", err)
+	}
+	if err := (&operator.InstanceReconciler{Client: mgr.GetClient(), Unik: unik, Resync: resync}).SetupWithManager(mgr); err != nil {
+		return errors.New("setting up the instance controller", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"host":        host,
+		"kube-server": kubeConfig.Host,
+		"namespace":   namespace,
+		"interval":    resync,
+	}).Infof("running unik operator")
+	// stops on SIGINT and SIGTERM
+	return mgr.Start(ctrl.SetupSignalHandler())
+}
+
+// readClientConfig reads the client config, which need not exist if --host is given
+func readClientConfig() (config.ClientConfig, error) {
+	var clientConfig config.ClientConfig
+	data, err := ioutil.ReadFile(clientConfigFile)
+	if os.IsNotExist(err) && host != "" {
+		return clientConfig, nil
+	}
+	if err != nil {
+		return clientConfig, errors.New("reading client config "+clientConfigFile, err)
+	}
+	if err := yaml.Unmarshal(bytes.TrimSpace(data), &clientConfig); err != nil {
+		return clientConfig, errors.New("parsing client config "+clientConfigFile, err)
+	}
+	return clientConfig, nil
+}
@@ -0,0 +1,266 @@
+package operator
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/emc-advanced-dev/pkg/errors"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// the reconcilers make the instances and volumes on unik match the UnikInstance
+// and UnikVolume resources in kubernetes. they watch the resources, and look at
+// each again every Resync, as unik has nothing to watch: anything changed behind
+// their back (e.g. an instance deleted with unik rm) is corrected by then.
+//
+// instances cannot be changed once they run, so an instance whose spec changes
+// is deleted and run again. volumes cannot be changed at all; a changed spec is
+// reported in the status of the volume.
+
+// InstanceReconciler runs an instance on unik for each UnikInstance
+// +kubebuilder:object:generate=false
+type InstanceReconciler struct {
+	client.Client
+	Unik   Unik
+	Resync time.Duration
+}
+
+// SetupWithManager reconciles UnikInstances when they change, and when a
+// UnikVolume they mount does, so instances waiting for a volume are run once it
+// has been created
+func (r *InstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&UnikInstance{}).
+		Watches(&UnikVolume{}, handler.EnqueueRequestsFromMapFunc(r.instancesMounting)).
+		Complete(r)
+}
+
+func (r *InstanceReconciler) instancesMounting(ctx context.Context, volume client.Object) []reconcile.Request {
+	var instances UnikInstanceList
+	if err := r.List(ctx, &instances, client.InNamespace(volume.GetNamespace())); err != nil {
+		logrus.WithError(err).Warnf("listing the instance resources that mount %s/%s", volume.GetNamespace(), volume.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, instance := range instances.Items {
+		for _, mount := range instance.Spec.Volumes {
+			if mount.Volume == volume.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&instance)})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var resource UnikInstance
+	if err := r.Get(ctx, req.NamespacedName, &resource); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	name := unikName(&resource)
+	existing, err := r.instance(name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !resource.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(&resource, Finalizer) {
+			return ctrl.Result{}, nil
+		}
+		if existing != nil {
+			if err := r.Unik.DeleteInstance(existing.Id); err != nil {
+				return ctrl.Result{}, errors.New("deleting instance "+name, err)
+			}
+		}
+		controllerutil.RemoveFinalizer(&resource, Finalizer)
+		return ctrl.Result{}, r.Update(ctx, &resource)
+	}
+	if controllerutil.AddFinalizer(&resource, Finalizer) {
+		if err := r.Update(ctx, &resource); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := resource.Status
+	if existing != nil && status.InstanceId != "" && status.ObservedGeneration != resource.Generation {
+		logrus.WithFields(logrus.Fields{"resource": req.String(), "instance": existing.Id}).Infof("spec changed, replacing instance")
+		if err := r.Unik.DeleteInstance(existing.Id); err != nil {
+			return ctrl.Result{}, errors.New("deleting instance "+name+" to run it with its new spec", err)
+		}
+		existing = nil
+	}
+
+	if existing == nil {
+		instance, err := r.runInstance(&resource)
+		if err != nil {
+			status = UnikInstanceStatus{State: "error", Message: err.Error(), ObservedGeneration: status.ObservedGeneration}
+		} else {
+			logrus.WithFields(logrus.Fields{"resource": req.String(), "instance": instance.Id}).Infof("ran instance")
+			existing = instance
+		}
+	}
+	if existing != nil {
+		status = UnikInstanceStatus{
+			InstanceId:         existing.Id,
+			State:              string(existing.State),
+			IpAddress:          existing.IpAddress,
+			ObservedGeneration: resource.Generation,
+		}
+	}
+	if status != resource.Status {
+		resource.Status = status
+		if err := r.Status().Update(ctx, &resource); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: r.Resync}, nil
+}
+
+// instance returns the unik instance named name, nil if there is none
+func (r *InstanceReconciler) instance(name string) (*types.Instance, error) {
+	instances, err := r.Unik.ListInstances()
+	if err != nil {
+		return nil, errors.New("listing unik instances", err)
+	}
+	for _, instance := range instances {
+		if instance.Name == name {
+			return instance, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *InstanceReconciler) runInstance(resource *UnikInstance) (*types.Instance, error) {
+	spec := resource.Spec
+	if spec.Image == "" {
+		return nil, errors.New("spec.image must be set", nil)
+	}
+	if spec.Provider != "" {
+		image, err := r.Unik.GetImage(spec.Image)
+		if err != nil {
+			return nil, errors.New("retrieving image "+spec.Image, err)
+		}
+		// infrastructure names are the upper case provider names
+		if !strings.EqualFold(string(image.Infrastructure), spec.Provider) {
+			return nil, errors.New("image "+spec.Image+" is on "+strings.ToLower(string(image.Infrastructure))+", not on provider "+spec.Provider, nil)
+		}
+	}
+	mounts := make(map[string]string)
+	if len(spec.Volumes) > 0 {
+		volumes, err := r.Unik.ListVolumes()
+		if err != nil {
+			return nil, errors.New("listing unik volumes", err)
+		}
+		volumesByName := make(map[string]*types.Volume)
+		for _, volume := range volumes {
+			volumesByName[volume.Name] = volume
+		}
+		for _, mount := range spec.Volumes {
+			volume, ok := volumesByName[resource.Namespace+"-"+mount.Volume]
+			if !ok {
+				return nil, errors.New("waiting for volume "+mount.Volume+" to be created", nil)
+			}
+			mounts[mount.MountPoint] = volume.Id
+		}
+	}
+	return r.Unik.RunInstance(unikName(resource), spec.Image, mounts, spec.Env, spec.MemoryMb, spec.VCPUs)
+}
+
+// VolumeReconciler creates a volume on unik for each UnikVolume
+// +kubebuilder:object:generate=false
+type VolumeReconciler struct {
+	client.Client
+	Unik   Unik
+	Resync time.Duration
+}
+
+func (r *VolumeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&UnikVolume{}).
+		Complete(r)
+}
+
+func (r *VolumeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var resource UnikVolume
+	if err := r.Get(ctx, req.NamespacedName, &resource); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	name := unikName(&resource)
+	existing, err := r.volume(name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !resource.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(&resource, Finalizer) {
+			return ctrl.Result{}, nil
+		}
+		if existing != nil {
+			if err := r.Unik.DeleteVolume(existing.Id); err != nil {
+				return ctrl.Result{}, errors.New("deleting volume "+name, err)
+			}
+		}
+		controllerutil.RemoveFinalizer(&resource, Finalizer)
+		return ctrl.Result{}, r.Update(ctx, &resource)
+	}
+	if controllerutil.AddFinalizer(&resource, Finalizer) {
+		if err := r.Update(ctx, &resource); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := resource.Status
+	if existing == nil {
+		volume, err := r.Unik.CreateVolume(name, resource.Spec.Provider, resource.Spec.SizeMb, resource.Spec.Type)
+		if err != nil {
+			status.State = "error"
+			status.Message = err.Error()
+		} else {
+			logrus.WithFields(logrus.Fields{"resource": req.String(), "volume": volume.Id}).Infof("created volume")
+			existing = volume
+			status.Message = ""
+			status.ObservedGeneration = resource.Generation
+		}
+	} else if status.ObservedGeneration != 0 && status.ObservedGeneration != resource.Generation {
+		status.Message = "the spec of a volume cannot be changed once it is created; delete the resource and create it again"
+	}
+	if existing != nil {
+		status.VolumeId = existing.Id
+		status.State = "available"
+		if existing.Attachment != "" {
+			status.State = "attached"
+		}
+		if status.ObservedGeneration == 0 {
+			status.ObservedGeneration = resource.Generation
+		}
+	}
+	if status != resource.Status {
+		resource.Status = status
+		if err := r.Status().Update(ctx, &resource); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: r.Resync}, nil
+}
+
+// volume returns the unik volume named name, nil if there is none
+func (r *VolumeReconciler) volume(name string) (*types.Volume, error) {
+	volumes, err := r.Unik.ListVolumes()
+	if err != nil {
+		return nil, errors.New("listing unik volumes", err)
+	}
+	for _, volume := range volumes {
+		if volume.Name == name {
+			return volume, nil
+		}
+	}
+	return nil, nil
+}
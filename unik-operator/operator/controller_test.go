@@ -0,0 +1,236 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/emc-advanced-dev/unik/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeUnik keeps instances and volumes in memory
+type fakeUnik struct {
+	instances map[string]*types.Instance
+	volumes   map[string]*types.Volume
+	images    map[string]*types.Image
+	runs      int
+	lastRun   struct {
+		mounts   map[string]string
+		memoryMb int
+	}
+}
+
+func newFakeUnik() *fakeUnik {
+	return &fakeUnik{
+		instances: make(map[string]*types.Instance),
+		volumes:   make(map[string]*types.Volume),
+		images:    map[string]*types.Image{"myImage": {Name: "myImage", Infrastructure: types.Infrastructure_QEMU}},
+	}
+}
+
+func (f *fakeUnik) ListInstances() ([]*types.Instance, error) {
+	instances := []*types.Instance{}
+	for _, instance := range f.instances {
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func (f *fakeUnik) RunInstance(name, image string, mounts, env map[string]string, memoryMb, vcpus int) (*types.Instance, error) {
+	if _, ok := f.images[image]; !ok {
+		return nil, fmt.Errorf("image %s not found", image)
+	}
+	f.runs++
+	f.lastRun.mounts, f.lastRun.memoryMb = mounts, memoryMb
+	instance := &types.Instance{Id: fmt.Sprintf("id-%d", f.runs), Name: name, State: types.InstanceState_Running, IpAddress: "10.0.0.2"}
+	f.instances[instance.Id] = instance
+	return instance, nil
+}
+
+func (f *fakeUnik) DeleteInstance(id string) error {
+	delete(f.instances, id)
+	return nil
+}
+
+func (f *fakeUnik) GetImage(name string) (*types.Image, error) {
+	if image, ok := f.images[name]; ok {
+		return image, nil
+	}
+	return nil, fmt.Errorf("image %s not found", name)
+}
+
+func (f *fakeUnik) ListVolumes() ([]*types.Volume, error) {
+	volumes := []*types.Volume{}
+	for _, volume := range f.volumes {
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
+
+func (f *fakeUnik) CreateVolume(name, provider string, sizeMb int, volType string) (*types.Volume, error) {
+	volume := &types.Volume{Id: "vol-" + name, Name: name, SizeMb: int64(sizeMb)}
+	f.volumes[volume.Id] = volume
+	return volume, nil
+}
+
+func (f *fakeUnik) DeleteVolume(id string) error {
+	delete(f.volumes, id)
+	return nil
+}
+
+var _ = Describe("Controller", func() {
+	var (
+		ctx       = context.Background()
+		kube      client.Client
+		unik      *fakeUnik
+		instances *InstanceReconciler
+		volumes   *VolumeReconciler
+	)
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(AddToScheme(scheme)).To(Succeed())
+		kube = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&UnikInstance{}, &UnikVolume{}).Build()
+		unik = newFakeUnik()
+		instances = &InstanceReconciler{Client: kube, Unik: unik, Resync: time.Minute}
+		volumes = &VolumeReconciler{Client: kube, Unik: unik, Resync: time.Minute}
+	})
+
+	newInstance := func(name string, spec UnikInstanceSpec) *UnikInstance {
+		resource := &UnikInstance{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Generation: 1}, Spec: spec}
+		Expect(kube.Create(ctx, resource)).To(Succeed())
+		return resource
+	}
+	newVolume := func(name string, spec UnikVolumeSpec) *UnikVolume {
+		resource := &UnikVolume{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Generation: 1}, Spec: spec}
+		Expect(kube.Create(ctx, resource)).To(Succeed())
+		return resource
+	}
+	// reconcile reconciles resource, and reads it back from the cluster
+	reconcile := func(resource client.Object) {
+		request := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(resource)}
+		var err error
+		switch resource.(type) {
+		case *UnikInstance:
+			_, err = instances.Reconcile(ctx, request)
+		case *UnikVolume:
+			_, err = volumes.Reconcile(ctx, request)
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.IgnoreNotFound(kube.Get(ctx, request.NamespacedName, resource))).To(Succeed())
+	}
+	// changeSpec updates resource as the api server does for a change of its spec
+	changeSpec := func(resource client.Object, change func()) {
+		change()
+		resource.SetGeneration(resource.GetGeneration() + 1)
+		Expect(kube.Update(ctx, resource)).To(Succeed())
+	}
+	isGone := func(resource client.Object) bool {
+		return kube.Get(ctx, client.ObjectKeyFromObject(resource), resource) != nil
+	}
+
+	It("should run an instance for a new resource and report it in the status", func() {
+		resource := newInstance("web", UnikInstanceSpec{Image: "myImage", MemoryMb: 256})
+
+		reconcile(resource)
+		Expect(unik.instances).To(HaveLen(1))
+		Expect(unik.instances["id-1"].Name).To(Equal("ns-web"))
+		Expect(unik.lastRun.memoryMb).To(Equal(256))
+		Expect(resource.Finalizers).To(ConsistOf(Finalizer))
+		Expect(resource.Status).To(Equal(UnikInstanceStatus{InstanceId: "id-1", State: "running", IpAddress: "10.0.0.2", ObservedGeneration: 1}))
+
+		reconcile(resource)
+		Expect(unik.runs).To(Equal(1))
+	})
+
+	It("should replace the instance when the spec changes", func() {
+		resource := newInstance("web", UnikInstanceSpec{Image: "myImage"})
+		reconcile(resource)
+
+		changeSpec(resource, func() { resource.Spec.MemoryMb = 512 })
+		reconcile(resource)
+		Expect(unik.instances).To(HaveLen(1))
+		Expect(unik.instances).To(HaveKey("id-2"))
+		Expect(unik.lastRun.memoryMb).To(Equal(512))
+		Expect(resource.Status.InstanceId).To(Equal("id-2"))
+		Expect(resource.Status.ObservedGeneration).To(BeEquivalentTo(2))
+	})
+
+	It("should run the instance again if it was deleted on unik", func() {
+		resource := newInstance("web", UnikInstanceSpec{Image: "myImage"})
+		reconcile(resource)
+
+		unik.DeleteInstance("id-1")
+		reconcile(resource)
+		Expect(resource.Status.InstanceId).To(Equal("id-2"))
+	})
+
+	It("should delete the instance and the finalizer when the resource is deleted", func() {
+		resource := newInstance("web", UnikInstanceSpec{Image: "myImage"})
+		reconcile(resource)
+
+		Expect(kube.Delete(ctx, resource)).To(Succeed())
+		Expect(isGone(resource)).To(BeFalse())
+		reconcile(resource)
+		Expect(unik.instances).To(BeEmpty())
+		Expect(isGone(resource)).To(BeTrue())
+	})
+
+	It("should report failed runs in the status", func() {
+		resource := newInstance("web", UnikInstanceSpec{Image: "missing"})
+
+		reconcile(resource)
+		Expect(resource.Status.State).To(Equal("error"))
+		Expect(resource.Status.Message).To(ContainSubstring("image missing not found"))
+	})
+
+	It("should only run images that are on the provider of the spec", func() {
+		resource := newInstance("web", UnikInstanceSpec{Image: "myImage", Provider: "aws"})
+
+		reconcile(resource)
+		Expect(unik.runs).To(Equal(0))
+		Expect(resource.Status.Message).To(ContainSubstring("not on provider aws"))
+	})
+
+	It("should create volumes and mount them once they exist", func() {
+		volume := newVolume("data", UnikVolumeSpec{Provider: "qemu", SizeMb: 10})
+		resource := newInstance("db", UnikInstanceSpec{Image: "myImage", Volumes: []VolumeMount{{MountPoint: "/data", Volume: "data"}}})
+
+		reconcile(volume)
+		Expect(volume.Status).To(Equal(UnikVolumeStatus{VolumeId: "vol-ns-data", State: "available", ObservedGeneration: 1}))
+		reconcile(resource)
+		Expect(unik.lastRun.mounts).To(Equal(map[string]string{"/data": "vol-ns-data"}))
+	})
+
+	It("should wait for volumes that do not exist yet, and run the instance when they change", func() {
+		resource := newInstance("db", UnikInstanceSpec{Image: "myImage", Volumes: []VolumeMount{{MountPoint: "/data", Volume: "data"}}})
+		newInstance("web", UnikInstanceSpec{Image: "myImage"})
+
+		reconcile(resource)
+		Expect(unik.runs).To(Equal(0))
+		Expect(resource.Status.Message).To(ContainSubstring("waiting for volume data"))
+
+		volume := newVolume("data", UnikVolumeSpec{Provider: "qemu", SizeMb: 10})
+		Expect(instances.instancesMounting(ctx, volume)).To(ConsistOf(ctrl.Request{NamespacedName: client.ObjectKeyFromObject(resource)}))
+	})
+
+	It("should delete volumes of deleted resources, and refuse to change them", func() {
+		volume := newVolume("data", UnikVolumeSpec{Provider: "qemu", SizeMb: 10})
+		reconcile(volume)
+
+		changeSpec(volume, func() { volume.Spec.SizeMb = 20 })
+		reconcile(volume)
+		Expect(volume.Status.Message).To(ContainSubstring("cannot be changed"))
+
+		Expect(kube.Delete(ctx, volume)).To(Succeed())
+		reconcile(volume)
+		Expect(unik.volumes).To(BeEmpty())
+		Expect(isGone(volume)).To(BeTrue())
+	})
+})
@@ -0,0 +1,115 @@
+// Package operator reconciles the UnikInstance and UnikVolume resources of a
+// kubernetes cluster against a unik daemon, with controller-runtime.
+// +kubebuilder:object:generate=true
+// +groupName=unik.io
+package operator
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+const (
+	// Group and Version of the unik custom resources
+	Group   = "unik.io"
+	Version = "v1alpha1"
+
+	// Finalizer keeps a unik resource in kubernetes until the operator has
+	// deleted the instance or volume it stands for
+	Finalizer = "unik.io/cleanup"
+)
+
+var (
+	GroupVersion = schema.GroupVersion{Group: Group, Version: Version}
+
+	// AddToScheme adds the unik resources to a scheme
+	AddToScheme = (&scheme.Builder{GroupVersion: GroupVersion}).
+			Register(&UnikInstance{}, &UnikInstanceList{}, &UnikVolume{}, &UnikVolumeList{}).
+			AddToScheme
+)
+
+// UnikInstance is an instance that should be running on unik, with the options of unik run
+// +kubebuilder:object:root=true
+type UnikInstance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UnikInstanceSpec   `json:"spec"`
+	Status UnikInstanceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type UnikInstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []UnikInstance `json:"items"`
+}
+
+type UnikInstanceSpec struct {
+	// Image is the name of the unik image (or manifest) to run
+	Image string `json:"image"`
+	// Provider is optional; if set, the image must be on this provider
+	Provider string `json:"provider,omitempty"`
+	MemoryMb int    `json:"memoryMb,omitempty"`
+	VCPUs    int    `json:"vcpus,omitempty"`
+	// Volumes are UnikVolumes of the same namespace to mount
+	Volumes []VolumeMount     `json:"volumes,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+type VolumeMount struct {
+	MountPoint string `json:"mountPoint"`
+	// Volume is the name of a UnikVolume
+	Volume string `json:"volume"`
+}
+
+type UnikInstanceStatus struct {
+	InstanceId string `json:"instanceId,omitempty"`
+	State      string `json:"state,omitempty"`
+	IpAddress  string `json:"ipAddress,omitempty"`
+	// Message says why the instance is not running, e.g. a failed run
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the spec the instance was run with
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// UnikVolume is a volume that should exist on unik
+// +kubebuilder:object:root=true
+type UnikVolume struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UnikVolumeSpec   `json:"spec"`
+	Status UnikVolumeStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type UnikVolumeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []UnikVolume `json:"items"`
+}
+
+type UnikVolumeSpec struct {
+	Provider string `json:"provider"`
+	SizeMb   int    `json:"sizeMb"`
+	// Type is the filesystem of the volume, as for unik create-volume --type. optional
+	Type string `json:"type,omitempty"`
+}
+
+type UnikVolumeStatus struct {
+	VolumeId string `json:"volumeId,omitempty"`
+	// State is available, attached or error
+	State              string `json:"state,omitempty"`
+	Message            string `json:"message,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+}
+
+// unikName is the name of the unik instance or volume for a resource. names are
+// prefixed with the namespace, as unik names are global
+func unikName(meta metav1.Object) string {
+	return meta.GetNamespace() + "-" + meta.GetName()
+}
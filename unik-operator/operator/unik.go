@@ -0,0 +1,68 @@
+package operator
+
+import (
+	"github.com/emc-advanced-dev/unik/pkg/client"
+	"github.com/emc-advanced-dev/unik/pkg/types"
+)
+
+// Unik is the part of the unik daemon api the operator reconciles against
+// +kubebuilder:object:generate=false
+type Unik interface {
+	ListInstances() ([]*types.Instance, error)
+	RunInstance(name, image string, mounts, env map[string]string, memoryMb, vcpus int) (*types.Instance, error)
+	DeleteInstance(id string) error
+	GetImage(name string) (*types.Image, error)
+	ListVolumes() ([]*types.Volume, error)
+	CreateVolume(name, provider string, sizeMb int, volType string) (*types.Volume, error)
+	DeleteVolume(id string) error
+}
+
+// +kubebuilder:object:generate=false
+type unikClient struct {
+	host string
+}
+
+// NewUnik is a Unik on the rest api of the daemon at host
+func NewUnik(host string) Unik {
+	return &unikClient{host: host}
+}
+
+func (u *unikClient) ListInstances() ([]*types.Instance, error) {
+	return client.UnikClient(u.host).Instances().All()
+}
+
+func (u *unikClient) RunInstance(name, image string, mounts, env map[string]string, memoryMb, vcpus int) (*types.Instance, error) {
+	return client.UnikClient(u.host).Instances().Run(types.RunInstanceRequest{
+		InstanceName: name,
+		ImageName:    image,
+		Mounts:       mounts,
+		Env:          env,
+		MemoryMb:     memoryMb,
+		VCPUs:        vcpus,
+	})
+}
+
+func (u *unikClient) DeleteInstance(id string) error {
+	return client.UnikClient(u.host).Instances().Delete(id, true)
+}
+
+func (u *unikClient) GetImage(name string) (*types.Image, error) {
+	return client.UnikClient(u.host).Images().Get(name)
+}
+
+func (u *unikClient) ListVolumes() ([]*types.Volume, error) {
+	return client.UnikClient(u.host).Volumes().All()
+}
+
+func (u *unikClient) CreateVolume(name, provider string, sizeMb int, volType string) (*types.Volume, error) {
+	return client.UnikClient(u.host).Volumes().Create(name, client.CreateVolumeOptions{
+		Provider: provider,
+		SizeMb:   sizeMb,
+		Type:     volType,
+		Mode:     types.VolumeMode_Block,
+	})
+}
+
+func (u *unikClient) DeleteVolume(id string) error {
+	return client.UnikClient(u.host).Volumes().Delete(id, false)
+}